@@ -18,30 +18,60 @@ import (
 func InitAdmin() {
 	l4g.Debug(utils.T("api.admin.init.debug"))
 
-	BaseRoutes.Admin.Handle("/logs", ApiAdminSystemRequired(getLogs)).Methods("GET")
-	BaseRoutes.Admin.Handle("/audits", ApiAdminSystemRequired(getAllAudits)).Methods("GET")
-	BaseRoutes.Admin.Handle("/config", ApiAdminSystemRequired(getConfig)).Methods("GET")
+	BaseRoutes.Admin.Handle("/logs", ApiAdminPermissionRequired(getLogs, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/logs/query", ApiAdminPermissionRequired(queryLogs, model.PERMISSION_READ_SYSTEM)).Methods("POST")
+	BaseRoutes.Admin.Handle("/audits", ApiAdminPermissionRequired(getAllAudits, model.PERMISSION_READ_SYSTEM, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/config", ApiAdminPermissionRequired(getConfig, model.PERMISSION_READ_SYSTEM)).Methods("GET")
 	BaseRoutes.Admin.Handle("/save_config", ApiAdminSystemRequired(saveConfig)).Methods("POST")
 	BaseRoutes.Admin.Handle("/reload_config", ApiAdminSystemRequired(reloadConfig)).Methods("GET")
 	BaseRoutes.Admin.Handle("/invalidate_all_caches", ApiAdminSystemRequired(invalidateAllCaches)).Methods("GET")
 	BaseRoutes.Admin.Handle("/test_email", ApiAdminSystemRequired(testEmail)).Methods("POST")
+	BaseRoutes.Admin.Handle("/email_templates", ApiAdminPermissionRequired(getEmailTemplates, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/email_templates/preview", ApiAdminPermissionRequired(previewEmailTemplate, model.PERMISSION_READ_SYSTEM)).Methods("POST")
+	BaseRoutes.Admin.Handle("/email_templates/test", ApiAdminSystemRequired(testEmailTemplate)).Methods("POST")
 	BaseRoutes.Admin.Handle("/recycle_db_conn", ApiAdminSystemRequired(recycleDatabaseConnection)).Methods("GET")
-	BaseRoutes.Admin.Handle("/analytics/{id:[A-Za-z0-9]+}/{name:[A-Za-z0-9_]+}", ApiAdminSystemRequired(getAnalytics)).Methods("GET")
-	BaseRoutes.Admin.Handle("/analytics/{name:[A-Za-z0-9_]+}", ApiAdminSystemRequired(getAnalytics)).Methods("GET")
-	BaseRoutes.Admin.Handle("/save_compliance_report", ApiAdminSystemRequired(saveComplianceReport)).Methods("POST")
-	BaseRoutes.Admin.Handle("/compliance_reports", ApiAdminSystemRequired(getComplianceReports)).Methods("GET")
-	BaseRoutes.Admin.Handle("/download_compliance_report/{id:[A-Za-z0-9]+}", ApiAdminSystemRequiredTrustRequester(downloadComplianceReport)).Methods("GET")
+	BaseRoutes.Admin.Handle("/database_health", ApiAdminPermissionRequired(getDatabaseHealth, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/analytics/{id:[A-Za-z0-9]+}/{name:[A-Za-z0-9_]+}", ApiAdminPermissionRequired(getAnalytics, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/analytics/{name:[A-Za-z0-9_]+}", ApiAdminPermissionRequired(getAnalytics, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/aggregate_analytics", ApiAdminSystemRequired(aggregateAnalytics)).Methods("POST")
+	BaseRoutes.Admin.Handle("/save_compliance_report", ApiAdminPermissionRequired(saveComplianceReport, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/compliance_reports", ApiAdminPermissionRequired(getComplianceReports, model.PERMISSION_READ_SYSTEM, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/download_compliance_report/{id:[A-Za-z0-9]+}", ApiAdminPermissionRequiredTrustRequester(downloadComplianceReport, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
 	BaseRoutes.Admin.Handle("/upload_brand_image", ApiAdminSystemRequired(uploadBrandImage)).Methods("POST")
 	BaseRoutes.Admin.Handle("/get_brand_image", ApiAppHandlerTrustRequester(getBrandImage)).Methods("GET")
-	BaseRoutes.Admin.Handle("/reset_mfa", ApiAdminSystemRequired(adminResetMfa)).Methods("POST")
-	BaseRoutes.Admin.Handle("/reset_password", ApiAdminSystemRequired(adminResetPassword)).Methods("POST")
+	BaseRoutes.Admin.Handle("/reset_mfa", ApiAdminPermissionRequired(adminResetMfa, model.PERMISSION_MANAGE_USERS)).Methods("POST")
+	BaseRoutes.Admin.Handle("/reset_password", ApiAdminPermissionRequired(adminResetPassword, model.PERMISSION_MANAGE_USERS)).Methods("POST")
+	BaseRoutes.Admin.Handle("/unlock_user", ApiAdminPermissionRequired(adminUnlockUser, model.PERMISSION_MANAGE_USERS)).Methods("POST")
+	BaseRoutes.Admin.Handle("/unlock_ip", ApiAdminPermissionRequired(adminUnlockIpAddress, model.PERMISSION_MANAGE_USERS)).Methods("POST")
 	BaseRoutes.Admin.Handle("/ldap_sync_now", ApiAdminSystemRequired(ldapSyncNow)).Methods("POST")
 	BaseRoutes.Admin.Handle("/ldap_test", ApiAdminSystemRequired(ldapTest)).Methods("POST")
 	BaseRoutes.Admin.Handle("/saml_metadata", ApiAppHandler(samlMetadata)).Methods("GET")
 	BaseRoutes.Admin.Handle("/add_certificate", ApiAdminSystemRequired(addCertificate)).Methods("POST")
 	BaseRoutes.Admin.Handle("/remove_certificate", ApiAdminSystemRequired(removeCertificate)).Methods("POST")
 	BaseRoutes.Admin.Handle("/saml_cert_status", ApiAdminSystemRequired(samlCertificateStatus)).Methods("GET")
-	BaseRoutes.Admin.Handle("/cluster_status", ApiAdminSystemRequired(getClusterStatus)).Methods("GET")
+	BaseRoutes.Admin.Handle("/cluster_status", ApiAdminPermissionRequired(getClusterStatus, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/cluster_nodes", ApiAdminPermissionRequired(getClusterNodes, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/retention_policies", ApiAdminPermissionRequired(getRetentionPolicies, model.PERMISSION_READ_SYSTEM, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/retention_policies/create", ApiAdminPermissionRequired(createRetentionPolicy, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/retention_policies/preview", ApiAdminPermissionRequired(previewRetentionPolicy, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/retention_policies/{policy_id:[A-Za-z0-9]+}/update", ApiAdminPermissionRequired(updateRetentionPolicy, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/retention_policies/{policy_id:[A-Za-z0-9]+}/delete", ApiAdminPermissionRequired(deleteRetentionPolicy, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/legal_holds", ApiAdminPermissionRequired(getLegalHolds, model.PERMISSION_READ_SYSTEM, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/legal_holds/create", ApiAdminPermissionRequired(createLegalHold, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/legal_holds/{hold_id:[A-Za-z0-9]+}/update", ApiAdminPermissionRequired(updateLegalHold, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/legal_holds/{hold_id:[A-Za-z0-9]+}/delete", ApiAdminPermissionRequired(deleteLegalHold, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/legal_holds/{hold_id:[A-Za-z0-9]+}/export/{offset:[0-9]+}/{limit:[0-9]+}", ApiAdminPermissionRequired(exportLegalHold, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/file_access_log/export", ApiAdminPermissionRequired(exportFileAccessLog, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/files/deleted/{offset:[0-9]+}/{limit:[0-9]+}", ApiAdminPermissionRequired(getDeletedFiles, model.PERMISSION_READ_SYSTEM, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("GET")
+	BaseRoutes.Admin.Handle("/files/restore", ApiAdminPermissionRequired(restoreFiles, model.PERMISSION_MANAGE_COMPLIANCE)).Methods("POST")
+	BaseRoutes.Admin.Handle("/pseudonyms/{offset:[0-9]+}/{limit:[0-9]+}", ApiAdminPermissionRequired(getPseudonyms, model.PERMISSION_READ_PSEUDONYMS)).Methods("GET")
+	BaseRoutes.Admin.Handle("/search/reindex", ApiAdminSystemRequired(reindexSearch)).Methods("POST")
+	BaseRoutes.Admin.Handle("/channel_counts/repair", ApiAdminSystemRequired(repairChannelCounts)).Methods("POST")
+	BaseRoutes.Admin.Handle("/jobs/type/{job_type:[a-z_]+}/{offset:[0-9]+}/{limit:[0-9]+}", ApiAdminPermissionRequired(getJobsByType, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/jobs/schedules", ApiAdminPermissionRequired(getJobSchedules, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/jobs/schedules/update", ApiAdminSystemRequired(updateJobSchedule)).Methods("POST")
+	BaseRoutes.Admin.Handle("/jobs/{job_id:[A-Za-z0-9]+}", ApiAdminPermissionRequired(getJob, model.PERMISSION_READ_SYSTEM)).Methods("GET")
+	BaseRoutes.Admin.Handle("/jobs/{job_id:[A-Za-z0-9]+}/cancel", ApiAdminSystemRequired(cancelJob)).Methods("POST")
 	BaseRoutes.Admin.Handle("/recently_active_users/{team_id:[A-Za-z0-9]+}", ApiUserRequired(getRecentlyActiveUsers)).Methods("GET")
 }
 
@@ -55,11 +85,427 @@ func getLogs(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.ArrayToJson(lines)))
 }
 
+func queryLogs(c *Context, w http.ResponseWriter, r *http.Request) {
+	query := model.LogQueryFromJson(r.Body)
+	if query == nil {
+		c.SetInvalidParam("queryLogs", "query")
+		return
+	}
+
+	if err := query.IsValid(); err != nil {
+		c.Err = err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if page, err := app.GetLogPage(query); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(page.ToJson()))
+	}
+}
+
 func getClusterStatus(c *Context, w http.ResponseWriter, r *http.Request) {
 	infos := app.GetClusterStatus()
 	w.Write([]byte(model.ClusterInfosToJson(infos)))
 }
 
+func getClusterNodes(c *Context, w http.ResponseWriter, r *http.Request) {
+	nodes := app.GetClusterDiscoveryNodes()
+	w.Write([]byte(model.ClusterDiscoveriesToJson(nodes)))
+}
+
+func getRetentionPolicies(c *Context, w http.ResponseWriter, r *http.Request) {
+	if policies, err := app.GetRetentionPolicies(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.RetentionPolicyListToJson(policies)))
+	}
+}
+
+func createRetentionPolicy(c *Context, w http.ResponseWriter, r *http.Request) {
+	policy := model.RetentionPolicyFromJson(r.Body)
+	if policy == nil {
+		c.SetInvalidParam("createRetentionPolicy", "policy")
+		return
+	}
+
+	if policy, err := app.CreateRetentionPolicy(policy); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("policy_id=" + policy.Id)
+		w.Write([]byte(policy.ToJson()))
+	}
+}
+
+func updateRetentionPolicy(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	policyId := params["policy_id"]
+	if len(policyId) != 26 {
+		c.SetInvalidParam("updateRetentionPolicy", "policy_id")
+		return
+	}
+
+	policy := model.RetentionPolicyFromJson(r.Body)
+	if policy == nil || policy.Id != policyId {
+		c.SetInvalidParam("updateRetentionPolicy", "policy")
+		return
+	}
+
+	if policy, err := app.UpdateRetentionPolicy(policy); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("policy_id=" + policy.Id)
+		w.Write([]byte(policy.ToJson()))
+	}
+}
+
+func deleteRetentionPolicy(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	policyId := params["policy_id"]
+	if len(policyId) != 26 {
+		c.SetInvalidParam("deleteRetentionPolicy", "policy_id")
+		return
+	}
+
+	if err := app.DeleteRetentionPolicy(policyId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("policy_id=" + policyId)
+	ReturnStatusOK(w)
+}
+
+func previewRetentionPolicy(c *Context, w http.ResponseWriter, r *http.Request) {
+	policy := model.RetentionPolicyFromJson(r.Body)
+	if policy == nil || (len(policy.TeamId) == 0) == (len(policy.ChannelId) == 0) {
+		c.SetInvalidParam("previewRetentionPolicy", "policy")
+		return
+	}
+
+	if preview, err := app.PreviewRetentionPolicy(policy); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(preview.ToJson()))
+	}
+}
+
+func getLegalHolds(c *Context, w http.ResponseWriter, r *http.Request) {
+	if holds, err := app.GetLegalHolds(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.LegalHoldListToJson(holds)))
+	}
+}
+
+func createLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	hold := model.LegalHoldFromJson(r.Body)
+	if hold == nil {
+		c.SetInvalidParam("createLegalHold", "hold")
+		return
+	}
+
+	if hold, err := app.CreateLegalHold(hold); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("hold_id=" + hold.Id)
+		w.Write([]byte(hold.ToJson()))
+	}
+}
+
+func updateLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	holdId := params["hold_id"]
+	if len(holdId) != 26 {
+		c.SetInvalidParam("updateLegalHold", "hold_id")
+		return
+	}
+
+	hold := model.LegalHoldFromJson(r.Body)
+	if hold == nil || hold.Id != holdId {
+		c.SetInvalidParam("updateLegalHold", "hold")
+		return
+	}
+
+	if hold, err := app.UpdateLegalHold(hold); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("hold_id=" + hold.Id)
+		w.Write([]byte(hold.ToJson()))
+	}
+}
+
+func deleteLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	holdId := params["hold_id"]
+	if len(holdId) != 26 {
+		c.SetInvalidParam("deleteLegalHold", "hold_id")
+		return
+	}
+
+	if err := app.DeleteLegalHold(holdId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("hold_id=" + holdId)
+	ReturnStatusOK(w)
+}
+
+func exportLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	holdId := params["hold_id"]
+	if len(holdId) != 26 {
+		c.SetInvalidParam("exportLegalHold", "hold_id")
+		return
+	}
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("exportLegalHold", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit > 1000 {
+		c.SetInvalidParam("exportLegalHold", "limit")
+		return
+	}
+
+	if export, err := app.ExportLegalHold(holdId, offset, limit); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("hold_id=" + holdId)
+		w.Write([]byte(export.ToJson()))
+	}
+}
+
+func exportFileAccessLog(c *Context, w http.ResponseWriter, r *http.Request) {
+	query := model.FileAccessQueryFromJson(r.Body)
+	if query == nil {
+		c.SetInvalidParam("exportFileAccessLog", "query")
+		return
+	}
+
+	if err := query.IsValid(); err != nil {
+		c.Err = err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if accesses, err := app.GetFileAccessLog(query); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("")
+		w.Write([]byte(model.FileAccessListToJson(accesses)))
+	}
+}
+
+func getPseudonyms(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getPseudonyms", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit > 1000 {
+		c.SetInvalidParam("getPseudonyms", "limit")
+		return
+	}
+
+	if pseudonyms, err := app.GetPseudonyms(offset, limit); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("offset=" + strconv.Itoa(offset) + ", limit=" + strconv.Itoa(limit))
+		w.Write([]byte(model.PseudonymListToJson(pseudonyms)))
+	}
+}
+
+func getDeletedFiles(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getDeletedFiles", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit > 1000 {
+		c.SetInvalidParam("getDeletedFiles", "limit")
+		return
+	}
+
+	if infos, err := app.GetDeletedFiles(offset, limit); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.FileInfosToJson(infos)))
+	}
+}
+
+func restoreFiles(c *Context, w http.ResponseWriter, r *http.Request) {
+	fileIds := model.ArrayFromJson(r.Body)
+	if len(fileIds) == 0 || len(fileIds) > 1000 {
+		c.SetInvalidParam("restoreFiles", "file_ids")
+		return
+	}
+
+	for _, fileId := range fileIds {
+		if len(fileId) != 26 {
+			c.SetInvalidParam("restoreFiles", "file_ids")
+			return
+		}
+	}
+
+	if infos, err := app.RestoreFiles(fileIds); err != nil {
+		c.Err = err
+		return
+	} else {
+		for _, info := range infos {
+			c.LogAudit("file_id=" + info.Id + ", post_id=" + info.PostId)
+		}
+
+		w.Write([]byte(model.FileInfosToJson(infos)))
+	}
+}
+
+func reindexSearch(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.StringInterfaceFromJson(r.Body)
+
+	var startTime, endTime int64
+	if v, ok := props["start_time"].(float64); ok {
+		startTime = int64(v)
+	}
+	if v, ok := props["end_time"].(float64); ok {
+		endTime = int64(v)
+	}
+	rebuild, _ := props["rebuild"].(bool)
+
+	job, err := app.CreateSearchIndexingJob(startTime, endTime, "", rebuild)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("job_id=" + job.Id)
+	w.Write([]byte(job.ToJson()))
+}
+
+func repairChannelCounts(c *Context, w http.ResponseWriter, r *http.Request) {
+	job, err := app.CreateChannelCountsRepairJob()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("job_id=" + job.Id)
+	w.Write([]byte(job.ToJson()))
+}
+
+func getJobsByType(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getJobsByType", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit > 200 {
+		c.SetInvalidParam("getJobsByType", "limit")
+		return
+	}
+
+	if jobs, err := app.GetJobsByType(params["job_type"], offset, limit); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.JobsToJson(jobs)))
+	}
+}
+
+func getJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	jobId := params["job_id"]
+	if len(jobId) != 26 {
+		c.SetInvalidParam("getJob", "job_id")
+		return
+	}
+
+	if job, err := app.GetJob(jobId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(job.ToJson()))
+	}
+}
+
+func cancelJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	jobId := params["job_id"]
+	if len(jobId) != 26 {
+		c.SetInvalidParam("cancelJob", "job_id")
+		return
+	}
+
+	if job, err := app.CancelJob(jobId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("job_id=" + jobId)
+		w.Write([]byte(job.ToJson()))
+	}
+}
+
+func getJobSchedules(c *Context, w http.ResponseWriter, r *http.Request) {
+	if schedules, err := app.GetJobSchedules(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.JobSchedulesToJson(schedules)))
+	}
+}
+
+func updateJobSchedule(c *Context, w http.ResponseWriter, r *http.Request) {
+	schedule := model.JobScheduleFromJson(r.Body)
+	if schedule == nil {
+		c.SetInvalidParam("updateJobSchedule", "schedule")
+		return
+	}
+
+	if schedule, err := app.UpdateJobSchedule(schedule); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("job_type=" + schedule.JobType + " schedule=" + schedule.Schedule + " timezone=" + schedule.Timezone)
+		w.Write([]byte(schedule.ToJson()))
+	}
+}
+
 func getAllAudits(c *Context, w http.ResponseWriter, r *http.Request) {
 	if audits, err := app.GetAudits("", 200); err != nil {
 		c.Err = err
@@ -77,6 +523,8 @@ func getAllAudits(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getConfig returns the config with its passwords, secrets and salts hidden. Read only admins can get it too, so it
+// must never be returned without being sanitized.
 func getConfig(c *Context, w http.ResponseWriter, r *http.Request) {
 	cfg := app.GetConfig()
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -84,7 +532,11 @@ func getConfig(c *Context, w http.ResponseWriter, r *http.Request) {
 }
 
 func reloadConfig(c *Context, w http.ResponseWriter, r *http.Request) {
-	app.ReloadConfig()
+	if err := app.ReloadConfig(); err != nil {
+		c.Err = err
+		return
+	}
+
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	ReturnStatusOK(w)
 }
@@ -123,6 +575,17 @@ func recycleDatabaseConnection(c *Context, w http.ResponseWriter, r *http.Reques
 	ReturnStatusOK(w)
 }
 
+func getDatabaseHealth(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	health := app.CheckDatabaseHealth()
+	if !health.IsHealthy() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	w.Write([]byte(health.ToJson()))
+}
+
 func testEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 	cfg := model.ConfigFromJson(r.Body)
 	if cfg == nil {
@@ -141,6 +604,44 @@ func testEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(m)))
 }
 
+func getEmailTemplates(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(model.ArrayToJson(utils.GetHTMLTemplateNames())))
+}
+
+func previewEmailTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	templateName := props["template"]
+	if len(templateName) == 0 {
+		c.SetInvalidParam("previewEmailTemplate", "template")
+		return
+	}
+
+	if body, err := app.PreviewEmailTemplate(templateName, props["locale"], c.GetSiteURL()); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.MapToJson(map[string]string{"html": body})))
+	}
+}
+
+func testEmailTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	templateName := props["template"]
+	if len(templateName) == 0 {
+		c.SetInvalidParam("testEmailTemplate", "template")
+		return
+	}
+
+	if err := app.SendTestEmailTemplate(c.Session.UserId, templateName, props["locale"], c.GetSiteURL()); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
 func getComplianceReports(c *Context, w http.ResponseWriter, r *http.Request) {
 	crs, err := app.GetComplianceReports()
 	if err != nil {
@@ -150,6 +651,31 @@ func getComplianceReports(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(crs.ToJson()))
 }
 
+func aggregateAnalytics(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	startDay := props["start_day"]
+	if len(startDay) == 0 {
+		c.SetInvalidParam("aggregateAnalytics", "start_day")
+		return
+	}
+
+	endDay := props["end_day"]
+	if len(endDay) == 0 {
+		c.SetInvalidParam("aggregateAnalytics", "end_day")
+		return
+	}
+
+	job, err := app.CreateAnalyticsAggregationJob(startDay, endDay)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("job_id=" + job.Id)
+	w.Write([]byte(job.ToJson()))
+}
+
 func saveComplianceReport(c *Context, w http.ResponseWriter, r *http.Request) {
 	job := model.ComplianceFromJson(r.Body)
 	if job == nil {
@@ -290,6 +816,11 @@ func adminResetMfa(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !app.SessionCanManageUser(c.Session, userId) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
 	if err := app.DeactivateMfa(userId); err != nil {
 		c.Err = err
 		return
@@ -311,6 +842,11 @@ func adminResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !app.SessionCanManageUser(c.Session, userId) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
 	newPassword := props["new_password"]
 	if err := utils.IsPasswordValid(newPassword); err != nil {
 		c.Err = err
@@ -329,6 +865,53 @@ func adminResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(rdata)))
 }
 
+func adminUnlockUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	userId := props["user_id"]
+	if len(userId) != 26 {
+		c.SetInvalidParam("adminUnlockUser", "user_id")
+		return
+	}
+
+	if !app.SessionCanManageUser(c.Session, userId) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if err := app.UnlockUser(userId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAuditWithUserId(userId, "unlocked")
+
+	rdata := map[string]string{}
+	rdata["status"] = "ok"
+	w.Write([]byte(model.MapToJson(rdata)))
+}
+
+func adminUnlockIpAddress(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	ipAddress := props["ip_address"]
+	if len(ipAddress) == 0 || len(ipAddress) > 64 {
+		c.SetInvalidParam("adminUnlockIpAddress", "ip_address")
+		return
+	}
+
+	if err := app.UnlockIpAddress(ipAddress); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("unlocked ip_address=" + ipAddress)
+
+	rdata := map[string]string{}
+	rdata["status"] = "ok"
+	w.Write([]byte(model.MapToJson(rdata)))
+}
+
 func ldapSyncNow(c *Context, w http.ResponseWriter, r *http.Request) {
 	app.SyncLdap()
 
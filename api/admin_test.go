@@ -4,6 +4,7 @@
 package api
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 
@@ -27,6 +28,35 @@ func TestGetLogs(t *testing.T) {
 	}
 }
 
+func TestQueryLogs(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	query := &model.LogQuery{Limit: 10}
+
+	if _, err := th.BasicClient.QueryLogs(query); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if page, err := th.SystemAdminClient.QueryLogs(query); err != nil {
+		t.Fatal(err)
+	} else if len(page.Entries) == 0 || len(page.Entries) > 10 {
+		t.Fatal("should have returned a page of entries", len(page.Entries))
+	}
+
+	query.Level = model.LOG_LEVEL_CRITICAL
+	query.Since = model.GetMillis() + 60*1000
+	if page, err := th.SystemAdminClient.QueryLogs(query); err != nil {
+		t.Fatal(err)
+	} else if len(page.Entries) != 0 || page.HasMore {
+		t.Fatal("shouldn't have returned any entries from the future")
+	}
+
+	query.Level = "VERBOSE"
+	if _, err := th.SystemAdminClient.QueryLogs(query); err == nil {
+		t.Fatal("should have failed with an invalid level")
+	}
+}
+
 func TestGetClusterInfos(t *testing.T) {
 	th := Setup().InitSystemAdmin().InitBasic()
 
@@ -39,6 +69,344 @@ func TestGetClusterInfos(t *testing.T) {
 	}
 }
 
+func TestGetClusterNodes(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.GetClusterNodes(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.SystemAdminClient.GetClusterNodes(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetentionPolicies(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	policy := &model.RetentionPolicy{
+		DisplayName:          "Short retention",
+		ChannelId:            th.BasicChannel.Id,
+		MessageRetentionDays: 1,
+	}
+
+	if _, err := th.BasicClient.CreateRetentionPolicy(policy); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.BasicClient.GetRetentionPolicies(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.BasicClient.PreviewRetentionPolicy(policy); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	old := &model.Post{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id, Message: "old", CreateAt: model.GetMillis() - 2*app.DAY_MILLISECONDS}
+	if result := <-app.Srv.Store.Post().Save(old); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if preview, err := th.SystemAdminClient.PreviewRetentionPolicy(policy); err != nil {
+		t.Fatal(err)
+	} else if preview.PostCount != 1 || preview.FileCount != 0 {
+		t.Fatal("incorrect preview")
+	}
+
+	created, err := th.SystemAdminClient.CreateRetentionPolicy(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := th.SystemAdminClient.CreateRetentionPolicy(policy); err == nil {
+		t.Fatal("shouldn't be able to create a second policy for the same channel")
+	}
+
+	if policies, err := th.SystemAdminClient.GetRetentionPolicies(); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, received := range policies {
+			if received.Id == created.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the policy")
+		}
+	}
+
+	created.MessageRetentionDays = 0
+	if updated, err := th.SystemAdminClient.UpdateRetentionPolicy(created); err != nil {
+		t.Fatal(err)
+	} else if updated.MessageRetentionDays != 0 || updated.CreateAt != created.CreateAt {
+		t.Fatal("policy wasn't updated")
+	}
+
+	if _, err := th.BasicClient.DeleteRetentionPolicy(created.Id); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if ok, err := th.SystemAdminClient.DeleteRetentionPolicy(created.Id); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have deleted the policy")
+	}
+
+	if _, err := th.SystemAdminClient.DeleteRetentionPolicy(created.Id); err == nil {
+		t.Fatal("shouldn't be able to delete a policy twice")
+	}
+}
+
+func TestLegalHolds(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	hold := &model.LegalHold{
+		DisplayName: "Investigation",
+		ChannelId:   th.BasicChannel.Id,
+	}
+
+	if _, err := th.BasicClient.CreateLegalHold(hold); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.BasicClient.GetLegalHolds(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	created, err := th.SystemAdminClient.CreateLegalHold(hold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := th.SystemAdminClient.CreateLegalHold(&model.LegalHold{DisplayName: "Missing", ChannelId: model.NewId()}); err == nil {
+		t.Fatal("shouldn't be able to hold a channel that doesn't exist")
+	}
+
+	if holds, err := th.SystemAdminClient.GetLegalHolds(); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, received := range holds {
+			if received.Id == created.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the hold")
+		}
+	}
+
+	if _, err := th.BasicClient.ExportLegalHold(created.Id, 0, 100); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if export, err := th.SystemAdminClient.ExportLegalHold(created.Id, 0, 100); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, post := range export.Posts {
+			if post.Id == th.BasicPost.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have exported the posts in the channel")
+		}
+	}
+
+	created.EndAt = 1
+	if updated, err := th.SystemAdminClient.UpdateLegalHold(created); err != nil {
+		t.Fatal(err)
+	} else if updated.EndAt != 1 || updated.CreateAt != created.CreateAt {
+		t.Fatal("hold wasn't updated")
+	}
+
+	if export, err := th.SystemAdminClient.ExportLegalHold(created.Id, 0, 100); err != nil {
+		t.Fatal(err)
+	} else if len(export.Posts) != 0 {
+		t.Fatal("shouldn't have exported posts outside of the hold")
+	}
+
+	if _, err := th.BasicClient.DeleteLegalHold(created.Id); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if ok, err := th.SystemAdminClient.DeleteLegalHold(created.Id); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have deleted the hold")
+	}
+
+	if _, err := th.SystemAdminClient.DeleteLegalHold(created.Id); err == nil {
+		t.Fatal("shouldn't be able to delete a hold twice")
+	}
+}
+
+func TestReindexSearch(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.ReindexSearch(0, 0, false); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	// no search engine is registered when running the tests
+	if _, err := th.SystemAdminClient.ReindexSearch(0, 0, false); err == nil {
+		t.Fatal("should have failed without a search engine")
+	}
+}
+
+func TestRepairChannelCounts(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.RepairChannelCounts(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if job, err := th.SystemAdminClient.RepairChannelCounts(); err != nil {
+		t.Fatal(err)
+	} else if job.Type != model.JOB_TYPE_CHANNEL_COUNTS_REPAIR {
+		t.Fatal("should have created a repair job")
+	}
+}
+
+func TestAggregateAnalytics(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.AggregateAnalytics("2017-01-01", "2017-01-02"); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.SystemAdminClient.AggregateAnalytics("2017-01-02", "2017-01-01"); err == nil {
+		t.Fatal("should have failed with the start day after the end day")
+	}
+
+	if _, err := th.SystemAdminClient.AggregateAnalytics("01/01/2017", "2017-01-01"); err == nil {
+		t.Fatal("should have failed with an invalid day")
+	}
+
+	if job, err := th.SystemAdminClient.AggregateAnalytics("2017-01-01", "2017-01-02"); err != nil {
+		t.Fatal(err)
+	} else {
+		defer func() {
+			<-app.Srv.Store.Job().Delete(job.Id)
+		}()
+
+		if job.Type != model.JOB_TYPE_ANALYTICS_AGGREGATION || job.Data["start_day"] != "2017-01-01" {
+			t.Fatal("created incorrect job")
+		}
+	}
+}
+
+func TestJobs(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	job := &model.Job{
+		Type: model.JOB_TYPE_SEARCH_INDEXING,
+		Data: map[string]string{},
+	}
+	if result := <-app.Srv.Store.Job().Save(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	defer func() {
+		<-app.Srv.Store.Job().Delete(job.Id)
+	}()
+
+	if _, err := th.BasicClient.GetJob(job.Id); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.BasicClient.GetJobsByType(model.JOB_TYPE_SEARCH_INDEXING, 0, 10); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if _, err := th.BasicClient.CancelJob(job.Id); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if received, err := th.SystemAdminClient.GetJob(job.Id); err != nil {
+		t.Fatal(err)
+	} else if received.Id != job.Id || received.Status != model.JOB_STATUS_PENDING {
+		t.Fatal("received incorrect job")
+	}
+
+	if _, err := th.SystemAdminClient.GetJob(model.NewId()); err == nil {
+		t.Fatal("should have failed to get a job that doesn't exist")
+	}
+
+	if jobs, err := th.SystemAdminClient.GetJobsByType(model.JOB_TYPE_SEARCH_INDEXING, 0, 10); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, received := range jobs {
+			if received.Id == job.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the job")
+		}
+	}
+
+	if canceled, err := th.SystemAdminClient.CancelJob(job.Id); err != nil {
+		t.Fatal(err)
+	} else if canceled.Status != model.JOB_STATUS_CANCELED {
+		t.Fatal("pending job should have been canceled immediately")
+	}
+}
+
+func TestJobSchedules(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+
+	if _, err := th.BasicClient.GetJobSchedules(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	schedule := &model.JobSchedule{JobType: model.JOB_TYPE_DATA_RETENTION, Schedule: "30 3 * * sun", Timezone: "UTC"}
+	if _, err := th.BasicClient.UpdateJobSchedule(schedule); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if updated, err := th.SystemAdminClient.UpdateJobSchedule(schedule); err != nil {
+		t.Fatal(err)
+	} else if updated.Schedule != schedule.Schedule || updated.NextRunAt <= model.GetMillis() {
+		t.Fatal("should have updated the schedule and worked out the next run")
+	}
+
+	if schedules, err := th.SystemAdminClient.GetJobSchedules(); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, received := range schedules {
+			if received.JobType == model.JOB_TYPE_DATA_RETENTION {
+				found = received.Schedule == schedule.Schedule && received.Timezone == schedule.Timezone
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the updated schedule")
+		}
+	}
+
+	if _, err := th.SystemAdminClient.UpdateJobSchedule(&model.JobSchedule{JobType: model.JOB_TYPE_DATA_RETENTION, Schedule: "junk"}); err == nil {
+		t.Fatal("should have failed with an invalid schedule")
+	}
+
+	if _, err := th.SystemAdminClient.UpdateJobSchedule(&model.JobSchedule{JobType: model.JOB_TYPE_SEARCH_INDEXING, Schedule: "@daily"}); err == nil {
+		t.Fatal("should have failed with a job type that isn't scheduled")
+	}
+
+	if updated, err := th.SystemAdminClient.UpdateJobSchedule(&model.JobSchedule{JobType: model.JOB_TYPE_DATA_RETENTION}); err != nil {
+		t.Fatal(err)
+	} else if updated.Schedule != app.DATA_RETENTION_DEFAULT_SCHEDULE {
+		t.Fatal("should have gone back to the default schedule")
+	}
+}
+
 func TestGetAllAudits(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 
@@ -102,6 +470,42 @@ func TestGetConfig(t *testing.T) {
 	}
 }
 
+func TestGetConfigAsReadOnlyAdmin(t *testing.T) {
+	th := Setup().InitBasic()
+
+	original := utils.Cfg.ToJson()
+	defer func() {
+		utils.Cfg = model.ConfigFromJson(strings.NewReader(original))
+	}()
+
+	secret := "secret" + model.NewId()
+	utils.Cfg.SqlSettings.DataSourceReplicas = []string{"mmuser:" + secret + "@tcp(replica:3306)/mattermost"}
+	utils.Cfg.EmailSettings.SMTPPassword = secret
+	utils.Cfg.FileSettings.AmazonS3SecretAccessKey = secret
+	utils.Cfg.GitLabSettings.Secret = secret
+	utils.Cfg.GoogleSettings.Secret = secret
+	utils.Cfg.Office365Settings.Secret = secret
+	*utils.Cfg.WebrtcSettings.GatewayAdminSecret = secret
+	*utils.Cfg.WebrtcSettings.TurnSharedKey = secret
+
+	if _, err := app.UpdateUserRoles(th.BasicUser.Id, model.ROLE_SYSTEM_USER.Id+" "+model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id); err != nil {
+		t.Fatal(err)
+	}
+	th.LoginBasic()
+
+	if result, err := th.BasicClient.GetConfig(); err != nil {
+		t.Fatal(err)
+	} else if cfg := result.Data.(*model.Config); strings.Contains(cfg.ToJson(), secret) {
+		t.Fatal("should have hidden the secrets from a read only admin")
+	} else if cfg.SqlSettings.DataSource != model.FAKE_SETTING || cfg.SqlSettings.DataSourceReplicas[0] != model.FAKE_SETTING {
+		t.Fatal("should have hidden the data sources")
+	} else if cfg.EmailSettings.InviteSalt != model.FAKE_SETTING || cfg.EmailSettings.PasswordResetSalt != model.FAKE_SETTING || *cfg.FileSettings.PublicLinkSalt != model.FAKE_SETTING {
+		t.Fatal("should have hidden the salts")
+	} else if cfg.GoogleSettings.Secret != model.FAKE_SETTING || cfg.Office365Settings.Secret != model.FAKE_SETTING || *cfg.WebrtcSettings.TurnSharedKey != model.FAKE_SETTING {
+		t.Fatal("should have hidden the secrets")
+	}
+}
+
 func TestReloadConfig(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 
@@ -588,6 +992,38 @@ func TestAdminResetMfa(t *testing.T) {
 	// need to add more test cases when enterprise bits can be loaded into tests
 }
 
+func TestAdminUnlockUser(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.AdminUnlockUser(th.BasicUser.Id); err == nil {
+		t.Fatal("should have failed - not an admin")
+	}
+
+	if _, err := th.SystemAdminClient.AdminUnlockUser(""); err == nil {
+		t.Fatal("should have failed - empty user id")
+	}
+
+	if _, err := th.SystemAdminClient.AdminUnlockUser(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdminUnlockIpAddress(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if _, err := th.BasicClient.AdminUnlockIpAddress("192.0.2.1"); err == nil {
+		t.Fatal("should have failed - not an admin")
+	}
+
+	if _, err := th.SystemAdminClient.AdminUnlockIpAddress(""); err == nil {
+		t.Fatal("should have failed - empty ip address")
+	}
+
+	if _, err := th.SystemAdminClient.AdminUnlockIpAddress("192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestAdminResetPassword(t *testing.T) {
 	th := Setup().InitSystemAdmin()
 	Client := th.SystemAdminClient
@@ -652,3 +1088,241 @@ func TestGetRecentlyActiveUsers(t *testing.T) {
 		t.Fatal("should have been at least 2")
 	}
 }
+
+func TestAdminRoles(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+	Client := th.BasicClient
+
+	setRole := func(role string) {
+		if _, err := app.UpdateUserRoles(th.BasicUser.Id, model.ROLE_SYSTEM_USER.Id+" "+role); err != nil {
+			t.Fatal(err)
+		}
+		th.LoginBasic()
+	}
+
+	setRole(model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id)
+
+	if result, err := Client.GetConfig(); err != nil {
+		t.Fatal(err)
+	} else if config := result.Data.(*model.Config); config.SqlSettings.DataSource != model.FAKE_SETTING {
+		t.Fatal("should have hidden the secrets in the config")
+	} else if _, err := Client.SaveConfig(config); err == nil {
+		t.Fatal("read only admins shouldn't be able to save the config")
+	}
+
+	if _, err := Client.GetLegalHolds(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.CreateLegalHold(&model.LegalHold{DisplayName: "Investigation", ChannelId: th.BasicChannel.Id}); err == nil {
+		t.Fatal("read only admins shouldn't be able to create legal holds")
+	}
+
+	if _, err := Client.AdminResetPassword(th.BasicUser2.Id, "newpwd1"); err == nil {
+		t.Fatal("read only admins shouldn't be able to reset passwords")
+	}
+
+	setRole(model.ROLE_SYSTEM_USER_MANAGER.Id)
+
+	if _, err := Client.AdminResetPassword(th.BasicUser2.Id, "newpwd1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.AdminResetPassword(th.SystemAdminUser.Id, "newpwd1"); err == nil {
+		t.Fatal("user managers shouldn't be able to reset the passwords of admins")
+	}
+
+	if _, err := Client.UpdateActive(th.SystemAdminUser.Id, false); err == nil {
+		t.Fatal("user managers shouldn't be able to deactivate admins")
+	}
+
+	if _, err := Client.GetConfig(); err == nil {
+		t.Fatal("user managers shouldn't be able to read the config")
+	}
+
+	setRole(model.ROLE_SYSTEM_COMPLIANCE_OFFICER.Id)
+
+	if hold, err := Client.CreateLegalHold(&model.LegalHold{DisplayName: "Investigation", ChannelId: th.BasicChannel.Id}); err != nil {
+		t.Fatal(err)
+	} else if _, err := Client.DeleteLegalHold(hold.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.GetAllAudits(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.GetConfig(); err == nil {
+		t.Fatal("compliance officers shouldn't be able to read the config")
+	}
+
+	if _, err := Client.AdminResetPassword(th.BasicUser2.Id, "newpwd1"); err == nil {
+		t.Fatal("compliance officers shouldn't be able to reset passwords")
+	}
+
+	// system admins can still do everything
+	if _, err := th.SystemAdminClient.AdminResetPassword(th.BasicUser.Id, "newpwd1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+
+	SendEmailNotifications := utils.Cfg.EmailSettings.SendEmailNotifications
+	defer func() {
+		utils.Cfg.EmailSettings.SendEmailNotifications = SendEmailNotifications
+	}()
+	utils.Cfg.EmailSettings.SendEmailNotifications = false
+
+	if _, err := th.BasicClient.GetEmailTemplates(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if templates, err := th.SystemAdminClient.GetEmailTemplates(); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, name := range templates {
+			if name == "reset_body" {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the templates", templates)
+		}
+	}
+
+	if _, err := th.BasicClient.PreviewEmailTemplate("reset_body", ""); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if body, err := th.SystemAdminClient.PreviewEmailTemplate("reset_body", "en"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(body, "[Title]") {
+		t.Fatal("should have filled in placeholders", body)
+	}
+
+	if _, err := th.SystemAdminClient.PreviewEmailTemplate("junk", ""); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed to find the template")
+	}
+
+	if _, err := th.BasicClient.TestEmailTemplate("reset_body", ""); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if ok, err := th.SystemAdminClient.TestEmailTemplate("reset_body", ""); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have sent the template")
+	}
+}
+
+func TestRestoreFiles(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+
+	info := store.Must(app.Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		PostId:    th.BasicPost.Id,
+		Path:      "restore.txt",
+	})).(*model.FileInfo)
+	store.Must(app.Srv.Store.FileInfo().DeleteForPost(th.BasicPost.Id))
+
+	if _, err := Client.GetDeletedFiles(0, 100); err == nil {
+		t.Fatal("should need permission to get deleted files")
+	}
+
+	if _, err := Client.RestoreFiles([]string{info.Id}); err == nil {
+		t.Fatal("should need permission to restore files")
+	}
+
+	if _, err := th.SystemAdminClient.GetDeletedFiles(0, 1001); err == nil {
+		t.Fatal("should have failed with too large a limit")
+	}
+
+	if infos, err := th.SystemAdminClient.GetDeletedFiles(0, 100); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, deleted := range infos {
+			if deleted.Id == info.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the deleted file")
+		}
+	}
+
+	if _, err := th.SystemAdminClient.RestoreFiles([]string{"junk"}); err == nil {
+		t.Fatal("should have failed with an invalid file id")
+	}
+
+	if infos, err := th.SystemAdminClient.RestoreFiles([]string{info.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != info.Id || infos[0].DeleteAt != 0 {
+		t.Fatal("should have restored the file")
+	}
+
+	if infos, err := th.SystemAdminClient.RestoreFiles([]string{info.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 0 {
+		t.Fatal("shouldn't restore a file that isn't deleted")
+	}
+
+	if infos, err := app.GetFileInfosForPost(th.BasicPost.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != info.Id {
+		t.Fatal("restored file should be attached to the post again")
+	}
+}
+
+func TestGetPseudonyms(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+
+	pseudonym, err := app.GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.GetPseudonyms(0, 1000); err == nil {
+		t.Fatal("should need permission to read pseudonyms")
+	}
+
+	if _, err := app.UpdateUserRoles(th.BasicUser.Id, model.ROLE_SYSTEM_USER.Id+" "+model.ROLE_SYSTEM_COMPLIANCE_OFFICER.Id); err != nil {
+		t.Fatal(err)
+	}
+	th.LoginBasic()
+
+	if _, err := Client.GetPseudonyms(0, 1000); err == nil {
+		t.Fatal("compliance officers shouldn't be able to read pseudonyms")
+	}
+
+	if _, err := th.SystemAdminClient.GetPseudonyms(0, 1001); err == nil {
+		t.Fatal("should have failed with too large a limit")
+	}
+
+	found := false
+	for offset := 0; !found; offset += 1000 {
+		pseudonyms, err := th.SystemAdminClient.GetPseudonyms(offset, 1000)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(pseudonyms) == 0 {
+			break
+		}
+
+		for _, p := range pseudonyms {
+			if p.UserId == th.BasicUser.Id && p.Pseudonym == pseudonym.Pseudonym {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the user's pseudonym")
+	}
+}
@@ -21,6 +21,7 @@ func InitChannel() {
 	BaseRoutes.Channels.Handle("/more/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getMoreChannelsPage)).Methods("GET")
 	BaseRoutes.Channels.Handle("/more/search", ApiUserRequired(searchMoreChannels)).Methods("POST")
 	BaseRoutes.Channels.Handle("/counts", ApiUserRequired(getChannelCounts)).Methods("GET")
+	BaseRoutes.Channels.Handle("/stats", ApiUserRequired(getChannelsStats)).Methods("POST")
 	BaseRoutes.Channels.Handle("/members", ApiUserRequired(getMyChannelMembers)).Methods("GET")
 	BaseRoutes.Channels.Handle("/create", ApiUserRequired(createChannel)).Methods("POST")
 	BaseRoutes.Channels.Handle("/view", ApiUserRequired(viewChannel)).Methods("POST")
@@ -36,6 +37,7 @@ func InitChannel() {
 
 	BaseRoutes.NeedChannel.Handle("/", ApiUserRequired(getChannel)).Methods("GET")
 	BaseRoutes.NeedChannel.Handle("/stats", ApiUserRequired(getChannelStats)).Methods("GET")
+	BaseRoutes.NeedChannel.Handle("/export", ApiUserRequired(exportChannel)).Methods("GET")
 	BaseRoutes.NeedChannel.Handle("/members/{user_id:[A-Za-z0-9]+}", ApiUserRequired(getChannelMember)).Methods("GET")
 	BaseRoutes.NeedChannel.Handle("/members/ids", ApiUserRequired(getChannelMembersByIds)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/join", ApiUserRequired(join)).Methods("POST")
@@ -44,6 +46,9 @@ func InitChannel() {
 	BaseRoutes.NeedChannel.Handle("/add", ApiUserRequired(addMember)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/remove", ApiUserRequired(removeMember)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/update_member_roles", ApiUserRequired(updateChannelMemberRoles)).Methods("POST")
+	BaseRoutes.NeedChannel.Handle("/email_address", ApiUserRequired(getChannelEmailAddress)).Methods("GET")
+	BaseRoutes.NeedChannel.Handle("/email_address/regenerate", ApiUserRequired(regenerateChannelEmailAddress)).Methods("POST")
+	BaseRoutes.NeedChannel.Handle("/email_address/delete", ApiUserRequired(deleteChannelEmailAddress)).Methods("POST")
 }
 
 func createChannel(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -507,6 +512,49 @@ func getChannelByName(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func exportChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["channel_id"]
+
+	var channel *model.Channel
+	var err *model.AppError
+	if channel, err = app.GetChannel(id); err != nil {
+		c.Err = err
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, channel.Id, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	// direct and group messages don't belong to a team, so only a system admin can export them
+	if channel.TeamId == "" {
+		if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+			c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+			return
+		}
+	} else if !app.SessionHasPermissionToTeam(c.Session, channel.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	var pseudonymizer *app.Pseudonymizer
+	if r.URL.Query().Get("anonymize") == "true" {
+		pseudonymizer = app.NewPseudonymizer()
+	}
+
+	c.LogAudit("channel_id=" + channel.Id + ", anonymize=" + strconv.FormatBool(pseudonymizer != nil))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment;filename=\""+channel.Name+".zip\"")
+
+	// the export is streamed, so the response has already started by the time an error can occur
+	if err := app.ExportChannel(channel, w, pseudonymizer, c.Session.UserId, c.IpAddress); err != nil {
+		l4g.Error(utils.T("api.channel.export_channel.error"), channel.Id, err.Error())
+	}
+}
+
 func getChannelStats(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["channel_id"]
@@ -538,6 +586,28 @@ func getChannelStats(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getChannelsStats(c *Context, w http.ResponseWriter, r *http.Request) {
+	channelIds := model.ArrayFromJson(r.Body)
+	if len(channelIds) == 0 {
+		c.SetInvalidParam("getChannelsStats", "channel_ids")
+		return
+	}
+
+	for _, channelId := range channelIds {
+		if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+			c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+			return
+		}
+	}
+
+	if stats, err := app.GetChannelMemberCounts(channelIds); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.ChannelStatsListToJson(stats)))
+	}
+}
+
 func getChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	channelId := params["channel_id"]
@@ -826,3 +896,75 @@ func updateChannelMemberRoles(c *Context, w http.ResponseWriter, r *http.Request
 	rdata["status"] = "ok"
 	w.Write([]byte(model.MapToJson(rdata)))
 }
+
+func getChannelEmailAddress(c *Context, w http.ResponseWriter, r *http.Request) {
+	channelId := mux.Vars(r)["channel_id"]
+
+	if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	if address, err := app.GetChannelEmailAddress(channelId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(address.ToJson()))
+	}
+}
+
+func regenerateChannelEmailAddress(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !*utils.Cfg.EmailSettings.EnableInboundEmail {
+		c.Err = model.NewLocAppError("regenerateChannelEmailAddress", "api.channel.email_address.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	channel, err := app.GetChannel(mux.Vars(r)["channel_id"])
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if _, err := app.GetChannelMember(channel.Id, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	if !CanManageChannel(c, channel) {
+		return
+	}
+
+	if address, err := app.RegenerateChannelEmailAddress(channel, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("channel_id=" + channel.Id)
+		w.Write([]byte(address.ToJson()))
+	}
+}
+
+func deleteChannelEmailAddress(c *Context, w http.ResponseWriter, r *http.Request) {
+	channel, err := app.GetChannel(mux.Vars(r)["channel_id"])
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if _, err := app.GetChannelMember(channel.Id, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	if !CanManageChannel(c, channel) {
+		return
+	}
+
+	if err := app.DeleteChannelEmailAddress(channel.Id); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("channel_id=" + channel.Id)
+	ReturnStatusOK(w)
+}
@@ -4,6 +4,9 @@
 package api
 
 import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
@@ -1373,6 +1376,73 @@ func TestGetChannelStats(t *testing.T) {
 	}
 }
 
+func TestExportChannel(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+	Client := th.BasicClient
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "to export"}))
+
+	if _, err := Client.ExportChannel(th.BasicChannel.Id, false); err == nil {
+		t.Fatal("should need to be a team admin to export a channel")
+	}
+
+	th.SystemAdminClient.SetTeamId(th.BasicTeam.Id)
+	if body, err := th.SystemAdminClient.ExportChannel(th.BasicChannel.Id, false); err != nil {
+		t.Fatal(err)
+	} else {
+		defer body.Close()
+
+		data, readErr := ioutil.ReadAll(body)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+
+		if reader, zipErr := zip.NewReader(bytes.NewReader(data), int64(len(data))); zipErr != nil {
+			t.Fatal(zipErr)
+		} else if len(reader.File) != 1 || reader.File[0].Name != app.CHANNEL_EXPORT_MANIFEST_NAME {
+			t.Fatal("export should only contain the manifest")
+		}
+	}
+}
+
+func TestGetChannelsStats(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	team := th.BasicTeam
+
+	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	Client.Must(Client.AddChannelMember(channel1.Id, th.BasicUser2.Id))
+
+	channel2 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	stats := Client.Must(Client.GetChannelsStats([]string{channel1.Id, channel2.Id})).Data.([]*model.ChannelStats)
+	if len(stats) != 2 {
+		t.Fatal("should've returned stats for both channels")
+	} else if stats[0].ChannelId != channel1.Id || stats[0].MemberCount != 2 {
+		t.Fatal("got incorrect member count for the first channel")
+	} else if stats[1].ChannelId != channel2.Id || stats[1].MemberCount != 1 {
+		t.Fatal("got incorrect member count for the second channel")
+	}
+
+	Client.Must(Client.RemoveChannelMember(channel1.Id, th.BasicUser2.Id))
+
+	stats = Client.Must(Client.GetChannelsStats([]string{channel1.Id})).Data.([]*model.ChannelStats)
+	if stats[0].MemberCount != 1 {
+		t.Fatal("member count should've been updated")
+	}
+
+	if _, err := Client.GetChannelsStats([]string{}); err == nil {
+		t.Fatal("should've failed without any channels")
+	}
+
+	if _, err := Client.GetChannelsStats([]string{channel1.Id, model.NewId()}); err == nil {
+		t.Fatal("shouldn't be able to get stats for a channel that the user isn't in")
+	}
+}
+
 func TestAddChannelMember(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -2008,3 +2078,68 @@ func TestUpdateChannelRoles(t *testing.T) {
 		t.Fatal("Channel member should not be able to promote itself to channel admin:", meta)
 	}
 }
+
+func TestChannelEmailAddress(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableInboundEmail := *utils.Cfg.EmailSettings.EnableInboundEmail
+	inboundEmailDomain := *utils.Cfg.EmailSettings.InboundEmailDomain
+	defer func() {
+		*utils.Cfg.EmailSettings.EnableInboundEmail = enableInboundEmail
+		*utils.Cfg.EmailSettings.InboundEmailDomain = inboundEmailDomain
+	}()
+	*utils.Cfg.EmailSettings.EnableInboundEmail = false
+	*utils.Cfg.EmailSettings.InboundEmailDomain = "mail.example.com"
+
+	if _, err := Client.RegenerateChannelEmailAddress(th.BasicChannel.Id); err == nil {
+		t.Fatal("shouldn't be able to create an address while inbound email is disabled")
+	}
+
+	*utils.Cfg.EmailSettings.EnableInboundEmail = true
+
+	if _, err := Client.GetChannelEmailAddress(th.BasicChannel.Id); err == nil {
+		t.Fatal("channel shouldn't have an address yet")
+	}
+
+	address, err := Client.RegenerateChannelEmailAddress(th.BasicChannel.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(address.Address, "@mail.example.com") {
+		t.Fatal("bad address " + address.Address)
+	}
+
+	if fetched, err := Client.GetChannelEmailAddress(th.BasicChannel.Id); err != nil {
+		t.Fatal(err)
+	} else if fetched.Address != address.Address {
+		t.Fatal("should have returned the same address")
+	}
+
+	if regenerated, err := Client.RegenerateChannelEmailAddress(th.BasicChannel.Id); err != nil {
+		t.Fatal(err)
+	} else if regenerated.Address == address.Address {
+		t.Fatal("should have generated a new address")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.GetChannelEmailAddress(th.BasicChannel.Id); err == nil {
+		t.Fatal("non-member shouldn't be able to get the address")
+	}
+
+	if _, err := Client.DeleteChannelEmailAddress(th.BasicChannel.Id); err == nil {
+		t.Fatal("non-member shouldn't be able to delete the address")
+	}
+
+	th.LoginBasic()
+
+	if ok, err := Client.DeleteChannelEmailAddress(th.BasicChannel.Id); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have deleted the address")
+	}
+
+	if _, err := Client.GetChannelEmailAddress(th.BasicChannel.Id); err == nil {
+		t.Fatal("address should have been deleted")
+	}
+}
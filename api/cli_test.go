@@ -4,7 +4,11 @@
 package api
 
 import (
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/app"
@@ -426,6 +430,41 @@ func TestCliCreateChannel(t *testing.T) {
 	}
 }
 
+func TestCliExportUser(t *testing.T) {
+	if disableCliTests {
+		return
+	}
+
+	th := Setup().InitBasic()
+
+	dir, err := ioutil.TempDir("", "cli_export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "users.jsonl")
+
+	cmd := exec.Command("bash", "-c", "go run ../cmd/platform/*.go user export "+th.BasicUser.Email+" --file "+fileName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Log(string(output))
+		t.Fatal(err)
+	}
+
+	if data, err := ioutil.ReadFile(fileName); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(data), th.BasicUser.Username) {
+		t.Fatal("should have exported the user")
+	}
+
+	if files, err := ioutil.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 1 {
+		t.Fatal("shouldn't have left the temporary file behind")
+	}
+}
+
 func TestCliMakeUserActiveAndInactive(t *testing.T) {
 	if disableCliTests {
 		return
@@ -36,55 +36,65 @@ type Context struct {
 }
 
 func ApiAppHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, false, false, false}
+	return &handler{h, false, false, true, false, false, false, false, nil}
 }
 
 func AppHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, false, false, false, false, false}
+	return &handler{h, false, false, false, false, false, false, false, nil}
 }
 
 func AppHandlerIndependent(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, false, false, true, false, false}
+	return &handler{h, false, false, false, false, true, false, false, nil}
 }
 
 func ApiUserRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, true, false, false, false, true}
+	return &handler{h, true, false, true, false, false, false, true, nil}
 }
 
 func ApiUserRequiredActivity(h func(*Context, http.ResponseWriter, *http.Request), isUserActivity bool) http.Handler {
-	return &handler{h, true, false, true, isUserActivity, false, false, true}
+	return &handler{h, true, false, true, isUserActivity, false, false, true, nil}
 }
 
 func ApiUserRequiredMfa(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, true, false, false, false, false}
+	return &handler{h, true, false, true, false, false, false, false, nil}
 }
 
 func UserRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, false, false, false, false, true}
+	return &handler{h, true, false, false, false, false, false, true, nil}
 }
 
 func AppHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, false, false, false, true, false}
+	return &handler{h, false, false, false, false, false, true, false, nil}
 }
 
 func ApiAdminSystemRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, true, true, false, false, false, true}
+	return &handler{h, true, true, true, false, false, false, true, nil}
 }
 
 func ApiAdminSystemRequiredTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, true, true, false, false, true, true}
+	return &handler{h, true, true, true, false, false, true, true, nil}
+}
+
+// ApiAdminPermissionRequired allows access to an admin function to users with any of the given permissions, so that
+// it can be used by users with roles that only give them access to some admin functions as well as system admins.
+func ApiAdminPermissionRequired(h func(*Context, http.ResponseWriter, *http.Request), permissions ...*model.Permission) http.Handler {
+	return &handler{h, true, false, true, false, false, false, true, permissions}
+}
+
+func ApiAdminPermissionRequiredTrustRequester(h func(*Context, http.ResponseWriter, *http.Request), permissions ...*model.Permission) http.Handler {
+	return &handler{h, true, false, true, false, false, true, true, permissions}
 }
 
 func ApiAppHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, false, true, false}
+	return &handler{h, false, false, true, false, false, true, false, nil}
 }
 
 func ApiUserRequiredTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, true, false, false, true, true}
+	return &handler{h, true, false, true, false, false, true, true, nil}
 }
 
 func ApiAppHandlerTrustRequesterIndependent(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, true, true, false}
+	return &handler{h, false, false, true, false, true, true, false, nil}
 }
 
 type handler struct {
@@ -96,6 +106,7 @@ type handler struct {
 	isTeamIndependent  bool
 	trustRequester     bool
 	requireMfa         bool
+	requirePermissions []*model.Permission
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -207,6 +218,18 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		c.SystemAdminRequired()
 	}
 
+	if c.Err == nil && len(h.requirePermissions) > 0 {
+		c.AnyPermissionRequired(h.requirePermissions)
+	}
+
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		c.Err = app.CheckUserAccessTokenScope(&c.Session, r.Method, r.URL.Path)
+	}
+
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		c.Err = app.RecordUserAccessTokenUse(&c.Session)
+	}
+
 	if c.Err == nil && h.isUserActivity && token != "" && len(c.Session.UserId) > 0 {
 		app.SetStatusOnline(c.Session.UserId, c.Session.Id, false)
 	}
@@ -347,6 +370,17 @@ func (c *Context) SystemAdminRequired() {
 	}
 }
 
+func (c *Context) AnyPermissionRequired(permissions []*model.Permission) {
+	for _, permission := range permissions {
+		if app.SessionHasPermissionTo(c.Session, permission) {
+			return
+		}
+	}
+
+	c.Err = model.NewLocAppError("", "api.context.permissions.app_error", nil, "AdminPermissionRequired")
+	c.Err.StatusCode = http.StatusForbidden
+}
+
 func (c *Context) IsSystemAdmin() bool {
 	return app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM)
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/mux"
@@ -29,6 +30,9 @@ func InitFile() {
 	BaseRoutes.NeedFile.Handle("/get_preview", ApiUserRequiredTrustRequester(getFilePreview)).Methods("GET")
 	BaseRoutes.NeedFile.Handle("/get_info", ApiUserRequired(getFileInfo)).Methods("GET")
 	BaseRoutes.NeedFile.Handle("/get_public_link", ApiUserRequired(getPublicLink)).Methods("GET")
+	BaseRoutes.NeedFile.Handle("/acl", ApiUserRequired(getFileAcl)).Methods("GET")
+	BaseRoutes.NeedFile.Handle("/acl/update", ApiUserRequired(updateFileAcl)).Methods("POST")
+	BaseRoutes.NeedFile.Handle("/acl/delete", ApiUserRequired(deleteFileAcl)).Methods("POST")
 
 	BaseRoutes.Public.Handle("/files/{file_id:[A-Za-z0-9]+}/get", ApiAppHandlerTrustRequesterIndependent(getPublicFile)).Methods("GET")
 	BaseRoutes.Public.Handle("/files/get/{team_id:[A-Za-z0-9]+}/{channel_id:[A-Za-z0-9]+}/{user_id:[A-Za-z0-9]+}/{filename:(?:[A-Za-z0-9]+/)?.+(?:\\.[A-Za-z0-9]{3,})?}", ApiAppHandlerTrustRequesterIndependent(getPublicFileOld)).Methods("GET")
@@ -76,6 +80,7 @@ func uploadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	previewPathList := []string{}
+	webPPreviewPathList := []string{}
 	thumbnailPathList := []string{}
 	imageDataList := [][]byte{}
 
@@ -99,6 +104,7 @@ func uploadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 
 		if info.PreviewPath != "" || info.ThumbnailPath != "" {
 			previewPathList = append(previewPathList, info.PreviewPath)
+			webPPreviewPathList = append(webPPreviewPathList, info.WebPPreviewPath)
 			thumbnailPathList = append(thumbnailPathList, info.ThumbnailPath)
 			imageDataList = append(imageDataList, data)
 		}
@@ -110,7 +116,7 @@ func uploadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	app.HandleImages(previewPathList, thumbnailPathList, imageDataList)
+	app.HandleImages(previewPathList, webPPreviewPathList, thumbnailPathList, imageDataList)
 
 	w.Write([]byte(resStruct.ToJson()))
 }
@@ -122,12 +128,13 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.Path); err != nil {
+	if data, err := readFileForDownload(c, w, info); err != nil {
 		c.Err = err
-		c.Err.StatusCode = http.StatusNotFound
 	} else if err := writeFileResponse(info.Name, info.MimeType, data, w, r); err != nil {
 		c.Err = err
 		return
+	} else {
+		app.RecordFileAccess(info.Id, c.Session.UserId, c.IpAddress, model.FILE_ACCESS_LINK_TYPE_DOWNLOAD)
 	}
 }
 
@@ -150,6 +157,8 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if err := writeFileResponse(info.Name, "", data, w, r); err != nil {
 		c.Err = err
 		return
+	} else {
+		app.RecordFileAccess(info.Id, c.Session.UserId, c.IpAddress, model.FILE_ACCESS_LINK_TYPE_THUMBNAIL)
 	}
 }
 
@@ -166,15 +175,34 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.PreviewPath); err != nil {
+	if len(info.WebPPreviewPath) > 0 {
+		// The same url can return either preview depending on what the client accepts
+		w.Header().Set("Vary", "Accept")
+	}
+
+	if data, err := readFilePreview(info, r); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
 	} else if err := writeFileResponse(info.Name, "", data, w, r); err != nil {
 		c.Err = err
 		return
+	} else {
+		app.RecordFileAccess(info.Id, c.Session.UserId, c.IpAddress, model.FILE_ACCESS_LINK_TYPE_PREVIEW)
 	}
 }
 
+// readFilePreview returns the WebP copy of a file's preview if it has one and the client says that it supports WebP
+// images, falling back to the regular preview otherwise.
+func readFilePreview(info *model.FileInfo, r *http.Request) ([]byte, *model.AppError) {
+	if len(info.WebPPreviewPath) > 0 && *utils.Cfg.FileSettings.EnableWebPPreviews && strings.Contains(r.Header.Get("Accept"), "image/webp") {
+		if data, err := app.ReadFile(info.WebPPreviewPath); err == nil {
+			return data, nil
+		}
+	}
+
+	return app.ReadFile(info.PreviewPath)
+}
+
 func getFileInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	info, err := getFileInfoForRequest(c, r, true)
 	if err != nil {
@@ -216,12 +244,13 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.Path); err != nil {
+	if data, err := readFileForDownload(c, w, info); err != nil {
 		c.Err = err
-		c.Err.StatusCode = http.StatusNotFound
 	} else if err := writeFileResponse(info.Name, info.MimeType, data, w, r); err != nil {
 		c.Err = err
 		return
+	} else {
+		app.RecordFileAccess(info.Id, c.Session.UserId, c.IpAddress, model.FILE_ACCESS_LINK_TYPE_PUBLIC)
 	}
 }
 
@@ -262,9 +291,38 @@ func getFileInfoForRequest(c *Context, r *http.Request, requireFileVisible bool)
 		}
 	}
 
+	// files that have been restricted to certain users can't be accessed by anyone else even if they can see the post
+	if requireFileVisible && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		if allowed, err := app.HasFileAccess(c.Session.UserId, info); err != nil {
+			return nil, err
+		} else if !allowed {
+			err := model.NewLocAppError("getFileInfoForRequest", "api.file.get_file_info_for_request.restricted.app_error", nil, "file_id="+fileId)
+			err.StatusCode = http.StatusForbidden
+			return nil, err
+		}
+	} else if !requireFileVisible {
+		if err := checkFileNotRestricted(info); err != nil {
+			return nil, err
+		}
+	}
+
 	return info, nil
 }
 
+// checkFileNotRestricted returns an error for files that have been restricted to certain users, since they can't be
+// shared through public links.
+func checkFileNotRestricted(info *model.FileInfo) *model.AppError {
+	if acl, err := app.GetFileAcl(info.Id); err != nil {
+		return err
+	} else if acl != nil {
+		err := model.NewLocAppError("checkFileNotRestricted", "api.file.get_public_link.restricted.app_error", nil, "file_id="+info.Id)
+		err.StatusCode = http.StatusForbidden
+		return err
+	}
+
+	return nil
+}
+
 func getPublicFileOld(c *Context, w http.ResponseWriter, r *http.Request) {
 	if len(utils.Cfg.FileSettings.DriverName) == 0 {
 		c.Err = model.NewLocAppError("getPublicFile", "api.file.get_public_file_old.storage.app_error", nil, "")
@@ -315,13 +373,45 @@ func getPublicFileOld(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.Path); err != nil {
+	if err := checkFileNotRestricted(info); err != nil {
+		c.Err = err
+		return
+	}
+
+	if data, err := readFileForDownload(c, w, info); err != nil {
 		c.Err = err
-		c.Err.StatusCode = http.StatusNotFound
 	} else if err := writeFileResponse(info.Name, info.MimeType, data, w, r); err != nil {
 		c.Err = err
 		return
+	} else {
+		app.RecordFileAccess(info.Id, c.Session.UserId, c.IpAddress, model.FILE_ACCESS_LINK_TYPE_PUBLIC)
+	}
+}
+
+// readFileForDownload reads the original copy of a file that's being downloaded. If enabled, the file is checked against
+// the checksum recorded when it was uploaded and the checksum is sent to the client in a Content-Digest header.
+func readFileForDownload(c *Context, w http.ResponseWriter, info *model.FileInfo) ([]byte, *model.AppError) {
+	data, err := app.ReadFile(info.Path)
+	if err != nil {
+		err.StatusCode = http.StatusNotFound
+		return nil, err
+	}
+
+	if *utils.Cfg.FileSettings.VerifyChecksums {
+		if err := app.VerifyFileChecksum(info, data); err != nil {
+			// the file storage is likely corrupted, so make sure that admins can find out which files were affected
+			c.LogAudit("fail - checksum mismatch file_id=" + info.Id + ", path=" + info.Path)
+			return nil, err
+		}
+	}
+
+	if *utils.Cfg.FileSettings.SendContentDigest {
+		if digest := info.ContentDigest(); len(digest) > 0 {
+			w.Header().Set("Content-Digest", digest)
+		}
 	}
+
+	return data, nil
 }
 
 func writeFileResponse(filename string, contentType string, bytes []byte, w http.ResponseWriter, r *http.Request) *model.AppError {
@@ -364,5 +454,98 @@ func getPublicLink(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkFileNotRestricted(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	w.Write([]byte(model.StringToJson(app.GeneratePublicLink(c.GetSiteURL(), info))))
 }
+
+func getFileAcl(c *Context, w http.ResponseWriter, r *http.Request) {
+	info, err := getFileInfoForAclRequest(c, r)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if acl, err := app.GetFileAcl(info.Id); err != nil {
+		c.Err = err
+		return
+	} else if acl == nil {
+		w.Write([]byte((&model.FileAcl{FileId: info.Id, UserIds: model.StringArray{}, ChannelIds: model.StringArray{}}).ToJson()))
+	} else {
+		w.Write([]byte(acl.ToJson()))
+	}
+}
+
+func updateFileAcl(c *Context, w http.ResponseWriter, r *http.Request) {
+	info, err := getFileInfoForAclRequest(c, r)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	acl := model.FileAclFromJson(r.Body)
+	if acl == nil {
+		c.SetInvalidParam("updateFileAcl", "acl")
+		return
+	}
+
+	acl.FileId = info.Id
+
+	// files can only be shared with channels that the user can see so that they can't be used to find out about others
+	for _, channelId := range acl.ChannelIds {
+		if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+			c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+			return
+		}
+	}
+
+	if acl, err := app.UpdateFileAcl(acl); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("file_id=" + info.Id)
+		w.Write([]byte(acl.ToJson()))
+	}
+}
+
+func deleteFileAcl(c *Context, w http.ResponseWriter, r *http.Request) {
+	info, err := getFileInfoForAclRequest(c, r)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if err := app.DeleteFileAcl(info.Id); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("file_id=" + info.Id)
+	ReturnStatusOK(w)
+}
+
+// getFileInfoForAclRequest returns the file that a request to manage who can access it is for. Only the user who
+// uploaded the file and system admins can do so.
+func getFileInfoForAclRequest(c *Context, r *http.Request) (*model.FileInfo, *model.AppError) {
+	fileId := mux.Vars(r)["file_id"]
+	if len(fileId) != 26 {
+		return nil, NewInvalidParamError("getFileInfoForAclRequest", "file_id")
+	}
+
+	var info *model.FileInfo
+	if result := <-app.Srv.Store.FileInfo().Get(fileId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		info = result.Data.(*model.FileInfo)
+	}
+
+	if info.CreatorId != c.Session.UserId && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return nil, c.Err
+	}
+
+	return info, nil
+}
@@ -6,6 +6,7 @@ package api
 import (
 	"bytes"
 	"fmt"
+	"image"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
@@ -258,6 +260,161 @@ func TestGetFile(t *testing.T) {
 	}
 }
 
+func TestGetFileChecksum(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	sendContentDigest := *utils.Cfg.FileSettings.SendContentDigest
+	verifyChecksums := *utils.Cfg.FileSettings.VerifyChecksums
+	defer func() {
+		*utils.Cfg.FileSettings.SendContentDigest = sendContentDigest
+		*utils.Cfg.FileSettings.VerifyChecksums = verifyChecksums
+	}()
+
+	Client := th.BasicClient
+
+	data, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileId := Client.MustGeneric(Client.UploadPostAttachment(data, th.BasicChannel.Id, "test.png")).(*model.FileUploadResponse).FileInfos[0].Id
+
+	if info, err := Client.GetFileInfo(fileId); err != nil {
+		t.Fatal(err)
+	} else if info.Checksum != model.GetFileChecksum(data) {
+		t.Fatal("should've returned the checksum of the uploaded file")
+	}
+
+	info := store.Must(app.Srv.Store.FileInfo().Get(fileId)).(*model.FileInfo)
+	defer cleanupTestFile(info)
+
+	*utils.Cfg.FileSettings.SendContentDigest = false
+	if r, err := Client.DoApiGet(Client.GetFileRoute(fileId)+"/get", "", ""); err != nil {
+		t.Fatal(err)
+	} else {
+		r.Body.Close()
+
+		if r.Header.Get("Content-Digest") != "" {
+			t.Fatal("shouldn't have sent a Content-Digest header")
+		}
+	}
+
+	*utils.Cfg.FileSettings.SendContentDigest = true
+	if r, err := Client.DoApiGet(Client.GetFileRoute(fileId)+"/get", "", ""); err != nil {
+		t.Fatal(err)
+	} else {
+		r.Body.Close()
+
+		if r.Header.Get("Content-Digest") != info.ContentDigest() {
+			t.Fatal("should've sent the file's checksum in a Content-Digest header")
+		}
+	}
+
+	// corrupt the stored file
+	if err := app.WriteFile([]byte("corrupted"), info.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	*utils.Cfg.FileSettings.VerifyChecksums = false
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal("shouldn't have checked the file when verification is disabled")
+	} else {
+		body.Close()
+	}
+
+	*utils.Cfg.FileSettings.VerifyChecksums = true
+	if _, err := Client.GetFile(fileId); err == nil {
+		t.Fatal("should've failed to download a corrupted file")
+	} else if err.Id != "app.file.verify_checksum.mismatch.app_error" {
+		t.Fatal("should've failed because of a checksum mismatch")
+	}
+
+	found := false
+	audits := store.Must(app.Srv.Store.Audit().Get(th.BasicUser.Id, 100)).(model.Audits)
+	for _, audit := range audits {
+		if strings.Contains(audit.ExtraInfo, "checksum mismatch file_id="+fileId) {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should've recorded the checksum mismatch in the audit log")
+	}
+}
+
+func TestFileAccessLog(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	enableFileAccessLog := *utils.Cfg.ComplianceSettings.EnableFileAccessLog
+	defer func() {
+		*utils.Cfg.ComplianceSettings.EnableFileAccessLog = enableFileAccessLog
+	}()
+	*utils.Cfg.ComplianceSettings.EnableFileAccessLog = true
+
+	Client := th.BasicClient
+
+	var fileId string
+	data, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	} else {
+		fileId = Client.MustGeneric(Client.UploadPostAttachment(data, th.BasicChannel.Id, "test.png")).(*model.FileUploadResponse).FileInfos[0].Id
+	}
+
+	// Wait a bit for files to ready
+	time.Sleep(2 * time.Second)
+
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal(err)
+	} else {
+		body.Close()
+	}
+
+	query := &model.FileAccessQuery{FileId: fileId, Limit: 10}
+
+	if _, err := Client.ExportFileAccessLog(query); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if accesses, err := th.SystemAdminClient.ExportFileAccessLog(query); err != nil {
+		t.Fatal(err)
+	} else if len(accesses) != 1 {
+		t.Fatal("should have recorded the download")
+	} else if accesses[0].UserId != th.BasicUser.Id || accesses[0].LinkType != model.FILE_ACCESS_LINK_TYPE_DOWNLOAD || accesses[0].IpAddress == "" {
+		t.Fatal("recorded the wrong access")
+	}
+
+	*utils.Cfg.ComplianceSettings.EnableFileAccessLog = false
+
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal(err)
+	} else {
+		body.Close()
+	}
+
+	if accesses, err := th.SystemAdminClient.ExportFileAccessLog(query); err != nil {
+		t.Fatal(err)
+	} else if len(accesses) != 1 {
+		t.Fatal("shouldn't have recorded the download while the log is disabled")
+	}
+
+	if _, err := th.SystemAdminClient.ExportFileAccessLog(&model.FileAccessQuery{Limit: model.FILE_ACCESS_QUERY_MAX_LIMIT + 1}); err == nil {
+		t.Fatal("should have failed with an invalid query")
+	}
+
+	if err := cleanupTestFile(store.Must(app.Srv.Store.FileInfo().Get(fileId)).(*model.FileInfo)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGetFileThumbnail(t *testing.T) {
 	th := Setup().InitBasic()
 
@@ -370,6 +527,78 @@ func TestGetFilePreview(t *testing.T) {
 	}
 }
 
+type fakeImageEncoder struct{}
+
+func (fakeImageEncoder) EncodeWebP(img image.Image) ([]byte, *model.AppError) {
+	return []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), nil
+}
+
+func TestGetFilePreviewWebP(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	enableWebPPreviews := *utils.Cfg.FileSettings.EnableWebPPreviews
+	defer func() {
+		*utils.Cfg.FileSettings.EnableWebPPreviews = enableWebPPreviews
+		einterfaces.RegisterImageEncoderInterface(nil)
+	}()
+	*utils.Cfg.FileSettings.EnableWebPPreviews = true
+	einterfaces.RegisterImageEncoderInterface(fakeImageEncoder{})
+
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	var info *model.FileInfo
+	data, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	} else {
+		info = Client.MustGeneric(Client.UploadPostAttachment(data, channel.Id, "test.png")).(*model.FileUploadResponse).FileInfos[0]
+	}
+
+	// Wait a bit for files to ready
+	time.Sleep(2 * time.Second)
+
+	info = store.Must(app.Srv.Store.FileInfo().Get(info.Id)).(*model.FileInfo)
+	if info.WebPPreviewPath == "" {
+		t.Fatal("should have a WebP preview")
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "image/webp,image/*,*/*;q=0.8")
+
+	if preview, err := readFilePreview(info, r); err != nil {
+		t.Fatal(err)
+	} else if http.DetectContentType(preview) != "image/webp" {
+		t.Fatal("should have received the WebP preview", http.DetectContentType(preview))
+	}
+
+	r.Header.Set("Accept", "image/*,*/*;q=0.8")
+
+	if preview, err := readFilePreview(info, r); err != nil {
+		t.Fatal(err)
+	} else if http.DetectContentType(preview) != "image/jpeg" {
+		t.Fatal("should have received the JPEG preview when the client doesn't support WebP", http.DetectContentType(preview))
+	}
+
+	if body, err := Client.GetFilePreview(info.Id); err != nil {
+		t.Fatal(err)
+	} else {
+		body.Close()
+	}
+
+	if err := app.RemoveFile(info.WebPPreviewPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cleanupTestFile(info); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGetPublicFile(t *testing.T) {
 	th := Setup().InitBasic()
 
@@ -582,6 +811,136 @@ func TestGetPublicLink(t *testing.T) {
 	}
 }
 
+func TestFileAcl(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	enablePublicLink := utils.Cfg.FileSettings.EnablePublicLink
+	defer func() {
+		utils.Cfg.FileSettings.EnablePublicLink = enablePublicLink
+	}()
+	utils.Cfg.FileSettings.EnablePublicLink = true
+
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	var fileId string
+	data, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	} else {
+		fileId = Client.MustGeneric(Client.UploadPostAttachment(data, channel.Id, "test.png")).(*model.FileUploadResponse).FileInfos[0].Id
+	}
+
+	// Hacky way to assign file to a post (usually would be done by CreatePost call)
+	store.Must(app.Srv.Store.FileInfo().AttachToPost(fileId, th.BasicPost.Id))
+
+	// Wait a bit for files to ready
+	time.Sleep(2 * time.Second)
+
+	if acl, err := Client.GetFileAcl(fileId); err != nil {
+		t.Fatal(err)
+	} else if len(acl.UserIds) != 0 || len(acl.ChannelIds) != 0 {
+		t.Fatal("file shouldn't be restricted yet")
+	}
+
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel.Id))
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{UserIds: model.StringArray{th.BasicUser2.Id}}); err == nil {
+		t.Fatal("other user shouldn't be able to restrict the file")
+	}
+
+	otherChannel := Client.Must(Client.CreateChannel(&model.Channel{DisplayName: "Other", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: th.BasicTeam.Id})).Data.(*model.Channel)
+
+	th.LoginBasic()
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{ChannelIds: model.StringArray{otherChannel.Id}}); err == nil {
+		t.Fatal("shouldn't be able to share the file with a channel that the owner isn't a member of")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have been forbidden", err.StatusCode)
+	}
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{ChannelIds: model.StringArray{model.NewId()}}); err == nil {
+		t.Fatal("shouldn't be able to share the file with a channel that doesn't exist")
+	}
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{UserIds: model.StringArray{model.NewId()}}); err == nil {
+		t.Fatal("shouldn't be able to share the file with a user that doesn't exist")
+	}
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{UserIds: model.StringArray{th.BasicUser.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.GetPublicLink(fileId); err == nil {
+		t.Fatal("shouldn't be able to get a public link for a restricted file")
+	}
+
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal("owner should still be able to get the file", err)
+	} else {
+		body.Close()
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.GetFile(fileId); err == nil {
+		t.Fatal("other user shouldn't be able to get a restricted file")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have been forbidden", err.StatusCode)
+	}
+
+	if _, err := Client.GetFileThumbnail(fileId); err == nil {
+		t.Fatal("other user shouldn't be able to get the thumbnail of a restricted file")
+	}
+
+	th.LoginBasic()
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{UserIds: model.StringArray{th.BasicUser.Id}, ChannelIds: model.StringArray{channel.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if acl, err := Client.GetFileAcl(fileId); err != nil {
+		t.Fatal(err)
+	} else if acl.FileId != fileId || len(acl.UserIds) != 1 || len(acl.ChannelIds) != 1 || acl.ChannelIds[0] != channel.Id {
+		t.Fatal("received the wrong access control list")
+	}
+
+	th.LoginBasic2()
+
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal("members of a channel that the file is shared with should be able to get it", err)
+	} else {
+		body.Close()
+	}
+
+	th.LoginBasic()
+
+	if _, err := Client.UpdateFileAcl(fileId, &model.FileAcl{UserIds: model.StringArray{th.BasicUser.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.DeleteFileAcl(fileId); err != nil {
+		t.Fatal(err)
+	}
+
+	th.LoginBasic2()
+
+	if body, err := Client.GetFile(fileId); err != nil {
+		t.Fatal("other user should be able to get the file once it's no longer restricted", err)
+	} else {
+		body.Close()
+	}
+
+	if err := cleanupTestFile(store.Must(app.Srv.Store.FileInfo().Get(fileId)).(*model.FileInfo)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestMigrateFilenamesToFileInfos(t *testing.T) {
 	th := Setup().InitBasic()
 
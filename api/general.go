@@ -21,6 +21,7 @@ func InitGeneral() {
 	BaseRoutes.General.Handle("/client_props", ApiAppHandler(getClientConfig)).Methods("GET")
 	BaseRoutes.General.Handle("/log_client", ApiAppHandler(logClient)).Methods("POST")
 	BaseRoutes.General.Handle("/ping", ApiAppHandler(ping)).Methods("GET")
+	BaseRoutes.General.Handle("/database_health", ApiAppHandler(databaseHealth)).Methods("GET")
 
 	app.Srv.WebSocketRouter.Handle("ping", ApiWebSocketHandler(webSocketPing))
 }
@@ -73,6 +74,14 @@ func ping(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(m)))
 }
 
+// databaseHealth reports the result of the most recent database health check without the errors that it found. It
+// always succeeds so that load balancers checking it don't stop sending requests to every server at once because of a
+// problem with the database that they share. The admin route fails while the database is unhealthy instead.
+func databaseHealth(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(app.GetLatestDatabaseHealth().WithoutErrors().ToJson()))
+}
+
 func webSocketPing(req *model.WebSocketRequest) (map[string]interface{}, *model.AppError) {
 	data := map[string]interface{}{}
 	data["text"] = "pong"
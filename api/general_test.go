@@ -4,7 +4,11 @@
 package api
 
 import (
+	"net/http"
 	"testing"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
 )
 
 func TestGetClientProperties(t *testing.T) {
@@ -38,3 +42,47 @@ func TestGetPing(t *testing.T) {
 		}
 	}
 }
+
+func TestDatabaseHealth(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+
+	if health, err := th.BasicClient.GetDatabaseHealth(); err != nil {
+		t.Fatal(err)
+	} else if health.Status != model.DATABASE_HEALTH_OK {
+		t.Fatal("database should be healthy")
+	}
+
+	if _, err := th.BasicClient.CheckDatabaseHealth(); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if health, err := th.SystemAdminClient.CheckDatabaseHealth(); err != nil {
+		t.Fatal(err)
+	} else if health.Status != model.DATABASE_HEALTH_OK || !health.Master.Healthy || health.SchemaVersion != model.CurrentVersion {
+		t.Fatal("database should be healthy", health.ToJson())
+	}
+
+	if result := <-app.Srv.Store.System().Update(&model.System{Name: "Version", Value: "3.0.0"}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	defer func() {
+		<-app.Srv.Store.System().Update(&model.System{Name: "Version", Value: model.CurrentVersion})
+		app.CheckDatabaseHealth()
+	}()
+
+	if health, err := th.SystemAdminClient.CheckDatabaseHealth(); err == nil {
+		t.Fatal("should have failed while the database is unhealthy")
+	} else if err.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("should have returned a 503", err.StatusCode)
+	} else if health.Status != model.DATABASE_HEALTH_UNHEALTHY || health.Master.Healthy || len(health.Master.Errors) != 1 {
+		t.Fatal("should have found that the schema is out of date", health.ToJson())
+	}
+
+	if health, err := th.BasicClient.GetDatabaseHealth(); err != nil {
+		t.Fatal("shouldn't have failed while the database is unhealthy", err)
+	} else if health.Status != model.DATABASE_HEALTH_UNHEALTHY || health.Master.Healthy {
+		t.Fatal("should have reported the database as unhealthy", health.ToJson())
+	} else if len(health.Master.Errors) != 0 {
+		t.Fatal("shouldn't have included the errors", health.ToJson())
+	}
+}
@@ -34,6 +34,7 @@ func InitOAuth() {
 	BaseRoutes.OAuth.Handle("/delete", ApiUserRequired(deleteOAuthApp)).Methods("POST")
 	BaseRoutes.OAuth.Handle("/{id:[A-Za-z0-9]+}/deauthorize", ApiUserRequired(deauthorizeOAuthApp)).Methods("POST")
 	BaseRoutes.OAuth.Handle("/{id:[A-Za-z0-9]+}/regen_secret", ApiUserRequired(regenerateOAuthSecret)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/{id:[A-Za-z0-9]+}/revoke_tokens", ApiUserRequired(revokeOAuthAppTokens)).Methods("POST")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z0-9]+}/complete", AppHandlerIndependent(completeOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z0-9]+}/login", AppHandlerIndependent(loginWithOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z0-9]+}/signup", AppHandlerIndependent(signupWithOAuth)).Methods("GET")
@@ -565,6 +566,11 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		<-app.Srv.Store.OAuth().RemoveAuthData(authData.Code)
 	} else {
 		// when grantType is refresh_token
+		if result := <-app.Srv.Store.OAuth().GetRevokedToken(refreshToken); result.Err == nil {
+			revokeReusedRefreshToken(c, result.Data.(*model.OAuthRevokedToken), clientId)
+			return
+		}
+
 		if result := <-app.Srv.Store.OAuth().GetAccessDataByRefreshToken(refreshToken); result.Err != nil {
 			c.LogAudit("fail - refresh token is invalid")
 			c.Err = model.NewLocAppError("getAccessToken", "api.oauth.get_access_token.refresh_token.app_error", nil, "")
@@ -573,6 +579,26 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 			accessData = result.Data.(*model.AccessData)
 		}
 
+		if accessData.ClientId != clientId {
+			c.LogAudit("fail - refresh token was issued to another app")
+			c.Err = model.NewLocAppError("getAccessToken", "api.oauth.get_access_token.refresh_token.app_error", nil, "")
+			return
+		}
+
+		// Refresh tokens are single use, so claim this one before exchanging it. If another request
+		// claimed it first, it's being used by two different clients.
+		revoked := &model.OAuthRevokedToken{Token: refreshToken, ClientId: accessData.ClientId, UserId: accessData.UserId, ExpiresAt: accessData.ExpiresAt}
+		if result := <-app.Srv.Store.OAuth().SaveRevokedToken(revoked); result.Err != nil {
+			if result.Err.Id == "store.sql_oauth.save_revoked_token.exists.app_error" {
+				revokeReusedRefreshToken(c, revoked, clientId)
+			} else {
+				c.Err = model.NewLocAppError("getAccessToken", "api.oauth.get_access_token.internal_saving.app_error", nil, result.Err.Error())
+			}
+			return
+		}
+
+		accessData.RefreshToken = model.NewId()
+
 		uchan := app.Srv.Store.User().Get(accessData.UserId)
 		if result := <-uchan; result.Err != nil {
 			c.Err = model.NewLocAppError("getAccessToken", "api.oauth.get_access_token.internal_user.app_error", nil, "")
@@ -598,6 +624,19 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(accessRsp.ToJson()))
 }
 
+// revokeReusedRefreshToken handles a refresh token that has already been exchanged. Since it must
+// have leaked, the access that was granted with it is revoked for whoever currently holds it.
+func revokeReusedRefreshToken(c *Context, revoked *model.OAuthRevokedToken, clientId string) {
+	if revoked.ClientId == clientId {
+		if err := app.RevokeAccessDataByUserForApp(revoked.UserId, revoked.ClientId); err != nil {
+			l4g.Error(err.Error())
+		}
+	}
+
+	c.LogAuditWithUserId(revoked.UserId, "fail - refresh token has already been used")
+	c.Err = model.NewLocAppError("getAccessToken", "api.oauth.get_access_token.refresh_token.app_error", nil, "")
+}
+
 func loginWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	service := params["service"]
@@ -647,11 +686,14 @@ func getTeamIdFromQuery(query url.Values) (string, *model.AppError) {
 
 		return props["id"], nil
 	} else if len(inviteId) > 0 {
-		if result := <-app.Srv.Store.Team().GetByInviteId(inviteId); result.Err != nil {
+		if invitation, link, team, err := app.GetInvite(inviteId); err != nil {
 			// soft fail, so we still create user but don't auto-join team
-			l4g.Error("%v", result.Err)
+			l4g.Error("%v", err)
+		} else if err := app.UseInvite(invitation, link, ""); err != nil {
+			// the user's email address isn't known yet, so only unrestricted invitations can be used here
+			l4g.Error("%v", err)
 		} else {
-			return result.Data.(*model.Team).Id, nil
+			return team.Id, nil
 		}
 	}
 
@@ -914,7 +956,7 @@ func deauthorizeOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
 		accessData := result.Data.([]*model.AccessData)
 
 		for _, a := range accessData {
-			if err := app.RevokeAccessToken(a.Token); err != nil {
+			if err := app.RevokeAccessData(a); err != nil {
 				c.Err = err
 				return
 			}
@@ -975,6 +1017,48 @@ func regenerateOAuthSecret(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func revokeOAuthAppTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("revokeOAuthAppTokens", "api.oauth.allow_oauth.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_OAUTH) {
+		c.Err = model.NewLocAppError("revokeOAuthAppTokens", "api.command.admin_only.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	params := mux.Vars(r)
+	id := params["id"]
+
+	if len(id) == 0 {
+		c.SetInvalidParam("revokeOAuthAppTokens", "id")
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	if result := <-app.Srv.Store.OAuth().GetApp(id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if c.Session.UserId != result.Data.(*model.OAuthApp).CreatorId && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM_WIDE_OAUTH) {
+		c.LogAudit("fail - inappropriate permissions")
+		c.Err = model.NewLocAppError("revokeOAuthAppTokens", "api.oauth.revoke_tokens.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if err := app.RevokeAllAccessDataForApp(id); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("success")
+	ReturnStatusOK(w)
+}
+
 func newSession(appName string, user *model.User) (*model.Session, *model.AppError) {
 	// set new token an session
 	session := &model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true}
@@ -1010,9 +1094,10 @@ func newSessionUpdateToken(appName string, accessData *model.AccessData, user *m
 		return nil, model.NewLocAppError("getAccessToken", "web.get_access_token.internal_saving.app_error", nil, "")
 	}
 	accessRsp := &model.AccessResponse{
-		AccessToken: session.Token,
-		TokenType:   model.ACCESS_TOKEN_TYPE,
-		ExpiresIn:   int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24),
+		AccessToken:  session.Token,
+		TokenType:    model.ACCESS_TOKEN_TYPE,
+		RefreshToken: accessData.RefreshToken,
+		ExpiresIn:    int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24),
 	}
 
 	return accessRsp, nil
@@ -4,7 +4,9 @@
 package api
 
 import (
+	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
 	"net/url"
 	"testing"
@@ -335,3 +337,74 @@ func TestOAuthDeleteApp(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRevokeOAuthAppTokens(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+	AdminClient := th.SystemAdminClient
+
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	oauthApp := &model.OAuthApp{Name: "TestApp7" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	oauthApp = AdminClient.Must(AdminClient.RegisterApp(oauthApp)).Data.(*model.OAuthApp)
+
+	accessData := createOAuthAccessData(th.BasicUser, oauthApp)
+
+	if _, err := Client.RevokeOAuthAppTokens(oauthApp.Id); err == nil {
+		t.Fatal("should have failed - not the creator of the app")
+	}
+
+	if _, err := AdminClient.RevokeOAuthAppTokens(oauthApp.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-app.Srv.Store.OAuth().GetAccessData(accessData.Token); result.Err == nil {
+		t.Fatal("should have removed the access token")
+	}
+
+	if result := <-app.Srv.Store.OAuth().GetRevokedToken(accessData.RefreshToken); result.Err != nil {
+		t.Fatal("should have added the refresh token to the revocation list")
+	}
+}
+
+func TestRevokeUserOAuthTokens(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+	AdminClient := th.SystemAdminClient
+
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	oauthApp := &model.OAuthApp{Name: "TestApp8" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	oauthApp = AdminClient.Must(AdminClient.RegisterApp(oauthApp)).Data.(*model.OAuthApp)
+
+	accessData := createOAuthAccessData(th.BasicUser2, oauthApp)
+
+	if _, err := Client.RevokeUserOAuthTokens(th.BasicUser2.Id); err == nil {
+		t.Fatal("should have failed - can't revoke another user's tokens")
+	}
+
+	if _, err := AdminClient.RevokeUserOAuthTokens(th.BasicUser2.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-app.Srv.Store.OAuth().GetAccessData(accessData.Token); result.Err == nil {
+		t.Fatal("should have removed the access token")
+	}
+
+	if _, err := Client.RevokeUserOAuthTokens(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createOAuthAccessData(user *model.User, oauthApp *model.OAuthApp) *model.AccessData {
+	session := store.Must(app.Srv.Store.Session().Save(&model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true})).(*model.Session)
+
+	return store.Must(app.Srv.Store.OAuth().SaveAccessData(&model.AccessData{
+		ClientId:     oauthApp.Id,
+		UserId:       user.Id,
+		Token:        session.Token,
+		RefreshToken: model.NewId(),
+		RedirectUri:  oauthApp.CallbackUrls[0],
+		ExpiresAt:    session.ExpiresAt,
+	})).(*model.AccessData)
+}
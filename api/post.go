@@ -28,12 +28,16 @@ func InitPost() {
 	BaseRoutes.Posts.Handle("/update", ApiUserRequiredActivity(updatePost, true)).Methods("POST")
 	BaseRoutes.Posts.Handle("/page/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getPosts)).Methods("GET")
 	BaseRoutes.Posts.Handle("/since/{time:[0-9]+}", ApiUserRequired(getPostsSince)).Methods("GET")
+	BaseRoutes.Posts.Handle("/threads", ApiUserRequired(getFollowedThreads)).Methods("GET")
 
 	BaseRoutes.NeedPost.Handle("/get", ApiUserRequired(getPost)).Methods("GET")
 	BaseRoutes.NeedPost.Handle("/delete", ApiUserRequiredActivity(deletePost, true)).Methods("POST")
 	BaseRoutes.NeedPost.Handle("/before/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsBefore)).Methods("GET")
 	BaseRoutes.NeedPost.Handle("/after/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsAfter)).Methods("GET")
 	BaseRoutes.NeedPost.Handle("/get_file_infos", ApiUserRequired(getFileInfosForPost)).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/thread/read", ApiUserRequired(markThreadRead)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/forward", ApiUserRequiredActivity(forwardPost, true)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/translate", ApiUserRequired(translatePost)).Methods("GET")
 }
 
 func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -106,7 +110,7 @@ func getFlaggedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.Err = err
 		return
 	} else {
-		w.Write([]byte(posts.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(posts, c.Session.UserId).ToJson()))
 	}
 }
 
@@ -119,10 +123,13 @@ func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	offset, err := strconv.Atoi(params["offset"])
-	if err != nil {
+	// Older posts are paged through using the earliest post that the client has as a cursor rather than with an offset
+	if offset, err := strconv.Atoi(params["offset"]); err != nil {
 		c.SetInvalidParam("getPosts", "offset")
 		return
+	} else if offset != 0 {
+		c.Err = model.NewAppError("getPosts", "api.post.get_posts.offset.app_error", nil, "offset="+params["offset"], http.StatusBadRequest)
+		return
 	}
 
 	limit, err := strconv.Atoi(params["limit"])
@@ -142,12 +149,12 @@ func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if list, err := app.GetPosts(id, offset, limit); err != nil {
+	if list, err := app.GetPosts(id, limit); err != nil {
 		c.Err = err
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 
 }
@@ -176,7 +183,7 @@ func getPostsSince(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.Err = err
 		return
 	} else {
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 
 }
@@ -214,7 +221,7 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 }
 
@@ -247,7 +254,7 @@ func getPostById(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 }
 
@@ -272,7 +279,7 @@ func getPermalinkTmp(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 }
 
@@ -346,10 +353,12 @@ func getPostsBeforeOrAfter(c *Context, w http.ResponseWriter, r *http.Request, b
 		return
 	}
 
-	offset, err := strconv.Atoi(params["offset"])
-	if err != nil || offset < 0 {
+	if offset, err := strconv.Atoi(params["offset"]); err != nil || offset < 0 {
 		c.SetInvalidParam("getPostsBeforeOrAfter", "offset")
 		return
+	} else if offset != 0 {
+		c.Err = model.NewAppError("getPostsBeforeOrAfter", "api.post.get_posts.offset.app_error", nil, "offset="+params["offset"], http.StatusBadRequest)
+		return
 	}
 
 	if !app.SessionHasPermissionToChannel(c.Session, id, model.PERMISSION_READ_CHANNEL) {
@@ -364,12 +373,12 @@ func getPostsBeforeOrAfter(c *Context, w http.ResponseWriter, r *http.Request, b
 		return
 	}
 
-	if list, err := app.GetPostsAroundPost(postId, id, offset, numPosts, before); err != nil {
+	if list, err := app.GetPostsAroundPost(postId, id, numPosts, before); err != nil {
 		c.Err = err
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.AddPermalinkPreviewsToPostList(list, c.Session.UserId).ToJson()))
 	}
 }
 
@@ -394,7 +403,7 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	w.Write([]byte(posts.ToJson()))
+	w.Write([]byte(app.AddPermalinkPreviewsToPostList(posts, c.Session.UserId).ToJson()))
 }
 
 func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -429,6 +438,113 @@ func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getFollowedThreads(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("getFollowedThreads", "channelId")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	if memberships, err := app.GetFollowedThreads(c.Session.UserId, channelId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.ThreadMembershipsToJson(memberships)))
+	}
+}
+
+func markThreadRead(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("markThreadRead", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("markThreadRead", "postId")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	if membership, err := app.MarkThreadRead(postId, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(membership.ToJson()))
+	}
+}
+
+func forwardPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("forwardPost", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("forwardPost", "postId")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	if !app.SessionHasPermissionToChannelByPost(c.Session, postId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	// posts can be forwarded to a user, in which case they're sent in a direct message
+	toChannelId := props["channel_id"]
+	if toUserId := props["user_id"]; len(toUserId) == 26 && len(toChannelId) == 0 {
+		if channel, err := app.CreateDirectChannel(c.Session.UserId, toUserId); err != nil {
+			c.Err = err
+			return
+		} else {
+			toChannelId = channel.Id
+		}
+	}
+
+	if len(toChannelId) != 26 {
+		c.SetInvalidParam("forwardPost", "channel_id")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, toChannelId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	if post, err := app.ForwardPost(postId, c.Session.UserId, toChannelId, c.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("post_id=" + postId + ", channel_id=" + toChannelId)
+		w.Write([]byte(post.ToJson()))
+	}
+}
+
 func getOpenGraphMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
 	props := model.StringInterfaceFromJson(r.Body)
 
@@ -447,3 +563,57 @@ func getOpenGraphMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(ogJson)
 }
+
+func translatePost(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("translatePost", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("translatePost", "postId")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, channelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	post, err := app.GetSinglePost(postId)
+	if err != nil {
+		c.Err = err
+		return
+	} else if post.ChannelId != channelId {
+		c.SetInvalidParam("translatePost", "postId")
+		return
+	}
+
+	// posts are translated into the user's own language unless they ask for another one
+	locale := r.URL.Query().Get("locale")
+	if len(locale) == 0 {
+		if user, err := app.GetUser(c.Session.UserId); err != nil {
+			c.Err = err
+			return
+		} else {
+			locale = user.Locale
+		}
+	}
+
+	// each locale that a post is translated into is saved, so only the ones that the server supports are allowed
+	if _, ok := utils.GetSupportedLocales()[locale]; !ok {
+		c.SetInvalidParam("translatePost", "locale")
+		return
+	}
+
+	if translation, err := app.TranslatePost(post, locale); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(translation.ToJson()))
+	}
+}
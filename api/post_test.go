@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
@@ -136,7 +137,7 @@ func TestCreatePost(t *testing.T) {
 	} else if rpost9 := resp.Data.(*model.Post); len(rpost9.FileIds) != 3 {
 		t.Fatal("post should have 3 files")
 	} else {
-		infos := store.Must(app.Srv.Store.FileInfo().GetForPost(rpost9.Id)).([]*model.FileInfo)
+		infos := store.Must(app.Srv.Store.FileInfo().GetForPost(rpost9.Id, false)).([]*model.FileInfo)
 
 		if len(infos) != 3 {
 			t.Fatal("should've attached all 3 files to post")
@@ -478,7 +479,13 @@ func TestGetPosts(t *testing.T) {
 		t.Fatal("wrong size")
 	}
 
-	r2 := Client.Must(Client.GetPosts(channel1.Id, 2, 2, "")).Data.(*model.PostList)
+	if _, err := Client.GetPosts(channel1.Id, 2, 2, ""); err == nil {
+		t.Fatal("should have failed to page with an offset")
+	} else if err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have returned a bad request")
+	}
+
+	r2 := Client.Must(Client.GetPostsBefore(channel1.Id, r1.Order[1], 0, 2, "")).Data.(*model.PostList)
 
 	if r2.Order[0] != post2.Id {
 		t.Fatal("wrong order")
@@ -598,6 +605,12 @@ func TestGetPostsBeforeAfter(t *testing.T) {
 		t.Fatal("wrong size")
 	}
 
+	if _, err := Client.GetPostsBefore(channel1.Id, post1a1.Id, 1, 10, ""); err == nil {
+		t.Fatal("should have failed to page with an offset")
+	} else if err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have returned a bad request")
+	}
+
 	r2 := Client.Must(Client.GetPostsAfter(channel1.Id, post3a1.Id, 0, 3, "")).Data.(*model.PostList)
 
 	if len(r2.Posts) != 0 {
@@ -1329,3 +1342,238 @@ func TestGetOpenGraphMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestThreadUnreads(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	root := th.BasicPost
+
+	WebSocketClient, err := th.CreateWebSocketClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer WebSocketClient.Close()
+	WebSocketClient.Listen()
+
+	time.Sleep(300 * time.Millisecond)
+	if resp := <-WebSocketClient.ResponseChannel; resp.Status != model.STATUS_OK {
+		t.Fatal("should have responded OK to authentication challenge")
+	}
+
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel.Id))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "reply", RootId: root.Id}))
+
+	// the user who replied follows the thread with nothing unread
+	if memberships, err := Client.GetFollowedThreads(channel.Id); err != nil {
+		t.Fatal(err)
+	} else if len(memberships) != 1 || memberships[0].PostId != root.Id || memberships[0].UnreadReplies != 0 {
+		t.Fatal("should be following the thread with nothing unread")
+	}
+
+	stop := make(chan bool)
+	var updated *model.ThreadMembership
+
+	go func() {
+		for {
+			select {
+			case resp := <-WebSocketClient.EventChannel:
+				if resp.Event == model.WEBSOCKET_EVENT_THREAD_UPDATED {
+					updated = model.ThreadMembershipFromJson(strings.NewReader(resp.Data["thread"].(string)))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	time.Sleep(400 * time.Millisecond)
+
+	stop <- true
+
+	if updated == nil || updated.PostId != root.Id || updated.UnreadReplies != 1 {
+		t.Fatal("the user who started the thread should have been sent its unread replies")
+	}
+
+	th.LoginBasic()
+
+	if memberships, err := Client.GetFollowedThreads(channel.Id); err != nil {
+		t.Fatal(err)
+	} else if len(memberships) != 1 || memberships[0].UnreadReplies != 1 {
+		t.Fatal("should have an unread reply in the thread")
+	}
+
+	if membership, err := Client.MarkThreadRead(channel.Id, root.Id); err != nil {
+		t.Fatal(err)
+	} else if membership.UnreadReplies != 0 || membership.LastViewedAt == 0 {
+		t.Fatal("should have marked the thread as read")
+	}
+
+	if _, err := Client.MarkThreadRead(channel.Id, model.NewId()); err == nil {
+		t.Fatal("shouldn't be able to mark a thread that isn't followed as read")
+	}
+
+	otherChannel := th.CreateChannel(Client, th.BasicTeam)
+	th.LoginBasic2()
+
+	if _, err := Client.GetFollowedThreads(otherChannel.Id); err == nil {
+		t.Fatal("shouldn't be able to get the threads of a channel the user isn't in")
+	}
+}
+
+func TestForwardPost(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	post := th.BasicPost
+	otherChannel := th.CreateChannel(Client, th.BasicTeam)
+
+	WebSocketClient, err := th.CreateWebSocketClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer WebSocketClient.Close()
+	WebSocketClient.Listen()
+
+	time.Sleep(300 * time.Millisecond)
+	if resp := <-WebSocketClient.ResponseChannel; resp.Status != model.STATUS_OK {
+		t.Fatal("should have responded OK to authentication challenge")
+	}
+
+	stop := make(chan bool)
+	var posted *model.Post
+
+	go func() {
+		for {
+			select {
+			case resp := <-WebSocketClient.EventChannel:
+				if resp.Event == model.WEBSOCKET_EVENT_POSTED && resp.Broadcast.ChannelId == otherChannel.Id {
+					posted = model.PostFromJson(strings.NewReader(resp.Data["post"].(string)))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	forwarded, appErr := Client.ForwardPost(post.ChannelId, post.Id, otherChannel.Id)
+	if appErr != nil {
+		t.Fatal(appErr)
+	} else if forwarded.ChannelId != otherChannel.Id || forwarded.Message != post.Message || !forwarded.IsForwarded() {
+		t.Fatal("should have forwarded the post")
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	stop <- true
+
+	if posted == nil || posted.Id != forwarded.Id {
+		t.Fatal("should have sent the forwarded post to the other channel")
+	}
+
+	if direct, appErr := Client.ForwardPostToUser(post.ChannelId, post.Id, th.BasicUser2.Id); appErr != nil {
+		t.Fatal(appErr)
+	} else if channel := Client.Must(Client.GetChannel(direct.ChannelId, "")).Data.(*model.ChannelData).Channel; channel.Type != model.CHANNEL_DIRECT {
+		t.Fatal("should have forwarded the post to a direct message")
+	}
+
+	if _, appErr := Client.ForwardPost(post.ChannelId, post.Id, model.NewId()); appErr == nil {
+		t.Fatal("shouldn't be able to forward to a channel that doesn't exist")
+	}
+
+	th.LoginBasic2()
+
+	if _, appErr := Client.ForwardPost(post.ChannelId, post.Id, otherChannel.Id); appErr == nil {
+		t.Fatal("shouldn't be able to forward a post from a channel the user isn't in")
+	}
+
+	Client.Must(Client.JoinChannel(post.ChannelId))
+
+	if _, appErr := Client.ForwardPost(post.ChannelId, post.Id, otherChannel.Id); appErr == nil {
+		t.Fatal("shouldn't be able to forward a post to a channel the user isn't in")
+	}
+}
+
+func TestPostPermalinkPreviews(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	siteURL := *utils.Cfg.ServiceSettings.SiteURL
+	defer func() {
+		*utils.Cfg.ServiceSettings.SiteURL = siteURL
+	}()
+	*utils.Cfg.ServiceSettings.SiteURL = "http://localhost:8065"
+
+	linked := th.BasicPost
+	hidden := th.CreatePost(Client, th.CreateChannel(Client, th.BasicTeam))
+	message := "see http://localhost:8065/" + th.BasicTeam.Name + "/pl/" + linked.Id + " and http://localhost:8065/" + th.BasicTeam.Name + "/pl/" + hidden.Id
+	post := Client.Must(Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: message})).Data.(*model.Post)
+
+	getPermalinkPreviews := func() []*model.PermalinkPreview {
+		list := Client.Must(Client.GetPosts(th.BasicChannel.Id, 0, 10, "")).Data.(*model.PostList)
+		return list.Posts[post.Id].PermalinkPreviews
+	}
+
+	if previews := getPermalinkPreviews(); len(previews) != 2 || previews[0].LinkedPostId != linked.Id || previews[0].LinkedUserId != th.BasicUser.Id || previews[0].Message != linked.Message {
+		t.Fatal("should have included the previews of the linked posts")
+	}
+
+	if list := Client.Must(Client.GetPost(th.BasicChannel.Id, post.Id, "")).Data.(*model.PostList); len(list.Posts[post.Id].PermalinkPreviews) != 2 {
+		t.Fatal("should have included the previews in the post's thread")
+	}
+
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(th.BasicChannel.Id))
+
+	if previews := getPermalinkPreviews(); len(previews) != 1 || previews[0].LinkedPostId != linked.Id {
+		t.Fatal("shouldn't have included the preview of a post in a channel the user isn't in")
+	}
+}
+
+type fakeTranslator struct{}
+
+func (fakeTranslator) Translate(text string, locale string) (string, string, *model.AppError) {
+	return "[" + locale + "] " + text, "en", nil
+}
+
+func TestTranslatePost(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if _, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, "fr"); err == nil || err.StatusCode != http.StatusNotImplemented {
+		t.Fatal("shouldn't be able to translate without a provider")
+	}
+
+	einterfaces.RegisterTranslationInterface(fakeTranslator{})
+	defer einterfaces.RegisterTranslationInterface(nil)
+
+	if translation, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, "fr"); err != nil {
+		t.Fatal(err)
+	} else if translation.Message != "[fr] "+th.BasicPost.Message || translation.Locale != "fr" {
+		t.Fatal("wrong translation " + translation.Message)
+	}
+
+	if translation, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, ""); err != nil {
+		t.Fatal(err)
+	} else if translation.Locale != th.BasicUser.Locale {
+		t.Fatal("should have translated into the user's locale")
+	}
+
+	if _, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, "not a locale"); err == nil {
+		t.Fatal("shouldn't accept an invalid locale")
+	}
+
+	if _, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, "xx-YY"); err == nil {
+		t.Fatal("shouldn't accept a locale that the server doesn't support")
+	}
+
+	if _, err := Client.TranslatePost(th.BasicChannel.Id, model.NewId(), "fr"); err == nil {
+		t.Fatal("shouldn't translate a missing post")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.TranslatePost(th.BasicChannel.Id, th.BasicPost.Id, "fr"); err == nil {
+		t.Fatal("non-member shouldn't be able to translate the post")
+	}
+}
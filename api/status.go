@@ -18,6 +18,8 @@ func InitStatus() {
 
 	BaseRoutes.Users.Handle("/status", ApiUserRequired(getStatusesHttp)).Methods("GET")
 	BaseRoutes.Users.Handle("/status/ids", ApiUserRequired(getStatusesByIdsHttp)).Methods("POST")
+	BaseRoutes.Users.Handle("/status/custom", ApiUserRequired(setCustomStatus)).Methods("POST")
+	BaseRoutes.Users.Handle("/status/custom/clear", ApiUserRequired(removeCustomStatus)).Methods("POST")
 	app.Srv.WebSocketRouter.Handle("get_statuses", ApiWebSocketHandler(getStatusesWebSocket))
 	app.Srv.WebSocketRouter.Handle("get_statuses_by_ids", ApiWebSocketHandler(getStatusesByIdsWebSocket))
 }
@@ -63,3 +65,27 @@ func getStatusesByIdsWebSocket(req *model.WebSocketRequest) (map[string]interfac
 
 	return statusMap, nil
 }
+
+func setCustomStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	status := model.CustomStatusFromJson(r.Body)
+	if status == nil {
+		c.SetInvalidParam("setCustomStatus", "custom_status")
+		return
+	}
+
+	if err := app.SetCustomStatus(c.Session.UserId, status); err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(status.ToJson()))
+}
+
+func removeCustomStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	if err := app.RemoveCustomStatus(c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
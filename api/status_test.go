@@ -230,3 +230,44 @@ func TestGetStatusesByIds(t *testing.T) {
 		t.Fatal("should have errored")
 	}
 }
+
+func TestCustomStatus(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if _, err := Client.SetCustomStatus(&model.CustomStatus{}); err == nil {
+		t.Fatal("shouldn't have set an empty custom status")
+	}
+
+	if _, err := Client.SetCustomStatus(&model.CustomStatus{Text: "Out of office", ExpiresAt: model.GetMillis() - 1000}); err == nil {
+		t.Fatal("shouldn't have set an expired custom status")
+	}
+
+	expiresAt := model.GetMillis() + 60*60*1000
+	if status, err := Client.SetCustomStatus(&model.CustomStatus{Emoji: "palm_tree", Text: "Out of office", ExpiresAt: expiresAt}); err != nil {
+		t.Fatal(err)
+	} else if status.Text != "Out of office" || status.ExpiresAt != expiresAt {
+		t.Fatal("should have returned the custom status")
+	}
+
+	th.LoginBasic2()
+	if result, err := Client.GetUser(th.BasicUser.Id, ""); err != nil {
+		t.Fatal(err)
+	} else if user := result.Data.(*model.User); user.CustomStatusEmoji != "palm_tree" || user.CustomStatusText != "Out of office" || user.CustomStatusExpiry != expiresAt {
+		t.Fatal("should have shown the custom status to other users")
+	}
+
+	th.LoginBasic()
+
+	if ok, err := Client.RemoveCustomStatus(); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have removed the custom status")
+	}
+
+	if result, err := Client.GetMe(""); err != nil {
+		t.Fatal(err)
+	} else if user := result.Data.(*model.User); user.CustomStatusText != "" || user.CustomStatusExpiry != 0 {
+		t.Fatal("should have cleared the custom status")
+	}
+}
@@ -39,6 +39,13 @@ func InitTeam() {
 	BaseRoutes.NeedTeam.Handle("/update_member_roles", ApiUserRequired(updateMemberRoles)).Methods("POST")
 
 	BaseRoutes.NeedTeam.Handle("/invite_members", ApiUserRequired(inviteMembers)).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/invitations", ApiUserRequired(getInvitations)).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/invitations/create", ApiUserRequired(createInvitation)).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/invitations/{invitation_id:[A-Za-z0-9]+}/revoke", ApiUserRequired(revokeInvitation)).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/invite_links", ApiUserRequired(getTeamInviteLinks)).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/invite_links/regenerate", ApiUserRequired(regenerateTeamInviteLink)).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/default_channels", ApiUserRequired(getTeamDefaultChannels)).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/default_channels/update", ApiUserRequired(updateTeamDefaultChannels)).Methods("POST")
 
 	BaseRoutes.NeedTeam.Handle("/add_user_to_team", ApiUserRequired(addUserToTeam)).Methods("POST")
 	BaseRoutes.NeedTeam.Handle("/remove_user_from_team", ApiUserRequired(removeUserFromTeam)).Methods("POST")
@@ -115,9 +122,7 @@ func getAll(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.TeamMapToJson(m)))
 }
 
-func inviteMembers(c *Context, w http.ResponseWriter, r *http.Request) {
-	invites := model.InvitesFromJson(r.Body)
-
+func checkInvitePermission(c *Context, where string) bool {
 	if utils.IsLicensed && !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_INVITE_USER) {
 		errorId := ""
 		if *utils.Cfg.TeamSettings.RestrictTeamInvite == model.PERMISSIONS_SYSTEM_ADMIN {
@@ -126,8 +131,18 @@ func inviteMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 			errorId = "api.team.invite_members.restricted_team_admin.app_error"
 		}
 
-		c.Err = model.NewLocAppError("inviteMembers", errorId, nil, "")
+		c.Err = model.NewLocAppError(where, errorId, nil, "")
 		c.Err.StatusCode = http.StatusForbidden
+		return false
+	}
+
+	return true
+}
+
+func inviteMembers(c *Context, w http.ResponseWriter, r *http.Request) {
+	invites := model.InvitesFromJson(r.Body)
+
+	if !checkInvitePermission(c, "inviteMembers") {
 		return
 	}
 
@@ -139,6 +154,149 @@ func inviteMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(invites.ToJson()))
 }
 
+func createInvitation(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+	email := strings.ToLower(props["email"])
+
+	if len(email) > 0 && !model.IsValidEmail(email) {
+		c.SetInvalidParam("createInvitation", "email")
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	if !checkInvitePermission(c, "createInvitation") {
+		return
+	}
+
+	if invitation, err := app.CreateInvitation(c.TeamId, email, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("invitation_id=" + invitation.Id)
+		w.Write([]byte(invitation.ToJson()))
+	}
+}
+
+func getInvitations(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if invitations, err := app.GetInvitationsForTeam(c.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.InvitationListToJson(invitations)))
+	}
+}
+
+func revokeInvitation(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	invitationId := params["invitation_id"]
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if err := app.RevokeInvitation(c.TeamId, invitationId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("invitation_id=" + invitationId)
+	ReturnStatusOK(w)
+}
+
+func getTeamInviteLinks(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if links, err := app.GetTeamInviteLinks(c.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.TeamInviteLinkListToJson(links)))
+	}
+}
+
+func getTeamDefaultChannels(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if defaultChannels, err := app.GetTeamDefaultChannels(c.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.TeamDefaultChannelListToJson(defaultChannels)))
+	}
+}
+
+func updateTeamDefaultChannels(c *Context, w http.ResponseWriter, r *http.Request) {
+	channelIds := model.ArrayFromJson(r.Body)
+	if channelIds == nil {
+		c.SetInvalidParam("updateTeamDefaultChannels", "channel_ids")
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if defaultChannels, err := app.UpdateTeamDefaultChannels(c.TeamId, c.Session.UserId, channelIds); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("channel_ids=" + strings.Join(channelIds, ","))
+		w.Write([]byte(model.TeamDefaultChannelListToJson(defaultChannels)))
+	}
+}
+
+func regenerateTeamInviteLink(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.StringInterfaceFromJson(r.Body)
+
+	var maxUses, expiresInHours int64
+	if v, ok := props["max_uses"].(float64); ok {
+		maxUses = int64(v)
+	}
+	if v, ok := props["expires_in_hours"].(float64); ok {
+		expiresInHours = int64(v)
+	}
+
+	if maxUses < 0 {
+		c.SetInvalidParam("regenerateTeamInviteLink", "max_uses")
+		return
+	}
+
+	if expiresInHours < 0 {
+		c.SetInvalidParam("regenerateTeamInviteLink", "expires_in_hours")
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if link, err := app.RegenerateTeamInviteLink(c.TeamId, c.Session.UserId, maxUses, expiresInHours); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("invite_link_id=" + link.Id)
+		w.Write([]byte(link.ToJson()))
+	}
+}
+
 func addUserToTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := model.MapFromJson(r.Body)
 	userId := params["user_id"]
@@ -439,11 +597,12 @@ func getInviteInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	m := model.MapFromJson(r.Body)
 	inviteId := m["invite_id"]
 
-	if team, err := app.GetTeamByInviteId(inviteId); err != nil {
+	if invitation, link, team, err := app.GetInvite(inviteId); err != nil {
 		c.Err = err
 		return
 	} else {
-		if !(team.Type == model.TEAM_OPEN) {
+		// invitations and invite links are created by team members so they can be used to join invite only teams
+		if invitation == nil && link == nil && !(team.Type == model.TEAM_OPEN) {
 			c.Err = model.NewLocAppError("getInviteInfo", "api.team.get_invite_info.not_open_team", nil, "id="+inviteId)
 			return
 		}
@@ -453,6 +612,9 @@ func getInviteInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 		result["description"] = team.Description
 		result["name"] = team.Name
 		result["id"] = team.Id
+		if invitation != nil && len(invitation.Email) > 0 {
+			result["email"] = invitation.Email
+		}
 		w.Write([]byte(model.MapToJson(result)))
 	}
 }
@@ -472,6 +634,23 @@ func getTeamMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// larger pages used to be allowed, so they're shortened instead of rejected
+	if limit > model.TEAM_MEMBERS_MAX_PAGE_SIZE {
+		limit = model.TEAM_MEMBERS_MAX_PAGE_SIZE
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if !model.IsValidTeamMemberSort(sort) {
+		c.SetInvalidParam("getTeamMembers", "sort")
+		return
+	}
+
+	role := r.URL.Query().Get("role")
+	if len(role) > 0 && role != model.ROLE_TEAM_USER.Id && role != model.ROLE_TEAM_ADMIN.Id {
+		c.SetInvalidParam("getTeamMembers", "role")
+		return
+	}
+
 	if c.Session.GetTeamByTeamId(c.TeamId) == nil {
 		if !app.SessionHasPermissionToTeam(c.Session, c.TeamId, model.PERMISSION_MANAGE_SYSTEM) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
@@ -479,7 +658,7 @@ func getTeamMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if members, err := app.GetTeamMembers(c.TeamId, offset, limit); err != nil {
+	if members, err := app.GetTeamMembersPage(c.TeamId, offset, limit, sort, role); err != nil {
 		c.Err = err
 		return
 	} else {
@@ -4,6 +4,7 @@
 package api
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/app"
@@ -121,6 +122,12 @@ func TestRemoveUserFromTeam(t *testing.T) {
 func TestAddUserToTeamFromInvite(t *testing.T) {
 	th := Setup().InitBasic()
 
+	enablePermanentInviteLinks := *utils.Cfg.TeamSettings.EnablePermanentInviteLinks
+	defer func() {
+		*utils.Cfg.TeamSettings.EnablePermanentInviteLinks = enablePermanentInviteLinks
+	}()
+	*utils.Cfg.TeamSettings.EnablePermanentInviteLinks = true
+
 	user2 := th.CreateUser(th.BasicClient)
 	th.BasicClient.Must(th.BasicClient.Logout())
 	th.BasicClient.Must(th.BasicClient.Login(user2.Email, user2.Password))
@@ -135,6 +142,123 @@ func TestAddUserToTeamFromInvite(t *testing.T) {
 	}
 }
 
+func TestTeamInvitations(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+	Client := th.BasicClient
+	SystemAdminClient := th.SystemAdminClient
+	SystemAdminClient.SetTeamId(th.BasicTeam.Id)
+
+	if _, err := Client.CreateInvitation("junk"); err == nil {
+		t.Fatal("should have failed with an invalid email")
+	}
+
+	invitation, err := Client.CreateInvitation("Success+" + model.NewId() + "@simulator.amazonses.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Client.GetInvitations(); err == nil {
+		t.Fatal("should need to be a team admin to list invitations")
+	}
+
+	if invitations, err := SystemAdminClient.GetInvitations(); err != nil {
+		t.Fatal(err)
+	} else if len(invitations) != 1 || invitations[0].Id != invitation.Id {
+		t.Fatal("should have returned the invitation")
+	}
+
+	if _, err := Client.RevokeInvitation(invitation.Id); err == nil {
+		t.Fatal("should need to be a team admin to revoke invitations")
+	}
+
+	if ok, err := SystemAdminClient.RevokeInvitation(invitation.Id); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("should have revoked the invitation")
+	}
+
+	if invitations, err := SystemAdminClient.GetInvitations(); err != nil {
+		t.Fatal(err)
+	} else if len(invitations) != 0 {
+		t.Fatal("revoked invitation shouldn't be returned")
+	}
+}
+
+func TestAddUserToTeamFromInvitation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	invitation, err := th.BasicClient.CreateInvitation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2 := th.CreateUser(th.BasicClient)
+	th.BasicClient.Must(th.BasicClient.Logout())
+	th.BasicClient.Must(th.BasicClient.Login(user2.Email, user2.Password))
+
+	if result, err := th.BasicClient.AddUserToTeamFromInvite("", "", invitation.Id); err != nil {
+		t.Fatal(err)
+	} else if result.Data.(*model.Team).Id != th.BasicTeam.Id {
+		t.Fatal("should have joined the invited team")
+	}
+
+	user3 := th.CreateUser(th.BasicClient)
+	th.BasicClient.Must(th.BasicClient.Logout())
+	th.BasicClient.Must(th.BasicClient.Login(user3.Email, user3.Password))
+
+	if _, err := th.BasicClient.AddUserToTeamFromInvite("", "", invitation.Id); err == nil {
+		t.Fatal("invitation should only be usable once")
+	}
+}
+
+func TestTeamInviteLinks(t *testing.T) {
+	th := Setup().InitSystemAdmin().InitBasic()
+	Client := th.BasicClient
+	SystemAdminClient := th.SystemAdminClient
+	SystemAdminClient.SetTeamId(th.BasicTeam.Id)
+
+	if _, err := Client.RegenerateTeamInviteLink(0, 0); err == nil {
+		t.Fatal("should need to be a team admin to regenerate the invite link")
+	}
+
+	if _, err := SystemAdminClient.RegenerateTeamInviteLink(-1, 0); err == nil {
+		t.Fatal("should have failed with invalid max uses")
+	}
+
+	link, err := SystemAdminClient.RegenerateTeamInviteLink(1, 24)
+	if err != nil {
+		t.Fatal(err)
+	} else if link.MaxUses != 1 || link.ExpiresAt == 0 {
+		t.Fatal("should have created a link with the given limits")
+	}
+
+	user2 := th.CreateUser(Client)
+	Client.Must(Client.Logout())
+	Client.Must(Client.Login(user2.Email, user2.Password))
+
+	if _, err := Client.AddUserToTeamFromInvite("", "", link.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	user3 := th.CreateUser(Client)
+	Client.Must(Client.Logout())
+	Client.Must(Client.Login(user3.Email, user3.Password))
+
+	if _, err := Client.AddUserToTeamFromInvite("", "", link.Id); err == nil {
+		t.Fatal("link should only be usable once")
+	}
+
+	if _, err := Client.GetTeamInviteLinks(); err == nil {
+		t.Fatal("should need to be a team admin to list invite links")
+	}
+
+	if links, err := SystemAdminClient.GetTeamInviteLinks(); err != nil {
+		t.Fatal(err)
+	} else if len(links) != 1 || links[0].Id != link.Id || links[0].UseCount != 1 {
+		t.Fatal("should have returned the link and its use count")
+	}
+}
+
 func TestGetAllTeams(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	Client := th.BasicClient
@@ -454,6 +578,74 @@ func TestGetTeamMembers(t *testing.T) {
 	if _, err := th.BasicClient.GetTeamMembers("junk", 0, 100); err == nil {
 		t.Fatal("should have errored - bad team id")
 	}
+
+	if result, err := th.BasicClient.GetTeamMembers(th.BasicTeam.Id, 0, model.TEAM_MEMBERS_MAX_PAGE_SIZE+1); err != nil {
+		t.Fatal("should have limited the page size instead of failing", err)
+	} else if members := result.Data.([]*model.TeamMember); len(members) == 0 || len(members) > model.TEAM_MEMBERS_MAX_PAGE_SIZE {
+		t.Fatal("should have returned at most a full page of members", len(members))
+	}
+}
+
+func TestGetSortedTeamMembers(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if _, err := app.UpdateTeamMemberRoles(th.BasicTeam.Id, th.BasicUser2.Id, model.ROLE_TEAM_USER.Id+" "+model.ROLE_TEAM_ADMIN.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 0, 100, model.TEAM_MEMBER_SORT_BY_USERNAME, ""); err != nil {
+		t.Fatal(err)
+	} else if members := result.Data.([]*model.TeamMember); len(members) != 2 {
+		t.Fatal("should have returned both members")
+	} else {
+		first := th.BasicUser
+		if th.BasicUser2.Username < th.BasicUser.Username {
+			first = th.BasicUser2
+		}
+
+		if members[0].UserId != first.Id {
+			t.Fatal("should have sorted by username")
+		}
+	}
+
+	var joined []*model.TeamMember
+	if result, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 0, 100, model.TEAM_MEMBER_SORT_BY_JOINED_AT, ""); err != nil {
+		t.Fatal(err)
+	} else if joined = result.Data.([]*model.TeamMember); len(joined) != 2 || joined[0].CreateAt > joined[1].CreateAt {
+		t.Fatal("should have sorted by when the members joined")
+	}
+
+	if result, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 0, 100, "", model.ROLE_TEAM_ADMIN.Id); err != nil {
+		t.Fatal(err)
+	} else {
+		found := false
+		for _, member := range result.Data.([]*model.TeamMember) {
+			if member.UserId == th.BasicUser2.Id {
+				found = true
+			} else if !strings.Contains(member.Roles, model.ROLE_TEAM_ADMIN.Id) {
+				t.Fatal("should only have returned team admins")
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the team admin")
+		}
+	}
+
+	if result, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 1, 1, model.TEAM_MEMBER_SORT_BY_JOINED_AT, ""); err != nil {
+		t.Fatal(err)
+	} else if members := result.Data.([]*model.TeamMember); len(members) != 1 || members[0].UserId != joined[1].UserId {
+		t.Fatal("should have returned the second page")
+	}
+
+	if _, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 0, 100, "junk", ""); err == nil {
+		t.Fatal("should have errored - bad sort")
+	}
+
+	if _, err := Client.GetSortedTeamMembers(th.BasicTeam.Id, 0, 100, "", "system_admin"); err == nil {
+		t.Fatal("should have errored - bad role")
+	}
 }
 
 func TestGetMyTeamMembers(t *testing.T) {
@@ -770,3 +962,32 @@ func TestGetTeamByName(t *testing.T) {
 	}
 
 }
+
+func TestTeamDefaultChannels(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+
+	channel := th.CreateChannel(Client, th.BasicTeam)
+
+	if _, err := Client.UpdateTeamDefaultChannels([]string{channel.Id}); err == nil {
+		t.Fatal("should need permission to manage the team")
+	}
+
+	th.SystemAdminClient.SetTeamId(th.BasicTeam.Id)
+
+	if defaultChannels, err := th.SystemAdminClient.UpdateTeamDefaultChannels([]string{channel.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 1 || defaultChannels[0].ChannelId != channel.Id {
+		t.Fatal("should have saved the default channel")
+	}
+
+	if defaultChannels, err := th.SystemAdminClient.GetTeamDefaultChannels(); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 1 {
+		t.Fatal("should have returned the default channel")
+	}
+
+	if _, err := Client.GetTeamDefaultChannels(); err == nil {
+		t.Fatal("should need permission to manage the team")
+	}
+}
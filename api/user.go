@@ -65,9 +65,15 @@ func InitUser() {
 	BaseRoutes.Users.Handle("/name/{username:[A-Za-z0-9_\\-.]+}", ApiUserRequired(getByUsername)).Methods("GET")
 	BaseRoutes.Users.Handle("/email/{email}", ApiUserRequired(getByEmail)).Methods("GET")
 	BaseRoutes.NeedUser.Handle("/sessions", ApiUserRequired(getSessions)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/revoke_oauth_tokens", ApiUserRequired(revokeUserOAuthTokens)).Methods("POST")
 	BaseRoutes.NeedUser.Handle("/audits", ApiUserRequired(getAudits)).Methods("GET")
 	BaseRoutes.NeedUser.Handle("/image", ApiUserRequiredTrustRequester(getProfileImage)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/image/history", ApiUserRequired(getProfileImageHistory)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/image/{file_id:[A-Za-z0-9]+}/revert", ApiUserRequired(revertProfileImage)).Methods("POST")
 	BaseRoutes.NeedUser.Handle("/update_roles", ApiUserRequired(updateRoles)).Methods("POST")
+	BaseRoutes.NeedUser.Handle("/tokens", ApiUserRequired(getUserAccessTokens)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/tokens/create", ApiUserRequired(createUserAccessToken)).Methods("POST")
+	BaseRoutes.NeedUser.Handle("/tokens/{token_id:[A-Za-z0-9]+}/revoke", ApiUserRequired(revokeUserAccessToken)).Methods("POST")
 
 	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(loginWithSaml)).Methods("GET")
 	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(completeSaml)).Methods("POST")
@@ -115,7 +121,7 @@ func login(c *Context, w http.ResponseWriter, r *http.Request) {
 	ldapOnly := props["ldap_only"] == "true"
 
 	c.LogAudit("attempt - user_id=" + id + " login_id=" + loginId)
-	user, err := app.AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId, ldapOnly)
+	user, err := app.AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId, ldapOnly, c.IpAddress)
 	if err != nil {
 		c.LogAudit("failure - user_id=" + id + " login_id=" + loginId)
 		c.Err = err
@@ -271,6 +277,24 @@ func getSessions(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func revokeUserOAuthTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["user_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if err := app.RevokeAllAccessDataForUser(id); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("user_id=" + id)
+	ReturnStatusOK(w)
+}
+
 func logout(c *Context, w http.ResponseWriter, r *http.Request) {
 	data := make(map[string]string)
 	data["user_id"] = c.Session.UserId
@@ -603,7 +627,12 @@ func getProfileImage(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		var img []byte
-		img, err = app.GetProfileImage(user)
+		if r.URL.Query().Get("size") == "thumbnail" {
+			etag += ".thumbnail"
+			img, err = app.GetProfileImageThumbnail(user)
+		} else {
+			img, err = app.GetProfileImage(user)
+		}
 		if err != nil {
 			c.Err = err
 			return
@@ -621,6 +650,114 @@ func getProfileImage(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getProfileImageHistory(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["user_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if images, err := app.GetProfileImageHistory(id); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.ProfileImagesToJson(images)))
+	}
+}
+
+func revertProfileImage(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["user_id"]
+	fileId := params["file_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if err := app.RevertProfileImage(id, fileId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("file_id=" + fileId)
+
+	ReturnStatusOK(w)
+}
+
+func createUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["user_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	token := model.UserAccessTokenFromJson(r.Body)
+	if token == nil {
+		c.SetInvalidParam("createUserAccessToken", "token")
+		return
+	}
+
+	token.UserId = id
+
+	if token, err := app.CreateUserAccessToken(token); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("token_id=" + token.Id)
+		w.Write([]byte(token.ToJson()))
+	}
+}
+
+func getUserAccessTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["user_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if tokens, err := app.GetUserAccessTokensForUser(id); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.UserAccessTokenListToJson(tokens)))
+	}
+}
+
+func revokeUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["user_id"]
+	tokenId := params["token_id"]
+
+	if !app.SessionHasPermissionToUser(c.Session, id) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	token, err := app.GetUserAccessToken(tokenId)
+	if err != nil {
+		c.Err = err
+		return
+	} else if token.UserId != id {
+		c.Err = model.NewAppError("revokeUserAccessToken", "api.user.revoke_user_access_token.not_found.app_error", nil, "token_id="+tokenId, http.StatusNotFound)
+		return
+	}
+
+	if err := app.RevokeUserAccessToken(token); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("token_id=" + tokenId)
+
+	ReturnStatusOK(w)
+}
+
 func uploadProfileImage(c *Context, w http.ResponseWriter, r *http.Request) {
 	if len(utils.Cfg.FileSettings.DriverName) == 0 {
 		c.Err = model.NewLocAppError("uploadProfileImage", "api.user.upload_profile_user.storage.app_error", nil, "")
@@ -772,7 +909,7 @@ func updateActive(c *Context, w http.ResponseWriter, r *http.Request) {
 	// true when you're trying to de-activate yourself
 	isSelfDeactive := !active && userId == c.Session.UserId
 
-	if !isSelfDeactive && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+	if !isSelfDeactive && !app.SessionCanManageUser(c.Session, userId) {
 		c.Err = model.NewLocAppError("updateActive", "api.user.update_active.permissions.app_error", nil, "userId="+userId)
 		c.Err.StatusCode = http.StatusForbidden
 		return
@@ -918,7 +1055,7 @@ func emailToOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.CheckPasswordAndAllCriteria(user, password, mfaToken); err != nil {
+	if err := app.CheckPasswordAndAllCriteria(user, password, mfaToken, c.IpAddress); err != nil {
 		c.LogAuditWithUserId(user.Id, "failed - bad authentication")
 		c.Err = err
 		return
@@ -1046,7 +1183,7 @@ func emailToLdap(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.CheckPasswordAndAllCriteria(user, emailPassword, token); err != nil {
+	if err := app.CheckPasswordAndAllCriteria(user, emailPassword, token, c.IpAddress); err != nil {
 		c.LogAuditWithUserId(user.Id, "failed - bad authentication")
 		c.Err = err
 		return
@@ -1462,11 +1599,14 @@ func userTyping(req *model.WebSocketRequest) (map[string]interface{}, *model.App
 	omitUsers := make(map[string]bool, 1)
 	omitUsers[req.Session.UserId] = true
 
+	// Older clients only understand the typing event for each message, so it's still sent along with the users typing
 	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", channelId, "", omitUsers)
 	event.Add("parent_id", parentId)
 	event.Add("user_id", req.Session.UserId)
 	go app.Publish(event)
 
+	app.UserTyping(channelId, parentId, req.Session.UserId)
+
 	return nil, nil
 }
 
@@ -1582,7 +1722,9 @@ func autocompleteUsersInChannel(c *Context, w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	searchOptions := map[string]bool{}
+	searchOptions := map[string]bool{
+		store.USER_SEARCH_OPTION_AUTOCOMPLETE: true,
+	}
 
 	hideFullName := !utils.Cfg.PrivacySettings.ShowFullName
 	if hideFullName && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
@@ -1620,7 +1762,9 @@ func autocompleteUsersInTeam(c *Context, w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	searchOptions := map[string]bool{}
+	searchOptions := map[string]bool{
+		store.USER_SEARCH_OPTION_AUTOCOMPLETE: true,
+	}
 
 	hideFullName := !utils.Cfg.PrivacySettings.ShowFullName
 	if hideFullName && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
@@ -1645,7 +1789,9 @@ func autocompleteUsersInTeam(c *Context, w http.ResponseWriter, r *http.Request)
 func autocompleteUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 	term := r.URL.Query().Get("term")
 
-	searchOptions := map[string]bool{}
+	searchOptions := map[string]bool{
+		store.USER_SEARCH_OPTION_AUTOCOMPLETE: true,
+	}
 
 	hideFullName := !utils.Cfg.PrivacySettings.ShowFullName
 	if hideFullName && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
@@ -259,7 +259,7 @@ func TestLoginWithDeviceId(t *testing.T) {
 }
 
 func TestPasswordGuessLockout(t *testing.T) {
-	th := Setup().InitBasic()
+	th := Setup().InitBasic().InitSystemAdmin()
 	Client := th.BasicClient
 	user := th.BasicUser
 	Client.Must(Client.Logout())
@@ -292,6 +292,13 @@ func TestPasswordGuessLockout(t *testing.T) {
 	if _, err := Client.Login(user.Email, user.Password); err == nil {
 		t.Fatal("Shouldn't be able to login with password when account is locked out.")
 	}
+
+	// Unlocked by an admin
+	th.SystemAdminClient.Must(th.SystemAdminClient.AdminUnlockUser(user.Id))
+
+	if _, err := Client.Login(user.Email, user.Password); err != nil {
+		t.Fatal("Should be able to login after the account is unlocked.", err)
+	}
 }
 
 func TestSessions(t *testing.T) {
@@ -795,24 +802,39 @@ func TestUserUploadProfileImage(t *testing.T) {
 
 		Client.DoApiGet("/users/"+user.Id+"/image", "", "")
 
-		if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-			endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-			accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-			secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-			secure := *utils.Cfg.FileSettings.AmazonS3SSL
-			s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
-			if err != nil {
-				t.Fatal(err)
-			}
-			bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-			if err = s3Clnt.RemoveObject(bucket, "/users/"+user.Id+"/profile.png"); err != nil {
-				t.Fatal(err)
-			}
-		} else {
-			path := utils.Cfg.FileSettings.Directory + "users/" + user.Id + "/profile.png"
-			if err := os.Remove(path); err != nil {
-				t.Fatal("Couldn't remove file at " + path)
-			}
+		if r, err := Client.DoApiGet("/users/"+user.Id+"/image?size=thumbnail", "", ""); err != nil {
+			t.Fatal(err)
+		} else if img, _, err := image.Decode(r.Body); err != nil {
+			t.Fatal(err)
+		} else if img.Bounds().Dx() != model.PROFILE_IMAGE_THUMBNAIL_SIZE {
+			t.Fatal("thumbnail should have been resized")
+		}
+
+		images, upErr := Client.GetProfileImageHistory(user.Id)
+		if upErr != nil {
+			t.Fatal(upErr)
+		} else if len(images) != 1 {
+			t.Fatal("should have saved the uploaded picture")
+		}
+
+		if _, upErr := Client.RevertProfileImage(user.Id, images[0].FileId); upErr != nil {
+			t.Fatal(upErr)
+		}
+
+		if _, upErr := Client.RevertProfileImage(user.Id, model.NewId()); upErr == nil {
+			t.Fatal("shouldn't be able to revert to a picture that doesn't exist")
+		}
+
+		if _, upErr := Client.GetProfileImageHistory(th.BasicUser.Id); upErr == nil {
+			t.Fatal("shouldn't be able to see another user's pictures")
+		}
+
+		if _, upErr := Client.RevertProfileImage(th.BasicUser.Id, images[0].FileId); upErr == nil {
+			t.Fatal("shouldn't be able to change another user's picture")
+		}
+
+		if err := app.PermanentDeleteUser(user); err != nil {
+			t.Fatal(err)
 		}
 	} else {
 		body := &bytes.Buffer{}
@@ -2608,3 +2630,81 @@ func TestGetByEmail(t *testing.T) {
 		}
 	}
 }
+
+func TestUserAccessTokens(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enable := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enable
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	token, err := Client.CreateUserAccessToken(th.BasicUser.Id, &model.UserAccessToken{Description: "test", Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}, RateLimit: 3})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(token.Token) != 26 {
+		t.Fatal("should have returned the token's value")
+	}
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser2.Id, &model.UserAccessToken{}); err == nil {
+		t.Fatal("shouldn't have created a token for another user")
+	}
+
+	if tokens, err := Client.GetUserAccessTokens(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if len(tokens) != 1 || tokens[0].Id != token.Id || tokens[0].Token != "" {
+		t.Fatal("should have returned the token without its value")
+	}
+
+	tokenClient := th.CreateClient()
+	tokenClient.AuthToken = token.Token
+	tokenClient.AuthType = model.HEADER_BEARER
+	tokenClient.SetTeamId(th.BasicTeam.Id)
+
+	if _, err := tokenClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokenClient.GetPosts(th.BasicChannel.Id, 0, 10, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokenClient.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "a" + model.NewId()}); err == nil || err.StatusCode != http.StatusForbidden {
+		t.Fatal("shouldn't have created a post without the write-posts scope")
+	}
+
+	if _, err := tokenClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokenClient.GetMe(""); err == nil || err.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("should have been rate limited")
+	}
+
+	webSocketClient, err := model.NewWebSocketClient("ws://localhost"+utils.Cfg.ServiceSettings.ListenAddress, token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer webSocketClient.Close()
+	webSocketClient.Listen()
+
+	if resp, ok := <-webSocketClient.ResponseChannel; ok && resp.Status == model.STATUS_OK {
+		t.Fatal("shouldn't have connected to the websocket with a scoped token")
+	}
+
+	if tokens, err := Client.GetUserAccessTokens(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if tokens[0].LastUsedAt == 0 {
+		t.Fatal("should have recorded when the token was used")
+	}
+
+	if _, err := Client.RevokeUserAccessToken(th.BasicUser.Id, token.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokenClient.GetPosts(th.BasicChannel.Id, 0, 10, ""); err == nil || err.StatusCode != http.StatusUnauthorized {
+		t.Fatal("shouldn't have accepted a revoked token")
+	}
+}
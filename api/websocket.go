@@ -5,6 +5,9 @@ package api
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/websocket"
@@ -16,14 +19,40 @@ import (
 func InitWebSocket() {
 	l4g.Debug(utils.T("api.web_socket.init.debug"))
 	BaseRoutes.Users.Handle("/websocket", ApiAppHandlerTrustRequester(connect)).Methods("GET")
+	BaseRoutes.Users.Handle("/websocket_token", ApiUserRequired(createWebSocketConnectionToken)).Methods("POST")
 	app.HubStart()
 }
 
+func createWebSocketConnectionToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	if token, err := app.CreateWebSocketConnectionToken(&c.Session); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(token.ToJson()))
+	}
+}
+
 func connect(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkWebSocketOrigin(r) {
+		c.Err = model.NewLocAppError("connect", "api.web_socket.connect.origin.app_error", nil, "origin="+r.Header.Get("Origin"))
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	session := c.Session
+	if token := r.URL.Query().Get("connection_token"); token != "" {
+		if tokenSession, err := app.GetSessionForWebSocketConnectionToken(token); err != nil {
+			c.Err = err
+			return
+		} else {
+			session = *tokenSession
+		}
+	}
+
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  model.SOCKET_MAX_MESSAGE_SIZE_KB,
 		WriteBufferSize: model.SOCKET_MAX_MESSAGE_SIZE_KB,
-		CheckOrigin:     nil,
+		CheckOrigin:     checkWebSocketOrigin,
 	}
 
 	ws, err := upgrader.Upgrade(w, r, nil)
@@ -33,8 +62,39 @@ func connect(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wc := app.NewWebConn(ws, c.Session, c.T, c.Locale)
+	wc := app.NewWebConn(ws, session, c.T, c.Locale)
+
+	// A client that was disconnected can resume its previous connection to receive the events that it missed
+	query := r.URL.Query()
+	if connectionId := query.Get("connection_id"); len(connectionId) == 26 {
+		if sequence, err := strconv.ParseInt(query.Get("sequence_number"), 10, 64); err == nil {
+			wc.SetResume(connectionId, sequence)
+		}
+	}
+
 	app.HubRegister(wc)
 	go wc.WritePump()
 	wc.ReadPump()
 }
+
+// checkWebSocketOrigin allows requests without an Origin, such as those from mobile apps, and requests from pages
+// served by this server. Browsers on other sites are only allowed if their origin is listed in
+// ServiceSettings.WebsocketAllowedOrigins, which is a space separated list of origins or * to allow any origin.
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range strings.Fields(*utils.Cfg.ServiceSettings.WebsocketAllowedOrigins) {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -270,6 +270,161 @@ func TestWebsocketOriginSecurity(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	allowedOrigins := *utils.Cfg.ServiceSettings.WebsocketAllowedOrigins
+	defer func() {
+		*utils.Cfg.ServiceSettings.WebsocketAllowedOrigins = allowedOrigins
+	}()
+
+	*utils.Cfg.ServiceSettings.WebsocketAllowedOrigins = "http://www.example.com https://chat.example.com"
+
+	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX_V3+"/users/websocket", http.Header{
+		"Origin": []string{"https://chat.example.com"},
+	})
+	if err != nil {
+		t.Fatal("should have allowed an origin from the allowlist", err)
+	}
+
+	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX_V3+"/users/websocket", http.Header{
+		"Origin": []string{"http://www.evil.com"},
+	})
+	if err == nil {
+		t.Fatal("should have errored because Origin isn't in the allowlist")
+	}
+
+	*utils.Cfg.ServiceSettings.WebsocketAllowedOrigins = "*"
+
+	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX_V3+"/users/websocket", http.Header{
+		"Origin": []string{"http://www.evil.com"},
+	})
+	if err != nil {
+		t.Fatal("should have allowed any origin", err)
+	}
+}
+
+func TestWebSocketConnectionToken(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	url := "ws://localhost" + utils.Cfg.ServiceSettings.ListenAddress + model.API_URL_SUFFIX_V3 + "/users/websocket"
+
+	token, err := Client.CreateWebSocketConnectionToken()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(token.Token) != 26 {
+		t.Fatal("should have returned a token")
+	}
+
+	conn, _, dialErr := websocket.DefaultDialer.Dial(url+"?connection_token="+token.Token, nil)
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	defer conn.Close()
+
+	// The connection is authenticated without sending an authentication challenge
+	if err := conn.WriteJSON(&model.WebSocketRequest{Seq: 1, Action: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		var resp model.WebSocketResponse
+		if err := conn.ReadJSON(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.SeqReply != 1 {
+			// skip events such as the hello message
+			continue
+		}
+
+		if resp.Status != model.STATUS_OK || resp.Data["text"] != "pong" {
+			t.Fatal("should have been authenticated by the connection token")
+		}
+
+		break
+	}
+
+	if _, _, dialErr := websocket.DefaultDialer.Dial(url+"?connection_token="+token.Token, nil); dialErr == nil {
+		t.Fatal("shouldn't have been able to use a connection token twice")
+	}
+
+	if _, _, dialErr := websocket.DefaultDialer.Dial(url+"?connection_token="+model.NewId(), nil); dialErr == nil {
+		t.Fatal("shouldn't have been able to use a made up connection token")
+	}
+
+	token, err = Client.CreateWebSocketConnectionToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-app.Srv.Store.Session().Remove(Client.AuthToken)
+	app.ClearSessionCacheForUser(th.BasicUser.Id)
+
+	if _, _, dialErr := websocket.DefaultDialer.Dial(url+"?connection_token="+token.Token, nil); dialErr == nil {
+		t.Fatal("shouldn't have been able to use a connection token for a revoked session")
+	}
+
+	Client.Logout()
+	if _, err := Client.CreateWebSocketConnectionToken(); err == nil {
+		t.Fatal("should have required a session")
+	}
+}
+
+func TestWebSocketResume(t *testing.T) {
+	th := Setup().InitBasic()
+
+	WebSocketClient, err := th.CreateWebSocketClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	WebSocketClient.Listen()
+
+	// waitForEvent returns the first event of the given type received by the client
+	waitForEvent := func(eventType string) *model.WebSocketEvent {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event := <-WebSocketClient.EventChannel:
+				if event != nil && event.Event == eventType {
+					return event
+				}
+			case <-timeout:
+				t.Fatal("didn't receive a " + eventType + " event")
+				return nil
+			}
+		}
+	}
+
+	if hello := waitForEvent(model.WEBSOCKET_EVENT_HELLO); hello.Data["resumed"] != false || len(WebSocketClient.ConnectionId) != 26 {
+		t.Fatal("should have sent the id of a new connection")
+	}
+
+	received := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", "", th.BasicUser.Id, nil)
+	app.Publish(received)
+	if event := waitForEvent(model.WEBSOCKET_EVENT_TYPING); event.Sequence != WebSocketClient.EventSequence || event.Sequence == 0 {
+		t.Fatal("should have numbered the event")
+	}
+
+	WebSocketClient.Close()
+	time.Sleep(300 * time.Millisecond)
+
+	missed := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", "", th.BasicUser.Id, nil)
+	missed.Add("missed", true)
+	app.Publish(missed)
+	time.Sleep(300 * time.Millisecond)
+
+	if err := WebSocketClient.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer WebSocketClient.Close()
+	WebSocketClient.Listen()
+
+	if hello := waitForEvent(model.WEBSOCKET_EVENT_HELLO); hello.Data["resumed"] != true {
+		t.Fatal("should have resumed the connection")
+	}
+
+	if event := waitForEvent(model.WEBSOCKET_EVENT_TYPING); event.Data["missed"] != true {
+		t.Fatal("should have sent the event that was missed while disconnected")
+	}
 }
 
 func TestZZWebSocketTearDown(t *testing.T) {
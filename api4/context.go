@@ -170,6 +170,14 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		c.MfaRequired()
 	}
 
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		c.Err = app.CheckUserAccessTokenScope(&c.Session, r.Method, r.URL.Path)
+	}
+
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		c.Err = app.RecordUserAccessTokenUse(&c.Session)
+	}
+
 	if c.Err == nil {
 		h.handleFunc(c, w, r)
 	}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
@@ -414,3 +415,39 @@ func TestGetUsersNotInChannel(t *testing.T) {
 	_, resp = th.SystemAdminClient.GetUsersNotInChannel(teamId, channelId, 0, 60, "")
 	CheckNoError(t, resp)
 }
+
+func TestUserAccessTokenScopes(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+
+	enable := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enable
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	readToken, err := app.CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id, Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manageToken, err := app.CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id, Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_MANAGE_CHANNELS}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Client := th.CreateClient()
+	Client.AuthType = model.HEADER_BEARER
+
+	Client.AuthToken = readToken.Token
+	_, resp := Client.GetUser(th.BasicUser2.Id, "")
+	CheckForbiddenStatus(t, resp)
+
+	channel := &model.Channel{DisplayName: "Test API Name", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN, TeamId: th.BasicTeam.Id}
+	_, resp = Client.CreateChannel(channel)
+	CheckForbiddenStatus(t, resp)
+
+	Client.AuthToken = manageToken.Token
+	_, resp = Client.CreateChannel(channel)
+	CheckNoError(t, resp)
+}
@@ -90,6 +90,7 @@ func InvalidateAllCachesSkipSend() {
 	store.ClearUserCaches()
 	store.ClearPostCaches()
 	store.ClearWebhookCaches()
+	store.ClearFileCaches()
 }
 
 func GetConfig() *model.Config {
@@ -100,12 +101,10 @@ func GetConfig() *model.Config {
 	return cfg
 }
 
-func ReloadConfig() {
+func ReloadConfig() *model.AppError {
 	debug.FreeOSMemory()
-	utils.LoadConfig(utils.CfgFileName)
 
-	// start/restart email batching job if necessary
-	InitEmailBatching()
+	return utils.ReloadConfig()
 }
 
 func SaveConfig(cfg *model.Config) *model.AppError {
@@ -125,15 +124,12 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 	}
 
 	//oldCfg := utils.Cfg
-	utils.SaveConfig(utils.CfgFileName, cfg)
-	utils.LoadConfig(utils.CfgFileName)
+	if err := utils.SaveConfig(utils.CfgFileName, cfg); err != nil {
+		return err
+	}
 
-	if einterfaces.GetMetricsInterface() != nil {
-		if *utils.Cfg.MetricsSettings.Enable {
-			einterfaces.GetMetricsInterface().StartServer()
-		} else {
-			einterfaces.GetMetricsInterface().StopServer()
-		}
+	if err := utils.ReloadConfig(); err != nil {
+		return err
 	}
 
 	// Future feature is to sync the configuration files
@@ -144,9 +140,6 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 	// 	}
 	// }
 
-	// start/restart email batching job if necessary
-	InitEmailBatching()
-
 	return nil
 }
 
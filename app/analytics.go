@@ -138,6 +138,10 @@ func GetAnalytics(name string, teamId string) (model.AnalyticsRows, *model.AppEr
 
 		return rows, nil
 	} else if name == "post_counts_day" {
+		if analyticsAggregatedThroughYesterday() {
+			return getDailyAnalyticsRollup(Srv.Store.Analytics().GetPostCountsByDay, teamId)
+		}
+
 		if skipIntensiveQueries {
 			rows := model.AnalyticsRows{&model.AnalyticsRow{"", -1}}
 			return rows, nil
@@ -149,6 +153,10 @@ func GetAnalytics(name string, teamId string) (model.AnalyticsRows, *model.AppEr
 			return r.Data.(model.AnalyticsRows), nil
 		}
 	} else if name == "user_counts_with_posts_day" {
+		if analyticsAggregatedThroughYesterday() {
+			return getDailyAnalyticsRollup(Srv.Store.Analytics().GetUserCountsWithPostsByDay, teamId)
+		}
+
 		if skipIntensiveQueries {
 			rows := model.AnalyticsRows{&model.AnalyticsRow{"", -1}}
 			return rows, nil
@@ -230,6 +238,19 @@ func GetAnalytics(name string, teamId string) (model.AnalyticsRows, *model.AppEr
 	return nil, nil
 }
 
+// getDailyAnalyticsRollup reads the pre-aggregated daily stats for the same days that
+// would otherwise be queried from the posts directly.
+func getDailyAnalyticsRollup(get func(teamId string, startDay string, endDay string) store.StoreChannel, teamId string) (model.AnalyticsRows, *model.AppError) {
+	startDay := model.AnalyticsDayFromTime(utils.Yesterday().AddDate(0, 0, -31))
+	endDay := model.AnalyticsDayFromTime(utils.Yesterday())
+
+	if r := <-get(teamId, startDay, endDay); r.Err != nil {
+		return nil, r.Err
+	} else {
+		return r.Data.(model.AnalyticsRows), nil
+	}
+}
+
 func GetRecentlyActiveUsersForTeam(teamId string) (map[string]*model.User, *model.AppError) {
 	if result := <-Srv.Store.User().GetRecentlyActiveUsersForTeam(teamId); result.Err != nil {
 		return nil, result.Err
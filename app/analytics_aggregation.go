@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	ANALYTICS_AGGREGATION_DEFAULT_SCHEDULE = "15 0 * * *"
+
+	// The number of days that are backfilled the first time the aggregation runs, which
+	// matches the number of days shown on the system console.
+	ANALYTICS_BACKFILL_DAYS = 31
+
+	ANALYTICS_AGGREGATION_DATA_START_DAY = "start_day"
+	ANALYTICS_AGGREGATION_DATA_END_DAY   = "end_day"
+	ANALYTICS_AGGREGATION_DATA_LAST_DAY  = "last_day"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_ANALYTICS_AGGREGATION, runAnalyticsAggregationJob)
+	RegisterJobScheduler(model.JOB_TYPE_ANALYTICS_AGGREGATION, ANALYTICS_AGGREGATION_DEFAULT_SCHEDULE, scheduleAnalyticsAggregation)
+}
+
+// CreateAnalyticsAggregationJob queues a job that recomputes the daily stats for every day
+// from startDay to endDay. It can be used to backfill the stats for historical data.
+func CreateAnalyticsAggregationJob(startDay string, endDay string) (*model.Job, *model.AppError) {
+	start, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, startDay, time.Local)
+	if err != nil {
+		return nil, model.NewAppError("CreateAnalyticsAggregationJob", "app.analytics_aggregation.invalid_day.app_error", nil, "start_day="+startDay, http.StatusBadRequest)
+	}
+
+	end, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, endDay, time.Local)
+	if err != nil {
+		return nil, model.NewAppError("CreateAnalyticsAggregationJob", "app.analytics_aggregation.invalid_day.app_error", nil, "end_day="+endDay, http.StatusBadRequest)
+	}
+
+	if start.After(end) {
+		return nil, model.NewAppError("CreateAnalyticsAggregationJob", "app.analytics_aggregation.invalid_range.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return CreateJob(model.JOB_TYPE_ANALYTICS_AGGREGATION, map[string]string{
+		ANALYTICS_AGGREGATION_DATA_START_DAY: startDay,
+		ANALYTICS_AGGREGATION_DATA_END_DAY:   endDay,
+	})
+}
+
+// scheduleAnalyticsAggregation queues a job to aggregate every day that has finished since
+// the stats were last computed.
+func scheduleAnalyticsAggregation() {
+	yesterday := model.AnalyticsDayFromTime(utils.Yesterday())
+
+	var lastDay string
+	if result := <-Srv.Store.Analytics().GetLastAggregatedDay(); result.Err != nil {
+		l4g.Error(utils.T("app.analytics_aggregation.schedule.error"), result.Err.Error())
+		return
+	} else {
+		lastDay = result.Data.(string)
+	}
+
+	if lastDay >= yesterday {
+		return
+	}
+
+	if result := <-Srv.Store.Job().GetAllByType(model.JOB_TYPE_ANALYTICS_AGGREGATION, 0, 1); result.Err != nil {
+		l4g.Error(utils.T("app.analytics_aggregation.schedule.error"), result.Err.Error())
+		return
+	} else if jobs := result.Data.([]*model.Job); len(jobs) > 0 && !jobs[0].IsFinished() {
+		return
+	}
+
+	startDay := model.AnalyticsDayFromTime(utils.Yesterday().AddDate(0, 0, -ANALYTICS_BACKFILL_DAYS))
+	if lastDay != "" {
+		if last, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, lastDay, time.Local); err == nil {
+			startDay = model.AnalyticsDayFromTime(last.AddDate(0, 0, 1))
+		}
+	}
+
+	if _, err := CreateAnalyticsAggregationJob(startDay, yesterday); err != nil {
+		l4g.Error(utils.T("app.analytics_aggregation.schedule.error"), err.Error())
+	}
+}
+
+func runAnalyticsAggregationJob(job *model.Job) *model.AppError {
+	start, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, job.Data[ANALYTICS_AGGREGATION_DATA_START_DAY], time.Local)
+	if err != nil {
+		return model.NewAppError("runAnalyticsAggregationJob", "app.analytics_aggregation.invalid_day.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	end, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, job.Data[ANALYTICS_AGGREGATION_DATA_END_DAY], time.Local)
+	if err != nil {
+		return model.NewAppError("runAnalyticsAggregationJob", "app.analytics_aggregation.invalid_day.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	// resume from the day after the last one that was saved
+	if lastDay, ok := job.Data[ANALYTICS_AGGREGATION_DATA_LAST_DAY]; ok {
+		if last, err := time.ParseInLocation(model.ANALYTICS_DAY_FORMAT, lastDay, time.Local); err == nil {
+			start = last.AddDate(0, 0, 1)
+		}
+	}
+
+	totalDays := int64(end.Sub(start).Hours()/24) + 1
+
+	for date, done := start, int64(0); !date.After(end); date, done = date.AddDate(0, 0, 1), done+1 {
+		day := model.AnalyticsDayFromTime(date)
+
+		var stats []*model.AnalyticsDailyStat
+		if result := <-Srv.Store.Analytics().ComputeDailyStats(day, utils.MillisFromTime(utils.StartOfDay(date)), utils.MillisFromTime(utils.EndOfDay(date))); result.Err != nil {
+			return result.Err
+		} else {
+			stats = result.Data.([]*model.AnalyticsDailyStat)
+		}
+
+		if result := <-Srv.Store.Analytics().SaveDailyStats(day, stats); result.Err != nil {
+			return result.Err
+		}
+
+		job.Data[ANALYTICS_AGGREGATION_DATA_LAST_DAY] = day
+		if err := SetJobProgress(job, done*100/totalDays); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// analyticsAggregatedThroughYesterday returns true if the daily stats are up to date and
+// can be used instead of querying the posts directly.
+func analyticsAggregatedThroughYesterday() bool {
+	if result := <-Srv.Store.Analytics().GetLastAggregatedDay(); result.Err != nil {
+		return false
+	} else {
+		return result.Data.(string) >= model.AnalyticsDayFromTime(utils.Yesterday())
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_AUDIO_WAVEFORM, runAudioWaveformJob)
+}
+
+// CreateAudioWaveformJob queues a job to compute the duration and waveform of an uploaded
+// audio file.
+func CreateAudioWaveformJob(fileId string) (*model.Job, *model.AppError) {
+	return CreateJob(model.JOB_TYPE_AUDIO_WAVEFORM, map[string]string{"file_id": fileId})
+}
+
+func runAudioWaveformJob(job *model.Job) *model.AppError {
+	var info *model.FileInfo
+	if result := <-Srv.Store.FileInfo().Get(job.Data["file_id"]); result.Err != nil {
+		return result.Err
+	} else {
+		info = result.Data.(*model.FileInfo)
+	}
+
+	if !info.IsWav() && einterfaces.GetTranscoderInterface() == nil {
+		// there's nothing that can decode this file
+		return nil
+	}
+
+	data, err := ReadFile(info.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := SetJobProgress(job, 50); err != nil {
+		return err
+	}
+
+	var audioInfo *model.AudioInfo
+	if info.IsWav() {
+		if decoded, decodeErr := model.GetAudioInfoForWav(data); decodeErr != nil {
+			return model.NewAppError("runAudioWaveformJob", "app.audio_waveform.decode.app_error", nil, "file_id="+info.Id+", "+decodeErr.Error(), http.StatusBadRequest)
+		} else {
+			audioInfo = decoded
+		}
+	} else if audioInfo, err = einterfaces.GetTranscoderInterface().ExtractAudioInfo(data, info.MimeType); err != nil {
+		return err
+	}
+
+	info.Duration = audioInfo.Duration
+	info.Waveform = model.EncodeWaveform(model.GetWaveform(audioInfo.Samples, model.WAVEFORM_SAMPLES))
+
+	if result := <-Srv.Store.FileInfo().UpdateWaveform(info); result.Err != nil {
+		return result.Err
+	} else {
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_UPDATED, result.Data.(*model.FileInfo), "")
+	}
+
+	return nil
+}
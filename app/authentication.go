@@ -12,12 +12,12 @@ import (
 	"strings"
 )
 
-func CheckPasswordAndAllCriteria(user *model.User, password string, mfaToken string) *model.AppError {
+func CheckPasswordAndAllCriteria(user *model.User, password string, mfaToken string, ipAddress string) *model.AppError {
 	if err := CheckUserAdditionalAuthenticationCriteria(user, mfaToken); err != nil {
 		return err
 	}
 
-	if err := checkUserPassword(user, password); err != nil {
+	if err := checkUserPassword(user, password, ipAddress); err != nil {
 		return err
 	}
 
@@ -30,23 +30,23 @@ func doubleCheckPassword(user *model.User, password string) *model.AppError {
 		return err
 	}
 
-	if err := checkUserPassword(user, password); err != nil {
+	if err := checkUserPassword(user, password, ""); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func checkUserPassword(user *model.User, password string) *model.AppError {
+func checkUserPassword(user *model.User, password string, ipAddress string) *model.AppError {
 	if !model.ComparePassword(user.Password, password) {
-		if result := <-Srv.Store.User().UpdateFailedPasswordAttempts(user.Id, user.FailedAttempts+1); result.Err != nil {
-			return result.Err
+		if err := recordFailedLoginAttempt(model.LOGIN_ATTEMPT_TYPE_USER, user.Id, utils.Cfg.ServiceSettings.MaximumLoginAttempts, ipAddress); err != nil {
+			return err
 		}
 
 		return model.NewLocAppError("checkUserPassword", "api.user.check_user_password.invalid.app_error", nil, "user_id="+user.Id)
 	} else {
-		if result := <-Srv.Store.User().UpdateFailedPasswordAttempts(user.Id, 0); result.Err != nil {
-			return result.Err
+		if err := clearLoginAttempts(model.LOGIN_ATTEMPT_TYPE_USER, user.Id); err != nil {
+			return err
 		}
 
 		return nil
@@ -122,13 +122,29 @@ func CheckUserMfa(user *model.User, token string) *model.AppError {
 }
 
 func checkUserLoginAttempts(user *model.User) *model.AppError {
-	if user.FailedAttempts >= utils.Cfg.ServiceSettings.MaximumLoginAttempts {
+	if locked, err := isLoginLocked(model.LOGIN_ATTEMPT_TYPE_USER, user.Id); err != nil {
+		return err
+	} else if locked {
 		return model.NewLocAppError("checkUserLoginAttempts", "api.user.check_user_login_attempts.too_many.app_error", nil, "user_id="+user.Id)
 	}
 
 	return nil
 }
 
+func checkIpAddressLoginAttempts(ipAddress string) *model.AppError {
+	if len(ipAddress) == 0 {
+		return nil
+	}
+
+	if locked, err := isLoginLocked(model.LOGIN_ATTEMPT_TYPE_IP, ipAddress); err != nil {
+		return err
+	} else if locked {
+		return model.NewAppError("checkIpAddressLoginAttempts", "api.user.check_ip_address_login_attempts.too_many.app_error", nil, "ip_address="+ipAddress, http.StatusTooManyRequests)
+	}
+
+	return nil
+}
+
 func checkEmailVerified(user *model.User) *model.AppError {
 	if !user.EmailVerified && utils.Cfg.EmailSettings.RequireEmailVerification {
 		return model.NewLocAppError("Login", "api.user.login.not_verified.app_error", nil, "user_id="+user.Id)
@@ -143,7 +159,7 @@ func checkUserNotDisabled(user *model.User) *model.AppError {
 	return nil
 }
 
-func authenticateUser(user *model.User, password, mfaToken string) (*model.User, *model.AppError) {
+func authenticateUser(user *model.User, password, mfaToken, ipAddress string) (*model.User, *model.AppError) {
 	ldapAvailable := *utils.Cfg.LdapSettings.Enable && einterfaces.GetLdapInterface() != nil && utils.IsLicensed && *utils.License.Features.LDAP
 
 	if user.AuthService == model.USER_AUTH_SERVICE_LDAP {
@@ -167,7 +183,7 @@ func authenticateUser(user *model.User, password, mfaToken string) (*model.User,
 		err.StatusCode = http.StatusBadRequest
 		return user, err
 	} else {
-		if err := CheckPasswordAndAllCriteria(user, password, mfaToken); err != nil {
+		if err := CheckPasswordAndAllCriteria(user, password, mfaToken, ipAddress); err != nil {
 			err.StatusCode = http.StatusUnauthorized
 			return user, err
 		} else {
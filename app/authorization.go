@@ -83,6 +83,32 @@ func SessionHasPermissionToUser(session model.Session, userId string) bool {
 	return false
 }
 
+// SessionCanManageUser returns true if the session can use the admin functions that manage a user's account, like
+// resetting their password. System admins can manage anyone, but users who can only manage users can't manage anyone
+// with an admin role since they could then take over that user's account and use it to do whatever it's allowed to.
+func SessionCanManageUser(session model.Session, userId string) bool {
+	if SessionHasPermissionTo(session, model.PERMISSION_MANAGE_SYSTEM) {
+		return true
+	}
+
+	if !SessionHasPermissionTo(session, model.PERMISSION_MANAGE_USERS) {
+		return false
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return false
+	}
+
+	for _, role := range user.GetRoles() {
+		if model.IsAdminRole(role) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func SessionHasPermissionToPost(session model.Session, postId string, permission *model.Permission) bool {
 	post, err := GetSinglePost(postId)
 	if err != nil {
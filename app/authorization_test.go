@@ -34,3 +34,36 @@ func TestCheckIfRolesGrantPermission(t *testing.T) {
 	}
 
 }
+
+func TestSessionCanManageUser(t *testing.T) {
+	th := Setup().InitBasic()
+
+	// the first user created might have been made a system admin
+	if _, err := UpdateUserRoles(th.BasicUser.Id, model.ROLE_SYSTEM_USER.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateUserRoles(th.BasicUser2.Id, model.ROLE_SYSTEM_USER.Id+" "+model.ROLE_SYSTEM_COMPLIANCE_OFFICER.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	admin := model.Session{UserId: model.NewId(), Roles: model.ROLE_SYSTEM_USER.Id + " " + model.ROLE_SYSTEM_ADMIN.Id}
+	manager := model.Session{UserId: model.NewId(), Roles: model.ROLE_SYSTEM_USER.Id + " " + model.ROLE_SYSTEM_USER_MANAGER.Id}
+	user := model.Session{UserId: model.NewId(), Roles: model.ROLE_SYSTEM_USER.Id}
+
+	if !SessionCanManageUser(admin, th.BasicUser.Id) || !SessionCanManageUser(admin, th.BasicUser2.Id) {
+		t.Fatal("system admins should be able to manage anyone")
+	}
+
+	if !SessionCanManageUser(manager, th.BasicUser.Id) {
+		t.Fatal("user managers should be able to manage users")
+	}
+
+	if SessionCanManageUser(manager, th.BasicUser2.Id) {
+		t.Fatal("user managers shouldn't be able to manage admins")
+	}
+
+	if SessionCanManageUser(user, th.BasicUser.Id) {
+		t.Fatal("users shouldn't be able to manage other users")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	BACKGROUND_MIGRATION_BATCH_SIZE = 1000
+
+	BACKGROUND_MIGRATION_DATA_MIGRATION     = "migration"
+	BACKGROUND_MIGRATION_DATA_LAST_ID       = "last_id"
+	BACKGROUND_MIGRATION_DATA_ROWS_MIGRATED = "rows_migrated"
+	BACKGROUND_MIGRATION_DATA_TOTAL_ROWS    = "total_rows"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_BACKGROUND_MIGRATION, runBackgroundMigrationJob)
+}
+
+// StartBackgroundMigrations queues a job to finish any migrations that the database upgrade left to be run in the
+// background. A job that failed part way through is resumed from its last checkpoint instead, and nothing is queued if
+// a job is already waiting or running on another server.
+func StartBackgroundMigrations() {
+	if result := <-Srv.Store.BackgroundMigration().GetPending(); result.Err != nil {
+		l4g.Error(utils.T("app.background_migration.start.error"), result.Err.Error())
+		return
+	} else if len(result.Data.([]string)) == 0 {
+		return
+	}
+
+	if jobs, err := GetJobsByType(model.JOB_TYPE_BACKGROUND_MIGRATION, 0, 1); err != nil {
+		l4g.Error(utils.T("app.background_migration.start.error"), err.Error())
+		return
+	} else if len(jobs) > 0 {
+		switch jobs[0].Status {
+		case model.JOB_STATUS_PENDING, model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_CANCEL_REQUESTED:
+			return
+		case model.JOB_STATUS_ERROR:
+			if _, err := ResumeJob(jobs[0].Id); err != nil {
+				l4g.Error(utils.T("app.background_migration.start.error"), err.Error())
+			}
+			return
+		}
+	}
+
+	if _, err := CreateJob(model.JOB_TYPE_BACKGROUND_MIGRATION, map[string]string{}); err != nil {
+		l4g.Error(utils.T("app.background_migration.start.error"), err.Error())
+	}
+}
+
+func runBackgroundMigrationJob(job *model.Job) *model.AppError {
+	for {
+		var name string
+		if result := <-Srv.Store.BackgroundMigration().GetPending(); result.Err != nil {
+			return result.Err
+		} else if pending := result.Data.([]string); len(pending) == 0 {
+			break
+		} else {
+			name = pending[0]
+		}
+
+		// Start the migration from the beginning unless the job is being resumed part way through it
+		if job.Data[BACKGROUND_MIGRATION_DATA_MIGRATION] != name {
+			var total int64
+			if result := <-Srv.Store.BackgroundMigration().CountRows(name); result.Err != nil {
+				return result.Err
+			} else {
+				total = result.Data.(int64)
+			}
+
+			job.Data[BACKGROUND_MIGRATION_DATA_MIGRATION] = name
+			job.Data[BACKGROUND_MIGRATION_DATA_LAST_ID] = ""
+			job.SetDataInt64(BACKGROUND_MIGRATION_DATA_ROWS_MIGRATED, 0)
+			job.SetDataInt64(BACKGROUND_MIGRATION_DATA_TOTAL_ROWS, total)
+
+			l4g.Info(utils.T("app.background_migration.started.info"), name, total)
+		}
+
+		if err := migrateInBatches(job, name); err != nil {
+			return err
+		}
+
+		if result := <-Srv.Store.BackgroundMigration().Complete(name); result.Err != nil {
+			return result.Err
+		}
+
+		l4g.Info(utils.T("app.background_migration.finished.info"), name, job.GetDataInt64(BACKGROUND_MIGRATION_DATA_ROWS_MIGRATED))
+	}
+
+	return nil
+}
+
+// migrateInBatches runs a background migration one batch at a time from the job's last checkpoint, saving a new
+// checkpoint after each batch so that the migration can be resumed if the server goes down.
+func migrateInBatches(job *model.Job, name string) *model.AppError {
+	lastId := job.Data[BACKGROUND_MIGRATION_DATA_LAST_ID]
+	rowsMigrated := job.GetDataInt64(BACKGROUND_MIGRATION_DATA_ROWS_MIGRATED)
+	total := job.GetDataInt64(BACKGROUND_MIGRATION_DATA_TOTAL_ROWS)
+
+	for {
+		var ids []string
+		if result := <-Srv.Store.BackgroundMigration().MigrateBatch(name, lastId, BACKGROUND_MIGRATION_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			ids = result.Data.([]string)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		lastId = ids[len(ids)-1]
+		rowsMigrated += int64(len(ids))
+
+		job.Data[BACKGROUND_MIGRATION_DATA_LAST_ID] = lastId
+		job.SetDataInt64(BACKGROUND_MIGRATION_DATA_ROWS_MIGRATED, rowsMigrated)
+
+		if err := SetJobProgress(job, backgroundMigrationProgress(rowsMigrated, total)); err != nil {
+			return err
+		}
+
+		if len(ids) < BACKGROUND_MIGRATION_BATCH_SIZE {
+			return nil
+		}
+	}
+}
+
+func backgroundMigrationProgress(migrated int64, total int64) int64 {
+	if total <= 0 {
+		return 0
+	}
+
+	progress := migrated * 100 / total
+	if progress > 99 {
+		// 100 is reserved for when the job has actually finished
+		return 99
+	}
+
+	return progress
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestBackgroundMigrations(t *testing.T) {
+	th := Setup().InitBasic()
+
+	post := th.CreatePost(th.BasicChannel)
+	info := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{CreatorId: th.BasicUser.Id, PostId: post.Id, Path: "file", MimeType: "image/png"})).(*model.FileInfo)
+	defer func() {
+		<-Srv.Store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	store.Must(Srv.Store.System().SaveOrUpdate(&model.System{
+		Name:  store.BACKGROUND_MIGRATION_SYSTEM_PREFIX + store.BACKGROUND_MIGRATION_POST_FILE_COUNTS,
+		Value: store.BACKGROUND_MIGRATION_PENDING,
+	}))
+
+	StartBackgroundMigrations()
+
+	var job *model.Job
+	if jobs, err := GetJobsByType(model.JOB_TYPE_BACKGROUND_MIGRATION, 0, 1); err != nil {
+		t.Fatal(err)
+	} else if len(jobs) != 1 || jobs[0].Status != model.JOB_STATUS_PENDING {
+		t.Fatal("should have queued a job for the pending migration")
+	} else {
+		job = jobs[0]
+	}
+
+	// a second server starting up shouldn't queue another job
+	StartBackgroundMigrations()
+	if jobs, err := GetJobsByType(model.JOB_TYPE_BACKGROUND_MIGRATION, 0, 2); err != nil {
+		t.Fatal(err)
+	} else if len(jobs) != 1 {
+		t.Fatal("should only have queued one job")
+	}
+
+	if !RunJobNow(job) {
+		t.Fatal("should have run the job")
+	}
+
+	if job, err := GetJob(job.Id); err != nil {
+		t.Fatal(err)
+	} else if job.Status != model.JOB_STATUS_SUCCESS {
+		t.Fatal("job should have succeeded", job.Data["error"])
+	}
+
+	if migrated := store.Must(Srv.Store.Post().GetSingle(post.Id)).(*model.Post); migrated.FileCount != 1 || !migrated.HasImage {
+		t.Fatal("should have filled in the file count of the post")
+	}
+
+	if pending := store.Must(Srv.Store.BackgroundMigration().GetPending()).([]string); len(pending) != 0 {
+		t.Fatal("should have completed the migration")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+// testCacheInvalidationCluster records the cache invalidations sent to the cluster and leaves everything else
+// unimplemented.
+type testCacheInvalidationCluster struct {
+	einterfaces.ClusterInterface
+
+	mutex         sync.Mutex
+	invalidations []*model.CacheInvalidation
+}
+
+func (c *testCacheInvalidationCluster) SendCacheInvalidation(invalidation *model.CacheInvalidation) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.invalidations = append(c.invalidations, invalidation)
+}
+
+func TestSendCacheInvalidation(t *testing.T) {
+	Setup()
+
+	cluster := &testCacheInvalidationCluster{}
+	einterfaces.RegisterClusterInterface(cluster)
+	defer einterfaces.RegisterClusterInterface(nil)
+
+	channel := &model.Channel{Id: model.NewId(), TeamId: model.NewId(), Name: "town-square"}
+	InvalidateCacheForChannel(channel)
+	userId := model.NewId()
+	ClearSessionCacheForUser(userId)
+	postId := model.NewId()
+	InvalidateCacheForFileInfosForPost(postId)
+
+	expected := []model.CacheInvalidation{
+		{Type: model.CACHE_INVALIDATION_TYPE_CHANNEL, Id: channel.Id},
+		{Type: model.CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME, TeamId: channel.TeamId, Name: channel.Name},
+		{Type: model.CACHE_INVALIDATION_TYPE_SESSIONS_FOR_USER, Id: userId},
+		{Type: model.CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST, Id: postId},
+	}
+
+	if len(cluster.invalidations) != len(expected) {
+		t.Fatal("should have sent one invalidation per cache", cluster.invalidations)
+	}
+
+	for i, invalidation := range cluster.invalidations {
+		if *invalidation != expected[i] {
+			t.Fatal("sent the wrong invalidation", invalidation, expected[i])
+		}
+
+		if err := invalidation.IsValid(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	InvalidateCacheForFileInfosForPostSkipClusterSend(postId)
+	if len(cluster.invalidations) != len(expected) {
+		t.Fatal("shouldn't have sent an invalidation when skipping the cluster")
+	}
+}
+
+func TestHandleCacheInvalidation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	post := th.CreatePost(th.BasicChannel)
+	info := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	store.Must(Srv.Store.FileInfo().AttachToPost(info.Id, post.Id))
+
+	cluster := &testCacheInvalidationCluster{}
+	einterfaces.RegisterClusterInterface(cluster)
+	defer einterfaces.RegisterClusterInterface(nil)
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) != 1 {
+		t.Fatal("should have gotten the attached file")
+	}
+
+	// simulate another node deleting the files without the invalidation reaching this node yet, which can't go
+	// through the store since that invalidates this node's cache
+	Srv.Store.(*store.SqlStore).GetMaster().Exec("UPDATE FileInfo SET DeleteAt = :DeleteAt WHERE PostId = :PostId",
+		map[string]interface{}{"DeleteAt": model.GetMillis(), "PostId": post.Id})
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) != 1 {
+		t.Fatal("should have gotten the cached file")
+	}
+
+	if err := HandleCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST, post.Id)); err != nil {
+		t.Fatal(err)
+	}
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) != 0 {
+		t.Fatal("should have invalidated the cached files")
+	}
+
+	if len(cluster.invalidations) != 0 {
+		t.Fatal("shouldn't have sent a received invalidation back out", cluster.invalidations)
+	}
+
+	if err := HandleCacheInvalidation(&model.CacheInvalidation{Type: "junk", Id: post.Id}); err == nil {
+		t.Fatal("should have failed on an unknown invalidation type")
+	}
+}
@@ -86,45 +86,63 @@ func CreateDefaultChannels(teamId string) ([]*model.Channel, *model.AppError) {
 	return channels, nil
 }
 
+// JoinDefaultChannels adds a new member of a team to Town Square, Off-Topic and the team's default channels. The user is
+// either added to all of the channels that they aren't already in or none of them.
 func JoinDefaultChannels(teamId string, user *model.User, channelRole string) *model.AppError {
 	var err *model.AppError = nil
 
-	if result := <-Srv.Store.Channel().GetByName(teamId, "town-square", true); result.Err != nil {
-		err = result.Err
+	channels := []*model.Channel{}
+	for _, name := range []string{"town-square", "off-topic"} {
+		if result := <-Srv.Store.Channel().GetByName(teamId, name, true); result.Err != nil {
+			err = result.Err
+		} else {
+			channels = append(channels, result.Data.(*model.Channel))
+		}
+	}
+
+	if defaultChannels, defaultErr := getTeamDefaultChannelsToJoin(teamId); defaultErr != nil {
+		return defaultErr
 	} else {
-		townSquare := result.Data.(*model.Channel)
+		channels = append(channels, defaultChannels...)
+	}
 
-		cm := &model.ChannelMember{ChannelId: townSquare.Id, UserId: user.Id,
-			Roles: channelRole, NotifyProps: model.GetDefaultChannelNotifyProps()}
+	joined := []*model.Channel{}
+	members := []*model.ChannelMember{}
+	seen := map[string]bool{}
 
-		if cmResult := <-Srv.Store.Channel().SaveMember(cm); cmResult.Err != nil {
-			err = cmResult.Err
+	for _, channel := range channels {
+		if seen[channel.Id] {
+			continue
 		}
+		seen[channel.Id] = true
 
-		if err := postJoinChannelMessage(user, townSquare); err != nil {
-			l4g.Error(utils.T("api.channel.post_user_add_remove_message_and_forget.error"), err)
+		if result := <-Srv.Store.Channel().GetMember(channel.Id, user.Id); result.Err == nil {
+			continue
 		}
 
-		InvalidateCacheForChannelMembers(result.Data.(*model.Channel).Id)
+		joined = append(joined, channel)
+		members = append(members, &model.ChannelMember{
+			ChannelId:   channel.Id,
+			UserId:      user.Id,
+			Roles:       channelRole,
+			NotifyProps: model.GetDefaultChannelNotifyProps(),
+		})
 	}
 
-	if result := <-Srv.Store.Channel().GetByName(teamId, "off-topic", true); result.Err != nil {
-		err = result.Err
-	} else {
-		offTopic := result.Data.(*model.Channel)
-
-		cm := &model.ChannelMember{ChannelId: offTopic.Id, UserId: user.Id,
-			Roles: channelRole, NotifyProps: model.GetDefaultChannelNotifyProps()}
+	if len(members) == 0 {
+		return err
+	}
 
-		if cmResult := <-Srv.Store.Channel().SaveMember(cm); cmResult.Err != nil {
-			err = cmResult.Err
-		}
+	if result := <-Srv.Store.Channel().SaveMembers(members); result.Err != nil {
+		return result.Err
+	}
 
-		if err := postJoinChannelMessage(user, offTopic); err != nil {
+	for _, channel := range joined {
+		if err := postJoinChannelMessage(user, channel); err != nil {
 			l4g.Error(utils.T("api.channel.post_user_add_remove_message_and_forget.error"), err)
 		}
 
-		InvalidateCacheForChannelMembers(result.Data.(*model.Channel).Id)
+		InvalidateCacheForChannelMembers(channel.Id)
 	}
 
 	return err
@@ -181,6 +199,7 @@ func CreateChannel(channel *model.Channel, addMember bool) (*model.Channel, *mod
 			}
 
 			InvalidateCacheForUser(channel.CreatorId)
+			InvalidateCacheForChannelMembers(sc.Id)
 		}
 
 		return sc, nil
@@ -606,6 +625,22 @@ func GetChannelMemberCount(channelId string) (int64, *model.AppError) {
 	}
 }
 
+// GetChannelMemberCounts returns the number of members in each of the given channels.
+func GetChannelMemberCounts(channelIds []string) ([]*model.ChannelStats, *model.AppError) {
+	if result := <-Srv.Store.Channel().GetMemberCounts(channelIds, true); result.Err != nil {
+		return nil, result.Err
+	} else {
+		counts := result.Data.(map[string]int64)
+
+		stats := make([]*model.ChannelStats, len(channelIds))
+		for i, channelId := range channelIds {
+			stats[i] = &model.ChannelStats{ChannelId: channelId, MemberCount: counts[channelId]}
+		}
+
+		return stats, nil
+	}
+}
+
 func GetChannelCounts(teamId string, userId string) (*model.ChannelCounts, *model.AppError) {
 	if result := <-Srv.Store.Channel().GetChannelCounts(teamId, userId); result.Err != nil {
 		return nil, result.Err
@@ -871,6 +906,19 @@ func ViewChannel(view *model.ChannelView, teamId string, userId string, clearPus
 }
 
 func PermanentDeleteChannel(channel *model.Channel) *model.AppError {
+	if err := checkNotUnderLegalHold("", channel.Id); err != nil {
+		return err
+	}
+
+	// the previews and translations are found through the channel's posts, so they need to be deleted first
+	if result := <-Srv.Store.PermalinkPreview().PermanentDeleteByChannel(channel.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.PostTranslation().PermanentDeleteByChannel(channel.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Post().PermanentDeleteByChannel(channel.Id); result.Err != nil {
 		return result.Err
 	}
@@ -879,6 +927,16 @@ func PermanentDeleteChannel(channel *model.Channel) *model.AppError {
 		return result.Err
 	}
 
+	InvalidateCacheForChannelMembers(channel.Id)
+
+	if result := <-Srv.Store.TeamDefaultChannel().DeleteForChannel(channel.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.ChannelEmailAddress().DeleteForChannel(channel.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Channel().PermanentDelete(channel.Id); result.Err != nil {
 		return result.Err
 	}
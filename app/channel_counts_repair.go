@@ -0,0 +1,239 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CHANNEL_COUNTS_REPAIR_BATCH_SIZE       = 100
+	CHANNEL_COUNTS_REPAIR_POSTS_BATCH_SIZE = 1000
+
+	CHANNEL_COUNTS_REPAIR_DATA_LAST_CHANNEL_ID   = "last_channel_id"
+	CHANNEL_COUNTS_REPAIR_DATA_CHANNELS_REPAIRED = "channels_repaired"
+	CHANNEL_COUNTS_REPAIR_DATA_TOTAL_CHANNELS    = "total_channels"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_CHANNEL_COUNTS_REPAIR, runChannelCountsRepairJob)
+}
+
+// CreateChannelCountsRepairJob queues a job that recounts the message counts of every channel and its members from the
+// posts in it. The counts are normally kept up to date as posts are made and channels are viewed, so this is only
+// needed if they've drifted, such as after posts were imported or deleted directly from the database.
+func CreateChannelCountsRepairJob() (*model.Job, *model.AppError) {
+	return CreateJob(model.JOB_TYPE_CHANNEL_COUNTS_REPAIR, map[string]string{})
+}
+
+func runChannelCountsRepairJob(job *model.Job) *model.AppError {
+	// Count the channels once up front so that the progress doesn't move backwards if channels are created later
+	if _, counted := job.Data[CHANNEL_COUNTS_REPAIR_DATA_TOTAL_CHANNELS]; !counted {
+		var total int64
+		for _, channelType := range []string{model.CHANNEL_OPEN, model.CHANNEL_PRIVATE, model.CHANNEL_DIRECT} {
+			if result := <-Srv.Store.Channel().AnalyticsTypeCount("", channelType); result.Err != nil {
+				return result.Err
+			} else {
+				total += result.Data.(int64)
+			}
+		}
+
+		job.SetDataInt64(CHANNEL_COUNTS_REPAIR_DATA_TOTAL_CHANNELS, total)
+	}
+
+	total := job.GetDataInt64(CHANNEL_COUNTS_REPAIR_DATA_TOTAL_CHANNELS)
+	lastChannelId := job.Data[CHANNEL_COUNTS_REPAIR_DATA_LAST_CHANNEL_ID]
+	channelsRepaired := job.GetDataInt64(CHANNEL_COUNTS_REPAIR_DATA_CHANNELS_REPAIRED)
+
+	for {
+		var channelIds []string
+		if result := <-Srv.Store.Channel().RepairMessageCounts(lastChannelId, CHANNEL_COUNTS_REPAIR_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			channelIds = result.Data.([]string)
+		}
+
+		if len(channelIds) == 0 {
+			break
+		}
+
+		for _, channelId := range channelIds {
+			if err := repairMentionCounts(channelId); err != nil {
+				return err
+			}
+		}
+
+		lastChannelId = channelIds[len(channelIds)-1]
+		channelsRepaired += int64(len(channelIds))
+
+		job.Data[CHANNEL_COUNTS_REPAIR_DATA_LAST_CHANNEL_ID] = lastChannelId
+		job.SetDataInt64(CHANNEL_COUNTS_REPAIR_DATA_CHANNELS_REPAIRED, channelsRepaired)
+
+		if err := SetJobProgress(job, channelCountsRepairProgress(channelsRepaired, total)); err != nil {
+			return err
+		}
+
+		if len(channelIds) < CHANNEL_COUNTS_REPAIR_BATCH_SIZE {
+			break
+		}
+	}
+
+	l4g.Info(utils.T("app.channel_counts_repair.finished.info"), channelsRepaired)
+
+	return nil
+}
+
+// repairMentionCounts recounts the mentions of each member of a channel in the posts that they haven't seen yet, using
+// the same rules as SendNotifications. Posts made while it runs are left to be counted as they're sent.
+func repairMentionCounts(channelId string) *model.AppError {
+	recountedAt := model.GetMillis()
+
+	cchan := Srv.Store.Channel().Get(channelId, false)
+	pchan := Srv.Store.User().GetAllProfilesInChannel(channelId, false)
+	mchan := Srv.Store.Channel().GetMembers(channelId)
+
+	var channel *model.Channel
+	if result := <-cchan; result.Err != nil {
+		return result.Err
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	var profiles map[string]*model.User
+	if result := <-pchan; result.Err != nil {
+		return result.Err
+	} else {
+		profiles = result.Data.(map[string]*model.User)
+	}
+
+	members := make(map[string]*model.ChannelMember)
+	mentionCounts := make(map[string]int64)
+	since := channel.LastPostAt
+	if result := <-mchan; result.Err != nil {
+		return result.Err
+	} else {
+		for _, member := range result.Data.([]model.ChannelMember) {
+			member := member
+			members[member.UserId] = &member
+			mentionCounts[member.UserId] = 0
+
+			if member.LastViewedAt < since {
+				since = member.LastViewedAt
+			}
+		}
+	}
+
+	keywords := GetMentionKeywordsInChannel(profiles)
+	threads := make(map[string]*model.PostList)
+
+	startTime, startPostId := since, ""
+	for since < channel.LastPostAt {
+		var posts []*model.Post
+		if result := <-Srv.Store.Post().GetPostsBatchForExport(channelId, startTime, startPostId, CHANNEL_COUNTS_REPAIR_POSTS_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			posts = result.Data.([]*model.Post)
+		}
+
+		for _, post := range posts {
+			if post.CreateAt > recountedAt {
+				break
+			}
+
+			mentioned, err := getMentionedUserIdsForRepair(post, channel, profiles, keywords, threads)
+			if err != nil {
+				return err
+			}
+
+			for userId := range mentioned {
+				if member, ok := members[userId]; ok && post.CreateAt > member.LastViewedAt {
+					mentionCounts[userId]++
+				}
+			}
+		}
+
+		if len(posts) < CHANNEL_COUNTS_REPAIR_POSTS_BATCH_SIZE || posts[len(posts)-1].CreateAt > recountedAt {
+			break
+		}
+
+		startTime, startPostId = posts[len(posts)-1].CreateAt, posts[len(posts)-1].Id
+	}
+
+	for userId, member := range members {
+		if member.MentionCount == mentionCounts[userId] {
+			continue
+		}
+
+		member.MentionCount = mentionCounts[userId]
+
+		// If the member has viewed the channel or been mentioned since they were read, the count is left alone
+		if result := <-Srv.Store.Channel().RepairMentionCount(member); result.Err != nil {
+			return result.Err
+		} else if result.Data.(bool) {
+			InvalidateCacheForChannelMembers(channelId)
+		}
+	}
+
+	return nil
+}
+
+// getMentionedUserIdsForRepair returns the users that were mentioned by a post when it was sent. The threads that replies
+// belong to are kept in threads so that they're only loaded once.
+func getMentionedUserIdsForRepair(post *model.Post, channel *model.Channel, profiles map[string]*model.User, keywords map[string][]string, threads map[string]*model.PostList) (map[string]bool, *model.AppError) {
+	mentioned := make(map[string]bool)
+
+	if channel.Type == model.CHANNEL_DIRECT {
+		for _, userId := range strings.Split(channel.Name, "__") {
+			if userId != post.UserId || post.Props["from_webhook"] == "true" {
+				mentioned[userId] = true
+			}
+		}
+	} else {
+		mentioned, _, _, _, _ = GetExplicitMentions(post.Message, keywords)
+
+		if len(post.RootId) > 0 {
+			thread, ok := threads[post.RootId]
+			if !ok {
+				if result := <-Srv.Store.Post().Get(post.RootId); result.Err != nil {
+					return nil, result.Err
+				} else {
+					thread = result.Data.(*model.PostList)
+					threads[post.RootId] = thread
+				}
+			}
+
+			// only the posts in the thread from before this one would have been there when it was sent
+			for _, threadPost := range thread.Posts {
+				if profile, ok := profiles[threadPost.UserId]; ok && threadPost.CreateAt < post.CreateAt &&
+					(profile.NotifyProps["comments"] == "any" || (profile.NotifyProps["comments"] == "root" && threadPost.Id == post.RootId)) {
+					mentioned[threadPost.UserId] = true
+				}
+			}
+		}
+
+		if post.Props["from_webhook"] != "true" {
+			delete(mentioned, post.UserId)
+		}
+	}
+
+	return mentioned, nil
+}
+
+func channelCountsRepairProgress(repaired int64, total int64) int64 {
+	if total <= 0 {
+		return 0
+	}
+
+	progress := repaired * 100 / total
+	if progress > 99 {
+		// 100 is reserved for when the job has actually finished
+		return 99
+	}
+
+	return progress
+}
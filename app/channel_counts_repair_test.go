@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestChannelCountsRepair(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	th.CreatePost(channel)
+	th.CreatePost(channel)
+
+	// knock the counts out of sync with the posts in the channel
+	channel = store.Must(Srv.Store.Channel().Get(channel.Id, false)).(*model.Channel)
+	channel.TotalMsgCount = 50
+	store.Must(Srv.Store.Channel().Update(channel))
+
+	job, err := CreateChannelCountsRepairJob()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !RunJobNow(job) {
+		t.Fatal("should have run the job")
+	}
+
+	if job, err = GetJob(job.Id); err != nil {
+		t.Fatal(err)
+	} else if job.Status != model.JOB_STATUS_SUCCESS {
+		t.Fatal("job should have succeeded", job.Data["error"])
+	} else if job.GetDataInt64(CHANNEL_COUNTS_REPAIR_DATA_CHANNELS_REPAIRED) < 1 {
+		t.Fatal("should have repaired the channels")
+	}
+
+	if repaired := store.Must(Srv.Store.Channel().Get(channel.Id, false)).(*model.Channel); repaired.TotalMsgCount != 2 {
+		t.Fatal("should have recounted the posts in the channel", repaired.TotalMsgCount)
+	}
+}
+
+func TestRepairMentionCounts(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	store.Must(Srv.Store.Channel().SaveMember(&model.ChannelMember{
+		ChannelId:    channel.Id,
+		UserId:       th.BasicUser2.Id,
+		NotifyProps:  model.GetDefaultChannelNotifyProps(),
+		MentionCount: 7,
+	}))
+
+	// save the posts directly so that no notifications are sent for them
+	root := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser2.Id, Message: "root"})).(*model.Post)
+	store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "hello @" + th.BasicUser2.Username}))
+	store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "hello"}))
+	store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "reply", RootId: root.Id, ParentId: root.Id}))
+	store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser2.Id, Message: "hello @" + th.BasicUser2.Username}))
+
+	if err := repairMentionCounts(channel.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// replies to threads aren't mentions by default and users can't mention themselves
+	if member := store.Must(Srv.Store.Channel().GetMember(channel.Id, th.BasicUser2.Id)).(*model.ChannelMember); member.MentionCount != 1 {
+		t.Fatal("should have recounted the mentions of the member", member.MentionCount)
+	}
+
+	if member := store.Must(Srv.Store.Channel().GetMember(channel.Id, th.BasicUser.Id)).(*model.ChannelMember); member.MentionCount != 0 {
+		t.Fatal("shouldn't have counted any mentions for a member who wasn't mentioned", member.MentionCount)
+	}
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CLUSTER_MESSAGE_PATH     = "/cluster/message"
+	CLUSTER_MESSAGE_MAX_SIZE = 50 * 1024 * 1024 // 50MB, since the whole log file can be sent
+	CLUSTER_SEND_QUEUE_SIZE  = 4096
+	CLUSTER_REQUEST_TIMEOUT  = 15 * time.Second
+)
+
+// Cluster is the einterfaces.ClusterInterface that's used when clustering is enabled and no other one has been
+// registered. The nodes find each other through the ClusterDiscovery table and send each other messages over HTTP
+// on the inter-node listen address, signed with the at rest encryption key that they share through their config.
+type Cluster struct {
+	discovery     *ClusterDiscoveryService
+	listenAddress string
+	client        *http.Client
+
+	// the events to be sent to every other node, which are sent in order by a single goroutine
+	sendQueue chan *model.ClusterMessage
+
+	server  *http.Server
+	stop    chan bool
+	stopped chan bool
+}
+
+// InitCluster registers a Cluster that uses the discovery service started by StartClusterDiscovery, unless another
+// implementation has already been registered.
+func InitCluster() {
+	if !*utils.Cfg.ClusterSettings.Enable || clusterDiscovery == nil || einterfaces.GetClusterInterface() != nil {
+		return
+	}
+
+	einterfaces.RegisterClusterInterface(NewCluster(clusterDiscovery, *utils.Cfg.ClusterSettings.InterNodeListenAddress))
+}
+
+func NewCluster(discovery *ClusterDiscoveryService, listenAddress string) *Cluster {
+	return &Cluster{
+		discovery:     discovery,
+		listenAddress: listenAddress,
+		client:        &http.Client{Timeout: CLUSTER_REQUEST_TIMEOUT},
+		sendQueue:     make(chan *model.ClusterMessage, CLUSTER_SEND_QUEUE_SIZE),
+	}
+}
+
+func (c *Cluster) StartInterNodeCommunication() {
+	listener, err := net.Listen("tcp", c.listenAddress)
+	if err != nil {
+		l4g.Error(utils.T("app.cluster.start.listen.error"), c.listenAddress, err.Error())
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(CLUSTER_MESSAGE_PATH, c.handleMessage)
+
+	c.server = &http.Server{Handler: mux}
+	c.stop = make(chan bool)
+	c.stopped = make(chan bool)
+
+	go c.server.Serve(listener)
+	go c.sendQueuedMessages()
+
+	l4g.Info(utils.T("app.cluster.start.info"), listener.Addr().String())
+}
+
+func (c *Cluster) StopInterNodeCommunication() {
+	if c.server == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.stopped
+
+	c.server.Close()
+	c.server = nil
+}
+
+func (c *Cluster) GetClusterId() string {
+	return c.discovery.Id
+}
+
+func (c *Cluster) IsLeader() bool {
+	return c.discovery.IsLeader()
+}
+
+func (c *Cluster) GetClusterInfos() []*model.ClusterInfo {
+	nodes := c.discovery.GetNodes()
+
+	infos := make([]*model.ClusterInfo, 0, len(nodes))
+	for _, node := range nodes {
+		infos = append(infos, &model.ClusterInfo{
+			Id:                 node.Id,
+			Version:            node.Version,
+			ConfigHash:         node.ConfigHash,
+			InterNodeUrl:       getClusterNodeUrl(node),
+			Hostname:           node.Hostname,
+			LastSuccessfulPing: node.LastPingAt,
+			IsAlive:            node.IsAlive(),
+		})
+	}
+
+	return infos
+}
+
+// GetClusterStats returns the stats of the other nodes. The ones that can't be reached are left out.
+func (c *Cluster) GetClusterStats() ([]*model.ClusterStats, *model.AppError) {
+	stats := make([]*model.ClusterStats, 0)
+
+	for _, node := range c.getOtherNodes() {
+		data, err := c.sendToNode(node, model.NewClusterMessage(model.CLUSTER_EVENT_GET_STATS, c.GetClusterId(), ""))
+		if err != nil {
+			l4g.Warn(utils.T("app.cluster.get_stats.warn"), node.Hostname, err.Error())
+			continue
+		}
+
+		if nodeStats := model.ClusterStatsFromJson(bytes.NewReader(data)); nodeStats != nil {
+			stats = append(stats, nodeStats)
+		}
+	}
+
+	return stats, nil
+}
+
+func (c *Cluster) SendCacheInvalidation(invalidation *model.CacheInvalidation) {
+	c.queue(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_CACHE, c.GetClusterId(), invalidation.ToJson()))
+}
+
+func (c *Cluster) Publish(event *model.WebSocketEvent) {
+	c.queue(model.NewClusterMessage(model.CLUSTER_EVENT_PUBLISH, c.GetClusterId(), event.ToJson()))
+}
+
+func (c *Cluster) UpdateStatus(status *model.Status) {
+	c.queue(model.NewClusterMessage(model.CLUSTER_EVENT_UPDATE_STATUS, c.GetClusterId(), status.ToJson()))
+}
+
+// GetLogs returns the log lines of the other nodes. The ones that can't be reached are left out.
+func (c *Cluster) GetLogs() ([]string, *model.AppError) {
+	lines := make([]string, 0)
+
+	for _, node := range c.getOtherNodes() {
+		data, err := c.sendToNode(node, model.NewClusterMessage(model.CLUSTER_EVENT_GET_LOGS, c.GetClusterId(), ""))
+		if err != nil {
+			l4g.Warn(utils.T("app.cluster.get_logs.warn"), node.Hostname, err.Error())
+			continue
+		}
+
+		lines = append(lines, model.ArrayFromJson(bytes.NewReader(data))...)
+	}
+
+	return lines, nil
+}
+
+// GetLogPage returns a page of the log entries of the node that the query is for.
+func (c *Cluster) GetLogPage(query *model.LogQuery) (*model.LogPage, *model.AppError) {
+	for _, node := range c.getOtherNodes() {
+		if node.Id != query.NodeId {
+			continue
+		}
+
+		data, err := c.sendToNode(node, model.NewClusterMessage(model.CLUSTER_EVENT_GET_LOG_PAGE, c.GetClusterId(), query.ToJson()))
+		if err != nil {
+			return nil, err
+		}
+
+		if page := model.LogPageFromJson(bytes.NewReader(data)); page != nil {
+			return page, nil
+		}
+
+		return nil, model.NewAppError("Cluster.GetLogPage", "app.cluster.get_log_page.decode.app_error", nil, "node_id="+node.Id, http.StatusInternalServerError)
+	}
+
+	return nil, model.NewAppError("Cluster.GetLogPage", "app.cluster.get_log_page.node.app_error", nil, "node_id="+query.NodeId, http.StatusNotFound)
+}
+
+// ConfigChanged tells the other nodes to reload their config after it was changed on this one.
+func (c *Cluster) ConfigChanged(previousConfig *model.Config, newConfig *model.Config, sendToOtherServer bool) *model.AppError {
+	if !sendToOtherServer {
+		return nil
+	}
+
+	return c.sendToOtherNodes(model.NewClusterMessage(model.CLUSTER_EVENT_CONFIG_CHANGED, c.GetClusterId(), ""))
+}
+
+func (c *Cluster) InvalidateAllCaches() *model.AppError {
+	return c.sendToOtherNodes(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES, c.GetClusterId(), ""))
+}
+
+// getOtherNodes returns the live nodes in the cluster other than this one.
+func (c *Cluster) getOtherNodes() []*model.ClusterDiscovery {
+	nodes := make([]*model.ClusterDiscovery, 0)
+	for _, node := range c.discovery.GetNodes() {
+		if node.Id != c.GetClusterId() && node.IsAlive() {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+func getClusterNodeUrl(node *model.ClusterDiscovery) string {
+	return "http://" + net.JoinHostPort(node.Hostname, strconv.Itoa(int(node.Port)))
+}
+
+// queue sends a message to the other nodes in the background. It's dropped if too many messages are waiting already
+// rather than slowing down the request that it's for.
+func (c *Cluster) queue(message *model.ClusterMessage) {
+	select {
+	case c.sendQueue <- message:
+	default:
+		l4g.Warn(utils.T("app.cluster.queue_full.warn"), message.Event)
+	}
+}
+
+func (c *Cluster) sendQueuedMessages() {
+	defer close(c.stopped)
+
+	for {
+		select {
+		case message := <-c.sendQueue:
+			if err := c.sendToOtherNodes(message); err != nil {
+				l4g.Warn(utils.T("app.cluster.send.warn"), message.Event, err.Error())
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// sendToOtherNodes sends a message to each of the other nodes, returning the first error after trying all of them.
+func (c *Cluster) sendToOtherNodes(message *model.ClusterMessage) *model.AppError {
+	var firstErr *model.AppError
+
+	for _, node := range c.getOtherNodes() {
+		if _, err := c.sendToNode(node, message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// sendToNode sends a message to a node and returns the body of its response.
+func (c *Cluster) sendToNode(node *model.ClusterDiscovery, message *model.ClusterMessage) ([]byte, *model.AppError) {
+	body := []byte(message.ToJson())
+
+	req, err := http.NewRequest("POST", getClusterNodeUrl(node)+CLUSTER_MESSAGE_PATH, bytes.NewReader(body))
+	if err != nil {
+		return nil, model.NewAppError("Cluster.sendToNode", "app.cluster.send.app_error", map[string]interface{}{"Hostname": node.Hostname}, err.Error(), http.StatusInternalServerError)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.HEADER_CLUSTER_SIGNATURE, model.SignClusterMessage(utils.Cfg.SqlSettings.AtRestEncryptKey, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, model.NewAppError("Cluster.sendToNode", "app.cluster.send.app_error", map[string]interface{}{"Hostname": node.Hostname}, err.Error(), http.StatusInternalServerError)
+	}
+	defer func() {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewAppError("Cluster.sendToNode", "app.cluster.send.app_error", map[string]interface{}{"Hostname": node.Hostname}, "status="+strconv.Itoa(resp.StatusCode), http.StatusInternalServerError)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, CLUSTER_MESSAGE_MAX_SIZE))
+	if err != nil {
+		return nil, model.NewAppError("Cluster.sendToNode", "app.cluster.send.app_error", map[string]interface{}{"Hostname": node.Hostname}, err.Error(), http.StatusInternalServerError)
+	}
+
+	return data, nil
+}
+
+func (c *Cluster) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, CLUSTER_MESSAGE_MAX_SIZE))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !model.IsClusterMessageSignatureValid(utils.Cfg.SqlSettings.AtRestEncryptKey, body, r.Header.Get(model.HEADER_CLUSTER_SIGNATURE)) {
+		l4g.Warn(utils.T("app.cluster.receive.signature.warn"), r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	message := model.ClusterMessageFromJson(bytes.NewReader(body))
+	if message == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if data, err := c.receive(message); err != nil {
+		l4g.Warn(utils.T("app.cluster.receive.warn"), message.Event, message.NodeId, err.Error())
+		w.WriteHeader(err.StatusCode)
+	} else {
+		w.Write([]byte(data))
+	}
+}
+
+// receive applies a message from another node to this one without sending it back out and returns the response to it.
+func (c *Cluster) receive(message *model.ClusterMessage) (string, *model.AppError) {
+	data := strings.NewReader(message.Data)
+
+	switch message.Event {
+	case model.CLUSTER_EVENT_PUBLISH:
+		if event := model.WebSocketEventFromJson(data); event != nil {
+			PublishSkipClusterSend(event)
+			return "", nil
+		}
+	case model.CLUSTER_EVENT_UPDATE_STATUS:
+		if status := model.StatusFromJson(data); status != nil {
+			AddStatusCacheSkipClusterSend(status)
+			return "", nil
+		}
+	case model.CLUSTER_EVENT_INVALIDATE_CACHE:
+		if invalidation := model.CacheInvalidationFromJson(data); invalidation != nil {
+			return "", HandleCacheInvalidation(invalidation)
+		}
+	case model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES:
+		InvalidateAllCachesSkipSend()
+		return "", nil
+	case model.CLUSTER_EVENT_CONFIG_CHANGED:
+		return "", ReloadConfig()
+	case model.CLUSTER_EVENT_GET_LOGS:
+		if lines, err := GetLogsSkipSend(); err != nil {
+			return "", err
+		} else {
+			return model.ArrayToJson(lines), nil
+		}
+	case model.CLUSTER_EVENT_GET_LOG_PAGE:
+		if query := model.LogQueryFromJson(data); query != nil {
+			if page, err := GetLogPageSkipSend(query); err != nil {
+				return "", err
+			} else {
+				page.NodeId = c.GetClusterId()
+				return page.ToJson(), nil
+			}
+		}
+	case model.CLUSTER_EVENT_GET_STATS:
+		stats := &model.ClusterStats{
+			Id:                        c.GetClusterId(),
+			TotalWebsocketConnections: TotalWebsocketConnections(),
+			TotalMasterDbConnections:  Srv.Store.TotalMasterDbConnections(),
+			TotalReadDbConnections:    Srv.Store.TotalReadDbConnections(),
+		}
+		return stats.ToJson(), nil
+	default:
+		return "", model.NewAppError("Cluster.receive", "app.cluster.receive.event.app_error", nil, fmt.Sprintf("event=%v", message.Event), http.StatusBadRequest)
+	}
+
+	return "", model.NewAppError("Cluster.receive", "app.cluster.receive.data.app_error", nil, fmt.Sprintf("event=%v", message.Event), http.StatusBadRequest)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CLUSTER_DISCOVERY_TASK_NAME = "Cluster Discovery"
+)
+
+var clusterDiscovery *ClusterDiscoveryService
+
+// ClusterDiscoveryService registers this node in the ClusterDiscovery table and keeps
+// its entry alive so that the other app servers behind the load balancer can find it.
+// The port that's registered is the one that the node listens on for messages from
+// the other nodes.
+type ClusterDiscoveryService struct {
+	model.ClusterDiscovery
+	mutex    sync.RWMutex
+	isLeader bool
+	nodes    []*model.ClusterDiscovery
+}
+
+func NewClusterDiscoveryService() *ClusterDiscoveryService {
+	ds := &ClusterDiscoveryService{}
+	ds.Type = model.CDS_TYPE_APP
+	ds.ClusterName = *utils.Cfg.ClusterSettings.ClusterName
+	ds.Version = model.CurrentVersion
+	ds.AutoFillHostname()
+
+	if _, port, err := net.SplitHostPort(*utils.Cfg.ClusterSettings.InterNodeListenAddress); err == nil {
+		if p, err := strconv.ParseInt(port, 10, 32); err == nil {
+			ds.Port = int32(p)
+		}
+	}
+
+	return ds
+}
+
+func StartClusterDiscovery() {
+	if !*utils.Cfg.ClusterSettings.Enable {
+		return
+	}
+
+	if clusterDiscovery != nil {
+		clusterDiscovery.Stop()
+	}
+
+	clusterDiscovery = NewClusterDiscoveryService()
+	clusterDiscovery.Start()
+}
+
+func StopClusterDiscovery() {
+	if clusterDiscovery != nil {
+		clusterDiscovery.Stop()
+		clusterDiscovery = nil
+	}
+}
+
+func (ds *ClusterDiscoveryService) Start() {
+	<-Srv.Store.ClusterDiscovery().Cleanup()
+
+	ds.ConfigHash = utils.CfgHash
+
+	if result := <-Srv.Store.ClusterDiscovery().Exists(&ds.ClusterDiscovery); result.Err != nil {
+		l4g.Error(utils.T("api.cluster_discovery.start.exists.error"), result.Err.Error())
+	} else if result.Data.(bool) {
+		if result := <-Srv.Store.ClusterDiscovery().Delete(&ds.ClusterDiscovery); result.Err != nil {
+			l4g.Error(utils.T("api.cluster_discovery.start.delete.error"), result.Err.Error())
+		}
+	}
+
+	if result := <-Srv.Store.ClusterDiscovery().Save(&ds.ClusterDiscovery); result.Err != nil {
+		l4g.Error(utils.T("api.cluster_discovery.start.save.error"), result.Err.Error())
+		return
+	}
+
+	l4g.Info(utils.T("api.cluster_discovery.start.info"), ds.Hostname, ds.ClusterName)
+
+	ds.heartbeat()
+	model.CreateRecurringTask(CLUSTER_DISCOVERY_TASK_NAME, ds.heartbeat, time.Duration(model.CDS_PING_INTERVAL_MILLIS)*time.Millisecond)
+}
+
+func (ds *ClusterDiscoveryService) Stop() {
+	if task := model.GetTaskByName(CLUSTER_DISCOVERY_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	if result := <-Srv.Store.ClusterDiscovery().Delete(&ds.ClusterDiscovery); result.Err != nil {
+		l4g.Error(utils.T("api.cluster_discovery.stop.delete.error"), result.Err.Error())
+	}
+
+	ds.mutex.Lock()
+	ds.isLeader = false
+	ds.nodes = nil
+	ds.mutex.Unlock()
+}
+
+func (ds *ClusterDiscoveryService) heartbeat() {
+	ds.ConfigHash = utils.CfgHash
+
+	if result := <-Srv.Store.ClusterDiscovery().SetLastPingAt(&ds.ClusterDiscovery); result.Err != nil {
+		l4g.Error(utils.T("api.cluster_discovery.heartbeat.ping.error"), result.Err.Error())
+		return
+	}
+
+	result := <-Srv.Store.ClusterDiscovery().GetAll(ds.Type, ds.ClusterName)
+	if result.Err != nil {
+		l4g.Error(utils.T("api.cluster_discovery.heartbeat.get_all.error"), result.Err.Error())
+		return
+	}
+
+	nodes := result.Data.([]*model.ClusterDiscovery)
+
+	for _, node := range model.GetInconsistentClusterNodes(nodes, ds.ConfigHash) {
+		l4g.Warn(utils.T("api.cluster_discovery.heartbeat.config_mismatch.warn"), node.Hostname, node.Port)
+	}
+
+	leader := model.GetClusterLeader(nodes)
+
+	ds.mutex.Lock()
+	wasLeader := ds.isLeader
+	ds.isLeader = leader != nil && leader.Id == ds.Id
+	ds.nodes = nodes
+	isLeader := ds.isLeader
+	ds.mutex.Unlock()
+
+	if isLeader && !wasLeader {
+		l4g.Info(utils.T("api.cluster_discovery.heartbeat.leader.info"), ds.Hostname)
+	}
+}
+
+func (ds *ClusterDiscoveryService) IsLeader() bool {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	return ds.isLeader
+}
+
+func (ds *ClusterDiscoveryService) GetNodes() []*model.ClusterDiscovery {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	nodes := make([]*model.ClusterDiscovery, len(ds.nodes))
+	copy(nodes, ds.nodes)
+	return nodes
+}
+
+// IsLeader returns true if this node should run the singleton background jobs for the
+// cluster. A node that isn't part of a cluster is always its own leader.
+func IsLeader() bool {
+	if !*utils.Cfg.ClusterSettings.Enable {
+		return true
+	}
+
+	if einterfaces.GetClusterInterface() != nil {
+		return einterfaces.GetClusterInterface().IsLeader()
+	}
+
+	if clusterDiscovery != nil {
+		return clusterDiscovery.IsLeader()
+	}
+
+	return false
+}
+
+func GetClusterDiscoveryNodes() []*model.ClusterDiscovery {
+	if clusterDiscovery == nil {
+		return make([]*model.ClusterDiscovery, 0)
+	}
+
+	return clusterDiscovery.GetNodes()
+}
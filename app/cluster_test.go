@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+// startTestClusterNodes starts a cluster of nodes listening on free local ports, registered in the ClusterDiscovery
+// table under a cluster name of their own.
+func startTestClusterNodes(t *testing.T, count int) []*Cluster {
+	clusterName := model.NewId()
+
+	nodes := make([]*Cluster, count)
+	for i := range nodes {
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		ds := &ClusterDiscoveryService{}
+		ds.Type = model.CDS_TYPE_APP
+		ds.ClusterName = clusterName
+		ds.Hostname = "localhost"
+		ds.Port = int32(port)
+		store.Must(Srv.Store.ClusterDiscovery().Save(&ds.ClusterDiscovery))
+
+		nodes[i] = NewCluster(ds, net.JoinHostPort("localhost", strconv.Itoa(port)))
+		nodes[i].StartInterNodeCommunication()
+	}
+
+	for _, node := range nodes {
+		node.discovery.heartbeat()
+	}
+
+	return nodes
+}
+
+func stopTestClusterNodes(nodes []*Cluster) {
+	for _, node := range nodes {
+		node.StopInterNodeCommunication()
+		<-Srv.Store.ClusterDiscovery().Delete(&node.discovery.ClusterDiscovery)
+	}
+}
+
+func TestClusterNodes(t *testing.T) {
+	Setup()
+
+	nodes := startTestClusterNodes(t, 2)
+	defer stopTestClusterNodes(nodes)
+
+	for _, node := range nodes {
+		if infos := node.GetClusterInfos(); len(infos) != 2 {
+			t.Fatal("should have found both nodes", infos)
+		} else {
+			for _, info := range infos {
+				if !info.IsAlive {
+					t.Fatal("should be alive", info)
+				}
+			}
+		}
+	}
+
+	leader := model.GetClusterLeader(nodes[0].discovery.GetNodes())
+	for _, node := range nodes {
+		if node.IsLeader() != (node.GetClusterId() == leader.Id) {
+			t.Fatal("only the oldest node should be the leader")
+		}
+	}
+
+	stats, err := nodes[0].GetClusterStats()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(stats) != 1 || stats[0].Id != nodes[1].GetClusterId() {
+		t.Fatal("should have gotten the stats of the other node", stats)
+	}
+
+	if _, err := nodes[0].GetLogPage(&model.LogQuery{NodeId: model.NewId(), Limit: 10}); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed to get the logs of an unknown node")
+	}
+
+	if err := nodes[0].InvalidateAllCaches(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterSendCacheInvalidation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	nodes := startTestClusterNodes(t, 2)
+	defer stopTestClusterNodes(nodes)
+
+	post := th.CreatePost(th.BasicChannel)
+	info := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	store.Must(Srv.Store.FileInfo().AttachToPost(info.Id, post.Id))
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) != 1 {
+		t.Fatal("should have gotten the attached file")
+	}
+
+	// both nodes share this process's caches, so the other node clearing the cache shows that it got the message
+	Srv.Store.(*store.SqlStore).GetMaster().Exec("UPDATE FileInfo SET DeleteAt = :DeleteAt WHERE PostId = :PostId",
+		map[string]interface{}{"DeleteAt": model.GetMillis(), "PostId": post.Id})
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) != 1 {
+		t.Fatal("should have gotten the cached file")
+	}
+
+	nodes[0].SendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST, post.Id))
+
+	for i := 0; ; i++ {
+		if infos := store.Must(Srv.Store.FileInfo().GetForPost(post.Id, true)).([]*model.FileInfo); len(infos) == 0 {
+			break
+		} else if i == 50 {
+			t.Fatal("the other node should have invalidated the cached files")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestClusterRejectsUnsignedMessages(t *testing.T) {
+	Setup()
+
+	nodes := startTestClusterNodes(t, 1)
+	defer stopTestClusterNodes(nodes)
+
+	url := getClusterNodeUrl(&nodes[0].discovery.ClusterDiscovery) + CLUSTER_MESSAGE_PATH
+	body := []byte(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES, model.NewId(), "").ToJson())
+
+	send := func(signature string) int {
+		req, _ := http.NewRequest("POST", url, bytes.NewReader(body))
+		req.Header.Set(model.HEADER_CLUSTER_SIGNATURE, signature)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if status := send(""); status != http.StatusUnauthorized {
+		t.Fatal("should have rejected an unsigned message", status)
+	}
+
+	if status := send(model.SignClusterMessage(model.NewRandomString(32), body)); status != http.StatusUnauthorized {
+		t.Fatal("should have rejected a message signed with another key", status)
+	}
+
+	if status := send(model.SignClusterMessage(utils.Cfg.SqlSettings.AtRestEncryptKey, body)); status != http.StatusOK {
+		t.Fatal("should have accepted a signed message", status)
+	}
+}
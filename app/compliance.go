@@ -40,6 +40,28 @@ func SaveComplianceReport(job *model.Compliance) (*model.Compliance, *model.AppE
 	return job, nil
 }
 
+// GetCompliancePosts returns the posts in a compliance report. The users in an anonymized report are replaced by their
+// pseudonyms, so the compliance job should always read the posts through this instead of from the store.
+func GetCompliancePosts(job *model.Compliance) ([]*model.CompliancePost, *model.AppError) {
+	var posts []*model.CompliancePost
+	if result := <-Srv.Store.Compliance().ComplianceExport(job); result.Err != nil {
+		return nil, result.Err
+	} else {
+		posts = result.Data.([]*model.CompliancePost)
+	}
+
+	if job.Anonymize {
+		pseudonymizer := NewPseudonymizer()
+		for _, post := range posts {
+			if err := pseudonymizer.PseudonymizeCompliancePost(post); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return posts, nil
+}
+
 func GetComplianceReport(reportId string) (*model.Compliance, *model.AppError) {
 	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance || einterfaces.GetComplianceInterface() == nil {
 		return nil, model.NewLocAppError("downloadComplianceReport", "ent.compliance.licence_disable.app_error", nil, "")
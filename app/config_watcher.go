@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"os"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CONFIG_WATCHER_TASK_NAME = "Config Watcher"
+	CONFIG_WATCHER_INTERVAL  = 5 * time.Second
+)
+
+var configWatcherModTime time.Time
+
+// StartConfigWatcher reloads the config whenever the config file is modified on disk.
+func StartConfigWatcher() {
+	if task := model.GetTaskByName(CONFIG_WATCHER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	if info, err := os.Stat(utils.CfgFileName); err == nil {
+		configWatcherModTime = info.ModTime()
+	}
+
+	model.CreateRecurringTask(CONFIG_WATCHER_TASK_NAME, checkConfigFile, CONFIG_WATCHER_INTERVAL)
+}
+
+func StopConfigWatcher() {
+	if task := model.GetTaskByName(CONFIG_WATCHER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+}
+
+func checkConfigFile() {
+	info, err := os.Stat(utils.CfgFileName)
+	if err != nil || info.ModTime().Equal(configWatcherModTime) {
+		return
+	}
+
+	// Remember the modification time even if the reload fails so that an invalid file is only reported once
+	configWatcherModTime = info.ModTime()
+
+	ReloadConfigAndLog()
+}
+
+// ReloadConfigAndLog reloads the config on behalf of the server itself, such as when the config file changes
+// or the process receives SIGHUP, logging the outcome since there's no one to return an error to.
+func ReloadConfigAndLog() {
+	if err := ReloadConfig(); err != nil {
+		l4g.Error(utils.T("app.config.reload.error"), utils.CfgFileName, err.SystemMessage(utils.T))
+	} else {
+		l4g.Info(utils.T("app.config.reload.info"), utils.CfgFileName)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CUSTOM_STATUS_EXPIRY_TASK_NAME  = "Custom Status Expiry"
+	CUSTOM_STATUS_EXPIRY_INTERVAL   = time.Minute
+	CUSTOM_STATUS_EXPIRY_BATCH_SIZE = 100
+)
+
+func StartCustomStatusExpiry() {
+	if task := model.GetTaskByName(CUSTOM_STATUS_EXPIRY_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(CUSTOM_STATUS_EXPIRY_TASK_NAME, expireCustomStatuses, CUSTOM_STATUS_EXPIRY_INTERVAL)
+}
+
+func StopCustomStatusExpiry() {
+	if task := model.GetTaskByName(CUSTOM_STATUS_EXPIRY_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	ReleaseLease(CUSTOM_STATUS_EXPIRY_TASK_NAME)
+}
+
+// SetCustomStatus sets the message shown next to a user's name and lets everyone who can see them know about it.
+func SetCustomStatus(userId string, status *model.CustomStatus) *model.AppError {
+	status.PreSave()
+	if err := status.IsValid(); err != nil {
+		return err
+	}
+
+	if status.IsExpired() {
+		return model.NewAppError("SetCustomStatus", "app.custom_status.expired.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.User().UpdateCustomStatus(userId, status); result.Err != nil {
+		return result.Err
+	}
+
+	customStatusChanged(userId, status)
+
+	return nil
+}
+
+func RemoveCustomStatus(userId string) *model.AppError {
+	if result := <-Srv.Store.User().UpdateCustomStatus(userId, nil); result.Err != nil {
+		return result.Err
+	}
+
+	customStatusChanged(userId, nil)
+
+	return nil
+}
+
+func customStatusChanged(userId string, status *model.CustomStatus) {
+	InvalidateCacheForUser(userId)
+
+	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CUSTOM_STATUS, "", "", "", nil)
+	event.Add("user_id", userId)
+	if status != nil {
+		event.Add("custom_status", status.ToJson())
+	}
+	go Publish(event)
+}
+
+// expireCustomStatuses clears the custom statuses that have passed their expiry times.
+func expireCustomStatuses() {
+	if !AcquireLease(CUSTOM_STATUS_EXPIRY_TASK_NAME, 2*CUSTOM_STATUS_EXPIRY_INTERVAL) {
+		return
+	}
+
+	for {
+		now := model.GetMillis()
+
+		var userIds []string
+		if result := <-Srv.Store.User().GetExpiredCustomStatuses(now, CUSTOM_STATUS_EXPIRY_BATCH_SIZE); result.Err != nil {
+			l4g.Error(utils.T("app.custom_status.expire.error"), result.Err)
+			return
+		} else {
+			userIds = result.Data.([]string)
+		}
+
+		for _, userId := range userIds {
+			// the status is only cleared if it's still expired in case the user set a new one in the meantime
+			if result := <-Srv.Store.User().ClearExpiredCustomStatus(userId, now); result.Err != nil {
+				l4g.Error(utils.T("app.custom_status.expire.error"), result.Err)
+				return
+			} else if result.Data.(bool) {
+				customStatusChanged(userId, nil)
+			}
+		}
+
+		if len(userIds) < CUSTOM_STATUS_EXPIRY_BATCH_SIZE {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestExpireCustomStatuses(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if err := SetCustomStatus(th.BasicUser.Id, &model.CustomStatus{Text: "In a meeting", ExpiresAt: model.GetMillis() + 60*60*1000}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetCustomStatus(th.BasicUser2.Id, &model.CustomStatus{Emoji: "palm_tree"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ReleaseLease(CUSTOM_STATUS_EXPIRY_TASK_NAME)
+	expireCustomStatuses()
+
+	if user, err := GetUser(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if user.GetCustomStatus() == nil {
+		t.Fatal("shouldn't have cleared a custom status that hasn't expired")
+	}
+
+	<-Srv.Store.User().UpdateCustomStatus(th.BasicUser.Id, &model.CustomStatus{Text: "In a meeting", ExpiresAt: model.GetMillis() - 1000})
+
+	ReleaseLease(CUSTOM_STATUS_EXPIRY_TASK_NAME)
+	expireCustomStatuses()
+
+	if user, err := GetUser(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if user.CustomStatusText != "" || user.CustomStatusExpiry != 0 {
+		t.Fatal("should have cleared the expired custom status")
+	}
+
+	if user, err := GetUser(th.BasicUser2.Id); err != nil {
+		t.Fatal(err)
+	} else if user.GetCustomStatus() == nil {
+		t.Fatal("shouldn't have cleared a custom status without an expiry")
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	DATA_RETENTION_DEFAULT_SCHEDULE = "0 2 * * *"
+
+	DATA_RETENTION_BATCH_SIZE = 1000
+)
+
+// retentionPeriod pairs a scope with the times before which its messages and files are
+// deleted. A time of 0 keeps them forever.
+type retentionPeriod struct {
+	scope          *model.RetentionScope
+	messagesBefore int64
+	filesBefore    int64
+}
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_DATA_RETENTION, runDataRetentionJob)
+	RegisterJobScheduler(model.JOB_TYPE_DATA_RETENTION, DATA_RETENTION_DEFAULT_SCHEDULE, scheduleDataRetention)
+}
+
+func GetRetentionPolicies() ([]*model.RetentionPolicy, *model.AppError) {
+	if result := <-Srv.Store.RetentionPolicy().GetAll(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.RetentionPolicy), nil
+	}
+}
+
+func GetRetentionPolicy(id string) (*model.RetentionPolicy, *model.AppError) {
+	if result := <-Srv.Store.RetentionPolicy().Get(id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.RetentionPolicy), nil
+	}
+}
+
+func CreateRetentionPolicy(policy *model.RetentionPolicy) (*model.RetentionPolicy, *model.AppError) {
+	policy.Id = ""
+
+	if err := checkRetentionPolicyTarget(policy); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.RetentionPolicy().Save(policy); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.RetentionPolicy), nil
+	}
+}
+
+func UpdateRetentionPolicy(policy *model.RetentionPolicy) (*model.RetentionPolicy, *model.AppError) {
+	oldPolicy, err := GetRetentionPolicy(policy.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.CreateAt = oldPolicy.CreateAt
+
+	if err := checkRetentionPolicyTarget(policy); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.RetentionPolicy().Update(policy); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.RetentionPolicy), nil
+	}
+}
+
+func DeleteRetentionPolicy(id string) *model.AppError {
+	if result := <-Srv.Store.RetentionPolicy().Delete(id); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// checkRetentionPolicyTarget makes sure that the team or channel that a policy applies to
+// exists and doesn't already have a different policy.
+func checkRetentionPolicyTarget(policy *model.RetentionPolicy) *model.AppError {
+	if len(policy.ChannelId) > 0 {
+		if _, err := GetChannel(policy.ChannelId); err != nil {
+			return err
+		}
+	} else if len(policy.TeamId) > 0 {
+		if _, err := GetTeam(policy.TeamId); err != nil {
+			return err
+		}
+	}
+
+	policies, err := GetRetentionPolicies()
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range policies {
+		if existing.Id == policy.Id {
+			continue
+		}
+
+		if (len(policy.ChannelId) > 0 && existing.ChannelId == policy.ChannelId) || (len(policy.TeamId) > 0 && existing.TeamId == policy.TeamId) {
+			return model.NewAppError("checkRetentionPolicyTarget", "app.retention_policy.duplicate.app_error", nil, "id="+existing.Id, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+// PreviewRetentionPolicy returns how many posts and files the retention job would delete
+// for the given policy if it were saved. The policy doesn't need to exist yet.
+func PreviewRetentionPolicy(policy *model.RetentionPolicy) (*model.RetentionPreview, *model.AppError) {
+	policies, err := GetRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	period := getPolicyRetentionPeriod(policy, policies, model.GetMillis())
+	preview := &model.RetentionPreview{}
+
+	if period.messagesBefore > 0 {
+		if result := <-Srv.Store.RetentionPolicy().CountPostsBefore(period.scope, period.messagesBefore); result.Err != nil {
+			return nil, result.Err
+		} else {
+			preview.PostCount = result.Data.(int64)
+		}
+	}
+
+	if period.filesBefore > 0 {
+		if result := <-Srv.Store.RetentionPolicy().CountFilesBefore(period.scope, period.filesBefore); result.Err != nil {
+			return nil, result.Err
+		} else {
+			preview.FileCount = result.Data.(int64)
+		}
+	}
+
+	return preview, nil
+}
+
+// scheduleDataRetention queues the retention job if there is anything for it to do and the
+// previous run has finished.
+func scheduleDataRetention() {
+	policies, err := GetRetentionPolicies()
+	if err != nil {
+		l4g.Error(utils.T("app.data_retention.schedule.error"), err.Error())
+		return
+	}
+
+	fileAccessLogRetained := *utils.Cfg.ComplianceSettings.EnableFileAccessLog && *utils.Cfg.ComplianceSettings.FileAccessLogRetentionDays > 0
+	deletedFilesRetained := *utils.Cfg.DataRetentionSettings.DeletedFileRetentionDays > 0
+
+	if len(policies) == 0 && !*utils.Cfg.DataRetentionSettings.EnableMessageDeletion && !*utils.Cfg.DataRetentionSettings.EnableFileDeletion && !fileAccessLogRetained && !deletedFilesRetained {
+		return
+	}
+
+	if result := <-Srv.Store.Job().GetAllByType(model.JOB_TYPE_DATA_RETENTION, 0, 1); result.Err != nil {
+		l4g.Error(utils.T("app.data_retention.schedule.error"), result.Err.Error())
+		return
+	} else if jobs := result.Data.([]*model.Job); len(jobs) > 0 {
+		if !jobs[0].IsFinished() {
+			return
+		}
+	}
+
+	if _, err := CreateJob(model.JOB_TYPE_DATA_RETENTION, map[string]string{}); err != nil {
+		l4g.Error(utils.T("app.data_retention.schedule.error"), err.Error())
+	}
+}
+
+func runDataRetentionJob(job *model.Job) *model.AppError {
+	policies, err := GetRetentionPolicies()
+	if err != nil {
+		return err
+	}
+
+	now := model.GetMillis()
+
+	periods := make([]*retentionPeriod, 0, len(policies)+1)
+	for _, policy := range policies {
+		periods = append(periods, getPolicyRetentionPeriod(policy, policies, now))
+	}
+	periods = append(periods, getGlobalRetentionPeriod(policies, now))
+
+	var postsDeleted, filesDeleted int64
+	for i, period := range periods {
+		// files can't outlive the messages that they're attached to
+		filesBefore := period.filesBefore
+		if period.messagesBefore > filesBefore {
+			filesBefore = period.messagesBefore
+		}
+
+		if filesBefore > 0 {
+			if deleted, err := deleteFilesBefore(period.scope, filesBefore); err != nil {
+				return err
+			} else {
+				filesDeleted += deleted
+			}
+		}
+
+		if period.messagesBefore > 0 {
+			if deleted, err := deletePostsBefore(period.scope, period.messagesBefore); err != nil {
+				return err
+			} else {
+				postsDeleted += deleted
+			}
+		}
+
+		if err := SetJobProgress(job, int64(i+1)*100/int64(len(periods)+1)); err != nil {
+			return err
+		}
+	}
+
+	fileAccessesDeleted, err := deleteExpiredFileAccesses(now)
+	if err != nil {
+		return err
+	}
+
+	deletedFilesReclaimed, err := reclaimDeletedFiles(now)
+	if err != nil {
+		return err
+	}
+
+	job.SetDataInt64("posts_deleted", postsDeleted)
+	job.SetDataInt64("files_deleted", filesDeleted)
+	job.SetDataInt64("file_accesses_deleted", fileAccessesDeleted)
+	job.SetDataInt64("deleted_files_reclaimed", deletedFilesReclaimed)
+
+	l4g.Info(utils.T("app.data_retention.finished.info"), postsDeleted, filesDeleted)
+
+	return nil
+}
+
+func getPolicyRetentionPeriod(policy *model.RetentionPolicy, policies []*model.RetentionPolicy, now int64) *retentionPeriod {
+	return &retentionPeriod{
+		scope:          policy.GetRetentionScope(policies),
+		messagesBefore: retentionCutoff(policy.MessageRetentionDays, now),
+		filesBefore:    retentionCutoff(policy.FileRetentionDays, now),
+	}
+}
+
+func getGlobalRetentionPeriod(policies []*model.RetentionPolicy, now int64) *retentionPeriod {
+	period := &retentionPeriod{scope: model.GetGlobalRetentionScope(policies)}
+
+	if *utils.Cfg.DataRetentionSettings.EnableMessageDeletion {
+		period.messagesBefore = retentionCutoff(*utils.Cfg.DataRetentionSettings.MessageRetentionDays, now)
+	}
+
+	if *utils.Cfg.DataRetentionSettings.EnableFileDeletion {
+		period.filesBefore = retentionCutoff(*utils.Cfg.DataRetentionSettings.FileRetentionDays, now)
+	}
+
+	return period
+}
+
+func retentionCutoff(days int, now int64) int64 {
+	if days <= 0 {
+		return 0
+	}
+
+	return now - int64(days)*DAY_MILLISECONDS
+}
+
+func deleteFilesBefore(scope *model.RetentionScope, before int64) (int64, *model.AppError) {
+	var deleted int64
+
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.RetentionPolicy().GetFileInfosBefore(scope, before, DATA_RETENTION_BATCH_SIZE); result.Err != nil {
+			return deleted, result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		if len(infos) == 0 {
+			return deleted, nil
+		}
+
+		if err := permanentDeleteFiles(infos); err != nil {
+			return deleted, err
+		}
+
+		deleted += int64(len(infos))
+
+		if len(infos) < DATA_RETENTION_BATCH_SIZE {
+			return deleted, nil
+		}
+	}
+}
+
+// permanentDeleteFiles deletes the given FileInfos and removes their files from storage, other than the ones held by a
+// legal hold.
+func permanentDeleteFiles(infos []*model.FileInfo) *model.AppError {
+	fileIds := make([]string, len(infos))
+	for i, info := range infos {
+		fileIds[i] = info.Id
+	}
+
+	deleted := map[string]bool{}
+	if result := <-Srv.Store.FileInfo().PermanentDeleteBatch(fileIds); result.Err != nil {
+		return result.Err
+	} else {
+		for _, fileId := range result.Data.([]string) {
+			deleted[fileId] = true
+		}
+	}
+
+	for _, info := range infos {
+		// files held by a legal hold aren't deleted, so their contents need to be kept too
+		if !deleted[info.Id] {
+			continue
+		}
+
+		// forwarded posts share their files with the post that they were forwarded from, so only remove
+		// files that aren't used by any other FileInfo
+		if result := <-Srv.Store.FileInfo().CountByPath(info.Path, info.CreateAt); result.Err != nil {
+			l4g.Warn(utils.T("app.data_retention.remove_file.warn"), info.Path, result.Err.Error())
+			continue
+		} else if result.Data.(int64) > 0 {
+			continue
+		}
+
+		for _, path := range []string{info.Path, info.ThumbnailPath, info.PreviewPath, info.WebPPreviewPath} {
+			if len(path) == 0 {
+				continue
+			}
+
+			if err := RemoveFile(path); err != nil {
+				l4g.Warn(utils.T("app.data_retention.remove_file.warn"), path, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func deletePostsBefore(scope *model.RetentionScope, before int64) (int64, *model.AppError) {
+	var deleted int64
+
+	for {
+		var postIds []string
+		if result := <-Srv.Store.RetentionPolicy().GetPostIdsBefore(scope, before, DATA_RETENTION_BATCH_SIZE); result.Err != nil {
+			return deleted, result.Err
+		} else {
+			postIds = result.Data.([]string)
+		}
+
+		if len(postIds) == 0 {
+			return deleted, nil
+		}
+
+		if result := <-Srv.Store.Post().PermanentDeleteBatch(postIds); result.Err != nil {
+			return deleted, result.Err
+		}
+
+		if result := <-Srv.Store.PermalinkPreview().PermanentDeleteBatch(postIds); result.Err != nil {
+			return deleted, result.Err
+		}
+
+		if result := <-Srv.Store.PostTranslation().PermanentDeleteBatch(postIds); result.Err != nil {
+			return deleted, result.Err
+		}
+
+		if engine := einterfaces.GetSearchEngineInterface(); engine != nil && *utils.Cfg.SearchSettings.EnableIndexing {
+			for _, postId := range postIds {
+				if err := engine.DeletePost(postId); err != nil {
+					l4g.Warn(utils.T("app.data_retention.delete_from_index.warn"), postId, err.Error())
+				}
+			}
+		}
+
+		deleted += int64(len(postIds))
+
+		if len(postIds) < DATA_RETENTION_BATCH_SIZE {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	DATABASE_HEALTH_TASK_NAME = "Database Health Check"
+	DATABASE_HEALTH_INTERVAL  = 15 * time.Second
+)
+
+var latestDatabaseHealth *model.DatabaseHealth
+var latestDatabaseHealthLock sync.RWMutex
+
+func StartDatabaseHealthCheck() {
+	if task := model.GetTaskByName(DATABASE_HEALTH_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(DATABASE_HEALTH_TASK_NAME, func() { CheckDatabaseHealth() }, DATABASE_HEALTH_INTERVAL)
+}
+
+func StopDatabaseHealthCheck() {
+	if task := model.GetTaskByName(DATABASE_HEALTH_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+}
+
+// CheckDatabaseHealth checks the master database and its replicas against the configured limits and remembers the
+// result so that it can be served to load balancers without querying the database for every request.
+func CheckDatabaseHealth() *model.DatabaseHealth {
+	health := Srv.Store.CheckHealth()
+	health.Evaluate(*utils.Cfg.SqlSettings.HealthCheckMaxLatencyMilliseconds, *utils.Cfg.SqlSettings.HealthCheckMaxReplicationLagMilliseconds)
+
+	latestDatabaseHealthLock.Lock()
+	previous := latestDatabaseHealth
+	latestDatabaseHealth = health
+	latestDatabaseHealthLock.Unlock()
+
+	if !health.IsHealthy() {
+		for _, c := range append([]*model.DatabaseConnectionHealth{health.Master}, health.Replicas...) {
+			if !c.Healthy {
+				l4g.Warn(utils.T("app.database_health.unhealthy.warn"), c.Name, strings.Join(c.Errors, ", "))
+			}
+		}
+	} else if previous != nil && !previous.IsHealthy() {
+		l4g.Info(utils.T("app.database_health.recovered.info"))
+	}
+
+	return health
+}
+
+// GetLatestDatabaseHealth returns the result of the most recent health check, running one if there hasn't been one
+// recently.
+func GetLatestDatabaseHealth() *model.DatabaseHealth {
+	latestDatabaseHealthLock.RLock()
+	health := latestDatabaseHealth
+	latestDatabaseHealthLock.RUnlock()
+
+	if health == nil || model.GetMillis()-health.CheckedAt > 2*int64(DATABASE_HEALTH_INTERVAL/time.Millisecond) {
+		return CheckDatabaseHealth()
+	}
+
+	return health
+}
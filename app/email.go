@@ -4,8 +4,10 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
 	"html/template"
+	"net/http"
 	"net/url"
 
 	l4g "github.com/alecthomas/log4go"
@@ -197,7 +199,7 @@ func SendMfaChangeEmail(email string, activated bool, locale, siteURL string) *m
 	return nil
 }
 
-func SendInviteEmails(team *model.Team, senderName string, invites []string, siteURL string) {
+func SendInviteEmails(team *model.Team, senderName string, senderId string, invites []string, siteURL string) {
 	for _, invite := range invites {
 		if len(invite) > 0 {
 			senderRole := utils.T("api.team.invite_members.member")
@@ -214,15 +216,12 @@ func SendInviteEmails(team *model.Team, senderName string, invites []string, sit
 			bodyPage.Html["ExtraInfo"] = template.HTML(utils.T("api.templates.invite_body.extra_info",
 				map[string]interface{}{"TeamDisplayName": team.DisplayName, "TeamURL": siteURL + "/" + team.Name}))
 
-			props := make(map[string]string)
-			props["email"] = invite
-			props["id"] = team.Id
-			props["display_name"] = team.DisplayName
-			props["name"] = team.Name
-			props["time"] = fmt.Sprintf("%v", model.GetMillis())
-			data := model.MapToJson(props)
-			hash := model.HashPassword(fmt.Sprintf("%v:%v", data, utils.Cfg.EmailSettings.InviteSalt))
-			bodyPage.Props["Link"] = fmt.Sprintf("%s/signup_user_complete/?d=%s&h=%s", siteURL, url.QueryEscape(data), url.QueryEscape(hash))
+			invitation, err := CreateInvitation(team.Id, invite, senderId)
+			if err != nil {
+				l4g.Error(utils.T("api.team.invite_members.send.error"), err)
+				continue
+			}
+			bodyPage.Props["Link"] = fmt.Sprintf("%s/signup_user_complete/?id=%s", siteURL, url.QueryEscape(invitation.Id))
 
 			if !utils.Cfg.EmailSettings.SendEmailNotifications {
 				l4g.Info(utils.T("api.team.invite_members.sending.info"), invite, bodyPage.Props["Link"])
@@ -234,3 +233,65 @@ func SendInviteEmails(team *model.Team, senderName string, invites []string, sit
 		}
 	}
 }
+
+// emailTemplatePreviewProps are the props used by the email templates, which are filled in with placeholders when
+// previewing them.
+var emailTemplatePreviewProps = []string{
+	"AppDownloadInfo", "AppDownloadLink", "BodyText", "Button", "ChannelName", "Date", "Info", "Link", "PostLink",
+	"PostMessage", "Posts", "ResetUrl", "SenderName", "TeamLink", "Title", "VerifyButton", "VerifyUrl",
+}
+
+// PreviewEmailTemplate renders an email template for the given locale, including any overrides for it, with
+// placeholders in place of the details that are normally filled in when the email is sent.
+func PreviewEmailTemplate(templateName, locale, siteURL string) (string, *model.AppError) {
+	if !utils.HTMLTemplateExists(templateName) {
+		return "", model.NewAppError("PreviewEmailTemplate", "app.email.preview_template.not_found.app_error", nil, "template="+templateName, http.StatusNotFound)
+	}
+
+	if locale == "" {
+		locale = model.DEFAULT_LOCALE
+	}
+
+	page := utils.NewHTMLTemplate(templateName, locale)
+	for _, prop := range emailTemplatePreviewProps {
+		page.Props[prop] = "[" + prop + "]"
+	}
+	page.Props["SiteURL"] = siteURL
+	page.Html["Info"] = template.HTML("[Info]")
+	page.Html["ExtraInfo"] = template.HTML("[ExtraInfo]")
+
+	var body bytes.Buffer
+	if err := page.RenderToWriter(&body); err != nil {
+		return "", model.NewAppError("PreviewEmailTemplate", "app.email.preview_template.app_error", nil, "template="+templateName+", "+err.Error(), http.StatusBadRequest)
+	}
+
+	return body.String(), nil
+}
+
+// SendTestEmailTemplate sends a preview of an email template to the given user so that an admin can check how it
+// looks in their email client.
+func SendTestEmailTemplate(userId, templateName, locale, siteURL string) *model.AppError {
+	user, err := GetUser(userId)
+	if err != nil {
+		return err
+	}
+
+	if locale == "" {
+		locale = user.Locale
+	}
+
+	body, err := PreviewEmailTemplate(templateName, locale, siteURL)
+	if err != nil {
+		return err
+	}
+
+	T := utils.GetUserTranslations(user.Locale)
+	subject := fmt.Sprintf("[%v] %v", utils.Cfg.TeamSettings.SiteName, T("api.templates.test_template_subject",
+		map[string]interface{}{"TemplateName": templateName}))
+
+	if err := utils.SendMail(user.Email, subject, body); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -381,7 +381,7 @@ func TestSendInviteEmails(t *testing.T) {
 	utils.DeleteMailBox(email1)
 	utils.DeleteMailBox(email2)
 
-	SendInviteEmails(th.BasicTeam, senderName, invites, siteURL)
+	SendInviteEmails(th.BasicTeam, senderName, th.BasicUser.Id, invites, siteURL)
 
 	//Check if the email was send to the rigth email address to email1
 	if resultsMailbox, err := utils.GetMailBox(email1); err != nil && !strings.ContainsAny(resultsMailbox[0].To[0], email1) {
@@ -0,0 +1,385 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	CHANNEL_EXPORT_BATCH_SIZE    = 1000
+	CHANNEL_EXPORT_MANIFEST_NAME = "manifest.jsonl"
+	CHANNEL_EXPORT_FILES_DIR     = "files"
+)
+
+// ChannelExportLine is a line of the manifest of a channel export. The first line describes the
+// channel and is followed by one line for each of its posts, oldest first.
+type ChannelExportLine struct {
+	Type    string             `json:"type"`
+	Channel *ChannelImportData `json:"channel,omitempty"`
+	Post    *PostExportData    `json:"post,omitempty"`
+}
+
+type PostExportData struct {
+	Id        string                `json:"id"`
+	RootId    string                `json:"root_id,omitempty"`
+	User      string                `json:"user"`
+	Message   string                `json:"message"`
+	Type      string                `json:"type,omitempty"`
+	Props     model.StringInterface `json:"props,omitempty"`
+	CreateAt  int64                 `json:"create_at"`
+	EditAt    int64                 `json:"edit_at,omitempty"`
+	Reactions []*ReactionExportData `json:"reactions,omitempty"`
+	Files     []*FileExportData     `json:"files,omitempty"`
+}
+
+type ReactionExportData struct {
+	User      string `json:"user"`
+	EmojiName string `json:"emoji_name"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+// FileExportData describes a file attached to an exported post. Path is the location of the
+// file's contents within the export.
+type FileExportData struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+}
+
+// ExportUser returns a user along with their team and channel memberships as a line of a bulk
+// import file, so that the account can be recreated with BulkImport. Passwords are never
+// exported, so imported users without AuthData are given a new random password. If a
+// pseudonymizer is given, the user's username and email are replaced by their pseudonym and
+// the rest of their personal details are left out.
+func ExportUser(user *model.User, pseudonymizer *Pseudonymizer) (*LineImportData, *model.AppError) {
+	data := &UserImportData{
+		Username:  &user.Username,
+		Email:     &user.Email,
+		Nickname:  &user.Nickname,
+		FirstName: &user.FirstName,
+		LastName:  &user.LastName,
+		Position:  &user.Position,
+		Roles:     &user.Roles,
+		Locale:    &user.Locale,
+	}
+
+	if pseudonymizer != nil {
+		pseudonym, err := pseudonymizer.GetPseudonym(user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		email := pseudonym.Email()
+		empty := ""
+		data.Username = &pseudonym.Pseudonym
+		data.Email = &email
+		data.Nickname = &empty
+		data.FirstName = &empty
+		data.LastName = &empty
+		data.Position = &empty
+	} else if user.AuthService != "" {
+		data.AuthService = &user.AuthService
+		data.AuthData = user.AuthData
+	}
+
+	var members []*model.TeamMember
+	if result := <-Srv.Store.Team().GetTeamsForUser(user.Id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		members = result.Data.([]*model.TeamMember)
+	}
+
+	var channelRoles map[string]string
+	if result := <-Srv.Store.Channel().GetAllChannelMembersForUser(user.Id, false); result.Err != nil {
+		return nil, result.Err
+	} else {
+		channelRoles = result.Data.(map[string]string)
+	}
+
+	teams := make([]UserTeamImportData, 0, len(members))
+	for _, member := range members {
+		if member.DeleteAt != 0 {
+			continue
+		}
+
+		team, err := GetTeam(member.TeamId)
+		if err != nil {
+			return nil, err
+		}
+
+		channels := make([]UserChannelImportData, 0)
+		if result := <-Srv.Store.Channel().GetChannels(team.Id, user.Id); result.Err == nil {
+			for _, channel := range *result.Data.(*model.ChannelList) {
+				// direct and group messages don't belong to a team and can't be imported
+				if channel.TeamId != team.Id {
+					continue
+				}
+
+				name := channel.Name
+				roles := channelRoles[channel.Id]
+				channels = append(channels, UserChannelImportData{
+					Name:  &name,
+					Roles: &roles,
+				})
+			}
+		}
+
+		roles := member.Roles
+		teams = append(teams, UserTeamImportData{
+			Name:     &team.Name,
+			Roles:    &roles,
+			Channels: &channels,
+		})
+	}
+	data.Teams = &teams
+
+	return &LineImportData{
+		Type: "user",
+		User: data,
+	}, nil
+}
+
+// ExportChannel writes a zip file containing a channel's posts, reactions and attached files to
+// w. The zip contains a JSONL manifest followed by the contents of the files. Posts are read in
+// batches and each file is written as soon as it's read so that large channels can be streamed.
+// If a pseudonymizer is given, the users in the export are replaced by their pseudonyms.
+//
+// The export is made for the user with the given id, so files that they aren't allowed to access are
+// left out and the rest are recorded in the file access log as being exported by them. The user id is
+// empty for exports made from the command line, which include every file.
+func ExportChannel(channel *model.Channel, w io.Writer, pseudonymizer *Pseudonymizer, userId string, ipAddress string) *model.AppError {
+	zipWriter := zip.NewWriter(w)
+
+	manifest, err := zipWriter.Create(CHANNEL_EXPORT_MANIFEST_NAME)
+	if err != nil {
+		return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	encoder := json.NewEncoder(manifest)
+
+	name := channel.Name
+	if pseudonymizer != nil {
+		var err *model.AppError
+		if name, err = pseudonymizer.PseudonymizeChannelName(name); err != nil {
+			return err
+		}
+	}
+
+	channelData := &ChannelImportData{
+		Name:        &name,
+		DisplayName: &channel.DisplayName,
+		Type:        &channel.Type,
+		Header:      &channel.Header,
+		Purpose:     &channel.Purpose,
+	}
+
+	if channel.TeamId != "" {
+		team, err := GetTeam(channel.TeamId)
+		if err != nil {
+			return err
+		}
+		channelData.Team = &team.Name
+	}
+
+	if err := encoder.Encode(&ChannelExportLine{Type: "channel", Channel: channelData}); err != nil {
+		return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	usernames := map[string]string{}
+	var files []*model.FileInfo
+
+	if err := forEachPostToExport(channel.Id, func(post *model.Post) *model.AppError {
+		data, postFiles, err := exportPost(post, usernames, pseudonymizer, userId)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(&ChannelExportLine{Type: "post", Post: data}); err != nil {
+			return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		files = append(files, postFiles...)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// the manifest has to be finished before the files can be added since zip entries can't be interleaved
+	for _, info := range files {
+		if err := exportFile(zipWriter, info); err != nil {
+			return err
+		}
+
+		RecordFileAccess(info.Id, userId, ipAddress, model.FILE_ACCESS_LINK_TYPE_EXPORT)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// exportFile copies a file into the zip without reading all of it into memory at once.
+func exportFile(zipWriter *zip.Writer, info *model.FileInfo) *model.AppError {
+	reader, appErr := FileReader(info.Path)
+	if appErr != nil {
+		return appErr
+	}
+	defer reader.Close()
+
+	if fileWriter, err := zipWriter.Create(getExportedFilePath(info)); err != nil {
+		return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	} else if _, err := io.Copy(fileWriter, reader); err != nil {
+		return model.NewAppError("ExportChannel", "app.export.channel.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func forEachPostToExport(channelId string, f func(post *model.Post) *model.AppError) *model.AppError {
+	var lastCreateAt int64
+	lastPostId := ""
+
+	for {
+		var posts []*model.Post
+		if result := <-Srv.Store.Post().GetPostsBatchForExport(channelId, lastCreateAt, lastPostId, CHANNEL_EXPORT_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			posts = result.Data.([]*model.Post)
+		}
+
+		for _, post := range posts {
+			if err := f(post); err != nil {
+				return err
+			}
+		}
+
+		if len(posts) < CHANNEL_EXPORT_BATCH_SIZE {
+			return nil
+		}
+
+		lastCreateAt = posts[len(posts)-1].CreateAt
+		lastPostId = posts[len(posts)-1].Id
+	}
+}
+
+func exportPost(post *model.Post, usernames map[string]string, pseudonymizer *Pseudonymizer, userId string) (*PostExportData, []*model.FileInfo, *model.AppError) {
+	if pseudonymizer != nil {
+		var err *model.AppError
+		if post, err = pseudonymizer.PseudonymizePost(post); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	username, err := getExportedUsername(post.UserId, usernames, pseudonymizer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := &PostExportData{
+		Id:       post.Id,
+		RootId:   post.RootId,
+		User:     username,
+		Message:  post.Message,
+		Type:     post.Type,
+		Props:    post.Props,
+		CreateAt: post.CreateAt,
+		EditAt:   post.EditAt,
+	}
+
+	if post.HasReactions {
+		if result := <-Srv.Store.Reaction().GetForPost(post.Id); result.Err != nil {
+			return nil, nil, result.Err
+		} else {
+			for _, reaction := range result.Data.([]*model.Reaction) {
+				username, err := getExportedUsername(reaction.UserId, usernames, pseudonymizer)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				data.Reactions = append(data.Reactions, &ReactionExportData{
+					User:      username,
+					EmojiName: reaction.EmojiName,
+					CreateAt:  reaction.CreateAt,
+				})
+			}
+		}
+	}
+
+	var files []*model.FileInfo
+	if len(post.FileIds) > 0 {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.FileInfo().GetForPost(post.Id, false); result.Err != nil {
+			return nil, nil, result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		for _, info := range infos {
+			if userId != "" {
+				if allowed, err := HasFileAccess(userId, info); err != nil {
+					return nil, nil, err
+				} else if !allowed {
+					continue
+				}
+			}
+
+			files = append(files, info)
+			data.Files = append(data.Files, &FileExportData{
+				Id:       info.Id,
+				Name:     info.Name,
+				Path:     getExportedFilePath(info),
+				Size:     info.Size,
+				MimeType: info.MimeType,
+			})
+		}
+	}
+
+	return data, files, nil
+}
+
+// getExportedUsername returns the username of a user, or their pseudonym if a pseudonymizer is
+// given, caching it in usernames since most posts in a channel are made by a small number of
+// users. Users that no longer exist are exported without a username.
+func getExportedUsername(userId string, usernames map[string]string, pseudonymizer *Pseudonymizer) (string, *model.AppError) {
+	if username, ok := usernames[userId]; ok {
+		return username, nil
+	}
+
+	username := ""
+	if pseudonymizer != nil {
+		if pseudonym, err := pseudonymizer.GetPseudonym(userId); err != nil {
+			return "", err
+		} else {
+			username = pseudonym.Pseudonym
+		}
+	} else if result := <-Srv.Store.User().Get(userId); result.Err == nil {
+		username = result.Data.(*model.User).Username
+	}
+
+	usernames[userId] = username
+	return username, nil
+}
+
+// getExportedFilePath returns where a file is put in the zip. Only the last part of its name is used so that a name
+// like "../file" can't place it outside of the files directory when the zip is extracted.
+func getExportedFilePath(info *model.FileInfo) string {
+	name := filepath.Base(strings.Replace(info.Name, "\\", "/", -1))
+	if name == "." || name == ".." || name == "/" {
+		name = info.Id
+	}
+
+	return CHANNEL_EXPORT_FILES_DIR + "/" + info.Id + "/" + name
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestExportUser(t *testing.T) {
+	th := Setup().InitBasic()
+
+	line, err := ExportUser(th.BasicUser, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line.Type != "user" || *line.User.Username != th.BasicUser.Username || *line.User.Email != th.BasicUser.Email {
+		t.Fatal("should have exported the user's details")
+	}
+
+	if line.User.AuthData != nil {
+		t.Fatal("shouldn't have exported auth data for an email user")
+	}
+
+	if err := validateUserImportData(line.User); err != nil {
+		t.Fatal("exported user should be valid import data", err)
+	}
+
+	if len(*line.User.Teams) != 1 || *(*line.User.Teams)[0].Name != th.BasicTeam.Name {
+		t.Fatal("should have exported the user's team")
+	}
+
+	found := false
+	for _, channel := range *(*line.User.Teams)[0].Channels {
+		if *channel.Name == th.BasicChannel.Name {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have exported the user's channels")
+	}
+}
+
+func TestExportChannel(t *testing.T) {
+	th := Setup().InitBasic()
+
+	info, err := DoUploadFile(th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "test.txt", []byte("file contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveFile(info.Path)
+
+	post1 := th.CreatePost(th.BasicChannel)
+	post2, err := CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "with a file", RootId: post1.Id, FileIds: []string{info.Id}}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-Srv.Store.Reaction().Save(&model.Reaction{UserId: th.BasicUser2.Id, PostId: post1.Id, EmojiName: "smile"}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportChannel(th.BasicChannel, &buf, nil, th.BasicUser.Id, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, zipErr := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if zipErr != nil {
+		t.Fatal(zipErr)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	manifest, zipErr := files[CHANNEL_EXPORT_MANIFEST_NAME].Open()
+	if zipErr != nil {
+		t.Fatal(zipErr)
+	}
+	defer manifest.Close()
+
+	var lines []*ChannelExportLine
+	scanner := bufio.NewScanner(manifest)
+	for scanner.Scan() {
+		var line ChannelExportLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, &line)
+	}
+
+	if len(lines) == 0 || lines[0].Type != "channel" || *lines[0].Channel.Name != th.BasicChannel.Name || *lines[0].Channel.Team != th.BasicTeam.Name {
+		t.Fatal("first line should describe the channel")
+	}
+
+	// the channel may also contain system messages, so only look at the posts that were made by the test
+	var exported []*PostExportData
+	for _, line := range lines[1:] {
+		if line.Post.Id == post1.Id || line.Post.Id == post2.Id {
+			exported = append(exported, line.Post)
+		}
+	}
+
+	if len(exported) != 2 || exported[0].Id != post1.Id || exported[0].User != th.BasicUser.Username {
+		t.Fatal("should have exported the posts in order")
+	} else if len(exported[0].Reactions) != 1 || exported[0].Reactions[0].User != th.BasicUser2.Username || exported[0].Reactions[0].EmojiName != "smile" {
+		t.Fatal("should have exported the post's reactions")
+	}
+
+	if exported[1].RootId != post1.Id || len(exported[1].Files) != 1 {
+		t.Fatal("should have exported the reply and its file")
+	}
+
+	if file, ok := files[exported[1].Files[0].Path]; !ok {
+		t.Fatal("should have included the file in the export")
+	} else if contents, zipErr := file.Open(); zipErr != nil {
+		t.Fatal(zipErr)
+	} else if data, _ := ioutil.ReadAll(contents); string(data) != "file contents" {
+		t.Fatal("should have exported the file's contents")
+	}
+
+	t.Run("FileAccess", func(t *testing.T) {
+		enableFileAccessLog := *utils.Cfg.ComplianceSettings.EnableFileAccessLog
+		defer func() {
+			*utils.Cfg.ComplianceSettings.EnableFileAccessLog = enableFileAccessLog
+		}()
+		*utils.Cfg.ComplianceSettings.EnableFileAccessLog = true
+
+		if _, err := UpdateFileAcl(&model.FileAcl{FileId: info.Id, UserIds: model.StringArray{th.BasicUser.Id}}); err != nil {
+			t.Fatal(err)
+		}
+
+		exportedFile := func(userId string) bool {
+			var buf bytes.Buffer
+			if err := ExportChannel(th.BasicChannel, &buf, nil, userId, "127.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, zipErr := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if zipErr != nil {
+				t.Fatal(zipErr)
+			}
+
+			for _, file := range reader.File {
+				if file.Name == getExportedFilePath(info) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if exportedFile(th.BasicUser2.Id) {
+			t.Fatal("shouldn't have exported a file that the user can't access")
+		} else if accesses, err := GetFileAccessLog(&model.FileAccessQuery{FileId: info.Id, Limit: 10}); err != nil {
+			t.Fatal(err)
+		} else if len(accesses) != 0 {
+			t.Fatal("shouldn't have logged a file that wasn't exported")
+		}
+
+		if !exportedFile(th.BasicUser.Id) {
+			t.Fatal("should have exported a file that the user can access")
+		} else if accesses, err := GetFileAccessLog(&model.FileAccessQuery{FileId: info.Id, Limit: 10}); err != nil {
+			t.Fatal(err)
+		} else if len(accesses) != 1 || accesses[0].UserId != th.BasicUser.Id || accesses[0].LinkType != model.FILE_ACCESS_LINK_TYPE_EXPORT {
+			t.Fatal("should have logged the exported file")
+		}
+	})
+}
+
+func TestGetExportedFilePath(t *testing.T) {
+	for name, expected := range map[string]string{
+		"file.txt":         "files/abc/file.txt",
+		"../../file.txt":   "files/abc/file.txt",
+		"/etc/passwd":      "files/abc/passwd",
+		"..\\..\\file.txt": "files/abc/file.txt",
+		"..":               "files/abc/abc",
+		"":                 "files/abc/abc",
+		"directory/":       "files/abc/directory",
+	} {
+		if actual := getExportedFilePath(&model.FileInfo{Id: "abc", Name: name}); actual != expected {
+			t.Fatalf("got %v for %v, expected %v", actual, name, expected)
+		}
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	_ "image/gif"
+	"image/gif"
 	"image/jpeg"
 	"io"
 	"io/ioutil"
@@ -25,11 +25,13 @@ import (
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/disintegration/imaging"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	s3 "github.com/minio/minio-go"
 	"github.com/rwcarlsen/goexif/exif"
 	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
 )
 
 const (
@@ -56,16 +58,22 @@ const (
 )
 
 func ReadFile(path string) ([]byte, *model.AppError) {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-		secure := *utils.Cfg.FileSettings.AmazonS3SSL
+	return readFileWithSettings(&utils.Cfg.FileSettings, path)
+}
+
+// readFileWithSettings reads a file from the storage backend described by the given settings, which don't have to be
+// the ones that the server is configured with.
+func readFileWithSettings(settings *model.FileSettings, path string) ([]byte, *model.AppError) {
+	if settings.DriverName == model.IMAGE_DRIVER_S3 {
+		endpoint := settings.AmazonS3Endpoint
+		accessKey := settings.AmazonS3AccessKeyId
+		secretKey := settings.AmazonS3SecretAccessKey
+		secure := *settings.AmazonS3SSL
 		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
 		if err != nil {
 			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error())
 		}
-		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
+		bucket := settings.AmazonS3Bucket
 		minioObject, err := s3Clnt.GetObject(bucket, path)
 		defer minioObject.Close()
 		if err != nil {
@@ -76,8 +84,8 @@ func ReadFile(path string) ([]byte, *model.AppError) {
 		} else {
 			return f, nil
 		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if f, err := ioutil.ReadFile(utils.Cfg.FileSettings.Directory + path); err != nil {
+	} else if settings.DriverName == model.IMAGE_DRIVER_LOCAL {
+		if f, err := ioutil.ReadFile(settings.Directory + path); err != nil {
 			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.reading_local.app_error", nil, err.Error())
 		} else {
 			return f, nil
@@ -87,6 +95,36 @@ func ReadFile(path string) ([]byte, *model.AppError) {
 	}
 }
 
+// FileReader opens a file from the storage backend so that it can be read without loading the whole thing into
+// memory. The caller has to close it.
+func FileReader(path string) (io.ReadCloser, *model.AppError) {
+	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
+		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
+		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
+		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
+		secure := *utils.Cfg.FileSettings.AmazonS3SSL
+		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
+		if err != nil {
+			return nil, model.NewLocAppError("FileReader", "api.file.read_file.s3.app_error", nil, err.Error())
+		}
+		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
+
+		if minioObject, err := s3Clnt.GetObject(bucket, path); err != nil {
+			return nil, model.NewLocAppError("FileReader", "api.file.read_file.s3.app_error", nil, err.Error())
+		} else {
+			return minioObject, nil
+		}
+	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
+		if f, err := os.Open(utils.Cfg.FileSettings.Directory + path); err != nil {
+			return nil, model.NewLocAppError("FileReader", "api.file.read_file.reading_local.app_error", nil, err.Error())
+		} else {
+			return f, nil
+		}
+	} else {
+		return nil, model.NewLocAppError("FileReader", "api.file.read_file.configured.app_error", nil, "")
+	}
+}
+
 func MoveFile(oldPath, newPath string) *model.AppError {
 	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
 		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
@@ -99,8 +137,7 @@ func MoveFile(oldPath, newPath string) *model.AppError {
 		}
 		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
 
-		var copyConds = s3.NewCopyConditions()
-		if err = s3Clnt.CopyObject(bucket, newPath, "/"+path.Join(bucket, oldPath), copyConds); err != nil {
+		if err = s3CopyObject(&utils.Cfg.FileSettings, s3Clnt, bucket, oldPath, newPath); err != nil {
 			return model.NewLocAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error())
 		}
 		if err = s3Clnt.RemoveObject(bucket, oldPath); err != nil {
@@ -121,7 +158,7 @@ func MoveFile(oldPath, newPath string) *model.AppError {
 	return nil
 }
 
-func WriteFile(f []byte, path string) *model.AppError {
+func RemoveFile(path string) *model.AppError {
 	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
 		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
 		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
@@ -129,21 +166,53 @@ func WriteFile(f []byte, path string) *model.AppError {
 		secure := *utils.Cfg.FileSettings.AmazonS3SSL
 		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
 		if err != nil {
-			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.s3.app_error", nil, err.Error())
 		}
 		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
+
+		if err := s3Clnt.RemoveObject(bucket, path); err != nil {
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.s3.app_error", nil, err.Error())
+		}
+	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
+		if err := os.Remove(utils.Cfg.FileSettings.Directory + path); err != nil && !os.IsNotExist(err) {
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.local.app_error", nil, err.Error())
+		}
+	} else {
+		return model.NewLocAppError("RemoveFile", "api.file.remove_file.configured.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func WriteFile(f []byte, path string) *model.AppError {
+	return writeFileWithSettings(&utils.Cfg.FileSettings, f, path)
+}
+
+// writeFileWithSettings writes a file to the storage backend described by the given settings, which don't have to be
+// the ones that the server is configured with.
+func writeFileWithSettings(settings *model.FileSettings, f []byte, path string) *model.AppError {
+	if settings.DriverName == model.IMAGE_DRIVER_S3 {
+		endpoint := settings.AmazonS3Endpoint
+		accessKey := settings.AmazonS3AccessKeyId
+		secretKey := settings.AmazonS3SecretAccessKey
+		secure := *settings.AmazonS3SSL
+		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
+		if err != nil {
+			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
+		}
+		bucket := settings.AmazonS3Bucket
 		ext := filepath.Ext(path)
 
 		if model.IsFileExtImage(ext) {
-			_, err = s3Clnt.PutObject(bucket, path, bytes.NewReader(f), model.GetImageMimeType(ext))
+			err = s3PutObject(settings, s3Clnt, bucket, path, f, model.GetImageMimeType(ext))
 		} else {
-			_, err = s3Clnt.PutObject(bucket, path, bytes.NewReader(f), "binary/octet-stream")
+			err = s3PutObject(settings, s3Clnt, bucket, path, f, "binary/octet-stream")
 		}
 		if err != nil {
 			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
 		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := writeFileLocally(f, utils.Cfg.FileSettings.Directory+path); err != nil {
+	} else if settings.DriverName == model.IMAGE_DRIVER_LOCAL {
+		if err := writeFileLocally(f, settings.Directory+path); err != nil {
 			return err
 		}
 	} else {
@@ -318,7 +387,7 @@ func MigrateFilenamesToFileInfos(post *model.Post) []*model.FileInfo {
 		return []*model.FileInfo{}
 	} else if newPost := result.Data.(*model.PostList).Posts[post.Id]; len(newPost.Filenames) != len(post.Filenames) {
 		// Another thread has already created FileInfos for this post, so just return those
-		if result := <-Srv.Store.FileInfo().GetForPost(post.Id); result.Err != nil {
+		if result := <-Srv.Store.FileInfo().GetForPost(post.Id, false); result.Err != nil {
 			l4g.Error(utils.T("api.file.migrate_filenames_to_file_infos.get_post_file_infos_again.app_error"), post.Id, result.Err)
 			return []*model.FileInfo{}
 		} else {
@@ -353,6 +422,10 @@ func MigrateFilenamesToFileInfos(post *model.Post) []*model.FileInfo {
 		l4g.Error(utils.T("api.file.migrate_filenames_to_file_infos.save_post.app_error"), post.Id, newPost.FileIds, post.Filenames, result.Err)
 		return []*model.FileInfo{}
 	} else {
+		for _, info := range savedInfos {
+			sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_ATTACHED, info, post.ChannelId)
+		}
+
 		return savedInfos
 	}
 }
@@ -395,7 +468,16 @@ func DoUploadFile(teamId string, channelId string, userId string, rawFilename st
 
 		nameWithoutExtension := filename[:strings.LastIndex(filename, ".")]
 		info.PreviewPath = pathPrefix + nameWithoutExtension + "_preview.jpg"
-		info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.jpg"
+
+		if canGenerateWebPPreview(info) {
+			info.WebPPreviewPath = pathPrefix + nameWithoutExtension + "_preview.webp"
+		}
+
+		if info.IsAnimated && canGenerateAnimatedThumbnail(info, data) {
+			info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.gif"
+		} else {
+			info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.jpg"
+		}
 	}
 
 	if err := WriteFile(data, info.Path); err != nil {
@@ -406,16 +488,141 @@ func DoUploadFile(teamId string, channelId string, userId string, rawFilename st
 		return nil, result.Err
 	}
 
+	// the file isn't attached to a post yet, so only the user's other clients are told about it
+	sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_ATTACHED, info, "")
+
+	if info.IsVideo() && einterfaces.GetTranscoderInterface() != nil {
+		// work on a copy since the transcoder can take a while and the caller still needs the original
+		videoInfo := *info
+		go generateVideoPreview(&videoInfo, data)
+	}
+
+	if info.IsAudio() {
+		if _, err := CreateAudioWaveformJob(info.Id); err != nil {
+			l4g.Error(utils.T("api.file.create_audio_waveform_job.error"), info.Id, err.Error())
+		}
+	}
+
+	if info.IsPdf() && einterfaces.GetPdfRendererInterface() != nil {
+		if _, err := CreatePdfPreviewJob(info.Id); err != nil {
+			l4g.Error(utils.T("api.file.create_pdf_preview_job.error"), info.Id, err.Error())
+		}
+	}
+
 	return info, nil
 }
 
-func HandleImages(previewPathList []string, thumbnailPathList []string, fileData [][]byte) {
+// VerifyFileChecksum returns an error if a file read from storage doesn't match the checksum recorded when it was
+// uploaded. Files uploaded before checksums were recorded can't be checked, so they're always considered valid.
+func VerifyFileChecksum(info *model.FileInfo, data []byte) *model.AppError {
+	if len(info.Checksum) == 0 {
+		return nil
+	}
+
+	if checksum := model.GetFileChecksum(data); checksum != info.Checksum {
+		return model.NewAppError("VerifyFileChecksum", "app.file.verify_checksum.mismatch.app_error", nil, "file_id="+info.Id+", expected="+info.Checksum+", actual="+checksum, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// canGenerateAnimatedThumbnail returns true if an animated gif should get an animated thumbnail. Gifs with too many
+// frames, or that would take too much memory to decode all at once, get a thumbnail of their first frame instead.
+func canGenerateAnimatedThumbnail(info *model.FileInfo, data []byte) bool {
+	if !*utils.Cfg.FileSettings.EnableAnimatedThumbnails {
+		return false
+	}
+
+	frames, err := model.CountGifFrames(data)
+	if err != nil {
+		return false
+	}
+
+	return frames <= *utils.Cfg.FileSettings.MaxAnimatedThumbnailFrames && frames*info.Width*info.Height <= MaxImageSize
+}
+
+// canGenerateWebPPreview returns true if a WebP copy of an image's preview should be saved for clients that support
+// them. This needs an image encoder to be registered since WebP images can only be decoded otherwise.
+func canGenerateWebPPreview(info *model.FileInfo) bool {
+	if !*utils.Cfg.FileSettings.EnableWebPPreviews || einterfaces.GetImageEncoderInterface() == nil {
+		return false
+	}
+
+	return info.MimeType == "image/jpeg" || info.MimeType == "image/png"
+}
+
+// generateVideoPreview uses the registered transcoder to fill in the duration and resolution of
+// an uploaded video and to save a poster frame as its thumbnail and preview image.
+func generateVideoPreview(info *model.FileInfo, data []byte) {
+	videoInfo, err := einterfaces.GetTranscoderInterface().ExtractVideoInfo(data, info.MimeType)
+	if err != nil {
+		l4g.Error(utils.T("api.file.generate_video_preview.extract.error"), info.Id, err.Error())
+		return
+	}
+
+	info.Duration = videoInfo.Duration
+	info.Width = videoInfo.Width
+	info.Height = videoInfo.Height
+
+	if videoInfo.PosterFrame != nil {
+		generateFilePreviewFromImage(info, videoInfo.PosterFrame)
+	}
+
+	if result := <-Srv.Store.FileInfo().UpdatePreview(info); result.Err != nil {
+		l4g.Error(utils.T("api.file.generate_video_preview.update.error"), info.Id, result.Err.Error())
+	} else {
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_UPDATED, result.Data.(*model.FileInfo), "")
+	}
+}
+
+// generateFilePreviewFromImage saves an image as the thumbnail and preview of a file that isn't an image itself, such as
+// a frame of a video or the first page of a document.
+func generateFilePreviewFromImage(info *model.FileInfo, img image.Image) {
+	pathPrefix := path.Dir(info.Path) + "/"
+	nameWithoutExtension := strings.TrimSuffix(info.Name, filepath.Ext(info.Name))
+	info.PreviewPath = pathPrefix + nameWithoutExtension + "_preview.jpg"
+	info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.jpg"
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	generateThumbnailImage(img, info.ThumbnailPath, width, height)
+	generatePreviewImage(img, info.PreviewPath, "", width)
+
+	info.HasPreviewImage = true
+}
+
+// sendFileInfoEvent lets clients know that a file's info has changed. Events for files that are
+// attached to a post go to the post's channel, which is looked up if channelId is empty, and events
+// for files that haven't been posted yet only go to the user that uploaded them.
+func sendFileInfoEvent(event string, info *model.FileInfo, channelId string) {
+	if len(info.PostId) > 0 && len(channelId) == 0 {
+		if result := <-Srv.Store.Post().GetSingle(info.PostId); result.Err != nil {
+			l4g.Warn(utils.T("api.file.send_file_info_event.post.warn"), info.Id, result.Err.Error())
+			return
+		} else {
+			channelId = result.Data.(*model.Post).ChannelId
+		}
+	}
+
+	var message *model.WebSocketEvent
+	if len(channelId) > 0 {
+		message = model.NewWebSocketEvent(event, "", channelId, "", nil)
+	} else {
+		message = model.NewWebSocketEvent(event, "", "", info.CreatorId, nil)
+	}
+
+	message.Add("file_info", info.ToJson())
+
+	go Publish(message)
+}
+
+func HandleImages(previewPathList []string, webPPreviewPathList []string, thumbnailPathList []string, fileData [][]byte) {
 	for i, data := range fileData {
 		go func(i int, data []byte) {
 			img, width, height := prepareImage(fileData[i])
 			if img != nil {
-				go generateThumbnailImage(*img, thumbnailPathList[i], width, height)
-				go generatePreviewImage(*img, previewPathList[i], width)
+				go generateThumbnail(*img, data, thumbnailPathList[i], width, height)
+				go generatePreviewImage(*img, previewPathList[i], webPPreviewPathList[i], width)
 			}
 		}(i, data)
 	}
@@ -480,20 +687,35 @@ func getImageOrientation(imageData []byte) (int, error) {
 	}
 }
 
-func generateThumbnailImage(img image.Image, thumbnailPath string, width int, height int) {
+// generateThumbnail saves the thumbnail of an uploaded image, which is animated if DoUploadFile gave the image a gif
+// thumbnail path.
+func generateThumbnail(img image.Image, data []byte, thumbnailPath string, width int, height int) {
+	if path.Ext(thumbnailPath) == ".gif" {
+		generateAnimatedThumbnailImage(data, thumbnailPath)
+	} else {
+		generateThumbnailImage(img, thumbnailPath, width, height)
+	}
+}
+
+// resizeToThumbnail scales an image so that it just covers the configured thumbnail size, leaving it alone if it's
+// already smaller than that.
+func resizeToThumbnail(img image.Image, width int, height int) image.Image {
 	thumbWidth := float64(utils.Cfg.FileSettings.ThumbnailWidth)
 	thumbHeight := float64(utils.Cfg.FileSettings.ThumbnailHeight)
 	imgWidth := float64(width)
 	imgHeight := float64(height)
 
-	var thumbnail image.Image
 	if imgHeight < thumbHeight && imgWidth < thumbWidth {
-		thumbnail = img
+		return img
 	} else if imgHeight/imgWidth < thumbHeight/thumbWidth {
-		thumbnail = imaging.Resize(img, 0, utils.Cfg.FileSettings.ThumbnailHeight, imaging.Lanczos)
+		return imaging.Resize(img, 0, utils.Cfg.FileSettings.ThumbnailHeight, imaging.Lanczos)
 	} else {
-		thumbnail = imaging.Resize(img, utils.Cfg.FileSettings.ThumbnailWidth, 0, imaging.Lanczos)
+		return imaging.Resize(img, utils.Cfg.FileSettings.ThumbnailWidth, 0, imaging.Lanczos)
 	}
+}
+
+func generateThumbnailImage(img image.Image, thumbnailPath string, width int, height int) {
+	thumbnail := resizeToThumbnail(img, width, height)
 
 	buf := new(bytes.Buffer)
 	if err := jpeg.Encode(buf, thumbnail, &jpeg.Options{Quality: 90}); err != nil {
@@ -507,7 +729,64 @@ func generateThumbnailImage(img image.Image, thumbnailPath string, width int, he
 	}
 }
 
-func generatePreviewImage(img image.Image, previewPath string, width int) {
+// generateAnimatedThumbnailImage saves a scaled down copy of an animated gif as its thumbnail. Since frames can update
+// just part of the image, each one is drawn over what came before it, following the gif's disposal methods, and the
+// thumbnail is made up of the scaled down results.
+func generateAnimatedThumbnailImage(data []byte, thumbnailPath string) {
+	src, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		l4g.Error(utils.T("api.file.generate_animated_thumbnail.decode.error"), thumbnailPath, err)
+		return
+	}
+
+	bounds := image.Rect(0, 0, src.Config.Width, src.Config.Height)
+	canvas := image.NewRGBA(bounds)
+
+	thumbnail := &gif.GIF{
+		Image:     make([]*image.Paletted, len(src.Image)),
+		Delay:     src.Delay,
+		Disposal:  make([]byte, len(src.Image)),
+		LoopCount: src.LoopCount,
+	}
+
+	for i, frame := range src.Image {
+		var previous *image.RGBA
+		if src.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, image.ZP, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		scaled := resizeToThumbnail(canvas, bounds.Dx(), bounds.Dy())
+		thumbnail.Image[i] = image.NewPaletted(scaled.Bounds(), frame.Palette)
+		draw.Draw(thumbnail.Image[i], scaled.Bounds(), scaled, scaled.Bounds().Min, draw.Src)
+
+		// Every frame of the thumbnail covers the whole image, so it's cleared before drawing the next one in case
+		// any of it is transparent
+		thumbnail.Disposal[i] = gif.DisposalBackground
+
+		switch src.Disposal[i] {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.ZP, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gif.EncodeAll(buf, thumbnail); err != nil {
+		l4g.Error(utils.T("api.file.generate_animated_thumbnail.encode.error"), thumbnailPath, err)
+		return
+	}
+
+	if err := WriteFile(buf.Bytes(), thumbnailPath); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.upload_thumb.error"), thumbnailPath, err)
+		return
+	}
+}
+
+func generatePreviewImage(img image.Image, previewPath string, webPPreviewPath string, width int) {
 	var preview image.Image
 	if width > int(utils.Cfg.FileSettings.PreviewWidth) {
 		preview = imaging.Resize(img, utils.Cfg.FileSettings.PreviewWidth, utils.Cfg.FileSettings.PreviewHeight, imaging.Lanczos)
@@ -526,4 +805,26 @@ func generatePreviewImage(img image.Image, previewPath string, width int) {
 		l4g.Error(utils.T("api.file.handle_images_forget.upload_preview.error"), previewPath, err)
 		return
 	}
+
+	if len(webPPreviewPath) > 0 {
+		generateWebPPreviewImage(preview, webPPreviewPath)
+	}
+}
+
+func generateWebPPreviewImage(preview image.Image, webPPreviewPath string) {
+	encoder := einterfaces.GetImageEncoderInterface()
+	if encoder == nil {
+		return
+	}
+
+	data, err := encoder.EncodeWebP(preview)
+	if err != nil {
+		l4g.Error(utils.T("api.file.generate_webp_preview.encode.error"), webPPreviewPath, err.Error())
+		return
+	}
+
+	if err := WriteFile(data, webPPreviewPath); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.upload_preview.error"), webPPreviewPath, err)
+		return
+	}
 }
@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// RecordFileAccess adds a file being sent to a user to the file access log if it's enabled. A failure to record the
+// access is logged rather than returned so that it doesn't stop the file from being sent.
+func RecordFileAccess(fileId string, userId string, ipAddress string, linkType string) {
+	if !*utils.Cfg.ComplianceSettings.EnableFileAccessLog {
+		return
+	}
+
+	access := &model.FileAccess{
+		FileId:    fileId,
+		UserId:    userId,
+		IpAddress: ipAddress,
+		LinkType:  linkType,
+	}
+
+	if result := <-Srv.Store.FileAccess().Save(access); result.Err != nil {
+		l4g.Error(utils.T("app.file_access.record.error"), fileId, result.Err.Error())
+	}
+}
+
+func GetFileAccessLog(query *model.FileAccessQuery) ([]*model.FileAccess, *model.AppError) {
+	if result := <-Srv.Store.FileAccess().Query(query); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.FileAccess), nil
+	}
+}
+
+// deleteExpiredFileAccesses removes the entries of the file access log that are older than it's configured to keep
+// them for and returns how many there were.
+func deleteExpiredFileAccesses(now int64) (int64, *model.AppError) {
+	before := retentionCutoff(*utils.Cfg.ComplianceSettings.FileAccessLogRetentionDays, now)
+	if before == 0 {
+		return 0, nil
+	}
+
+	if result := <-Srv.Store.FileAccess().PermanentDeleteBefore(before); result.Err != nil {
+		return 0, result.Err
+	} else {
+		return result.Data.(int64), nil
+	}
+}
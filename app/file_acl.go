@@ -0,0 +1,79 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+// GetFileAcl returns the access control list of a file, or nil if the file isn't restricted.
+func GetFileAcl(fileId string) (*model.FileAcl, *model.AppError) {
+	if result := <-Srv.Store.FileAcl().Get(fileId); result.Err != nil {
+		if result.Err.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.FileAcl), nil
+	}
+}
+
+// UpdateFileAcl restricts a file to the users and channels in the given access control list, replacing any that it
+// already had.
+func UpdateFileAcl(acl *model.FileAcl) (*model.FileAcl, *model.AppError) {
+	for _, userId := range acl.UserIds {
+		if _, err := GetUser(userId); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, channelId := range acl.ChannelIds {
+		if _, err := GetChannel(channelId); err != nil {
+			return nil, err
+		}
+	}
+
+	if result := <-Srv.Store.FileAcl().Save(acl); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.FileAcl), nil
+	}
+}
+
+// DeleteFileAcl removes the restrictions on a file so that anyone who can see the post that it's attached to can
+// access it again.
+func DeleteFileAcl(fileId string) *model.AppError {
+	if result := <-Srv.Store.FileAcl().Delete(fileId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// HasFileAccess returns true if the user is allowed to access a file by its access control list. Files that aren't
+// restricted can be accessed by anyone, so callers still need to check that the user can see the file's post.
+func HasFileAccess(userId string, info *model.FileInfo) (bool, *model.AppError) {
+	acl, err := GetFileAcl(info.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if acl == nil || info.CreatorId == userId || acl.AllowsUser(userId) {
+		return true, nil
+	}
+
+	for _, channelId := range acl.ChannelIds {
+		if result := <-Srv.Store.Channel().GetMember(channelId, userId); result.Err == nil {
+			return true, nil
+		} else if result.Err.Id != store.MISSING_CHANNEL_MEMBER_ERROR {
+			return false, result.Err
+		}
+	}
+
+	return false, nil
+}
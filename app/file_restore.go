@@ -0,0 +1,86 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// deletedFileRestoreCutoff returns the time after which deleted files can still be restored. It's 0 when deleted files
+// are kept until the regular retention settings remove them.
+func deletedFileRestoreCutoff(now int64) int64 {
+	return retentionCutoff(*utils.Cfg.DataRetentionSettings.DeletedFileRetentionDays, now)
+}
+
+// GetDeletedFiles returns the deleted files that can still be restored, most recently deleted first.
+func GetDeletedFiles(offset int, limit int) ([]*model.FileInfo, *model.AppError) {
+	if result := <-Srv.Store.FileInfo().GetDeleted(deletedFileRestoreCutoff(model.GetMillis()), offset, limit); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.FileInfo), nil
+	}
+}
+
+// RestoreFiles undeletes the given files and reattaches them to their posts. Files that aren't deleted, that were
+// deleted too long ago to be restored or whose post has been deleted are skipped, so only the restored files are
+// returned.
+func RestoreFiles(fileIds []string) ([]*model.FileInfo, *model.AppError) {
+	var infos []*model.FileInfo
+	if result := <-Srv.Store.FileInfo().Restore(fileIds, deletedFileRestoreCutoff(model.GetMillis())); result.Err != nil {
+		return nil, result.Err
+	} else {
+		infos = result.Data.([]*model.FileInfo)
+	}
+
+	invalidated := map[string]bool{}
+	for _, info := range infos {
+		if len(info.PostId) == 0 {
+			continue
+		}
+
+		if !invalidated[info.PostId] {
+			InvalidateCacheForFileInfosForPost(info.PostId)
+			invalidated[info.PostId] = true
+		}
+
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_ATTACHED, info, "")
+	}
+
+	return infos, nil
+}
+
+// reclaimDeletedFiles permanently removes the files that were deleted too long ago to be restored and returns how
+// many there were.
+func reclaimDeletedFiles(now int64) (int64, *model.AppError) {
+	before := deletedFileRestoreCutoff(now)
+	if before == 0 {
+		return 0, nil
+	}
+
+	var reclaimed int64
+
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.FileInfo().GetDeletedBefore(before, DATA_RETENTION_BATCH_SIZE); result.Err != nil {
+			return reclaimed, result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		if len(infos) == 0 {
+			return reclaimed, nil
+		}
+
+		if err := permanentDeleteFiles(infos); err != nil {
+			return reclaimed, err
+		}
+
+		reclaimed += int64(len(infos))
+
+		if len(infos) < DATA_RETENTION_BATCH_SIZE {
+			return reclaimed, nil
+		}
+	}
+}
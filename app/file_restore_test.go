@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestRestoreFiles(t *testing.T) {
+	th := Setup().InitBasic()
+
+	retentionDays := *utils.Cfg.DataRetentionSettings.DeletedFileRetentionDays
+	defer func() {
+		*utils.Cfg.DataRetentionSettings.DeletedFileRetentionDays = retentionDays
+	}()
+	*utils.Cfg.DataRetentionSettings.DeletedFileRetentionDays = 1
+
+	now := model.GetMillis()
+
+	recent := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		PostId:    th.BasicPost.Id,
+		Path:      "recent.txt",
+		DeleteAt:  now,
+	})).(*model.FileInfo)
+	old := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		PostId:    th.BasicPost.Id,
+		Path:      "old.txt",
+		DeleteAt:  now - 2*DAY_MILLISECONDS,
+	})).(*model.FileInfo)
+
+	if infos, err := RestoreFiles([]string{recent.Id, old.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != recent.Id {
+		t.Fatal("should've only restored the file deleted within the window")
+	}
+
+	if infos, err := GetFileInfosForPost(th.BasicPost.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != recent.Id {
+		t.Fatal("restored file should be attached to the post")
+	}
+
+	if reclaimed, err := reclaimDeletedFiles(model.GetMillis()); err != nil {
+		t.Fatal(err)
+	} else if reclaimed < 1 {
+		t.Fatal("should've reclaimed the old file")
+	}
+
+	if count := store.Must(Srv.Store.FileInfo().CountByPath(old.Path, old.CreateAt)).(int64); count != 0 {
+		t.Fatal("old file should've been permanently deleted")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	s3 "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/minio/minio-go/pkg/s3utils"
+)
+
+const (
+	// Files larger than this are uploaded to S3 in parts of this size, which has to be at least the 5MB that S3
+	// requires for every part but the last one
+	S3_MULTIPART_PART_SIZE = 8 * 1024 * 1024
+
+	S3_MULTIPART_MAX_ATTEMPTS = 3
+	S3_MULTIPART_RETRY_DELAY  = time.Second
+
+	// How long a request made directly to S3 can take, which needs to be long enough to upload a whole part
+	S3_REQUEST_TIMEOUT = 2 * time.Minute
+)
+
+// The client used for the requests that are made to S3 directly, so that a stalled connection fails instead of
+// holding up whoever is waiting on it
+var s3HttpClient = &http.Client{Timeout: S3_REQUEST_TIMEOUT}
+
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadId string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+// s3ServerSideEncryptionHeaders returns the headers that ask S3 to encrypt the files that it stores with either its
+// own keys or a KMS key depending on how it's configured.
+func s3ServerSideEncryptionHeaders(settings *model.FileSettings) map[string]string {
+	headers := make(map[string]string)
+
+	switch *settings.AmazonS3SSE {
+	case model.S3_SSE_AES256:
+		headers["X-Amz-Server-Side-Encryption"] = model.S3_SSE_AES256
+	case model.S3_SSE_KMS:
+		headers["X-Amz-Server-Side-Encryption"] = model.S3_SSE_KMS
+		if keyId := *settings.AmazonS3KMSKeyId; len(keyId) > 0 {
+			headers["X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"] = keyId
+		}
+	}
+
+	return headers
+}
+
+// s3PutObject uploads a file in a single request if it's small enough or as a multipart upload otherwise.
+func s3PutObject(settings *model.FileSettings, s3Clnt *s3.Client, bucket string, objectPath string, data []byte, contentType string) error {
+	if len(data) > S3_MULTIPART_PART_SIZE {
+		return s3PutObjectMultipart(settings, bucket, objectPath, data, contentType)
+	}
+
+	metaData := map[string][]string{
+		"Content-Type": {contentType},
+	}
+	for name, value := range s3ServerSideEncryptionHeaders(settings) {
+		metaData[name] = []string{value}
+	}
+
+	_, err := s3Clnt.PutObjectWithMetadata(bucket, objectPath, bytes.NewReader(data), metaData, nil)
+	return err
+}
+
+// s3CopyObject copies a file within the bucket. The client library can't ask for the copy to be encrypted, so the
+// request is made directly when the files are meant to be.
+func s3CopyObject(settings *model.FileSettings, s3Clnt *s3.Client, bucket string, oldPath string, newPath string) error {
+	headers := s3ServerSideEncryptionHeaders(settings)
+	if len(headers) == 0 {
+		return s3Clnt.CopyObject(bucket, newPath, "/"+bucket+"/"+oldPath, s3.NewCopyConditions())
+	}
+
+	headers["X-Amz-Copy-Source"] = s3utils.EncodePath("/" + bucket + "/" + oldPath)
+
+	_, err := doS3Request(settings, "PUT", bucket, newPath, nil, headers, nil, nil)
+	return err
+}
+
+// s3PutObjectMultipart uploads a file in parts, retrying each part a few times before giving up. The upload is
+// aborted if it fails so that S3 doesn't keep the parts that were uploaded.
+func s3PutObjectMultipart(settings *model.FileSettings, bucket string, objectPath string, data []byte, contentType string) error {
+	headers := s3ServerSideEncryptionHeaders(settings)
+	headers["Content-Type"] = contentType
+
+	var initiated s3InitiateMultipartUploadResult
+	if _, err := doS3Request(settings, "POST", bucket, objectPath, url.Values{"uploads": {""}}, headers, nil, &initiated); err != nil {
+		return err
+	}
+
+	complete := s3CompleteMultipartUpload{}
+
+	for partNumber := 1; len(data) > 0; partNumber++ {
+		size := S3_MULTIPART_PART_SIZE
+		if len(data) < size {
+			size = len(data)
+		}
+
+		etag, err := s3UploadPart(settings, bucket, objectPath, initiated.UploadId, partNumber, data[:size])
+		if err != nil {
+			s3AbortMultipartUpload(settings, bucket, objectPath, initiated.UploadId)
+			return err
+		}
+
+		complete.Parts = append(complete.Parts, s3CompletedPart{PartNumber: partNumber, ETag: etag})
+		data = data[size:]
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		s3AbortMultipartUpload(settings, bucket, objectPath, initiated.UploadId)
+		return err
+	}
+
+	if _, err := doS3Request(settings, "POST", bucket, objectPath, url.Values{"uploadId": {initiated.UploadId}}, nil, body, nil); err != nil {
+		s3AbortMultipartUpload(settings, bucket, objectPath, initiated.UploadId)
+		return err
+	}
+
+	return nil
+}
+
+func s3UploadPart(settings *model.FileSettings, bucket string, objectPath string, uploadId string, partNumber int, data []byte) (string, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadId},
+	}
+
+	var err error
+	for attempt := 1; attempt <= S3_MULTIPART_MAX_ATTEMPTS; attempt++ {
+		var header http.Header
+		if header, err = doS3Request(settings, "PUT", bucket, objectPath, query, nil, data, nil); err == nil {
+			return header.Get("ETag"), nil
+		}
+
+		l4g.Warn(utils.T("api.file.s3_upload_part.retry.warn"), partNumber, objectPath, attempt, err.Error())
+
+		if attempt < S3_MULTIPART_MAX_ATTEMPTS {
+			time.Sleep(time.Duration(attempt) * S3_MULTIPART_RETRY_DELAY)
+		}
+	}
+
+	return "", err
+}
+
+func s3AbortMultipartUpload(settings *model.FileSettings, bucket string, objectPath string, uploadId string) {
+	if _, err := doS3Request(settings, "DELETE", bucket, objectPath, url.Values{"uploadId": {uploadId}}, nil, nil, nil); err != nil {
+		l4g.Error(utils.T("api.file.s3_abort_multipart_upload.error"), objectPath, err.Error())
+	}
+}
+
+// doS3Request makes a signed request for an object in the bucket and decodes the XML response into result if it's
+// given. S3 can report an error with a successful status code, so the body is always checked for one.
+func doS3Request(settings *model.FileSettings, method string, bucket string, objectPath string, query url.Values, headers map[string]string, body []byte, result interface{}) (http.Header, error) {
+	scheme := "http"
+	if *settings.AmazonS3SSL {
+		scheme = "https"
+	}
+
+	fullPath := "/" + bucket + "/" + strings.TrimPrefix(objectPath, "/")
+	requestUrl := &url.URL{
+		Scheme:   scheme,
+		Host:     settings.AmazonS3Endpoint,
+		Path:     fullPath,
+		RawPath:  s3utils.EncodePath(fullPath),
+		RawQuery: strings.Replace(query.Encode(), "+", "%20", -1),
+	}
+
+	req, err := http.NewRequest(method, requestUrl.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	sum := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+
+	req = s3signer.SignV4(*req, settings.AmazonS3AccessKeyId, settings.AmazonS3SecretAccessKey, settings.AmazonS3Region)
+
+	resp, err := s3HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var respErr s3Error
+	if len(respBody) > 0 && xml.Unmarshal(respBody, &respErr) == nil {
+		return nil, &respErr
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%v %v failed with status %v", method, fullPath, resp.StatusCode)
+	}
+
+	if result != nil {
+		if err := xml.Unmarshal(respBody, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Header, nil
+}
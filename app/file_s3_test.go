@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// fakeS3 handles just enough of the multipart upload API to check the requests that are made to it.
+type fakeS3 struct {
+	mutex     sync.Mutex
+	headers   http.Header
+	parts     map[string][]byte
+	completed []byte
+	aborted   bool
+	failures  map[string]int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	query := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+
+	switch {
+	case r.Method == "POST" && r.URL.RawQuery == "uploads=":
+		f.headers = r.Header
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><InitiateMultipartUploadResult><UploadId>upload1</UploadId></InitiateMultipartUploadResult>`))
+	case r.Method == "PUT" && query.Get("uploadId") == "upload1":
+		partNumber := query.Get("partNumber")
+		if f.failures[partNumber] > 0 {
+			f.failures[partNumber]--
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<Error><Code>InternalError</Code><Message>try again</Message></Error>`))
+			return
+		}
+
+		f.parts[partNumber] = body
+		w.Header().Set("ETag", `"etag`+partNumber+`"`)
+	case r.Method == "POST" && query.Get("uploadId") == "upload1":
+		f.completed = body
+		w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`))
+	case r.Method == "DELETE" && query.Get("uploadId") == "upload1":
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestS3PutObjectMultipart(t *testing.T) {
+	Setup()
+
+	fake := &fakeS3{parts: make(map[string][]byte), failures: map[string]int{"2": 1}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.AmazonS3Endpoint = strings.TrimPrefix(server.URL, "http://")
+	utils.Cfg.FileSettings.AmazonS3SSL = new(bool)
+	utils.Cfg.FileSettings.AmazonS3AccessKeyId = "accesskey"
+	utils.Cfg.FileSettings.AmazonS3SecretAccessKey = "secretkey"
+	sse := model.S3_SSE_KMS
+	utils.Cfg.FileSettings.AmazonS3SSE = &sse
+	keyId := "key1"
+	utils.Cfg.FileSettings.AmazonS3KMSKeyId = &keyId
+
+	data := bytes.Repeat([]byte("a"), S3_MULTIPART_PART_SIZE*2+10)
+
+	if err := s3PutObjectMultipart(&utils.Cfg.FileSettings, "bucket", "teams/file name.txt", data, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.headers.Get("X-Amz-Server-Side-Encryption") != model.S3_SSE_KMS || fake.headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id") != "key1" {
+		t.Fatal("should have asked for the file to be encrypted", fake.headers)
+	}
+
+	if len(fake.parts) != 3 || len(fake.parts["1"]) != S3_MULTIPART_PART_SIZE || len(fake.parts["3"]) != 10 {
+		t.Fatal("should have uploaded the file in three parts")
+	}
+
+	var complete s3CompleteMultipartUpload
+	if err := xml.Unmarshal(fake.completed, &complete); err != nil {
+		t.Fatal(err)
+	} else if len(complete.Parts) != 3 || complete.Parts[1].PartNumber != 2 || complete.Parts[1].ETag != `"etag2"` {
+		t.Fatal("should have completed the upload with every part", string(fake.completed))
+	}
+
+	if fake.aborted {
+		t.Fatal("shouldn't have aborted a successful upload")
+	}
+}
+
+func TestS3PutObjectMultipartAbort(t *testing.T) {
+	Setup()
+
+	fake := &fakeS3{parts: make(map[string][]byte), failures: map[string]int{"2": S3_MULTIPART_MAX_ATTEMPTS}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.AmazonS3Endpoint = strings.TrimPrefix(server.URL, "http://")
+	utils.Cfg.FileSettings.AmazonS3SSL = new(bool)
+	sse := model.S3_SSE_NONE
+	utils.Cfg.FileSettings.AmazonS3SSE = &sse
+
+	data := bytes.Repeat([]byte("a"), S3_MULTIPART_PART_SIZE*3)
+
+	if err := s3PutObjectMultipart(&utils.Cfg.FileSettings, "bucket", "file.txt", data, "text/plain"); err == nil {
+		t.Fatal("should have failed when a part couldn't be uploaded")
+	} else if err.Error() != "InternalError: try again" {
+		t.Fatal("should have returned the error from S3", err)
+	}
+
+	if !fake.aborted || fake.completed != nil {
+		t.Fatal("should have aborted the upload")
+	}
+
+	if len(fake.parts) != 1 {
+		t.Fatal("shouldn't have uploaded any parts after the one that failed")
+	}
+}
+
+func TestDoS3RequestTimeout(t *testing.T) {
+	Setup()
+
+	stalled := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	timeout := s3HttpClient.Timeout
+	defer func() {
+		s3HttpClient.Timeout = timeout
+	}()
+	s3HttpClient.Timeout = 100 * time.Millisecond
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.AmazonS3Endpoint = strings.TrimPrefix(server.URL, "http://")
+	utils.Cfg.FileSettings.AmazonS3SSL = new(bool)
+
+	if _, err := doS3Request(&utils.Cfg.FileSettings, "DELETE", "bucket", "file.txt", nil, nil, nil, nil); err == nil {
+		t.Fatal("should have given up on a request that stalled")
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	FILE_STORAGE_MIGRATION_BATCH_SIZE = 100
+
+	FILE_STORAGE_MIGRATION_DATA_DESTINATION_CONFIG = "destination_config"
+	FILE_STORAGE_MIGRATION_DATA_PATH_PREFIX        = "path_prefix"
+	FILE_STORAGE_MIGRATION_DATA_FIRST_CREATE_AT    = "first_create_at"
+	FILE_STORAGE_MIGRATION_DATA_LAST_CREATE_AT     = "last_create_at"
+	FILE_STORAGE_MIGRATION_DATA_LAST_FILE_ID       = "last_file_id"
+	FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED     = "files_migrated"
+	FILE_STORAGE_MIGRATION_DATA_FILES_MISSING      = "files_missing"
+	FILE_STORAGE_MIGRATION_DATA_COPIED             = "copied"
+	FILE_STORAGE_MIGRATION_DATA_UPDATED_CREATE_AT  = "updated_create_at"
+	FILE_STORAGE_MIGRATION_DATA_UPDATED_FILE_ID    = "updated_file_id"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_FILE_STORAGE_MIGRATION, runFileStorageMigrationJob)
+}
+
+// CreateFileStorageMigrationJob queues a job that copies every file from the configured storage backend to the one
+// described by the FileSettings of the given config file. The config file is read when the job runs rather than
+// being saved with the job so that storage credentials aren't kept in the database. If pathPrefix is set, the copies
+// are stored under it and the paths saved in the database are updated to match once they've all been copied.
+func CreateFileStorageMigrationJob(destinationConfigFile string, pathPrefix string) (*model.Job, *model.AppError) {
+	destinationConfigFile, err := filepath.Abs(destinationConfigFile)
+	if err != nil {
+		return nil, model.NewAppError("CreateFileStorageMigrationJob", "app.file_storage_migration.destination_config.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	destination, appErr := readFileStorageMigrationDestination(destinationConfigFile)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if pathPrefix == "" && isSameFileStorage(&utils.Cfg.FileSettings, destination) {
+		return nil, model.NewAppError("CreateFileStorageMigrationJob", "app.file_storage_migration.same_storage.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return CreateJob(model.JOB_TYPE_FILE_STORAGE_MIGRATION, map[string]string{
+		FILE_STORAGE_MIGRATION_DATA_DESTINATION_CONFIG: destinationConfigFile,
+		FILE_STORAGE_MIGRATION_DATA_PATH_PREFIX:        pathPrefix,
+	})
+}
+
+func readFileStorageMigrationDestination(fileName string) (*model.FileSettings, *model.AppError) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, model.NewAppError("readFileStorageMigrationDestination", "app.file_storage_migration.destination_config.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+
+	config := model.ConfigFromJson(file)
+	if config == nil {
+		return nil, model.NewAppError("readFileStorageMigrationDestination", "app.file_storage_migration.destination_config.app_error", nil, "file="+fileName, http.StatusBadRequest)
+	}
+
+	config.SetDefaults()
+
+	if config.FileSettings.DriverName != model.IMAGE_DRIVER_LOCAL && config.FileSettings.DriverName != model.IMAGE_DRIVER_S3 {
+		return nil, model.NewAppError("readFileStorageMigrationDestination", "app.file_storage_migration.destination_driver.app_error", nil, "driver="+config.FileSettings.DriverName, http.StatusBadRequest)
+	}
+
+	return &config.FileSettings, nil
+}
+
+func isSameFileStorage(a *model.FileSettings, b *model.FileSettings) bool {
+	if a.DriverName != b.DriverName {
+		return false
+	}
+
+	if a.DriverName == model.IMAGE_DRIVER_S3 {
+		return a.AmazonS3Endpoint == b.AmazonS3Endpoint && a.AmazonS3Bucket == b.AmazonS3Bucket
+	}
+
+	sourceDir, _ := filepath.Abs(a.Directory)
+	destinationDir, _ := filepath.Abs(b.Directory)
+	return sourceDir == destinationDir
+}
+
+// runFileStorageMigrationJob copies the files in batches ordered by when they were created, saving a checkpoint after
+// each batch so that an interrupted migration can be resumed without copying everything again. If the files are being
+// moved under a prefix, the paths saved in the database are only changed once every file has been copied, so the
+// server keeps using the original files until the copies are all in place.
+func runFileStorageMigrationJob(job *model.Job) *model.AppError {
+	source := &utils.Cfg.FileSettings
+
+	destination, err := readFileStorageMigrationDestination(job.Data[FILE_STORAGE_MIGRATION_DATA_DESTINATION_CONFIG])
+	if err != nil {
+		return err
+	}
+
+	pathPrefix := job.Data[FILE_STORAGE_MIGRATION_DATA_PATH_PREFIX]
+
+	if job.Data[FILE_STORAGE_MIGRATION_DATA_COPIED] != "true" {
+		if err := copyFilesForMigration(job, source, destination, pathPrefix); err != nil {
+			return err
+		}
+	}
+
+	if len(pathPrefix) > 0 {
+		if err := updateFilePathsForMigration(job, pathPrefix); err != nil {
+			return err
+		}
+	}
+
+	l4g.Info(utils.T("app.file_storage_migration.finished.info"), job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED), job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MISSING))
+
+	return nil
+}
+
+func copyFilesForMigration(job *model.Job, source *model.FileSettings, destination *model.FileSettings, pathPrefix string) *model.AppError {
+	firstCreateAt := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FIRST_CREATE_AT)
+	lastCreateAt := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_LAST_CREATE_AT)
+	lastFileId := job.Data[FILE_STORAGE_MIGRATION_DATA_LAST_FILE_ID]
+
+	filesMigrated := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED)
+	filesMissing := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MISSING)
+
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.FileInfo().GetBatchForMigration(lastCreateAt, lastFileId, FILE_STORAGE_MIGRATION_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		if len(infos) == 0 {
+			break
+		}
+
+		if firstCreateAt == 0 {
+			firstCreateAt = infos[0].CreateAt
+			job.SetDataInt64(FILE_STORAGE_MIGRATION_DATA_FIRST_CREATE_AT, firstCreateAt)
+		}
+
+		for _, info := range infos {
+			for _, path := range []string{info.Path, info.ThumbnailPath, info.PreviewPath, info.WebPPreviewPath} {
+				if len(path) == 0 {
+					continue
+				}
+
+				if found, err := migrateFile(source, destination, path, prefixFilePath(pathPrefix, path)); err != nil {
+					return err
+				} else if !found {
+					filesMissing++
+				}
+			}
+		}
+
+		last := infos[len(infos)-1]
+		lastCreateAt = last.CreateAt
+		lastFileId = last.Id
+		filesMigrated += int64(len(infos))
+
+		job.SetDataInt64(FILE_STORAGE_MIGRATION_DATA_LAST_CREATE_AT, lastCreateAt)
+		job.Data[FILE_STORAGE_MIGRATION_DATA_LAST_FILE_ID] = lastFileId
+		job.SetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED, filesMigrated)
+		job.SetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MISSING, filesMissing)
+
+		if err := SetJobProgress(job, searchIndexingProgress(firstCreateAt, job.CreateAt, lastCreateAt)); err != nil {
+			return err
+		}
+
+		if len(infos) < FILE_STORAGE_MIGRATION_BATCH_SIZE {
+			break
+		}
+	}
+
+	job.Data[FILE_STORAGE_MIGRATION_DATA_COPIED] = "true"
+	return SetJobProgress(job, job.Progress)
+}
+
+// updateFilePathsForMigration moves the paths saved in the database under the prefix once the files have been copied.
+// Only the files that were copied are updated, and it saves a checkpoint after each batch in the same way as copying
+// the files does.
+func updateFilePathsForMigration(job *model.Job, pathPrefix string) *model.AppError {
+	copiedCreateAt := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_LAST_CREATE_AT)
+	copiedFileId := job.Data[FILE_STORAGE_MIGRATION_DATA_LAST_FILE_ID]
+
+	lastCreateAt := job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_UPDATED_CREATE_AT)
+	lastFileId := job.Data[FILE_STORAGE_MIGRATION_DATA_UPDATED_FILE_ID]
+
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.FileInfo().GetBatchForMigration(lastCreateAt, lastFileId, FILE_STORAGE_MIGRATION_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		copied := []*model.FileInfo{}
+		for _, info := range infos {
+			// files uploaded after the copying finished weren't copied, so they have to stay where they are
+			if info.CreateAt > copiedCreateAt || (info.CreateAt == copiedCreateAt && info.Id > copiedFileId) {
+				break
+			}
+
+			for _, path := range []*string{&info.Path, &info.ThumbnailPath, &info.PreviewPath, &info.WebPPreviewPath} {
+				if len(*path) > 0 {
+					*path = prefixFilePath(pathPrefix, *path)
+				}
+			}
+
+			copied = append(copied, info)
+		}
+
+		if len(copied) == 0 {
+			return nil
+		}
+
+		if result := <-Srv.Store.FileInfo().UpdatePaths(copied); result.Err != nil {
+			return result.Err
+		}
+
+		last := copied[len(copied)-1]
+		lastCreateAt = last.CreateAt
+		lastFileId = last.Id
+
+		job.SetDataInt64(FILE_STORAGE_MIGRATION_DATA_UPDATED_CREATE_AT, lastCreateAt)
+		job.Data[FILE_STORAGE_MIGRATION_DATA_UPDATED_FILE_ID] = lastFileId
+
+		if err := SetJobProgress(job, job.Progress); err != nil {
+			return err
+		}
+
+		if len(copied) < FILE_STORAGE_MIGRATION_BATCH_SIZE {
+			return nil
+		}
+	}
+}
+
+// prefixFilePath returns where a file is stored under the prefix. Paths that are already under it are left alone so
+// that files aren't nested under the prefix twice if the same migration is run again.
+func prefixFilePath(pathPrefix string, path string) string {
+	if strings.HasPrefix(path, pathPrefix) {
+		return path
+	}
+
+	return pathPrefix + path
+}
+
+// migrateFile copies a file to the destination and reads it back to make sure that it wasn't corrupted on the way. It
+// returns false without an error if the file doesn't exist in the source, since the files of old posts may have been
+// removed by hand.
+func migrateFile(source *model.FileSettings, destination *model.FileSettings, path string, newPath string) (bool, *model.AppError) {
+	data, err := readFileWithSettings(source, path)
+	if err != nil {
+		l4g.Warn(utils.T("app.file_storage_migration.read.warn"), path, err.Error())
+		return false, nil
+	}
+
+	if err := writeFileWithSettings(destination, data, newPath); err != nil {
+		return true, err
+	}
+
+	copied, err := readFileWithSettings(destination, newPath)
+	if err != nil {
+		return true, err
+	}
+
+	if expected, actual := sha256.Sum256(data), sha256.Sum256(copied); !bytes.Equal(expected[:], actual[:]) {
+		return true, model.NewAppError("migrateFile", "app.file_storage_migration.checksum.app_error", nil, "path="+newPath, http.StatusInternalServerError)
+	}
+
+	return true, nil
+}
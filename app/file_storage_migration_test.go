@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestFileStorageMigration(t *testing.T) {
+	Setup()
+
+	sourceDir, err := ioutil.TempDir("", "migration_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sourceDir)
+
+	destinationDir, err := ioutil.TempDir("", "migration_destination")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(destinationDir)
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = sourceDir + "/"
+
+	data := []byte("file contents")
+	info := &model.FileInfo{CreatorId: model.NewId(), Path: "migration/" + model.NewId() + "/file.txt", ThumbnailPath: "migration/missing_thumb.jpg"}
+	if err := WriteFile(data, info.Path); err != nil {
+		t.Fatal(err)
+	}
+	info = store.Must(Srv.Store.FileInfo().Save(info)).(*model.FileInfo)
+	defer func() {
+		<-Srv.Store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	sameConfig := filepath.Join(sourceDir, "same.json")
+	if err := ioutil.WriteFile(sameConfig, []byte(utils.Cfg.ToJson()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CreateFileStorageMigrationJob(sameConfig, ""); err == nil {
+		t.Fatal("shouldn't be able to migrate to the same storage")
+	}
+
+	destinationConfig := filepath.Join(sourceDir, "destination.json")
+	config := *utils.Cfg
+	config.FileSettings.Directory = destinationDir + "/"
+	if err := ioutil.WriteFile(destinationConfig, []byte(config.ToJson()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	job, appErr := CreateFileStorageMigrationJob(destinationConfig, "")
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if !RunJobNow(job) {
+		t.Fatal("should have run the job")
+	}
+
+	if job, appErr = GetJob(job.Id); appErr != nil {
+		t.Fatal(appErr)
+	} else if job.Status != model.JOB_STATUS_SUCCESS {
+		t.Fatal("job should have succeeded", job.Data["error"])
+	} else if job.GetDataInt64(FILE_STORAGE_MIGRATION_DATA_FILES_MISSING) < 1 {
+		t.Fatal("should have counted the missing thumbnail")
+	}
+
+	if copied, err := ioutil.ReadFile(filepath.Join(destinationDir, info.Path)); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(copied, data) {
+		t.Fatal("copy should match the original")
+	}
+
+	if received := store.Must(Srv.Store.FileInfo().Get(info.Id)).(*model.FileInfo); received.Path != info.Path {
+		t.Fatal("shouldn't have changed the path without a prefix")
+	}
+
+	if _, err := ResumeJob(job.Id); err == nil {
+		t.Fatal("shouldn't be able to resume a job that succeeded")
+	}
+
+	t.Run("PathPrefix", func(t *testing.T) {
+		// a file that was already moved under the prefix by an earlier migration
+		moved := &model.FileInfo{CreatorId: model.NewId(), Path: "prefix/migration/" + model.NewId() + "/moved.txt"}
+		if err := WriteFile(data, moved.Path); err != nil {
+			t.Fatal(err)
+		}
+		moved = store.Must(Srv.Store.FileInfo().Save(moved)).(*model.FileInfo)
+		defer func() {
+			<-Srv.Store.FileInfo().PermanentDeleteBatch([]string{moved.Id})
+		}()
+
+		job, appErr := CreateFileStorageMigrationJob(destinationConfig, "prefix/")
+		if appErr != nil {
+			t.Fatal(appErr)
+		}
+
+		if !RunJobNow(job) {
+			t.Fatal("should have run the job")
+		}
+
+		if job, appErr = GetJob(job.Id); appErr != nil {
+			t.Fatal(appErr)
+		} else if job.Status != model.JOB_STATUS_SUCCESS {
+			t.Fatal("job should have succeeded", job.Data["error"])
+		}
+
+		if received := store.Must(Srv.Store.FileInfo().Get(info.Id)).(*model.FileInfo); received.Path != "prefix/"+info.Path {
+			t.Fatal("should have moved the path under the prefix", received.Path)
+		} else if _, err := os.Stat(filepath.Join(destinationDir, received.Path)); err != nil {
+			t.Fatal("should have copied the file under the prefix")
+		}
+
+		if received := store.Must(Srv.Store.FileInfo().Get(moved.Id)).(*model.FileInfo); received.Path != moved.Path {
+			t.Fatal("shouldn't have added the prefix twice", received.Path)
+		} else if _, err := os.Stat(filepath.Join(destinationDir, moved.Path)); err != nil {
+			t.Fatal("should have copied the file that was already under the prefix")
+		}
+	})
+}
+
+func TestMigrateFileChecksum(t *testing.T) {
+	Setup()
+
+	dir, err := ioutil.TempDir("", "migration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := &model.FileSettings{DriverName: model.IMAGE_DRIVER_LOCAL, Directory: dir + "/source/"}
+	destination := &model.FileSettings{DriverName: model.IMAGE_DRIVER_LOCAL, Directory: dir + "/destination/"}
+
+	if err := writeFileWithSettings(source, []byte("contents"), "file.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := migrateFile(source, destination, "file.txt", "prefix/file.txt"); err != nil {
+		t.Fatal(err)
+	} else if !found {
+		t.Fatal("should have found the file")
+	}
+
+	if copied, err := readFileWithSettings(destination, "prefix/file.txt"); err != nil {
+		t.Fatal(err)
+	} else if string(copied) != "contents" {
+		t.Fatal("copy should match the original")
+	}
+
+	if found, err := migrateFile(source, destination, "missing.txt", "missing.txt"); err != nil {
+		t.Fatal(err)
+	} else if found {
+		t.Fatal("shouldn't have found a file that doesn't exist")
+	}
+}
@@ -4,9 +4,15 @@
 package app
 
 import (
+	"bytes"
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 func TestGeneratePublicLinkHash(t *testing.T) {
@@ -31,3 +37,135 @@ func TestGeneratePublicLinkHash(t *testing.T) {
 		t.Fatal("hashes for the same file with different salts should not be equal")
 	}
 }
+
+func TestAnimatedThumbnail(t *testing.T) {
+	Setup()
+
+	dir, err := ioutil.TempDir("", "animated_thumbnail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = dir + "/"
+	*utils.Cfg.FileSettings.EnableAnimatedThumbnails = false
+
+	data, err := ioutil.ReadFile("../tests/testgif.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, appErr := DoUploadFile(model.NewId(), model.NewId(), model.NewId(), "testgif.gif", data)
+	if appErr != nil {
+		t.Fatal(appErr)
+	} else if !info.IsAnimated {
+		t.Fatal("should have been marked as animated")
+	} else if !strings.HasSuffix(info.ThumbnailPath, "_thumb.jpg") {
+		t.Fatal("should have a static thumbnail when animated thumbnails are disabled", info.ThumbnailPath)
+	}
+
+	utils.Cfg.FileSettings.EnableAnimatedThumbnails = new(bool)
+	*utils.Cfg.FileSettings.EnableAnimatedThumbnails = true
+	utils.Cfg.FileSettings.MaxAnimatedThumbnailFrames = new(int)
+	*utils.Cfg.FileSettings.MaxAnimatedThumbnailFrames = len(src.Image) - 1
+
+	if info, appErr := DoUploadFile(model.NewId(), model.NewId(), model.NewId(), "testgif.gif", data); appErr != nil {
+		t.Fatal(appErr)
+	} else if !strings.HasSuffix(info.ThumbnailPath, "_thumb.jpg") {
+		t.Fatal("should have a static thumbnail when there are too many frames", info.ThumbnailPath)
+	}
+
+	*utils.Cfg.FileSettings.MaxAnimatedThumbnailFrames = len(src.Image)
+
+	info, appErr = DoUploadFile(model.NewId(), model.NewId(), model.NewId(), "testgif.gif", data)
+	if appErr != nil {
+		t.Fatal(appErr)
+	} else if !strings.HasSuffix(info.ThumbnailPath, "_thumb.gif") {
+		t.Fatal("should have an animated thumbnail", info.ThumbnailPath)
+	}
+
+	img, width, height := prepareImage(data)
+	generateThumbnail(*img, data, info.ThumbnailPath, width, height)
+
+	thumbnailData, appErr := ReadFile(info.ThumbnailPath)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if thumbnail, err := gif.DecodeAll(bytes.NewReader(thumbnailData)); err != nil {
+		t.Fatal(err)
+	} else if len(thumbnail.Image) != len(src.Image) {
+		t.Fatal("thumbnail should have every frame", len(thumbnail.Image))
+	} else if expected := resizeToThumbnail(*img, width, height).Bounds(); thumbnail.Config.Width != expected.Dx() || thumbnail.Config.Height != expected.Dy() {
+		t.Fatal("thumbnail should be the same size as a static one", thumbnail.Config.Width, thumbnail.Config.Height)
+	}
+}
+
+func TestUploadWebP(t *testing.T) {
+	Setup()
+
+	dir, err := ioutil.TempDir("", "webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = dir + "/"
+
+	data, err := ioutil.ReadFile("../tests/test.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, appErr := DoUploadFile(model.NewId(), model.NewId(), model.NewId(), "test.webp", data)
+	if appErr != nil {
+		t.Fatal(appErr)
+	} else if info.ThumbnailPath == "" || info.PreviewPath == "" {
+		t.Fatal("should have a thumbnail and a preview")
+	} else if info.WebPPreviewPath != "" {
+		t.Fatal("shouldn't have a WebP preview without an image encoder")
+	}
+
+	img, width, height := prepareImage(data)
+	if img == nil {
+		t.Fatal("should have decoded the image")
+	}
+	generateThumbnail(*img, data, info.ThumbnailPath, width, height)
+
+	if _, appErr := ReadFile(info.ThumbnailPath); appErr != nil {
+		t.Fatal(appErr)
+	}
+}
+
+func TestDeletePostFiles(t *testing.T) {
+	th := Setup().InitBasic()
+
+	// FileIds isn't filled in for every post, so the files should be found from the post instead
+	post := th.CreatePost(th.BasicChannel)
+	if result := <-Srv.Store.FileInfo().Save(&model.FileInfo{CreatorId: th.BasicUser.Id, PostId: post.Id, Path: "file.txt"}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	DeletePostFiles(post)
+
+	if result := <-Srv.Store.FileInfo().GetForPost(post.Id, false); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.FileInfo)) != 0 {
+		t.Fatal("should have deleted the post's file")
+	}
+}
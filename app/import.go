@@ -13,11 +13,15 @@ import (
 	"unicode/utf8"
 
 	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"net/http"
 )
 
+// Posts are saved this many at a time by bulk imports
+const BULK_IMPORT_POST_BATCH_SIZE = 1000
+
 // Import Data Models
 
 type LineImportData struct {
@@ -25,6 +29,7 @@ type LineImportData struct {
 	Team    *TeamImportData    `json:"team"`
 	Channel *ChannelImportData `json:"channel"`
 	User    *UserImportData    `json:"user"`
+	Post    *PostImportData    `json:"post"`
 }
 
 type TeamImportData struct {
@@ -70,15 +75,53 @@ type UserChannelImportData struct {
 	Roles *string `json:"roles"`
 }
 
+type PostImportData struct {
+	Team     *string `json:"team"`
+	Channel  *string `json:"channel"`
+	User     *string `json:"user"`
+	Message  *string `json:"message"`
+	CreateAt *int64  `json:"create_at"`
+}
+
 //
 // -- Bulk Import Functions --
 // These functions import data directly into the database. Security and permission checks are bypassed but validity is
 // still enforced.
 //
 
+// BulkImport imports every line of the given file. Consecutive post lines are saved in batches without sending any
+// notifications or events, and once everything has been imported, a search indexing job is queued for them instead of
+// indexing them one at a time.
 func BulkImport(fileReader io.Reader, dryRun bool) (*model.AppError, int) {
 	scanner := bufio.NewScanner(fileReader)
 	lineNumber := 0
+
+	posts := make([]*PostImportData, 0, BULK_IMPORT_POST_BATCH_SIZE)
+	firstPostLineNumber := 0
+	var firstCreateAt, lastCreateAt int64
+
+	importPosts := func() (*model.AppError, int) {
+		if len(posts) == 0 {
+			return nil, 0
+		}
+
+		if err, index := ImportPosts(posts, dryRun); err != nil {
+			return err, firstPostLineNumber + index
+		}
+
+		for _, post := range posts {
+			if firstCreateAt == 0 || *post.CreateAt < firstCreateAt {
+				firstCreateAt = *post.CreateAt
+			}
+			if *post.CreateAt > lastCreateAt {
+				lastCreateAt = *post.CreateAt
+			}
+		}
+
+		posts = posts[:0]
+		return nil, 0
+	}
+
 	for scanner.Scan() {
 		decoder := json.NewDecoder(strings.NewReader(scanner.Text()))
 		lineNumber++
@@ -86,7 +129,25 @@ func BulkImport(fileReader io.Reader, dryRun bool) (*model.AppError, int) {
 		var line LineImportData
 		if err := decoder.Decode(&line); err != nil {
 			return model.NewLocAppError("BulkImport", "app.import.bulk_import.json_decode.error", nil, err.Error()), lineNumber
-		} else {
+		}
+
+		if line.Type == "post" && line.Post != nil {
+			if len(posts) == 0 {
+				firstPostLineNumber = lineNumber
+			}
+
+			posts = append(posts, line.Post)
+			if len(posts) < BULK_IMPORT_POST_BATCH_SIZE {
+				continue
+			}
+		}
+
+		// Save any posts before importing anything else so that lines are still imported in order
+		if err, errLineNumber := importPosts(); err != nil {
+			return err, errLineNumber
+		}
+
+		if line.Type != "post" || line.Post == nil {
 			if err := ImportLine(line, dryRun); err != nil {
 				return err, lineNumber
 			}
@@ -97,6 +158,16 @@ func BulkImport(fileReader io.Reader, dryRun bool) (*model.AppError, int) {
 		return model.NewLocAppError("BulkImport", "app.import.bulk_import.file_scan.error", nil, err.Error()), 0
 	}
 
+	if err, errLineNumber := importPosts(); err != nil {
+		return err, errLineNumber
+	}
+
+	if !dryRun && lastCreateAt > 0 && einterfaces.GetSearchEngineInterface() != nil && *utils.Cfg.SearchSettings.EnableIndexing {
+		if _, err := CreateSearchIndexingJob(firstCreateAt, lastCreateAt+1, "", false); err != nil {
+			l4g.Error(utils.T("app.import.bulk_import.search_indexing.error"), err.Error())
+		}
+	}
+
 	return nil, 0
 }
 
@@ -120,6 +191,13 @@ func ImportLine(line LineImportData, dryRun bool) *model.AppError {
 		} else {
 			return ImportUser(line.User, dryRun)
 		}
+	case line.Type == "post":
+		if line.Post == nil {
+			return model.NewAppError("BulkImport", "app.import.import_line.null_post.error", nil, "", http.StatusBadRequest)
+		} else {
+			err, _ := ImportPosts([]*PostImportData{line.Post}, dryRun)
+			return err
+		}
 	default:
 		return model.NewLocAppError("BulkImport", "app.import.import_line.unknown_line_type.error", map[string]interface{}{"Type": line.Type}, "")
 	}
@@ -568,6 +646,102 @@ func validateUserChannelsImportData(data *[]UserChannelImportData) *model.AppErr
 	return nil
 }
 
+// ImportPosts saves a batch of posts in one go. It skips everything that happens when a post is created normally, such
+// as notifications, WebSocket events and search indexing. If there's an error, nothing is saved and the index of the
+// post that caused it is returned along with it.
+func ImportPosts(data []*PostImportData, dryRun bool) (*model.AppError, int) {
+	for i, post := range data {
+		if err := validatePostImportData(post); err != nil {
+			return err, i
+		}
+	}
+
+	// If this is a Dry Run, do not continue any further.
+	if dryRun {
+		return nil, 0
+	}
+
+	teams := make(map[string]*model.Team)
+	channels := make(map[string]*model.Channel)
+	users := make(map[string]*model.User)
+
+	posts := make([]*model.Post, len(data))
+	for i, postData := range data {
+		team, ok := teams[*postData.Team]
+		if !ok {
+			if result := <-Srv.Store.Team().GetByName(*postData.Team); result.Err != nil {
+				return model.NewAppError("BulkImport", "app.import.import_post.team_not_found.error", map[string]interface{}{"TeamName": *postData.Team}, result.Err.Error(), http.StatusBadRequest), i
+			} else {
+				team = result.Data.(*model.Team)
+				teams[*postData.Team] = team
+			}
+		}
+
+		channelKey := team.Id + *postData.Channel
+		channel, ok := channels[channelKey]
+		if !ok {
+			if result := <-Srv.Store.Channel().GetByName(team.Id, *postData.Channel, true); result.Err != nil {
+				return model.NewAppError("BulkImport", "app.import.import_post.channel_not_found.error", map[string]interface{}{"ChannelName": *postData.Channel}, result.Err.Error(), http.StatusBadRequest), i
+			} else {
+				channel = result.Data.(*model.Channel)
+				channels[channelKey] = channel
+			}
+		}
+
+		user, ok := users[*postData.User]
+		if !ok {
+			if result := <-Srv.Store.User().GetByUsername(*postData.User); result.Err != nil {
+				return model.NewAppError("BulkImport", "app.import.import_post.user_not_found.error", map[string]interface{}{"Username": *postData.User}, result.Err.Error(), http.StatusBadRequest), i
+			} else {
+				user = result.Data.(*model.User)
+				users[*postData.User] = user
+			}
+		}
+
+		posts[i] = &model.Post{
+			ChannelId: channel.Id,
+			UserId:    user.Id,
+			Message:   *postData.Message,
+			CreateAt:  *postData.CreateAt,
+		}
+		posts[i].Hashtags, _ = model.ParseHashtags(posts[i].Message)
+	}
+
+	if result := <-Srv.Store.Post().SaveMultiple(posts); result.Err != nil {
+		return result.Err, 0
+	}
+
+	return nil, 0
+}
+
+func validatePostImportData(data *PostImportData) *model.AppError {
+	if data.Team == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.team_missing.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.Channel == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.channel_missing.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.User == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.user_missing.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.Message == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.message_missing.error", nil, "", http.StatusBadRequest)
+	} else if utf8.RuneCountInString(*data.Message) > model.POST_MESSAGE_MAX_RUNES {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.message_length.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.CreateAt == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.create_at_missing.error", nil, "", http.StatusBadRequest)
+	} else if *data.CreateAt <= 0 {
+		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.create_at_zero.error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
 //
 // -- Old SlackImport Functions --
 // Import functions are sutible for entering posts and users into the database without
@@ -599,6 +773,10 @@ func ImportPost(post *model.Post) {
 			}
 		}
 
+		if len(post.FileIds) > 0 {
+			InvalidateCacheForFileInfosForPost(post.Id)
+		}
+
 		post.Id = ""
 		post.CreateAt++
 		post.Message = remainder
@@ -650,8 +828,8 @@ func ImportFile(file io.Reader, teamId string, channelId string, userId string,
 
 	img, width, height := prepareImage(data)
 	if img != nil {
-		generateThumbnailImage(*img, fileInfo.ThumbnailPath, width, height)
-		generatePreviewImage(*img, fileInfo.PreviewPath, width)
+		generateThumbnail(*img, data, fileInfo.ThumbnailPath, width, height)
+		generatePreviewImage(*img, fileInfo.PreviewPath, fileInfo.WebPPreviewPath, width)
 	}
 
 	return fileInfo, nil
@@ -4,7 +4,9 @@
 package app
 
 import (
+	"bytes"
 	"github.com/mattermost/platform/model"
+	"strconv"
 	"strings"
 	"testing"
 	"github.com/mattermost/platform/utils"
@@ -425,6 +427,63 @@ func TestImportValidateUserChannelsImportData(t *testing.T) {
 	}
 }
 
+func TestImportValidatePostImportData(t *testing.T) {
+
+	// Valid.
+	data := PostImportData{
+		Team:     ptrStr("teamname"),
+		Channel:  ptrStr("channelname"),
+		User:     ptrStr("username"),
+		Message:  ptrStr("message"),
+		CreateAt: ptrInt64(model.GetMillis()),
+	}
+	if err := validatePostImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Missing properties.
+	data.Team = nil
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing team.")
+	}
+	data.Team = ptrStr("teamname")
+
+	data.Channel = nil
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing channel.")
+	}
+	data.Channel = ptrStr("channelname")
+
+	data.User = nil
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing user.")
+	}
+	data.User = ptrStr("username")
+
+	data.Message = nil
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing message.")
+	}
+
+	// Message too long.
+	data.Message = ptrStr(strings.Repeat("1234567890", 500))
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to too long message.")
+	}
+	data.Message = ptrStr("message")
+
+	// Invalid CreateAt.
+	data.CreateAt = nil
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing create_at.")
+	}
+
+	data.CreateAt = ptrInt64(0)
+	if err := validatePostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to zero create_at.")
+	}
+}
+
 func TestImportImportTeam(t *testing.T) {
 	_ = Setup()
 
@@ -1205,6 +1264,12 @@ func TestImportImportLine(t *testing.T) {
 	if err := ImportLine(line, false); err == nil {
 		t.Fatalf("Expected an error when importing a line with type uesr with a nil user.")
 	}
+
+	// Try import line with post type but nil post.
+	line.Type = "post"
+	if err := ImportLine(line, false); err == nil {
+		t.Fatalf("Expected an error when importing a line with type post with a nil post.")
+	}
 }
 
 func TestImportBulkImport(t *testing.T) {
@@ -1223,6 +1288,49 @@ func TestImportBulkImport(t *testing.T) {
 		t.Fatalf("BulkImport should have succeeded: %v, %v", err.Error(), line)
 	}
 
+	// Run bulk import with posts, spread over more than one batch and with other lines in between.
+	username := "bwshaim6qnc2ne7oqkd5b2s2rq"
+	var data3 bytes.Buffer
+	for i := 0; i < BULK_IMPORT_POST_BATCH_SIZE+10; i++ {
+		if i == 5 {
+			data3.WriteString(`{"type": "user", "user": {"username": "` + username + `", "email": "` + username + `@example.com", "nickname": "imported"}}` + "\n")
+		}
+
+		data3.WriteString(`{"type": "post", "post": {"team": "` + teamName + `", "channel": "` + channelName + `", "user": "` + username + `", "message": "imported #post", "create_at": ` + strconv.Itoa(1000+i) + `}}` + "\n")
+	}
+
+	if err, line := BulkImport(bytes.NewReader(data3.Bytes()), true); err != nil || line != 0 {
+		t.Fatalf("BulkImport dry run should have succeeded: %v, %v", err, line)
+	}
+
+	team := Srv.Store.Team().GetByName(teamName)
+	channel := (<-Srv.Store.Channel().GetByName((<-team).Data.(*model.Team).Id, channelName, false)).Data.(*model.Channel)
+
+	if posts := (<-Srv.Store.Post().GetPosts(channel.Id, 10, false)).Data.(*model.PostList); len(posts.Order) != 0 {
+		t.Fatal("dry run shouldn't have imported any posts")
+	}
+
+	if err, line := BulkImport(bytes.NewReader(data3.Bytes()), false); err != nil || line != 0 {
+		t.Fatalf("BulkImport should have succeeded: %v, %v", err, line)
+	}
+
+	if count := (<-Srv.Store.Post().AnalyticsPostCount((<-Srv.Store.Team().GetByName(teamName)).Data.(*model.Team).Id, false, false)).Data.(int64); count != BULK_IMPORT_POST_BATCH_SIZE+10 {
+		t.Fatalf("should have imported every post, got %v", count)
+	}
+
+	if posts := (<-Srv.Store.Post().GetPosts(channel.Id, 1, false)).Data.(*model.PostList); posts.Posts[posts.Order[0]].CreateAt != int64(1000+BULK_IMPORT_POST_BATCH_SIZE+9) {
+		t.Fatal("should have kept the time the last post was created at")
+	} else if posts.Posts[posts.Order[0]].Hashtags != "#post" {
+		t.Fatal("should have set the post's hashtags")
+	}
+
+	// Run bulk import with a post in a channel that doesn't exist.
+	data4 := `{"type": "post", "post": {"team": "` + teamName + `", "channel": "` + channelName + `", "user": "` + username + `", "message": "message", "create_at": 1000}}
+{"type": "post", "post": {"team": "` + teamName + `", "channel": "` + model.NewId() + `", "user": "` + username + `", "message": "message", "create_at": 1000}}`
+	if err, line := BulkImport(strings.NewReader(data4), false); err == nil || line != 2 {
+		t.Fatalf("Should have failed due to the missing channel on line 2: %v", line)
+	}
+
 	// Run bulk import using a string that contains a line with invalid json.
 	data2 := `{"type": "team", "team": {"type": "O", "display_name": "lskmw2d7a5ao7ppwqh5ljchvr4", "name": "vinewy665jam3n6oxzhsdgajly"}`
 	if err, line := BulkImport(strings.NewReader(data2), false); err == nil || line != 1 {
@@ -0,0 +1,441 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	INBOUND_EMAIL_MAX_ATTACHMENTS = 5
+	INBOUND_EMAIL_MAX_PART_DEPTH  = 10
+)
+
+var (
+	inboundEmailHtmlIgnoredPattern = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	inboundEmailHtmlBreakPattern   = regexp.MustCompile(`(?i)<br[^>]*>|</(p|div|li|tr|h[1-6])>`)
+	inboundEmailHtmlTagPattern     = regexp.MustCompile(`<[^>]*>`)
+	inboundEmailBlankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+	inboundEmailCommentPattern     = regexp.MustCompile(`\([^)]*\)`)
+)
+
+// inboundEmail is the part of an email that's used to post it.
+type inboundEmail struct {
+	header      mail.Header
+	from        string
+	subject     string
+	text        string
+	html        string
+	attachments []*inboundEmailAttachment
+}
+
+type inboundEmailAttachment struct {
+	name string
+	data []byte
+}
+
+// GetChannelEmailAddress returns the address that email can be sent to in order to post in a channel.
+func GetChannelEmailAddress(channelId string) (*model.ChannelEmailAddress, *model.AppError) {
+	if result := <-Srv.Store.ChannelEmailAddress().GetForChannel(channelId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		address := result.Data.(*model.ChannelEmailAddress)
+		sanitizeChannelEmailAddress(address)
+		return address, nil
+	}
+}
+
+// RegenerateChannelEmailAddress gives a channel a new email address, replacing the one that it had before so that
+// anyone who knew the old address can no longer post with it.
+func RegenerateChannelEmailAddress(channel *model.Channel, userId string) (*model.ChannelEmailAddress, *model.AppError) {
+	if channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE {
+		return nil, model.NewAppError("RegenerateChannelEmailAddress", "app.inbound_email.regenerate.channel_type.app_error", nil, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.ChannelEmailAddress().Replace(&model.ChannelEmailAddress{ChannelId: channel.Id, CreatorId: userId}); result.Err != nil {
+		return nil, result.Err
+	} else {
+		address := result.Data.(*model.ChannelEmailAddress)
+		sanitizeChannelEmailAddress(address)
+		return address, nil
+	}
+}
+
+func DeleteChannelEmailAddress(channelId string) *model.AppError {
+	if result := <-Srv.Store.ChannelEmailAddress().DeleteForChannel(channelId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func sanitizeChannelEmailAddress(address *model.ChannelEmailAddress) {
+	if len(*utils.Cfg.EmailSettings.InboundEmailDomain) > 0 {
+		address.Address = address.Token + "@" + strings.ToLower(*utils.Cfg.EmailSettings.InboundEmailDomain)
+	}
+}
+
+// getChannelForInboundEmail returns the channel that email sent to the given address should be posted in.
+func getChannelForInboundEmail(recipient string) (*model.Channel, *model.AppError) {
+	at := strings.LastIndex(recipient, "@")
+	if at == -1 || !strings.EqualFold(recipient[at+1:], *utils.Cfg.EmailSettings.InboundEmailDomain) {
+		return nil, model.NewAppError("getChannelForInboundEmail", "app.inbound_email.recipient.app_error", nil, "recipient="+recipient, http.StatusNotFound)
+	}
+
+	var address *model.ChannelEmailAddress
+	if result := <-Srv.Store.ChannelEmailAddress().GetByToken(strings.ToLower(recipient[:at])); result.Err != nil {
+		return nil, model.NewAppError("getChannelForInboundEmail", "app.inbound_email.recipient.app_error", nil, "recipient="+recipient, http.StatusNotFound)
+	} else {
+		address = result.Data.(*model.ChannelEmailAddress)
+	}
+
+	if channel, err := GetChannel(address.ChannelId); err != nil {
+		return nil, err
+	} else if channel.DeleteAt != 0 {
+		return nil, model.NewAppError("getChannelForInboundEmail", "app.inbound_email.recipient.app_error", nil, "recipient="+recipient+", channel_id="+channel.Id, http.StatusNotFound)
+	} else {
+		return channel, nil
+	}
+}
+
+// ReceiveInboundEmail posts an email that was sent to a channel's address as the user whose email address it's from,
+// along with its attachments. Since the From header can say anything, the email is only posted if it was sent from
+// the same address and the relay that passed it on verified that address with DKIM or SPF. Email that was sent
+// automatically, such as bounces, auto-replies and the server's own notifications, is dropped so that the server
+// never ends up in a loop replying to itself.
+func ReceiveInboundEmail(sender string, recipient string, data []byte) *model.AppError {
+	if len(data) > *utils.Cfg.EmailSettings.InboundEmailMaxMessageSize {
+		return model.NewAppError("ReceiveInboundEmail", "app.inbound_email.too_large.app_error", nil, "recipient="+recipient, http.StatusRequestEntityTooLarge)
+	}
+
+	channel, err := getChannelForInboundEmail(recipient)
+	if err != nil {
+		return err
+	}
+
+	email, err := parseInboundEmail(data)
+	if err != nil {
+		return err
+	}
+
+	if len(sender) == 0 || isAutomaticInboundEmail(email) {
+		l4g.Info(utils.T("app.inbound_email.automatic.info"), email.from, channel.Id)
+		return nil
+	}
+
+	if !strings.EqualFold(sender, email.from) || !isAuthenticatedInboundEmail(email) {
+		return model.NewAppError("ReceiveInboundEmail", "app.inbound_email.unauthenticated.app_error", nil, "from="+email.from+", sender="+sender, http.StatusForbidden)
+	}
+
+	var user *model.User
+	if user, err = GetUserByEmail(email.from); err != nil || user.DeleteAt != 0 {
+		return model.NewAppError("ReceiveInboundEmail", "app.inbound_email.unknown_sender.app_error", nil, "from="+email.from, http.StatusForbidden)
+	}
+
+	if !HasPermissionToChannel(user.Id, channel.Id, model.PERMISSION_CREATE_POST) || (len(email.attachments) > 0 && !HasPermissionToChannel(user.Id, channel.Id, model.PERMISSION_UPLOAD_FILE)) {
+		return model.NewAppError("ReceiveInboundEmail", "app.inbound_email.permissions.app_error", nil, "user_id="+user.Id+", channel_id="+channel.Id, http.StatusForbidden)
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    user.Id,
+		Message:   email.message(),
+	}
+	post.AddProp("from_email", "true")
+
+	if len(post.Message) == 0 && len(email.attachments) == 0 {
+		return model.NewAppError("ReceiveInboundEmail", "app.inbound_email.empty.app_error", nil, "from="+email.from, http.StatusBadRequest)
+	}
+
+	previewPathList := []string{}
+	webPPreviewPathList := []string{}
+	thumbnailPathList := []string{}
+	imageDataList := [][]byte{}
+
+	// the attachments are uploaded before the post is created, so they're removed again if that doesn't happen
+	var infos []*model.FileInfo
+	removeAttachments := func() {
+		if len(infos) == 0 {
+			return
+		}
+
+		if err := permanentDeleteFiles(infos); err != nil {
+			l4g.Error(utils.T("app.inbound_email.remove_attachments.error"), post.ChannelId, err.Error())
+		}
+	}
+
+	for _, attachment := range email.attachments {
+		info, err := DoUploadFile(channel.TeamId, channel.Id, user.Id, attachment.name, attachment.data)
+		if err != nil {
+			removeAttachments()
+			return err
+		}
+		infos = append(infos, info)
+
+		if info.PreviewPath != "" || info.ThumbnailPath != "" {
+			previewPathList = append(previewPathList, info.PreviewPath)
+			webPPreviewPathList = append(webPPreviewPathList, info.WebPPreviewPath)
+			thumbnailPathList = append(thumbnailPathList, info.ThumbnailPath)
+			imageDataList = append(imageDataList, attachment.data)
+		}
+
+		post.FileIds = append(post.FileIds, info.Id)
+	}
+
+	if _, err := CreatePost(post, channel.TeamId, true); err != nil {
+		removeAttachments()
+		return err
+	}
+
+	HandleImages(previewPathList, webPPreviewPathList, thumbnailPathList, imageDataList)
+
+	return nil
+}
+
+// isAutomaticInboundEmail returns true if an email was sent automatically rather than by a person, as described by
+// RFC 3834, or if it's one of the server's own emails that's made its way back.
+func isAutomaticInboundEmail(email *inboundEmail) bool {
+	if autoSubmitted := strings.ToLower(strings.TrimSpace(email.header.Get("Auto-Submitted"))); len(autoSubmitted) > 0 && autoSubmitted != "no" {
+		return true
+	}
+
+	switch strings.ToLower(strings.TrimSpace(email.header.Get("Precedence"))) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+
+	if loop := strings.ToLower(email.header.Get("X-Loop")); len(loop) > 0 && strings.Contains(loop, strings.ToLower(*utils.Cfg.EmailSettings.InboundEmailDomain)) {
+		return true
+	}
+
+	return len(utils.Cfg.EmailSettings.FeedbackEmail) > 0 && strings.EqualFold(email.from, utils.Cfg.EmailSettings.FeedbackEmail)
+}
+
+// isAuthenticatedInboundEmail returns true if the relay that passed on an email verified that it came from the domain
+// in its From header. Relays add their Authentication-Results header above any that were already in the email, so
+// only the first one is trusted, and only a DKIM signature or SPF check for the From domain counts.
+func isAuthenticatedInboundEmail(email *inboundEmail) bool {
+	results := inboundEmailCommentPattern.ReplaceAllString(email.header.Get("Authentication-Results"), "")
+
+	at := strings.LastIndex(email.from, "@")
+	if at == -1 {
+		return false
+	}
+	domain := email.from[at+1:]
+
+	// the first part is the id of the server that added the results, and each of the rest is the result of a check
+	parts := strings.Split(results, ";")
+	for _, part := range parts[1:] {
+		fields := strings.Fields(strings.ToLower(part))
+		if len(fields) == 0 {
+			continue
+		}
+
+		properties := map[string]string{}
+		for _, field := range fields[1:] {
+			if index := strings.Index(field, "="); index != -1 {
+				properties[field[:index]] = strings.Trim(field[index+1:], "\"")
+			}
+		}
+
+		switch fields[0] {
+		case "dkim=pass":
+			if signer := properties["header.d"]; signer == domain || strings.HasSuffix(domain, "."+signer) {
+				return true
+			} else if identity := properties["header.i"]; strings.HasSuffix(identity, "@"+domain) {
+				return true
+			}
+		case "spf=pass":
+			mailFrom := properties["smtp.mailfrom"]
+			if index := strings.LastIndex(mailFrom, "@"); index != -1 {
+				mailFrom = mailFrom[index+1:]
+			}
+
+			if mailFrom == domain {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parseInboundEmail(data []byte) (*inboundEmail, *model.AppError) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, model.NewAppError("parseInboundEmail", "app.inbound_email.parse.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	email := &inboundEmail{
+		header:  msg.Header,
+		subject: decodeInboundEmailHeader(msg.Header.Get("Subject")),
+	}
+
+	if from, err := msg.Header.AddressList("From"); err != nil || len(from) == 0 {
+		return nil, model.NewAppError("parseInboundEmail", "app.inbound_email.parse_from.app_error", nil, "", http.StatusBadRequest)
+	} else {
+		email.from = strings.ToLower(from[0].Address)
+	}
+
+	if err := email.readPart(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+
+	return email, nil
+}
+
+// readPart reads the text and attachments out of a part of an email, descending into any parts that it contains.
+// The first plain text part is the body of the email, and an HTML part is only used if there isn't one.
+func (e *inboundEmail) readPart(header textproto.MIMEHeader, body io.Reader, depth int) *model.AppError {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= INBOUND_EMAIL_MAX_PART_DEPTH {
+			return nil
+		}
+
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return model.NewAppError("readPart", "app.inbound_email.parse.app_error", nil, err.Error(), http.StatusBadRequest)
+			}
+
+			if err := e.readPart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if len(filename) == 0 {
+		filename = params["name"]
+	}
+
+	if disposition == "attachment" || len(filename) > 0 {
+		return e.readAttachment(decodeInboundEmailHeader(filename), body)
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return model.NewAppError("readPart", "app.inbound_email.parse.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	if mediaType == "text/plain" && len(e.text) == 0 {
+		e.text = decodeInboundEmailText(data, params["charset"])
+	} else if mediaType == "text/html" && len(e.html) == 0 {
+		e.html = decodeInboundEmailText(data, params["charset"])
+	}
+
+	return nil
+}
+
+func (e *inboundEmail) readAttachment(filename string, body io.Reader) *model.AppError {
+	if len(e.attachments) >= INBOUND_EMAIL_MAX_ATTACHMENTS {
+		return model.NewAppError("readAttachment", "app.inbound_email.too_many_attachments.app_error", map[string]interface{}{"Max": INBOUND_EMAIL_MAX_ATTACHMENTS}, "", http.StatusRequestEntityTooLarge)
+	}
+
+	maxFileSize := *utils.Cfg.FileSettings.MaxFileSize
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxFileSize+1))
+	if err != nil {
+		return model.NewAppError("readAttachment", "app.inbound_email.parse.app_error", nil, err.Error(), http.StatusBadRequest)
+	} else if int64(len(data)) > maxFileSize {
+		return model.NewAppError("readAttachment", "app.inbound_email.attachment_too_large.app_error", map[string]interface{}{"Filename": filename}, "", http.StatusRequestEntityTooLarge)
+	}
+
+	if len(filename) == 0 {
+		filename = "attachment"
+	}
+
+	e.attachments = append(e.attachments, &inboundEmailAttachment{name: filename, data: data})
+
+	return nil
+}
+
+// message returns the message of the post for an email, which is its subject followed by its body.
+func (e *inboundEmail) message() string {
+	body := e.text
+	if len(body) == 0 && len(e.html) > 0 {
+		body = inboundEmailHtmlIgnoredPattern.ReplaceAllString(e.html, "")
+		body = inboundEmailHtmlBreakPattern.ReplaceAllString(body, "\n")
+		body = html.UnescapeString(inboundEmailHtmlTagPattern.ReplaceAllString(body, ""))
+	}
+
+	body = strings.Replace(body, "\r\n", "\n", -1)
+	body = strings.TrimSpace(inboundEmailBlankLinesPattern.ReplaceAllString(body, "\n\n"))
+
+	message := body
+	if subject := strings.TrimSpace(e.subject); len(subject) > 0 {
+		message = "**" + subject + "**"
+		if len(body) > 0 {
+			message += "\n\n" + body
+		}
+	}
+
+	if utf8.RuneCountInString(message) > model.POST_MESSAGE_MAX_RUNES {
+		message = string([]rune(message)[:model.POST_MESSAGE_MAX_RUNES])
+	}
+
+	return message
+}
+
+func decodeInboundEmailHeader(value string) string {
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(value); err == nil {
+		return decoded
+	}
+
+	return value
+}
+
+// decodeInboundEmailText converts text to UTF-8. Latin-1 is the only other character set that's converted since
+// it's the one that mail clients still commonly fall back to.
+func decodeInboundEmailText(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,394 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	INBOUND_EMAIL_MAX_CONNECTIONS = 100
+	INBOUND_EMAIL_MAX_RECIPIENTS  = 10
+	INBOUND_EMAIL_TIMEOUT         = 5 * time.Minute
+)
+
+// InboundEmailServer is a minimal SMTP server that receives email for channels' addresses. It's meant to sit behind
+// the organization's mail server, which relays the email for the inbound domain to it, so it doesn't support
+// authentication or encryption itself. Instead, it only accepts connections from the trusted relays, and it relies on
+// them to check SPF and DKIM and to record the outcome in the Authentication-Results header.
+type InboundEmailServer struct {
+	listener    net.Listener
+	connections chan struct{}
+	closed      bool
+	mutex       sync.Mutex
+}
+
+var inboundEmailServer *InboundEmailServer
+var inboundEmailServerMutex sync.Mutex
+
+func StartInboundEmail() {
+	inboundEmailServerMutex.Lock()
+	defer inboundEmailServerMutex.Unlock()
+
+	if inboundEmailServer != nil || !*utils.Cfg.EmailSettings.EnableInboundEmail {
+		return
+	}
+
+	listener, err := net.Listen("tcp", *utils.Cfg.EmailSettings.InboundEmailListenAddress)
+	if err != nil {
+		l4g.Error(utils.T("app.inbound_email.start.error"), *utils.Cfg.EmailSettings.InboundEmailListenAddress, err.Error())
+		return
+	}
+
+	l4g.Info(utils.T("app.inbound_email.start.info"), listener.Addr().String())
+
+	inboundEmailServer = &InboundEmailServer{
+		listener:    listener,
+		connections: make(chan struct{}, INBOUND_EMAIL_MAX_CONNECTIONS),
+	}
+	go inboundEmailServer.serve()
+}
+
+func StopInboundEmail() {
+	inboundEmailServerMutex.Lock()
+	defer inboundEmailServerMutex.Unlock()
+
+	if inboundEmailServer == nil {
+		return
+	}
+
+	inboundEmailServer.close()
+	inboundEmailServer = nil
+}
+
+func restartInboundEmail() {
+	StopInboundEmail()
+	StartInboundEmail()
+}
+
+func (s *InboundEmailServer) close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	s.listener.Close()
+}
+
+func (s *InboundEmailServer) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.closed
+}
+
+func (s *InboundEmailServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+
+			l4g.Error(utils.T("app.inbound_email.accept.error"), err.Error())
+			return
+		}
+
+		select {
+		case s.connections <- struct{}{}:
+			go func() {
+				defer func() { <-s.connections }()
+				newInboundEmailSession(conn).serve()
+			}()
+		default:
+			conn.Write([]byte("421 4.3.2 Too many connections, try again later\r\n"))
+			conn.Close()
+		}
+	}
+}
+
+// inboundEmailSession is a single SMTP connection. It accepts any sender, but only recipients that are the addresses
+// of channels, and it delivers each email to all of its recipients once it's been received.
+type inboundEmailSession struct {
+	conn       net.Conn
+	text       *textproto.Conn
+	hasSender  bool
+	sender     string
+	recipients []string
+}
+
+func newInboundEmailSession(conn net.Conn) *inboundEmailSession {
+	return &inboundEmailSession{
+		conn: conn,
+		text: textproto.NewConn(conn),
+	}
+}
+
+func (s *inboundEmailSession) serve() {
+	defer s.text.Close()
+
+	hostname := *utils.Cfg.EmailSettings.InboundEmailDomain
+
+	s.conn.SetDeadline(time.Now().Add(INBOUND_EMAIL_TIMEOUT))
+
+	if !isTrustedInboundEmailRelay(s.conn.RemoteAddr()) {
+		l4g.Warn(utils.T("app.inbound_email.untrusted_relay.warn"), s.conn.RemoteAddr().String())
+		s.reply(554, "5.7.1 Relaying from this address is not allowed")
+		return
+	}
+
+	s.reply(220, hostname+" ESMTP ready")
+
+	for {
+		s.conn.SetDeadline(time.Now().Add(INBOUND_EMAIL_TIMEOUT))
+
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if index := strings.Index(line, " "); index != -1 {
+			verb, arg = line[:index], strings.TrimSpace(line[index+1:])
+		}
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			s.reset()
+			s.reply(250, hostname)
+		case "EHLO":
+			s.reset()
+			s.reply(250, hostname, "SIZE "+strconv.Itoa(*utils.Cfg.EmailSettings.InboundEmailMaxMessageSize), "8BITMIME")
+		case "MAIL":
+			s.handleMail(arg)
+		case "RCPT":
+			s.handleRcpt(arg)
+		case "DATA":
+			if !s.handleData() {
+				return
+			}
+		case "RSET":
+			s.reset()
+			s.reply(250, "2.0.0 OK")
+		case "NOOP":
+			s.reply(250, "2.0.0 OK")
+		case "VRFY":
+			s.reply(252, "2.5.0 Cannot verify user")
+		case "QUIT":
+			s.reply(221, "2.0.0 Bye")
+			return
+		default:
+			s.reply(502, "5.5.1 Command not implemented")
+		}
+	}
+}
+
+// reply sends a response with one or more lines to the client.
+func (s *inboundEmailSession) reply(code int, lines ...string) {
+	for i, line := range lines {
+		separator := " "
+		if i < len(lines)-1 {
+			separator = "-"
+		}
+
+		s.text.PrintfLine("%d%s%s", code, separator, line)
+	}
+}
+
+func (s *inboundEmailSession) reset() {
+	s.hasSender = false
+	s.sender = ""
+	s.recipients = nil
+}
+
+func (s *inboundEmailSession) handleMail(arg string) {
+	if s.hasSender {
+		s.reply(503, "5.5.1 Sender already specified")
+		return
+	}
+
+	sender, params, ok := parseInboundEmailPath(arg, "FROM:")
+	if !ok {
+		s.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+		return
+	}
+
+	for _, param := range params {
+		if strings.HasPrefix(strings.ToUpper(param), "SIZE=") {
+			if size, err := strconv.Atoi(param[len("SIZE="):]); err == nil && size > *utils.Cfg.EmailSettings.InboundEmailMaxMessageSize {
+				s.reply(552, "5.3.4 Message too big")
+				return
+			}
+		}
+	}
+
+	s.hasSender = true
+	s.sender = sender
+	s.reply(250, "2.1.0 OK")
+}
+
+func (s *inboundEmailSession) handleRcpt(arg string) {
+	if !s.hasSender {
+		s.reply(503, "5.5.1 Need MAIL command first")
+		return
+	}
+
+	recipient, _, ok := parseInboundEmailPath(arg, "TO:")
+	if !ok {
+		s.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+		return
+	}
+
+	if len(s.recipients) >= INBOUND_EMAIL_MAX_RECIPIENTS {
+		s.reply(452, "4.5.3 Too many recipients")
+		return
+	}
+
+	if _, err := getChannelForInboundEmail(recipient); err != nil {
+		s.reply(inboundEmailReplyForError(err))
+		return
+	}
+
+	for _, existing := range s.recipients {
+		if strings.EqualFold(existing, recipient) {
+			s.reply(250, "2.1.5 OK")
+			return
+		}
+	}
+
+	s.recipients = append(s.recipients, recipient)
+	s.reply(250, "2.1.5 OK")
+}
+
+// handleData receives an email and delivers it. It returns false if the connection can't be used any more.
+func (s *inboundEmailSession) handleData() bool {
+	if len(s.recipients) == 0 {
+		s.reply(503, "5.5.1 Need RCPT command first")
+		return true
+	}
+
+	s.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	maxSize := *utils.Cfg.EmailSettings.InboundEmailMaxMessageSize
+
+	reader := s.text.DotReader()
+	data, err := ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return false
+	}
+
+	if len(data) > maxSize {
+		if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+			return false
+		}
+
+		s.reset()
+		s.reply(552, "5.3.4 Message too big")
+		return true
+	}
+
+	var deliveryErr *model.AppError
+	delivered := false
+	for _, recipient := range s.recipients {
+		if err := ReceiveInboundEmail(s.sender, recipient, data); err != nil {
+			l4g.Warn(utils.T("app.inbound_email.receive.warn"), recipient, err.Error())
+			if deliveryErr == nil {
+				deliveryErr = err
+			}
+		} else {
+			delivered = true
+		}
+	}
+
+	s.reset()
+
+	if delivered || deliveryErr == nil {
+		s.reply(250, "2.0.0 OK")
+	} else {
+		s.reply(inboundEmailReplyForError(deliveryErr))
+	}
+
+	return true
+}
+
+// isTrustedInboundEmailRelay returns true if a connection comes from one of the relays in the trusted relays setting,
+// each of which is an IP address or CIDR range.
+func isTrustedInboundEmailRelay(addr net.Addr) bool {
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	default:
+		if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+			ip = net.ParseIP(host)
+		}
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, relay := range strings.Fields(*utils.Cfg.EmailSettings.InboundEmailTrustedRelays) {
+		if _, network, err := net.ParseCIDR(relay); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if relayIP := net.ParseIP(relay); relayIP != nil && relayIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseInboundEmailPath parses the address and any parameters out of the argument of a MAIL or RCPT command.
+func parseInboundEmailPath(arg string, prefix string) (string, []string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", nil, false
+	}
+
+	path := strings.TrimSpace(arg[len(prefix):])
+	end := strings.Index(path, ">")
+	if !strings.HasPrefix(path, "<") || end == -1 {
+		return "", nil, false
+	}
+
+	return path[1:end], strings.Fields(path[end+1:]), true
+}
+
+// inboundEmailReplyForError returns the SMTP reply for an email that couldn't be delivered. Errors that retrying
+// won't fix are permanent so that the sender is told about them instead of the email being retried.
+func inboundEmailReplyForError(err *model.AppError) (int, string) {
+	message := err.SystemMessage(utils.T)
+
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return 550, "5.1.1 " + message
+	case http.StatusForbidden:
+		return 550, "5.7.1 " + message
+	case http.StatusRequestEntityTooLarge:
+		return 552, "5.3.4 " + message
+	case http.StatusBadRequest:
+		return 554, "5.6.0 " + message
+	default:
+		return 451, "4.3.0 " + message
+	}
+}
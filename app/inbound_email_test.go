@@ -0,0 +1,314 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func setupInboundEmail(t *testing.T, th *TestHelper) (string, func()) {
+	dir, err := ioutil.TempDir("", "inbound_email")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domain := *utils.Cfg.EmailSettings.InboundEmailDomain
+	driverName := utils.Cfg.FileSettings.DriverName
+	directory := utils.Cfg.FileSettings.Directory
+
+	*utils.Cfg.EmailSettings.InboundEmailDomain = "mail.example.com"
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = dir + "/"
+
+	address, appErr := RegenerateChannelEmailAddress(th.BasicChannel, th.BasicUser.Id)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	return address.Address, func() {
+		*utils.Cfg.EmailSettings.InboundEmailDomain = domain
+		utils.Cfg.FileSettings.DriverName = driverName
+		utils.Cfg.FileSettings.Directory = directory
+		os.RemoveAll(dir)
+	}
+}
+
+// authenticationResults returns the header that a trusted relay adds to an email after verifying that it's from the
+// given address.
+func authenticationResults(email string) string {
+	return "Authentication-Results: mx.example.com; dkim=pass (2048-bit key) header.d=" + email[strings.LastIndex(email, "@")+1:] + "; spf=pass smtp.mailfrom=" + email + "\r\n"
+}
+
+func getLastPost(t *testing.T, channelId string) *model.Post {
+	list, err := GetPosts(channelId, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return list.Posts[list.Order[0]]
+}
+
+func TestReceiveInboundEmail(t *testing.T) {
+	th := Setup().InitBasic()
+
+	address, teardown := setupInboundEmail(t, th)
+	defer teardown()
+
+	if address != strings.ToLower(address) || !strings.HasSuffix(address, "@mail.example.com") {
+		t.Fatal("bad address " + address)
+	}
+
+	email := authenticationResults(th.BasicUser.Email) +
+		"From: Basic User <" + th.BasicUser.Email + ">\r\n" +
+		"To: " + address + "\r\n" +
+		"Subject: =?utf-8?q?Weekly_r=C3=A9sum=C3=A9?=\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Everything is on =\r\ntrack.\r\n"
+
+	if err := ReceiveInboundEmail(th.BasicUser.Email, strings.ToUpper(address), []byte(email)); err != nil {
+		t.Fatal(err)
+	}
+
+	post := getLastPost(t, th.BasicChannel.Id)
+	if post.UserId != th.BasicUser.Id {
+		t.Fatal("should have posted as the sender")
+	} else if post.Message != "**Weekly résumé**\n\nEverything is on track." {
+		t.Fatal("wrong message " + post.Message)
+	} else if post.Props["from_email"] != "true" {
+		t.Fatal("post should be marked as from email")
+	}
+
+	t.Run("Attachments", func(t *testing.T) {
+		email := authenticationResults(th.BasicUser.Email) +
+			"From: " + th.BasicUser.Email + "\r\n" +
+			"MIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/mixed; boundary=outer\r\n" +
+			"\r\n" +
+			"--outer\r\n" +
+			"Content-Type: multipart/alternative; boundary=inner\r\n" +
+			"\r\n" +
+			"--inner\r\n" +
+			"Content-Type: text/html\r\n" +
+			"\r\n" +
+			"<html><head><style>p {}</style></head><body><p>See the &quot;notes&quot;</p></body></html>\r\n" +
+			"--inner--\r\n" +
+			"--outer\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+			"Content-Transfer-Encoding: base64\r\n" +
+			"\r\n" +
+			"c29tZSBu\r\nb3Rlcw==\r\n" +
+			"--outer--\r\n"
+
+		if err := ReceiveInboundEmail(th.BasicUser.Email, address, []byte(email)); err != nil {
+			t.Fatal(err)
+		}
+
+		post := getLastPost(t, th.BasicChannel.Id)
+		if post.Message != "See the \"notes\"" {
+			t.Fatal("should have used the html part, got " + post.Message)
+		} else if len(post.FileIds) != 1 {
+			t.Fatal("should have attached the file")
+		}
+
+		if data, err := ReadFile(store.Must(Srv.Store.FileInfo().Get(post.FileIds[0])).(*model.FileInfo).Path); err != nil {
+			t.Fatal(err)
+		} else if string(data) != "some notes" {
+			t.Fatal("attachment wasn't decoded")
+		}
+	})
+
+	t.Run("FailedUpload", func(t *testing.T) {
+		last := getLastPost(t, th.BasicChannel.Id)
+
+		// the second attachment claims to be an image that's too large to upload
+		email := authenticationResults(th.BasicUser.Email) +
+			"From: " + th.BasicUser.Email + "\r\n" +
+			"MIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/mixed; boundary=outer\r\n" +
+			"\r\n" +
+			"--outer\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+			"\r\n" +
+			"some notes\r\n" +
+			"--outer\r\n" +
+			"Content-Type: image/gif\r\n" +
+			"Content-Disposition: attachment; filename=\"huge.gif\"\r\n" +
+			"Content-Transfer-Encoding: base64\r\n" +
+			"\r\n" +
+			"R0lGODlh/////wAAAA==\r\n" +
+			"--outer--\r\n"
+
+		countFiles := func() int {
+			count := 0
+			filepath.Walk(utils.Cfg.FileSettings.Directory, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					count++
+				}
+				return nil
+			})
+			return count
+		}
+		before := countFiles()
+
+		if err := ReceiveInboundEmail(th.BasicUser.Email, address, []byte(email)); err == nil || err.StatusCode != http.StatusBadRequest {
+			t.Fatal("shouldn't have been able to upload the attachments", err)
+		}
+
+		if getLastPost(t, th.BasicChannel.Id).Id != last.Id {
+			t.Fatal("shouldn't have posted the email")
+		}
+
+		if countFiles() != before {
+			t.Fatal("should have removed the attachment that was uploaded")
+		}
+	})
+
+	t.Run("UnknownSender", func(t *testing.T) {
+		email := authenticationResults("someone@example.com") + "From: someone@example.com\r\n\r\nhello\r\n"
+		if err := ReceiveInboundEmail("someone@example.com", address, []byte(email)); err == nil || err.Id != "app.inbound_email.unknown_sender.app_error" {
+			t.Fatal("shouldn't have accepted email from an unknown sender")
+		}
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		last := getLastPost(t, th.BasicChannel.Id)
+
+		for _, test := range []struct {
+			sender string
+			email  string
+		}{
+			{th.BasicUser.Email, "From: " + th.BasicUser.Email + "\r\n\r\nno results\r\n"},
+			{"attacker@evil.com", authenticationResults("attacker@evil.com") + "From: " + th.BasicUser.Email + "\r\n\r\nforged from\r\n"},
+			{th.BasicUser.Email, authenticationResults("attacker@evil.com") + "From: " + th.BasicUser.Email + "\r\n\r\nforged sender\r\n"},
+			{th.BasicUser.Email, "Authentication-Results: mx.example.com; dkim=fail header.d=" + th.BasicUser.Email[strings.LastIndex(th.BasicUser.Email, "@")+1:] + "; spf=softfail\r\n" + "From: " + th.BasicUser.Email + "\r\n\r\nfailed checks\r\n"},
+			{th.BasicUser.Email, "Authentication-Results: mx.example.com; none\r\n" + authenticationResults(th.BasicUser.Email) + "From: " + th.BasicUser.Email + "\r\n\r\nresults added by the sender\r\n"},
+		} {
+			if err := ReceiveInboundEmail(test.sender, address, []byte(test.email)); err == nil || err.StatusCode != http.StatusForbidden {
+				t.Fatal("shouldn't have accepted email that wasn't authenticated: " + test.email)
+			}
+		}
+
+		if getLastPost(t, th.BasicChannel.Id).Id != last.Id {
+			t.Fatal("shouldn't have posted unauthenticated email")
+		}
+	})
+
+	t.Run("UnknownAddress", func(t *testing.T) {
+		email := "From: " + th.BasicUser.Email + "\r\n\r\nhello\r\n"
+		if err := ReceiveInboundEmail(th.BasicUser.Email, model.NewId()+"@mail.example.com", []byte(email)); err == nil || err.StatusCode != http.StatusNotFound {
+			t.Fatal("shouldn't have accepted email for an unknown address")
+		}
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		last := getLastPost(t, th.BasicChannel.Id)
+
+		for _, email := range []string{
+			"From: " + th.BasicUser.Email + "\r\nAuto-Submitted: auto-replied\r\n\r\nout of office\r\n",
+			"From: " + th.BasicUser.Email + "\r\nPrecedence: bulk\r\n\r\nnewsletter\r\n",
+		} {
+			if err := ReceiveInboundEmail(th.BasicUser.Email, address, []byte(email)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		email := "From: " + th.BasicUser.Email + "\r\n\r\ndelivery failed\r\n"
+		if err := ReceiveInboundEmail("", address, []byte(email)); err != nil {
+			t.Fatal(err)
+		}
+
+		if getLastPost(t, th.BasicChannel.Id).Id != last.Id {
+			t.Fatal("shouldn't have posted automatically sent email")
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		maxSize := *utils.Cfg.EmailSettings.InboundEmailMaxMessageSize
+		defer func() {
+			*utils.Cfg.EmailSettings.InboundEmailMaxMessageSize = maxSize
+		}()
+		*utils.Cfg.EmailSettings.InboundEmailMaxMessageSize = 100
+
+		email := "From: " + th.BasicUser.Email + "\r\n\r\n" + strings.Repeat("a", 100) + "\r\n"
+		if err := ReceiveInboundEmail(th.BasicUser.Email, address, []byte(email)); err == nil || err.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatal("shouldn't have accepted an email that's too large")
+		}
+	})
+}
+
+func TestInboundEmailSession(t *testing.T) {
+	th := Setup().InitBasic()
+
+	address, teardown := setupInboundEmail(t, th)
+	defer teardown()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	go newInboundEmailSession(&relayConn{server, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 25}}).serve()
+
+	text := textproto.NewConn(client)
+
+	expect := func(code int, command string, args ...interface{}) {
+		if len(command) > 0 {
+			if err := text.PrintfLine(command, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, message, err := text.ReadResponse(code); err != nil {
+			t.Fatalf("%v: %v %v", command, message, err)
+		}
+	}
+
+	expect(220, "")
+	expect(250, "EHLO client.example.com")
+	expect(503, "RCPT TO:<%v>", address)
+	expect(250, "MAIL FROM:<%v>", th.BasicUser.Email)
+	expect(550, "RCPT TO:<nobody@mail.example.com>")
+	expect(250, "RCPT TO:<%v>", address)
+	expect(354, "DATA")
+	expect(250, "%vFrom: %v\r\nSubject: Hello\r\n\r\n..leading dot\r\n.", authenticationResults(th.BasicUser.Email), th.BasicUser.Email)
+
+	if post := getLastPost(t, th.BasicChannel.Id); post.Message != "**Hello**\n\n.leading dot" {
+		t.Fatal("wrong message " + post.Message)
+	}
+
+	expect(552, "MAIL FROM:<%v> SIZE=%v", th.BasicUser.Email, *utils.Cfg.EmailSettings.InboundEmailMaxMessageSize+1)
+	expect(221, "QUIT")
+
+	t.Run("UntrustedRelay", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		go newInboundEmailSession(&relayConn{server, &net.TCPAddr{IP: net.ParseIP("203.0.113.1"), Port: 25}}).serve()
+
+		if _, _, err := textproto.NewConn(client).ReadResponse(220); err == nil {
+			t.Fatal("shouldn't have accepted a connection from an untrusted relay")
+		}
+	})
+}
+
+// relayConn is a connection that appears to come from a given address.
+type relayConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *relayConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
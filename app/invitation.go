@@ -0,0 +1,173 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	INVITATION_CLEANUP_TASK_NAME = "Invitation Cleanup"
+	INVITATION_CLEANUP_INTERVAL  = time.Hour
+)
+
+func StartInvitationCleanup() {
+	if task := model.GetTaskByName(INVITATION_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(INVITATION_CLEANUP_TASK_NAME, cleanupInvitations, INVITATION_CLEANUP_INTERVAL)
+}
+
+func StopInvitationCleanup() {
+	if task := model.GetTaskByName(INVITATION_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	ReleaseLease(INVITATION_CLEANUP_TASK_NAME)
+}
+
+func cleanupInvitations() {
+	if !AcquireLease(INVITATION_CLEANUP_TASK_NAME, 2*INVITATION_CLEANUP_INTERVAL) {
+		return
+	}
+
+	if result := <-Srv.Store.Invitation().DeleteExpired(model.GetMillis()); result.Err != nil {
+		l4g.Error(utils.T("app.invitation.cleanup.error"), result.Err)
+	}
+}
+
+// CreateInvitation creates an invitation to a team that can be used once before it expires. If an
+// email is given, only an account with that email address can use it.
+func CreateInvitation(teamId, email, creatorId string) (*model.Invitation, *model.AppError) {
+	invitation := &model.Invitation{
+		TeamId:    teamId,
+		Email:     email,
+		CreatorId: creatorId,
+		ExpiresAt: model.GetMillis() + int64(*utils.Cfg.TeamSettings.InvitationExpiryInHours)*60*60*1000,
+	}
+
+	if result := <-Srv.Store.Invitation().Save(invitation); result.Err != nil {
+		result.Err.StatusCode = http.StatusBadRequest
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Invitation), nil
+	}
+}
+
+func GetInvitationsForTeam(teamId string) ([]*model.Invitation, *model.AppError) {
+	if result := <-Srv.Store.Invitation().GetForTeam(teamId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Invitation), nil
+	}
+}
+
+func RevokeInvitation(teamId, invitationId string) *model.AppError {
+	if result := <-Srv.Store.Invitation().Get(invitationId); result.Err != nil {
+		return result.Err
+	} else if result.Data.(*model.Invitation).TeamId != teamId {
+		return model.NewAppError("RevokeInvitation", "store.sql_invitation.get.app_error", nil, "id="+invitationId, http.StatusNotFound)
+	}
+
+	if result := <-Srv.Store.Invitation().Delete(invitationId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// GetInvite returns the team that an invite id grants access to. The invite id is either the
+// token of an invitation or of a team invite link, in which case that is returned too, or the
+// invite id of a team if permanent invite links are enabled.
+func GetInvite(inviteId string) (*model.Invitation, *model.TeamInviteLink, *model.Team, *model.AppError) {
+	if result := <-Srv.Store.Invitation().Get(inviteId); result.Err == nil {
+		invitation := result.Data.(*model.Invitation)
+		if invitation.IsExpired() {
+			return nil, nil, nil, model.NewAppError("GetInvite", "app.invitation.expired.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+		}
+
+		team, err := GetTeam(invitation.TeamId)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return invitation, nil, team, nil
+	} else if result.Err.StatusCode != http.StatusNotFound {
+		return nil, nil, nil, result.Err
+	}
+
+	if result := <-Srv.Store.TeamInviteLink().Get(inviteId); result.Err == nil {
+		link := result.Data.(*model.TeamInviteLink)
+		if !link.IsActive() {
+			return nil, nil, nil, model.NewAppError("GetInvite", "app.team_invite_link.inactive.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+		}
+
+		team, err := GetTeam(link.TeamId)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return nil, link, team, nil
+	} else if result.Err.StatusCode != http.StatusNotFound {
+		return nil, nil, nil, result.Err
+	}
+
+	if !*utils.Cfg.TeamSettings.EnablePermanentInviteLinks {
+		return nil, nil, nil, model.NewAppError("GetInvite", "api.user.create_user.signup_link_invalid.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+	}
+
+	team, err := GetTeamByInviteId(inviteId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return nil, nil, team, nil
+}
+
+// UseInvite uses up the invitation or one use of the team invite link returned by GetInvite on
+// behalf of the account with the given email address.
+func UseInvite(invitation *model.Invitation, link *model.TeamInviteLink, email string) *model.AppError {
+	if invitation != nil {
+		return UseInvitation(invitation, email)
+	} else if link != nil {
+		return UseTeamInviteLink(link)
+	}
+
+	return nil
+}
+
+// RestoreInvite gives back the invitation or the use of a team invite link that was used up by UseInvite when the
+// account that it was used for couldn't be created.
+func RestoreInvite(invitation *model.Invitation, link *model.TeamInviteLink) {
+	if invitation != nil {
+		if result := <-Srv.Store.Invitation().Save(invitation); result.Err != nil {
+			l4g.Error(utils.T("app.invitation.restore.error"), invitation.Id, result.Err.Error())
+		}
+	} else if link != nil {
+		if result := <-Srv.Store.TeamInviteLink().DecrementUseCount(link.Id); result.Err != nil {
+			l4g.Error(utils.T("app.invitation.restore.error"), link.Id, result.Err.Error())
+		}
+	}
+}
+
+// UseInvitation uses up an invitation on behalf of the account with the given email address.
+func UseInvitation(invitation *model.Invitation, email string) *model.AppError {
+	if !invitation.CanBeUsedBy(email) {
+		return model.NewAppError("UseInvitation", "app.invitation.wrong_email.app_error", nil, "id="+invitation.Id, http.StatusForbidden)
+	}
+
+	if result := <-Srv.Store.Invitation().Delete(invitation.Id); result.Err != nil {
+		return result.Err
+	} else if result.Data.(int64) == 0 {
+		return model.NewAppError("UseInvitation", "app.invitation.used.app_error", nil, "id="+invitation.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
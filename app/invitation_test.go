@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestUseInvitation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	invitation, err := CreateInvitation(th.BasicTeam.Id, "", th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := th.CreateUser()
+	if team, err := AddUserToTeamByInviteId(invitation.Id, user.Id); err != nil {
+		t.Fatal(err)
+	} else if team.Id != th.BasicTeam.Id {
+		t.Fatal("should have joined the invited team")
+	}
+
+	user2 := th.CreateUser()
+	if _, err := AddUserToTeamByInviteId(invitation.Id, user2.Id); err == nil {
+		t.Fatal("invitation should only be usable once")
+	}
+}
+
+func TestUseInvitationWithEmail(t *testing.T) {
+	th := Setup().InitBasic()
+
+	user := th.CreateUser()
+	invitation, err := CreateInvitation(th.BasicTeam.Id, user.Email, th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2 := th.CreateUser()
+	if _, err := AddUserToTeamByInviteId(invitation.Id, user2.Id); err == nil {
+		t.Fatal("invitation should only be usable by the invited email address")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("wrong status code", err.StatusCode)
+	}
+
+	if _, err := AddUserToTeamByInviteId(invitation.Id, user.Id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateUserWithInvitation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	invitation, err := CreateInvitation(th.BasicTeam.Id, "invited"+model.NewId()+"@simulator.amazonses.com", th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &model.User{Email: "other" + model.NewId() + "@simulator.amazonses.com", Username: "n" + model.NewId(), Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, invitation.Id, ""); err == nil {
+		t.Fatal("shouldn't have created a user with someone else's invitation")
+	} else if result := <-Srv.Store.User().GetByEmail(user.Email); result.Err == nil {
+		t.Fatal("shouldn't have left an account behind")
+	}
+
+	user = &model.User{Email: invitation.Email, Username: th.BasicUser.Username, Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, invitation.Id, ""); err == nil {
+		t.Fatal("shouldn't have created a user with a username that's taken")
+	}
+
+	user = &model.User{Email: invitation.Email, Username: "n" + model.NewId(), Password: "passwd1"}
+	if ruser, err := CreateUserWithInviteId(user, invitation.Id, ""); err != nil {
+		t.Fatal(err)
+	} else if !ruser.EmailVerified {
+		t.Fatal("should have verified the invited email address")
+	}
+
+	user = &model.User{Email: invitation.Email, Username: "n" + model.NewId(), Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, invitation.Id, ""); err == nil {
+		t.Fatal("shouldn't have created a user with a used invitation")
+	} else if result := <-Srv.Store.User().GetByUsername(user.Username); result.Err == nil {
+		t.Fatal("shouldn't have left an account behind")
+	}
+}
+
+func TestCreateUserWithTeamInviteLink(t *testing.T) {
+	th := Setup().InitBasic()
+
+	link, err := RegenerateTeamInviteLink(th.BasicTeam.Id, th.BasicUser.Id, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &model.User{Email: th.BasicUser.Email, Username: "n" + model.NewId(), Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, link.Id, ""); err == nil {
+		t.Fatal("shouldn't have created a user with an email address that's taken")
+	}
+
+	user = &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Username: "n" + model.NewId(), Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, link.Id, ""); err != nil {
+		t.Fatal("the failed signup shouldn't have used up the link", err)
+	}
+
+	user = &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Username: "n" + model.NewId(), Password: "passwd1"}
+	if _, err := CreateUserWithInviteId(user, link.Id, ""); err == nil {
+		t.Fatal("shouldn't have been able to use the link more than once")
+	}
+}
+
+func TestGetInvite(t *testing.T) {
+	th := Setup().InitBasic()
+
+	invitation, err := CreateInvitation(th.BasicTeam.Id, "", th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inv, _, team, err := GetInvite(invitation.Id); err != nil {
+		t.Fatal(err)
+	} else if inv.Id != invitation.Id || team.Id != th.BasicTeam.Id {
+		t.Fatal("should have returned the invitation and its team")
+	}
+
+	expired, err := CreateInvitation(th.BasicTeam.Id, "", th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// manually expire the invitation, since it can't be saved with an expiry time in the past
+	Srv.Store.(*store.SqlStore).GetMaster().Exec("UPDATE Invitations SET ExpiresAt = :ExpiresAt WHERE Id = :Id",
+		map[string]interface{}{"Id": expired.Id, "ExpiresAt": model.GetMillis() - 1000})
+
+	if _, _, _, err := GetInvite(expired.Id); err == nil || err.Id != "app.invitation.expired.app_error" {
+		t.Fatal("expired invitation shouldn't be usable")
+	}
+
+	enablePermanentInviteLinks := *utils.Cfg.TeamSettings.EnablePermanentInviteLinks
+	defer func() {
+		*utils.Cfg.TeamSettings.EnablePermanentInviteLinks = enablePermanentInviteLinks
+	}()
+
+	*utils.Cfg.TeamSettings.EnablePermanentInviteLinks = true
+	if inv, _, team, err := GetInvite(th.BasicTeam.InviteId); err != nil {
+		t.Fatal(err)
+	} else if inv != nil || team.Id != th.BasicTeam.Id {
+		t.Fatal("should have returned the team for its invite id")
+	}
+
+	*utils.Cfg.TeamSettings.EnablePermanentInviteLinks = false
+	if _, _, _, err := GetInvite(th.BasicTeam.InviteId); err == nil {
+		t.Fatal("team invite id shouldn't be usable when permanent invite links are disabled")
+	}
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	th := Setup().InitBasic()
+
+	invitation, err := CreateInvitation(th.BasicTeam.Id, "", th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherTeam := th.CreateTeam()
+	if err := RevokeInvitation(otherTeam.Id, invitation.Id); err == nil {
+		t.Fatal("shouldn't be able to revoke an invitation from another team")
+	}
+
+	if err := RevokeInvitation(th.BasicTeam.Id, invitation.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := GetInvite(invitation.Id); err == nil {
+		t.Fatal("revoked invitation shouldn't be usable")
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	JOB_RUNNER_TASK_NAME = "Job Runner"
+	JOB_RUNNER_INTERVAL  = 15 * time.Second
+
+	// An in progress job that hasn't reported any progress for this long is assumed to have
+	// been abandoned by a server that went down, and will be resumed from its last checkpoint.
+	JOB_STALE_AFTER_MILLIS = 1000 * 60 * 5
+
+	JOB_CANCELED_ERROR = "app.job.canceled.app_error"
+)
+
+// JobWorkerFunc performs the work for a job. Long running workers should periodically
+// save a checkpoint in the job's data and call SetJobProgress so that they can be
+// resumed after a restart and so that they stop when the job is canceled.
+type JobWorkerFunc func(job *model.Job) *model.AppError
+
+var jobWorkers = make(map[string]JobWorkerFunc)
+
+var runningJobs = make(map[string]bool)
+var runningJobsMutex sync.Mutex
+
+func RegisterJobWorker(jobType string, worker JobWorkerFunc) {
+	jobWorkers[jobType] = worker
+}
+
+func StartJobs() {
+	if task := model.GetTaskByName(JOB_RUNNER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	l4g.Debug(utils.T("app.job.start.debug"))
+	model.CreateRecurringTask(JOB_RUNNER_TASK_NAME, RunPendingJobs, JOB_RUNNER_INTERVAL)
+}
+
+func StopJobs() {
+	if task := model.GetTaskByName(JOB_RUNNER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+}
+
+func CreateJob(jobType string, data map[string]string) (*model.Job, *model.AppError) {
+	if _, ok := jobWorkers[jobType]; !ok {
+		return nil, model.NewAppError("CreateJob", "app.job.create.unknown_type.app_error", nil, "type="+jobType, http.StatusBadRequest)
+	}
+
+	job := &model.Job{
+		Type: jobType,
+		Data: data,
+	}
+
+	if result := <-Srv.Store.Job().Save(job); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Job), nil
+	}
+}
+
+func GetJob(id string) (*model.Job, *model.AppError) {
+	if result := <-Srv.Store.Job().Get(id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Job), nil
+	}
+}
+
+func GetJobsByType(jobType string, offset int, limit int) ([]*model.Job, *model.AppError) {
+	if result := <-Srv.Store.Job().GetAllByType(jobType, offset, limit); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Job), nil
+	}
+}
+
+// CancelJob stops a job. Pending jobs are canceled immediately while running jobs are
+// flagged and will stop the next time their worker reports progress.
+func CancelJob(id string) (*model.Job, *model.AppError) {
+	job, err := GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.IsFinished() {
+		return job, nil
+	}
+
+	newStatus := model.JOB_STATUS_CANCEL_REQUESTED
+	if job.Status == model.JOB_STATUS_PENDING {
+		newStatus = model.JOB_STATUS_CANCELED
+	}
+
+	oldStatus := job.Status
+	job.Status = newStatus
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, oldStatus); result.Err != nil {
+		return nil, result.Err
+	} else if !result.Data.(bool) {
+		return nil, model.NewAppError("CancelJob", "app.job.cancel.changed.app_error", nil, "id="+id, http.StatusConflict)
+	}
+
+	return job, nil
+}
+
+// ResumeJob queues a job that failed, was canceled or was abandoned by a server that went
+// down to run again from the last checkpoint saved in its data.
+func ResumeJob(id string) (*model.Job, *model.AppError) {
+	job, err := GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case model.JOB_STATUS_ERROR, model.JOB_STATUS_CANCELED:
+	case model.JOB_STATUS_IN_PROGRESS:
+		if job.LastActivityAt >= model.GetMillis()-JOB_STALE_AFTER_MILLIS || isJobRunningLocally(job.Id) {
+			return nil, model.NewAppError("ResumeJob", "app.job.resume.running.app_error", nil, "id="+id, http.StatusBadRequest)
+		}
+	default:
+		return nil, model.NewAppError("ResumeJob", "app.job.resume.status.app_error", nil, "id="+id+", status="+job.Status, http.StatusBadRequest)
+	}
+
+	oldStatus := job.Status
+	job.Status = model.JOB_STATUS_PENDING
+	delete(job.Data, "error")
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, oldStatus); result.Err != nil {
+		return nil, result.Err
+	} else if !result.Data.(bool) {
+		return nil, model.NewAppError("ResumeJob", "app.job.resume.changed.app_error", nil, "id="+id, http.StatusConflict)
+	}
+
+	return job, nil
+}
+
+// SetJobProgress saves the job's progress and data. It returns an error with the id
+// JOB_CANCELED_ERROR if the job has been canceled, in which case the worker should stop.
+func SetJobProgress(job *model.Job, progress int64) *model.AppError {
+	job.Progress = progress
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return model.NewAppError("SetJobProgress", JOB_CANCELED_ERROR, nil, "id="+job.Id, http.StatusOK)
+	}
+
+	return nil
+}
+
+// RunJobNow claims a pending job and runs it on this server, returning once it has finished.
+// It returns false if the job was already claimed by another server.
+func RunJobNow(job *model.Job) bool {
+	if _, ok := jobWorkers[job.Type]; !ok || !claimJob(job) {
+		return false
+	}
+
+	runJob(job)
+	return true
+}
+
+func RunPendingJobs() {
+	resumeStaleJobs()
+
+	var jobs []*model.Job
+	if result := <-Srv.Store.Job().GetAllByStatus(model.JOB_STATUS_PENDING); result.Err != nil {
+		l4g.Error(utils.T("app.job.run_pending.get.error"), result.Err.Error())
+		return
+	} else {
+		jobs = result.Data.([]*model.Job)
+	}
+
+	for _, job := range jobs {
+		if _, ok := jobWorkers[job.Type]; !ok {
+			continue
+		}
+
+		if claimJob(job) {
+			go runJob(job)
+		}
+	}
+}
+
+func resumeStaleJobs() {
+	var jobs []*model.Job
+	if result := <-Srv.Store.Job().GetAllByStatus(model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		l4g.Error(utils.T("app.job.run_pending.get.error"), result.Err.Error())
+		return
+	} else {
+		jobs = result.Data.([]*model.Job)
+	}
+
+	staleBefore := model.GetMillis() - JOB_STALE_AFTER_MILLIS
+	for _, job := range jobs {
+		if job.LastActivityAt >= staleBefore || isJobRunningLocally(job.Id) {
+			continue
+		}
+
+		l4g.Warn(utils.T("app.job.resume_stale.warn"), job.Id, job.Type)
+
+		job.Status = model.JOB_STATUS_PENDING
+		if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+			l4g.Error(utils.T("app.job.update.error"), job.Id, result.Err.Error())
+		}
+	}
+}
+
+// claimJob atomically moves a pending job into progress so that no other server in the
+// cluster runs it at the same time.
+func claimJob(job *model.Job) bool {
+	runningJobsMutex.Lock()
+	defer runningJobsMutex.Unlock()
+
+	if runningJobs[job.Id] {
+		return false
+	}
+
+	job.Status = model.JOB_STATUS_IN_PROGRESS
+	if job.StartAt == 0 {
+		job.StartAt = model.GetMillis()
+	}
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_PENDING); result.Err != nil {
+		l4g.Error(utils.T("app.job.update.error"), job.Id, result.Err.Error())
+		return false
+	} else if !result.Data.(bool) {
+		return false
+	}
+
+	runningJobs[job.Id] = true
+	return true
+}
+
+func isJobRunningLocally(id string) bool {
+	runningJobsMutex.Lock()
+	defer runningJobsMutex.Unlock()
+
+	return runningJobs[id]
+}
+
+func runJob(job *model.Job) {
+	defer func() {
+		runningJobsMutex.Lock()
+		delete(runningJobs, job.Id)
+		runningJobsMutex.Unlock()
+	}()
+
+	l4g.Info(utils.T("app.job.run.info"), job.Id, job.Type)
+
+	err := jobWorkers[job.Type](job)
+
+	if err != nil && err.Id == JOB_CANCELED_ERROR {
+		l4g.Info(utils.T("app.job.run.canceled.info"), job.Id, job.Type)
+		<-Srv.Store.Job().UpdateStatus(job.Id, model.JOB_STATUS_CANCELED)
+		return
+	}
+
+	if err != nil {
+		l4g.Error(utils.T("app.job.run.error"), job.Id, job.Type, err.Error())
+		job.Status = model.JOB_STATUS_ERROR
+		job.Data["error"] = err.Error()
+	} else {
+		l4g.Info(utils.T("app.job.run.success.info"), job.Id, job.Type)
+		job.Status = model.JOB_STATUS_SUCCESS
+		job.Progress = 100
+	}
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		l4g.Error(utils.T("app.job.update.error"), job.Id, result.Err.Error())
+	} else if !result.Data.(bool) {
+		// the job was canceled after the worker had already finished
+		<-Srv.Store.Job().UpdateStatus(job.Id, model.JOB_STATUS_CANCELED)
+	}
+}
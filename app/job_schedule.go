@@ -0,0 +1,167 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	JOB_SCHEDULER_TASK_NAME = "Job Scheduler"
+	JOB_SCHEDULER_INTERVAL  = time.Minute
+)
+
+// JobSchedulerFunc is called whenever the schedule for its job type comes due and queues a job if
+// there is anything for one to do.
+type JobSchedulerFunc func()
+
+type jobScheduler struct {
+	defaultSchedule string
+	schedule        JobSchedulerFunc
+}
+
+var jobSchedulers = make(map[string]*jobScheduler)
+
+type jobSchedulesByType []*model.JobSchedule
+
+func (s jobSchedulesByType) Len() int           { return len(s) }
+func (s jobSchedulesByType) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s jobSchedulesByType) Less(i, j int) bool { return s[i].JobType < s[j].JobType }
+
+// RegisterJobScheduler makes a job type run on a cron schedule. The default schedule is used until
+// a system admin changes it.
+func RegisterJobScheduler(jobType string, defaultSchedule string, scheduler JobSchedulerFunc) {
+	jobSchedulers[jobType] = &jobScheduler{
+		defaultSchedule: defaultSchedule,
+		schedule:        scheduler,
+	}
+}
+
+func StartJobScheduler() {
+	if task := model.GetTaskByName(JOB_SCHEDULER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(JOB_SCHEDULER_TASK_NAME, runScheduledJobs, JOB_SCHEDULER_INTERVAL)
+}
+
+func StopJobScheduler() {
+	if task := model.GetTaskByName(JOB_SCHEDULER_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	ReleaseLease(JOB_SCHEDULER_TASK_NAME)
+}
+
+// GetJobSchedules returns the schedule of every job type that runs on one, including those still
+// on their default schedule.
+func GetJobSchedules() ([]*model.JobSchedule, *model.AppError) {
+	stored := make(map[string]*model.JobSchedule)
+	if result := <-Srv.Store.Job().GetAllSchedules(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		for _, schedule := range result.Data.([]*model.JobSchedule) {
+			stored[schedule.JobType] = schedule
+		}
+	}
+
+	schedules := make([]*model.JobSchedule, 0, len(jobSchedulers))
+	for jobType, scheduler := range jobSchedulers {
+		if schedule, ok := stored[jobType]; ok {
+			schedules = append(schedules, schedule)
+		} else {
+			schedules = append(schedules, &model.JobSchedule{
+				JobType:  jobType,
+				Schedule: scheduler.defaultSchedule,
+			})
+		}
+	}
+
+	sort.Sort(jobSchedulesByType(schedules))
+
+	return schedules, nil
+}
+
+// UpdateJobSchedule changes when a job type runs. An empty schedule puts the job type back on its
+// default schedule.
+func UpdateJobSchedule(schedule *model.JobSchedule) (*model.JobSchedule, *model.AppError) {
+	scheduler, ok := jobSchedulers[schedule.JobType]
+	if !ok {
+		return nil, model.NewAppError("UpdateJobSchedule", "app.job_schedule.update.unknown_type.app_error", nil, "type="+schedule.JobType, http.StatusBadRequest)
+	}
+
+	if schedule.Schedule == "" {
+		schedule.Schedule = scheduler.defaultSchedule
+	}
+
+	schedule.PreSave()
+	if err := schedule.IsValid(); err != nil {
+		err.StatusCode = http.StatusBadRequest
+		return nil, err
+	}
+
+	if err := setNextRunAt(schedule, model.GetMillis()); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.Job().SaveSchedule(schedule); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.JobSchedule), nil
+	}
+}
+
+func setNextRunAt(schedule *model.JobSchedule, now int64) *model.AppError {
+	if next, err := schedule.GetNextRunAfter(now); err != nil {
+		return model.NewAppError("setNextRunAt", "app.job_schedule.next_run.app_error", nil, "type="+schedule.JobType+", "+err.Error(), http.StatusBadRequest)
+	} else {
+		schedule.NextRunAt = next
+		return nil
+	}
+}
+
+// runScheduledJobs calls the scheduler of every job type whose schedule has come due. It only runs
+// on the server that holds the lease so that no job is scheduled twice. The lease is used instead of
+// cluster leadership since the leader can change without the lease changing hands.
+func runScheduledJobs() {
+	if !AcquireLease(JOB_SCHEDULER_TASK_NAME, 5*JOB_SCHEDULER_INTERVAL) {
+		return
+	}
+
+	schedules, err := GetJobSchedules()
+	if err != nil {
+		l4g.Error(utils.T("app.job_schedule.run.error"), err.Error())
+		return
+	}
+
+	now := model.GetMillis()
+	for _, schedule := range schedules {
+		due := schedule.NextRunAt != 0 && schedule.NextRunAt <= now
+
+		// a schedule that has never been saved hasn't had its first run worked out yet
+		if !due && schedule.UpdateAt != 0 {
+			continue
+		}
+
+		if due {
+			l4g.Debug(utils.T("app.job_schedule.run.debug"), schedule.JobType)
+			jobSchedulers[schedule.JobType].schedule()
+			schedule.LastRunAt = now
+		}
+
+		// if the schedule was changed while this was running, the next run that was worked out for the new schedule
+		// is kept instead
+		if err := setNextRunAt(schedule, now); err != nil {
+			l4g.Error(utils.T("app.job_schedule.run.error"), err.Error())
+		} else if result := <-Srv.Store.Job().SaveScheduleRun(schedule); result.Err != nil {
+			l4g.Error(utils.T("app.job_schedule.run.error"), result.Err.Error())
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestRunScheduledJobs(t *testing.T) {
+	Setup()
+
+	jobType := "test_" + model.NewId()[:20]
+	runs := 0
+	RegisterJobScheduler(jobType, "@daily", func() {
+		runs++
+	})
+	defer delete(jobSchedulers, jobType)
+	defer ReleaseLease(JOB_SCHEDULER_TASK_NAME)
+
+	getSchedule := func() *model.JobSchedule {
+		schedules, err := GetJobSchedules()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, schedule := range schedules {
+			if schedule.JobType == jobType {
+				return schedule
+			}
+		}
+
+		t.Fatal("should have returned the schedule")
+		return nil
+	}
+
+	if schedule := getSchedule(); schedule.Schedule != "@daily" || schedule.NextRunAt != 0 {
+		t.Fatal("should have returned the default schedule")
+	}
+
+	runScheduledJobs()
+
+	if runs != 0 {
+		t.Fatal("shouldn't have run before the first scheduled time")
+	}
+
+	schedule := getSchedule()
+	if schedule.NextRunAt <= model.GetMillis() {
+		t.Fatal("should have worked out the first run")
+	}
+
+	schedule.NextRunAt = model.GetMillis() - 1000
+	store.Must(Srv.Store.Job().SaveSchedule(schedule))
+
+	runScheduledJobs()
+
+	if runs != 1 {
+		t.Fatal("should have run once it was due", runs)
+	}
+
+	if schedule := getSchedule(); schedule.NextRunAt <= model.GetMillis() {
+		t.Fatal("should have worked out the next run")
+	} else if schedule.LastRunAt == 0 {
+		t.Fatal("should have saved when it ran")
+	}
+
+	runScheduledJobs()
+
+	if runs != 1 {
+		t.Fatal("shouldn't have run again before the next scheduled time", runs)
+	}
+}
+
+func TestUpdateJobSchedule(t *testing.T) {
+	Setup()
+
+	jobType := "test_" + model.NewId()[:20]
+	RegisterJobScheduler(jobType, "@daily", func() {})
+	defer delete(jobSchedulers, jobType)
+
+	if schedule, err := UpdateJobSchedule(&model.JobSchedule{JobType: jobType, Schedule: "0 0 30 feb *"}); err != nil {
+		t.Fatal(err)
+	} else if schedule.NextRunAt != 0 {
+		t.Fatal("should never run")
+	}
+
+	if _, err := UpdateJobSchedule(&model.JobSchedule{JobType: jobType, Schedule: "@daily", Timezone: "Nowhere/Special"}); err == nil {
+		t.Fatal("should have failed with an unknown time zone")
+	}
+
+	if _, err := UpdateJobSchedule(&model.JobSchedule{JobType: "test_" + model.NewId()[:20], Schedule: "@daily"}); err == nil {
+		t.Fatal("should have failed with a job type that isn't scheduled")
+	}
+}
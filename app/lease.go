@@ -0,0 +1,42 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// leaseOwnerId identifies this server as the holder of a lease. It changes on restart, so a restarted server has to
+// wait for its old leases to expire or be taken over like any other server.
+var leaseOwnerId = model.NewId()
+
+// AcquireLease takes the named lease for this server, or extends it if this server already holds it, and returns
+// true if this server may run the work that the lease guards. Periodic tasks should acquire the lease every time they
+// run with a duration longer than their interval so that the server holding it keeps it until it goes down.
+func AcquireLease(name string, duration time.Duration) bool {
+	lease := &model.Lease{
+		Name:     name,
+		OwnerId:  leaseOwnerId,
+		ExpireAt: model.GetMillis() + int64(duration/time.Millisecond),
+	}
+
+	if result := <-Srv.Store.Lease().Acquire(lease); result.Err != nil {
+		l4g.Error(utils.T("app.lease.acquire.error"), name, result.Err.Error())
+		return false
+	} else {
+		return result.Data.(bool)
+	}
+}
+
+// ReleaseLease gives up the named lease if this server holds it so that another server can take over without
+// waiting for it to expire.
+func ReleaseLease(name string) {
+	if result := <-Srv.Store.Lease().Release(name, leaseOwnerId); result.Err != nil {
+		l4g.Error(utils.T("app.lease.release.error"), name, result.Err.Error())
+	}
+}
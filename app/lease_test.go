@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestAcquireLease(t *testing.T) {
+	Setup()
+
+	name := "test-" + model.NewId()
+	defer ReleaseLease(name)
+
+	if !AcquireLease(name, time.Minute) {
+		t.Fatal("should have acquired a free lease")
+	}
+
+	if !AcquireLease(name, time.Minute) {
+		t.Fatal("should have renewed the lease")
+	}
+
+	otherServer := &model.Lease{Name: name, OwnerId: model.NewId(), ExpireAt: model.GetMillis() + 60000}
+	if store.Must(Srv.Store.Lease().Acquire(otherServer)).(bool) {
+		t.Fatal("another server shouldn't have acquired the lease")
+	}
+
+	ReleaseLease(name)
+
+	if !store.Must(Srv.Store.Lease().Acquire(otherServer)).(bool) {
+		t.Fatal("another server should have acquired the released lease")
+	}
+
+	if AcquireLease(name, time.Minute) {
+		t.Fatal("shouldn't have acquired a lease held by another server")
+	}
+
+	ReleaseLease(name)
+
+	if AcquireLease(name, time.Minute) {
+		t.Fatal("shouldn't have released a lease held by another server")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+func GetLegalHolds() ([]*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().GetAll(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.LegalHold), nil
+	}
+}
+
+func GetLegalHold(id string) (*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().Get(id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHold), nil
+	}
+}
+
+func CreateLegalHold(hold *model.LegalHold) (*model.LegalHold, *model.AppError) {
+	hold.Id = ""
+
+	if err := checkLegalHoldTarget(hold); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.LegalHold().Save(hold); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHold), nil
+	}
+}
+
+func UpdateLegalHold(hold *model.LegalHold) (*model.LegalHold, *model.AppError) {
+	oldHold, err := GetLegalHold(hold.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.CreateAt = oldHold.CreateAt
+
+	if err := checkLegalHoldTarget(hold); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.LegalHold().Update(hold); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHold), nil
+	}
+}
+
+// DeleteLegalHold releases a legal hold so that the content it held can be deleted again.
+func DeleteLegalHold(id string) *model.AppError {
+	if result := <-Srv.Store.LegalHold().Delete(id); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// checkLegalHoldTarget makes sure that the user or channel that a legal hold applies to exists. Deleted channels
+// are allowed since their posts are kept until they're permanently deleted.
+func checkLegalHoldTarget(hold *model.LegalHold) *model.AppError {
+	if len(hold.UserId) > 0 {
+		if _, err := GetUser(hold.UserId); err != nil {
+			return err
+		}
+	} else if len(hold.ChannelId) > 0 {
+		if result := <-Srv.Store.Channel().Get(hold.ChannelId, true); result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+// ExportLegalHold returns a page of the posts held by a legal hold, oldest first, and the files attached to them.
+func ExportLegalHold(id string, offset int, limit int) (*model.LegalHoldExport, *model.AppError) {
+	hold, err := GetLegalHold(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.LegalHold().GetExport(hold, offset, limit); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHoldExport), nil
+	}
+}
+
+// checkNotUnderLegalHold returns an error if a legal hold is on the given user or channel. Their posts are kept while
+// they're held, so the user or channel itself mustn't be permanently deleted out from under them either.
+func checkNotUnderLegalHold(userId string, channelId string) *model.AppError {
+	if result := <-Srv.Store.LegalHold().CountForTarget(userId, channelId); result.Err != nil {
+		return result.Err
+	} else if result.Data.(int64) > 0 {
+		return model.NewAppError("checkNotUnderLegalHold", "app.legal_hold.held.app_error", nil, "user_id="+userId+", channel_id="+channelId, http.StatusConflict)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestPermanentDeleteUserUnderLegalHold(t *testing.T) {
+	th := Setup().InitBasic()
+
+	user := th.CreateUser()
+	post := th.CreatePost(th.BasicChannel)
+
+	hold, err := CreateLegalHold(&model.LegalHold{DisplayName: "User", UserId: user.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PermanentDeleteUser(user); err == nil || err.StatusCode != http.StatusConflict {
+		t.Fatal("shouldn't be able to permanently delete a user under a legal hold", err)
+	}
+
+	if result := <-Srv.Store.User().Get(user.Id); result.Err != nil {
+		t.Fatal("should have kept the user")
+	} else if result.Data.(*model.User).DeleteAt != 0 {
+		t.Fatal("shouldn't have deactivated the user")
+	}
+
+	if err := DeleteLegalHold(hold.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PermanentDeleteUser(user); err != nil {
+		t.Fatal("should be able to permanently delete the user once the hold is released", err)
+	}
+
+	if _, err := GetSinglePost(post.Id); err != nil {
+		t.Fatal("shouldn't have deleted another user's post", err)
+	}
+}
+
+func TestPermanentDeleteChannelUnderLegalHold(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	post := th.CreatePost(channel)
+
+	hold, err := CreateLegalHold(&model.LegalHold{DisplayName: "Channel", ChannelId: channel.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PermanentDeleteChannel(channel); err == nil || err.StatusCode != http.StatusConflict {
+		t.Fatal("shouldn't be able to permanently delete a channel under a legal hold", err)
+	}
+
+	if _, err := GetChannel(channel.Id); err != nil {
+		t.Fatal("should have kept the channel", err)
+	}
+
+	if _, err := GetSinglePost(post.Id); err != nil {
+		t.Fatal("should have kept the channel's posts", err)
+	}
+
+	if err := DeleteLegalHold(hold.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PermanentDeleteChannel(channel); err != nil {
+		t.Fatal("should be able to permanently delete the channel once the hold is released", err)
+	}
+
+	if result := <-Srv.Store.Channel().Get(channel.Id, false); result.Err == nil {
+		t.Fatal("should have deleted the channel")
+	}
+}
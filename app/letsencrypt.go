@@ -0,0 +1,647 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/net/context"
+)
+
+const (
+	LETS_ENCRYPT_RENEWAL_TASK_NAME      = "Let's Encrypt Renewal"
+	LETS_ENCRYPT_RENEWAL_CHECK_INTERVAL = 12 * time.Hour
+	LETS_ENCRYPT_RENEW_BEFORE           = 30 * 24 * time.Hour
+	LETS_ENCRYPT_REQUEST_TIMEOUT        = 5 * time.Minute
+	LETS_ENCRYPT_MIN_RETRY_INTERVAL     = time.Minute
+	LETS_ENCRYPT_MAX_RETRY_INTERVAL     = time.Hour
+	LETS_ENCRYPT_ACCOUNT_KEY_NAME       = "acme_account.key"
+	LETS_ENCRYPT_CHALLENGE_NAME_PREFIX  = "http-01-"
+	LETS_ENCRYPT_HTTP_CHALLENGE_PATH    = "/.well-known/acme-challenge/"
+)
+
+var errLetsEncryptCacheMiss = errors.New("letsencrypt: cache miss")
+
+// letsEncryptCache persists the ACME account key, issued certificates and pending challenge responses
+// so that they survive restarts and, when backed by the database, are shared between servers.
+type letsEncryptCache interface {
+	Get(name string) ([]byte, error)
+	Put(name string, data []byte) error
+	Delete(name string) error
+}
+
+// letsEncryptFileCache keeps every entry in a single JSON file on disk.
+type letsEncryptFileCache struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func (c *letsEncryptFileCache) read() (map[string][]byte, error) {
+	entries := map[string][]byte{}
+
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// A cache file left behind by older versions has the account key and certificates that they obtained, so
+	// they're read from it and kept when it's next written rather than requesting new ones
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return readLegacyLetsEncryptCache(data)
+	}
+
+	return entries, nil
+}
+
+// legacyLetsEncryptState is the format of the cache file written by the letsencrypt package used by older versions.
+type legacyLetsEncryptState struct {
+	Key   string
+	Certs map[string]struct {
+		Cert string
+		Key  string
+	}
+}
+
+func readLegacyLetsEncryptCache(data []byte) (map[string][]byte, error) {
+	var state legacyLetsEncryptState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("letsencrypt: unable to read cache file: %v", err)
+	}
+
+	entries := map[string][]byte{}
+
+	if state.Key != "" {
+		entries[LETS_ENCRYPT_ACCOUNT_KEY_NAME] = []byte(state.Key)
+	}
+
+	for host, cert := range state.Certs {
+		entries[strings.ToLower(host)] = []byte(cert.Key + cert.Cert)
+	}
+
+	return entries, nil
+}
+
+func (c *letsEncryptFileCache) write(entries map[string][]byte) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, data, 0600)
+}
+
+func (c *letsEncryptFileCache) Get(name string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := entries[name]; ok {
+		return data, nil
+	}
+
+	return nil, errLetsEncryptCacheMiss
+}
+
+func (c *letsEncryptFileCache) Put(name string, data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	entries[name] = data
+
+	return c.write(entries)
+}
+
+func (c *letsEncryptFileCache) Delete(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := entries[name]; !ok {
+		return nil
+	}
+
+	delete(entries, name)
+
+	return c.write(entries)
+}
+
+// letsEncryptDatabaseCache keeps entries in the database so that every server in a cluster uses the same certificate.
+type letsEncryptDatabaseCache struct{}
+
+func (c *letsEncryptDatabaseCache) Get(name string) ([]byte, error) {
+	if result := <-Srv.Store.CertificateCache().Get(name); result.Err != nil {
+		if result.Err.Id == "store.sql_certificate_cache.get.missing.app_error" {
+			return nil, errLetsEncryptCacheMiss
+		}
+
+		return nil, result.Err
+	} else {
+		return []byte(result.Data.(*model.CertificateCacheEntry).Data), nil
+	}
+}
+
+func (c *letsEncryptDatabaseCache) Put(name string, data []byte) error {
+	if result := <-Srv.Store.CertificateCache().SaveOrUpdate(&model.CertificateCacheEntry{Name: name, Data: string(data)}); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func (c *letsEncryptDatabaseCache) Delete(name string) error {
+	if result := <-Srv.Store.CertificateCache().Delete(name); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// LetsEncryptManager obtains and renews a TLS certificate for a single domain from an ACME certificate authority
+// such as Let's Encrypt, proving control of the domain with HTTP-01 challenges served on port 80.
+type LetsEncryptManager struct {
+	domain       string
+	directoryURL string
+	cache        letsEncryptCache
+
+	// requestMutex makes sure that only one certificate is loaded or requested at a time. It also guards client and
+	// the record of failed requests.
+	requestMutex sync.Mutex
+	client       *acme.Client
+	failures     int
+	lastFailure  time.Time
+	lastError    error
+
+	certificateMutex sync.RWMutex
+	certificate      *tls.Certificate
+
+	challengeMutex     sync.RWMutex
+	challengeResponses map[string]string
+}
+
+func newLetsEncryptManager(domain, directoryURL string, cache letsEncryptCache) *LetsEncryptManager {
+	return &LetsEncryptManager{
+		domain:             strings.ToLower(domain),
+		directoryURL:       directoryURL,
+		cache:              cache,
+		challengeResponses: make(map[string]string),
+	}
+}
+
+func NewLetsEncryptManagerFromConfig() *LetsEncryptManager {
+	var domain string
+	if siteURL, err := url.Parse(*utils.Cfg.ServiceSettings.SiteURL); err == nil {
+		domain = siteURL.Hostname()
+	}
+
+	var cache letsEncryptCache
+	if *utils.Cfg.ServiceSettings.LetsEncryptCertificateCacheDriver == model.LETS_ENCRYPT_CACHE_DRIVER_DATABASE {
+		cache = &letsEncryptDatabaseCache{}
+	} else {
+		cache = &letsEncryptFileCache{path: *utils.Cfg.ServiceSettings.LetsEncryptCertificateCacheFile}
+	}
+
+	return newLetsEncryptManager(domain, *utils.Cfg.ServiceSettings.LetsEncryptDirectoryURL, cache)
+}
+
+// Start obtains a certificate in the background, rather than waiting for the first TLS handshake,
+// and periodically renews it once it gets close to expiring.
+func (m *LetsEncryptManager) Start() {
+	if task := model.GetTaskByName(LETS_ENCRYPT_RENEWAL_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	go m.renew()
+
+	model.CreateRecurringTask(LETS_ENCRYPT_RENEWAL_TASK_NAME, m.renew, LETS_ENCRYPT_RENEWAL_CHECK_INTERVAL)
+}
+
+func (m *LetsEncryptManager) Stop() {
+	if task := model.GetTaskByName(LETS_ENCRYPT_RENEWAL_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+}
+
+func (m *LetsEncryptManager) renew() {
+	if _, err := m.obtainCertificate(); err != nil {
+		l4g.Error(utils.T("api.server.lets_encrypt.obtain.error"), m.domain, err)
+	}
+}
+
+// GetCertificate is used as tls.Config.GetCertificate to serve the current certificate.
+func (m *LetsEncryptManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello.ServerName != "" && strings.ToLower(strings.TrimSuffix(hello.ServerName, ".")) != m.domain {
+		return nil, fmt.Errorf("letsencrypt: no certificate for server name %q", hello.ServerName)
+	}
+
+	if certificate := m.getCurrentCertificate(); certificate != nil {
+		return certificate, nil
+	}
+
+	certificate, err := m.obtainCertificate()
+	if certificate == nil {
+		return nil, err
+	} else if err != nil {
+		l4g.Error(utils.T("api.server.lets_encrypt.obtain.error"), m.domain, err)
+	}
+
+	return certificate, nil
+}
+
+// HTTPHandler answers HTTP-01 challenges and passes every other request on to fallback.
+func (m *LetsEncryptManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, LETS_ENCRYPT_HTTP_CHALLENGE_PATH) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.URL.Path, LETS_ENCRYPT_HTTP_CHALLENGE_PATH)
+		if response, ok := m.getChallengeResponse(token); ok {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(response))
+		} else {
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func (m *LetsEncryptManager) getCurrentCertificate() *tls.Certificate {
+	m.certificateMutex.RLock()
+	defer m.certificateMutex.RUnlock()
+
+	return m.certificate
+}
+
+func (m *LetsEncryptManager) setCurrentCertificate(certificate *tls.Certificate) {
+	m.certificateMutex.Lock()
+	defer m.certificateMutex.Unlock()
+
+	m.certificate = certificate
+}
+
+// obtainCertificate returns a certificate that isn't due for renewal, loading it from the cache or requesting a new one
+// as needed. If requesting a new certificate fails, the current one is still returned along with the error for as long
+// as it remains valid.
+func (m *LetsEncryptManager) obtainCertificate() (*tls.Certificate, error) {
+	m.requestMutex.Lock()
+	defer m.requestMutex.Unlock()
+
+	certificate := m.getCurrentCertificate()
+
+	if certificate == nil || letsEncryptCertificateNeedsRenewal(certificate) {
+		// Another server sharing the cache may have already renewed the certificate
+		if cached, err := m.getCachedCertificate(); err == nil {
+			if certificate == nil || cached.Leaf.NotAfter.After(certificate.Leaf.NotAfter) {
+				certificate = cached
+				m.setCurrentCertificate(certificate)
+			}
+		} else if err != errLetsEncryptCacheMiss {
+			l4g.Warn(utils.T("api.server.lets_encrypt.cache.warn"), m.domain, err)
+		}
+	}
+
+	if certificate != nil && !letsEncryptCertificateNeedsRenewal(certificate) {
+		return certificate, nil
+	}
+
+	// Every failed request counts against the certificate authority's rate limits, so once a request fails, the same
+	// error is returned until the retry interval has passed instead of starting a new order for each TLS handshake
+	err := m.lastError
+	if m.failures == 0 || !time.Now().Before(m.lastFailure.Add(m.getRetryInterval())) {
+		var requested *tls.Certificate
+		if requested, err = m.requestCertificate(); err == nil {
+			m.failures = 0
+			m.lastError = nil
+			return requested, nil
+		}
+
+		m.failures++
+		m.lastFailure = time.Now()
+		m.lastError = err
+	}
+
+	if certificate != nil && time.Now().Before(certificate.Leaf.NotAfter) {
+		return certificate, err
+	}
+
+	return nil, err
+}
+
+// getRetryInterval returns how long to wait after the last failed request before trying again. It starts at
+// LETS_ENCRYPT_MIN_RETRY_INTERVAL and doubles with each consecutive failure up to LETS_ENCRYPT_MAX_RETRY_INTERVAL.
+func (m *LetsEncryptManager) getRetryInterval() time.Duration {
+	interval := LETS_ENCRYPT_MIN_RETRY_INTERVAL
+	for i := 1; i < m.failures && interval < LETS_ENCRYPT_MAX_RETRY_INTERVAL; i++ {
+		interval *= 2
+	}
+
+	if interval > LETS_ENCRYPT_MAX_RETRY_INTERVAL {
+		return LETS_ENCRYPT_MAX_RETRY_INTERVAL
+	}
+
+	return interval
+}
+
+func (m *LetsEncryptManager) getCachedCertificate() (*tls.Certificate, error) {
+	data, err := m.cache.Get(m.domain)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := decodeLetsEncryptCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := certificate.Leaf.VerifyHostname(m.domain); err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(certificate.Leaf.NotAfter) {
+		return nil, errors.New("letsencrypt: cached certificate has expired")
+	}
+
+	return certificate, nil
+}
+
+func (m *LetsEncryptManager) requestCertificate() (*tls.Certificate, error) {
+	if m.domain == "" {
+		return nil, errors.New("letsencrypt: no domain configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), LETS_ENCRYPT_REQUEST_TIMEOUT)
+	defer cancel()
+
+	client, err := m.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(m.domain))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, authorizationURL := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, authorizationURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.domain},
+		DNSNames: []string{m.domain},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &tls.Certificate{
+		Certificate: der,
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+
+	if data, err := encodeLetsEncryptCertificate(certificate); err != nil {
+		l4g.Warn(utils.T("api.server.lets_encrypt.cache_save.warn"), m.domain, err)
+	} else if err := m.cache.Put(m.domain, data); err != nil {
+		l4g.Warn(utils.T("api.server.lets_encrypt.cache_save.warn"), m.domain, err)
+	}
+
+	m.setCurrentCertificate(certificate)
+
+	l4g.Info(utils.T("api.server.lets_encrypt.obtained.info"), m.domain, leaf.NotAfter)
+
+	return certificate, nil
+}
+
+func (m *LetsEncryptManager) getClient(ctx context.Context) (*acme.Client, error) {
+	if m.client != nil {
+		return m.client, nil
+	}
+
+	key, err := m.getAccountKey()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &acme.Client{
+		Key:          key,
+		DirectoryURL: m.directoryURL,
+	}
+
+	if _, err := client.Register(ctx, &acme.Account{}, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return nil, err
+	}
+
+	m.client = client
+
+	return client, nil
+}
+
+func (m *LetsEncryptManager) getAccountKey() (*ecdsa.PrivateKey, error) {
+	if data, err := m.cache.Get(LETS_ENCRYPT_ACCOUNT_KEY_NAME); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "EC PRIVATE KEY" {
+			return nil, errors.New("letsencrypt: invalid account key found in cache")
+		}
+
+		return x509.ParseECPrivateKey(block.Bytes)
+	} else if err != errLetsEncryptCacheMiss {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.cache.Put(LETS_ENCRYPT_ACCOUNT_KEY_NAME, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (m *LetsEncryptManager) authorize(ctx context.Context, client *acme.Client, authorizationURL string) error {
+	authorization, err := client.GetAuthorization(ctx, authorizationURL)
+	if err != nil {
+		return err
+	}
+
+	if authorization.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, c := range authorization.Challenges {
+		if c.Type == "http-01" {
+			challenge = c
+			break
+		}
+	}
+
+	if challenge == nil {
+		return errors.New("letsencrypt: the certificate authority didn't offer an http-01 challenge")
+	}
+
+	response, err := client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := m.putChallengeResponse(challenge.Token, response); err != nil {
+		return err
+	}
+	defer m.deleteChallengeResponse(challenge.Token)
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return err
+	}
+
+	_, err = client.WaitAuthorization(ctx, authorizationURL)
+	return err
+}
+
+// putChallengeResponse also stores the response in the cache since the certificate authority may send its
+// request to any server behind the load balancer.
+func (m *LetsEncryptManager) putChallengeResponse(token, response string) error {
+	m.challengeMutex.Lock()
+	m.challengeResponses[token] = response
+	m.challengeMutex.Unlock()
+
+	return m.cache.Put(LETS_ENCRYPT_CHALLENGE_NAME_PREFIX+token, []byte(response))
+}
+
+func (m *LetsEncryptManager) deleteChallengeResponse(token string) {
+	m.challengeMutex.Lock()
+	delete(m.challengeResponses, token)
+	m.challengeMutex.Unlock()
+
+	if err := m.cache.Delete(LETS_ENCRYPT_CHALLENGE_NAME_PREFIX + token); err != nil {
+		l4g.Warn(utils.T("api.server.lets_encrypt.cache_delete.warn"), m.domain, err)
+	}
+}
+
+func (m *LetsEncryptManager) getChallengeResponse(token string) (string, bool) {
+	if token == "" || len(token) > model.CERTIFICATE_CACHE_NAME_MAX_LENGTH-len(LETS_ENCRYPT_CHALLENGE_NAME_PREFIX) {
+		return "", false
+	}
+
+	m.challengeMutex.RLock()
+	response, ok := m.challengeResponses[token]
+	m.challengeMutex.RUnlock()
+
+	if ok {
+		return response, true
+	}
+
+	if data, err := m.cache.Get(LETS_ENCRYPT_CHALLENGE_NAME_PREFIX + token); err == nil {
+		return string(data), true
+	}
+
+	return "", false
+}
+
+func letsEncryptCertificateNeedsRenewal(certificate *tls.Certificate) bool {
+	return time.Now().Add(LETS_ENCRYPT_RENEW_BEFORE).After(certificate.Leaf.NotAfter)
+}
+
+func encodeLetsEncryptCertificate(certificate *tls.Certificate) ([]byte, error) {
+	key, ok := certificate.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("letsencrypt: unsupported private key type")
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, err
+	}
+
+	for _, certDer := range certificate.Certificate {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: certDer}); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeLetsEncryptCertificate(data []byte) (*tls.Certificate, error) {
+	certificate, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
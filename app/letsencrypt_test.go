@@ -0,0 +1,639 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"golang.org/x/crypto/acme"
+)
+
+func createTestLetsEncryptCertificate(t *testing.T, domain string, notAfter time.Time) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func testLetsEncryptCache(t *testing.T, cache letsEncryptCache) {
+	name := "example.com-" + model.NewId()
+
+	if _, err := cache.Get(name); err != errLetsEncryptCacheMiss {
+		t.Fatal("should have missed the cache", err)
+	}
+
+	if err := cache.Put(name, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := cache.Get(name); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "data" {
+		t.Fatal("should have gotten the cached data")
+	}
+
+	if err := cache.Delete(name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Get(name); err != errLetsEncryptCacheMiss {
+		t.Fatal("should have deleted the cached data", err)
+	}
+
+	if err := cache.Delete(name); err != nil {
+		t.Fatal("should be able to delete a missing entry", err)
+	}
+}
+
+func TestLetsEncryptFileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "letsencrypt.cache")
+	testLetsEncryptCache(t, &letsEncryptFileCache{path: path})
+
+	if err := (&letsEncryptFileCache{path: path}).Put("name", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := (&letsEncryptFileCache{path: path}).Get("name"); err != nil || string(data) != "data" {
+		t.Fatal("should have persisted the data to disk", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&letsEncryptFileCache{path: path}).Put("name", []byte("data")); err == nil {
+		t.Fatal("shouldn't have overwritten a cache file that couldn't be read")
+	} else if data, _ := ioutil.ReadFile(path); string(data) != "not json" {
+		t.Fatal("should have left the cache file alone")
+	}
+}
+
+func TestLetsEncryptFileCacheLegacyFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	accountKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accountKeyDer, err := x509.MarshalECPrivateKey(accountKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate := createTestLetsEncryptCertificate(t, "example.com", time.Now().Add(60*24*time.Hour))
+	certificateKeyDer, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// This is how the letsencrypt package used by older versions stored its state
+	state, err := json.Marshal(map[string]interface{}{
+		"Email": "",
+		"Reg":   map[string]interface{}{"uri": "https://acme-v01.api.letsencrypt.org/acme/reg/1"},
+		"Key":   string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: accountKeyDer})),
+		"Hosts": []string{"example.com"},
+		"Certs": map[string]interface{}{
+			"Example.com": map[string]string{
+				"Cert": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]})),
+				"Key":  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: certificateKeyDer})),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "letsencrypt.cache")
+	if err := ioutil.WriteFile(path, state, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := newLetsEncryptManager("example.com", "http://127.0.0.1:1/directory", &letsEncryptFileCache{path: path})
+
+	if key, err := manager.getAccountKey(); err != nil {
+		t.Fatal(err)
+	} else if key.D.Cmp(accountKey.D) != 0 {
+		t.Fatal("should have kept using the old account key")
+	}
+
+	if served, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); err != nil {
+		t.Fatal(err)
+	} else if !served.Leaf.Equal(certificate.Leaf) {
+		t.Fatal("should have served the old certificate")
+	}
+
+	if err := manager.cache.Put("name", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.getCachedCertificate(); err != nil {
+		t.Fatal("should have kept the old certificate after writing to the cache", err)
+	}
+}
+
+func TestLetsEncryptDatabaseCache(t *testing.T) {
+	Setup()
+
+	testLetsEncryptCache(t, &letsEncryptDatabaseCache{})
+}
+
+func TestLetsEncryptManagerHTTPHandler(t *testing.T) {
+	Setup()
+
+	cache := &letsEncryptDatabaseCache{}
+	manager := newLetsEncryptManager("example.com", "", cache)
+	otherManager := newLetsEncryptManager("example.com", "", cache)
+
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	token := model.NewId()
+	if err := manager.putChallengeResponse(token, "response"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []*LetsEncryptManager{manager, otherManager} {
+		w := httptest.NewRecorder()
+		m.HTTPHandler(fallback).ServeHTTP(w, httptest.NewRequest("GET", LETS_ENCRYPT_HTTP_CHALLENGE_PATH+token, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "response" {
+			t.Fatal("should have answered the challenge", w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	manager.HTTPHandler(fallback).ServeHTTP(w, httptest.NewRequest("GET", LETS_ENCRYPT_HTTP_CHALLENGE_PATH+model.NewId(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatal("shouldn't have answered an unknown challenge", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	manager.HTTPHandler(fallback).ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatal("should have passed other requests to the fallback handler", w.Code)
+	}
+
+	manager.deleteChallengeResponse(token)
+
+	w = httptest.NewRecorder()
+	otherManager.HTTPHandler(fallback).ServeHTTP(w, httptest.NewRequest("GET", LETS_ENCRYPT_HTTP_CHALLENGE_PATH+token, nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatal("shouldn't have answered a finished challenge", w.Code)
+	}
+}
+
+// createTestLetsEncryptManager returns a manager with a cached certificate expiring at notAfter. Nothing listens on its
+// directory URL so that any attempt to request a new certificate fails straight away.
+func createTestLetsEncryptManager(t *testing.T, dir string, notAfter time.Time) (*LetsEncryptManager, *tls.Certificate) {
+	cache := &letsEncryptFileCache{path: filepath.Join(dir, model.NewId()+".cache")}
+
+	certificate := createTestLetsEncryptCertificate(t, "example.com", notAfter)
+	if data, err := encodeLetsEncryptCertificate(certificate); err != nil {
+		t.Fatal(err)
+	} else if err := cache.Put("example.com", data); err != nil {
+		t.Fatal(err)
+	}
+
+	return newLetsEncryptManager("example.com", "http://127.0.0.1:1/directory", cache), certificate
+}
+
+func TestLetsEncryptManagerGetCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manager, certificate := createTestLetsEncryptManager(t, dir, time.Now().Add(60*24*time.Hour))
+
+	if served, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "Example.com"}); err != nil {
+		t.Fatal(err)
+	} else if !served.Leaf.Equal(certificate.Leaf) {
+		t.Fatal("should have served the cached certificate")
+	}
+
+	if _, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"}); err == nil {
+		t.Fatal("shouldn't have served a certificate for another domain")
+	}
+
+	manager, _ = createTestLetsEncryptManager(t, dir, time.Now().Add(-time.Minute))
+
+	if _, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); err == nil {
+		t.Fatal("shouldn't have served an expired certificate")
+	}
+}
+
+func TestLetsEncryptManagerRenewalFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manager, certificate := createTestLetsEncryptManager(t, dir, time.Now().Add(24*time.Hour))
+
+	if served, err := manager.obtainCertificate(); err == nil {
+		t.Fatal("should have failed to renew the certificate")
+	} else if served == nil || !served.Leaf.Equal(certificate.Leaf) {
+		t.Fatal("should have kept serving the current certificate until it expires")
+	}
+}
+
+// testAcmeProblem is an error returned by testAcmeServer.
+type testAcmeProblem struct {
+	Type   string `json:"type"`
+	Status int    `json:"status"`
+}
+
+type testAcmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type testAcmeOrder struct {
+	Status         string               `json:"status"`
+	Identifiers    []testAcmeIdentifier `json:"identifiers"`
+	Authorizations []string             `json:"authorizations"`
+	Finalize       string               `json:"finalize"`
+	Certificate    string               `json:"certificate,omitempty"`
+}
+
+type testAcmeChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+type testAcmeAuthorization struct {
+	Status     string              `json:"status"`
+	Identifier testAcmeIdentifier  `json:"identifier"`
+	Challenges []testAcmeChallenge `json:"challenges"`
+}
+
+const testAcmeBadNonce = "urn:ietf:params:acme:error:badNonce"
+
+// testAcmeServer is a certificate authority that implements just enough of ACME v2 to issue a certificate for a single
+// domain. It checks the signature and nonce of every request and answers the first order with a badNonce error.
+type testAcmeServer struct {
+	t        *testing.T
+	server   *httptest.Server
+	manager  *LetsEncryptManager
+	caKey    *ecdsa.PrivateKey
+	caCert   *x509.Certificate
+	mutex    sync.Mutex
+	nonces   map[string]bool
+	key      *ecdsa.PublicKey
+	jwk      map[string]string
+	orders   int
+	validity string
+	issued   []byte
+}
+
+func newTestAcmeServer(t *testing.T) *testAcmeServer {
+	ca := createTestLetsEncryptCertificate(t, "Test CA", time.Now().Add(time.Hour))
+
+	s := &testAcmeServer{
+		t:        t,
+		caKey:    ca.PrivateKey.(*ecdsa.PrivateKey),
+		caCert:   ca.Leaf,
+		nonces:   make(map[string]bool),
+		validity: acme.StatusPending,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+func (s *testAcmeServer) url(path string) string {
+	return s.server.URL + path
+}
+
+func (s *testAcmeServer) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *testAcmeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	nonce := model.NewId()
+	s.nonces[nonce] = true
+	w.Header().Set("Replay-Nonce", nonce)
+
+	if r.URL.Path == "/directory" {
+		s.writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   s.url("/new-nonce"),
+			"newAccount": s.url("/new-account"),
+			"newOrder":   s.url("/new-order"),
+		})
+		return
+	} else if r.URL.Path == "/new-nonce" {
+		return
+	}
+
+	payload, problem := s.verify(r)
+	if problem != nil {
+		s.writeJSON(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/new-account":
+		w.Header().Set("Location", s.url("/account"))
+		s.writeJSON(w, http.StatusCreated, map[string]string{"status": acme.StatusValid})
+
+	case "/new-order":
+		if s.orders++; s.orders == 1 {
+			s.writeJSON(w, http.StatusBadRequest, &testAcmeProblem{Type: testAcmeBadNonce, Status: http.StatusBadRequest})
+			return
+		}
+
+		w.Header().Set("Location", s.url("/order"))
+		s.writeJSON(w, http.StatusCreated, s.order())
+
+	case "/order":
+		s.writeJSON(w, http.StatusOK, s.order())
+
+	case "/authorization":
+		s.writeJSON(w, http.StatusOK, &testAcmeAuthorization{
+			Status:     s.validity,
+			Identifier: testAcmeIdentifier{Type: "dns", Value: "example.com"},
+			Challenges: []testAcmeChallenge{
+				{Type: "dns-01", URL: s.url("/dns-challenge"), Token: "dns-token", Status: acme.StatusPending},
+				{Type: "http-01", URL: s.url("/challenge"), Token: "http-token", Status: acme.StatusPending},
+			},
+		})
+
+	case "/challenge":
+		jwk, _ := json.Marshal(s.jwk)
+		thumbprint := sha256.Sum256(jwk)
+
+		response := httptest.NewRecorder()
+		s.manager.HTTPHandler(http.NotFoundHandler()).ServeHTTP(response, httptest.NewRequest("GET", LETS_ENCRYPT_HTTP_CHALLENGE_PATH+"http-token", nil))
+
+		if response.Body.String() == "http-token."+base64.RawURLEncoding.EncodeToString(thumbprint[:]) {
+			s.validity = acme.StatusValid
+		} else {
+			s.validity = acme.StatusInvalid
+		}
+
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": acme.StatusProcessing})
+
+	case "/finalize":
+		var request struct {
+			CSR string `json:"csr"`
+		}
+		json.Unmarshal(payload, &request)
+
+		der, _ := base64.RawURLEncoding.DecodeString(request.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil || s.validity != acme.StatusValid || len(csr.DNSNames) != 1 || csr.DNSNames[0] != "example.com" {
+			s.writeJSON(w, http.StatusForbidden, &testAcmeProblem{Type: "urn:ietf:params:acme:error:unauthorized", Status: http.StatusForbidden})
+			return
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		}
+
+		if s.issued, err = x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey); err != nil {
+			s.t.Fatal(err)
+		}
+
+		order := s.order()
+		order.Status = acme.StatusProcessing
+		order.Certificate = ""
+		w.Header().Set("Location", s.url("/order"))
+		s.writeJSON(w, http.StatusOK, order)
+
+	case "/certificate":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: s.issued})
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})
+
+	default:
+		s.writeJSON(w, http.StatusNotFound, &testAcmeProblem{Type: "urn:ietf:params:acme:error:malformed", Status: http.StatusNotFound})
+	}
+}
+
+func (s *testAcmeServer) order() *testAcmeOrder {
+	order := &testAcmeOrder{
+		Status:         s.validity,
+		Identifiers:    []testAcmeIdentifier{{Type: "dns", Value: "example.com"}},
+		Authorizations: []string{s.url("/authorization")},
+		Finalize:       s.url("/finalize"),
+	}
+
+	if s.validity == acme.StatusValid {
+		order.Status = acme.StatusReady
+	}
+
+	if s.issued != nil {
+		order.Status = acme.StatusValid
+		order.Certificate = s.url("/certificate")
+	}
+
+	return order
+}
+
+// verify checks that a request is signed by the account key, or that it creates the account, and returns its payload.
+func (s *testAcmeServer) verify(r *http.Request) ([]byte, *testAcmeProblem) {
+	malformed := &testAcmeProblem{Type: "urn:ietf:params:acme:error:malformed", Status: http.StatusBadRequest}
+
+	var request struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, malformed
+	}
+
+	var protected struct {
+		Alg   string            `json:"alg"`
+		Nonce string            `json:"nonce"`
+		URL   string            `json:"url"`
+		JWK   map[string]string `json:"jwk"`
+		Kid   string            `json:"kid"`
+	}
+	data, _ := base64.RawURLEncoding.DecodeString(request.Protected)
+	if err := json.Unmarshal(data, &protected); err != nil || protected.Alg != "ES256" || protected.URL != s.url(r.URL.Path) {
+		return nil, malformed
+	}
+
+	if !s.nonces[protected.Nonce] {
+		return nil, &testAcmeProblem{Type: testAcmeBadNonce, Status: http.StatusBadRequest}
+	}
+	delete(s.nonces, protected.Nonce)
+
+	if r.URL.Path == "/new-account" {
+		x, _ := base64.RawURLEncoding.DecodeString(protected.JWK["x"])
+		y, _ := base64.RawURLEncoding.DecodeString(protected.JWK["y"])
+		s.key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		s.jwk = protected.JWK
+	} else if s.key == nil || protected.Kid != s.url("/account") || protected.JWK != nil {
+		return nil, malformed
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(request.Signature)
+	hash := sha256.Sum256([]byte(request.Protected + "." + request.Payload))
+	if len(signature) != 64 || !ecdsa.Verify(s.key, hash[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		return nil, malformed
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(request.Payload)
+	return payload, nil
+}
+
+func TestLetsEncryptManagerRequestCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := newTestAcmeServer(t)
+	defer server.server.Close()
+
+	cache := &letsEncryptFileCache{path: filepath.Join(dir, "letsencrypt.cache")}
+	manager := newLetsEncryptManager("example.com", server.url("/directory"), cache)
+	server.manager = manager
+
+	certificate, err := manager.obtainCertificate()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(certificate.Certificate) != 2 || certificate.Leaf.VerifyHostname("example.com") != nil {
+		t.Fatal("should have obtained a certificate for the domain")
+	}
+
+	if cached, err := manager.getCachedCertificate(); err != nil {
+		t.Fatal(err)
+	} else if !cached.Leaf.Equal(certificate.Leaf) {
+		t.Fatal("should have cached the certificate")
+	}
+
+	if _, ok := manager.getChallengeResponse("http-token"); ok {
+		t.Fatal("should have removed the challenge response")
+	}
+}
+
+func TestLetsEncryptManagerRetryBackoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "letsencrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mutex sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	getRequests := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return requests
+	}
+
+	cache := &letsEncryptFileCache{path: filepath.Join(dir, "letsencrypt.cache")}
+	manager := newLetsEncryptManager("example.com", server.URL+"/directory", cache)
+
+	_, firstErr := manager.GetCertificate(&tls.ClientHelloInfo{})
+	if firstErr == nil {
+		t.Fatal("should have failed to request a certificate")
+	} else if getRequests() != 1 {
+		t.Fatal("should have contacted the certificate authority once", getRequests())
+	}
+
+	for i := 0; i < 5; i++ {
+		if certificate, err := manager.GetCertificate(&tls.ClientHelloInfo{}); certificate != nil || err != firstErr {
+			t.Fatal("should have returned the last error while waiting to retry", err)
+		}
+	}
+
+	if getRequests() != 1 {
+		t.Fatal("shouldn't have contacted the certificate authority again before the retry interval passed", getRequests())
+	}
+
+	manager.lastFailure = time.Now().Add(-LETS_ENCRYPT_MIN_RETRY_INTERVAL)
+
+	if _, err := manager.GetCertificate(&tls.ClientHelloInfo{}); err == nil {
+		t.Fatal("should have failed to request a certificate")
+	} else if getRequests() != 2 {
+		t.Fatal("should have retried once the retry interval passed", getRequests())
+	}
+
+	if interval := manager.getRetryInterval(); interval != 2*LETS_ENCRYPT_MIN_RETRY_INTERVAL {
+		t.Fatal("should have doubled the retry interval", interval)
+	}
+
+	manager.failures = 100
+	if interval := manager.getRetryInterval(); interval != LETS_ENCRYPT_MAX_RETRY_INTERVAL {
+		t.Fatal("should have limited the retry interval", interval)
+	}
+}
@@ -8,44 +8,43 @@ import (
 	"net/http"
 	"time"
 
+	l4g "github.com/alecthomas/log4go"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"github.com/mssola/user_agent"
 )
 
-func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId string, ldapOnly bool) (*model.User, *model.AppError) {
+func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId string, ldapOnly bool, ipAddress string) (*model.User, *model.AppError) {
 	if len(password) == 0 {
 		err := model.NewLocAppError("AuthenticateUserForLogin", "api.user.login.blank_pwd.app_error", nil, "")
 		err.StatusCode = http.StatusBadRequest
 		return nil, err
 	}
 
+	if err := checkIpAddressLoginAttempts(ipAddress); err != nil {
+		return nil, err
+	}
+
 	var user *model.User
 	var err *model.AppError
 
 	if len(id) != 0 {
 		if user, err = GetUser(id); err != nil {
 			err.StatusCode = http.StatusBadRequest
-			if einterfaces.GetMetricsInterface() != nil {
-				einterfaces.GetMetricsInterface().IncrementLoginFail()
-			}
+			recordFailedLogin(ipAddress)
 			return nil, err
 		}
 	} else {
 		if user, err = GetUserForLogin(loginId, ldapOnly); err != nil {
-			if einterfaces.GetMetricsInterface() != nil {
-				einterfaces.GetMetricsInterface().IncrementLoginFail()
-			}
+			recordFailedLogin(ipAddress)
 			return nil, err
 		}
 	}
 
 	// and then authenticate them
-	if user, err = authenticateUser(user, password, mfaToken); err != nil {
-		if einterfaces.GetMetricsInterface() != nil {
-			einterfaces.GetMetricsInterface().IncrementLoginFail()
-		}
+	if user, err = authenticateUser(user, password, mfaToken, ipAddress); err != nil {
+		recordFailedLogin(ipAddress)
 		return nil, err
 	}
 
@@ -56,6 +55,18 @@ func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId string,
 	return user, nil
 }
 
+func recordFailedLogin(ipAddress string) {
+	if einterfaces.GetMetricsInterface() != nil {
+		einterfaces.GetMetricsInterface().IncrementLoginFail()
+	}
+
+	if len(ipAddress) > 0 {
+		if err := recordFailedLoginAttempt(model.LOGIN_ATTEMPT_TYPE_IP, ipAddress, *utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIp, ipAddress); err != nil {
+			l4g.Error(err.Error())
+		}
+	}
+}
+
 func DoLogin(w http.ResponseWriter, r *http.Request, user *model.User, deviceId string) (*model.Session, *model.AppError) {
 	session := &model.Session{UserId: user.Id, Roles: user.GetRawRoles(), DeviceId: deviceId, IsOAuth: false}
 
@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	LOGIN_ATTEMPT_CLEANUP_TASK_NAME = "Login Attempt Cleanup"
+	LOGIN_ATTEMPT_CLEANUP_INTERVAL  = time.Hour
+)
+
+func StartLoginAttemptCleanup() {
+	if task := model.GetTaskByName(LOGIN_ATTEMPT_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(LOGIN_ATTEMPT_CLEANUP_TASK_NAME, cleanupLoginAttempts, LOGIN_ATTEMPT_CLEANUP_INTERVAL)
+}
+
+func StopLoginAttemptCleanup() {
+	if task := model.GetTaskByName(LOGIN_ATTEMPT_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	ReleaseLease(LOGIN_ATTEMPT_CLEANUP_TASK_NAME)
+}
+
+// cleanupLoginAttempts removes the attempts that would be started over on the next failed login anyway.
+func cleanupLoginAttempts() {
+	if !AcquireLease(LOGIN_ATTEMPT_CLEANUP_TASK_NAME, 2*LOGIN_ATTEMPT_CLEANUP_INTERVAL) {
+		return
+	}
+
+	before := model.GetMillis() - int64(*utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds)*1000
+
+	if result := <-Srv.Store.LoginAttempt().DeleteOlderThan(before); result.Err != nil {
+		l4g.Error(utils.T("app.login_attempt.cleanup.error"), result.Err)
+	}
+}
+
+func getLoginAttempt(attemptType, identifier string) (*model.LoginAttempt, *model.AppError) {
+	if result := <-Srv.Store.LoginAttempt().Get(attemptType, identifier); result.Err != nil {
+		if result.Err.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LoginAttempt), nil
+	}
+}
+
+func isLoginLocked(attemptType, identifier string) (bool, *model.AppError) {
+	if attempt, err := getLoginAttempt(attemptType, identifier); err != nil {
+		return false, err
+	} else {
+		return attempt != nil && attempt.IsLocked(), nil
+	}
+}
+
+// getLoginLockoutDuration returns how long, in milliseconds, a lockout lasts given how many
+// lockouts there have been in a row. Each lockout lasts twice as long as the previous one.
+func getLoginLockoutDuration(lockouts int) int64 {
+	duration := int64(*utils.Cfg.ServiceSettings.LoginLockoutDurationInSeconds) * 1000
+	maxDuration := int64(*utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds) * 1000
+
+	for i := 1; i < lockouts && duration < maxDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+
+	return duration
+}
+
+// recordFailedLoginAttempt counts a failed login for an account or IP address and locks it out
+// once there have been maxAttempts failures. Counting starts over once there have been no
+// failures for the maximum lockout duration.
+func recordFailedLoginAttempt(attemptType, identifier string, maxAttempts int, ipAddress string) *model.AppError {
+	now := model.GetMillis()
+	maxDuration := int64(*utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds) * 1000
+
+	var attempt *model.LoginAttempt
+	if result := <-Srv.Store.LoginAttempt().IncrementFailedAttempts(attemptType, identifier, now, now-maxDuration); result.Err != nil {
+		return result.Err
+	} else {
+		attempt = result.Data.(*model.LoginAttempt)
+	}
+
+	if attempt.FailedAttempts < maxAttempts {
+		return nil
+	}
+
+	lockouts := attempt.Lockouts + 1
+	lockedUntil := now + getLoginLockoutDuration(lockouts)
+
+	locked := false
+	if result := <-Srv.Store.LoginAttempt().Lock(attemptType, identifier, maxAttempts, attempt.Lockouts, lockedUntil); result.Err != nil {
+		return result.Err
+	} else {
+		locked = result.Data.(bool)
+	}
+
+	if locked {
+		userId := ""
+		if attemptType == model.LOGIN_ATTEMPT_TYPE_USER {
+			userId = identifier
+		}
+
+		extraInfo := fmt.Sprintf("locked out - type=%v identifier=%v lockouts=%v locked_until=%v", attemptType, identifier, lockouts, lockedUntil)
+		audit := &model.Audit{UserId: userId, IpAddress: ipAddress, Action: "login_lockout", ExtraInfo: extraInfo}
+		if result := <-Srv.Store.Audit().Save(audit); result.Err != nil {
+			l4g.Error(utils.T("app.login_attempt.audit.error"), result.Err)
+		}
+	}
+
+	return nil
+}
+
+func clearLoginAttempts(attemptType, identifier string) *model.AppError {
+	if result := <-Srv.Store.LoginAttempt().Delete(attemptType, identifier); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func unlockLoginAttempts(attemptType, identifier string) *model.AppError {
+	if result := <-Srv.Store.LoginAttempt().Unlock(attemptType, identifier); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// UnlockUser lifts the lockout of an account and resets its failed login count.
+func UnlockUser(userId string) *model.AppError {
+	return unlockLoginAttempts(model.LOGIN_ATTEMPT_TYPE_USER, userId)
+}
+
+// UnlockIpAddress lifts the lockout of an IP address and resets its failed login count.
+func UnlockIpAddress(ipAddress string) *model.AppError {
+	return unlockLoginAttempts(model.LOGIN_ATTEMPT_TYPE_IP, ipAddress)
+}
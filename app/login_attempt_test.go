@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestGetLoginLockoutDuration(t *testing.T) {
+	Setup()
+
+	duration := *utils.Cfg.ServiceSettings.LoginLockoutDurationInSeconds
+	maxDuration := *utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds
+	defer func() {
+		*utils.Cfg.ServiceSettings.LoginLockoutDurationInSeconds = duration
+		*utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds = maxDuration
+	}()
+	*utils.Cfg.ServiceSettings.LoginLockoutDurationInSeconds = 60
+	*utils.Cfg.ServiceSettings.MaximumLoginLockoutDurationInSeconds = 300
+
+	for lockouts, expected := range map[int]int64{1: 60000, 2: 120000, 3: 240000, 4: 300000, 50: 300000} {
+		if actual := getLoginLockoutDuration(lockouts); actual != expected {
+			t.Fatalf("lockout %v should have lasted %v ms but lasted %v ms", lockouts, expected, actual)
+		}
+	}
+}
+
+func TestUserLoginLockout(t *testing.T) {
+	th := Setup().InitBasic()
+
+	maxAttempts := utils.Cfg.ServiceSettings.MaximumLoginAttempts
+	defer func() {
+		utils.Cfg.ServiceSettings.MaximumLoginAttempts = maxAttempts
+	}()
+	utils.Cfg.ServiceSettings.MaximumLoginAttempts = 2
+
+	user := th.BasicUser
+
+	for i := 0; i < 2; i++ {
+		if _, err := AuthenticateUserForLogin(user.Id, "", "wrongpassword", "", "", false, ""); err == nil {
+			t.Fatal("shouldn't have logged in with the wrong password")
+		}
+	}
+
+	if _, err := AuthenticateUserForLogin(user.Id, "", "Password1", "", "", false, ""); err == nil {
+		t.Fatal("shouldn't have logged in while locked out")
+	} else if err.Id != "api.user.check_user_login_attempts.too_many.app_error" {
+		t.Fatal("should have been locked out", err)
+	}
+
+	attempt, err := getLoginAttempt(model.LOGIN_ATTEMPT_TYPE_USER, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if attempt.Lockouts != 1 {
+		t.Fatal("should have counted the lockout")
+	}
+
+	// let the lockout expire and fail again to check that the next lockout is longer
+	firstDuration := attempt.LockedUntil - attempt.LastFailedAt
+	attempt.LockedUntil = model.GetMillis() - 1
+	if result := <-Srv.Store.LoginAttempt().SaveOrUpdate(attempt); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := AuthenticateUserForLogin(user.Id, "", "wrongpassword", "", "", false, ""); err == nil {
+			t.Fatal("shouldn't have logged in with the wrong password")
+		}
+	}
+
+	if attempt, err := getLoginAttempt(model.LOGIN_ATTEMPT_TYPE_USER, user.Id); err != nil {
+		t.Fatal(err)
+	} else if attempt.Lockouts != 2 || attempt.LockedUntil-attempt.LastFailedAt <= firstDuration {
+		t.Fatal("second lockout should have been longer than the first")
+	}
+
+	if err := recordFailedLoginAttempt(model.LOGIN_ATTEMPT_TYPE_USER, user.Id, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnlockUser(user.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempt, err := getLoginAttempt(model.LOGIN_ATTEMPT_TYPE_USER, user.Id); err != nil {
+		t.Fatal(err)
+	} else if attempt.FailedAttempts != 0 || attempt.IsLocked() {
+		t.Fatal("should have reset the failed login count")
+	}
+
+	if _, err := AuthenticateUserForLogin(user.Id, "", "Password1", "", "", false, ""); err != nil {
+		t.Fatal("should have logged in after being unlocked", err)
+	}
+}
+
+func TestIpAddressLoginLockout(t *testing.T) {
+	th := Setup().InitBasic()
+
+	maxAttempts := *utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIp
+	defer func() {
+		*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIp = maxAttempts
+	}()
+	*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIp = 2
+
+	ipAddress := "192.0.2." + model.NewId()
+
+	if _, err := AuthenticateUserForLogin("", model.NewId(), "wrongpassword", "", "", false, ipAddress); err == nil {
+		t.Fatal("shouldn't have logged in as a user that doesn't exist")
+	}
+
+	if _, err := AuthenticateUserForLogin(th.BasicUser.Id, "", "wrongpassword", "", "", false, ipAddress); err == nil {
+		t.Fatal("shouldn't have logged in with the wrong password")
+	}
+
+	if _, err := AuthenticateUserForLogin(th.BasicUser2.Id, "", "Password1", "", "", false, ipAddress); err == nil {
+		t.Fatal("shouldn't have logged in from a blocked IP address")
+	} else if err.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("should have been blocked", err)
+	}
+
+	if _, err := AuthenticateUserForLogin(th.BasicUser2.Id, "", "Password1", "", "", false, "192.0.2."+model.NewId()); err != nil {
+		t.Fatal("should have logged in from another IP address", err)
+	}
+
+	if err := UnlockIpAddress(ipAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AuthenticateUserForLogin(th.BasicUser2.Id, "", "Password1", "", "", false, ipAddress); err != nil {
+		t.Fatal("should have logged in after the IP address was unlocked", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// The longest log line that can be read, since some messages include entire requests or stack traces
+const LOG_MAX_LINE_LENGTH = 1024 * 1024
+
+// Matches lines written in the default file format of "[%D %T] [%L] %M". Lines written in other formats and the
+// lines after the first one of multi-line messages don't match and are treated as part of the previous message.
+var logLinePattern = regexp.MustCompile(`^\[(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} [^\]]+)\] \[([A-Z]+)\] ?(.*)$`)
+
+var logLevels = map[string]string{
+	"FNST": model.LOG_LEVEL_DEBUG,
+	"FINE": model.LOG_LEVEL_DEBUG,
+	"DEBG": model.LOG_LEVEL_DEBUG,
+	"TRAC": model.LOG_LEVEL_DEBUG,
+	"INFO": model.LOG_LEVEL_INFO,
+	"WARN": model.LOG_LEVEL_WARN,
+	"EROR": model.LOG_LEVEL_ERROR,
+	"CRIT": model.LOG_LEVEL_CRITICAL,
+}
+
+// GetLogPage returns a page of the log entries of the cluster node that the query is for.
+func GetLogPage(query *model.LogQuery) (*model.LogPage, *model.AppError) {
+	if cluster := einterfaces.GetClusterInterface(); cluster != nil && len(query.NodeId) > 0 && query.NodeId != cluster.GetClusterId() {
+		return cluster.GetLogPage(query)
+	}
+
+	return GetLogPageSkipSend(query)
+}
+
+// GetLogPageSkipSend returns a page of the log entries of this server, reading the current log file and then the
+// ones that it has been rotated to until the page is full.
+func GetLogPageSkipSend(query *model.LogQuery) (*model.LogPage, *model.AppError) {
+	page := &model.LogPage{
+		Entries: []*model.LogEntry{},
+	}
+
+	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
+		page.NodeId = cluster.GetClusterId()
+	}
+
+	if !utils.Cfg.LogSettings.EnableFile {
+		return page, nil
+	}
+
+	skipped := 0
+	done := false
+	for _, fileName := range getLogFileNames(utils.GetLogFileLocation(utils.Cfg.LogSettings.FileLocation)) {
+		err := readLogFile(fileName, func(entry *model.LogEntry) bool {
+			// Everything after this in the current file and the older files was written earlier
+			if query.Since > 0 && entry.Timestamp > 0 && entry.Timestamp < query.Since {
+				done = true
+				return false
+			}
+
+			if !query.Matches(entry) {
+				return true
+			}
+
+			if skipped < query.Offset {
+				skipped++
+				return true
+			}
+
+			if len(page.Entries) == query.Limit {
+				page.HasMore = true
+				done = true
+				return false
+			}
+
+			page.Entries = append(page.Entries, entry)
+			return true
+		})
+		if err != nil {
+			return nil, model.NewLocAppError("GetLogPage", "api.admin.file_read_error", nil, err.Error())
+		}
+
+		if done {
+			break
+		}
+	}
+
+	return page, nil
+}
+
+// getLogFileNames returns the log file followed by the ones that it has been rotated to, which are numbered from the
+// newest to the oldest.
+func getLogFileNames(location string) []string {
+	fileNames := []string{location}
+
+	for i := 1; ; i++ {
+		fileName := fmt.Sprintf("%v.%v", location, i)
+		if _, err := os.Stat(fileName); err != nil {
+			break
+		}
+
+		fileNames = append(fileNames, fileName)
+	}
+
+	return fileNames
+}
+
+// readLogFile calls fn with each entry in a log file from the newest to the oldest until it returns false. The file is
+// read backwards from the end so that only as much of it as is needed for a page is read.
+func readLogFile(fileName string, fn func(*model.LogEntry) bool) error {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	return parseLogEntriesReverse(file, info.Size(), fn)
+}
+
+// parseLogEntriesReverse calls fn with each entry in the first size bytes of r from the last to the first until it
+// returns false.
+func parseLogEntriesReverse(r io.ReaderAt, size int64, fn func(*model.LogEntry) bool) error {
+	lines := newReverseLineReader(r, size)
+
+	// the lines that follow the first one of a message, from the last to the first
+	var continuation []string
+
+	for {
+		line, err := lines.ReadLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		match := logLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continuation = append(continuation, line)
+			continue
+		}
+
+		entry := &model.LogEntry{
+			Level:   logLevels[match[2]],
+			Message: match[3],
+		}
+
+		if t, err := time.ParseInLocation("2006/01/02 15:04:05 MST", match[1], time.Local); err == nil {
+			entry.Timestamp = t.UnixNano() / int64(time.Millisecond)
+		}
+
+		for i := len(continuation) - 1; i >= 0; i-- {
+			entry.Message += "\n" + continuation[i]
+		}
+		continuation = continuation[:0]
+
+		if !fn(entry) {
+			return nil
+		}
+	}
+
+	// Any lines at the start of the file that don't belong to a message are kept as one, starting from the first
+	// one that isn't empty
+	for len(continuation) > 0 && len(continuation[len(continuation)-1]) == 0 {
+		continuation = continuation[:len(continuation)-1]
+	}
+
+	if len(continuation) > 0 {
+		message := continuation[len(continuation)-1]
+		for i := len(continuation) - 2; i >= 0; i-- {
+			message += "\n" + continuation[i]
+		}
+
+		fn(&model.LogEntry{Message: message})
+	}
+
+	return nil
+}
+
+// The amount of a log file that's read at a time when reading it backwards
+const LOG_READ_BLOCK_SIZE = 64 * 1024
+
+// reverseLineReader returns the lines of a file from the last to the first, reading it a block at a time from the end.
+type reverseLineReader struct {
+	r      io.ReaderAt
+	offset int64
+
+	// the start of the file's earliest line that's been read, which may not be complete
+	partial []byte
+
+	// the complete lines that have been read but not returned yet, in the order that they're in the file
+	lines [][]byte
+}
+
+func newReverseLineReader(r io.ReaderAt, size int64) *reverseLineReader {
+	return &reverseLineReader{r: r, offset: size}
+}
+
+// ReadLine returns the next line from the end, without its line ending, or io.EOF once every line has been returned.
+func (lr *reverseLineReader) ReadLine() (string, error) {
+	for len(lr.lines) == 0 {
+		if lr.offset == 0 {
+			if lr.partial == nil {
+				return "", io.EOF
+			}
+
+			lr.lines = append(lr.lines, lr.partial)
+			lr.partial = nil
+			break
+		}
+
+		if err := lr.readBlock(); err != nil {
+			return "", err
+		}
+	}
+
+	line := lr.lines[len(lr.lines)-1]
+	lr.lines = lr.lines[:len(lr.lines)-1]
+
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+func (lr *reverseLineReader) readBlock() error {
+	blockSize := int64(LOG_READ_BLOCK_SIZE)
+	if blockSize > lr.offset {
+		blockSize = lr.offset
+	}
+
+	block := make([]byte, blockSize, blockSize+int64(len(lr.partial)))
+	if _, err := lr.r.ReadAt(block, lr.offset-blockSize); err != nil && err != io.EOF {
+		return err
+	}
+
+	atEnd := lr.partial == nil
+	lr.offset -= blockSize
+
+	data := append(block, lr.partial...)
+
+	// a line ending at the end of the file doesn't start another line
+	if atEnd && len(data) > 0 && data[len(data)-1] == '\n' {
+		data = data[:len(data)-1]
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	lr.partial = lines[0]
+	lr.lines = lines[1:]
+
+	if len(lr.partial) > LOG_MAX_LINE_LENGTH {
+		return bufio.ErrTooLong
+	}
+
+	return nil
+}
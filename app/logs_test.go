@@ -0,0 +1,131 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestParseLogEntriesReverse(t *testing.T) {
+	// parseEntries returns every entry in the log from the first to the last
+	parseEntries := func(log string) []*model.LogEntry {
+		var entries []*model.LogEntry
+		if err := parseLogEntriesReverse(strings.NewReader(log), int64(len(log)), func(entry *model.LogEntry) bool {
+			entries = append([]*model.LogEntry{entry}, entries...)
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	entries := parseEntries(`leftover from a custom format
+[2017/04/03 10:20:30 UTC] [INFO] Server is starting
+[2017/04/03 10:20:31 UTC] [EROR] Something went wrong
+goroutine 1 [running]:
+main.main()
+[2017/04/03 10:20:32 UTC] [CRIT] Unable to continue
+`)
+
+	if len(entries) != 4 {
+		t.Fatal("should have found four entries", len(entries))
+	}
+
+	if entries[0].Level != "" || entries[0].Timestamp != 0 || entries[0].Message != "leftover from a custom format" {
+		t.Fatal("should have kept the line that couldn't be parsed", entries[0])
+	}
+
+	if entries[1].Level != model.LOG_LEVEL_INFO || entries[1].Message != "Server is starting" {
+		t.Fatal("incorrect entry", entries[1])
+	}
+
+	if entries[2].Level != model.LOG_LEVEL_ERROR || entries[2].Message != "Something went wrong\ngoroutine 1 [running]:\nmain.main()" {
+		t.Fatal("should have included the stack trace in the message", entries[2].Message)
+	}
+
+	if entries[3].Level != model.LOG_LEVEL_CRITICAL || entries[3].Timestamp-entries[1].Timestamp != 2000 {
+		t.Fatal("incorrect entry", entries[3])
+	}
+
+	// messages longer than the blocks that the file is read in should be put back together
+	long := strings.Repeat("a", LOG_READ_BLOCK_SIZE+10)
+	entries = parseEntries("[2017/04/03 10:20:30 UTC] [INFO] " + long + "\r\n" + long + "\n[2017/04/03 10:20:31 UTC] [INFO] short")
+	if len(entries) != 2 || entries[0].Message != long+"\n"+long || entries[1].Message != "short" {
+		t.Fatal("should have read the entries across blocks", len(entries))
+	}
+
+	read := 0
+	log := "[2017/04/03 10:20:30 UTC] [INFO] one\n[2017/04/03 10:20:31 UTC] [INFO] two\n"
+	if err := parseLogEntriesReverse(strings.NewReader(log), int64(len(log)), func(entry *model.LogEntry) bool {
+		read++
+		return false
+	}); err != nil {
+		t.Fatal(err)
+	} else if read != 1 {
+		t.Fatal("should have stopped after the first entry", read)
+	}
+}
+
+func TestGetLogPageSkipSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	location := filepath.Join(dir, "mattermost.log")
+	ioutil.WriteFile(location+".2", []byte("[2017/04/01 00:00:00 UTC] [INFO] one\n[2017/04/01 00:00:01 UTC] [EROR] two\n"), 0600)
+	ioutil.WriteFile(location+".1", []byte("[2017/04/02 00:00:00 UTC] [INFO] three\n"), 0600)
+	ioutil.WriteFile(location, []byte("[2017/04/03 00:00:00 UTC] [WARN] four\n[2017/04/03 00:00:01 UTC] [DEBG] five\n"), 0600)
+
+	enableFile := utils.Cfg.LogSettings.EnableFile
+	fileLocation := utils.Cfg.LogSettings.FileLocation
+	defer func() {
+		utils.Cfg.LogSettings.EnableFile = enableFile
+		utils.Cfg.LogSettings.FileLocation = fileLocation
+	}()
+	utils.Cfg.LogSettings.EnableFile = true
+	utils.Cfg.LogSettings.FileLocation = location
+
+	messages := func(page *model.LogPage) string {
+		var received []string
+		for _, entry := range page.Entries {
+			received = append(received, entry.Message)
+		}
+		return strings.Join(received, ",")
+	}
+
+	if page, err := GetLogPageSkipSend(&model.LogQuery{Limit: 3}); err != nil {
+		t.Fatal(err)
+	} else if messages(page) != "five,four,three" || !page.HasMore {
+		t.Fatal("should have returned the newest entries", messages(page))
+	}
+
+	if page, err := GetLogPageSkipSend(&model.LogQuery{Offset: 3, Limit: 3}); err != nil {
+		t.Fatal(err)
+	} else if messages(page) != "two,one" || page.HasMore {
+		t.Fatal("should have returned the oldest entries from the rotated files", messages(page))
+	}
+
+	if page, err := GetLogPageSkipSend(&model.LogQuery{Level: model.LOG_LEVEL_WARN, Limit: 10}); err != nil {
+		t.Fatal(err)
+	} else if messages(page) != "four,two" {
+		t.Fatal("should have filtered by level", messages(page))
+	}
+
+	since := time.Date(2017, 4, 2, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	if page, err := GetLogPageSkipSend(&model.LogQuery{Since: since, Until: since + 24*60*60*1000, Limit: 10}); err != nil {
+		t.Fatal(err)
+	} else if messages(page) != "four,three" {
+		t.Fatal("should have filtered by time", messages(page))
+	}
+}
@@ -26,7 +26,7 @@ import (
 
 func SendNotifications(post *model.Post, team *model.Team, channel *model.Channel, sender *model.User) ([]string, *model.AppError) {
 	pchan := Srv.Store.User().GetAllProfilesInChannel(channel.Id, true)
-	fchan := Srv.Store.FileInfo().GetForPost(post.Id)
+	fchan := Srv.Store.FileInfo().GetForPost(post.Id, true)
 
 	var profileMap map[string]*model.User
 	if result := <-pchan; result.Err != nil {
@@ -414,7 +414,7 @@ func GetMessageForNotification(post *model.Post, translateFunc i18n.TranslateFun
 
 	// extract the filenames from their paths and determine what type of files are attached
 	var infos []*model.FileInfo
-	if result := <-Srv.Store.FileInfo().GetForPost(post.Id); result.Err != nil {
+	if result := <-Srv.Store.FileInfo().GetForPost(post.Id, true); result.Err != nil {
 		l4g.Warn(utils.T("api.post.get_message_for_notification.get_files.error"), post.Id, result.Err)
 	} else {
 		infos = result.Data.([]*model.FileInfo)
@@ -448,38 +448,68 @@ func sendPushNotification(post *model.Post, user *model.User, channel *model.Cha
 		return err
 	}
 
-	var channelName string
-
-	if channel.Type == model.CHANNEL_DIRECT {
-		channelName = senderName
-	} else {
-		channelName = channel.DisplayName
-	}
-
-	userLocale := utils.GetUserTranslations(user.Locale)
-
-	msg := model.PushNotification{}
+	msg := getPushNotificationMessage(post, user, channel, senderName, wasMentioned)
 	if badge := <-Srv.Store.User().GetUnreadCount(user.Id); badge.Err != nil {
 		msg.Badge = 1
 		l4g.Error(utils.T("store.sql_user.get_unread_count.app_error"), user.Id, badge.Err)
 	} else {
 		msg.Badge = int(badge.Data.(int64))
 	}
+
+	l4g.Debug(utils.T("api.post.send_notifications_and_forget.push_notification.debug"), msg.DeviceId, msg.Message)
+
+	for _, session := range sessions {
+		tmpMessage := *model.PushNotificationFromJson(strings.NewReader(msg.ToJson()))
+		tmpMessage.SetDeviceIdAndPlatform(session.DeviceId)
+		go sendToPushProxy(tmpMessage)
+
+		if einterfaces.GetMetricsInterface() != nil {
+			einterfaces.GetMetricsInterface().IncrementPostSentPush()
+		}
+	}
+
+	return nil
+}
+
+// getPushNotificationMessage returns the contents of a push notification about a post. How much of the
+// post is included depends on getPushNotificationContents.
+func getPushNotificationMessage(post *model.Post, user *model.User, channel *model.Channel, senderName string, wasMentioned bool) model.PushNotification {
+	userLocale := utils.GetUserTranslations(user.Locale)
+
+	msg := model.PushNotification{}
 	msg.Type = model.PUSH_TYPE_MESSAGE
 	msg.TeamId = channel.TeamId
 	msg.ChannelId = channel.Id
+	msg.PostId = post.Id
+
+	if channel.Type == model.CHANNEL_DIRECT {
+		msg.Category = model.CATEGORY_DM
+	}
+
+	contents := getPushNotificationContents(user)
+	if contents == model.ID_LOADED_NOTIFICATION {
+		// only ids are sent so that the device has to load the post from the server to display it
+		msg.Message = userLocale("api.post.send_notifications_and_forget.push_id_loaded")
+		return msg
+	}
+
 	msg.ChannelName = channel.Name
 
-	if *utils.Cfg.EmailSettings.PushNotificationContents == model.FULL_NOTIFICATION {
+	var channelName string
+	if channel.Type == model.CHANNEL_DIRECT {
+		channelName = senderName
+	} else {
+		channelName = channel.DisplayName
+	}
+
+	if contents == model.FULL_NOTIFICATION {
 		if channel.Type == model.CHANNEL_DIRECT {
-			msg.Category = model.CATEGORY_DM
 			msg.Message = "@" + senderName + ": " + model.ClearMentionTags(post.Message)
 		} else {
 			msg.Message = senderName + userLocale("api.post.send_notifications_and_forget.push_in") + channelName + ": " + model.ClearMentionTags(post.Message)
 		}
 	} else {
 		if channel.Type == model.CHANNEL_DIRECT {
-			msg.Category = model.CATEGORY_DM
 			msg.Message = senderName + userLocale("api.post.send_notifications_and_forget.push_message")
 		} else if wasMentioned {
 			msg.Message = senderName + userLocale("api.post.send_notifications_and_forget.push_mention") + channelName
@@ -488,19 +518,25 @@ func sendPushNotification(post *model.Post, user *model.User, channel *model.Cha
 		}
 	}
 
-	l4g.Debug(utils.T("api.post.send_notifications_and_forget.push_notification.debug"), msg.DeviceId, msg.Message)
+	return msg
+}
 
-	for _, session := range sessions {
-		tmpMessage := *model.PushNotificationFromJson(strings.NewReader(msg.ToJson()))
-		tmpMessage.SetDeviceIdAndPlatform(session.DeviceId)
-		go sendToPushProxy(tmpMessage)
+var pushNotificationContentsDetail = map[string]int{
+	model.ID_LOADED_NOTIFICATION: 0,
+	model.GENERIC_NOTIFICATION:   1,
+	model.FULL_NOTIFICATION:      2,
+}
 
-		if einterfaces.GetMetricsInterface() != nil {
-			einterfaces.GetMetricsInterface().IncrementPostSentPush()
-		}
+// getPushNotificationContents returns how much of a post should be sent in a push notification to a
+// user. A user can choose to receive less than the server's setting but never more.
+func getPushNotificationContents(user *model.User) string {
+	contents := *utils.Cfg.EmailSettings.PushNotificationContents
+
+	if userContents, ok := pushNotificationContentsDetail[user.NotifyProps["push_contents"]]; ok && userContents < pushNotificationContentsDetail[contents] {
+		contents = user.NotifyProps["push_contents"]
 	}
 
-	return nil
+	return contents
 }
 
 func ClearPushNotification(userId string, channelId string) *model.AppError {
@@ -4,9 +4,11 @@
 package app
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 func TestSendNotifications(t *testing.T) {
@@ -311,3 +313,63 @@ func TestGetMentionKeywords(t *testing.T) {
 		t.Fatal("should've mentioned user3 and user4 with @all")
 	}
 }
+
+func TestGetPushNotificationMessage(t *testing.T) {
+	th := Setup().InitBasic()
+
+	contents := *utils.Cfg.EmailSettings.PushNotificationContents
+	defer func() {
+		*utils.Cfg.EmailSettings.PushNotificationContents = contents
+	}()
+
+	post := &model.Post{Id: model.NewId(), ChannelId: th.BasicChannel.Id, Message: "secret message"}
+	user := &model.User{Id: model.NewId(), Locale: "en", NotifyProps: map[string]string{}}
+
+	*utils.Cfg.EmailSettings.PushNotificationContents = model.FULL_NOTIFICATION
+	if msg := getPushNotificationMessage(post, user, th.BasicChannel, "sender", false); !strings.Contains(msg.Message, "secret message") || !strings.Contains(msg.Message, "sender") {
+		t.Fatal("should have sent the full message", msg.Message)
+	}
+
+	*utils.Cfg.EmailSettings.PushNotificationContents = model.GENERIC_NOTIFICATION
+	if msg := getPushNotificationMessage(post, user, th.BasicChannel, "sender", false); strings.Contains(msg.Message, "secret message") || !strings.Contains(msg.Message, "sender") {
+		t.Fatal("should only have sent the sender and channel", msg.Message)
+	}
+
+	*utils.Cfg.EmailSettings.PushNotificationContents = model.ID_LOADED_NOTIFICATION
+	if msg := getPushNotificationMessage(post, user, th.BasicChannel, "sender", false); strings.Contains(msg.Message, "secret message") || strings.Contains(msg.Message, "sender") {
+		t.Fatal("shouldn't have sent any details of the message", msg.Message)
+	} else if msg.PostId != post.Id || msg.ChannelId != th.BasicChannel.Id || msg.ChannelName != "" {
+		t.Fatal("should only have sent the ids needed to load the message")
+	}
+}
+
+func TestGetPushNotificationContents(t *testing.T) {
+	Setup()
+
+	contents := *utils.Cfg.EmailSettings.PushNotificationContents
+	defer func() {
+		*utils.Cfg.EmailSettings.PushNotificationContents = contents
+	}()
+
+	*utils.Cfg.EmailSettings.PushNotificationContents = model.GENERIC_NOTIFICATION
+
+	user := &model.User{NotifyProps: map[string]string{}}
+	if actual := getPushNotificationContents(user); actual != model.GENERIC_NOTIFICATION {
+		t.Fatal("should have used the server's setting", actual)
+	}
+
+	user.NotifyProps["push_contents"] = model.ID_LOADED_NOTIFICATION
+	if actual := getPushNotificationContents(user); actual != model.ID_LOADED_NOTIFICATION {
+		t.Fatal("user should be able to receive less than the server's setting", actual)
+	}
+
+	user.NotifyProps["push_contents"] = model.FULL_NOTIFICATION
+	if actual := getPushNotificationContents(user); actual != model.GENERIC_NOTIFICATION {
+		t.Fatal("user shouldn't be able to receive more than the server's setting", actual)
+	}
+
+	user.NotifyProps["push_contents"] = "junk"
+	if actual := getPushNotificationContents(user); actual != model.GENERIC_NOTIFICATION {
+		t.Fatal("should have ignored an invalid setting", actual)
+	}
+}
@@ -4,9 +4,45 @@
 package app
 
 import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME = "OAuth Revoked Token Cleanup"
+	OAUTH_REVOKED_TOKEN_CLEANUP_INTERVAL  = time.Hour
 )
 
+func StartOAuthRevokedTokenCleanup() {
+	if task := model.GetTaskByName(OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME, cleanupOAuthRevokedTokens, OAUTH_REVOKED_TOKEN_CLEANUP_INTERVAL)
+}
+
+func StopOAuthRevokedTokenCleanup() {
+	if task := model.GetTaskByName(OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	ReleaseLease(OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME)
+}
+
+// cleanupOAuthRevokedTokens removes the revoked refresh tokens that would have expired by now anyway.
+func cleanupOAuthRevokedTokens() {
+	if !AcquireLease(OAUTH_REVOKED_TOKEN_CLEANUP_TASK_NAME, 2*OAUTH_REVOKED_TOKEN_CLEANUP_INTERVAL) {
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().DeleteExpiredRevokedTokens(model.GetMillis()); result.Err != nil {
+		l4g.Error(utils.T("app.oauth.cleanup_revoked_tokens.error"), result.Err)
+	}
+}
+
 func RevokeAccessToken(token string) *model.AppError {
 
 	session, _ := GetSession(token)
@@ -32,3 +68,52 @@ func RevokeAccessToken(token string) *model.AppError {
 
 	return nil
 }
+
+// RevokeAccessData revokes an app's access on behalf of a user by removing the access token's
+// session and adding the refresh token to the revocation list.
+func RevokeAccessData(accessData *model.AccessData) *model.AppError {
+	if len(accessData.RefreshToken) > 0 {
+		revoked := &model.OAuthRevokedToken{Token: accessData.RefreshToken, ClientId: accessData.ClientId, UserId: accessData.UserId, ExpiresAt: accessData.ExpiresAt}
+		if result := <-Srv.Store.OAuth().SaveRevokedToken(revoked); result.Err != nil && result.Err.Id != "store.sql_oauth.save_revoked_token.exists.app_error" {
+			return result.Err
+		}
+	}
+
+	return RevokeAccessToken(accessData.Token)
+}
+
+func RevokeAccessDataByUserForApp(userId, clientId string) *model.AppError {
+	if result := <-Srv.Store.OAuth().GetAccessDataByUserForApp(userId, clientId); result.Err != nil {
+		return result.Err
+	} else {
+		return revokeAllAccessData(result.Data.([]*model.AccessData))
+	}
+}
+
+// RevokeAllAccessDataForApp logs every user out of an app, forcing them to authorize it again.
+func RevokeAllAccessDataForApp(clientId string) *model.AppError {
+	if result := <-Srv.Store.OAuth().GetAccessDataForApp(clientId); result.Err != nil {
+		return result.Err
+	} else {
+		return revokeAllAccessData(result.Data.([]*model.AccessData))
+	}
+}
+
+// RevokeAllAccessDataForUser logs a user out of every app that they've authorized.
+func RevokeAllAccessDataForUser(userId string) *model.AppError {
+	if result := <-Srv.Store.OAuth().GetAccessDataByUser(userId); result.Err != nil {
+		return result.Err
+	} else {
+		return revokeAllAccessData(result.Data.([]*model.AccessData))
+	}
+}
+
+func revokeAllAccessData(accessData []*model.AccessData) *model.AppError {
+	for _, data := range accessData {
+		if err := RevokeAccessData(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_PDF_PREVIEW, runPdfPreviewJob)
+}
+
+// CreatePdfPreviewJob queues a job to render the first page of an uploaded pdf as its thumbnail
+// and preview image.
+func CreatePdfPreviewJob(fileId string) (*model.Job, *model.AppError) {
+	return CreateJob(model.JOB_TYPE_PDF_PREVIEW, map[string]string{"file_id": fileId})
+}
+
+func runPdfPreviewJob(job *model.Job) *model.AppError {
+	renderer := einterfaces.GetPdfRendererInterface()
+	if renderer == nil {
+		// there's nothing that can render this file
+		return nil
+	}
+
+	var info *model.FileInfo
+	if result := <-Srv.Store.FileInfo().Get(job.Data["file_id"]); result.Err != nil {
+		return result.Err
+	} else {
+		info = result.Data.(*model.FileInfo)
+	}
+
+	data, err := ReadFile(info.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := SetJobProgress(job, 50); err != nil {
+		return err
+	}
+
+	page, err := renderer.RenderFirstPage(data)
+	if err != nil {
+		return err
+	}
+
+	generateFilePreviewFromImage(info, page)
+
+	if result := <-Srv.Store.FileInfo().UpdatePreview(info); result.Err != nil {
+		return result.Err
+	} else {
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_UPDATED, result.Data.(*model.FileInfo), "")
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+type fakePdfRenderer struct{}
+
+func (fakePdfRenderer) RenderFirstPage(data []byte) (image.Image, *model.AppError) {
+	page := image.NewRGBA(image.Rect(0, 0, 612, 792))
+	for x := 0; x < 612; x++ {
+		page.Set(x, x, color.Black)
+	}
+
+	return page, nil
+}
+
+func TestPdfPreview(t *testing.T) {
+	Setup()
+
+	dir, err := ioutil.TempDir("", "pdf_preview")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+		einterfaces.RegisterPdfRendererInterface(nil)
+	}()
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = dir + "/"
+
+	info, appErr := DoUploadFile(model.NewId(), model.NewId(), model.NewId(), "document.pdf", []byte("%PDF-1.4"))
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	defer func() {
+		<-Srv.Store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	if !info.IsPdf() {
+		t.Fatal("should have been detected as a pdf")
+	} else if info.HasPreviewImage || info.ThumbnailPath != "" {
+		t.Fatal("shouldn't have a preview yet")
+	}
+
+	// the file's post is cached before the preview is generated
+	postId := model.NewId()
+	store.Must(Srv.Store.FileInfo().AttachToPost(info.Id, postId))
+	store.Must(Srv.Store.FileInfo().GetForPost(postId, true))
+
+	einterfaces.RegisterPdfRendererInterface(fakePdfRenderer{})
+
+	job, appErr := CreatePdfPreviewJob(info.Id)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if !RunJobNow(job) {
+		t.Fatal("should have run the job")
+	}
+
+	if job, appErr = GetJob(job.Id); appErr != nil {
+		t.Fatal(appErr)
+	} else if job.Status != model.JOB_STATUS_SUCCESS {
+		t.Fatal("job should have succeeded", job.Data["error"])
+	}
+
+	info = store.Must(Srv.Store.FileInfo().Get(info.Id)).(*model.FileInfo)
+	if !info.HasPreviewImage {
+		t.Fatal("should have a preview")
+	} else if info.PostId != postId {
+		t.Fatal("shouldn't have detached the file from its post")
+	}
+
+	if infos := store.Must(Srv.Store.FileInfo().GetForPost(postId, true)).([]*model.FileInfo); len(infos) != 1 || !infos[0].HasPreviewImage {
+		t.Fatal("should have invalidated the cached files of the post")
+	}
+
+	if _, appErr := ReadFile(info.ThumbnailPath); appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if _, appErr := ReadFile(info.PreviewPath); appErr != nil {
+		t.Fatal(appErr)
+	}
+}
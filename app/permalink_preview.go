@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// savePermalinkPreviews saves previews of the posts that a post links to so that they don't need to be looked up
+// every time that it's shown. Only posts that the author of the post can read are previewed.
+func savePermalinkPreviews(post *model.Post) {
+	postIds := model.ParsePermalinkPostIds(post.Message, *utils.Cfg.ServiceSettings.SiteURL)
+
+	// posts that haven't been edited don't have any previews to replace
+	if len(postIds) == 0 && post.EditAt == 0 {
+		return
+	}
+
+	previews := []*model.PermalinkPreview{}
+	for _, postId := range postIds {
+		if postId == post.Id {
+			continue
+		}
+
+		var linked *model.Post
+		if result := <-Srv.Store.Post().GetSingle(postId); result.Err != nil {
+			continue
+		} else {
+			linked = result.Data.(*model.Post)
+		}
+
+		if linked.IsSystemMessage() || !HasPermissionToChannel(post.UserId, linked.ChannelId, model.PERMISSION_READ_CHANNEL) {
+			continue
+		}
+
+		previews = append(previews, model.NewPermalinkPreview(post.Id, linked))
+	}
+
+	if result := <-Srv.Store.PermalinkPreview().SaveForPost(post.Id, previews); result.Err != nil {
+		l4g.Warn(utils.T("app.permalink_preview.save.warn"), post.Id, result.Err)
+	}
+}
+
+// updatePermalinkPreviews refreshes the previews shown alongside an edited post along with the previews of it that
+// are shown alongside other posts.
+func updatePermalinkPreviews(post *model.Post) {
+	savePermalinkPreviews(post)
+
+	if result := <-Srv.Store.PermalinkPreview().UpdateForLinkedPost(post); result.Err != nil {
+		l4g.Warn(utils.T("app.permalink_preview.update.warn"), post.Id, result.Err)
+	}
+}
+
+// AddPermalinkPreviewsToPostList returns a copy of a list of posts with the previews of the posts that they link to
+// filled in, leaving out the ones in channels that the given user can't read. The posts in the list may be shared with
+// the post cache, so they're copied rather than changed.
+func AddPermalinkPreviewsToPostList(list *model.PostList, userId string) *model.PostList {
+	if len(list.Posts) == 0 {
+		return list
+	}
+
+	postIds := make([]string, 0, len(list.Posts))
+	for postId := range list.Posts {
+		postIds = append(postIds, postId)
+	}
+
+	var previews []*model.PermalinkPreview
+	if result := <-Srv.Store.PermalinkPreview().GetForPosts(postIds); result.Err != nil {
+		l4g.Warn(utils.T("app.permalink_preview.get.warn"), result.Err)
+		return list
+	} else {
+		previews = result.Data.([]*model.PermalinkPreview)
+	}
+
+	if len(previews) == 0 {
+		return list
+	}
+
+	canRead := map[string]bool{}
+	previewsByPost := map[string][]*model.PermalinkPreview{}
+	for _, preview := range previews {
+		readable, ok := canRead[preview.LinkedChannelId]
+		if !ok {
+			readable = HasPermissionToChannel(userId, preview.LinkedChannelId, model.PERMISSION_READ_CHANNEL)
+			canRead[preview.LinkedChannelId] = readable
+		}
+
+		if readable {
+			previewsByPost[preview.PostId] = append(previewsByPost[preview.PostId], preview)
+		}
+	}
+
+	withPreviews := &model.PostList{
+		Order:   list.Order,
+		Posts:   make(map[string]*model.Post, len(list.Posts)),
+		Matches: list.Matches,
+	}
+
+	for postId, post := range list.Posts {
+		if postPreviews, ok := previewsByPost[postId]; ok {
+			copied := *post
+			copied.PermalinkPreviews = postPreviews
+			post = &copied
+		}
+
+		withPreviews.Posts[postId] = post
+	}
+
+	return withPreviews
+}
+
+// DeletePermalinkPreviews removes the previews shown alongside a post that's been deleted along with the previews of
+// it shown alongside other posts.
+func DeletePermalinkPreviews(postId string) {
+	if result := <-Srv.Store.PermalinkPreview().DeleteForPost(postId); result.Err != nil {
+		l4g.Warn(utils.T("app.permalink_preview.delete.warn"), postId, result.Err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestPermalinkPreviews(t *testing.T) {
+	th := Setup().InitBasic()
+
+	siteURL := *utils.Cfg.ServiceSettings.SiteURL
+	defer func() {
+		*utils.Cfg.ServiceSettings.SiteURL = siteURL
+	}()
+	*utils.Cfg.ServiceSettings.SiteURL = "http://localhost:8065"
+
+	if _, err := AddUserToChannel(th.BasicUser2, th.BasicChannel); err != nil {
+		t.Fatal(err)
+	}
+
+	linked := th.BasicPost
+	otherChannel := th.CreateChannel(th.BasicTeam)
+	hidden := th.CreatePost(otherChannel)
+
+	permalink := func(post *model.Post) string {
+		return "http://localhost:8065/" + th.BasicTeam.Name + "/pl/" + post.Id
+	}
+
+	getPermalinkPreviews := func(post *model.Post, userId string) []*model.PermalinkPreview {
+		list := &model.PostList{Order: []string{post.Id}, Posts: map[string]*model.Post{post.Id: post}}
+		return AddPermalinkPreviewsToPostList(list, userId).Posts[post.Id].PermalinkPreviews
+	}
+
+	post, err := CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "see " + permalink(linked) + " and " + permalink(hidden)}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if previews := getPermalinkPreviews(post, th.BasicUser.Id); len(previews) != 2 {
+		t.Fatal("should have previewed both linked posts", len(previews))
+	} else if previews[0].LinkedPostId != linked.Id || previews[0].LinkedUserId != linked.UserId || previews[0].Message != linked.Message || previews[0].LinkedCreateAt != linked.CreateAt {
+		t.Fatal("should have previewed the linked post")
+	}
+
+	if previews := getPermalinkPreviews(post, th.BasicUser2.Id); len(previews) != 1 || previews[0].LinkedPostId != linked.Id {
+		t.Fatal("shouldn't have shown the preview of a post in a channel the user isn't in")
+	}
+
+	if post.PermalinkPreviews != nil {
+		t.Fatal("shouldn't have changed the post in the list")
+	}
+
+	// users can't see previews of posts that they can't read by linking to them
+	if other, err := CreatePost(&model.Post{UserId: th.BasicUser2.Id, ChannelId: th.BasicChannel.Id, Message: permalink(hidden)}, th.BasicTeam.Id, false); err != nil {
+		t.Fatal(err)
+	} else if previews := getPermalinkPreviews(other, th.BasicUser.Id); len(previews) != 0 {
+		t.Fatal("shouldn't have previewed a post that the author can't read")
+	}
+
+	linked.Message = "edited"
+	if _, err := UpdatePost(linked); err != nil {
+		t.Fatal(err)
+	}
+
+	if previews := getPermalinkPreviews(post, th.BasicUser.Id); len(previews) != 2 || previews[0].Message != "edited" {
+		t.Fatal("should have updated the preview of the edited post")
+	}
+
+	post.Message = "see " + permalink(linked)
+	if _, err := UpdatePost(post); err != nil {
+		t.Fatal(err)
+	}
+
+	if previews := getPermalinkPreviews(post, th.BasicUser.Id); len(previews) != 1 || previews[0].LinkedPostId != linked.Id {
+		t.Fatal("should have removed the preview of the post that's no longer linked")
+	}
+
+	// the previews are deleted in the background
+	DeletePermalinkPreviews(linked.Id)
+
+	if previews := getPermalinkPreviews(post, th.BasicUser.Id); len(previews) != 0 {
+		t.Fatal("should have removed the preview of the deleted post")
+	}
+}
@@ -54,6 +54,7 @@ func CreatePostAsUser(post *model.Post, teamId string) (*model.Post, *model.AppE
 }
 
 func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.Post, *model.AppError) {
+	var rootPost *model.Post
 	var pchan store.StoreChannel
 	if len(post.RootId) > 0 {
 		pchan = Srv.Store.Post().Get(post.RootId)
@@ -69,6 +70,8 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 				return nil, model.NewLocAppError("createPost", "api.post.create_post.channel_root_id.app_error", nil, "")
 			}
 
+			rootPost = list.Posts[post.RootId]
+
 			if post.ParentId == "" {
 				post.ParentId = post.RootId
 			}
@@ -95,6 +98,15 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		einterfaces.GetMetricsInterface().IncrementPostCreate()
 	}
 
+	UserStoppedTyping(rpost.ChannelId, rpost.ParentId, rpost.UserId)
+
+	if rootPost != nil {
+		updateThreadMemberships(rpost, rootPost)
+	}
+
+	savePermalinkPreviews(rpost)
+
+	var attachedInfos []*model.FileInfo
 	if len(post.FileIds) > 0 {
 		// There's a rare bug where the client sends up duplicate FileIds so protect against that
 		post.FileIds = utils.RemoveDuplicatesFromStringArray(post.FileIds)
@@ -102,9 +114,13 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		for _, fileId := range post.FileIds {
 			if result := <-Srv.Store.FileInfo().AttachToPost(fileId, post.Id); result.Err != nil {
 				l4g.Error(utils.T("api.post.create_post.attach_files.error"), post.Id, post.FileIds, post.UserId, result.Err)
+			} else {
+				attachedInfos = append(attachedInfos, result.Data.(*model.FileInfo))
 			}
 		}
 
+		InvalidateCacheForFileInfosForPost(post.Id)
+
 		if einterfaces.GetMetricsInterface() != nil {
 			einterfaces.GetMetricsInterface().IncrementPostFileAttachment(len(post.FileIds))
 		}
@@ -114,6 +130,10 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		return nil, err
 	}
 
+	for _, info := range attachedInfos {
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_ATTACHED, info, rpost.ChannelId)
+	}
+
 	return rpost, nil
 }
 
@@ -295,6 +315,8 @@ func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
 	} else {
 		rpost := result.Data.(*model.Post)
 
+		updatePermalinkPreviews(rpost)
+
 		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_EDITED, "", rpost.ChannelId, "", nil)
 		message.Add("post", rpost.ToJson())
 
@@ -306,8 +328,10 @@ func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
 	}
 }
 
-func GetPosts(channelId string, offset int, limit int) (*model.PostList, *model.AppError) {
-	if result := <-Srv.Store.Post().GetPosts(channelId, offset, limit, true); result.Err != nil {
+// GetPosts returns the most recent posts in a channel. Use GetPostsAroundPost with the earliest of them to get the
+// ones before.
+func GetPosts(channelId string, limit int) (*model.PostList, *model.AppError) {
+	if result := <-Srv.Store.Post().GetPosts(channelId, limit, true); result.Err != nil {
 		return nil, result.Err
 	} else {
 		return result.Data.(*model.PostList), nil
@@ -375,12 +399,14 @@ func GetPermalinkPost(postId string, userId string) (*model.PostList, *model.App
 	}
 }
 
-func GetPostsAroundPost(postId, channelId string, offset, limit int, before bool) (*model.PostList, *model.AppError) {
+// GetPostsAroundPost returns the posts created before or after the given one, which is used as the cursor to page
+// through a channel's history.
+func GetPostsAroundPost(postId, channelId string, limit int, before bool) (*model.PostList, *model.AppError) {
 	var pchan store.StoreChannel
 	if before {
-		pchan = Srv.Store.Post().GetPostsBefore(channelId, postId, limit, offset)
+		pchan = Srv.Store.Post().GetPostsBefore(channelId, postId, limit)
 	} else {
-		pchan = Srv.Store.Post().GetPostsAfter(channelId, postId, limit, offset)
+		pchan = Srv.Store.Post().GetPostsAfter(channelId, postId, limit)
 	}
 
 	if result := <-pchan; result.Err != nil {
@@ -406,6 +432,12 @@ func DeletePost(postId string) (*model.Post, *model.AppError) {
 		go Publish(message)
 		go DeletePostFiles(post)
 		go DeleteFlaggedPosts(post.Id)
+		go DeletePermalinkPreviews(post.Id)
+		go DeletePostTranslations(post.Id)
+
+		if post.RootId == "" {
+			go DeleteThreadMemberships(post.Id)
+		}
 
 		InvalidateCacheForChannelPosts(post.ChannelId)
 
@@ -420,13 +452,30 @@ func DeleteFlaggedPosts(postId string) {
 	}
 }
 
+// DeletePostFiles deletes the files attached to a post and tells the clients in its channel about each of them. The
+// attached files are looked up instead of using FileIds since that isn't kept up to date for every post.
 func DeletePostFiles(post *model.Post) {
-	if len(post.FileIds) != 0 {
+	var infos []*model.FileInfo
+	if result := <-Srv.Store.FileInfo().GetForPost(post.Id, false); result.Err != nil {
+		l4g.Warn(utils.T("api.post.delete_post_files.app_error.warn"), post.Id, result.Err)
+		return
+	} else {
+		infos = result.Data.([]*model.FileInfo)
+	}
+
+	if len(infos) == 0 {
 		return
 	}
 
 	if result := <-Srv.Store.FileInfo().DeleteForPost(post.Id); result.Err != nil {
 		l4g.Warn(utils.T("api.post.delete_post_files.app_error.warn"), post.Id, result.Err)
+		return
+	}
+
+	InvalidateCacheForFileInfosForPost(post.Id)
+
+	for _, info := range infos {
+		sendFileInfoEvent(model.WEBSOCKET_EVENT_FILE_DELETED, info, post.ChannelId)
 	}
 }
 
@@ -457,7 +506,7 @@ func SearchPostsInTeam(terms string, userId string, teamId string, isOrSearch bo
 
 func GetFileInfosForPost(postId string) ([]*model.FileInfo, *model.AppError) {
 	pchan := Srv.Store.Post().Get(postId)
-	fchan := Srv.Store.FileInfo().GetForPost(postId)
+	fchan := Srv.Store.FileInfo().GetForPost(postId, true)
 
 	var infos []*model.FileInfo
 	if result := <-fchan; result.Err != nil {
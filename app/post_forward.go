@@ -0,0 +1,102 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+// ForwardPost posts a copy of an existing post to another channel on behalf of the given user. The copy records where
+// the original was posted, or where it was first posted if it had already been forwarded. Its attachments are new
+// FileInfos that point to the same files as the original's, so that they're visible in the new channel without
+// uploading them again, and any restrictions on who can access them are copied along with them.
+func ForwardPost(postId string, userId string, channelId string, teamId string) (*model.Post, *model.AppError) {
+	original, err := GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.IsSystemMessage() {
+		return nil, model.NewAppError("ForwardPost", "app.post_forward.system_message.app_error", nil, "post_id="+postId, http.StatusBadRequest)
+	}
+
+	if original.ChannelId == channelId {
+		return nil, model.NewAppError("ForwardPost", "app.post_forward.same_channel.app_error", nil, "post_id="+postId, http.StatusBadRequest)
+	}
+
+	if channel, err := GetChannel(channelId); err != nil {
+		return nil, err
+	} else if len(channel.TeamId) > 0 {
+		teamId = channel.TeamId
+	}
+
+	post := &model.Post{
+		ChannelId: channelId,
+		UserId:    userId,
+		Message:   original.Message,
+	}
+
+	if original.IsForwarded() {
+		for _, key := range []string{model.POST_PROPS_FORWARDED_POST_ID, model.POST_PROPS_FORWARDED_CHANNEL_ID, model.POST_PROPS_FORWARDED_USER_ID, model.POST_PROPS_FORWARDED_CREATE_AT} {
+			post.AddProp(key, original.Props[key])
+		}
+	} else {
+		post.AddProp(model.POST_PROPS_FORWARDED_POST_ID, original.Id)
+		post.AddProp(model.POST_PROPS_FORWARDED_CHANNEL_ID, original.ChannelId)
+		post.AddProp(model.POST_PROPS_FORWARDED_USER_ID, original.UserId)
+		post.AddProp(model.POST_PROPS_FORWARDED_CREATE_AT, original.CreateAt)
+	}
+
+	if attachments, ok := original.Props["attachments"]; ok {
+		post.AddProp("attachments", attachments)
+	}
+
+	if len(original.FileIds) > 0 {
+		if post.FileIds, err = copyFileInfosForForward(original.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return CreatePostAsUser(post, teamId)
+}
+
+// copyFileInfosForForward saves unattached copies of a post's FileInfos and returns their ids so that they can be
+// attached to the forwarded post. The copies keep the CreateAt of the originals so that data retention can tell when
+// the files that they share are no longer used by any of them.
+func copyFileInfosForForward(postId string) ([]string, *model.AppError) {
+	infos, err := GetFileInfosForPost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	fileIds := make([]string, 0, len(infos))
+	for _, info := range infos {
+		acl, err := GetFileAcl(info.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		copied := *info
+		copied.Id = ""
+		copied.PostId = ""
+
+		if result := <-Srv.Store.FileInfo().Save(&copied); result.Err != nil {
+			return nil, result.Err
+		}
+
+		if acl != nil {
+			acl.FileId = copied.Id
+			acl.CreateAt = 0
+			if _, err := UpdateFileAcl(acl); err != nil {
+				return nil, err
+			}
+		}
+
+		fileIds = append(fileIds, copied.Id)
+	}
+
+	return fileIds, nil
+}
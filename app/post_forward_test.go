@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestForwardPost(t *testing.T) {
+	th := Setup().InitBasic()
+	otherChannel := th.CreateChannel(th.BasicTeam)
+
+	info := store.Must(Srv.Store.FileInfo().Save(&model.FileInfo{
+		CreatorId: th.BasicUser.Id,
+		Path:      "forward/" + model.NewId() + "/file.txt",
+		Name:      "file.txt",
+	})).(*model.FileInfo)
+
+	if _, err := UpdateFileAcl(&model.FileAcl{FileId: info.Id, UserIds: model.StringArray{th.BasicUser2.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "original", FileIds: model.StringArray{info.Id}}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwarded, err := ForwardPost(original.Id, th.BasicUser2.Id, otherChannel.Id, th.BasicTeam.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forwarded.ChannelId != otherChannel.Id || forwarded.UserId != th.BasicUser2.Id || forwarded.Message != original.Message {
+		t.Fatal("should have posted a copy of the post in the other channel")
+	} else if forwarded.Props[model.POST_PROPS_FORWARDED_POST_ID] != original.Id || forwarded.Props[model.POST_PROPS_FORWARDED_CHANNEL_ID] != original.ChannelId || forwarded.Props[model.POST_PROPS_FORWARDED_USER_ID] != original.UserId {
+		t.Fatal("should have recorded where the post came from")
+	}
+
+	if infos, err := GetFileInfosForPost(forwarded.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 {
+		t.Fatal("should have attached the file to the copy")
+	} else if infos[0].Id == info.Id || infos[0].Path != info.Path || infos[0].CreateAt != info.CreateAt {
+		t.Fatal("should have referenced the original file")
+	} else if acl, err := GetFileAcl(infos[0].Id); err != nil {
+		t.Fatal(err)
+	} else if acl == nil || !acl.AllowsUser(th.BasicUser2.Id) {
+		t.Fatal("should have kept the restrictions on the file")
+	}
+
+	if infos, err := GetFileInfosForPost(original.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != info.Id {
+		t.Fatal("shouldn't have changed the original's attachments")
+	}
+
+	// forwarding a forwarded post keeps pointing to where it was first posted
+	if again, err := ForwardPost(forwarded.Id, th.BasicUser.Id, th.BasicChannel.Id, th.BasicTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if again.Props[model.POST_PROPS_FORWARDED_POST_ID] != original.Id || again.Props[model.POST_PROPS_FORWARDED_CHANNEL_ID] != original.ChannelId {
+		t.Fatal("should have kept the original post's details")
+	}
+
+	if _, err := ForwardPost(original.Id, th.BasicUser.Id, th.BasicChannel.Id, th.BasicTeam.Id); err == nil {
+		t.Fatal("shouldn't be able to forward a post to its own channel")
+	}
+
+	systemPost, err := CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "joined", Type: model.POST_JOIN_CHANNEL}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ForwardPost(systemPost.Id, th.BasicUser.Id, otherChannel.Id, th.BasicTeam.Id); err == nil {
+		t.Fatal("shouldn't be able to forward a system message")
+	}
+}
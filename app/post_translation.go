@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"unicode/utf8"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// TranslatePost returns a post's message translated into the given locale by the translation provider. Translations
+// are saved so that the provider is only asked once for each locale until the post is edited.
+func TranslatePost(post *model.Post, locale string) (*model.PostTranslation, *model.AppError) {
+	translator := einterfaces.GetTranslationInterface()
+	if translator == nil {
+		return nil, model.NewAppError("TranslatePost", "app.post_translation.not_available.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if result := <-Srv.Store.PostTranslation().Get(post.Id, locale); result.Err == nil {
+		if translation := result.Data.(*model.PostTranslation); translation.PostEditAt == post.EditAt {
+			return translation, nil
+		}
+	}
+
+	translation := &model.PostTranslation{
+		PostId:     post.Id,
+		Locale:     locale,
+		PostEditAt: post.EditAt,
+	}
+
+	if len(post.Message) > 0 {
+		message, sourceLocale, err := translator.Translate(post.Message, locale)
+		if err != nil {
+			return nil, err
+		}
+
+		if utf8.RuneCountInString(message) > model.POST_MESSAGE_MAX_RUNES {
+			message = string([]rune(message)[:model.POST_MESSAGE_MAX_RUNES])
+		}
+
+		translation.Message = message
+		translation.SourceLocale = sourceLocale
+	}
+
+	if result := <-Srv.Store.PostTranslation().Save(translation); result.Err != nil {
+		// the translation can still be returned, it'll just be requested again next time
+		l4g.Warn(utils.T("app.post_translation.save.warn"), post.Id, locale, result.Err)
+	}
+
+	return translation, nil
+}
+
+// DeletePostTranslations removes the saved translations of a post that's been deleted.
+func DeletePostTranslations(postId string) {
+	if result := <-Srv.Store.PostTranslation().DeleteForPost(postId); result.Err != nil {
+		l4g.Warn(utils.T("app.post_translation.delete.warn"), postId, result.Err)
+	}
+}
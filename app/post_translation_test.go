@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+)
+
+type testTranslator struct {
+	calls int
+}
+
+func (t *testTranslator) Translate(text string, locale string) (string, string, *model.AppError) {
+	t.calls++
+	return locale + ":" + text, "en", nil
+}
+
+func TestTranslatePost(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if _, err := TranslatePost(th.BasicPost, "fr"); err == nil || err.StatusCode != http.StatusNotImplemented {
+		t.Fatal("shouldn't be able to translate without a provider")
+	}
+
+	translator := &testTranslator{}
+	einterfaces.RegisterTranslationInterface(translator)
+	defer einterfaces.RegisterTranslationInterface(nil)
+
+	post := th.BasicPost
+
+	if translation, err := TranslatePost(post, "fr"); err != nil {
+		t.Fatal(err)
+	} else if translation.Message != "fr:"+post.Message || translation.SourceLocale != "en" {
+		t.Fatal("wrong translation " + translation.Message)
+	}
+
+	if translation, err := TranslatePost(post, "fr"); err != nil {
+		t.Fatal(err)
+	} else if translation.Message != "fr:"+post.Message || translator.calls != 1 {
+		t.Fatal("should have reused the saved translation")
+	}
+
+	if _, err := TranslatePost(post, "de"); err != nil {
+		t.Fatal(err)
+	} else if translator.calls != 2 {
+		t.Fatal("should have translated into another locale")
+	}
+
+	post.Message = "edited"
+	post.EditAt = model.GetMillis()
+
+	if translation, err := TranslatePost(post, "fr"); err != nil {
+		t.Fatal(err)
+	} else if translation.Message != "fr:edited" || translator.calls != 3 {
+		t.Fatal("should have translated the post again after it was edited")
+	}
+
+	if _, err := DeletePost(post.Id); err != nil {
+		t.Fatal(err)
+	}
+	DeletePostTranslations(post.Id)
+
+	if result := <-Srv.Store.PostTranslation().Get(post.Id, "de"); result.Err == nil {
+		t.Fatal("should have deleted the translations")
+	}
+}
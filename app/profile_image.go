@@ -0,0 +1,225 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/disintegration/imaging"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// saveProfileImage stores a new profile picture for a user as a FileInfo, along with a thumbnail of it, and makes it
+// their current one. Their oldest pictures are removed once they have more than the configured number of them.
+func saveProfileImage(userId string, img image.Image) (*model.ProfileImage, *model.AppError) {
+	profile := imaging.Resize(img, utils.Cfg.FileSettings.ProfileWidth, utils.Cfg.FileSettings.ProfileHeight, imaging.Lanczos)
+	thumbnail := imaging.Resize(profile, model.PROFILE_IMAGE_THUMBNAIL_SIZE, 0, imaging.Lanczos)
+
+	info := &model.FileInfo{
+		Id:        model.NewId(),
+		CreatorId: userId,
+		Name:      "profile.png",
+		Extension: "png",
+		MimeType:  "image/png",
+		Width:     profile.Bounds().Dx(),
+		Height:    profile.Bounds().Dy(),
+	}
+
+	pathPrefix := "users/" + userId + "/profile/" + info.Id + "/"
+	info.Path = pathPrefix + "profile.png"
+	info.ThumbnailPath = pathPrefix + "profile_thumb.png"
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, profile); err != nil {
+		return nil, model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.encode.app_error", nil, err.Error())
+	}
+	info.Size = int64(buf.Len())
+	info.Checksum = model.GetFileChecksum(buf.Bytes())
+
+	if err := WriteFile(buf.Bytes(), info.Path); err != nil {
+		return nil, model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.upload_profile.app_error", nil, err.Error())
+	}
+
+	buf = new(bytes.Buffer)
+	if err := png.Encode(buf, thumbnail); err != nil {
+		return nil, model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.encode.app_error", nil, err.Error())
+	}
+
+	if err := WriteFile(buf.Bytes(), info.ThumbnailPath); err != nil {
+		return nil, model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.upload_profile.app_error", nil, err.Error())
+	}
+
+	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+		return nil, result.Err
+	}
+
+	var profileImage *model.ProfileImage
+	if result := <-Srv.Store.ProfileImage().Save(&model.ProfileImage{FileId: info.Id, UserId: userId}); result.Err != nil {
+		return nil, result.Err
+	} else {
+		profileImage = result.Data.(*model.ProfileImage)
+	}
+
+	if err := deleteProfileImages(userId, *utils.Cfg.FileSettings.MaxProfileImageVersions); err != nil {
+		l4g.Warn(utils.T("app.profile_image.prune.warn"), userId, err.Error())
+	}
+
+	return profileImage, nil
+}
+
+// GetProfileImageHistory returns the profile pictures that a user has uploaded and can switch back to, starting with
+// their current one.
+func GetProfileImageHistory(userId string) ([]*model.ProfileImage, *model.AppError) {
+	if result := <-Srv.Store.ProfileImage().GetForUser(userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.ProfileImage), nil
+	}
+}
+
+// RevertProfileImage makes one of the profile pictures that a user uploaded before their current one again.
+func RevertProfileImage(userId string, fileId string) *model.AppError {
+	if result := <-Srv.Store.ProfileImage().Get(fileId); result.Err != nil {
+		return result.Err
+	} else if result.Data.(*model.ProfileImage).UserId != userId {
+		return model.NewAppError("RevertProfileImage", "app.profile_image.revert.user.app_error", nil, "user_id="+userId+", file_id="+fileId, http.StatusNotFound)
+	}
+
+	if result := <-Srv.Store.ProfileImage().Activate(fileId, model.GetMillis()); result.Err != nil {
+		return result.Err
+	}
+
+	profileImageChanged(userId)
+
+	return nil
+}
+
+// getProfileImageInfo returns the FileInfo of a user's current profile picture, or nil if they haven't uploaded one
+// since profile pictures started being stored as files.
+func getProfileImageInfo(userId string) (*model.FileInfo, *model.AppError) {
+	var images []*model.ProfileImage
+	if result := <-Srv.Store.ProfileImage().GetForUser(userId); result.Err != nil {
+		return nil, result.Err
+	} else if images = result.Data.([]*model.ProfileImage); len(images) == 0 {
+		return nil, nil
+	}
+
+	if result := <-Srv.Store.FileInfo().Get(images[0].FileId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.FileInfo), nil
+	}
+}
+
+// GetProfileImageThumbnail returns a smaller version of a user's profile picture. Pictures that were uploaded before
+// thumbnails were stored for them, along with the ones generated for users without a picture, are resized as needed.
+func GetProfileImageThumbnail(user *model.User) ([]byte, *model.AppError) {
+	if len(utils.Cfg.FileSettings.DriverName) != 0 {
+		if info, err := getProfileImageInfo(user.Id); err != nil {
+			return nil, err
+		} else if info != nil && info.ThumbnailPath != "" {
+			return ReadFile(info.ThumbnailPath)
+		}
+	}
+
+	data, err := GetProfileImage(user)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, decodeErr := image.Decode(bytes.NewReader(data))
+	if decodeErr != nil {
+		return nil, model.NewLocAppError("GetProfileImageThumbnail", "api.user.upload_profile_user.decode.app_error", nil, decodeErr.Error())
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, imaging.Resize(img, model.PROFILE_IMAGE_THUMBNAIL_SIZE, 0, imaging.Lanczos)); err != nil {
+		return nil, model.NewLocAppError("GetProfileImageThumbnail", "api.user.upload_profile_user.encode.app_error", nil, err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// deleteProfileImages removes all but the given number of a user's most recently used profile pictures, along with
+// their files. Pictures held by a legal hold are kept.
+func deleteProfileImages(userId string, keep int) *model.AppError {
+	images, err := GetProfileImageHistory(userId)
+	if err != nil {
+		return err
+	}
+
+	if len(images) <= keep {
+		return nil
+	}
+
+	fileIds := []string{}
+	missingIds := []string{}
+	infos := []*model.FileInfo{}
+	for _, image := range images[keep:] {
+		if result := <-Srv.Store.FileInfo().Get(image.FileId); result.Err == nil {
+			infos = append(infos, result.Data.(*model.FileInfo))
+			fileIds = append(fileIds, image.FileId)
+		} else {
+			// there's nothing left to remove for a picture whose FileInfo is already gone
+			missingIds = append(missingIds, image.FileId)
+		}
+	}
+
+	// the rows are deleted before the files so that no picture is left pointing at files that are gone, and files held
+	// by a legal hold aren't deleted, so only the files of the pictures that were actually deleted are removed
+	var deletedIds []string
+	if result := <-Srv.Store.FileInfo().PermanentDeleteBatch(fileIds); result.Err != nil {
+		return result.Err
+	} else {
+		deletedIds = result.Data.([]string)
+	}
+
+	if result := <-Srv.Store.ProfileImage().PermanentDeleteBatch(append(missingIds, deletedIds...)); result.Err != nil {
+		return result.Err
+	}
+
+	deleted := make(map[string]bool, len(deletedIds))
+	for _, fileId := range deletedIds {
+		deleted[fileId] = true
+	}
+
+	for _, info := range infos {
+		if !deleted[info.Id] {
+			continue
+		}
+
+		for _, path := range []string{info.Path, info.ThumbnailPath} {
+			if err := RemoveFile(path); err != nil {
+				l4g.Warn(utils.T("app.profile_image.remove_file.warn"), path, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// profileImageChanged lets everyone else know that a user's profile picture has changed so that they can load the new
+// one.
+func profileImageChanged(userId string) {
+	Srv.Store.User().UpdateLastPictureUpdate(userId)
+
+	if user, err := GetUser(userId); err != nil {
+		l4g.Error(utils.T("api.user.get_me.getting.error"), userId)
+	} else {
+		options := utils.Cfg.GetSanitizeOptions()
+		user.SanitizeProfile(options)
+
+		omitUsers := make(map[string]bool, 1)
+		omitUsers[userId] = true
+		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_USER_UPDATED, "", "", "", omitUsers)
+		message.Add("user", user)
+
+		Publish(message)
+	}
+}
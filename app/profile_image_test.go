@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestProfileImageHistory(t *testing.T) {
+	th := Setup().InitBasic()
+
+	dir, err := ioutil.TempDir("", "profile_images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileSettings := utils.Cfg.FileSettings
+	defer func() {
+		utils.Cfg.FileSettings = fileSettings
+	}()
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	utils.Cfg.FileSettings.Directory = dir + "/"
+	maxVersions := 2
+	utils.Cfg.FileSettings.MaxProfileImageVersions = &maxVersions
+
+	userId := th.BasicUser.Id
+	defer deleteProfileImages(userId, 0)
+
+	colors := []color.Color{color.White, color.Black, color.Transparent}
+	saved := []*model.ProfileImage{}
+	for _, c := range colors {
+		img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+		for x := 0; x < 256; x++ {
+			for y := 0; y < 256; y++ {
+				img.Set(x, y, c)
+			}
+		}
+
+		if profileImage, err := saveProfileImage(userId, img); err != nil {
+			t.Fatal(err)
+		} else {
+			saved = append(saved, profileImage)
+		}
+	}
+
+	images, appErr := GetProfileImageHistory(userId)
+	if appErr != nil {
+		t.Fatal(appErr)
+	} else if len(images) != 2 {
+		t.Fatal("should have removed the oldest picture", len(images))
+	} else if images[0].FileId != saved[2].FileId || images[1].FileId != saved[1].FileId {
+		t.Fatal("should have kept the most recent pictures")
+	}
+
+	current, appErr := GetProfileImage(th.BasicUser)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if appErr := RevertProfileImage(userId, saved[1].FileId); appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if reverted, appErr := GetProfileImage(th.BasicUser); appErr != nil {
+		t.Fatal(appErr)
+	} else if bytes.Equal(reverted, current) {
+		t.Fatal("should have switched back to the previous picture")
+	}
+
+	if appErr := RevertProfileImage(th.BasicUser2.Id, saved[1].FileId); appErr == nil {
+		t.Fatal("shouldn't be able to use another user's picture")
+	}
+
+	if appErr := RevertProfileImage(userId, saved[0].FileId); appErr == nil {
+		t.Fatal("shouldn't be able to revert to a removed picture")
+	}
+
+	if data, appErr := GetProfileImageThumbnail(th.BasicUser); appErr != nil {
+		t.Fatal(appErr)
+	} else if img, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	} else if img.Bounds().Dx() != model.PROFILE_IMAGE_THUMBNAIL_SIZE {
+		t.Fatal("thumbnail should have been resized")
+	}
+
+	if data, appErr := GetProfileImageThumbnail(th.BasicUser2); appErr != nil {
+		t.Fatal(appErr)
+	} else if img, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	} else if img.Bounds().Dx() != model.PROFILE_IMAGE_THUMBNAIL_SIZE {
+		t.Fatal("generated picture should have been resized")
+	}
+
+	t.Run("LegalHold", func(t *testing.T) {
+		hold, appErr := CreateLegalHold(&model.LegalHold{DisplayName: "Profile pictures", UserId: userId})
+		if appErr != nil {
+			t.Fatal(appErr)
+		}
+		defer DeleteLegalHold(hold.Id)
+
+		images, appErr := GetProfileImageHistory(userId)
+		if appErr != nil {
+			t.Fatal(appErr)
+		}
+
+		var info *model.FileInfo
+		if result := <-Srv.Store.FileInfo().Get(images[len(images)-1].FileId); result.Err != nil {
+			t.Fatal(result.Err)
+		} else {
+			info = result.Data.(*model.FileInfo)
+		}
+
+		if appErr := deleteProfileImages(userId, 0); appErr != nil {
+			t.Fatal(appErr)
+		}
+
+		if remaining, appErr := GetProfileImageHistory(userId); appErr != nil {
+			t.Fatal(appErr)
+		} else if len(remaining) != len(images) {
+			t.Fatal("shouldn't have removed held pictures")
+		}
+
+		if _, appErr := ReadFile(info.Path); appErr != nil {
+			t.Fatal("shouldn't have removed the files of a held picture")
+		}
+	})
+}
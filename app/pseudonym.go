@@ -0,0 +1,204 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+var pseudonymMentionPattern = regexp.MustCompile(`@[A-Za-z0-9\.\-_]+`)
+
+// post props that contain the usernames of the users that a system message is about
+var pseudonymUsernameProps = []string{"username", "addedUsername", "removedUsername"}
+
+// GetPseudonym returns the pseudonym that replaces a user in anonymized exports, creating it the first time that the
+// user is exported.
+func GetPseudonym(userId string) (*model.Pseudonym, *model.AppError) {
+	if result := <-Srv.Store.Pseudonym().GetForUser(userId); result.Err == nil {
+		return result.Data.(*model.Pseudonym), nil
+	}
+
+	if result := <-Srv.Store.Pseudonym().Save(&model.Pseudonym{UserId: userId}); result.Err != nil {
+		// another server may have created the pseudonym at the same time
+		if getResult := <-Srv.Store.Pseudonym().GetForUser(userId); getResult.Err == nil {
+			return getResult.Data.(*model.Pseudonym), nil
+		}
+
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Pseudonym), nil
+	}
+}
+
+func GetPseudonyms(offset int, limit int) ([]*model.Pseudonym, *model.AppError) {
+	if result := <-Srv.Store.Pseudonym().GetAll(offset, limit); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Pseudonym), nil
+	}
+}
+
+// Pseudonymizer replaces the users in exported data with their pseudonyms. It caches the pseudonyms that it has
+// looked up, so a new one should be used for each export.
+type Pseudonymizer struct {
+	byUserId   map[string]*model.Pseudonym
+	byUsername map[string]*model.Pseudonym
+}
+
+func NewPseudonymizer() *Pseudonymizer {
+	return &Pseudonymizer{
+		byUserId:   map[string]*model.Pseudonym{},
+		byUsername: map[string]*model.Pseudonym{},
+	}
+}
+
+func (p *Pseudonymizer) GetPseudonym(userId string) (*model.Pseudonym, *model.AppError) {
+	if pseudonym, ok := p.byUserId[userId]; ok {
+		return pseudonym, nil
+	}
+
+	pseudonym, err := GetPseudonym(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	p.byUserId[userId] = pseudonym
+	return pseudonym, nil
+}
+
+// getPseudonymForUsername returns the pseudonym of the user with the given username or nil if there's no such user.
+func (p *Pseudonymizer) getPseudonymForUsername(username string) (*model.Pseudonym, *model.AppError) {
+	if pseudonym, ok := p.byUsername[username]; ok {
+		return pseudonym, nil
+	}
+
+	var pseudonym *model.Pseudonym
+	if result := <-Srv.Store.User().GetByUsername(username); result.Err == nil {
+		var err *model.AppError
+		if pseudonym, err = p.GetPseudonym(result.Data.(*model.User).Id); err != nil {
+			return nil, err
+		}
+	}
+
+	p.byUsername[username] = pseudonym
+	return pseudonym, nil
+}
+
+// PseudonymizeMessage replaces the @mentions of users in a message with mentions of their pseudonyms. Mentions that
+// don't match a user, such as @channel, are left as they are.
+func (p *Pseudonymizer) PseudonymizeMessage(message string) (string, *model.AppError) {
+	var err *model.AppError
+
+	message = pseudonymMentionPattern.ReplaceAllStringFunc(message, func(mention string) string {
+		if err != nil {
+			return mention
+		}
+
+		username := strings.ToLower(mention[1:])
+		// mentions are often followed by punctuation that's also valid in a username
+		trimmed := strings.TrimRight(username, ".-_")
+
+		for _, candidate := range []string{username, trimmed} {
+			var pseudonym *model.Pseudonym
+			if pseudonym, err = p.getPseudonymForUsername(candidate); err != nil {
+				return mention
+			} else if pseudonym != nil {
+				return "@" + pseudonym.Pseudonym + username[len(candidate):]
+			}
+		}
+
+		return mention
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return message, nil
+}
+
+// PseudonymizePost returns a copy of a post with the users mentioned in its message and, for system messages, the
+// users that it's about replaced with their pseudonyms. The post's UserId is left for the caller to replace.
+func (p *Pseudonymizer) PseudonymizePost(post *model.Post) (*model.Post, *model.AppError) {
+	pseudonymized := *post
+	pseudonymized.Props = model.StringInterface{}
+	for key, value := range post.Props {
+		pseudonymized.Props[key] = value
+	}
+
+	if post.IsSystemMessage() {
+		for _, key := range pseudonymUsernameProps {
+			username, ok := pseudonymized.Props[key].(string)
+			if !ok || username == "" {
+				continue
+			}
+
+			if pseudonym, err := p.getPseudonymForUsername(username); err != nil {
+				return nil, err
+			} else if pseudonym != nil {
+				pseudonymized.Props[key] = pseudonym.Pseudonym
+				pseudonymized.Message = regexp.MustCompile(`\b`+regexp.QuoteMeta(username)+`\b`).ReplaceAllLiteralString(pseudonymized.Message, pseudonym.Pseudonym)
+			}
+		}
+	}
+
+	if message, err := p.PseudonymizeMessage(pseudonymized.Message); err != nil {
+		return nil, err
+	} else {
+		pseudonymized.Message = message
+	}
+
+	return &pseudonymized, nil
+}
+
+// PseudonymizeCompliancePost replaces the identifying information about the author of a post in a compliance export
+// and the users mentioned in it with their pseudonyms.
+func (p *Pseudonymizer) PseudonymizeCompliancePost(post *model.CompliancePost) *model.AppError {
+	pseudonym, err := p.GetPseudonym(post.UserId)
+	if err != nil {
+		return err
+	}
+
+	post.UserId = ""
+	post.UserUsername = pseudonym.Pseudonym
+	post.UserEmail = pseudonym.Email()
+	post.UserNickname = ""
+
+	if name, err := p.PseudonymizeChannelName(post.ChannelName); err != nil {
+		return err
+	} else {
+		post.ChannelName = name
+	}
+
+	if message, err := p.PseudonymizeMessage(post.PostMessage); err != nil {
+		return err
+	} else {
+		post.PostMessage = message
+	}
+
+	return nil
+}
+
+// PseudonymizeChannelName replaces the ids of the users in the name of a direct message channel, which is made up of
+// their ids, with their pseudonyms. The names of other channels are returned as they are.
+func (p *Pseudonymizer) PseudonymizeChannelName(name string) (string, *model.AppError) {
+	userIds := strings.Split(name, "__")
+	if len(userIds) != 2 || len(userIds[0]) != 26 || len(userIds[1]) != 26 {
+		return name, nil
+	}
+
+	pseudonyms := make([]string, len(userIds))
+	for i, userId := range userIds {
+		if pseudonym, err := p.GetPseudonym(userId); err != nil {
+			return "", err
+		} else {
+			pseudonyms[i] = pseudonym.Pseudonym
+		}
+	}
+
+	return strings.Join(pseudonyms, "__"), nil
+}
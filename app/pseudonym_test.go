@@ -0,0 +1,157 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestGetPseudonym(t *testing.T) {
+	th := Setup().InitBasic()
+
+	pseudonym, err := GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(pseudonym.Pseudonym, th.BasicUser.Username) {
+		t.Fatal("pseudonym shouldn't contain the username")
+	}
+
+	if pseudonym2, err := NewPseudonymizer().GetPseudonym(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if pseudonym2.Pseudonym != pseudonym.Pseudonym {
+		t.Fatal("should always use the same pseudonym for a user")
+	}
+
+	if other, err := GetPseudonym(th.BasicUser2.Id); err != nil {
+		t.Fatal(err)
+	} else if other.Pseudonym == pseudonym.Pseudonym {
+		t.Fatal("users should have different pseudonyms")
+	}
+}
+
+func TestPseudonymizePost(t *testing.T) {
+	th := Setup().InitBasic()
+
+	pseudonymizer := NewPseudonymizer()
+
+	pseudonym, err := pseudonymizer.GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := &model.Post{
+		UserId:  th.BasicUser2.Id,
+		Message: "hello @" + th.BasicUser.Username + ". ping @channel and @nobody" + model.NewId(),
+	}
+
+	if pseudonymized, err := pseudonymizer.PseudonymizePost(post); err != nil {
+		t.Fatal(err)
+	} else if expected := "hello @" + pseudonym.Pseudonym + ". ping @channel and @nobody"; !strings.HasPrefix(pseudonymized.Message, expected) {
+		t.Fatal("should have replaced the mention", pseudonymized.Message)
+	} else if post.Message == pseudonymized.Message {
+		t.Fatal("shouldn't have changed the original post")
+	}
+
+	joinPost := &model.Post{
+		UserId:  th.BasicUser.Id,
+		Type:    model.POST_JOIN_CHANNEL,
+		Message: th.BasicUser.Username + " has joined the channel.",
+		Props:   model.StringInterface{"username": th.BasicUser.Username},
+	}
+
+	if pseudonymized, err := pseudonymizer.PseudonymizePost(joinPost); err != nil {
+		t.Fatal(err)
+	} else if pseudonymized.Message != pseudonym.Pseudonym+" has joined the channel." || pseudonymized.Props["username"] != pseudonym.Pseudonym {
+		t.Fatal("should have replaced the user that the system message is about", pseudonymized.Message)
+	} else if joinPost.Props["username"] != th.BasicUser.Username {
+		t.Fatal("shouldn't have changed the original post's props")
+	}
+}
+
+func TestExportUserPseudonymized(t *testing.T) {
+	th := Setup().InitBasic()
+
+	pseudonym, err := GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := ExportUser(th.BasicUser, NewPseudonymizer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *line.User.Username != pseudonym.Pseudonym || *line.User.Email != pseudonym.Email() {
+		t.Fatal("should have replaced the username and email")
+	} else if *line.User.FirstName != "" || *line.User.LastName != "" || *line.User.Nickname != "" {
+		t.Fatal("shouldn't have exported the user's personal details")
+	}
+
+	if err := validateUserImportData(line.User); err != nil {
+		t.Fatal("exported user should be valid import data", err)
+	}
+}
+
+func TestPseudonymizeChannelName(t *testing.T) {
+	th := Setup().InitBasic()
+
+	pseudonymizer := NewPseudonymizer()
+
+	pseudonym1, err := pseudonymizer.GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pseudonym2, err := pseudonymizer.GetPseudonym(th.BasicUser2.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, err := pseudonymizer.PseudonymizeChannelName(model.GetDMNameFromIds(th.BasicUser.Id, th.BasicUser2.Id)); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(name, th.BasicUser.Id) || strings.Contains(name, th.BasicUser2.Id) || !strings.Contains(name, pseudonym1.Pseudonym) || !strings.Contains(name, pseudonym2.Pseudonym) {
+		t.Fatal("should have replaced the users in the direct channel's name", name)
+	}
+
+	if name, err := pseudonymizer.PseudonymizeChannelName(th.BasicChannel.Name); err != nil {
+		t.Fatal(err)
+	} else if name != th.BasicChannel.Name {
+		t.Fatal("shouldn't have changed the name of a regular channel")
+	}
+}
+
+func TestGetCompliancePostsAnonymized(t *testing.T) {
+	th := Setup().InitBasic()
+
+	pseudonym, err := GetPseudonym(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := th.CreatePost(th.BasicChannel)
+
+	job := &model.Compliance{
+		StartAt: post.CreateAt - 1,
+		EndAt:   post.CreateAt,
+		Emails:  th.BasicUser.Email,
+	}
+
+	if posts, err := GetCompliancePosts(job); err != nil {
+		t.Fatal(err)
+	} else if len(posts) != 1 || posts[0].UserUsername != th.BasicUser.Username {
+		t.Fatal("should have returned the post as it is")
+	}
+
+	job.Anonymize = true
+	if posts, err := GetCompliancePosts(job); err != nil {
+		t.Fatal(err)
+	} else if len(posts) != 1 {
+		t.Fatal("should have returned the post")
+	} else if posts[0].UserUsername != pseudonym.Pseudonym || posts[0].UserEmail != pseudonym.Email() || posts[0].UserId != "" {
+		t.Fatal("should have replaced the author with their pseudonym")
+	}
+}
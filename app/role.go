@@ -0,0 +1,86 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+// LoadRoles gives the stored roles the permissions saved for them in the database, first saving the permissions of any
+// that haven't been saved yet. It needs to be run again whenever the config is loaded since that resets every role.
+func LoadRoles() *model.AppError {
+	var storedRoles map[string]*model.Role
+	if result := <-Srv.Store.Role().GetAll(); result.Err != nil {
+		return result.Err
+	} else {
+		storedRoles = make(map[string]*model.Role)
+		for _, role := range result.Data.([]*model.Role) {
+			storedRoles[role.Id] = role
+		}
+	}
+
+	for _, id := range model.StoredRoleIds() {
+		role := model.BuiltInRoles[id]
+
+		if storedRole, ok := storedRoles[id]; ok {
+			role.Permissions = storedRole.Permissions
+		} else if result := <-Srv.Store.Role().Save(role); result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+func GetRole(id string) (*model.Role, *model.AppError) {
+	if role, ok := model.BuiltInRoles[id]; !ok {
+		return nil, model.NewAppError("GetRole", "app.role.get.missing.app_error", nil, "id="+id, http.StatusNotFound)
+	} else {
+		return role, nil
+	}
+}
+
+// UpdateRolePermissions replaces the permissions of a stored role. A role can only be given permissions that system
+// admins have.
+func UpdateRolePermissions(id string, permissions []string) (*model.Role, *model.AppError) {
+	role, err := GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isStored := false
+	for _, storedId := range model.StoredRoleIds() {
+		if storedId == id {
+			isStored = true
+			break
+		}
+	}
+
+	if !isStored {
+		return nil, model.NewAppError("UpdateRolePermissions", "app.role.update_permissions.not_stored.app_error", nil, "id="+id, http.StatusBadRequest)
+	}
+
+	for _, permission := range permissions {
+		if !CheckIfRolesGrantPermission([]string{model.ROLE_SYSTEM_ADMIN.Id}, permission) {
+			return nil, model.NewAppError("UpdateRolePermissions", "app.role.update_permissions.permission.app_error", map[string]interface{}{"Permission": permission}, "id="+id, http.StatusBadRequest)
+		}
+	}
+
+	updated := &model.Role{
+		Id:          role.Id,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+
+	if result := <-Srv.Store.Role().Save(updated); result.Err != nil {
+		return nil, result.Err
+	}
+
+	role.Permissions = updated.Permissions
+
+	return role, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestLoadRoles(t *testing.T) {
+	Setup()
+	defer utils.SetDefaultRolesBasedOnConfig()
+
+	if err := LoadRoles(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range model.StoredRoleIds() {
+		if result := <-Srv.Store.Role().Get(id); result.Err != nil {
+			t.Fatal("should have saved the role", id, result.Err)
+		}
+	}
+
+	if err := (<-Srv.Store.Role().Save(&model.Role{
+		Id:          model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id,
+		Name:        model.ROLE_SYSTEM_READ_ONLY_ADMIN.Name,
+		Description: model.ROLE_SYSTEM_READ_ONLY_ADMIN.Description,
+		Permissions: []string{model.PERMISSION_READ_SYSTEM.Id, model.PERMISSION_MANAGE_COMPLIANCE.Id},
+	})).Err; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		<-Srv.Store.Role().Save(model.ROLE_SYSTEM_READ_ONLY_ADMIN)
+	}()
+
+	// Resetting the roles gives them their default permissions until they're loaded again
+	utils.SetDefaultRolesBasedOnConfig()
+
+	if CheckIfRolesGrantPermission([]string{model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id}, model.PERMISSION_MANAGE_COMPLIANCE.Id) {
+		t.Fatal("shouldn't have given the role a permission it doesn't have by default")
+	}
+
+	if err := LoadRoles(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !CheckIfRolesGrantPermission([]string{model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id}, model.PERMISSION_MANAGE_COMPLIANCE.Id) {
+		t.Fatal("should have used the permissions saved for the role")
+	}
+}
+
+func TestUpdateRolePermissions(t *testing.T) {
+	Setup()
+	defer utils.SetDefaultRolesBasedOnConfig()
+	defer func() {
+		<-Srv.Store.Role().Save(model.ROLE_SYSTEM_USER_MANAGER)
+	}()
+
+	role, err := UpdateRolePermissions(model.ROLE_SYSTEM_USER_MANAGER.Id, []string{model.PERMISSION_MANAGE_USERS.Id, model.PERMISSION_READ_SYSTEM.Id})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(role.Permissions) != 2 {
+		t.Fatal("should have replaced the permissions", role.Permissions)
+	}
+
+	if !CheckIfRolesGrantPermission([]string{model.ROLE_SYSTEM_USER_MANAGER.Id}, model.PERMISSION_READ_SYSTEM.Id) {
+		t.Fatal("should have given the role its new permissions")
+	}
+
+	if result := <-Srv.Store.Role().Get(model.ROLE_SYSTEM_USER_MANAGER.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.(*model.Role).Permissions) != 2 {
+		t.Fatal("should have saved the new permissions")
+	}
+
+	if _, err := UpdateRolePermissions(model.ROLE_SYSTEM_USER_MANAGER.Id, []string{"not_a_permission"}); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("shouldn't have given the role an unknown permission", err)
+	}
+
+	if _, err := UpdateRolePermissions(model.ROLE_SYSTEM_ADMIN.Id, []string{model.PERMISSION_READ_SYSTEM.Id}); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("shouldn't have changed the permissions of a role that isn't stored", err)
+	}
+
+	if _, err := UpdateRolePermissions("not_a_role", []string{model.PERMISSION_READ_SYSTEM.Id}); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("shouldn't have changed the permissions of a missing role", err)
+	}
+}
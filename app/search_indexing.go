@@ -0,0 +1,207 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	SEARCH_INDEXING_DATA_START_TIME     = "start_time"
+	SEARCH_INDEXING_DATA_END_TIME       = "end_time"
+	SEARCH_INDEXING_DATA_TEAM_ID        = "team_id"
+	SEARCH_INDEXING_DATA_REBUILD        = "rebuild"
+	SEARCH_INDEXING_DATA_LAST_CREATE_AT = "last_create_at"
+	SEARCH_INDEXING_DATA_LAST_POST_ID   = "last_post_id"
+	SEARCH_INDEXING_DATA_LAST_FILE_AT   = "last_file_post_create_at"
+	SEARCH_INDEXING_DATA_LAST_FILE_ID   = "last_file_id"
+	SEARCH_INDEXING_DATA_POSTS_INDEXED  = "posts_indexed"
+	SEARCH_INDEXING_DATA_FILES_INDEXED  = "files_indexed"
+)
+
+func init() {
+	RegisterJobWorker(model.JOB_TYPE_SEARCH_INDEXING, runSearchIndexingJob)
+}
+
+// CreateSearchIndexingJob queues a job that indexes every post created between startTime and
+// endTime, or only the posts in one team if teamId is set. If rebuild is set, the existing
+// indexes are purged first so that they can be recreated with the current mappings.
+func CreateSearchIndexingJob(startTime int64, endTime int64, teamId string, rebuild bool) (*model.Job, *model.AppError) {
+	if einterfaces.GetSearchEngineInterface() == nil || !*utils.Cfg.SearchSettings.EnableIndexing {
+		return nil, model.NewAppError("CreateSearchIndexingJob", "app.search_indexing.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if endTime == 0 {
+		endTime = model.GetMillis()
+	}
+
+	if startTime > endTime {
+		return nil, model.NewAppError("CreateSearchIndexingJob", "app.search_indexing.invalid_range.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if teamId != "" && rebuild {
+		// purging would remove every other team's posts from the indexes too
+		return nil, model.NewAppError("CreateSearchIndexingJob", "app.search_indexing.rebuild_team.app_error", nil, "team_id="+teamId, http.StatusBadRequest)
+	}
+
+	return CreateJob(model.JOB_TYPE_SEARCH_INDEXING, map[string]string{
+		SEARCH_INDEXING_DATA_START_TIME: strconv.FormatInt(startTime, 10),
+		SEARCH_INDEXING_DATA_END_TIME:   strconv.FormatInt(endTime, 10),
+		SEARCH_INDEXING_DATA_TEAM_ID:    teamId,
+		SEARCH_INDEXING_DATA_REBUILD:    strconv.FormatBool(rebuild),
+	})
+}
+
+func runSearchIndexingJob(job *model.Job) *model.AppError {
+	engine := einterfaces.GetSearchEngineInterface()
+	if engine == nil {
+		return model.NewAppError("runSearchIndexingJob", "app.search_indexing.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	startTime := job.GetDataInt64(SEARCH_INDEXING_DATA_START_TIME)
+	endTime := job.GetDataInt64(SEARCH_INDEXING_DATA_END_TIME)
+	if endTime == 0 {
+		endTime = job.CreateAt
+	}
+	teamId := job.Data[SEARCH_INDEXING_DATA_TEAM_ID]
+
+	// Only purge the indexes the first time the job runs, not when it's resumed from a checkpoint
+	_, resumedPosts := job.Data[SEARCH_INDEXING_DATA_LAST_CREATE_AT]
+	_, resumedFiles := job.Data[SEARCH_INDEXING_DATA_LAST_FILE_AT]
+	if !resumedPosts && !resumedFiles && job.Data[SEARCH_INDEXING_DATA_REBUILD] == "true" {
+		l4g.Info(utils.T("app.search_indexing.purge.info"), engine.GetName())
+		if err := engine.PurgeIndexes(); err != nil {
+			return err
+		}
+	}
+
+	lastCreateAt := startTime - 1
+	if resumedPosts {
+		lastCreateAt = job.GetDataInt64(SEARCH_INDEXING_DATA_LAST_CREATE_AT)
+	}
+	lastPostId := job.Data[SEARCH_INDEXING_DATA_LAST_POST_ID]
+
+	postsIndexed := job.GetDataInt64(SEARCH_INDEXING_DATA_POSTS_INDEXED)
+	filesIndexed := job.GetDataInt64(SEARCH_INDEXING_DATA_FILES_INDEXED)
+
+	batchSize := *utils.Cfg.SearchSettings.BulkIndexingBatchSize
+
+	for {
+		var posts []*model.PostForIndexing
+		if result := <-Srv.Store.Post().GetPostsBatchForIndexing(lastCreateAt, lastPostId, endTime, teamId, batchSize); result.Err != nil {
+			return result.Err
+		} else {
+			posts = result.Data.([]*model.PostForIndexing)
+		}
+
+		if len(posts) == 0 {
+			break
+		}
+
+		segmentPostsForIndexing(posts)
+
+		if err := engine.BulkIndexPosts(posts); err != nil {
+			return err
+		}
+
+		last := posts[len(posts)-1]
+		lastCreateAt = last.CreateAt
+		lastPostId = last.Id
+		postsIndexed += int64(len(posts))
+
+		job.SetDataInt64(SEARCH_INDEXING_DATA_LAST_CREATE_AT, lastCreateAt)
+		job.Data[SEARCH_INDEXING_DATA_LAST_POST_ID] = lastPostId
+		job.SetDataInt64(SEARCH_INDEXING_DATA_POSTS_INDEXED, postsIndexed)
+
+		// posts make up the first half of the job and files the second
+		if err := SetJobProgress(job, searchIndexingProgress(startTime, endTime, lastCreateAt)/2); err != nil {
+			return err
+		}
+
+		if len(posts) < batchSize {
+			break
+		}
+	}
+
+	// Files are batched by the time that their posts were made so that the window is the same as for the posts
+	lastFileAt := startTime - 1
+	if resumedFiles {
+		lastFileAt = job.GetDataInt64(SEARCH_INDEXING_DATA_LAST_FILE_AT)
+	}
+	lastFileId := job.Data[SEARCH_INDEXING_DATA_LAST_FILE_ID]
+
+	for {
+		var files []*model.FileInfoForIndexing
+		if result := <-Srv.Store.FileInfo().GetBatchForIndexing(lastFileAt, lastFileId, endTime, teamId, batchSize); result.Err != nil {
+			return result.Err
+		} else {
+			files = result.Data.([]*model.FileInfoForIndexing)
+		}
+
+		if len(files) == 0 {
+			break
+		}
+
+		if err := engine.BulkIndexFiles(files); err != nil {
+			return err
+		}
+
+		last := files[len(files)-1]
+		lastFileAt = last.PostCreateAt
+		lastFileId = last.Id
+		filesIndexed += int64(len(files))
+
+		job.SetDataInt64(SEARCH_INDEXING_DATA_LAST_FILE_AT, lastFileAt)
+		job.Data[SEARCH_INDEXING_DATA_LAST_FILE_ID] = lastFileId
+		job.SetDataInt64(SEARCH_INDEXING_DATA_FILES_INDEXED, filesIndexed)
+
+		if err := SetJobProgress(job, 50+searchIndexingProgress(startTime, endTime, lastFileAt)/2); err != nil {
+			return err
+		}
+
+		if len(files) < batchSize {
+			break
+		}
+	}
+
+	l4g.Info(utils.T("app.search_indexing.finished.info"), postsIndexed, filesIndexed)
+
+	return nil
+}
+
+// segmentPostsForIndexing fills in the segmented messages of any posts containing CJK text so that the search engine can
+// match words within it.
+func segmentPostsForIndexing(posts []*model.PostForIndexing) {
+	if *utils.Cfg.SearchSettings.CJKSegmentation != model.SEARCH_CJK_SEGMENTATION_BIGRAM {
+		return
+	}
+
+	for _, post := range posts {
+		if model.ContainsCJK(post.Message) {
+			post.SegmentedMessage = model.SegmentCJKBigrams(post.Message)
+		}
+	}
+}
+
+func searchIndexingProgress(startTime int64, endTime int64, current int64) int64 {
+	if endTime <= startTime {
+		return 0
+	}
+
+	progress := (current - startTime) * 100 / (endTime - startTime)
+	if progress < 0 {
+		return 0
+	} else if progress > 99 {
+		// 100 is reserved for when the job has actually finished
+		return 99
+	}
+
+	return progress
+}
@@ -7,16 +7,18 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
-	"github.com/rsc/letsencrypt"
 	"github.com/tylerb/graceful"
 	"gopkg.in/throttled/throttled.v2"
 	"gopkg.in/throttled/throttled.v2/store/memstore"
@@ -27,6 +29,10 @@ type Server struct {
 	WebSocketRouter *WebSocketRouter
 	Router          *mux.Router
 	GracefulServer  *graceful.Server
+	LetsEncrypt     *LetsEncryptManager
+	RateLimiter     *RateLimitHandler
+
+	configListenerId string
 }
 
 var allowedMethods []string = []string{
@@ -79,6 +85,10 @@ func NewServer() {
 
 func InitStores() {
 	Srv.Store = store.NewSqlStore()
+
+	if err := LoadRoles(); err != nil {
+		l4g.Error(utils.T("app.role.load.error"), err.Error())
+	}
 }
 
 type VaryBy struct{}
@@ -117,43 +127,121 @@ func redirectHTTPToHTTPS(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, url.String(), http.StatusFound)
 }
 
-func StartServer() {
-	l4g.Info(utils.T("api.server.start_server.starting.info"))
+// RateLimitHandler applies the configured rate limits to requests before passing them on. Its limiter is rebuilt
+// when the rate limit settings change so that they can be adjusted without restarting the server.
+type RateLimitHandler struct {
+	handler http.Handler
 
-	var handler http.Handler = &CorsWrapper{Srv.Router}
+	mutex       sync.RWMutex
+	rateLimiter http.Handler
+}
 
-	if *utils.Cfg.RateLimitSettings.Enable {
-		l4g.Info(utils.T("api.server.start_server.rate.info"))
+func NewRateLimitHandler(handler http.Handler) *RateLimitHandler {
+	return &RateLimitHandler{handler: handler}
+}
 
-		store, err := memstore.New(utils.Cfg.RateLimitSettings.MemoryStoreSize)
-		if err != nil {
-			l4g.Critical(utils.T("api.server.start_server.rate_limiting_memory_store"))
-			return
-		}
+// Configure replaces the current limiter with one built from settings. If that fails, the current limiter is kept.
+func (h *RateLimitHandler) Configure(settings *model.RateLimitSettings) bool {
+	if !*settings.Enable {
+		h.mutex.Lock()
+		h.rateLimiter = nil
+		h.mutex.Unlock()
 
-		quota := throttled.RateQuota{
-			MaxRate:  throttled.PerSec(utils.Cfg.RateLimitSettings.PerSec),
-			MaxBurst: *utils.Cfg.RateLimitSettings.MaxBurst,
-		}
+		return true
+	}
 
-		rateLimiter, err := throttled.NewGCRARateLimiter(store, quota)
-		if err != nil {
-			l4g.Critical(utils.T("api.server.start_server.rate_limiting_rate_limiter"))
-			return
+	l4g.Info(utils.T("api.server.start_server.rate.info"))
+
+	store, err := memstore.New(settings.MemoryStoreSize)
+	if err != nil {
+		l4g.Critical(utils.T("api.server.start_server.rate_limiting_memory_store"))
+		return false
+	}
+
+	quota := throttled.RateQuota{
+		MaxRate:  throttled.PerSec(settings.PerSec),
+		MaxBurst: *settings.MaxBurst,
+	}
+
+	rateLimiter, err := throttled.NewGCRARateLimiter(store, quota)
+	if err != nil {
+		l4g.Critical(utils.T("api.server.start_server.rate_limiting_rate_limiter"))
+		return false
+	}
+
+	httpRateLimiter := throttled.HTTPRateLimiter{
+		RateLimiter: rateLimiter,
+		VaryBy:      &VaryBy{},
+		DeniedHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l4g.Error("%v: Denied due to throttling settings code=429 ip=%v", r.URL.Path, utils.GetIpAddress(r))
+			throttled.DefaultDeniedHandler.ServeHTTP(w, r)
+		}),
+	}
+
+	h.mutex.Lock()
+	h.rateLimiter = httpRateLimiter.RateLimit(h.handler)
+	h.mutex.Unlock()
+
+	return true
+}
+
+func (h *RateLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mutex.RLock()
+	rateLimiter := h.rateLimiter
+	h.mutex.RUnlock()
+
+	if rateLimiter != nil {
+		rateLimiter.ServeHTTP(w, r)
+	} else {
+		h.handler.ServeHTTP(w, r)
+	}
+}
+
+// configChanged applies settings that can change while the server is running.
+func configChanged(oldConfig *model.Config, newConfig *model.Config) {
+	if Srv.RateLimiter != nil && !reflect.DeepEqual(oldConfig.RateLimitSettings, newConfig.RateLimitSettings) {
+		Srv.RateLimiter.Configure(&newConfig.RateLimitSettings)
+	}
+
+	if metricsInterface := einterfaces.GetMetricsInterface(); metricsInterface != nil && !reflect.DeepEqual(oldConfig.MetricsSettings, newConfig.MetricsSettings) {
+		if *newConfig.MetricsSettings.Enable {
+			metricsInterface.StartServer()
+		} else {
+			metricsInterface.StopServer()
 		}
+	}
 
-		httpRateLimiter := throttled.HTTPRateLimiter{
-			RateLimiter: rateLimiter,
-			VaryBy:      &VaryBy{},
-			DeniedHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				l4g.Error("%v: Denied due to throttling settings code=429 ip=%v", r.URL.Path, utils.GetIpAddress(r))
-				throttled.DefaultDeniedHandler.ServeHTTP(w, r)
-			}),
+	if *oldConfig.EmailSettings.TemplatesDirectory != *newConfig.EmailSettings.TemplatesDirectory {
+		if err := utils.ReloadHTMLTemplates(); err != nil {
+			l4g.Error(utils.T("api.api.init.parsing_templates.error"), err)
 		}
+	}
+
+	// start/restart email batching job if necessary
+	InitEmailBatching()
+
+	if *oldConfig.EmailSettings.EnableInboundEmail != *newConfig.EmailSettings.EnableInboundEmail || *oldConfig.EmailSettings.InboundEmailListenAddress != *newConfig.EmailSettings.InboundEmailListenAddress {
+		restartInboundEmail()
+	}
 
-		handler = httpRateLimiter.RateLimit(handler)
+	// Loading the config resets the roles to their defaults
+	if err := LoadRoles(); err != nil {
+		l4g.Error(utils.T("app.role.load.error"), err.Error())
+	}
+}
+
+func StartServer() {
+	l4g.Info(utils.T("api.server.start_server.starting.info"))
+
+	Srv.RateLimiter = NewRateLimitHandler(&CorsWrapper{Srv.Router})
+	if !Srv.RateLimiter.Configure(&utils.Cfg.RateLimitSettings) {
+		return
 	}
 
+	Srv.configListenerId = utils.AddConfigListener(configChanged)
+
+	var handler http.Handler = Srv.RateLimiter
+
 	Srv.GracefulServer = &graceful.Server{
 		Timeout: TIME_TO_WAIT_FOR_CONNECTIONS_TO_CLOSE_ON_SERVER_SHUTDOWN,
 		Server: &http.Server{
@@ -165,7 +253,21 @@ func StartServer() {
 	}
 	l4g.Info(utils.T("api.server.start_server.listening.info"), utils.Cfg.ServiceSettings.ListenAddress)
 
-	if *utils.Cfg.ServiceSettings.Forward80To443 {
+	if *utils.Cfg.ServiceSettings.ConnectionSecurity == model.CONN_SECURITY_TLS && *utils.Cfg.ServiceSettings.UseLetsEncrypt {
+		Srv.LetsEncrypt = NewLetsEncryptManagerFromConfig()
+	}
+
+	// Port 80 is needed both to redirect to HTTPS and to answer Let's Encrypt's HTTP-01 challenges
+	if *utils.Cfg.ServiceSettings.Forward80To443 || Srv.LetsEncrypt != nil {
+		var httpHandler http.Handler = http.NotFoundHandler()
+		if *utils.Cfg.ServiceSettings.Forward80To443 {
+			httpHandler = http.HandlerFunc(redirectHTTPToHTTPS)
+		}
+
+		if Srv.LetsEncrypt != nil {
+			httpHandler = Srv.LetsEncrypt.HTTPHandler(httpHandler)
+		}
+
 		go func() {
 			listener, err := net.Listen("tcp", ":80")
 			if err != nil {
@@ -174,19 +276,20 @@ func StartServer() {
 			}
 			defer listener.Close()
 
-			http.Serve(listener, http.HandlerFunc(redirectHTTPToHTTPS))
+			http.Serve(listener, httpHandler)
 		}()
 	}
 
+	if Srv.LetsEncrypt != nil {
+		Srv.LetsEncrypt.Start()
+	}
+
 	go func() {
 		var err error
 		if *utils.Cfg.ServiceSettings.ConnectionSecurity == model.CONN_SECURITY_TLS {
-			if *utils.Cfg.ServiceSettings.UseLetsEncrypt {
-				var m letsencrypt.Manager
-				m.CacheFile(*utils.Cfg.ServiceSettings.LetsEncryptCertificateCacheFile)
-
+			if Srv.LetsEncrypt != nil {
 				tlsConfig := &tls.Config{
-					GetCertificate: m.GetCertificate,
+					GetCertificate: Srv.LetsEncrypt.GetCertificate,
 				}
 
 				tlsConfig.NextProtos = append(tlsConfig.NextProtos, "h2")
@@ -209,6 +312,13 @@ func StopServer() {
 
 	l4g.Info(utils.T("api.server.stop_server.stopping.info"))
 
+	utils.RemoveConfigListener(Srv.configListenerId)
+
+	if Srv.LetsEncrypt != nil {
+		Srv.LetsEncrypt.Stop()
+		Srv.LetsEncrypt = nil
+	}
+
 	Srv.GracefulServer.Stop(TIME_TO_WAIT_FOR_CONNECTIONS_TO_CLOSE_ON_SERVER_SHUTDOWN)
 	Srv.Store.Close()
 	HubStop()
@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestRateLimitHandlerConfigure(t *testing.T) {
+	Setup()
+
+	handler := NewRateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v3/users/me", nil))
+		return w.Code
+	}
+
+	settings := &model.RateLimitSettings{
+		Enable:          new(bool),
+		PerSec:          1,
+		MaxBurst:        new(int),
+		MemoryStoreSize: 100,
+	}
+
+	if !handler.Configure(settings) {
+		t.Fatal("should have configured the handler")
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := serve(); code != http.StatusOK {
+			t.Fatal("shouldn't have limited requests while rate limiting is disabled", code)
+		}
+	}
+
+	*settings.Enable = true
+	if !handler.Configure(settings) {
+		t.Fatal("should have configured the handler")
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatal("should have allowed the first request", code)
+	}
+
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatal("should have limited the second request", code)
+	}
+
+	*settings.MaxBurst = -1
+	if handler.Configure(settings) {
+		t.Fatal("shouldn't have configured the handler with an invalid burst size")
+	}
+
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatal("should have kept the previous limiter", code)
+	}
+
+	*settings.MaxBurst = 0
+	*settings.Enable = false
+	if !handler.Configure(settings) {
+		t.Fatal("should have configured the handler")
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatal("should have stopped limiting requests", code)
+	}
+}
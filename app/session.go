@@ -4,6 +4,8 @@
 package app
 
 import (
+	"net/http"
+
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -40,14 +42,26 @@ func GetSession(token string) (*model.Session, *model.AppError) {
 		}
 	}
 
+	if session != nil && isSessionIdle(session) {
+		// the cached session may be missing activity that another server has since saved
+		sessionCache.Remove(token)
+		session = nil
+	}
+
 	if session == nil {
 		if sessionResult := <-Srv.Store.Session().Get(token); sessionResult.Err != nil {
+			if *utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+				if tokenSession, err := createSessionForUserAccessToken(token); err == nil {
+					return tokenSession, nil
+				}
+			}
+
 			return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token, "Error": sessionResult.Err.DetailedError}, "")
 		} else {
 			session = sessionResult.Data.(*model.Session)
 
-			if session.IsExpired() || session.Token != token {
-				return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token, "Error": sessionResult.Err.DetailedError}, "")
+			if session.IsExpired() || session.Token != token || isSessionIdle(session) {
+				return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "")
 			} else {
 				AddSessionToCache(session)
 				return session, nil
@@ -55,7 +69,7 @@ func GetSession(token string) (*model.Session, *model.AppError) {
 		}
 	}
 
-	if session == nil || session.IsExpired() {
+	if session == nil || session.IsExpired() || (session.IsUserAccessToken() && !*utils.Cfg.ServiceSettings.EnableUserAccessTokens) {
 		return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "")
 	}
 
@@ -95,12 +109,9 @@ func RevokeAllSessions(userId string) *model.AppError {
 }
 
 func ClearSessionCacheForUser(userId string) {
-
 	ClearSessionCacheForUserSkipClusterSend(userId)
 
-	if einterfaces.GetClusterInterface() != nil {
-		einterfaces.GetClusterInterface().ClearSessionCacheForUser(userId)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_SESSIONS_FOR_USER, userId))
 }
 
 func ClearSessionCacheForUserSkipClusterSend(userId string) {
@@ -178,3 +189,51 @@ func AttachDeviceId(sessionId string, deviceId string, expiresAt int64) *model.A
 
 	return nil
 }
+
+// CreateWebSocketConnectionToken creates a one-time token that a client can use to open a WebSocket for the given
+// session instead of sending its session token or cookies with the upgrade request.
+func CreateWebSocketConnectionToken(session *model.Session) (*model.WebSocketConnectionToken, *model.AppError) {
+	if result := <-Srv.Store.WebSocketConnectionToken().DeleteExpired(model.GetMillis()); result.Err != nil {
+		l4g.Error(result.Err.Error())
+	}
+
+	if result := <-Srv.Store.WebSocketConnectionToken().Save(&model.WebSocketConnectionToken{SessionId: session.Id}); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.WebSocketConnectionToken), nil
+	}
+}
+
+// GetSessionForWebSocketConnectionToken uses up a token created by CreateWebSocketConnectionToken and returns the
+// session that it was created for if both are still valid.
+func GetSessionForWebSocketConnectionToken(token string) (*model.Session, *model.AppError) {
+	var wsToken *model.WebSocketConnectionToken
+	if result := <-Srv.Store.WebSocketConnectionToken().Consume(token); result.Err != nil {
+		result.Err.StatusCode = http.StatusUnauthorized
+		return nil, result.Err
+	} else {
+		wsToken = result.Data.(*model.WebSocketConnectionToken)
+	}
+
+	if wsToken.IsExpired() {
+		err := model.NewLocAppError("GetSessionForWebSocketConnectionToken", "app.session.websocket_connection_token.expired.app_error", nil, "")
+		err.StatusCode = http.StatusUnauthorized
+		return nil, err
+	}
+
+	var session *model.Session
+	if result := <-Srv.Store.Session().Get(wsToken.SessionId); result.Err != nil {
+		result.Err.StatusCode = http.StatusUnauthorized
+		return nil, result.Err
+	} else {
+		session = result.Data.(*model.Session)
+	}
+
+	// Go through GetSession so that expired, idle and revoked sessions are rejected like they are for other requests
+	if session, err := GetSession(session.Token); err != nil {
+		err.StatusCode = http.StatusUnauthorized
+		return nil, err
+	} else {
+		return session, nil
+	}
+}
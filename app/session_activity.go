@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	SESSION_ACTIVITY_TASK_NAME = "Session Activity Flush"
+	SESSION_ACTIVITY_INTERVAL  = 30 * time.Second
+)
+
+// sessionActivity holds the LastActivityAt of sessions that have been active since the last flush,
+// keyed by session id, so that activity is saved in batches instead of once per request.
+var sessionActivity = make(map[string]int64)
+var sessionActivityLock sync.Mutex
+
+func StartSessionActivityFlush() {
+	if task := model.GetTaskByName(SESSION_ACTIVITY_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(SESSION_ACTIVITY_TASK_NAME, FlushSessionActivity, SESSION_ACTIVITY_INTERVAL)
+}
+
+func StopSessionActivityFlush() {
+	if task := model.GetTaskByName(SESSION_ACTIVITY_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	FlushSessionActivity()
+}
+
+func RecordSessionActivity(sessionId string, lastActivityAt int64) {
+	sessionActivityLock.Lock()
+	defer sessionActivityLock.Unlock()
+
+	if lastActivityAt > sessionActivity[sessionId] {
+		sessionActivity[sessionId] = lastActivityAt
+	}
+}
+
+func getPendingSessionActivity(sessionId string) int64 {
+	sessionActivityLock.Lock()
+	defer sessionActivityLock.Unlock()
+
+	return sessionActivity[sessionId]
+}
+
+// FlushSessionActivity saves the activity recorded since the last flush to the database.
+func FlushSessionActivity() {
+	sessionActivityLock.Lock()
+	activity := sessionActivity
+	sessionActivity = make(map[string]int64)
+	sessionActivityLock.Unlock()
+
+	if len(activity) == 0 {
+		return
+	}
+
+	if result := <-Srv.Store.Session().UpdateLastActivityAtBatch(activity); result.Err != nil {
+		l4g.Error(utils.T("app.session.flush_activity.error"), len(activity), result.Err)
+	}
+}
+
+// isSessionIdle returns true if the idle timeout is enabled and the session hasn't been used for
+// longer than it. OAuth, mobile and personal access token sessions aren't subject to the idle timeout.
+func isSessionIdle(session *model.Session) bool {
+	timeout := int64(*utils.Cfg.ServiceSettings.SessionIdleTimeoutInMinutes) * 60 * 1000
+	if timeout <= 0 || session.IsOAuth || session.IsMobileApp() || session.IsUserAccessToken() {
+		return false
+	}
+
+	lastActivityAt := session.LastActivityAt
+	if pending := getPendingSessionActivity(session.Id); pending > lastActivityAt {
+		lastActivityAt = pending
+	}
+
+	return model.GetMillis()-lastActivityAt > timeout
+}
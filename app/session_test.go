@@ -5,6 +5,7 @@ package app
 
 import (
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 	"testing"
 )
 
@@ -29,3 +30,46 @@ func TestCache(t *testing.T) {
 		t.Fatal("should have one less")
 	}
 }
+
+func TestGetSessionIdleTimeout(t *testing.T) {
+	th := Setup().InitBasic()
+
+	timeout := *utils.Cfg.ServiceSettings.SessionIdleTimeoutInMinutes
+	defer func() {
+		*utils.Cfg.ServiceSettings.SessionIdleTimeoutInMinutes = timeout
+	}()
+	*utils.Cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 5
+
+	session, err := CreateSession(&model.Session{UserId: th.BasicUser.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetSession(session.Token); err != nil {
+		t.Fatal("new session shouldn't be idle", err)
+	}
+
+	idleSince := model.GetMillis() - 10*60*1000
+	if result := <-Srv.Store.Session().UpdateLastActivityAt(session.Id, idleSince); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	session.LastActivityAt = idleSince
+
+	if _, err := GetSession(session.Token); err == nil {
+		t.Fatal("idle session should have been rejected")
+	}
+
+	RecordSessionActivity(session.Id, model.GetMillis())
+
+	if _, err := GetSession(session.Token); err != nil {
+		t.Fatal("recent activity should have kept the session alive", err)
+	}
+
+	FlushSessionActivity()
+
+	if result := <-Srv.Store.Session().Get(session.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.Session).LastActivityAt <= idleSince {
+		t.Fatal("flushing should have saved the session activity")
+	}
+}
@@ -249,6 +249,10 @@ func SlackAddPosts(teamId string, channel *model.Channel, posts []SlackPost, use
 				}
 			}
 
+			if len(newPost.FileIds) > 0 {
+				InvalidateCacheForFileInfosForPost(newPost.Id)
+			}
+
 		case sPost.Type == "message" && sPost.SubType == "file_comment":
 			if sPost.Comment == nil {
 				l4g.Debug(utils.T("api.slackimport.slack_add_posts.msg_no_comment.debug"))
@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	SQL_METRICS_TASK_NAME = "SQL Connection Pool Metrics"
+	SQL_METRICS_INTERVAL  = 15 * time.Second
+)
+
+func StartSqlMetrics() {
+	if task := model.GetTaskByName(SQL_METRICS_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+
+	model.CreateRecurringTask(SQL_METRICS_TASK_NAME, reportSqlConnectionPoolStats, SQL_METRICS_INTERVAL)
+}
+
+func StopSqlMetrics() {
+	if task := model.GetTaskByName(SQL_METRICS_TASK_NAME); task != nil {
+		task.Cancel()
+	}
+}
+
+// reportSqlConnectionPoolStats records how busy the connection pool of each data source is so that
+// the SQL settings can be tuned.
+func reportSqlConnectionPoolStats() {
+	metrics := einterfaces.GetMetricsInterface()
+	if metrics == nil || !*utils.Cfg.MetricsSettings.Enable {
+		return
+	}
+
+	for dataSource, stats := range Srv.Store.ConnectionPoolStats() {
+		metrics.SetSqlOpenConnections(dataSource, float64(stats.OpenConnections))
+		metrics.SetSqlInUseConnections(dataSource, float64(stats.InUse))
+		metrics.SetSqlConnectionWaitCount(dataSource, float64(stats.WaitCount))
+		metrics.SetSqlConnectionWaitDuration(dataSource, stats.WaitDuration.Seconds())
+	}
+}
@@ -91,6 +91,8 @@ func GetStatusesByIds(userIds []string) (map[string]interface{}, *model.AppError
 }
 
 func SetStatusOnline(userId string, sessionId string, manual bool) {
+	RecordSessionActivity(sessionId, model.GetMillis())
+
 	broadcast := false
 
 	var oldStatus string = model.STATUS_OFFLINE
@@ -125,8 +127,6 @@ func SetStatusOnline(userId string, sessionId string, manual bool) {
 	// Only update the database if the status has changed, the status has been manually set,
 	// or enough time has passed since the previous action
 	if status.Status != oldStatus || status.Manual != oldManual || status.LastActivityAt-oldTime > model.STATUS_MIN_UPDATE_TIME {
-		achan := Srv.Store.Session().UpdateLastActivityAt(sessionId, status.LastActivityAt)
-
 		var schan store.StoreChannel
 		if broadcast {
 			schan = Srv.Store.Status().SaveOrUpdate(status)
@@ -134,10 +134,6 @@ func SetStatusOnline(userId string, sessionId string, manual bool) {
 			schan = Srv.Store.Status().UpdateLastActivityAt(status.UserId, status.LastActivityAt)
 		}
 
-		if result := <-achan; result.Err != nil {
-			l4g.Error(utils.T("api.status.last_activity.error"), userId, sessionId, result.Err)
-		}
-
 		if result := <-schan; result.Err != nil {
 			l4g.Error(utils.T("api.status.save_status.error"), userId, result.Err)
 		}
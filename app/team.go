@@ -207,14 +207,11 @@ func AddUserToTeamByHash(userId string, hash string, data string) (*model.Team,
 }
 
 func AddUserToTeamByInviteId(inviteId string, userId string) (*model.Team, *model.AppError) {
-	tchan := Srv.Store.Team().GetByInviteId(inviteId)
 	uchan := Srv.Store.User().Get(userId)
 
-	var team *model.Team
-	if result := <-tchan; result.Err != nil {
-		return nil, result.Err
-	} else {
-		team = result.Data.(*model.Team)
+	invitation, link, team, err := GetInvite(inviteId)
+	if err != nil {
+		return nil, err
 	}
 
 	var user *model.User
@@ -224,6 +221,10 @@ func AddUserToTeamByInviteId(inviteId string, userId string) (*model.Team, *mode
 		user = result.Data.(*model.User)
 	}
 
+	if err := UseInvite(invitation, link, user.Email); err != nil {
+		return nil, err
+	}
+
 	if err := JoinUserToTeam(team, user); err != nil {
 		return nil, err
 	}
@@ -366,6 +367,14 @@ func GetTeamMembers(teamId string, offset int, limit int) ([]*model.TeamMember,
 	}
 }
 
+func GetTeamMembersPage(teamId string, offset int, limit int, sort string, role string) ([]*model.TeamMember, *model.AppError) {
+	if result := <-Srv.Store.Team().GetMembersPage(teamId, offset, limit, sort, role); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.TeamMember), nil
+	}
+}
+
 func GetTeamMembersByIds(teamId string, userIds []string) ([]*model.TeamMember, *model.AppError) {
 	if result := <-Srv.Store.Team().GetMembersByIds(teamId, userIds); result.Err != nil {
 		return nil, result.Err
@@ -481,7 +490,7 @@ func InviteNewUsersToTeam(emailList []string, teamId, senderId, siteURL string)
 		user = result.Data.(*model.User)
 	}
 
-	SendInviteEmails(team, user.GetDisplayName(), emailList, siteURL)
+	SendInviteEmails(team, user.GetDisplayName(), user.Id, emailList, siteURL)
 
 	return nil
 }
@@ -552,6 +561,18 @@ func PermanentDeleteTeam(team *model.Team) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.Invitation().PermanentDeleteByTeam(team.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.TeamInviteLink().PermanentDeleteByTeam(team.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.TeamDefaultChannel().PermanentDeleteByTeam(team.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Team().PermanentDelete(team.Id); result.Err != nil {
 		return result.Err
 	}
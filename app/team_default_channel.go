@@ -0,0 +1,93 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+func GetTeamDefaultChannels(teamId string) ([]*model.TeamDefaultChannel, *model.AppError) {
+	if result := <-Srv.Store.TeamDefaultChannel().GetForTeam(teamId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.TeamDefaultChannel), nil
+	}
+}
+
+// UpdateTeamDefaultChannels replaces the channels that new members of a team are added to, in the given order. Only
+// public and private channels that belong to the team can be used, and the user making the change can only add
+// private channels that they're a member of so that they can't use it to give anyone access to them.
+func UpdateTeamDefaultChannels(teamId string, userId string, channelIds []string) ([]*model.TeamDefaultChannel, *model.AppError) {
+	uniqueIds := []string{}
+	seen := map[string]bool{}
+	for _, channelId := range channelIds {
+		if !seen[channelId] {
+			seen[channelId] = true
+			uniqueIds = append(uniqueIds, channelId)
+		}
+	}
+	channelIds = uniqueIds
+
+	if len(channelIds) > model.TEAM_DEFAULT_CHANNELS_MAX {
+		return nil, model.NewAppError("UpdateTeamDefaultChannels", "app.team_default_channel.too_many.app_error", map[string]interface{}{"Max": model.TEAM_DEFAULT_CHANNELS_MAX}, "team_id="+teamId, http.StatusBadRequest)
+	}
+
+	currentChannels, err := GetTeamDefaultChannels(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	isCurrentChannel := map[string]bool{}
+	for _, defaultChannel := range currentChannels {
+		isCurrentChannel[defaultChannel.ChannelId] = true
+	}
+
+	for _, channelId := range channelIds {
+		channel, err := GetChannel(channelId)
+		if err != nil {
+			return nil, err
+		}
+
+		if channel.TeamId != teamId || channel.DeleteAt != 0 || (channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE) {
+			return nil, model.NewAppError("UpdateTeamDefaultChannels", "app.team_default_channel.invalid_channel.app_error", nil, "team_id="+teamId+", channel_id="+channelId, http.StatusBadRequest)
+		}
+
+		if channel.Type == model.CHANNEL_PRIVATE && !isCurrentChannel[channelId] {
+			if result := <-Srv.Store.Channel().GetMember(channelId, userId); result.Err != nil {
+				return nil, model.NewAppError("UpdateTeamDefaultChannels", "app.team_default_channel.private_channel.app_error", nil, "team_id="+teamId+", channel_id="+channelId, http.StatusForbidden)
+			}
+		}
+	}
+
+	if result := <-Srv.Store.TeamDefaultChannel().ReplaceForTeam(teamId, channelIds); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.TeamDefaultChannel), nil
+	}
+}
+
+// getTeamDefaultChannelsToJoin returns the default channels of a team that a new member should be added to. Channels
+// that have been deleted since they were added to the set are skipped.
+func getTeamDefaultChannelsToJoin(teamId string) ([]*model.Channel, *model.AppError) {
+	defaultChannels, err := GetTeamDefaultChannels(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := []*model.Channel{}
+	for _, defaultChannel := range defaultChannels {
+		channel, err := GetChannel(defaultChannel.ChannelId)
+		if err != nil {
+			return nil, err
+		} else if channel.DeleteAt != 0 || channel.TeamId != teamId {
+			continue
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
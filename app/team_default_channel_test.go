@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+)
+
+func TestUpdateTeamDefaultChannels(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	otherChannel := th.CreateChannel(th.CreateTeam())
+
+	if _, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser.Id, []string{channel.Id, otherChannel.Id}); err == nil {
+		t.Fatal("shouldn't be able to use a channel from another team")
+	} else if err.Id != "app.team_default_channel.invalid_channel.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	if defaultChannels, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser.Id, []string{channel.Id, channel.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 1 || defaultChannels[0].ChannelId != channel.Id {
+		t.Fatal("should have saved the channel once")
+	}
+
+	if defaultChannels, err := GetTeamDefaultChannels(th.BasicTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 1 {
+		t.Fatal("should have returned the saved channel")
+	}
+
+	privateChannel := th.CreatePrivateChannel(th.BasicTeam)
+
+	if _, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser2.Id, []string{channel.Id, privateChannel.Id}); err == nil {
+		t.Fatal("shouldn't be able to add a private channel that the user isn't a member of")
+	} else if err.Id != "app.team_default_channel.private_channel.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	if defaultChannels, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser.Id, []string{privateChannel.Id, channel.Id}); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 2 {
+		t.Fatal("should have saved both channels")
+	}
+
+	if defaultChannels, err := GetTeamDefaultChannels(th.BasicTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if len(defaultChannels) != 2 || defaultChannels[0].ChannelId != privateChannel.Id || defaultChannels[1].ChannelId != channel.Id {
+		t.Fatal("should have kept the order of the channels")
+	}
+
+	// a private channel that's already in the set can be kept by someone who isn't a member of it
+	if _, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser2.Id, []string{channel.Id, privateChannel.Id}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJoinTeamDefaultChannels(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	privateChannel := th.CreatePrivateChannel(th.BasicTeam)
+
+	if _, err := UpdateTeamDefaultChannels(th.BasicTeam.Id, th.BasicUser.Id, []string{channel.Id, privateChannel.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := th.CreateUser()
+	if err := JoinUserToTeam(th.BasicTeam, user); err != nil {
+		t.Fatal(err)
+	}
+
+	townSquare := store.Must(Srv.Store.Channel().GetByName(th.BasicTeam.Id, "town-square", false)).(*model.Channel)
+
+	for _, channelId := range []string{townSquare.Id, channel.Id, privateChannel.Id} {
+		if result := <-Srv.Store.Channel().GetMember(channelId, user.Id); result.Err != nil {
+			t.Fatal("should have added the user to the default channel", result.Err)
+		}
+	}
+}
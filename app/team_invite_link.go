@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+// RegenerateTeamInviteLink replaces a team's invite links with a new one. The old links stop
+// working immediately on every server since links are always read from the master database.
+// A maxUses or expiresInHours of 0 means that the new link has no such limit.
+func RegenerateTeamInviteLink(teamId, creatorId string, maxUses int64, expiresInHours int64) (*model.TeamInviteLink, *model.AppError) {
+	link := &model.TeamInviteLink{
+		TeamId:    teamId,
+		CreatorId: creatorId,
+		MaxUses:   maxUses,
+	}
+
+	if expiresInHours > 0 {
+		link.ExpiresAt = model.GetMillis() + expiresInHours*60*60*1000
+	}
+
+	if result := <-Srv.Store.TeamInviteLink().DeleteForTeam(teamId, model.GetMillis()); result.Err != nil {
+		return nil, result.Err
+	}
+
+	if result := <-Srv.Store.TeamInviteLink().Save(link); result.Err != nil {
+		result.Err.StatusCode = http.StatusBadRequest
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.TeamInviteLink), nil
+	}
+}
+
+// GetTeamInviteLinks returns every link that has been created for a team, including the ones that
+// no longer work, along with the number of users that joined through each of them.
+func GetTeamInviteLinks(teamId string) ([]*model.TeamInviteLink, *model.AppError) {
+	if result := <-Srv.Store.TeamInviteLink().GetForTeam(teamId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.TeamInviteLink), nil
+	}
+}
+
+func UseTeamInviteLink(link *model.TeamInviteLink) *model.AppError {
+	if result := <-Srv.Store.TeamInviteLink().IncrementUseCount(link.Id); result.Err != nil {
+		return result.Err
+	} else if result.Data.(int64) == 0 {
+		return model.NewAppError("UseTeamInviteLink", "app.team_invite_link.inactive.app_error", nil, "id="+link.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+)
+
+func TestTeamInviteLinkMaxUses(t *testing.T) {
+	th := Setup().InitBasic()
+
+	link, err := RegenerateTeamInviteLink(th.BasicTeam.Id, th.BasicUser.Id, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if team, err := AddUserToTeamByInviteId(link.Id, th.CreateUser().Id); err != nil {
+			t.Fatal(err)
+		} else if team.Id != th.BasicTeam.Id {
+			t.Fatal("should have joined the team of the link")
+		}
+	}
+
+	if _, err := AddUserToTeamByInviteId(link.Id, th.CreateUser().Id); err == nil {
+		t.Fatal("shouldn't be able to use the link more than its maximum number of uses")
+	} else if err.Id != "app.team_invite_link.inactive.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	if links, err := GetTeamInviteLinks(th.BasicTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if len(links) != 1 || links[0].UseCount != 2 {
+		t.Fatal("should have counted the joins through the link")
+	}
+}
+
+func TestRegenerateTeamInviteLink(t *testing.T) {
+	th := Setup().InitBasic()
+
+	oldLink, err := RegenerateTeamInviteLink(th.BasicTeam.Id, th.BasicUser.Id, 0, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if oldLink.ExpiresAt == 0 {
+		t.Fatal("link should expire")
+	}
+
+	if _, err := AddUserToTeamByInviteId(oldLink.Id, th.CreateUser().Id); err != nil {
+		t.Fatal(err)
+	}
+
+	newLink, err := RegenerateTeamInviteLink(th.BasicTeam.Id, th.BasicUser.Id, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AddUserToTeamByInviteId(oldLink.Id, th.CreateUser().Id); err == nil {
+		t.Fatal("old link should have stopped working")
+	}
+
+	if _, err := AddUserToTeamByInviteId(newLink.Id, th.CreateUser().Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if links, err := GetTeamInviteLinks(th.BasicTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if len(links) != 2 {
+		t.Fatal("should have returned both links")
+	} else {
+		for _, link := range links {
+			if link.UseCount != 1 {
+				t.Fatal("each link should have been used once")
+			}
+
+			if link.Id == oldLink.Id && link.IsActive() {
+				t.Fatal("old link shouldn't be active")
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// updateThreadMemberships counts a reply as unread for everyone following its thread and makes the user who replied
+// follow the thread with everything in it read. The user who started the thread follows it from the first reply.
+func updateThreadMemberships(reply *model.Post, root *model.Post) {
+	if root.UserId != reply.UserId {
+		if result := <-Srv.Store.ThreadMembership().Get(root.Id, root.UserId); result.Err != nil && result.Err.StatusCode == http.StatusNotFound {
+			rootMembership := &model.ThreadMembership{
+				PostId:       root.Id,
+				ChannelId:    root.ChannelId,
+				UserId:       root.UserId,
+				Following:    true,
+				LastViewedAt: root.CreateAt,
+			}
+
+			if result := <-Srv.Store.ThreadMembership().Save(rootMembership); result.Err != nil {
+				l4g.Error(utils.T("app.thread.update_memberships.error"), root.Id, result.Err)
+			}
+		}
+	}
+
+	if result := <-Srv.Store.ThreadMembership().IncrementUnreadReplies(root.Id, reply.UserId); result.Err != nil {
+		l4g.Error(utils.T("app.thread.update_memberships.error"), root.Id, result.Err)
+		return
+	}
+
+	membership := &model.ThreadMembership{
+		PostId:       root.Id,
+		ChannelId:    root.ChannelId,
+		UserId:       reply.UserId,
+		Following:    true,
+		LastViewedAt: reply.CreateAt,
+	}
+
+	if result := <-Srv.Store.ThreadMembership().Save(membership); result.Err != nil {
+		l4g.Error(utils.T("app.thread.update_memberships.error"), root.Id, result.Err)
+	}
+
+	if result := <-Srv.Store.ThreadMembership().GetFollowers(root.Id); result.Err != nil {
+		l4g.Error(utils.T("app.thread.update_memberships.error"), root.Id, result.Err)
+	} else {
+		for _, follower := range result.Data.([]*model.ThreadMembership) {
+			if follower.UserId != reply.UserId {
+				sendThreadUpdatedEvent(follower)
+			}
+		}
+	}
+}
+
+// sendThreadUpdatedEvent lets a user's clients know how many unread replies are in a thread that they're following.
+func sendThreadUpdatedEvent(membership *model.ThreadMembership) {
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_THREAD_UPDATED, "", "", membership.UserId, nil)
+	message.Add("thread", membership.ToJson())
+
+	go Publish(message)
+}
+
+// GetFollowedThreads returns the user's memberships in the threads that they're following in a channel.
+func GetFollowedThreads(userId string, channelId string) ([]*model.ThreadMembership, *model.AppError) {
+	if result := <-Srv.Store.ThreadMembership().GetForUser(userId, channelId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.ThreadMembership), nil
+	}
+}
+
+// MarkThreadRead clears the unread replies in a thread for a user, and lets their other clients know that it has been
+// read.
+func MarkThreadRead(postId string, userId string) (*model.ThreadMembership, *model.AppError) {
+	if result := <-Srv.Store.ThreadMembership().MarkRead(postId, userId, model.GetMillis()); result.Err != nil {
+		return nil, result.Err
+	} else {
+		membership := result.Data.(*model.ThreadMembership)
+		sendThreadUpdatedEvent(membership)
+		return membership, nil
+	}
+}
+
+func DeleteThreadMemberships(postId string) {
+	if result := <-Srv.Store.ThreadMembership().DeleteForPost(postId); result.Err != nil {
+		l4g.Warn(utils.T("app.thread.delete_memberships.warn"), postId, result.Err)
+	}
+}
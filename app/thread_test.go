@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestThreadMemberships(t *testing.T) {
+	th := Setup().InitBasic()
+	root := th.BasicPost
+
+	if _, err := AddUserToChannel(th.BasicUser2, th.BasicChannel); err != nil {
+		t.Fatal(err)
+	}
+
+	reply := &model.Post{UserId: th.BasicUser2.Id, ChannelId: root.ChannelId, RootId: root.Id, Message: "reply"}
+	if _, err := CreatePost(reply, th.BasicTeam.Id, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if memberships, err := GetFollowedThreads(th.BasicUser.Id, root.ChannelId); err != nil {
+		t.Fatal(err)
+	} else if len(memberships) != 1 || memberships[0].UnreadReplies != 1 || memberships[0].LastViewedAt != root.CreateAt {
+		t.Fatal("the user who started the thread should be following it with the reply unread")
+	}
+
+	if memberships, err := GetFollowedThreads(th.BasicUser2.Id, root.ChannelId); err != nil {
+		t.Fatal(err)
+	} else if len(memberships) != 1 || memberships[0].UnreadReplies != 0 {
+		t.Fatal("the user who replied should be following the thread with nothing unread")
+	}
+
+	if membership, err := MarkThreadRead(root.Id, th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if membership.UnreadReplies != 0 {
+		t.Fatal("should have marked the thread as read")
+	}
+
+	if _, err := DeletePost(root.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// the memberships are deleted in the background
+	DeleteThreadMemberships(root.Id)
+
+	if memberships, err := GetFollowedThreads(th.BasicUser.Id, root.ChannelId); err != nil {
+		t.Fatal(err)
+	} else if len(memberships) != 0 {
+		t.Fatal("should have deleted the memberships of a deleted thread")
+	}
+}
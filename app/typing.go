@@ -0,0 +1,302 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	// Users who start or stop typing within this long of each other are reported together
+	TYPING_COALESCE_INTERVAL = 500 * time.Millisecond
+
+	// How long after their last typing message a user is still considered to be typing on top of the time that
+	// clients wait between typing messages
+	TYPING_EXPIRY_GRACE = 2 * time.Second
+)
+
+type typingLocation struct {
+	channelId string
+	parentId  string
+}
+
+// remoteTypingUsers are the users typing in a location on another node of the cluster. Nodes send them again while
+// they're typing, so they're forgotten if the node stops sending them.
+type remoteTypingUsers struct {
+	userIds  []string
+	expireAt time.Time
+}
+
+// sentTypingUsers are the users typing in a location on this node that were last sent to the other nodes.
+type sentTypingUsers struct {
+	userIds []string
+	sentAt  time.Time
+}
+
+// typingAggregator keeps track of who is typing in each channel and thread so that clients are sent a list of the
+// users that are currently typing when it changes instead of an event for every typing message. Each node of a
+// cluster sends the users typing on it to the other nodes, which merge them with their own.
+type typingAggregator struct {
+	mutex         sync.Mutex
+	typing        map[typingLocation]map[string]time.Time
+	remote        map[typingLocation]map[string]*remoteTypingUsers
+	published     map[typingLocation][]string
+	sent          map[typingLocation]*sentTypingUsers
+	expiry        time.Duration
+	running       bool
+	publish       func(*model.WebSocketEvent)
+	sendToCluster func(*model.WebSocketEvent)
+}
+
+var typingUsers = newTypingAggregator(publishToHubs, sendTypingUsersToCluster)
+
+func newTypingAggregator(publish func(*model.WebSocketEvent), sendToCluster func(*model.WebSocketEvent)) *typingAggregator {
+	return &typingAggregator{
+		typing:        make(map[typingLocation]map[string]time.Time),
+		remote:        make(map[typingLocation]map[string]*remoteTypingUsers),
+		published:     make(map[typingLocation][]string),
+		sent:          make(map[typingLocation]*sentTypingUsers),
+		publish:       publish,
+		sendToCluster: sendToCluster,
+	}
+}
+
+// UserTyping records that a user is typing in a channel, or in a thread of it if parentId is set.
+func UserTyping(channelId, parentId, userId string) {
+	typingUsers.userTyping(channelId, parentId, userId, typingExpiry())
+}
+
+// UserStoppedTyping records that a user is no longer typing, such as because they just posted their message.
+func UserStoppedTyping(channelId, parentId, userId string) {
+	typingUsers.userStoppedTyping(channelId, parentId, userId)
+}
+
+func typingExpiry() time.Duration {
+	return time.Duration(*utils.Cfg.ServiceSettings.TimeBetweenUserTypingUpdatesMilliseconds)*time.Millisecond + TYPING_EXPIRY_GRACE
+}
+
+func sendTypingUsersToCluster(event *model.WebSocketEvent) {
+	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
+		event.Add("node_id", cluster.GetClusterId())
+		cluster.Publish(event)
+	}
+}
+
+// isTypingUsersFromNode returns true if an event is the users typing on another node of the cluster rather than an
+// event for clients.
+func isTypingUsersFromNode(event *model.WebSocketEvent) bool {
+	if event.Event != model.WEBSOCKET_EVENT_TYPING_USERS {
+		return false
+	}
+
+	_, ok := event.Data["node_id"]
+	return ok
+}
+
+func (a *typingAggregator) userTyping(channelId, parentId, userId string, expiry time.Duration) {
+	loc := typingLocation{channelId, parentId}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	users := a.typing[loc]
+	if users == nil {
+		users = make(map[string]time.Time)
+		a.typing[loc] = users
+	}
+
+	users[userId] = time.Now().Add(expiry)
+	a.expiry = expiry
+
+	a.start()
+}
+
+func (a *typingAggregator) userStoppedTyping(channelId, parentId, userId string) {
+	loc := typingLocation{channelId, parentId}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	delete(a.typing[loc], userId)
+}
+
+// receiveFromNode records the users typing in a location on another node of the cluster.
+func (a *typingAggregator) receiveFromNode(event *model.WebSocketEvent, expiry time.Duration) {
+	nodeId, _ := event.Data["node_id"].(string)
+	parentId, _ := event.Data["parent_id"].(string)
+	loc := typingLocation{event.Broadcast.ChannelId, parentId}
+
+	// the user ids are decoded from JSON when the event comes from another node
+	var userIds []string
+	switch ids := event.Data["user_ids"].(type) {
+	case []string:
+		userIds = ids
+	case []interface{}:
+		for _, id := range ids {
+			if userId, ok := id.(string); ok {
+				userIds = append(userIds, userId)
+			}
+		}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(userIds) == 0 {
+		delete(a.remote[loc], nodeId)
+		return
+	}
+
+	if a.remote[loc] == nil {
+		a.remote[loc] = make(map[string]*remoteTypingUsers)
+	}
+	a.remote[loc][nodeId] = &remoteTypingUsers{userIds: userIds, expireAt: time.Now().Add(expiry)}
+
+	a.start()
+}
+
+// start starts reporting the users typing if it isn't already. It must be called with the mutex held.
+func (a *typingAggregator) start() {
+	if a.running {
+		return
+	}
+
+	a.running = true
+	go func() {
+		ticker := time.NewTicker(TYPING_COALESCE_INTERVAL)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !a.tick(time.Now()) {
+				return
+			}
+		}
+	}()
+}
+
+// tick forgets users who haven't typed in a while and reports the users typing in each location where they've changed.
+// It returns false once nobody is typing anywhere, at which point reporting stops until somebody starts typing again.
+func (a *typingAggregator) tick(now time.Time) bool {
+	a.mutex.Lock()
+
+	locs := make(map[typingLocation]bool)
+	for loc := range a.typing {
+		locs[loc] = true
+	}
+	for loc := range a.remote {
+		locs[loc] = true
+	}
+	for loc := range a.published {
+		locs[loc] = true
+	}
+	for loc := range a.sent {
+		locs[loc] = true
+	}
+
+	var toCluster, toClients []*model.WebSocketEvent
+
+	for loc := range locs {
+		local := []string{}
+		for userId, expireAt := range a.typing[loc] {
+			if expireAt.After(now) {
+				local = append(local, userId)
+			} else {
+				delete(a.typing[loc], userId)
+			}
+		}
+		sort.Strings(local)
+
+		merged := make(map[string]bool, len(local))
+		for _, userId := range local {
+			merged[userId] = true
+		}
+		for nodeId, remote := range a.remote[loc] {
+			if remote.expireAt.After(now) {
+				for _, userId := range remote.userIds {
+					merged[userId] = true
+				}
+			} else {
+				delete(a.remote[loc], nodeId)
+			}
+		}
+
+		userIds := make([]string, 0, len(merged))
+		for userId := range merged {
+			userIds = append(userIds, userId)
+		}
+		sort.Strings(userIds)
+
+		// the other nodes are sent the users typing here again before they would forget them
+		sent := a.sent[loc]
+		changed := sent == nil && len(local) > 0 || sent != nil && !typingUsersEqual(sent.userIds, local)
+		stale := sent != nil && len(local) > 0 && now.Sub(sent.sentAt) >= a.expiry/2
+		if changed || stale {
+			toCluster = append(toCluster, newTypingUsersEvent(loc, local))
+
+			if len(local) > 0 {
+				a.sent[loc] = &sentTypingUsers{userIds: local, sentAt: now}
+			} else {
+				delete(a.sent, loc)
+			}
+		}
+
+		if !typingUsersEqual(a.published[loc], userIds) {
+			toClients = append(toClients, newTypingUsersEvent(loc, userIds))
+
+			if len(userIds) > 0 {
+				a.published[loc] = userIds
+			} else {
+				delete(a.published, loc)
+			}
+		}
+
+		if len(a.typing[loc]) == 0 {
+			delete(a.typing, loc)
+		}
+		if len(a.remote[loc]) == 0 {
+			delete(a.remote, loc)
+		}
+	}
+
+	a.running = len(a.typing) > 0 || len(a.remote) > 0 || len(a.published) > 0 || len(a.sent) > 0
+	running := a.running
+
+	a.mutex.Unlock()
+
+	for _, event := range toCluster {
+		a.sendToCluster(event)
+	}
+	for _, event := range toClients {
+		a.publish(event)
+	}
+
+	return running
+}
+
+func newTypingUsersEvent(loc typingLocation, userIds []string) *model.WebSocketEvent {
+	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING_USERS, "", loc.channelId, "", nil)
+	event.Add("parent_id", loc.parentId)
+	event.Add("user_ids", userIds)
+	return event
+}
+
+func typingUsersEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func receiveTypingUsers(t *testing.T, events chan *model.WebSocketEvent) []string {
+	select {
+	case event := <-events:
+		if event.Event != model.WEBSOCKET_EVENT_TYPING_USERS {
+			t.Fatal("sent the wrong event", event.Event)
+		}
+
+		return event.Data["user_ids"].([]string)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't send who is typing")
+	}
+
+	return nil
+}
+
+func TestTypingAggregatorCoalescesEvents(t *testing.T) {
+	events := make(chan *model.WebSocketEvent, 10)
+	aggregator := newTypingAggregator(func(event *model.WebSocketEvent) {
+		events <- event
+	}, func(event *model.WebSocketEvent) {})
+
+	channelId := model.NewId()
+	user1 := "a" + model.NewId()[1:]
+	user2 := "b" + model.NewId()[1:]
+
+	for i := 0; i < 10; i++ {
+		aggregator.userTyping(channelId, "", user1, time.Minute)
+		aggregator.userTyping(channelId, "", user2, time.Minute)
+	}
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 2 || userIds[0] != user1 || userIds[1] != user2 {
+		t.Fatal("should have sent both typing users at once", userIds)
+	}
+
+	aggregator.userTyping(channelId, "", user1, time.Minute)
+
+	select {
+	case event := <-events:
+		t.Fatal("shouldn't have sent anything when nobody started or stopped typing", event.Data)
+	case <-time.After(2 * TYPING_COALESCE_INTERVAL):
+	}
+
+	aggregator.userStoppedTyping(channelId, "", user1)
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 1 || userIds[0] != user2 {
+		t.Fatal("should have removed the user who stopped typing", userIds)
+	}
+}
+
+func TestTypingAggregatorExpiry(t *testing.T) {
+	events := make(chan *model.WebSocketEvent, 10)
+	aggregator := newTypingAggregator(func(event *model.WebSocketEvent) {
+		events <- event
+	}, func(event *model.WebSocketEvent) {})
+
+	channelId := model.NewId()
+	parentId := model.NewId()
+	userId := model.NewId()
+
+	aggregator.userTyping(channelId, parentId, userId, TYPING_COALESCE_INTERVAL*2)
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 1 {
+		t.Fatal("should have sent the typing user", userIds)
+	}
+
+	var event *model.WebSocketEvent
+	select {
+	case event = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't send that the user stopped typing")
+	}
+
+	if event.Broadcast.ChannelId != channelId || event.Data["parent_id"] != parentId {
+		t.Fatal("should have sent the event to the thread's channel")
+	} else if userIds := event.Data["user_ids"].([]string); len(userIds) != 0 {
+		t.Fatal("should have stopped typing after the expiry", userIds)
+	}
+
+	// the aggregator stops once nobody is typing
+	time.Sleep(2 * TYPING_COALESCE_INTERVAL)
+
+	aggregator.mutex.Lock()
+	defer aggregator.mutex.Unlock()
+
+	if len(aggregator.typing) != 0 || len(aggregator.published) != 0 || aggregator.running {
+		t.Fatal("should have forgotten about the channel once nobody is typing")
+	}
+}
+
+func TestTypingAggregatorCluster(t *testing.T) {
+	events := make(chan *model.WebSocketEvent, 10)
+	toCluster := make(chan *model.WebSocketEvent, 10)
+	aggregator := newTypingAggregator(func(event *model.WebSocketEvent) {
+		events <- event
+	}, func(event *model.WebSocketEvent) {
+		event.Add("node_id", "node1")
+		toCluster <- event
+	})
+
+	channelId := model.NewId()
+	user1 := "a" + model.NewId()[1:]
+	user2 := "b" + model.NewId()[1:]
+
+	aggregator.userTyping(channelId, "", user1, time.Minute)
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 1 || userIds[0] != user1 {
+		t.Fatal("should have sent the local typing user", userIds)
+	}
+
+	var sent *model.WebSocketEvent
+	select {
+	case sent = <-toCluster:
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't send the local typing user to the other nodes")
+	}
+
+	// the event is sent to the other nodes as JSON
+	fromNode := model.WebSocketEventFromJson(strings.NewReader(sent.ToJson()))
+	if !isTypingUsersFromNode(fromNode) {
+		t.Fatal("should be recognized as the users typing on another node")
+	}
+
+	remote := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING_USERS, "", channelId, "", nil)
+	remote.Add("parent_id", "")
+	remote.Add("node_id", "node2")
+	var userIds []interface{}
+	json.Unmarshal([]byte(`["`+user2+`"]`), &userIds)
+	remote.Add("user_ids", userIds)
+	aggregator.receiveFromNode(remote, time.Minute)
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 2 || userIds[0] != user1 || userIds[1] != user2 {
+		t.Fatal("should have merged the users typing on the other node", userIds)
+	}
+
+	remote.Add("user_ids", []string{})
+	aggregator.receiveFromNode(remote, time.Minute)
+
+	if userIds := receiveTypingUsers(t, events); len(userIds) != 1 || userIds[0] != user1 {
+		t.Fatal("should have removed the users who stopped typing on the other node", userIds)
+	}
+
+	aggregator.userStoppedTyping(channelId, "", user1)
+	receiveTypingUsers(t, events)
+}
@@ -23,7 +23,6 @@ import (
 	"strings"
 
 	l4g "github.com/alecthomas/log4go"
-	"github.com/disintegration/imaging"
 	"github.com/golang/freetype"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
@@ -77,18 +76,28 @@ func CreateUserWithInviteId(user *model.User, inviteId string, siteURL string) (
 		return nil, err
 	}
 
-	var team *model.Team
-	if result := <-Srv.Store.Team().GetByInviteId(inviteId); result.Err != nil {
-		return nil, result.Err
-	} else {
-		team = result.Data.(*model.Team)
+	invitation, link, team, err := GetInvite(inviteId)
+	if err != nil {
+		return nil, err
 	}
 
-	user.EmailVerified = false
+	// The invitation is used up before the account is created so that an invitation which can't be used doesn't leave
+	// an account behind. The password is checked first so that a mistake in it doesn't use up the invitation, and the
+	// invitation is given back if the account can't be created for any other reason.
+	if err := utils.IsPasswordValid(user.Password); user.AuthService == "" && err != nil {
+		return nil, err
+	}
+
+	if err := UseInvite(invitation, link, user.Email); err != nil {
+		return nil, err
+	}
+
+	// an invitation that was emailed to the user means that their address doesn't need to be verified again
+	user.EmailVerified = invitation != nil && len(invitation.Email) > 0
 
 	var ruser *model.User
-	var err *model.AppError
 	if ruser, err = CreateUser(user); err != nil {
+		RestoreInvite(invitation, link)
 		return nil, err
 	}
 
@@ -671,7 +680,12 @@ func GetProfileImage(user *model.User) ([]byte, *model.AppError) {
 		if img, err = CreateProfileImage(user.Username, user.Id); err != nil {
 			return nil, err
 		}
+	} else if info, err := getProfileImageInfo(user.Id); err != nil {
+		return nil, err
+	} else if info != nil {
+		return ReadFile(info.Path)
 	} else {
+		// Users who haven't uploaded a picture since they started being stored as files still have it at the old path
 		path := "users/" + user.Id + "/profile.png"
 
 		if data, err := ReadFile(path); err != nil {
@@ -716,36 +730,11 @@ func SetProfileImage(userId string, imageData *multipart.FileHeader) *model.AppE
 		return model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.decode.app_error", nil, err.Error())
 	}
 
-	// Scale profile image
-	img = imaging.Resize(img, utils.Cfg.FileSettings.ProfileWidth, utils.Cfg.FileSettings.ProfileHeight, imaging.Lanczos)
-
-	buf := new(bytes.Buffer)
-	err = png.Encode(buf, img)
-	if err != nil {
-		return model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.encode.app_error", nil, err.Error())
-	}
-
-	path := "users/" + userId + "/profile.png"
-
-	if err := WriteFile(buf.Bytes(), path); err != nil {
-		return model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.upload_profile.app_error", nil, "")
+	if _, err := saveProfileImage(userId, img); err != nil {
+		return err
 	}
 
-	Srv.Store.User().UpdateLastPictureUpdate(userId)
-
-	if user, err := GetUser(userId); err != nil {
-		l4g.Error(utils.T("api.user.get_me.getting.error"), userId)
-	} else {
-		options := utils.Cfg.GetSanitizeOptions()
-		user.SanitizeProfile(options)
-
-		omitUsers := make(map[string]bool, 1)
-		omitUsers[userId] = true
-		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_USER_UPDATED, "", "", "", omitUsers)
-		message.Add("user", user)
-
-		Publish(message)
-	}
+	profileImageChanged(userId)
 
 	return nil
 }
@@ -939,6 +928,11 @@ func UpdatePassword(user *model.User, newPassword string) *model.AppError {
 		return model.NewLocAppError("UpdatePassword", "api.user.update_password.failed.app_error", nil, result.Err.Error())
 	}
 
+	// setting a new password also lifts any lockout caused by guessing the old one
+	if err := UnlockUser(user.Id); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1082,6 +1076,10 @@ func UpdateUserRoles(userId string, newRoles string) (*model.User, *model.AppErr
 }
 
 func PermanentDeleteUser(user *model.User) *model.AppError {
+	if err := checkNotUnderLegalHold(user.Id, ""); err != nil {
+		return err
+	}
+
 	l4g.Warn(utils.T("api.user.permanent_delete_user.attempting.warn"), user.Email, user.Id)
 	if user.IsInRole(model.ROLE_SYSTEM_ADMIN.Id) {
 		l4g.Warn(utils.T("api.user.permanent_delete_user.system_admin.warn"), user.Email)
@@ -1095,6 +1093,14 @@ func PermanentDeleteUser(user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.UserAccessToken().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Pseudonym().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.OAuth().PermanentDeleteAuthDataByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -1115,14 +1121,35 @@ func PermanentDeleteUser(user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if err := deleteProfileImages(user.Id, 0); err != nil {
+		return err
+	}
+
 	if result := <-Srv.Store.Channel().PermanentDeleteMembersByUser(user.Id); result.Err != nil {
 		return result.Err
+	} else {
+		for _, channelId := range result.Data.([]string) {
+			InvalidateCacheForChannelMembers(channelId)
+		}
+	}
+
+	// the previews and translations are found through the user's posts, so they need to be deleted first
+	if result := <-Srv.Store.PermalinkPreview().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.PostTranslation().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
 	}
 
 	if result := <-Srv.Store.Post().PermanentDeleteByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.LoginAttempt().Delete(model.LOGIN_ATTEMPT_TYPE_USER, user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.User().PermanentDelete(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -0,0 +1,236 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	USER_ACCESS_TOKEN_RATE_LIMIT_WINDOW  = 60 * 1000
+	USER_ACCESS_TOKEN_LAST_USED_INTERVAL = 60 * 1000
+)
+
+// userAccessTokenUse counts the requests made with a personal access token on this server in the current rate limit
+// window, and when its last used time was last saved. Rate limits are applied by each server separately.
+type userAccessTokenUse struct {
+	windowStart int64
+	requests    int
+	lastSavedAt int64
+}
+
+var userAccessTokenUses = make(map[string]*userAccessTokenUse)
+var userAccessTokenUsesLock sync.Mutex
+
+var (
+	userAccessTokenFilesPattern    = regexp.MustCompile(`^/(teams/[A-Za-z0-9]+/)?files/`)
+	userAccessTokenPostsPattern    = regexp.MustCompile(`^/(teams/[A-Za-z0-9]+/)?(channels/[A-Za-z0-9]+/)?(posts|pltmp)(/|$)`)
+	userAccessTokenChannelsPattern = regexp.MustCompile(`^/(teams/[A-Za-z0-9]+/)?channels(/|$)`)
+)
+
+func CreateUserAccessToken(token *model.UserAccessToken) (*model.UserAccessToken, *model.AppError) {
+	if !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+		return nil, model.NewAppError("CreateUserAccessToken", "app.user_access_token.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if user, err := GetUser(token.UserId); err != nil {
+		return nil, err
+	} else if user.DeleteAt != 0 {
+		return nil, model.NewAppError("CreateUserAccessToken", "app.user_access_token.inactive_user.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.UserAccessToken().Save(token); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.UserAccessToken), nil
+	}
+}
+
+func GetUserAccessToken(tokenId string) (*model.UserAccessToken, *model.AppError) {
+	if result := <-Srv.Store.UserAccessToken().Get(tokenId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		token := result.Data.(*model.UserAccessToken)
+		token.Sanitize()
+		return token, nil
+	}
+}
+
+func GetUserAccessTokensForUser(userId string) ([]*model.UserAccessToken, *model.AppError) {
+	if result := <-Srv.Store.UserAccessToken().GetByUser(userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		tokens := result.Data.([]*model.UserAccessToken)
+		for _, token := range tokens {
+			token.Sanitize()
+		}
+
+		return tokens, nil
+	}
+}
+
+// RevokeUserAccessToken deletes a personal access token and logs out the sessions that were created for it.
+func RevokeUserAccessToken(token *model.UserAccessToken) *model.AppError {
+	if result := <-Srv.Store.UserAccessToken().Delete(token.Id); result.Err != nil {
+		return result.Err
+	}
+
+	userAccessTokenUsesLock.Lock()
+	delete(userAccessTokenUses, token.Id)
+	userAccessTokenUsesLock.Unlock()
+
+	ClearSessionCacheForUser(token.UserId)
+
+	return nil
+}
+
+// createSessionForUserAccessToken returns the session for the personal access token with the given value. Sessions
+// for tokens aren't saved, so it's only cached.
+func createSessionForUserAccessToken(tokenString string) (*model.Session, *model.AppError) {
+	var token *model.UserAccessToken
+	if result := <-Srv.Store.UserAccessToken().GetByToken(tokenString); result.Err != nil {
+		return nil, result.Err
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	user, err := GetUser(token.UserId)
+	if err != nil {
+		return nil, err
+	} else if user.DeleteAt != 0 {
+		return nil, model.NewAppError("createSessionForUserAccessToken", "app.user_access_token.inactive_user.app_error", nil, "user_id="+user.Id, http.StatusUnauthorized)
+	}
+
+	session := token.NewSession(user)
+
+	if result := <-Srv.Store.Team().GetTeamsForUser(user.Id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		members := result.Data.([]*model.TeamMember)
+		session.TeamMembers = make([]*model.TeamMember, 0, len(members))
+		for _, member := range members {
+			if member.DeleteAt == 0 {
+				session.TeamMembers = append(session.TeamMembers, member)
+			}
+		}
+	}
+
+	AddSessionToCache(session)
+
+	return session, nil
+}
+
+// RecordUserAccessTokenUse counts a request made with a personal access token against its rate limit, returning an
+// error if the limit has been reached, and updates when the token was last used at most once a minute.
+func RecordUserAccessTokenUse(session *model.Session) *model.AppError {
+	if !session.IsUserAccessToken() {
+		return nil
+	}
+
+	tokenId := session.Props[model.SESSION_PROP_USER_ACCESS_TOKEN_ID]
+	limit := session.GetUserAccessTokenRateLimit()
+	now := model.GetMillis()
+
+	userAccessTokenUsesLock.Lock()
+
+	use, ok := userAccessTokenUses[tokenId]
+	if !ok {
+		use = &userAccessTokenUse{}
+		userAccessTokenUses[tokenId] = use
+	}
+
+	if now-use.windowStart >= USER_ACCESS_TOKEN_RATE_LIMIT_WINDOW {
+		use.windowStart = now
+		use.requests = 0
+	}
+
+	if limit > 0 && use.requests >= limit {
+		userAccessTokenUsesLock.Unlock()
+		return model.NewAppError("RecordUserAccessTokenUse", "app.user_access_token.rate_limit.app_error", nil, "token_id="+tokenId, http.StatusTooManyRequests)
+	}
+
+	use.requests++
+
+	save := now-use.lastSavedAt >= USER_ACCESS_TOKEN_LAST_USED_INTERVAL
+	if save {
+		use.lastSavedAt = now
+	}
+
+	userAccessTokenUsesLock.Unlock()
+
+	if save {
+		if result := <-Srv.Store.UserAccessToken().UpdateLastUsedAt(tokenId, now); result.Err != nil {
+			l4g.Error(utils.T("app.user_access_token.update_last_used_at.error"), tokenId, result.Err)
+		}
+	}
+
+	return nil
+}
+
+// CheckUserAccessTokenScope returns an error if a request made with a personal access token isn't covered by one of
+// the token's scopes. Every version of the API checks requests with it so that a token can't be used for more than
+// it was given by making the same request through another one. Tokens without scopes can be used for any request.
+func CheckUserAccessTokenScope(session *model.Session, method string, path string) *model.AppError {
+	tokenScopes := session.GetUserAccessTokenScopes()
+	if len(tokenScopes) == 0 {
+		return nil
+	}
+
+	for _, scope := range userAccessTokenScopesForRequest(method, path) {
+		for _, tokenScope := range tokenScopes {
+			if scope == tokenScope {
+				return nil
+			}
+		}
+	}
+
+	return model.NewAppError("CheckUserAccessTokenScope", "app.user_access_token.scope.app_error", nil, "path="+path, http.StatusForbidden)
+}
+
+// userAccessTokenScopesForRequest returns the personal access token scopes that allow a request to be made. Files can
+// be read with read-posts and uploaded with write-posts so that the attachments of posts can be, channels can be read
+// with read-posts so that their posts can be found, and any scope allows the token's user to be read. Nothing else,
+// including the websocket, can be used with a scoped token.
+func userAccessTokenScopesForRequest(method string, path string) []string {
+	if strings.HasPrefix(path, model.API_URL_SUFFIX_V3+"/") {
+		path = strings.TrimPrefix(path, model.API_URL_SUFFIX_V3)
+	} else if strings.HasPrefix(path, model.API_URL_SUFFIX_V4+"/") {
+		path = strings.TrimPrefix(path, model.API_URL_SUFFIX_V4)
+	} else {
+		return []string{}
+	}
+
+	isRead := method == "GET"
+
+	switch {
+	case path == "/users/me" && isRead:
+		return model.UserAccessTokenScopes
+	case userAccessTokenFilesPattern.MatchString(path):
+		if isRead {
+			return []string{model.USER_ACCESS_TOKEN_SCOPE_FILES_ONLY, model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}
+		} else if strings.HasSuffix(path, "/upload") {
+			return []string{model.USER_ACCESS_TOKEN_SCOPE_FILES_ONLY, model.USER_ACCESS_TOKEN_SCOPE_WRITE_POSTS}
+		}
+		return []string{model.USER_ACCESS_TOKEN_SCOPE_FILES_ONLY}
+	case userAccessTokenPostsPattern.MatchString(path):
+		if isRead || strings.HasSuffix(path, "/posts/search") {
+			return []string{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}
+		}
+		return []string{model.USER_ACCESS_TOKEN_SCOPE_WRITE_POSTS}
+	case userAccessTokenChannelsPattern.MatchString(path):
+		if isRead {
+			return []string{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS, model.USER_ACCESS_TOKEN_SCOPE_MANAGE_CHANNELS}
+		}
+		return []string{model.USER_ACCESS_TOKEN_SCOPE_MANAGE_CHANNELS}
+	}
+
+	return []string{}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestUserAccessTokenSession(t *testing.T) {
+	th := Setup().InitBasic()
+
+	enable := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enable
+	}()
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = false
+	if _, err := CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id}); err == nil || err.StatusCode != http.StatusNotImplemented {
+		t.Fatal("shouldn't have created a token while they're disabled")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+	token, err := CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id, Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := GetSession(token.Token)
+	if err != nil {
+		t.Fatal(err)
+	} else if !session.IsUserAccessToken() || session.UserId != th.BasicUser.Id || len(session.TeamMembers) != 1 {
+		t.Fatal("should have created a session for the token's user")
+	}
+
+	if tokens, err := GetUserAccessTokensForUser(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if len(tokens) != 1 || tokens[0].Id != token.Id || tokens[0].Token != "" {
+		t.Fatal("should have returned the token without its value")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = false
+	if _, err := GetSession(token.Token); err == nil {
+		t.Fatal("shouldn't have accepted a token while they're disabled")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+	if err := RevokeUserAccessToken(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetSession(token.Token); err == nil {
+		t.Fatal("shouldn't have accepted a revoked token")
+	}
+}
+
+func TestRecordUserAccessTokenUse(t *testing.T) {
+	th := Setup().InitBasic()
+
+	enable := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enable
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	token, err := CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id, RateLimit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := GetSession(token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := RecordUserAccessTokenUse(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RecordUserAccessTokenUse(session); err == nil || err.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("should have been rate limited")
+	}
+
+	if saved, err := GetUserAccessToken(token.Id); err != nil {
+		t.Fatal(err)
+	} else if saved.LastUsedAt == 0 {
+		t.Fatal("should have recorded when the token was used")
+	}
+
+	if err := RecordUserAccessTokenUse(&model.Session{}); err != nil {
+		t.Fatal("shouldn't limit sessions that aren't for tokens")
+	}
+}
+
+func TestCheckUserAccessTokenScope(t *testing.T) {
+	token := &model.UserAccessToken{Id: model.NewId(), Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}}
+	session := token.NewSession(&model.User{Id: model.NewId()})
+
+	for _, test := range []struct {
+		method  string
+		path    string
+		allowed bool
+	}{
+		{"GET", model.API_URL_SUFFIX_V3 + "/users/me", true},
+		{"GET", model.API_URL_SUFFIX_V3 + "/teams/" + model.NewId() + "/channels/" + model.NewId() + "/posts/page/0/60", true},
+		{"POST", model.API_URL_SUFFIX_V3 + "/teams/" + model.NewId() + "/channels/" + model.NewId() + "/posts/create", false},
+		{"GET", model.API_URL_SUFFIX_V4 + "/users/me", true},
+		{"GET", model.API_URL_SUFFIX_V4 + "/channels/" + model.NewId() + "/posts", true},
+		{"POST", model.API_URL_SUFFIX_V4 + "/channels", false},
+		{"GET", model.API_URL_SUFFIX_V4 + "/users/" + model.NewId(), false},
+		{"GET", model.API_URL_SUFFIX_V3 + "/users/websocket", false},
+		{"GET", "/static/posts", false},
+	} {
+		if err := CheckUserAccessTokenScope(session, test.method, test.path); (err == nil) != test.allowed {
+			t.Fatalf("wrong result for %v %v", test.method, test.path)
+		}
+	}
+
+	if err := CheckUserAccessTokenScope((&model.UserAccessToken{Id: model.NewId()}).NewSession(&model.User{Id: model.NewId()}), "GET", model.API_URL_SUFFIX_V3+"/users/websocket"); err != nil {
+		t.Fatal("tokens without scopes should be allowed to make any request")
+	}
+}
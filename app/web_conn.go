@@ -21,6 +21,19 @@ const (
 	PONG_WAIT    = 100 * time.Second
 	PING_PERIOD  = (PONG_WAIT * 6) / 10
 	AUTH_TIMEOUT = 5 * time.Second
+
+	WEBSOCKET_EVENT_BUFFER_SIZE     = 128
+	WEBSOCKET_HUB_EVENT_BUFFER_SIZE = 4096
+	WEBSOCKET_RESUME_WINDOW         = 2 * time.Minute
+)
+
+// The states of a connection as tracked by its hub. A connection is pending until it's authenticated and has been sent
+// its hello, and is dead after the client disconnects or falls too far behind. Dead connections are kept until they
+// expire so that the client can resume them, and catch up on the events broadcast since they died when that happens.
+const (
+	webConnPending = iota
+	webConnActive
+	webConnDead
 )
 
 type WebConn struct {
@@ -33,6 +46,16 @@ type WebConn struct {
 	Locale                    string
 	AllChannelMembers         map[string]string
 	LastAllChannelMembersTime int64
+	ConnectionId              string
+
+	// These are only used by the connection's hub
+	state          int
+	deadAt         int64
+	deadEventCount int64
+	sequence       int64
+	events         []*model.SequencedWebSocketEvent
+	resuming       bool
+	resumeSequence int64
 }
 
 func NewWebConn(ws *websocket.Conn, session model.Session, t goi18n.TranslateFunc, locale string) *WebConn {
@@ -48,9 +71,18 @@ func NewWebConn(ws *websocket.Conn, session model.Session, t goi18n.TranslateFun
 		SessionExpiresAt: session.ExpiresAt,
 		T:                t,
 		Locale:           locale,
+		ConnectionId:     model.NewId(),
 	}
 }
 
+// SetResume makes the connection resume a previous one that the client was disconnected from, replaying the events
+// after the last one that the client received on it. It must be called before the connection is registered.
+func (webCon *WebConn) SetResume(connectionId string, sequence int64) {
+	webCon.ConnectionId = connectionId
+	webCon.resuming = true
+	webCon.resumeSequence = sequence
+}
+
 func (c *WebConn) ReadPump() {
 	defer func() {
 		HubUnregister(c)
@@ -103,7 +135,7 @@ func (c *WebConn) WritePump() {
 			}
 
 			c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
-			if err := c.WebSocket.WriteMessage(websocket.TextMessage, msg.GetPreComputeJson()); err != nil {
+			if err := c.writeMessage(msg); err != nil {
 				// browsers will appear as CloseNoStatusReceived
 				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
 					l4g.Debug(fmt.Sprintf("websocket.send: client side closed socket userId=%v", c.UserId))
@@ -143,6 +175,27 @@ func (c *WebConn) WritePump() {
 	}
 }
 
+// writeMessage writes a message to the websocket. Sequenced events are written straight from the JSON that they share
+// with the other connections that they're sent on.
+func (c *WebConn) writeMessage(msg model.WebSocketMessage) error {
+	event, ok := msg.(*model.SequencedWebSocketEvent)
+	if !ok {
+		return c.WebSocket.WriteMessage(websocket.TextMessage, msg.GetPreComputeJson())
+	}
+
+	w, err := c.WebSocket.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+
+	if err := event.WriteJson(w); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
 func (webCon *WebConn) InvalidateCache() {
 	webCon.AllChannelMembers = nil
 	webCon.LastAllChannelMembersTime = 0
@@ -171,11 +224,42 @@ func (webCon *WebConn) IsAuthenticated() bool {
 	return true
 }
 
-func (webCon *WebConn) SendHello() {
+// helloEvent returns the first event sent on a connection. It tells the client the id to resume the connection with
+// and whether it resumed a previous one. A client that asked to resume a connection and couldn't has missed events
+// and needs to reload its data.
+func (webCon *WebConn) helloEvent(resumed bool) *model.WebSocketEvent {
 	msg := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_HELLO, "", "", webCon.UserId, nil)
 	msg.Add("server_version", fmt.Sprintf("%v.%v.%v", model.CurrentVersion, model.BuildNumber, utils.CfgHash))
+	msg.Add("connection_id", webCon.ConnectionId)
+	msg.Add("resumed", resumed)
 	msg.DoPreComputeJson()
-	webCon.Send <- msg
+	return msg
+}
+
+// sequenceEvent numbers an event with the connection's next sequence number and keeps it in case the client reconnects
+// without having received it.
+func (webCon *WebConn) sequenceEvent(msg *model.WebSocketEvent) *model.SequencedWebSocketEvent {
+	webCon.sequence++
+
+	event := &model.SequencedWebSocketEvent{Event: msg, Sequence: webCon.sequence}
+
+	webCon.events = append(webCon.events, event)
+	if len(webCon.events) > WEBSOCKET_EVENT_BUFFER_SIZE {
+		webCon.events = webCon.events[len(webCon.events)-WEBSOCKET_EVENT_BUFFER_SIZE:]
+	}
+
+	return event
+}
+
+// eventsSince returns the buffered events numbered after the given sequence number, or false if some of them are no
+// longer buffered.
+func (webCon *WebConn) eventsSince(sequence int64) ([]*model.SequencedWebSocketEvent, bool) {
+	missed := webCon.sequence - sequence
+	if sequence < 0 || missed < 0 || missed > int64(len(webCon.events)) {
+		return nil, false
+	}
+
+	return webCon.events[int64(len(webCon.events))-missed:], true
 }
 
 func (webCon *WebConn) ShouldSendEvent(msg *model.WebSocketEvent) bool {
@@ -200,7 +284,7 @@ func (webCon *WebConn) ShouldSendEvent(msg *model.WebSocketEvent) bool {
 	if len(msg.Broadcast.ChannelId) > 0 {
 
 		// Only broadcast typing messages if less than 1K people in channel
-		if msg.Event == model.WEBSOCKET_EVENT_TYPING {
+		if msg.Event == model.WEBSOCKET_EVENT_TYPING || msg.Event == model.WEBSOCKET_EVENT_TYPING_USERS {
 			if Srv.Store.Channel().GetMemberCountFromCache(msg.Broadcast.ChannelId) > *utils.Cfg.TeamSettings.MaxNotificationsPerChannel {
 				return false
 			}
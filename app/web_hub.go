@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"runtime"
+	"sync"
+	"time"
 
 	l4g "github.com/alecthomas/log4go"
 
@@ -17,8 +19,11 @@ import (
 
 type Hub struct {
 	connections    []*WebConn
+	events         []*model.WebSocketEvent
+	eventCount     int64
 	register       chan *WebConn
 	unregister     chan *WebConn
+	activate       chan *WebConn
 	broadcast      chan *model.WebSocketEvent
 	stop           chan string
 	invalidateUser chan string
@@ -26,10 +31,16 @@ type Hub struct {
 
 var hubs []*Hub = make([]*Hub, 0)
 
+// activeUserConnections counts the active connections of each user across all of the hubs so that a user is only set
+// offline when their last connection closes.
+var activeUserConnections = make(map[string]int)
+var activeUserConnectionsLock sync.Mutex
+
 func NewWebHub() *Hub {
 	return &Hub{
 		register:       make(chan *WebConn),
 		unregister:     make(chan *WebConn),
+		activate:       make(chan *WebConn),
 		connections:    make([]*WebConn, 0, model.SESSION_CACHE_SIZE),
 		broadcast:      make(chan *model.WebSocketEvent, 4096),
 		stop:           make(chan string),
@@ -42,7 +53,11 @@ func TotalWebsocketConnections() int {
 	// so it's probably OK
 	count := 0
 	for _, hub := range hubs {
-		count = count + len(hub.connections)
+		for _, webCon := range hub.connections {
+			if webCon.state != webConnDead {
+				count++
+			}
+		}
 	}
 
 	return count
@@ -70,26 +85,30 @@ func HubStop() {
 	hubs = make([]*Hub, 0)
 }
 
-func GetHubForUserId(userId string) *Hub {
+// GetHubForConnectionId returns the hub that handles a connection. Hubs are picked by connection id so that a client
+// that reconnects to resume a connection is handled by the hub that kept its events.
+func GetHubForConnectionId(connectionId string) *Hub {
 	hash := fnv.New32a()
-	hash.Write([]byte(userId))
+	hash.Write([]byte(connectionId))
 	index := hash.Sum32() % uint32(len(hubs))
 	return hubs[index]
 }
 
 func HubRegister(webConn *WebConn) {
-	GetHubForUserId(webConn.UserId).Register(webConn)
+	GetHubForConnectionId(webConn.ConnectionId).Register(webConn)
 }
 
 func HubUnregister(webConn *WebConn) {
-	GetHubForUserId(webConn.UserId).Unregister(webConn)
+	GetHubForConnectionId(webConn.ConnectionId).Unregister(webConn)
+}
+
+// HubActivate starts sending events on a connection that was authenticated after it was registered.
+func HubActivate(webConn *WebConn) {
+	GetHubForConnectionId(webConn.ConnectionId).Activate(webConn)
 }
 
 func Publish(message *model.WebSocketEvent) {
-	message.DoPreComputeJson()
-	for _, hub := range hubs {
-		hub.Broadcast(message)
-	}
+	publishToHubs(message)
 
 	if einterfaces.GetClusterInterface() != nil {
 		einterfaces.GetClusterInterface().Publish(message)
@@ -97,6 +116,21 @@ func Publish(message *model.WebSocketEvent) {
 }
 
 func PublishSkipClusterSend(message *model.WebSocketEvent) {
+	// The users typing on another node are merged with the ones typing on this one before they're sent to clients
+	if isTypingUsersFromNode(message) {
+		typingUsers.receiveFromNode(message, typingExpiry())
+		return
+	}
+
+	publishToHubs(message)
+}
+
+// publishToHubs sends an event to the clients connected to this node.
+func publishToHubs(message *model.WebSocketEvent) {
+	if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
+		metrics.IncrementWebSocketBroadcast(message.Event)
+	}
+
 	message.DoPreComputeJson()
 	for _, hub := range hubs {
 		hub.Broadcast(message)
@@ -107,18 +141,18 @@ func InvalidateCacheForChannel(channel *model.Channel) {
 	InvalidateCacheForChannelSkipClusterSend(channel.Id)
 	InvalidateCacheForChannelByNameSkipClusterSend(channel.TeamId, channel.Name)
 
-	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
-		cluster.InvalidateCacheForChannel(channel.Id)
-		cluster.InvalidateCacheForChannelByName(channel.TeamId, channel.Name)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_CHANNEL, channel.Id))
+	sendCacheInvalidation(&model.CacheInvalidation{
+		Type:   model.CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME,
+		TeamId: channel.TeamId,
+		Name:   channel.Name,
+	})
 }
 
 func InvalidateCacheForChannelMembers(channelId string) {
 	InvalidateCacheForChannelMembersSkipClusterSend(channelId)
 
-	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
-		cluster.InvalidateCacheForChannelMembers(channelId)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_CHANNEL_MEMBERS, channelId))
 }
 
 func InvalidateCacheForChannelSkipClusterSend(channelId string) {
@@ -137,9 +171,7 @@ func InvalidateCacheForChannelByNameSkipClusterSend(teamId, name string) {
 func InvalidateCacheForChannelPosts(channelId string) {
 	InvalidateCacheForChannelPostsSkipClusterSend(channelId)
 
-	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
-		cluster.InvalidateCacheForChannelPosts(channelId)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_CHANNEL_POSTS, channelId))
 }
 
 func InvalidateCacheForChannelPostsSkipClusterSend(channelId string) {
@@ -149,9 +181,7 @@ func InvalidateCacheForChannelPostsSkipClusterSend(channelId string) {
 func InvalidateCacheForUser(userId string) {
 	InvalidateCacheForUserSkipClusterSend(userId)
 
-	if einterfaces.GetClusterInterface() != nil {
-		einterfaces.GetClusterInterface().InvalidateCacheForUser(userId)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_USER, userId))
 }
 
 func InvalidateCacheForUserSkipClusterSend(userId string) {
@@ -159,41 +189,83 @@ func InvalidateCacheForUserSkipClusterSend(userId string) {
 	Srv.Store.User().InvalidateProfilesInChannelCacheByUser(userId)
 	Srv.Store.User().InvalidatProfileCacheForUser(userId)
 
-	if len(hubs) != 0 {
-		GetHubForUserId(userId).InvalidateUser(userId)
-	}
+	InvalidateWebConnSessionCacheForUser(userId)
 }
 
 func InvalidateCacheForWebhook(webhookId string) {
 	InvalidateCacheForWebhookSkipClusterSend(webhookId)
 
-	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
-		cluster.InvalidateCacheForWebhook(webhookId)
-	}
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_WEBHOOK, webhookId))
 }
 
 func InvalidateCacheForWebhookSkipClusterSend(webhookId string) {
 	Srv.Store.Webhook().InvalidateWebhookCache(webhookId)
 }
 
+func InvalidateCacheForFileInfosForPost(postId string) {
+	InvalidateCacheForFileInfosForPostSkipClusterSend(postId)
+
+	sendCacheInvalidation(model.NewCacheInvalidation(model.CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST, postId))
+}
+
+func InvalidateCacheForFileInfosForPostSkipClusterSend(postId string) {
+	Srv.Store.FileInfo().InvalidateFileInfosForPostCache(postId)
+}
+
+func sendCacheInvalidation(invalidation *model.CacheInvalidation) {
+	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
+		cluster.SendCacheInvalidation(invalidation)
+	}
+}
+
+// HandleCacheInvalidation applies an invalidation received from another node of the cluster to this node's caches
+// without sending it back out.
+func HandleCacheInvalidation(invalidation *model.CacheInvalidation) *model.AppError {
+	if err := invalidation.IsValid(); err != nil {
+		return err
+	}
+
+	switch invalidation.Type {
+	case model.CACHE_INVALIDATION_TYPE_USER:
+		InvalidateCacheForUserSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_SESSIONS_FOR_USER:
+		ClearSessionCacheForUserSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_CHANNEL:
+		InvalidateCacheForChannelSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME:
+		InvalidateCacheForChannelByNameSkipClusterSend(invalidation.TeamId, invalidation.Name)
+	case model.CACHE_INVALIDATION_TYPE_CHANNEL_MEMBERS:
+		InvalidateCacheForChannelMembersSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_CHANNEL_POSTS:
+		InvalidateCacheForChannelPostsSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST:
+		InvalidateCacheForFileInfosForPostSkipClusterSend(invalidation.Id)
+	case model.CACHE_INVALIDATION_TYPE_WEBHOOK:
+		InvalidateCacheForWebhookSkipClusterSend(invalidation.Id)
+	}
+
+	return nil
+}
+
 func InvalidateWebConnSessionCacheForUser(userId string) {
-	if len(hubs) != 0 {
-		GetHubForUserId(userId).InvalidateUser(userId)
+	// A user's connections can be handled by any of the hubs
+	for _, hub := range hubs {
+		hub.InvalidateUser(userId)
 	}
 }
 
 func (h *Hub) Register(webConn *WebConn) {
 	h.register <- webConn
-
-	if webConn.IsAuthenticated() {
-		webConn.SendHello()
-	}
 }
 
 func (h *Hub) Unregister(webConn *WebConn) {
 	h.unregister <- webConn
 }
 
+func (h *Hub) Activate(webConn *WebConn) {
+	h.activate <- webConn
+}
+
 func (h *Hub) Broadcast(message *model.WebSocketEvent) {
 	if message != nil {
 		h.broadcast <- message
@@ -210,42 +282,31 @@ func (h *Hub) Stop() {
 
 func (h *Hub) Start() {
 	go func() {
+		expireTicker := time.NewTicker(WEBSOCKET_RESUME_WINDOW / 4)
+		defer expireTicker.Stop()
+
 		for {
+			metrics := einterfaces.GetMetricsInterface()
+
 			select {
 			case webCon := <-h.register:
 				h.connections = append(h.connections, webCon)
 
-			case webCon := <-h.unregister:
-				userId := webCon.UserId
-
-				found := false
-				indexToDel := -1
-				for i, webConCandidate := range h.connections {
-					if webConCandidate == webCon {
-						indexToDel = i
-						continue
-					}
-					if userId == webConCandidate.UserId {
-						found = true
-						if indexToDel != -1 {
-							break
-						}
-					}
+				if metrics != nil {
+					metrics.IncrementWebSocketConnections()
 				}
 
-				if indexToDel != -1 {
-					// Delete the webcon we are unregistering
-					h.connections[indexToDel] = h.connections[len(h.connections)-1]
-					h.connections = h.connections[:len(h.connections)-1]
+				if webCon.IsAuthenticated() {
+					h.activateConnection(webCon)
 				}
 
-				if len(userId) == 0 {
-					continue
+			case webCon := <-h.activate:
+				if webCon.state == webConnPending {
+					h.activateConnection(webCon)
 				}
 
-				if !found {
-					go SetStatusOffline(userId, false)
-				}
+			case webCon := <-h.unregister:
+				h.unregisterConnection(webCon)
 
 			case userId := <-h.invalidateUser:
 				for _, webCon := range h.connections {
@@ -255,27 +316,33 @@ func (h *Hub) Start() {
 				}
 
 			case msg := <-h.broadcast:
+				h.bufferEvent(msg)
+
+				// Dead connections only catch up on the events that they missed if they're resumed
 				for _, webCon := range h.connections {
-					if webCon.ShouldSendEvent(msg) {
-						select {
-						case webCon.Send <- msg:
-						default:
-							l4g.Error(fmt.Sprintf("webhub.broadcast: cannot send, closing websocket for userId=%v", webCon.UserId))
-							close(webCon.Send)
-							for i, webConCandidate := range h.connections {
-								if webConCandidate == webCon {
-									h.connections[i] = h.connections[len(h.connections)-1]
-									h.connections = h.connections[:len(h.connections)-1]
-									break
-								}
-							}
-						}
+					if webCon.state != webConnActive || !webCon.ShouldSendEvent(msg) {
+						continue
 					}
+
+					h.send(webCon, webCon.sequenceEvent(msg))
 				}
 
+			case <-expireTicker.C:
+				h.expireConnections()
+
 			case <-h.stop:
 				for _, webCon := range h.connections {
+					if webCon.state == webConnDead {
+						continue
+					} else if webCon.state == webConnActive {
+						removeActiveUserConnection(webCon.UserId)
+					}
+
 					webCon.WebSocket.Close()
+
+					if metrics != nil {
+						metrics.DecrementWebSocketConnections()
+					}
 				}
 
 				return
@@ -283,3 +350,171 @@ func (h *Hub) Start() {
 		}
 	}()
 }
+
+// activateConnection sends a connection its hello and starts sending it events. If the client asked to resume a
+// previous connection, that connection is closed and the events that the client missed are sent again if they're
+// still buffered.
+func (h *Hub) activateConnection(webCon *WebConn) {
+	var previous *WebConn
+	var missed []*model.SequencedWebSocketEvent
+	resumed := false
+
+	if webCon.resuming {
+		for i, webConCandidate := range h.connections {
+			if webConCandidate != webCon && webConCandidate.ConnectionId == webCon.ConnectionId && webConCandidate.UserId == webCon.UserId {
+				previous = webConCandidate
+				h.removeConnection(i)
+				break
+			}
+		}
+	}
+
+	if previous != nil && (previous.state != webConnDead || h.catchUpConnection(previous)) {
+		if events, ok := previous.eventsSince(webCon.resumeSequence); ok {
+			webCon.sequence = previous.sequence
+			webCon.events = previous.events
+			missed = events
+			resumed = true
+		}
+	}
+
+	webCon.state = webConnActive
+	addActiveUserConnection(webCon.UserId)
+
+	// The client has moved to the new connection even if it couldn't resume the old one, which may not have noticed
+	// that the client is gone yet
+	if previous != nil && previous.state == webConnActive {
+		close(previous.Send)
+		h.deactivateConnection(previous)
+	}
+
+	h.send(webCon, webCon.helloEvent(resumed))
+	for _, event := range missed {
+		h.send(webCon, event)
+	}
+}
+
+// bufferEvent keeps a broadcast event so that dead connections can catch up on it if they're resumed.
+func (h *Hub) bufferEvent(msg *model.WebSocketEvent) {
+	h.events = append(h.events, msg)
+	if len(h.events) > WEBSOCKET_HUB_EVENT_BUFFER_SIZE {
+		h.events = h.events[len(h.events)-WEBSOCKET_HUB_EVENT_BUFFER_SIZE:]
+	}
+
+	h.eventCount++
+}
+
+// catchUpConnection numbers and buffers the events that were broadcast after a connection died as if it had stayed
+// active. It returns false if some of them are no longer buffered by the hub.
+func (h *Hub) catchUpConnection(webCon *WebConn) bool {
+	missed := h.eventCount - webCon.deadEventCount
+	if missed > int64(len(h.events)) {
+		return false
+	}
+
+	for _, msg := range h.events[int64(len(h.events))-missed:] {
+		if webCon.ShouldSendEvent(msg) {
+			webCon.sequenceEvent(msg)
+		}
+	}
+
+	webCon.deadEventCount = h.eventCount
+	return true
+}
+
+// send queues a message on a connection, closing the connection if its send queue is full. The connection is kept as
+// a dead connection so that the client can resume it after reconnecting.
+func (h *Hub) send(webCon *WebConn, msg model.WebSocketMessage) {
+	if webCon.state != webConnActive {
+		return
+	}
+
+	select {
+	case webCon.Send <- msg:
+	default:
+		l4g.Error(fmt.Sprintf("webhub.broadcast: cannot send, closing websocket for userId=%v", webCon.UserId))
+		close(webCon.Send)
+
+		// The event is dropped along with the connection since its send queue is full
+		if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
+			metrics.IncrementWebSocketBroadcastDrop(msg.EventType())
+			metrics.IncrementWebSocketSlowConsumer()
+		}
+
+		h.deactivateConnection(webCon)
+	}
+}
+
+// unregisterConnection handles a client disconnecting. Connections that were active are kept as dead connections
+// until they're resumed or expire.
+func (h *Hub) unregisterConnection(webCon *WebConn) {
+	for i, webConCandidate := range h.connections {
+		if webConCandidate != webCon {
+			continue
+		}
+
+		if webCon.state == webConnActive {
+			h.deactivateConnection(webCon)
+		} else if webCon.state == webConnPending {
+			h.removeConnection(i)
+
+			if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
+				metrics.DecrementWebSocketConnections()
+			}
+		}
+
+		break
+	}
+}
+
+// deactivateConnection stops sending events on an active connection and sets its user offline if it was their last
+// active connection on this server.
+func (h *Hub) deactivateConnection(webCon *WebConn) {
+	webCon.state = webConnDead
+	webCon.deadAt = model.GetMillis()
+	webCon.deadEventCount = h.eventCount
+
+	if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
+		metrics.DecrementWebSocketConnections()
+	}
+
+	if removeActiveUserConnection(webCon.UserId) {
+		go SetStatusOffline(webCon.UserId, false)
+	}
+}
+
+func (h *Hub) expireConnections() {
+	expireBefore := model.GetMillis() - int64(WEBSOCKET_RESUME_WINDOW/time.Millisecond)
+
+	for i := len(h.connections) - 1; i >= 0; i-- {
+		if webCon := h.connections[i]; webCon.state == webConnDead && webCon.deadAt < expireBefore {
+			h.removeConnection(i)
+		}
+	}
+}
+
+func addActiveUserConnection(userId string) {
+	activeUserConnectionsLock.Lock()
+	defer activeUserConnectionsLock.Unlock()
+
+	activeUserConnections[userId]++
+}
+
+// removeActiveUserConnection returns true if the user has no active connections left.
+func removeActiveUserConnection(userId string) bool {
+	activeUserConnectionsLock.Lock()
+	defer activeUserConnectionsLock.Unlock()
+
+	if activeUserConnections[userId] <= 1 {
+		delete(activeUserConnections, userId)
+		return true
+	}
+
+	activeUserConnections[userId]--
+	return false
+}
+
+func (h *Hub) removeConnection(i int) {
+	h.connections[i] = h.connections[len(h.connections)-1]
+	h.connections = h.connections[:len(h.connections)-1]
+}
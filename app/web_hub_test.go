@@ -0,0 +1,217 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+)
+
+// testWebSocketMetrics records the websocket metrics and leaves every other metric unimplemented.
+type testWebSocketMetrics struct {
+	einterfaces.MetricsInterface
+
+	mutex         sync.Mutex
+	connections   int
+	broadcasts    map[string]int
+	drops         map[string]int
+	slowConsumers int
+}
+
+func (m *testWebSocketMetrics) IncrementWebSocketConnections() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.connections++
+}
+
+func (m *testWebSocketMetrics) DecrementWebSocketConnections() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.connections--
+}
+
+func (m *testWebSocketMetrics) IncrementWebSocketBroadcast(eventType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.broadcasts[eventType]++
+}
+
+func (m *testWebSocketMetrics) IncrementWebSocketBroadcastDrop(eventType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.drops[eventType]++
+}
+
+func (m *testWebSocketMetrics) IncrementWebSocketSlowConsumer() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.slowConsumers++
+}
+
+func TestHubMetrics(t *testing.T) {
+	Setup()
+
+	metrics := &testWebSocketMetrics{broadcasts: map[string]int{}, drops: map[string]int{}}
+	einterfaces.RegisterMetricsInterface(metrics)
+	defer einterfaces.RegisterMetricsInterface(nil)
+
+	hub := NewWebHub()
+	hub.Start()
+
+	// Both connections count as authenticated until their sessions expire so that they don't need a real session
+	userId := model.NewId()
+	webConn := &WebConn{UserId: userId, SessionExpiresAt: model.GetMillis() + 100000, Send: make(chan model.WebSocketMessage, 256)}
+	slowWebConn := &WebConn{UserId: model.NewId(), SessionExpiresAt: model.GetMillis() + 100000, Send: make(chan model.WebSocketMessage, 1)}
+
+	hub.Register(webConn)
+	hub.Register(slowWebConn)
+
+	// Every hub operation waits for the previous one to finish, so this makes sure all of them have been handled
+	waitForHub := func() {
+		hub.InvalidateUser("")
+	}
+
+	waitForHub()
+	if metrics.connections != 2 {
+		t.Fatal("should have counted both connections", metrics.connections)
+	}
+
+	PublishSkipClusterSend(model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", "", "", nil))
+	if metrics.broadcasts[model.WEBSOCKET_EVENT_TYPING] != 1 {
+		t.Fatal("should have counted the broadcast event by type")
+	}
+
+	// The slow connection's send queue is already full with its hello message
+	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "", "", "", nil)
+	event.DoPreComputeJson()
+	hub.Broadcast(event)
+
+	waitForHub()
+	if metrics.drops[model.WEBSOCKET_EVENT_POSTED] != 1 || metrics.slowConsumers != 1 {
+		t.Fatal("should have counted the dropped event and the slow consumer", metrics.drops, metrics.slowConsumers)
+	} else if metrics.connections != 1 {
+		t.Fatal("should have stopped counting the slow connection", metrics.connections)
+	}
+
+	hub.Unregister(slowWebConn)
+	hub.Unregister(webConn)
+
+	waitForHub()
+	if metrics.connections != 0 {
+		t.Fatal("should have stopped counting the closed connections", metrics.connections)
+	}
+
+	hub.Stop()
+}
+
+func TestHubResume(t *testing.T) {
+	Setup()
+
+	hub := NewWebHub()
+	hub.Start()
+
+	waitForHub := func() {
+		hub.InvalidateUser("")
+	}
+
+	userId := model.NewId()
+	connectionId := model.NewId()
+	newWebConn := func(userId string) *WebConn {
+		return &WebConn{UserId: userId, ConnectionId: connectionId, SessionExpiresAt: model.GetMillis() + 100000, Send: make(chan model.WebSocketMessage, 256)}
+	}
+
+	broadcast := func(message string) {
+		event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", "", userId, nil)
+		event.Add("message", message)
+		event.DoPreComputeJson()
+		hub.Broadcast(event)
+	}
+
+	// receive returns the messages queued on a connection, decoded the way a client would see them
+	receive := func(webConn *WebConn) []*model.WebSocketEvent {
+		waitForHub()
+
+		events := []*model.WebSocketEvent{}
+		for len(webConn.Send) > 0 {
+			msg := <-webConn.Send
+			events = append(events, model.WebSocketEventFromJson(strings.NewReader(string(msg.GetPreComputeJson()))))
+		}
+		return events
+	}
+
+	webConn := newWebConn(userId)
+	hub.Register(webConn)
+	broadcast("1")
+	broadcast("2")
+
+	if events := receive(webConn); len(events) != 3 || events[0].Event != model.WEBSOCKET_EVENT_HELLO || events[0].Data["connection_id"] != connectionId {
+		t.Fatal("should have sent the hello with the connection id", events)
+	} else if events[1].Sequence != 1 || events[2].Sequence != 2 || events[2].Data["message"] != "2" {
+		t.Fatal("should have numbered the events", events)
+	}
+
+	// Events are still buffered by the hub after the client disconnects
+	hub.Unregister(webConn)
+	broadcast("3")
+
+	waitForHub()
+	if webConn.sequence != 2 || len(webConn.events) != 2 {
+		t.Fatal("shouldn't have numbered events for the dead connection until it's resumed")
+	}
+
+	resumedWebConn := newWebConn(userId)
+	resumedWebConn.SetResume(connectionId, 1)
+	hub.Register(resumedWebConn)
+
+	if events := receive(resumedWebConn); len(events) != 3 || events[0].Data["resumed"] != true {
+		t.Fatal("should have resumed the connection", events)
+	} else if events[1].Sequence != 2 || events[1].Data["message"] != "2" || events[2].Sequence != 3 || events[2].Data["message"] != "3" {
+		t.Fatal("should have sent the missed events again", events)
+	}
+
+	broadcast("4")
+	if events := receive(resumedWebConn); len(events) != 1 || events[0].Sequence != 4 {
+		t.Fatal("should have kept numbering events from the resumed connection", events)
+	}
+
+	// Another user can't take over the connection
+	otherWebConn := newWebConn(model.NewId())
+	otherWebConn.SetResume(connectionId, 4)
+	hub.Register(otherWebConn)
+
+	if events := receive(otherWebConn); len(events) != 1 || events[0].Data["resumed"] != false {
+		t.Fatal("shouldn't have resumed another user's connection", events)
+	}
+
+	// The client asks for events that are no longer buffered
+	for i := 0; i < WEBSOCKET_EVENT_BUFFER_SIZE; i++ {
+		broadcast("more")
+	}
+	receive(resumedWebConn)
+
+	refreshedWebConn := newWebConn(userId)
+	refreshedWebConn.SetResume(connectionId, 1)
+	hub.Register(refreshedWebConn)
+
+	if events := receive(refreshedWebConn); len(events) != 1 || events[0].Data["resumed"] != false {
+		t.Fatal("should have told the client to refresh", events)
+	}
+
+	if _, ok := <-resumedWebConn.Send; ok {
+		t.Fatal("should have closed the connection that the client moved from")
+	}
+
+	broadcast("5")
+	if events := receive(refreshedWebConn); len(events) != 1 || events[0].Sequence != 1 {
+		t.Fatal("should have numbered events from the start again", events)
+	}
+
+	hub.Unregister(otherWebConn)
+	hub.Unregister(refreshedWebConn)
+	hub.Stop()
+}
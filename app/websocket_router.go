@@ -52,6 +52,9 @@ func (wr *WebSocketRouter) ServeWebSocket(conn *WebConn, r *model.WebSocketReque
 
 		if err != nil {
 			conn.WebSocket.Close()
+		} else if err := CheckUserAccessTokenScope(session, "GET", model.API_URL_SUFFIX_V3+"/users/websocket"); err != nil {
+			// the websocket sends every event that the user can see, which is more than any scope allows
+			conn.WebSocket.Close()
 		} else {
 			go SetStatusOnline(session.UserId, session.Id, false)
 
@@ -61,7 +64,7 @@ func (wr *WebSocketRouter) ServeWebSocket(conn *WebConn, r *model.WebSocketReque
 			resp := model.NewWebSocketResponse(model.STATUS_OK, r.Seq, nil)
 			resp.DoPreComputeJson()
 			conn.Send <- resp
-			conn.SendHello()
+			HubActivate(conn)
 		}
 
 		return
@@ -4,6 +4,8 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"os"
 
 	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
@@ -69,6 +71,17 @@ Channels can be specified by [team]:[channel]. ie. myteam:mychannel or by channe
 	RunE:    restoreChannelsCmdF,
 }
 
+var exportChannelCmd = &cobra.Command{
+	Use:   "export [channel]",
+	Short: "Export a channel",
+	Long: `Export a channel's posts, reactions and attached files as a zip file.
+Channels can be specified by [team]:[channel]. ie. myteam:mychannel or by channel ID.
+Use --anonymize to replace users with their pseudonyms.`,
+	Example: `  channel export myteam:mychannel --file mychannel.zip
+  channel export myteam:mychannel --file mychannel.zip --anonymize`,
+	RunE: exportChannelCmdF,
+}
+
 func init() {
 	channelCreateCmd.Flags().String("name", "", "Channel Name")
 	channelCreateCmd.Flags().String("display_name", "", "Channel Display Name")
@@ -77,6 +90,9 @@ func init() {
 	channelCreateCmd.Flags().String("purpose", "", "Channel purpose")
 	channelCreateCmd.Flags().Bool("private", false, "Create a private channel.")
 
+	exportChannelCmd.Flags().String("file", "", "File to write the export to.")
+	exportChannelCmd.Flags().Bool("anonymize", false, "Replace users with their pseudonyms.")
+
 	channelCmd.AddCommand(
 		channelCreateCmd,
 		removeChannelUsersCmd,
@@ -84,6 +100,7 @@ func init() {
 		deleteChannelsCmd,
 		listChannelsCmd,
 		restoreChannelsCmd,
+		exportChannelCmd,
 	)
 }
 
@@ -287,3 +304,39 @@ func restoreChannelsCmdF(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func exportChannelCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) != 1 {
+		return errors.New("Enter one channel to export.")
+	}
+
+	fileName, _ := cmd.Flags().GetString("file")
+	if fileName == "" {
+		return errors.New("File is required")
+	}
+
+	channel := getChannelFromChannelArg(args[0])
+	if channel == nil {
+		return errors.New("Unable to find channel '" + args[0] + "'")
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var pseudonymizer *app.Pseudonymizer
+	if anonymize, _ := cmd.Flags().GetBool("anonymize"); anonymize {
+		pseudonymizer = app.NewPseudonymizer()
+	}
+
+	if err := app.ExportChannel(channel, file, pseudonymizer, "", ""); err != nil {
+		return errors.New("Unable to export channel '" + args[0] + "'. Error: " + err.Error())
+	}
+
+	CommandPrettyPrintln(fmt.Sprintf("Exported channel %v to %v", channel.Name, fileName))
+
+	return nil
+}
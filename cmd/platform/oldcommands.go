@@ -319,7 +319,7 @@ func cmdInviteUser() {
 		}
 
 		invites := []string{flagEmail}
-		app.SendInviteEmails(team, user.GetDisplayName(), invites, *utils.Cfg.ServiceSettings.SiteURL)
+		app.SendInviteEmails(team, user.GetDisplayName(), user.Id, invites, *utils.Cfg.ServiceSettings.SiteURL)
 
 		os.Exit(0)
 	}
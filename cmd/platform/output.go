@@ -5,6 +5,8 @@ package main
 import (
 	"fmt"
 	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func CommandPrintln(a ...interface{}) (int, error) {
@@ -30,3 +32,9 @@ func CommandPrettyPrintln(a ...interface{}) (int, error) {
 func CommandPrettyPrint(a ...interface{}) (int, error) {
 	return fmt.Fprint(os.Stderr, a...)
 }
+
+// stdinIsTerminal returns true if the command is being run by someone who can answer its prompts, rather than by a
+// script.
+func stdinIsTerminal() bool {
+	return terminal.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -4,8 +4,10 @@ package main
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +24,38 @@ var makeSystemAdminCmd = &cobra.Command{
 	RunE:    makeSystemAdminCmdF,
 }
 
+var makeReadOnlyAdminCmd = &cobra.Command{
+	Use:     "read_only_admin [users]",
+	Short:   "Set a user as read only admin",
+	Long:    "Let some users view the System Console without being able to change anything.",
+	Example: "  roles read_only_admin user1",
+	RunE:    makeReadOnlyAdminCmdF,
+}
+
+var makeUserManagerCmd = &cobra.Command{
+	Use:     "user_manager [users]",
+	Short:   "Set a user as user manager",
+	Long:    "Let some users reset the passwords and MFA of, unlock, and deactivate users who aren't admins.",
+	Example: "  roles user_manager user1",
+	RunE:    makeUserManagerCmdF,
+}
+
+var makeComplianceOfficerCmd = &cobra.Command{
+	Use:     "compliance_officer [users]",
+	Short:   "Set a user as compliance officer",
+	Long:    "Let some users manage compliance reports, legal holds and data retention policies.",
+	Example: "  roles compliance_officer user1",
+	RunE:    makeComplianceOfficerCmdF,
+}
+
+var rolePermissionsCmd = &cobra.Command{
+	Use:     "permissions [role] [permissions]",
+	Short:   "Set the permissions of a role",
+	Long:    "Replace the permissions of the system_read_only_admin, system_user_manager or system_compliance_officer role. With no permissions, prints the role's current permissions instead.",
+	Example: "  roles permissions system_read_only_admin read_system manage_compliance",
+	RunE:    rolePermissionsCmdF,
+}
+
 var makeMemberCmd = &cobra.Command{
 	Use:     "member [users]",
 	Short:   "Remove system admin privileges",
@@ -33,6 +67,10 @@ var makeMemberCmd = &cobra.Command{
 func init() {
 	rolesCmd.AddCommand(
 		makeSystemAdminCmd,
+		makeReadOnlyAdminCmd,
+		makeUserManagerCmd,
+		makeComplianceOfficerCmd,
+		rolePermissionsCmd,
 		makeMemberCmd,
 	)
 }
@@ -57,6 +95,62 @@ func makeSystemAdminCmdF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func makeReadOnlyAdminCmdF(cmd *cobra.Command, args []string) error {
+	return setSystemRoleCmdF(cmd, args, model.ROLE_SYSTEM_READ_ONLY_ADMIN.Id)
+}
+
+func makeUserManagerCmdF(cmd *cobra.Command, args []string) error {
+	return setSystemRoleCmdF(cmd, args, model.ROLE_SYSTEM_USER_MANAGER.Id)
+}
+
+func makeComplianceOfficerCmdF(cmd *cobra.Command, args []string) error {
+	return setSystemRoleCmdF(cmd, args, model.ROLE_SYSTEM_COMPLIANCE_OFFICER.Id)
+}
+
+// setSystemRoleCmdF replaces the roles of some users with the given admin role along with system_user.
+func setSystemRoleCmdF(cmd *cobra.Command, args []string, role string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) < 1 {
+		return errors.New("Enter at least one user.")
+	}
+
+	users := getUsersFromUserArgs(args)
+	for i, user := range users {
+		if user == nil {
+			return errors.New("Unable to find user '" + args[i] + "'")
+		}
+
+		if _, err := app.UpdateUserRoles(user.Id, role+" "+model.ROLE_SYSTEM_USER.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rolePermissionsCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) < 1 {
+		return errors.New("Enter a role.")
+	}
+
+	if len(args) == 1 {
+		role, err := app.GetRole(args[0])
+		if err != nil {
+			return err
+		}
+
+		CommandPrintln(strings.Join(role.Permissions, " "))
+		return nil
+	}
+
+	if _, err := app.UpdateRolePermissions(args[0], args[1:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func makeMemberCmdF(cmd *cobra.Command, args []string) error {
 	initDBCommandContextCobra(cmd)
 	if len(args) < 1 {
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/spf13/cobra"
+)
+
+const SEARCH_REINDEX_DATE_FORMAT = "2006-01-02"
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Management of the search index",
+}
+
+var searchReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the search index",
+	Long: `Index posts and files in the search engine.
+Runs the bulk indexing job on this machine and prints its progress until it finishes.`,
+	Example: `  search reindex
+  search reindex --team myteam --from 2017-01-01
+  search reindex --rebuild`,
+	RunE: searchReindexCmdF,
+}
+
+func init() {
+	searchReindexCmd.Flags().String("team", "", "Only index the posts in this team")
+	searchReindexCmd.Flags().String("from", "", "Only index the posts created on or after this date (YYYY-MM-DD)")
+	searchReindexCmd.Flags().String("to", "", "Only index the posts created before this date (YYYY-MM-DD)")
+	searchReindexCmd.Flags().Bool("rebuild", false, "Purge the existing indexes before indexing every post. Can't be used with --team.")
+
+	searchCmd.AddCommand(
+		searchReindexCmd,
+	)
+}
+
+func searchReindexCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+
+	var teamId string
+	if teamArg, _ := cmd.Flags().GetString("team"); teamArg != "" {
+		team := getTeamFromTeamArg(teamArg)
+		if team == nil {
+			return errors.New("Unable to find team '" + teamArg + "'")
+		}
+		teamId = team.Id
+	}
+
+	var startTime, endTime int64
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		if date, err := time.ParseInLocation(SEARCH_REINDEX_DATE_FORMAT, from, time.Local); err != nil {
+			return errors.New("Invalid --from date '" + from + "', expected YYYY-MM-DD")
+		} else {
+			startTime = date.UnixNano() / int64(time.Millisecond)
+		}
+	}
+	if to, _ := cmd.Flags().GetString("to"); to != "" {
+		if date, err := time.ParseInLocation(SEARCH_REINDEX_DATE_FORMAT, to, time.Local); err != nil {
+			return errors.New("Invalid --to date '" + to + "', expected YYYY-MM-DD")
+		} else {
+			endTime = date.UnixNano() / int64(time.Millisecond)
+		}
+	}
+
+	rebuild, _ := cmd.Flags().GetBool("rebuild")
+
+	job, err := app.CreateSearchIndexingJob(startTime, endTime, teamId, rebuild)
+	if err != nil {
+		return errors.New("Unable to create the indexing job: " + err.Error())
+	}
+
+	CommandPrettyPrintln("Started indexing job " + job.Id)
+
+	done := make(chan bool, 1)
+	go func() {
+		done <- app.RunJobNow(job)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case claimed := <-done:
+			if !claimed {
+				return errors.New("The indexing job was started by another server")
+			}
+
+			return printSearchReindexResult(job.Id)
+		case <-ticker.C:
+			if current, err := app.GetJob(job.Id); err == nil {
+				CommandPrettyPrint(fmt.Sprintf("\rIndexed %v posts and %v files (%v%%)",
+					current.GetDataInt64(app.SEARCH_INDEXING_DATA_POSTS_INDEXED), current.GetDataInt64(app.SEARCH_INDEXING_DATA_FILES_INDEXED), current.Progress))
+			}
+		}
+	}
+}
+
+func printSearchReindexResult(jobId string) error {
+	job, err := app.GetJob(jobId)
+	if err != nil {
+		return err
+	}
+
+	CommandPrettyPrintln("")
+
+	switch job.Status {
+	case model.JOB_STATUS_SUCCESS:
+		CommandPrettyPrintln(fmt.Sprintf("SUCCESS: Indexed %v posts and %v files",
+			job.GetDataInt64(app.SEARCH_INDEXING_DATA_POSTS_INDEXED), job.GetDataInt64(app.SEARCH_INDEXING_DATA_FILES_INDEXED)))
+		return nil
+	case model.JOB_STATUS_CANCELED:
+		return errors.New("The indexing job was canceled")
+	default:
+		return errors.New("The indexing job failed: " + job.Data["error"])
+	}
+}
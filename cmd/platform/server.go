@@ -107,6 +107,21 @@ func runServer(configFileLocation string) {
 		complianceI.StartComplianceDailyJob()
 	}
 
+	app.StartClusterDiscovery()
+	app.InitCluster()
+	app.StartJobs()
+	app.StartJobScheduler()
+	app.StartBackgroundMigrations()
+	app.StartSqlMetrics()
+	app.StartDatabaseHealthCheck()
+	app.StartSessionActivityFlush()
+	app.StartLoginAttemptCleanup()
+	app.StartCustomStatusExpiry()
+	app.StartInvitationCleanup()
+	app.StartOAuthRevokedTokenCleanup()
+	app.StartConfigWatcher()
+	app.StartInboundEmail()
+
 	if einterfaces.GetClusterInterface() != nil {
 		einterfaces.GetClusterInterface().StartInterNodeCommunication()
 	}
@@ -115,16 +130,40 @@ func runServer(configFileLocation string) {
 		einterfaces.GetMetricsInterface().StartServer()
 	}
 
+	// reload the config on SIGHUP without restarting the server
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			app.ReloadConfigAndLog()
+		}
+	}()
+
 	// wait for kill signal before attempting to gracefully shutdown
 	// the running service
 	c := make(chan os.Signal)
 	signal.Notify(c, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	<-c
 
+	signal.Stop(reload)
+
 	if einterfaces.GetClusterInterface() != nil {
 		einterfaces.GetClusterInterface().StopInterNodeCommunication()
 	}
 
+	app.StopInboundEmail()
+	app.StopConfigWatcher()
+	app.StopOAuthRevokedTokenCleanup()
+	app.StopInvitationCleanup()
+	app.StopCustomStatusExpiry()
+	app.StopLoginAttemptCleanup()
+	app.StopSessionActivityFlush()
+	app.StopDatabaseHealthCheck()
+	app.StopSqlMetrics()
+	app.StopJobScheduler()
+	app.StopJobs()
+	app.StopClusterDiscovery()
+
 	if einterfaces.GetMetricsInterface() != nil {
 		einterfaces.GetMetricsInterface().StopServer()
 	}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/spf13/cobra"
+)
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Management of file storage",
+}
+
+var storageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy all files to another storage backend",
+	Long: `Copy every uploaded file, thumbnail and preview from the configured file storage to the one set in the FileSettings of another config file, checking that each copy matches the original.
+Runs the migration job on this machine and prints its progress until it finishes. An interrupted migration can be continued with --resume.
+The configured file storage isn't changed, so update FileSettings once the migration has finished.`,
+	Example: `  storage migrate --destination-config /opt/mattermost/config/s3.json
+  storage migrate --destination-config /opt/mattermost/config/s3.json --prefix mattermost/
+  storage migrate --resume 8jxwhpfm4tg7tb7rk5cs4ws3ee`,
+	RunE: storageMigrateCmdF,
+}
+
+func init() {
+	storageMigrateCmd.Flags().String("destination-config", "", "A config file whose FileSettings describe the storage to copy the files to")
+	storageMigrateCmd.Flags().String("prefix", "", "Store the copies under this path prefix and update the paths of the files in the database to match")
+	storageMigrateCmd.Flags().String("resume", "", "The id of an interrupted, failed or canceled migration job to continue")
+
+	storageCmd.AddCommand(
+		storageMigrateCmd,
+	)
+}
+
+func storageMigrateCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+
+	destinationConfig, _ := cmd.Flags().GetString("destination-config")
+	prefix, _ := cmd.Flags().GetString("prefix")
+	resume, _ := cmd.Flags().GetString("resume")
+
+	var job *model.Job
+	if resume != "" {
+		if destinationConfig != "" || prefix != "" {
+			return errors.New("--destination-config and --prefix can't be changed when resuming a migration")
+		}
+
+		var err *model.AppError
+		if job, err = app.ResumeJob(resume); err != nil {
+			return errors.New("Unable to resume the migration job: " + err.Error())
+		} else if job.Type != model.JOB_TYPE_FILE_STORAGE_MIGRATION {
+			return errors.New("Job " + resume + " isn't a file storage migration")
+		}
+
+		CommandPrettyPrintln("Resuming migration job " + job.Id)
+	} else {
+		if destinationConfig == "" {
+			return errors.New("Either --destination-config or --resume is required")
+		}
+
+		var err *model.AppError
+		if job, err = app.CreateFileStorageMigrationJob(destinationConfig, prefix); err != nil {
+			return errors.New("Unable to create the migration job: " + err.Error())
+		}
+
+		CommandPrettyPrintln("Started migration job " + job.Id)
+	}
+
+	done := make(chan bool, 1)
+	go func() {
+		done <- app.RunJobNow(job)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case claimed := <-done:
+			if !claimed {
+				return errors.New("The migration job was started by another server")
+			}
+
+			return printStorageMigrateResult(job.Id)
+		case <-ticker.C:
+			if current, err := app.GetJob(job.Id); err == nil {
+				CommandPrettyPrint(fmt.Sprintf("\rMigrated %v files (%v%%)",
+					current.GetDataInt64(app.FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED), current.Progress))
+			}
+		}
+	}
+}
+
+func printStorageMigrateResult(jobId string) error {
+	job, err := app.GetJob(jobId)
+	if err != nil {
+		return err
+	}
+
+	CommandPrettyPrintln("")
+
+	switch job.Status {
+	case model.JOB_STATUS_SUCCESS:
+		CommandPrettyPrintln(fmt.Sprintf("SUCCESS: Migrated %v files", job.GetDataInt64(app.FILE_STORAGE_MIGRATION_DATA_FILES_MIGRATED)))
+		if missing := job.GetDataInt64(app.FILE_STORAGE_MIGRATION_DATA_FILES_MISSING); missing > 0 {
+			CommandPrettyPrintln(fmt.Sprintf("WARNING: %v files couldn't be read from the current storage and weren't copied, see the log for details", missing))
+		}
+		return nil
+	case model.JOB_STATUS_CANCELED:
+		return errors.New("The migration job was canceled, run the command again with --resume " + job.Id + " to continue it")
+	default:
+		return errors.New("The migration job failed: " + job.Data["error"] + "\nRun the command again with --resume " + job.Id + " to continue it")
+	}
+}
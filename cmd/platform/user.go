@@ -3,8 +3,12 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/einterfaces"
@@ -23,7 +27,7 @@ var userActivateCmd = &cobra.Command{
 	Short: "Activate users",
 	Long:  "Activate users that have been deactivated.",
 	Example: `  user activate user@example.com
-  user activate username`,
+  user activate username --dry-run`,
 	RunE: userActivateCmdF,
 }
 
@@ -32,7 +36,8 @@ var userDeactivateCmd = &cobra.Command{
 	Short: "Deactivate users",
 	Long:  "Deactivate users. Deactivated users are immediately logged out of all sessions and are unable to log back in.",
 	Example: `  user deactivate user@example.com
-  user deactivate username`,
+  user deactivate username --confirm
+  user deactivate username --dry-run`,
 	RunE: userDeactivateCmdF,
 }
 
@@ -74,11 +79,24 @@ If MFA enforcement is enabled, the user will be forced to re-enable MFA as soon
 }
 
 var deleteUserCmd = &cobra.Command{
-	Use:     "delete [users]",
-	Short:   "Delete users and all posts",
-	Long:    "Permanently delete user and all related information including posts.",
-	Example: "  user delete user@example.com",
-	RunE:    deleteUserCmdF,
+	Use:   "delete [users]",
+	Short: "Delete users and all posts",
+	Long:  "Permanently delete user and all related information including posts.",
+	Example: `  user delete user@example.com
+  user delete username --dry-run`,
+	RunE: deleteUserCmdF,
+}
+
+var userExportCmd = &cobra.Command{
+	Use:   "export [users]",
+	Short: "Export users",
+	Long: `Export users along with their team and channel memberships as a Mattermost Bulk Import File.
+Passwords are not exported. Use --anonymize to replace usernames and emails with pseudonyms
+and leave out the rest of the users' personal details.`,
+	Example: `  user export user@example.com username
+  user export username --file users.json
+  user export username --anonymize`,
+	RunE: userExportCmdF,
 }
 
 var deleteAllUsersCmd = &cobra.Command{
@@ -129,7 +147,16 @@ func init() {
 	userCreateCmd.Flags().String("locale", "", "Locale (ex: en, fr)")
 	userCreateCmd.Flags().Bool("system_admin", false, "Make the user a system administrator")
 
+	userActivateCmd.Flags().Bool("dry-run", false, "List the users that would be activated without changing them.")
+
+	userDeactivateCmd.Flags().Bool("confirm", false, "Confirm you really want to deactivate the users and log them out of all sessions.")
+	userDeactivateCmd.Flags().Bool("dry-run", false, "List the users that would be deactivated without changing them.")
+
+	userExportCmd.Flags().String("file", "", "File to write the users to. Defaults to standard output.")
+	userExportCmd.Flags().Bool("anonymize", false, "Replace usernames and emails with pseudonyms.")
+
 	deleteUserCmd.Flags().Bool("confirm", false, "Confirm you really want to delete the user and a DB backup has been performed.")
+	deleteUserCmd.Flags().Bool("dry-run", false, "List the users that would be deleted without deleting them.")
 
 	deleteAllUsersCmd.Flags().Bool("confirm", false, "Confirm you really want to delete the user and a DB backup has been performed.")
 
@@ -140,6 +167,7 @@ func init() {
 		userInviteCmd,
 		resetUserPasswordCmd,
 		resetUserMfaCmd,
+		userExportCmd,
 		deleteUserCmd,
 		deleteAllUsersCmd,
 		migrateAuthCmd,
@@ -154,18 +182,20 @@ func userActivateCmdF(cmd *cobra.Command, args []string) error {
 		return errors.New("Enter user(s) to activate.")
 	}
 
-	changeUsersActiveStatus(args, true)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	changeUsersActiveStatus(args, true, dryRun)
 	return nil
 }
 
-func changeUsersActiveStatus(userArgs []string, active bool) {
+func changeUsersActiveStatus(userArgs []string, active bool, dryRun bool) {
 	users := getUsersFromUserArgs(userArgs)
 	for i, user := range users {
-		changeUserActiveStatus(user, userArgs[i], active)
+		changeUserActiveStatus(user, userArgs[i], active, dryRun)
 	}
 }
 
-func changeUserActiveStatus(user *model.User, userArg string, activate bool) {
+func changeUserActiveStatus(user *model.User, userArg string, activate bool, dryRun bool) {
 	if user == nil {
 		CommandPrintErrorln("Can't find user '" + userArg + "'")
 		return
@@ -174,6 +204,14 @@ func changeUserActiveStatus(user *model.User, userArg string, activate bool) {
 		CommandPrintErrorln(utils.T("api.user.update_active.no_deactivate_ldap.app_error"))
 		return
 	}
+	if dryRun {
+		if activate {
+			CommandPrettyPrintln("Would activate user: " + user.Username)
+		} else {
+			CommandPrettyPrintln("Would deactivate user: " + user.Username)
+		}
+		return
+	}
 	if _, err := app.UpdateActive(user, activate); err != nil {
 		CommandPrintErrorln("Unable to change activation status of user: " + userArg)
 	}
@@ -186,7 +224,20 @@ func userDeactivateCmdF(cmd *cobra.Command, args []string) error {
 		return errors.New("Enter user(s) to deactivate.")
 	}
 
-	changeUsersActiveStatus(args, false)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	// only ask when someone is there to answer so that scripts can keep deactivating users without --confirm
+	confirmFlag, _ := cmd.Flags().GetBool("confirm")
+	if !confirmFlag && !dryRun && stdinIsTerminal() {
+		var confirm string
+		CommandPrettyPrintln("Are you sure you want to deactivate the users specified? They will be logged out of all sessions. (YES/NO): ")
+		fmt.Scanln(&confirm)
+		if confirm != "YES" {
+			return errors.New("ABORTED: You did not answer YES exactly, in all capitals.")
+		}
+	}
+
+	changeUsersActiveStatus(args, false, dryRun)
 	return nil
 }
 
@@ -261,7 +312,7 @@ func inviteUser(email string, team *model.Team, teamArg string) {
 		CommandPrintErrorln("Can't find team '" + teamArg + "'")
 		return
 	}
-	app.SendInviteEmails(team, "Administrator", invites, *utils.Cfg.ServiceSettings.SiteURL)
+	app.SendInviteEmails(team, "Administrator", "", invites, *utils.Cfg.ServiceSettings.SiteURL)
 	CommandPrettyPrintln("Invites may or may not have been sent.")
 }
 
@@ -311,8 +362,10 @@ func deleteUserCmdF(cmd *cobra.Command, args []string) error {
 		return errors.New("Enter at least one user.")
 	}
 
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
 	confirmFlag, _ := cmd.Flags().GetBool("confirm")
-	if !confirmFlag {
+	if !confirmFlag && !dryRun {
 		var confirm string
 		CommandPrettyPrintln("Have you performed a database backup? (YES/NO): ")
 		fmt.Scanln(&confirm)
@@ -334,6 +387,11 @@ func deleteUserCmdF(cmd *cobra.Command, args []string) error {
 			return errors.New("Unable to find user '" + args[i] + "'")
 		}
 
+		if dryRun {
+			CommandPrettyPrintln("Would delete user: " + user.Username)
+			continue
+		}
+
 		if err := app.PermanentDeleteUser(user); err != nil {
 			return err
 		}
@@ -342,6 +400,64 @@ func deleteUserCmdF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func userExportCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) < 1 {
+		return errors.New("Enter at least one user.")
+	}
+
+	users := getUsersFromUserArgs(args)
+	for i, user := range users {
+		if user == nil {
+			return errors.New("Unable to find user '" + args[i] + "'")
+		}
+	}
+
+	// the users are written to a temporary file that replaces the requested one once they've all been exported, so
+	// that an export that fails part way through doesn't leave an incomplete file behind
+	output := os.Stdout
+	fileName, _ := cmd.Flags().GetString("file")
+	if fileName != "" {
+		file, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		output = file
+	}
+
+	var pseudonymizer *app.Pseudonymizer
+	if anonymize, _ := cmd.Flags().GetBool("anonymize"); anonymize {
+		pseudonymizer = app.NewPseudonymizer()
+	}
+
+	encoder := json.NewEncoder(output)
+	for _, user := range users {
+		line, err := app.ExportUser(user, pseudonymizer)
+		if err != nil {
+			return errors.New("Unable to export user '" + user.Username + "'. Error: " + err.Error())
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	if fileName != "" {
+		if err := output.Close(); err != nil {
+			return err
+		} else if err := os.Rename(output.Name(), fileName); err != nil {
+			return err
+		}
+	}
+
+	CommandPrettyPrintln(fmt.Sprintf("Exported %v users", len(users)))
+
+	return nil
+}
+
 func deleteAllUsersCommandF(cmd *cobra.Command, args []string) error {
 	initDBCommandContextCobra(cmd)
 	if len(args) > 0 {
@@ -12,19 +12,15 @@ type ClusterInterface interface {
 	StopInterNodeCommunication()
 	GetClusterInfos() []*model.ClusterInfo
 	GetClusterStats() ([]*model.ClusterStats, *model.AppError)
-	ClearSessionCacheForUser(userId string)
-	InvalidateCacheForUser(userId string)
-	InvalidateCacheForChannel(channelId string)
-	InvalidateCacheForChannelByName(teamId, name string)
-	InvalidateCacheForChannelMembers(channelId string)
-	InvalidateCacheForChannelPosts(channelId string)
-	InvalidateCacheForWebhook(webhookId string)
+	SendCacheInvalidation(invalidation *model.CacheInvalidation)
 	Publish(event *model.WebSocketEvent)
 	UpdateStatus(status *model.Status)
 	GetLogs() ([]string, *model.AppError)
+	GetLogPage(query *model.LogQuery) (*model.LogPage, *model.AppError)
 	GetClusterId() string
 	ConfigChanged(previousConfig *model.Config, newConfig *model.Config, sendToOtherServer bool) *model.AppError
 	InvalidateAllCaches() *model.AppError
+	IsLeader() bool
 }
 
 var theClusterInterface ClusterInterface
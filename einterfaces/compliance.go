@@ -9,6 +9,8 @@ import (
 
 type ComplianceInterface interface {
 	StartComplianceDailyJob()
+	// RunComplianceJob writes a compliance report. The posts in it should be read with app.GetCompliancePosts so
+	// that anonymized reports are pseudonymized.
 	RunComplianceJob(job *model.Compliance) *model.AppError
 }
 
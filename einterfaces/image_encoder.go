@@ -0,0 +1,24 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"image"
+
+	"github.com/mattermost/platform/model"
+)
+
+type ImageEncoderInterface interface {
+	EncodeWebP(img image.Image) ([]byte, *model.AppError)
+}
+
+var theImageEncoderInterface ImageEncoderInterface
+
+func RegisterImageEncoderInterface(newInterface ImageEncoderInterface) {
+	theImageEncoderInterface = newInterface
+}
+
+func GetImageEncoderInterface() ImageEncoderInterface {
+	return theImageEncoderInterface
+}
@@ -20,6 +20,12 @@ type MetricsInterface interface {
 	IncrementLogin()
 	IncrementLoginFail()
 
+	IncrementWebSocketConnections()
+	DecrementWebSocketConnections()
+	IncrementWebSocketBroadcast(eventType string)
+	IncrementWebSocketBroadcastDrop(eventType string)
+	IncrementWebSocketSlowConsumer()
+
 	IncrementEtagHitCounter(route string)
 	IncrementEtagMissCounter(route string)
 
@@ -28,6 +34,11 @@ type MetricsInterface interface {
 
 	AddMemCacheHitCounter(cacheName string, amount float64)
 	AddMemCacheMissCounter(cacheName string, amount float64)
+
+	SetSqlOpenConnections(dataSource string, count float64)
+	SetSqlInUseConnections(dataSource string, count float64)
+	SetSqlConnectionWaitCount(dataSource string, count float64)
+	SetSqlConnectionWaitDuration(dataSource string, seconds float64)
 }
 
 var theMetricsInterface MetricsInterface
@@ -0,0 +1,24 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"image"
+
+	"github.com/mattermost/platform/model"
+)
+
+type PdfRendererInterface interface {
+	RenderFirstPage(data []byte) (image.Image, *model.AppError)
+}
+
+var thePdfRendererInterface PdfRendererInterface
+
+func RegisterPdfRendererInterface(newInterface PdfRendererInterface) {
+	thePdfRendererInterface = newInterface
+}
+
+func GetPdfRendererInterface() PdfRendererInterface {
+	return thePdfRendererInterface
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SearchEngineInterface interface {
+	Start() *model.AppError
+	Stop() *model.AppError
+	GetName() string
+	IndexPost(post *model.PostForIndexing) *model.AppError
+	DeletePost(postId string) *model.AppError
+	BulkIndexPosts(posts []*model.PostForIndexing) *model.AppError
+	BulkIndexFiles(files []*model.FileInfoForIndexing) *model.AppError
+	PurgeIndexes() *model.AppError
+}
+
+var theSearchEngineInterface SearchEngineInterface
+
+func RegisterSearchEngineInterface(newInterface SearchEngineInterface) {
+	theSearchEngineInterface = newInterface
+}
+
+func GetSearchEngineInterface() SearchEngineInterface {
+	return theSearchEngineInterface
+}
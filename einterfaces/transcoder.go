@@ -0,0 +1,23 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type TranscoderInterface interface {
+	ExtractVideoInfo(data []byte, mimeType string) (*model.VideoInfo, *model.AppError)
+	ExtractAudioInfo(data []byte, mimeType string) (*model.AudioInfo, *model.AppError)
+}
+
+var theTranscoderInterface TranscoderInterface
+
+func RegisterTranscoderInterface(newInterface TranscoderInterface) {
+	theTranscoderInterface = newInterface
+}
+
+func GetTranscoderInterface() TranscoderInterface {
+	return theTranscoderInterface
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type TranslationInterface interface {
+	// Translate returns the text translated into the given locale along with the locale that it was written in.
+	Translate(text string, locale string) (string, string, *model.AppError)
+}
+
+var theTranslationInterface TranslationInterface
+
+func RegisterTranslationInterface(newInterface TranslationInterface) {
+	theTranslationInterface = newInterface
+}
+
+func GetTranslationInterface() TranslationInterface {
+	return theTranslationInterface
+}
@@ -23,6 +23,17 @@ type AccessData struct {
 	ExpiresAt    int64  `json:"expires_at"`
 }
 
+// OAuthRevokedToken records a refresh token that has already been exchanged or revoked. Refresh
+// tokens are single use, so presenting one of these again means that it has leaked. It's kept until
+// the access that the token was issued with would have expired.
+type OAuthRevokedToken struct {
+	Token     string `json:"token"`
+	ClientId  string `json:"client_id"`
+	UserId    string `json:"user_id"`
+	RevokedAt int64  `json:"revoked_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
 type AccessResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
@@ -58,6 +69,28 @@ func (ad *AccessData) IsValid() *AppError {
 	return nil
 }
 
+func (rt *OAuthRevokedToken) PreSave() {
+	if rt.RevokedAt == 0 {
+		rt.RevokedAt = GetMillis()
+	}
+}
+
+func (rt *OAuthRevokedToken) IsValid() *AppError {
+	if len(rt.Token) != 26 {
+		return NewLocAppError("OAuthRevokedToken.IsValid", "model.access.is_valid.refresh_token.app_error", nil, "")
+	}
+
+	if len(rt.ClientId) != 26 {
+		return NewLocAppError("OAuthRevokedToken.IsValid", "model.access.is_valid.client_id.app_error", nil, "")
+	}
+
+	if len(rt.UserId) != 26 {
+		return NewLocAppError("OAuthRevokedToken.IsValid", "model.access.is_valid.user_id.app_error", nil, "")
+	}
+
+	return nil
+}
+
 func (me *AccessData) IsExpired() bool {
 
 	if me.ExpiresAt <= 0 {
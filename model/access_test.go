@@ -45,3 +45,31 @@ func TestAccessIsValid(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOAuthRevokedTokenIsValid(t *testing.T) {
+	rt := OAuthRevokedToken{}
+
+	if err := rt.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	rt.Token = NewId()
+	if err := rt.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	rt.ClientId = NewId()
+	if err := rt.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	rt.UserId = NewId()
+	if err := rt.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	rt.PreSave()
+	if rt.RevokedAt == 0 {
+		t.Fatal("should have set the revocation time")
+	}
+}
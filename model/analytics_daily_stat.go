@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	ANALYTICS_DAY_FORMAT = "2006-01-02"
+)
+
+// AnalyticsDailyStat holds the pre-aggregated activity for a single day. A blank TeamId
+// holds the totals for the whole system, including direct and group messages.
+type AnalyticsDailyStat struct {
+	Day             string `json:"day"`
+	TeamId          string `json:"team_id"`
+	PostCount       int64  `json:"post_count"`
+	ActiveUserCount int64  `json:"active_user_count"`
+	UpdateAt        int64  `json:"update_at"`
+}
+
+func (o *AnalyticsDailyStat) PreSave() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *AnalyticsDailyStat) IsValid() *AppError {
+	if _, err := time.Parse(ANALYTICS_DAY_FORMAT, o.Day); err != nil {
+		return NewLocAppError("AnalyticsDailyStat.IsValid", "model.analytics_daily_stat.is_valid.day.app_error", nil, "day="+o.Day)
+	}
+
+	if len(o.TeamId) != 0 && len(o.TeamId) != 26 {
+		return NewLocAppError("AnalyticsDailyStat.IsValid", "model.analytics_daily_stat.is_valid.team_id.app_error", nil, "day="+o.Day)
+	}
+
+	if o.PostCount < 0 || o.ActiveUserCount < 0 {
+		return NewLocAppError("AnalyticsDailyStat.IsValid", "model.analytics_daily_stat.is_valid.count.app_error", nil, "day="+o.Day)
+	}
+
+	return nil
+}
+
+func (o *AnalyticsDailyStat) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func AnalyticsDailyStatFromJson(data io.Reader) *AnalyticsDailyStat {
+	decoder := json.NewDecoder(data)
+	var o AnalyticsDailyStat
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// AnalyticsDayFromTime returns the day that t falls on in the format used to key the
+// daily stats.
+func AnalyticsDayFromTime(t time.Time) string {
+	return t.Format(ANALYTICS_DAY_FORMAT)
+}
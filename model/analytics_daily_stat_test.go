@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyticsDailyStatJson(t *testing.T) {
+	o := AnalyticsDailyStat{Day: "2017-04-01", TeamId: NewId(), PostCount: 10, ActiveUserCount: 2}
+	json := o.ToJson()
+	ro := AnalyticsDailyStatFromJson(strings.NewReader(json))
+
+	if ro.Day != o.Day || ro.TeamId != o.TeamId || ro.PostCount != o.PostCount || ro.ActiveUserCount != o.ActiveUserCount {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestAnalyticsDailyStatIsValid(t *testing.T) {
+	o := AnalyticsDailyStat{Day: "2017-04-01"}
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.TeamId = "abc"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.TeamId = NewId()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Day = "04/01/2017"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Day = "2017-04-01"
+	o.PostCount = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestAnalyticsDayFromTime(t *testing.T) {
+	if day := AnalyticsDayFromTime(time.Date(2017, time.April, 1, 23, 59, 59, 0, time.Local)); day != "2017-04-01" {
+		t.Fatal("incorrect day " + day)
+	}
+}
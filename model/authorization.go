@@ -9,11 +9,38 @@ type Permission struct {
 	Description string `json:"description"`
 }
 
+const (
+	ROLE_ID_MAX_LENGTH          = 64
+	ROLE_NAME_MAX_LENGTH        = 128
+	ROLE_DESCRIPTION_MAX_LENGTH = 128
+	ROLE_PERMISSIONS_MAX_LENGTH = 4096
+)
+
 type Role struct {
-	Id          string   `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Permissions []string `json:"permissions"`
+	Id          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Permissions StringArray `json:"permissions"`
+}
+
+func (r *Role) IsValid() *AppError {
+	if len(r.Id) == 0 || len(r.Id) > ROLE_ID_MAX_LENGTH {
+		return NewLocAppError("Role.IsValid", "model.role.is_valid.id.app_error", nil, "")
+	}
+
+	if len(r.Name) > ROLE_NAME_MAX_LENGTH {
+		return NewLocAppError("Role.IsValid", "model.role.is_valid.name.app_error", nil, "id="+r.Id)
+	}
+
+	if len(r.Description) > ROLE_DESCRIPTION_MAX_LENGTH {
+		return NewLocAppError("Role.IsValid", "model.role.is_valid.description.app_error", nil, "id="+r.Id)
+	}
+
+	if len(ArrayToJson(r.Permissions)) > ROLE_PERMISSIONS_MAX_LENGTH {
+		return NewLocAppError("Role.IsValid", "model.role.is_valid.permissions.app_error", nil, "id="+r.Id)
+	}
+
+	return nil
 }
 
 var PERMISSION_INVITE_USER *Permission
@@ -61,8 +88,19 @@ var PERMISSION_VIEW_TEAM *Permission
 // admin functions but not others
 var PERMISSION_MANAGE_SYSTEM *Permission
 
+// Permissions that allow access to parts of the admin functions without being able to change the rest of the system
+var PERMISSION_READ_SYSTEM *Permission
+var PERMISSION_MANAGE_USERS *Permission
+var PERMISSION_MANAGE_COMPLIANCE *Permission
+
+// Allows reading the mapping from the pseudonyms used in anonymized exports back to the users that they replace
+var PERMISSION_READ_PSEUDONYMS *Permission
+
 var ROLE_SYSTEM_USER *Role
 var ROLE_SYSTEM_ADMIN *Role
+var ROLE_SYSTEM_READ_ONLY_ADMIN *Role
+var ROLE_SYSTEM_USER_MANAGER *Role
+var ROLE_SYSTEM_COMPLIANCE_OFFICER *Role
 
 var ROLE_TEAM_USER *Role
 var ROLE_TEAM_ADMIN *Role
@@ -144,6 +182,26 @@ func InitalizePermissions() {
 		"authentication.permissions.manage_system.name",
 		"authentication.permissions.manage_system.description",
 	}
+	PERMISSION_READ_SYSTEM = &Permission{
+		"read_system",
+		"authentication.permissions.read_system.name",
+		"authentication.permissions.read_system.description",
+	}
+	PERMISSION_MANAGE_USERS = &Permission{
+		"manage_users",
+		"authentication.permissions.manage_users.name",
+		"authentication.permissions.manage_users.description",
+	}
+	PERMISSION_MANAGE_COMPLIANCE = &Permission{
+		"manage_compliance",
+		"authentication.permissions.manage_compliance.name",
+		"authentication.permissions.manage_compliance.description",
+	}
+	PERMISSION_READ_PSEUDONYMS = &Permission{
+		"read_pseudonyms",
+		"authentication.permissions.read_pseudonyms.name",
+		"authentication.permissions.read_pseudonyms.description",
+	}
 	PERMISSION_CREATE_DIRECT_CHANNEL = &Permission{
 		"create_direct_channel",
 		"authentication.permissions.create_direct_channel.name",
@@ -369,6 +427,10 @@ func InitalizeRoles() {
 						[]string{
 							PERMISSION_ASSIGN_SYSTEM_ADMIN_ROLE.Id,
 							PERMISSION_MANAGE_SYSTEM.Id,
+							PERMISSION_READ_SYSTEM.Id,
+							PERMISSION_MANAGE_USERS.Id,
+							PERMISSION_MANAGE_COMPLIANCE.Id,
+							PERMISSION_READ_PSEUDONYMS.Id,
 							PERMISSION_MANAGE_ROLES.Id,
 							PERMISSION_MANAGE_PUBLIC_CHANNEL_PROPERTIES.Id,
 							PERMISSION_DELETE_PUBLIC_CHANNEL.Id,
@@ -396,6 +458,50 @@ func InitalizeRoles() {
 	}
 	BuiltInRoles[ROLE_SYSTEM_ADMIN.Id] = ROLE_SYSTEM_ADMIN
 
+	// These roles are given to users along with system_user to let them use some of the admin functions
+	ROLE_SYSTEM_READ_ONLY_ADMIN = &Role{
+		"system_read_only_admin",
+		"authentication.roles.system_read_only_admin.name",
+		"authentication.roles.system_read_only_admin.description",
+		[]string{
+			PERMISSION_READ_SYSTEM.Id,
+		},
+	}
+	BuiltInRoles[ROLE_SYSTEM_READ_ONLY_ADMIN.Id] = ROLE_SYSTEM_READ_ONLY_ADMIN
+	ROLE_SYSTEM_USER_MANAGER = &Role{
+		"system_user_manager",
+		"authentication.roles.system_user_manager.name",
+		"authentication.roles.system_user_manager.description",
+		[]string{
+			PERMISSION_MANAGE_USERS.Id,
+		},
+	}
+	BuiltInRoles[ROLE_SYSTEM_USER_MANAGER.Id] = ROLE_SYSTEM_USER_MANAGER
+	ROLE_SYSTEM_COMPLIANCE_OFFICER = &Role{
+		"system_compliance_officer",
+		"authentication.roles.system_compliance_officer.name",
+		"authentication.roles.system_compliance_officer.description",
+		[]string{
+			PERMISSION_MANAGE_COMPLIANCE.Id,
+		},
+	}
+	BuiltInRoles[ROLE_SYSTEM_COMPLIANCE_OFFICER.Id] = ROLE_SYSTEM_COMPLIANCE_OFFICER
+}
+
+// StoredRoleIds returns the roles whose permission sets are kept in the Roles table. They start out with the permissions
+// given to them by InitalizeRoles, but once saved, the permissions in the database are used instead.
+func StoredRoleIds() []string {
+	return []string{
+		ROLE_SYSTEM_READ_ONLY_ADMIN.Id,
+		ROLE_SYSTEM_USER_MANAGER.Id,
+		ROLE_SYSTEM_COMPLIANCE_OFFICER.Id,
+	}
+}
+
+// IsAdminRole returns true for the roles that give access to any of the admin functions.
+func IsAdminRole(roleId string) bool {
+	return roleId == ROLE_SYSTEM_ADMIN.Id || roleId == ROLE_SYSTEM_READ_ONLY_ADMIN.Id ||
+		roleId == ROLE_SYSTEM_USER_MANAGER.Id || roleId == ROLE_SYSTEM_COMPLIANCE_OFFICER.Id
 }
 
 func RoleIdsToString(roles []string) string {
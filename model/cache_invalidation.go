@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	CACHE_INVALIDATION_TYPE_USER                = "user"
+	CACHE_INVALIDATION_TYPE_SESSIONS_FOR_USER   = "sessions_for_user"
+	CACHE_INVALIDATION_TYPE_CHANNEL             = "channel"
+	CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME     = "channel_by_name"
+	CACHE_INVALIDATION_TYPE_CHANNEL_MEMBERS     = "channel_members"
+	CACHE_INVALIDATION_TYPE_CHANNEL_POSTS       = "channel_posts"
+	CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST = "file_infos_for_post"
+	CACHE_INVALIDATION_TYPE_WEBHOOK             = "webhook"
+)
+
+// CacheInvalidation is sent to the other nodes of a cluster when one of them changes data that each node keeps in
+// an in-memory cache. Channels looked up by name are identified by TeamId and Name, everything else by Id.
+type CacheInvalidation struct {
+	Type   string `json:"type"`
+	Id     string `json:"id,omitempty"`
+	TeamId string `json:"team_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+func NewCacheInvalidation(invalidationType, id string) *CacheInvalidation {
+	return &CacheInvalidation{Type: invalidationType, Id: id}
+}
+
+func (o *CacheInvalidation) IsValid() *AppError {
+	switch o.Type {
+	case CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME:
+		if len(o.TeamId) != 26 {
+			return NewLocAppError("CacheInvalidation.IsValid", "model.cache_invalidation.is_valid.team_id.app_error", nil, "type="+o.Type)
+		}
+
+		if len(o.Name) == 0 {
+			return NewLocAppError("CacheInvalidation.IsValid", "model.cache_invalidation.is_valid.name.app_error", nil, "type="+o.Type)
+		}
+	case CACHE_INVALIDATION_TYPE_USER,
+		CACHE_INVALIDATION_TYPE_SESSIONS_FOR_USER,
+		CACHE_INVALIDATION_TYPE_CHANNEL,
+		CACHE_INVALIDATION_TYPE_CHANNEL_MEMBERS,
+		CACHE_INVALIDATION_TYPE_CHANNEL_POSTS,
+		CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST,
+		CACHE_INVALIDATION_TYPE_WEBHOOK:
+		if len(o.Id) != 26 {
+			return NewLocAppError("CacheInvalidation.IsValid", "model.cache_invalidation.is_valid.id.app_error", nil, "type="+o.Type)
+		}
+	default:
+		return NewLocAppError("CacheInvalidation.IsValid", "model.cache_invalidation.is_valid.type.app_error", nil, "type="+o.Type)
+	}
+
+	return nil
+}
+
+func (o *CacheInvalidation) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func CacheInvalidationFromJson(data io.Reader) *CacheInvalidation {
+	decoder := json.NewDecoder(data)
+	var o CacheInvalidation
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	}
+
+	return nil
+}
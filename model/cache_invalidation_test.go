@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheInvalidationJson(t *testing.T) {
+	o := CacheInvalidation{Type: CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME, TeamId: NewId(), Name: "town-square"}
+	json := o.ToJson()
+	ro := CacheInvalidationFromJson(strings.NewReader(json))
+
+	if o != *ro {
+		t.Fatal("invalidations do not match")
+	}
+
+	if CacheInvalidationFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode junk")
+	}
+}
+
+func TestCacheInvalidationIsValid(t *testing.T) {
+	o := NewCacheInvalidation(CACHE_INVALIDATION_TYPE_FILE_INFOS_FOR_POST, NewId())
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Id = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without an id")
+	}
+
+	o.Type = "junk"
+	o.Id = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid with an unknown type")
+	}
+
+	o = &CacheInvalidation{Type: CACHE_INVALIDATION_TYPE_CHANNEL_BY_NAME, TeamId: NewId()}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a name")
+	}
+
+	o.Name = "town-square"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.TeamId = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a team id")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	CERTIFICATE_CACHE_NAME_MAX_LENGTH = 256
+	CERTIFICATE_CACHE_DATA_MAX_LENGTH = 65535
+)
+
+// CertificateCacheEntry holds an opaque blob, such as a PEM encoded certificate chain or ACME account key,
+// so that automatically issued TLS certificates can be shared between servers through the database.
+type CertificateCacheEntry struct {
+	Name     string
+	Data     string
+	UpdateAt int64
+}
+
+func (e *CertificateCacheEntry) PreSave() {
+	e.UpdateAt = GetMillis()
+}
+
+func (e *CertificateCacheEntry) IsValid() *AppError {
+	if len(e.Name) == 0 || len(e.Name) > CERTIFICATE_CACHE_NAME_MAX_LENGTH {
+		return NewLocAppError("CertificateCacheEntry.IsValid", "model.certificate_cache_entry.is_valid.name.app_error", nil, "")
+	}
+
+	if len(e.Data) > CERTIFICATE_CACHE_DATA_MAX_LENGTH {
+		return NewLocAppError("CertificateCacheEntry.IsValid", "model.certificate_cache_entry.is_valid.data.app_error", nil, "name="+e.Name)
+	}
+
+	if e.UpdateAt == 0 {
+		return NewLocAppError("CertificateCacheEntry.IsValid", "model.certificate_cache_entry.is_valid.update_at.app_error", nil, "name="+e.Name)
+	}
+
+	return nil
+}
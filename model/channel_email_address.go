@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ChannelEmailAddress is the address that email can be sent to in order to post it in a channel. The token is the
+// local part of the address, so anyone who knows the address can send to it as long as they have an account with the
+// email that they're sending from.
+type ChannelEmailAddress struct {
+	ChannelId string `json:"channel_id"`
+	Token     string `json:"-"`
+	CreatorId string `json:"creator_id"`
+	CreateAt  int64  `json:"create_at"`
+	Address   string `json:"address" db:"-"`
+}
+
+func (o *ChannelEmailAddress) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ChannelEmailAddressFromJson(data io.Reader) *ChannelEmailAddress {
+	decoder := json.NewDecoder(data)
+	var o ChannelEmailAddress
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func (o *ChannelEmailAddress) PreSave() {
+	if o.Token == "" {
+		o.Token = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *ChannelEmailAddress) IsValid() *AppError {
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("ChannelEmailAddress.IsValid", "model.channel_email_address.is_valid.channel_id.app_error", nil, "")
+	}
+
+	if len(o.Token) != 26 {
+		return NewLocAppError("ChannelEmailAddress.IsValid", "model.channel_email_address.is_valid.token.app_error", nil, "channel_id="+o.ChannelId)
+	}
+
+	if len(o.CreatorId) != 26 {
+		return NewLocAppError("ChannelEmailAddress.IsValid", "model.channel_email_address.is_valid.creator_id.app_error", nil, "channel_id="+o.ChannelId)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ChannelEmailAddress.IsValid", "model.channel_email_address.is_valid.create_at.app_error", nil, "channel_id="+o.ChannelId)
+	}
+
+	return nil
+}
@@ -32,3 +32,22 @@ func ChannelStatsFromJson(data io.Reader) *ChannelStats {
 		return nil
 	}
 }
+
+func ChannelStatsListToJson(o []*ChannelStats) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ChannelStatsListFromJson(data io.Reader) []*ChannelStats {
+	decoder := json.NewDecoder(data)
+	var o []*ChannelStats
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -298,6 +298,18 @@ func (c *Client) GetPing() (map[string]string, *AppError) {
 	}
 }
 
+// GetDatabaseHealth returns the result of the server's most recent check of the database
+// without the errors that it found. It doesn't fail when the database is unhealthy, so check
+// the status of the result.
+func (c *Client) GetDatabaseHealth() (*DatabaseHealth, *AppError) {
+	if r, err := c.DoApiGet(c.GetGeneralRoute()+"/database_health", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return DatabaseHealthFromJson(r.Body), nil
+	}
+}
+
 // Team Routes Section
 
 // CreateTeam creates a team based on the provided Team struct. On success it returns
@@ -419,6 +431,94 @@ func (c *Client) InviteMembers(invites *Invites) (*Result, *AppError) {
 	}
 }
 
+// CreateInvitation creates a single use invitation to the current team. If email is not empty, only
+// an account with that email address can use the invitation.
+func (c *Client) CreateInvitation(email string) (*Invitation, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/invitations/create", MapToJson(map[string]string{"email": email})); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return InvitationFromJson(r.Body), nil
+	}
+}
+
+// GetInvitations returns the unused, unexpired invitations to the current team. Must be
+// authenticated as a team admin for that team or a system admin.
+func (c *Client) GetInvitations() ([]*Invitation, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/invitations", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return InvitationListFromJson(r.Body), nil
+	}
+}
+
+// RevokeInvitation deletes an invitation to the current team so that it can no longer be used.
+// Must be authenticated as a team admin for that team or a system admin.
+func (c *Client) RevokeInvitation(invitationId string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/invitations/"+invitationId+"/revoke", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// RegenerateTeamInviteLink replaces the invite links of the current team with a new one that can be
+// used maxUses times and expires after expiresInHours. A limit of 0 means that the link has no such
+// limit. Must be authenticated as a team admin for that team or a system admin.
+func (c *Client) RegenerateTeamInviteLink(maxUses int64, expiresInHours int64) (*TeamInviteLink, *AppError) {
+	data := map[string]interface{}{"max_uses": maxUses, "expires_in_hours": expiresInHours}
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/invite_links/regenerate", StringInterfaceToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return TeamInviteLinkFromJson(r.Body), nil
+	}
+}
+
+// GetTeamInviteLinks returns all of the invite links that have been created for the current team
+// along with the number of users that joined through each of them. Must be authenticated as a
+// team admin for that team or a system admin.
+func (c *Client) GetTeamInviteLinks() ([]*TeamInviteLink, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/invite_links", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return TeamInviteLinkListFromJson(r.Body), nil
+	}
+}
+
+// GetTeamDefaultChannels returns the channels that new members of the current team are added to
+// in addition to Town Square and Off-Topic. Must be authenticated as a team admin for that team
+// or a system admin.
+func (c *Client) GetTeamDefaultChannels() ([]*TeamDefaultChannel, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/default_channels", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return TeamDefaultChannelListFromJson(r.Body), nil
+	}
+}
+
+// UpdateTeamDefaultChannels replaces the channels that new members of the current team are
+// added to. Must be authenticated as a team admin for that team or a system admin.
+func (c *Client) UpdateTeamDefaultChannels(channelIds []string) ([]*TeamDefaultChannel, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/default_channels/update", ArrayToJson(channelIds)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return TeamDefaultChannelListFromJson(r.Body), nil
+	}
+}
+
 // UpdateTeam updates a team based on the changes in the provided team struct. On success
 // it returns a sanitized version of the updated team. Must be authenticated as a team admin
 // for that team or a system admin.
@@ -518,6 +618,18 @@ func (c *Client) GetMe(etag string) (*Result, *AppError) {
 	}
 }
 
+// CreateWebSocketConnectionToken returns a one-time token that can be passed as the connection_token query
+// parameter when opening a WebSocket instead of authenticating with the session token. Must be authenticated.
+func (c *Client) CreateWebSocketConnectionToken() (*WebSocketConnectionToken, *AppError) {
+	if r, err := c.DoApiPost("/users/websocket_token", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return WebSocketConnectionTokenFromJson(r.Body), nil
+	}
+}
+
 // GetProfiles returns a map of users using user id as the key. Must be authenticated.
 func (c *Client) GetProfiles(offset int, limit int, etag string) (*Result, *AppError) {
 	if r, err := c.DoApiGet(fmt.Sprintf("/users/%v/%v", offset, limit), "", etag); err != nil {
@@ -913,6 +1025,17 @@ func (c *Client) GetLogs() (*Result, *AppError) {
 	}
 }
 
+// QueryLogs returns a page of the server log entries selected by the query, newest first. You
+// must have the system admin role to call this method.
+func (c *Client) QueryLogs(query *LogQuery) (*LogPage, *AppError) {
+	if r, err := c.DoApiPost("/admin/logs/query", query.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return LogPageFromJson(r.Body), nil
+	}
+}
+
 func (c *Client) GetClusterStatus() ([]*ClusterInfo, *AppError) {
 	if r, err := c.DoApiGet("/admin/cluster_status", "", ""); err != nil {
 		return nil, err
@@ -922,6 +1045,278 @@ func (c *Client) GetClusterStatus() ([]*ClusterInfo, *AppError) {
 	}
 }
 
+// GetClusterNodes returns the app servers that have registered themselves in the
+// cluster discovery table and are still sending heartbeats.
+func (c *Client) GetClusterNodes() ([]*ClusterDiscovery, *AppError) {
+	if r, err := c.DoApiGet("/admin/cluster_nodes", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ClusterDiscoveriesFromJson(r.Body), nil
+	}
+}
+
+// AggregateAnalytics queues a background job that recomputes the daily analytics stats for
+// every day from startDay to endDay, formatted as YYYY-MM-DD. It can be used to backfill
+// the stats for historical data. You must have the system admin role to call this method.
+func (c *Client) AggregateAnalytics(startDay string, endDay string) (*Job, *AppError) {
+	m := map[string]string{
+		"start_day": startDay,
+		"end_day":   endDay,
+	}
+
+	if r, err := c.DoApiPost("/admin/aggregate_analytics", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), nil
+	}
+}
+
+// GetRetentionPolicies returns the retention policies that override the global data
+// retention settings for teams and channels. You must have the system admin role to call
+// this method.
+func (c *Client) GetRetentionPolicies() ([]*RetentionPolicy, *AppError) {
+	if r, err := c.DoApiGet("/admin/retention_policies", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return RetentionPolicyListFromJson(r.Body), nil
+	}
+}
+
+// CreateRetentionPolicy creates a retention policy for a team or channel. You must have the
+// system admin role to call this method.
+func (c *Client) CreateRetentionPolicy(policy *RetentionPolicy) (*RetentionPolicy, *AppError) {
+	if r, err := c.DoApiPost("/admin/retention_policies/create", policy.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return RetentionPolicyFromJson(r.Body), nil
+	}
+}
+
+// UpdateRetentionPolicy updates an existing retention policy. You must have the system
+// admin role to call this method.
+func (c *Client) UpdateRetentionPolicy(policy *RetentionPolicy) (*RetentionPolicy, *AppError) {
+	if r, err := c.DoApiPost("/admin/retention_policies/"+policy.Id+"/update", policy.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return RetentionPolicyFromJson(r.Body), nil
+	}
+}
+
+// DeleteRetentionPolicy deletes a retention policy so that the team or channel falls back
+// to the global data retention settings. You must have the system admin role to call this
+// method.
+func (c *Client) DeleteRetentionPolicy(policyId string) (bool, *AppError) {
+	if r, err := c.DoApiPost("/admin/retention_policies/"+policyId+"/delete", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// PreviewRetentionPolicy returns the number of posts and files that would be deleted by the
+// given retention policy, which doesn't need to be saved first. You must have the system
+// admin role to call this method.
+func (c *Client) PreviewRetentionPolicy(policy *RetentionPolicy) (*RetentionPreview, *AppError) {
+	if r, err := c.DoApiPost("/admin/retention_policies/preview", policy.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return RetentionPreviewFromJson(r.Body), nil
+	}
+}
+
+// GetLegalHolds returns the legal holds that keep the content of users and channels from
+// being permanently deleted. You must have the system admin role to call this method.
+func (c *Client) GetLegalHolds() ([]*LegalHold, *AppError) {
+	if r, err := c.DoApiGet("/admin/legal_holds", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return LegalHoldListFromJson(r.Body), nil
+	}
+}
+
+// CreateLegalHold places a legal hold on the content of a user or channel. You must have the
+// system admin role to call this method.
+func (c *Client) CreateLegalHold(hold *LegalHold) (*LegalHold, *AppError) {
+	if r, err := c.DoApiPost("/admin/legal_holds/create", hold.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return LegalHoldFromJson(r.Body), nil
+	}
+}
+
+// UpdateLegalHold updates an existing legal hold. You must have the system admin role to
+// call this method.
+func (c *Client) UpdateLegalHold(hold *LegalHold) (*LegalHold, *AppError) {
+	if r, err := c.DoApiPost("/admin/legal_holds/"+hold.Id+"/update", hold.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return LegalHoldFromJson(r.Body), nil
+	}
+}
+
+// DeleteLegalHold releases a legal hold so that the content it held can be deleted again. You
+// must have the system admin role to call this method.
+func (c *Client) DeleteLegalHold(holdId string) (bool, *AppError) {
+	if r, err := c.DoApiPost("/admin/legal_holds/"+holdId+"/delete", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// ExportLegalHold returns a page of the posts held by a legal hold, oldest first, along with
+// the files attached to them. You must have the system admin role to call this method.
+func (c *Client) ExportLegalHold(holdId string, offset int, limit int) (*LegalHoldExport, *AppError) {
+	if r, err := c.DoApiGet(fmt.Sprintf("/admin/legal_holds/%v/export/%v/%v", holdId, offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return LegalHoldExportFromJson(r.Body), nil
+	}
+}
+
+// ExportFileAccessLog returns a page of the file downloads, previews and thumbnails recorded in
+// the file access log, oldest first. You must have the system admin role to call this method.
+func (c *Client) ExportFileAccessLog(query *FileAccessQuery) ([]*FileAccess, *AppError) {
+	if r, err := c.DoApiPost("/admin/file_access_log/export", query.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return FileAccessListFromJson(r.Body), nil
+	}
+}
+
+// ReindexSearch queues a background job that indexes the posts created between startTime
+// and endTime into the configured search backend. An endTime of 0 means now, and rebuild
+// purges the existing indexes first. You must have the system admin role to call this method.
+func (c *Client) ReindexSearch(startTime int64, endTime int64, rebuild bool) (*Job, *AppError) {
+	m := map[string]interface{}{
+		"start_time": startTime,
+		"end_time":   endTime,
+		"rebuild":    rebuild,
+	}
+
+	if r, err := c.DoApiPost("/admin/search/reindex", StringInterfaceToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), nil
+	}
+}
+
+// RepairChannelCounts queues a background job that recounts the message counts of every
+// channel and its members from the posts in them, in case they've drifted. You must have
+// the system admin role to call this method.
+func (c *Client) RepairChannelCounts() (*Job, *AppError) {
+	if r, err := c.DoApiPost("/admin/channel_counts/repair", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), nil
+	}
+}
+
+// GetJobsByType returns a page of the background jobs of the given type, newest first.
+// You must have the system admin role to call this method.
+func (c *Client) GetJobsByType(jobType string, offset int, limit int) ([]*Job, *AppError) {
+	if r, err := c.DoApiGet(fmt.Sprintf("/admin/jobs/type/%v/%v/%v", jobType, offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobsFromJson(r.Body), nil
+	}
+}
+
+// GetPseudonyms returns a page of the mapping between users and the pseudonyms that replace them
+// in anonymized exports. You must have the system admin role to call this method.
+func (c *Client) GetPseudonyms(offset int, limit int) ([]*Pseudonym, *AppError) {
+	if r, err := c.DoApiGet(fmt.Sprintf("/admin/pseudonyms/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return PseudonymListFromJson(r.Body), nil
+	}
+}
+
+// GetDeletedFiles returns a page of the deleted files that can still be restored, most recently
+// deleted first. You must have the system admin role to call this method.
+func (c *Client) GetDeletedFiles(offset int, limit int) ([]*FileInfo, *AppError) {
+	if r, err := c.DoApiGet(fmt.Sprintf("/admin/files/deleted/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return FileInfosFromJson(r.Body), nil
+	}
+}
+
+// RestoreFiles undeletes the given files and reattaches them to their posts. Only the files that
+// were restored are returned. You must have the system admin role to call this method.
+func (c *Client) RestoreFiles(fileIds []string) ([]*FileInfo, *AppError) {
+	if r, err := c.DoApiPost("/admin/files/restore", ArrayToJson(fileIds)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return FileInfosFromJson(r.Body), nil
+	}
+}
+
+// GetJob returns the background job with the given id, including its progress.
+// You must have the system admin role to call this method.
+func (c *Client) GetJob(jobId string) (*Job, *AppError) {
+	if r, err := c.DoApiGet("/admin/jobs/"+jobId, "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), nil
+	}
+}
+
+// CancelJob stops the background job with the given id. A job that is already running
+// stops the next time it reports its progress. You must have the system admin role to
+// call this method.
+func (c *Client) CancelJob(jobId string) (*Job, *AppError) {
+	if r, err := c.DoApiPost("/admin/jobs/"+jobId+"/cancel", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), nil
+	}
+}
+
+// GetJobSchedules returns when each type of scheduled background job runs.
+// You must have the system admin role to call this method.
+func (c *Client) GetJobSchedules() ([]*JobSchedule, *AppError) {
+	if r, err := c.DoApiGet("/admin/jobs/schedules", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobSchedulesFromJson(r.Body), nil
+	}
+}
+
+// UpdateJobSchedule changes the cron schedule and time zone that a type of background job
+// runs on. An empty schedule restores the default one. You must have the system admin role
+// to call this method.
+func (c *Client) UpdateJobSchedule(schedule *JobSchedule) (*JobSchedule, *AppError) {
+	if r, err := c.DoApiPost("/admin/jobs/schedules/update", schedule.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return JobScheduleFromJson(r.Body), nil
+	}
+}
+
 // GetRecentlyActiveUsers returns a map of users including lastActivityAt using user id as the key
 func (c *Client) GetRecentlyActiveUsers(teamId string) (*Result, *AppError) {
 	if r, err := c.DoApiGet("/admin/recently_active_users/"+teamId, "", ""); err != nil {
@@ -1000,6 +1395,32 @@ func (c *Client) RecycleDatabaseConnection() (bool, *AppError) {
 	}
 }
 
+// CheckDatabaseHealth checks the connections to the master database and each of its replicas
+// and returns the details of what was found. An error is returned along with the details if the
+// database is unhealthy. You must have the system admin role to call this method.
+func (c *Client) CheckDatabaseHealth() (*DatabaseHealth, *AppError) {
+	rq, _ := http.NewRequest("GET", c.ApiUrl+"/admin/database_health", nil)
+	rq.Close = true
+
+	if len(c.AuthToken) > 0 {
+		rq.Header.Set(HEADER_AUTH, c.AuthType+" "+c.AuthToken)
+	}
+
+	if rp, err := c.HttpClient.Do(rq); err != nil {
+		return nil, NewLocAppError("/admin/database_health", "model.client.connecting.app_error", nil, err.Error())
+	} else {
+		defer closeBody(rp)
+
+		if rp.StatusCode == http.StatusServiceUnavailable {
+			return DatabaseHealthFromJson(rp.Body), NewAppError("CheckDatabaseHealth", "model.client.check_database_health.unhealthy.app_error", nil, "", rp.StatusCode)
+		} else if rp.StatusCode >= 300 {
+			return nil, AppErrorFromJson(rp.Body)
+		}
+
+		return DatabaseHealthFromJson(rp.Body), nil
+	}
+}
+
 func (c *Client) TestEmail(config *Config) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/admin/test_email", config.ToJson()); err != nil {
 		return nil, err
@@ -1010,6 +1431,38 @@ func (c *Client) TestEmail(config *Config) (*Result, *AppError) {
 	}
 }
 
+// GetEmailTemplates returns the names of the email templates that can be previewed.
+func (c *Client) GetEmailTemplates() ([]string, *AppError) {
+	if r, err := c.DoApiGet("/admin/email_templates", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ArrayFromJson(r.Body), nil
+	}
+}
+
+// PreviewEmailTemplate returns the HTML of an email template as it would be sent to users with
+// the given locale, with placeholders in place of the details of each email.
+func (c *Client) PreviewEmailTemplate(templateName string, locale string) (string, *AppError) {
+	if r, err := c.DoApiPost("/admin/email_templates/preview", MapToJson(map[string]string{"template": templateName, "locale": locale})); err != nil {
+		return "", err
+	} else {
+		defer closeBody(r)
+		return MapFromJson(r.Body)["html"], nil
+	}
+}
+
+// TestEmailTemplate sends a preview of an email template to the current user's email address.
+// You must have the system admin role to call this method.
+func (c *Client) TestEmailTemplate(templateName string, locale string) (bool, *AppError) {
+	if r, err := c.DoApiPost("/admin/email_templates/test", MapToJson(map[string]string{"template": templateName, "locale": locale})); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
 // TestLdap will run a connection test on the current LDAP settings.
 // It will return the standard OK response if settings work. Otherwise
 // it will return an appropriate error.
@@ -1365,6 +1818,62 @@ func (c *Client) GetChannelStats(id string, etag string) (*Result, *AppError) {
 	}
 }
 
+// GetChannelEmailAddress returns the address that email can be sent to in order to post in a channel.
+// Must be able to post in the channel.
+func (c *Client) GetChannelEmailAddress(id string) (*ChannelEmailAddress, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(id)+"/email_address", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ChannelEmailAddressFromJson(r.Body), nil
+	}
+}
+
+// RegenerateChannelEmailAddress gives a channel a new email address, and the old one stops working.
+// Must be able to manage the channel.
+func (c *Client) RegenerateChannelEmailAddress(id string) (*ChannelEmailAddress, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(id)+"/email_address/regenerate", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ChannelEmailAddressFromJson(r.Body), nil
+	}
+}
+
+// DeleteChannelEmailAddress stops a channel from receiving email. Must be able to manage the channel.
+func (c *Client) DeleteChannelEmailAddress(id string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(id)+"/email_address/delete", ""); err != nil {
+		return false, err
+	} else {
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// ExportChannel returns a zip file containing a channel's posts, reactions and attached files. If
+// anonymize is true, the users in the export are replaced by their pseudonyms. Must be authenticated
+// as a team admin of the channel's team or a system admin. The caller is responsible for closing the
+// returned reader.
+func (c *Client) ExportChannel(id string, anonymize bool) (io.ReadCloser, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(id)+"/export?anonymize="+strconv.FormatBool(anonymize), "", ""); err != nil {
+		return nil, err
+	} else {
+		c.fillInExtraProperties(r)
+		return r.Body, nil
+	}
+}
+
+// GetChannelsStats returns the stats of each of the given channels as an array of
+// ChannelStats in the same order. Must be authenticated and a member of every channel.
+func (c *Client) GetChannelsStats(channelIds []string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/channels/stats", ArrayToJson(channelIds)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ChannelStatsListFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetChannelMember(channelId string, userId string) (*Result, *AppError) {
 	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/members/"+userId, "", ""); err != nil {
 		return nil, err
@@ -1457,6 +1966,68 @@ func (c *Client) GetPost(channelId string, postId string, etag string) (*Result,
 	}
 }
 
+// GetFollowedThreads returns the current user's memberships in the threads that they're
+// following in a channel, including how many replies in each are unread.
+func (c *Client) GetFollowedThreads(channelId string) ([]*ThreadMembership, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/posts/threads", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ThreadMembershipsFromJson(r.Body), nil
+	}
+}
+
+// MarkThreadRead clears the unread replies in the thread started by the given post for the
+// current user.
+func (c *Client) MarkThreadRead(channelId string, postId string) (*ThreadMembership, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/read", postId), ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ThreadMembershipFromJson(r.Body), nil
+	}
+}
+
+// ForwardPost posts a copy of a post, along with its attachments, to another channel. The copy
+// records where the post was originally posted in its props.
+func (c *Client) ForwardPost(channelId string, postId string, toChannelId string) (*Post, *AppError) {
+	data := map[string]string{"channel_id": toChannelId}
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/forward", postId), MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return PostFromJson(r.Body), nil
+	}
+}
+
+// ForwardPostToUser posts a copy of a post, along with its attachments, to a direct message
+// channel with the given user, creating that channel if it doesn't exist yet.
+func (c *Client) ForwardPostToUser(channelId string, postId string, toUserId string) (*Post, *AppError) {
+	data := map[string]string{"user_id": toUserId}
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/forward", postId), MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return PostFromJson(r.Body), nil
+	}
+}
+
+// TranslatePost returns a post's message translated into the given locale, or into the current
+// user's locale if it's empty. Must be able to read the post's channel.
+func (c *Client) TranslatePost(channelId string, postId string, locale string) (*PostTranslation, *AppError) {
+	query := ""
+	if len(locale) > 0 {
+		query = "?locale=" + url.QueryEscape(locale)
+	}
+
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/translate", postId)+query, "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return PostTranslationFromJson(r.Body), nil
+	}
+}
+
 // GetPostById returns a post and any posts in the same thread by post id
 func (c *Client) GetPostById(postId string, etag string) (*PostList, *ResponseMetadata) {
 	if r, err := c.DoApiGet(c.GetTeamRoute()+fmt.Sprintf("/posts/%v", postId), "", etag); err != nil {
@@ -1526,6 +2097,57 @@ func (c *Client) UploadProfileFile(data []byte, contentType string) (*Result, *A
 	return c.uploadFile(c.ApiUrl+"/users/newimage", data, contentType)
 }
 
+// GetProfileImageHistory returns the profile pictures that a user has uploaded and can revert to,
+// starting with their current one.
+func (c *Client) GetProfileImageHistory(userId string) ([]*ProfileImage, *AppError) {
+	if r, err := c.DoApiGet(c.GetUserRequiredRoute(userId)+"/image/history", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return ProfileImagesFromJson(r.Body), nil
+	}
+}
+
+// RevertProfileImage makes one of the profile pictures that a user uploaded before their current
+// one again.
+func (c *Client) RevertProfileImage(userId string, fileId string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetUserRequiredRoute(userId)+"/image/"+fileId+"/revert", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// CreateUserAccessToken creates a personal access token for a user with the description, scopes and rate limit of
+// the given one. The returned token is the only time that the token's value is returned.
+func (c *Client) CreateUserAccessToken(userId string, token *UserAccessToken) (*UserAccessToken, *AppError) {
+	if r, err := c.DoApiPost(c.GetUserRequiredRoute(userId)+"/tokens/create", token.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return UserAccessTokenFromJson(r.Body), nil
+	}
+}
+
+func (c *Client) GetUserAccessTokens(userId string) ([]*UserAccessToken, *AppError) {
+	if r, err := c.DoApiGet(c.GetUserRequiredRoute(userId)+"/tokens", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return UserAccessTokenListFromJson(r.Body), nil
+	}
+}
+
+func (c *Client) RevokeUserAccessToken(userId string, tokenId string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetUserRequiredRoute(userId)+"/tokens/"+tokenId+"/revoke", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
 func (c *Client) UploadPostAttachment(data []byte, channelId string, filename string) (*FileUploadResponse, *AppError) {
 	c.clearExtraProperties()
 
@@ -1623,6 +2245,41 @@ func (c *Client) GetPublicLink(fileId string) (string, *AppError) {
 	}
 }
 
+// GetFileAcl returns the users and channels that a file has been restricted to. Files that
+// aren't restricted have an empty list. You must have uploaded the file or be a system admin
+// to call this method.
+func (c *Client) GetFileAcl(fileId string) (*FileAcl, *AppError) {
+	if r, err := c.DoApiGet(c.GetFileRoute(fileId)+"/acl", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return FileAclFromJson(r.Body), nil
+	}
+}
+
+// UpdateFileAcl restricts a file so that it can only be accessed by the given users and the
+// members of the given channels, replacing any existing restrictions. You must have uploaded
+// the file or be a system admin to call this method.
+func (c *Client) UpdateFileAcl(fileId string, acl *FileAcl) (*FileAcl, *AppError) {
+	if r, err := c.DoApiPost(c.GetFileRoute(fileId)+"/acl/update", acl.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return FileAclFromJson(r.Body), nil
+	}
+}
+
+// DeleteFileAcl removes the restrictions on a file so that anyone who can see its post can
+// access it. You must have uploaded the file or be a system admin to call this method.
+func (c *Client) DeleteFileAcl(fileId string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetFileRoute(fileId)+"/acl/delete", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
 func (c *Client) UpdateUser(user *User) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/users/update", user.ToJson()); err != nil {
 		return nil, err
@@ -1748,6 +2405,34 @@ func (c *Client) AdminResetPassword(userId, newPassword string) (*Result, *AppEr
 	}
 }
 
+// AdminUnlockUser lifts the lockout of an account caused by too many failed logins. Must be
+// authenticated as a system administrator.
+func (c *Client) AdminUnlockUser(userId string) (*Result, *AppError) {
+	data := map[string]string{}
+	data["user_id"] = userId
+	if r, err := c.DoApiPost("/admin/unlock_user", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+// AdminUnlockIpAddress lifts the block on logins from an IP address caused by too many failed
+// logins. Must be authenticated as a system administrator.
+func (c *Client) AdminUnlockIpAddress(ipAddress string) (*Result, *AppError) {
+	data := map[string]string{}
+	data["ip_address"] = ipAddress
+	if r, err := c.DoApiPost("/admin/unlock_ip", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
 // GetStatuses returns a map of string statuses using user id as the key
 func (c *Client) GetStatuses() (*Result, *AppError) {
 	if r, err := c.DoApiGet("/users/status", "", ""); err != nil {
@@ -1771,6 +2456,27 @@ func (c *Client) GetStatusesByIds(userIds []string) (*Result, *AppError) {
 	}
 }
 
+// SetCustomStatus sets the emoji and text shown next to the current user's name until the status
+// is removed or it expires.
+func (c *Client) SetCustomStatus(status *CustomStatus) (*CustomStatus, *AppError) {
+	if r, err := c.DoApiPost("/users/status/custom", status.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return CustomStatusFromJson(r.Body), nil
+	}
+}
+
+// RemoveCustomStatus clears the current user's custom status.
+func (c *Client) RemoveCustomStatus() (bool, *AppError) {
+	if r, err := c.DoApiPost("/users/status/custom/clear", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
 // SetActiveChannel sets the the channel id the user is currently viewing.
 // The channelId key is required but the value can be blank. Returns standard
 // response.
@@ -1809,6 +2515,27 @@ func (c *Client) GetTeamMembers(teamId string, offset int, limit int) (*Result,
 	}
 }
 
+// GetSortedTeamMembers will return a page of team member objects sorted by either
+// TEAM_MEMBER_SORT_BY_USERNAME or TEAM_MEMBER_SORT_BY_JOINED_AT. If role is provided, only
+// members with that team role are returned. Must be authenticated.
+func (c *Client) GetSortedTeamMembers(teamId string, offset int, limit int, sort string, role string) (*Result, *AppError) {
+	query := url.Values{}
+	if len(sort) > 0 {
+		query.Set("sort", sort)
+	}
+	if len(role) > 0 {
+		query.Set("role", role)
+	}
+
+	if r, err := c.DoApiGet(fmt.Sprintf("/teams/%v/members/%v/%v?%v", teamId, offset, limit, query.Encode()), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), TeamMembersFromJson(r.Body)}, nil
+	}
+}
+
 // GetMyTeamMembers will return an array with team member objects that the current user
 // is a member of. Must be authenticated.
 func (c *Client) GetMyTeamMembers() (*Result, *AppError) {
@@ -1987,6 +2714,27 @@ func (c *Client) RegenerateOAuthAppSecret(clientId string) (*Result, *AppError)
 	}
 }
 
+// RevokeOAuthAppTokens revokes every access and refresh token issued by an OAuth app, so all of
+// its users need to authorize it again.
+func (c *Client) RevokeOAuthAppTokens(clientId string) (bool, *AppError) {
+	if r, err := c.DoApiPost("/oauth/"+clientId+"/revoke_tokens", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// RevokeUserOAuthTokens revokes every access and refresh token that OAuth apps hold for a user.
+func (c *Client) RevokeUserOAuthTokens(userId string) (bool, *AppError) {
+	if r, err := c.DoApiPost("/users/"+userId+"/revoke_oauth_tokens", ""); err != nil {
+		return false, err
+	} else {
+		defer closeBody(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
 func (c *Client) GetAccessToken(data url.Values) (*Result, *AppError) {
 	if r, err := c.DoPost("/oauth/access_token", data.Encode(), "application/x-www-form-urlencoded"); err != nil {
 		return nil, err
@@ -0,0 +1,190 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	CDS_OFFLINE_AFTER_MILLIS = 1000 * 60 * 2 // 2 minutes
+	CDS_PING_INTERVAL_MILLIS = 1000 * 30     // 30 seconds
+	CDS_TYPE_APP             = "mattermost_app"
+)
+
+type ClusterDiscovery struct {
+	Id          string `json:"id"`
+	Type        string `json:"type"`
+	ClusterName string `json:"cluster_name"`
+	Hostname    string `json:"hostname"`
+	Port        int32  `json:"port"`
+	Version     string `json:"version"`
+	ConfigHash  string `json:"config_hash"`
+	CreateAt    int64  `json:"create_at"`
+	LastPingAt  int64  `json:"last_ping_at"`
+}
+
+func (o *ClusterDiscovery) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+		o.LastPingAt = o.CreateAt
+	}
+}
+
+func (o *ClusterDiscovery) AutoFillHostname() {
+	// attempt to set the hostname from the OS
+	if len(o.Hostname) == 0 {
+		if hn, err := os.Hostname(); err == nil {
+			o.Hostname = hn
+		}
+	}
+}
+
+func (o *ClusterDiscovery) IsEqual(in *ClusterDiscovery) bool {
+	if in == nil {
+		return false
+	}
+
+	if o.Type != in.Type {
+		return false
+	}
+
+	if o.ClusterName != in.ClusterName {
+		return false
+	}
+
+	if o.Hostname != in.Hostname {
+		return false
+	}
+
+	if o.Port != in.Port {
+		return false
+	}
+
+	return true
+}
+
+// IsAlive returns true if the node has pinged recently enough to still be
+// considered a member of the cluster.
+func (o *ClusterDiscovery) IsAlive() bool {
+	return GetMillis()-o.LastPingAt < CDS_OFFLINE_AFTER_MILLIS
+}
+
+func (o *ClusterDiscovery) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.ClusterName) == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.name.app_error", nil, "")
+	}
+
+	if len(o.Type) == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.type.app_error", nil, "")
+	}
+
+	if len(o.Hostname) == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.hostname.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.create_at.app_error", nil, "")
+	}
+
+	if o.LastPingAt == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.last_ping_at.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *ClusterDiscovery) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func ClusterDiscoveryFromJson(data io.Reader) *ClusterDiscovery {
+	decoder := json.NewDecoder(data)
+	var me ClusterDiscovery
+	err := decoder.Decode(&me)
+	if err == nil {
+		return &me
+	}
+
+	return nil
+}
+
+func ClusterDiscoveriesToJson(list []*ClusterDiscovery) string {
+	if b, err := json.Marshal(list); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ClusterDiscoveriesFromJson(data io.Reader) []*ClusterDiscovery {
+	decoder := json.NewDecoder(data)
+
+	var list []*ClusterDiscovery
+	if err := decoder.Decode(&list); err != nil {
+		return make([]*ClusterDiscovery, 0)
+	} else {
+		return list
+	}
+}
+
+type clusterDiscoveryByAge []*ClusterDiscovery
+
+func (s clusterDiscoveryByAge) Len() int      { return len(s) }
+func (s clusterDiscoveryByAge) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s clusterDiscoveryByAge) Less(i, j int) bool {
+	if s[i].CreateAt == s[j].CreateAt {
+		return s[i].Id < s[j].Id
+	}
+
+	return s[i].CreateAt < s[j].CreateAt
+}
+
+// GetClusterLeader picks the leader out of a list of discovered nodes. The
+// oldest live node wins, with the id used as a tie breaker so that every node
+// in the cluster comes to the same conclusion without further coordination.
+func GetClusterLeader(list []*ClusterDiscovery) *ClusterDiscovery {
+	alive := make([]*ClusterDiscovery, 0, len(list))
+	for _, node := range list {
+		if node.IsAlive() {
+			alive = append(alive, node)
+		}
+	}
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	sort.Sort(clusterDiscoveryByAge(alive))
+	return alive[0]
+}
+
+// GetInconsistentClusterNodes returns the live nodes whose configuration hash
+// doesn't match the one given.
+func GetInconsistentClusterNodes(list []*ClusterDiscovery, configHash string) []*ClusterDiscovery {
+	inconsistent := make([]*ClusterDiscovery, 0)
+	for _, node := range list {
+		if node.IsAlive() && node.ConfigHash != configHash {
+			inconsistent = append(inconsistent, node)
+		}
+	}
+
+	return inconsistent
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterDiscovery(t *testing.T) {
+	o := ClusterDiscovery{
+		Type:        CDS_TYPE_APP,
+		ClusterName: "cluster_name",
+	}
+
+	o.AutoFillHostname()
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	json := o.ToJson()
+	rcd := ClusterDiscoveryFromJson(strings.NewReader(json))
+
+	if !o.IsEqual(rcd) {
+		t.Fatal("should be equal")
+	}
+
+	rcd.Port = 1
+	if o.IsEqual(rcd) {
+		t.Fatal("should not be equal")
+	}
+
+	if !o.IsAlive() {
+		t.Fatal("should be alive")
+	}
+
+	o.LastPingAt = GetMillis() - CDS_OFFLINE_AFTER_MILLIS - 1
+	if o.IsAlive() {
+		t.Fatal("should not be alive")
+	}
+}
+
+func TestClusterDiscoveryIsValid(t *testing.T) {
+	o := ClusterDiscovery{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Id = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ClusterName = "cluster_name"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = CDS_TYPE_APP
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Hostname = "localhost"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.CreateAt = GetMillis()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.LastPingAt = GetMillis()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetClusterLeader(t *testing.T) {
+	if GetClusterLeader([]*ClusterDiscovery{}) != nil {
+		t.Fatal("should not have a leader")
+	}
+
+	now := GetMillis()
+	dead := &ClusterDiscovery{Id: NewId(), CreateAt: now - 3000, LastPingAt: now - CDS_OFFLINE_AFTER_MILLIS - 1}
+	oldest := &ClusterDiscovery{Id: NewId(), CreateAt: now - 2000, LastPingAt: now}
+	newest := &ClusterDiscovery{Id: NewId(), CreateAt: now - 1000, LastPingAt: now}
+
+	if leader := GetClusterLeader([]*ClusterDiscovery{newest, dead, oldest}); leader != oldest {
+		t.Fatal("oldest live node should be the leader")
+	}
+
+	if leader := GetClusterLeader([]*ClusterDiscovery{dead}); leader != nil {
+		t.Fatal("dead nodes should never be the leader")
+	}
+}
+
+func TestGetInconsistentClusterNodes(t *testing.T) {
+	now := GetMillis()
+	nodes := []*ClusterDiscovery{
+		{Id: NewId(), ConfigHash: "a", LastPingAt: now},
+		{Id: NewId(), ConfigHash: "b", LastPingAt: now},
+		{Id: NewId(), ConfigHash: "b", LastPingAt: now - CDS_OFFLINE_AFTER_MILLIS - 1},
+	}
+
+	if inconsistent := GetInconsistentClusterNodes(nodes, "a"); len(inconsistent) != 1 || inconsistent[0] != nodes[1] {
+		t.Fatal("should have found exactly one inconsistent live node")
+	}
+}
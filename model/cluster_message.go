@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+const (
+	CLUSTER_EVENT_PUBLISH               = "publish"
+	CLUSTER_EVENT_UPDATE_STATUS         = "update_status"
+	CLUSTER_EVENT_INVALIDATE_CACHE      = "invalidate_cache"
+	CLUSTER_EVENT_INVALIDATE_ALL_CACHES = "invalidate_all_caches"
+	CLUSTER_EVENT_CONFIG_CHANGED        = "config_changed"
+	CLUSTER_EVENT_GET_LOGS              = "get_logs"
+	CLUSTER_EVENT_GET_LOG_PAGE          = "get_log_page"
+	CLUSTER_EVENT_GET_STATS             = "get_stats"
+
+	HEADER_CLUSTER_SIGNATURE = "X-Cluster-Signature"
+)
+
+// ClusterMessage is sent from one node of a cluster to another. Data holds the JSON of the object that the event is
+// about, such as a WebSocketEvent for CLUSTER_EVENT_PUBLISH.
+type ClusterMessage struct {
+	Event  string `json:"event"`
+	NodeId string `json:"node_id"`
+	Data   string `json:"data,omitempty"`
+}
+
+func NewClusterMessage(event, nodeId, data string) *ClusterMessage {
+	return &ClusterMessage{Event: event, NodeId: nodeId, Data: data}
+}
+
+func (o *ClusterMessage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func ClusterMessageFromJson(data io.Reader) *ClusterMessage {
+	decoder := json.NewDecoder(data)
+	var o ClusterMessage
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	}
+
+	return &o
+}
+
+// SignClusterMessage returns the signature that's sent along with the body of a message between the nodes of a
+// cluster, which proves that the sender has the same key.
+func SignClusterMessage(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsClusterMessageSignatureValid returns true if signature is the one that SignClusterMessage gives for the body.
+func IsClusterMessageSignatureValid(key string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignClusterMessage(key, body)), []byte(signature))
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessageJson(t *testing.T) {
+	message := NewClusterMessage(CLUSTER_EVENT_PUBLISH, NewId(), NewWebSocketEvent(WEBSOCKET_EVENT_POSTED, "", NewId(), "", nil).ToJson())
+	result := ClusterMessageFromJson(strings.NewReader(message.ToJson()))
+
+	if *result != *message {
+		t.Fatal("messages do not match", result, message)
+	}
+
+	if ClusterMessageFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode junk")
+	}
+}
+
+func TestClusterMessageSignature(t *testing.T) {
+	key := NewRandomString(32)
+	body := []byte(NewClusterMessage(CLUSTER_EVENT_GET_STATS, NewId(), "").ToJson())
+	signature := SignClusterMessage(key, body)
+
+	if !IsClusterMessageSignatureValid(key, body, signature) {
+		t.Fatal("should have accepted the signature")
+	}
+
+	if IsClusterMessageSignatureValid(NewRandomString(32), body, signature) {
+		t.Fatal("should have rejected a signature made with another key")
+	}
+
+	if IsClusterMessageSignatureValid(key, append(body, ' '), signature) {
+		t.Fatal("should have rejected a signature of another body")
+	}
+
+	if IsClusterMessageSignatureValid(key, body, "") {
+		t.Fatal("should have rejected a missing signature")
+	}
+}
@@ -21,17 +21,18 @@ const (
 )
 
 type Compliance struct {
-	Id       string `json:"id"`
-	CreateAt int64  `json:"create_at"`
-	UserId   string `json:"user_id"`
-	Status   string `json:"status"`
-	Count    int    `json:"count"`
-	Desc     string `json:"desc"`
-	Type     string `json:"type"`
-	StartAt  int64  `json:"start_at"`
-	EndAt    int64  `json:"end_at"`
-	Keywords string `json:"keywords"`
-	Emails   string `json:"emails"`
+	Id        string `json:"id"`
+	CreateAt  int64  `json:"create_at"`
+	UserId    string `json:"user_id"`
+	Status    string `json:"status"`
+	Count     int    `json:"count"`
+	Desc      string `json:"desc"`
+	Type      string `json:"type"`
+	StartAt   int64  `json:"start_at"`
+	EndAt     int64  `json:"end_at"`
+	Keywords  string `json:"keywords"`
+	Emails    string `json:"emails"`
+	Anonymize bool   `json:"anonymize"`
 }
 
 type Compliances []Compliance
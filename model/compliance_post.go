@@ -18,6 +18,7 @@ type CompliancePost struct {
 	ChannelDisplayName string
 
 	// From User
+	UserId       string
 	UserUsername string
 	UserEmail    string
 	UserNickname string
@@ -6,7 +6,9 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"net"
 	"net/url"
+	"strings"
 )
 
 const (
@@ -18,8 +20,16 @@ const (
 	IMAGE_DRIVER_LOCAL = "local"
 	IMAGE_DRIVER_S3    = "amazons3"
 
+	S3_SSE_NONE   = ""
+	S3_SSE_AES256 = "AES256"
+	S3_SSE_KMS    = "aws:kms"
+
 	DATABASE_DRIVER_MYSQL    = "mysql"
 	DATABASE_DRIVER_POSTGRES = "postgres"
+	DATABASE_DRIVER_SQLITE   = "sqlite3"
+
+	LETS_ENCRYPT_CACHE_DRIVER_FILE     = "file"
+	LETS_ENCRYPT_CACHE_DRIVER_DATABASE = "database"
 
 	PASSWORD_MAXIMUM_LENGTH = 64
 	PASSWORD_MINIMUM_LENGTH = 5
@@ -32,8 +42,9 @@ const (
 	WEBSERVER_MODE_GZIP     = "gzip"
 	WEBSERVER_MODE_DISABLED = "disabled"
 
-	GENERIC_NOTIFICATION = "generic"
-	FULL_NOTIFICATION    = "full"
+	ID_LOADED_NOTIFICATION = "id_loaded"
+	GENERIC_NOTIFICATION   = "generic"
+	FULL_NOTIFICATION      = "full"
 
 	DIRECT_MESSAGE_ANY  = "any"
 	DIRECT_MESSAGE_TEAM = "team"
@@ -60,7 +71,22 @@ const (
 	EMAIL_BATCHING_BUFFER_SIZE = 256
 	EMAIL_BATCHING_INTERVAL    = 30
 
+	INBOUND_EMAIL_LISTEN_ADDRESS   = "127.0.0.1:2525"
+	INBOUND_EMAIL_TRUSTED_RELAYS   = "127.0.0.1 ::1"
+	INBOUND_EMAIL_MAX_MESSAGE_SIZE = 10485760
+
 	SITENAME_MAX_LENGTH = 30
+
+	SEARCH_BACKEND_DATABASE      = "database"
+	SEARCH_BACKEND_ELASTICSEARCH = "elasticsearch"
+	SEARCH_BACKEND_BLEVE         = "bleve"
+
+	SEARCH_SETTINGS_DEFAULT_BULK_INDEXING_BATCH_SIZE = 1000
+
+	SEARCH_CJK_SEGMENTATION_NONE   = "none"
+	SEARCH_CJK_SEGMENTATION_BIGRAM = "bigram"
+
+	DATA_RETENTION_SETTINGS_DEFAULT_RETENTION_DAYS = 365
 )
 
 type ServiceSettings struct {
@@ -71,10 +97,15 @@ type ServiceSettings struct {
 	TLSKeyFile                               *string
 	UseLetsEncrypt                           *bool
 	LetsEncryptCertificateCacheFile          *string
+	LetsEncryptCertificateCacheDriver        *string
+	LetsEncryptDirectoryURL                  *string
 	Forward80To443                           *bool
 	ReadTimeout                              *int
 	WriteTimeout                             *int
 	MaximumLoginAttempts                     int
+	MaximumLoginAttemptsPerIp                *int
+	LoginLockoutDurationInSeconds            *int
+	MaximumLoginLockoutDurationInSeconds     *int
 	SegmentDeveloperKey                      string
 	GoogleDeveloperKey                       string
 	EnableOAuthServiceProvider               bool
@@ -82,6 +113,7 @@ type ServiceSettings struct {
 	EnableOutgoingWebhooks                   bool
 	EnableCommands                           *bool
 	EnableOnlyAdminIntegrations              *bool
+	EnableUserAccessTokens                   *bool
 	EnablePostUsernameOverride               bool
 	EnablePostIconOverride                   bool
 	EnableTesting                            bool
@@ -95,8 +127,10 @@ type ServiceSettings struct {
 	SessionLengthMobileInDays                *int
 	SessionLengthSSOInDays                   *int
 	SessionCacheInMinutes                    *int
+	SessionIdleTimeoutInMinutes              *int
 	WebsocketSecurePort                      *int
 	WebsocketPort                            *int
+	WebsocketAllowedOrigins                  *string
 	WebserverMode                            *string
 	EnableCustomEmoji                        *bool
 	RestrictCustomEmojiCreation              *string
@@ -109,6 +143,7 @@ type ServiceSettings struct {
 
 type ClusterSettings struct {
 	Enable                 *bool
+	ClusterName            *string
 	InterNodeListenAddress *string
 	InterNodeUrls          []string
 }
@@ -134,13 +169,19 @@ type SSOSettings struct {
 }
 
 type SqlSettings struct {
-	DriverName         string
-	DataSource         string
-	DataSourceReplicas []string
-	MaxIdleConns       int
-	MaxOpenConns       int
-	Trace              bool
-	AtRestEncryptKey   string
+	DriverName                               string
+	DataSource                               string
+	DataSourceReplicas                       []string
+	MaxIdleConns                             int
+	MaxOpenConns                             int
+	ConnMaxLifetimeMilliseconds              *int
+	ReplicaMaxIdleConns                      *int
+	ReplicaMaxOpenConns                      *int
+	ReplicaConnMaxLifetimeMilliseconds       *int
+	HealthCheckMaxLatencyMilliseconds        *int
+	HealthCheckMaxReplicationLagMilliseconds *int
+	Trace                                    bool
+	AtRestEncryptKey                         string
 }
 
 type LogSettings struct {
@@ -163,48 +204,62 @@ type PasswordSettings struct {
 }
 
 type FileSettings struct {
-	MaxFileSize             *int64
-	DriverName              string
-	Directory               string
-	EnablePublicLink        bool
-	PublicLinkSalt          *string
-	ThumbnailWidth          int
-	ThumbnailHeight         int
-	PreviewWidth            int
-	PreviewHeight           int
-	ProfileWidth            int
-	ProfileHeight           int
-	InitialFont             string
-	AmazonS3AccessKeyId     string
-	AmazonS3SecretAccessKey string
-	AmazonS3Bucket          string
-	AmazonS3Region          string
-	AmazonS3Endpoint        string
-	AmazonS3SSL             *bool
+	MaxFileSize                *int64
+	DriverName                 string
+	Directory                  string
+	EnablePublicLink           bool
+	PublicLinkSalt             *string
+	ThumbnailWidth             int
+	ThumbnailHeight            int
+	PreviewWidth               int
+	PreviewHeight              int
+	EnableAnimatedThumbnails   *bool
+	MaxAnimatedThumbnailFrames *int
+	EnableWebPPreviews         *bool
+	SendContentDigest          *bool
+	VerifyChecksums            *bool
+	ProfileWidth               int
+	ProfileHeight              int
+	MaxProfileImageVersions    *int
+	InitialFont                string
+	AmazonS3AccessKeyId        string
+	AmazonS3SecretAccessKey    string
+	AmazonS3Bucket             string
+	AmazonS3Region             string
+	AmazonS3Endpoint           string
+	AmazonS3SSL                *bool
+	AmazonS3SSE                *string
+	AmazonS3KMSKeyId           *string
 }
 
 type EmailSettings struct {
-	EnableSignUpWithEmail    bool
-	EnableSignInWithEmail    *bool
-	EnableSignInWithUsername *bool
-	SendEmailNotifications   bool
-	RequireEmailVerification bool
-	FeedbackName             string
-	FeedbackEmail            string
-	FeedbackOrganization     *string
-	SMTPUsername             string
-	SMTPPassword             string
-	SMTPServer               string
-	SMTPPort                 string
-	ConnectionSecurity       string
-	InviteSalt               string
-	PasswordResetSalt        string
-	SendPushNotifications    *bool
-	PushNotificationServer   *string
-	PushNotificationContents *string
-	EnableEmailBatching      *bool
-	EmailBatchingBufferSize  *int
-	EmailBatchingInterval    *int
+	EnableSignUpWithEmail      bool
+	EnableSignInWithEmail      *bool
+	EnableSignInWithUsername   *bool
+	SendEmailNotifications     bool
+	RequireEmailVerification   bool
+	FeedbackName               string
+	FeedbackEmail              string
+	FeedbackOrganization       *string
+	SMTPUsername               string
+	SMTPPassword               string
+	SMTPServer                 string
+	SMTPPort                   string
+	ConnectionSecurity         string
+	InviteSalt                 string
+	PasswordResetSalt          string
+	SendPushNotifications      *bool
+	PushNotificationServer     *string
+	PushNotificationContents   *string
+	EnableEmailBatching        *bool
+	EmailBatchingBufferSize    *int
+	EmailBatchingInterval      *int
+	TemplatesDirectory         *string
+	EnableInboundEmail         *bool
+	InboundEmailListenAddress  *string
+	InboundEmailDomain         *string
+	InboundEmailMaxMessageSize *int
+	InboundEmailTrustedRelays  *string
 }
 
 type RateLimitSettings struct {
@@ -251,6 +306,8 @@ type TeamSettings struct {
 	UserStatusAwayTimeout            *int64
 	MaxChannelsPerTeam               *int64
 	MaxNotificationsPerChannel       *int64
+	InvitationExpiryInHours          *int
+	EnablePermanentInviteLinks       *bool
 }
 
 type LdapSettings struct {
@@ -288,9 +345,11 @@ type LdapSettings struct {
 }
 
 type ComplianceSettings struct {
-	Enable      *bool
-	Directory   *string
-	EnableDaily *bool
+	Enable                     *bool
+	Directory                  *string
+	EnableDaily                *bool
+	EnableFileAccessLog        *bool
+	FileAccessLogRetentionDays *int
 }
 
 type LocalizationSettings struct {
@@ -342,29 +401,51 @@ type WebrtcSettings struct {
 	TurnSharedKey       *string
 }
 
+type SearchSettings struct {
+	Backend               *string
+	EnableIndexing        *bool
+	EnableSearching       *bool
+	ConnectionUrl         *string
+	Username              *string
+	Password              *string
+	IndexPrefix           *string
+	BulkIndexingBatchSize *int
+	CJKSegmentation       *string
+}
+
+type DataRetentionSettings struct {
+	EnableMessageDeletion    *bool
+	EnableFileDeletion       *bool
+	MessageRetentionDays     *int
+	FileRetentionDays        *int
+	DeletedFileRetentionDays *int
+}
+
 type Config struct {
-	ServiceSettings      ServiceSettings
-	TeamSettings         TeamSettings
-	SqlSettings          SqlSettings
-	LogSettings          LogSettings
-	PasswordSettings     PasswordSettings
-	FileSettings         FileSettings
-	EmailSettings        EmailSettings
-	RateLimitSettings    RateLimitSettings
-	PrivacySettings      PrivacySettings
-	SupportSettings      SupportSettings
-	GitLabSettings       SSOSettings
-	GoogleSettings       SSOSettings
-	Office365Settings    SSOSettings
-	LdapSettings         LdapSettings
-	ComplianceSettings   ComplianceSettings
-	LocalizationSettings LocalizationSettings
-	SamlSettings         SamlSettings
-	NativeAppSettings    NativeAppSettings
-	ClusterSettings      ClusterSettings
-	MetricsSettings      MetricsSettings
-	AnalyticsSettings    AnalyticsSettings
-	WebrtcSettings       WebrtcSettings
+	ServiceSettings       ServiceSettings
+	TeamSettings          TeamSettings
+	SqlSettings           SqlSettings
+	LogSettings           LogSettings
+	PasswordSettings      PasswordSettings
+	FileSettings          FileSettings
+	EmailSettings         EmailSettings
+	RateLimitSettings     RateLimitSettings
+	PrivacySettings       PrivacySettings
+	SupportSettings       SupportSettings
+	GitLabSettings        SSOSettings
+	GoogleSettings        SSOSettings
+	Office365Settings     SSOSettings
+	LdapSettings          LdapSettings
+	ComplianceSettings    ComplianceSettings
+	LocalizationSettings  LocalizationSettings
+	SamlSettings          SamlSettings
+	NativeAppSettings     NativeAppSettings
+	ClusterSettings       ClusterSettings
+	MetricsSettings       MetricsSettings
+	AnalyticsSettings     AnalyticsSettings
+	WebrtcSettings        WebrtcSettings
+	SearchSettings        SearchSettings
+	DataRetentionSettings DataRetentionSettings
 }
 
 func (o *Config) ToJson() string {
@@ -406,6 +487,37 @@ func (o *Config) SetDefaults() {
 		o.SqlSettings.AtRestEncryptKey = NewRandomString(32)
 	}
 
+	if o.SqlSettings.ConnMaxLifetimeMilliseconds == nil {
+		o.SqlSettings.ConnMaxLifetimeMilliseconds = new(int)
+		*o.SqlSettings.ConnMaxLifetimeMilliseconds = 900000
+	}
+
+	// The replicas use the same pool settings as the data source unless they've been tuned separately
+	if o.SqlSettings.ReplicaMaxIdleConns == nil {
+		o.SqlSettings.ReplicaMaxIdleConns = new(int)
+		*o.SqlSettings.ReplicaMaxIdleConns = o.SqlSettings.MaxIdleConns
+	}
+
+	if o.SqlSettings.ReplicaMaxOpenConns == nil {
+		o.SqlSettings.ReplicaMaxOpenConns = new(int)
+		*o.SqlSettings.ReplicaMaxOpenConns = o.SqlSettings.MaxOpenConns
+	}
+
+	if o.SqlSettings.ReplicaConnMaxLifetimeMilliseconds == nil {
+		o.SqlSettings.ReplicaConnMaxLifetimeMilliseconds = new(int)
+		*o.SqlSettings.ReplicaConnMaxLifetimeMilliseconds = *o.SqlSettings.ConnMaxLifetimeMilliseconds
+	}
+
+	if o.SqlSettings.HealthCheckMaxLatencyMilliseconds == nil {
+		o.SqlSettings.HealthCheckMaxLatencyMilliseconds = new(int)
+		*o.SqlSettings.HealthCheckMaxLatencyMilliseconds = 1000
+	}
+
+	if o.SqlSettings.HealthCheckMaxReplicationLagMilliseconds == nil {
+		o.SqlSettings.HealthCheckMaxReplicationLagMilliseconds = new(int)
+		*o.SqlSettings.HealthCheckMaxReplicationLagMilliseconds = 30000
+	}
+
 	if o.FileSettings.AmazonS3Endpoint == "" {
 		// Defaults to "s3.amazonaws.com"
 		o.FileSettings.AmazonS3Endpoint = "s3.amazonaws.com"
@@ -421,6 +533,16 @@ func (o *Config) SetDefaults() {
 		*o.FileSettings.AmazonS3SSL = true // Secure by default.
 	}
 
+	if o.FileSettings.AmazonS3SSE == nil {
+		o.FileSettings.AmazonS3SSE = new(string)
+		*o.FileSettings.AmazonS3SSE = S3_SSE_NONE
+	}
+
+	if o.FileSettings.AmazonS3KMSKeyId == nil {
+		o.FileSettings.AmazonS3KMSKeyId = new(string)
+		*o.FileSettings.AmazonS3KMSKeyId = ""
+	}
+
 	if o.FileSettings.MaxFileSize == nil {
 		o.FileSettings.MaxFileSize = new(int64)
 		*o.FileSettings.MaxFileSize = 52428800 // 50 MB
@@ -431,6 +553,36 @@ func (o *Config) SetDefaults() {
 		*o.FileSettings.PublicLinkSalt = NewRandomString(32)
 	}
 
+	if o.FileSettings.EnableAnimatedThumbnails == nil {
+		o.FileSettings.EnableAnimatedThumbnails = new(bool)
+		*o.FileSettings.EnableAnimatedThumbnails = false
+	}
+
+	if o.FileSettings.MaxAnimatedThumbnailFrames == nil {
+		o.FileSettings.MaxAnimatedThumbnailFrames = new(int)
+		*o.FileSettings.MaxAnimatedThumbnailFrames = 100
+	}
+
+	if o.FileSettings.MaxProfileImageVersions == nil {
+		o.FileSettings.MaxProfileImageVersions = new(int)
+		*o.FileSettings.MaxProfileImageVersions = 5
+	}
+
+	if o.FileSettings.EnableWebPPreviews == nil {
+		o.FileSettings.EnableWebPPreviews = new(bool)
+		*o.FileSettings.EnableWebPPreviews = false
+	}
+
+	if o.FileSettings.SendContentDigest == nil {
+		o.FileSettings.SendContentDigest = new(bool)
+		*o.FileSettings.SendContentDigest = false
+	}
+
+	if o.FileSettings.VerifyChecksums == nil {
+		o.FileSettings.VerifyChecksums = new(bool)
+		*o.FileSettings.VerifyChecksums = false
+	}
+
 	if o.FileSettings.InitialFont == "" {
 		// Defaults to "luximbi.ttf"
 		o.FileSettings.InitialFont = "luximbi.ttf"
@@ -578,6 +730,17 @@ func (o *Config) SetDefaults() {
 		*o.TeamSettings.MaxNotificationsPerChannel = 1000
 	}
 
+	if o.TeamSettings.InvitationExpiryInHours == nil {
+		o.TeamSettings.InvitationExpiryInHours = new(int)
+		*o.TeamSettings.InvitationExpiryInHours = 48
+	}
+
+	// Servers that are upgraded keep their existing invite links working, while config.json turns them off for new ones
+	if o.TeamSettings.EnablePermanentInviteLinks == nil {
+		o.TeamSettings.EnablePermanentInviteLinks = new(bool)
+		*o.TeamSettings.EnablePermanentInviteLinks = true
+	}
+
 	if o.EmailSettings.EnableSignInWithEmail == nil {
 		o.EmailSettings.EnableSignInWithEmail = new(bool)
 
@@ -613,6 +776,11 @@ func (o *Config) SetDefaults() {
 		*o.EmailSettings.FeedbackOrganization = ""
 	}
 
+	if o.EmailSettings.TemplatesDirectory == nil {
+		o.EmailSettings.TemplatesDirectory = new(string)
+		*o.EmailSettings.TemplatesDirectory = ""
+	}
+
 	if o.EmailSettings.EnableEmailBatching == nil {
 		o.EmailSettings.EnableEmailBatching = new(bool)
 		*o.EmailSettings.EnableEmailBatching = false
@@ -628,6 +796,31 @@ func (o *Config) SetDefaults() {
 		*o.EmailSettings.EmailBatchingInterval = EMAIL_BATCHING_INTERVAL
 	}
 
+	if o.EmailSettings.EnableInboundEmail == nil {
+		o.EmailSettings.EnableInboundEmail = new(bool)
+		*o.EmailSettings.EnableInboundEmail = false
+	}
+
+	if o.EmailSettings.InboundEmailListenAddress == nil {
+		o.EmailSettings.InboundEmailListenAddress = new(string)
+		*o.EmailSettings.InboundEmailListenAddress = INBOUND_EMAIL_LISTEN_ADDRESS
+	}
+
+	if o.EmailSettings.InboundEmailDomain == nil {
+		o.EmailSettings.InboundEmailDomain = new(string)
+		*o.EmailSettings.InboundEmailDomain = ""
+	}
+
+	if o.EmailSettings.InboundEmailMaxMessageSize == nil {
+		o.EmailSettings.InboundEmailMaxMessageSize = new(int)
+		*o.EmailSettings.InboundEmailMaxMessageSize = INBOUND_EMAIL_MAX_MESSAGE_SIZE
+	}
+
+	if o.EmailSettings.InboundEmailTrustedRelays == nil {
+		o.EmailSettings.InboundEmailTrustedRelays = new(string)
+		*o.EmailSettings.InboundEmailTrustedRelays = INBOUND_EMAIL_TRUSTED_RELAYS
+	}
+
 	if !IsSafeLink(o.SupportSettings.TermsOfServiceLink) {
 		o.SupportSettings.TermsOfServiceLink = nil
 	}
@@ -798,11 +991,36 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.SessionCacheInMinutes = 10
 	}
 
+	if o.ServiceSettings.MaximumLoginAttemptsPerIp == nil {
+		o.ServiceSettings.MaximumLoginAttemptsPerIp = new(int)
+		*o.ServiceSettings.MaximumLoginAttemptsPerIp = 200
+	}
+
+	if o.ServiceSettings.LoginLockoutDurationInSeconds == nil {
+		o.ServiceSettings.LoginLockoutDurationInSeconds = new(int)
+		*o.ServiceSettings.LoginLockoutDurationInSeconds = 60
+	}
+
+	if o.ServiceSettings.MaximumLoginLockoutDurationInSeconds == nil {
+		o.ServiceSettings.MaximumLoginLockoutDurationInSeconds = new(int)
+		*o.ServiceSettings.MaximumLoginLockoutDurationInSeconds = 3600
+	}
+
+	if o.ServiceSettings.SessionIdleTimeoutInMinutes == nil {
+		o.ServiceSettings.SessionIdleTimeoutInMinutes = new(int)
+		*o.ServiceSettings.SessionIdleTimeoutInMinutes = 0
+	}
+
 	if o.ServiceSettings.EnableCommands == nil {
 		o.ServiceSettings.EnableCommands = new(bool)
 		*o.ServiceSettings.EnableCommands = false
 	}
 
+	if o.ServiceSettings.EnableUserAccessTokens == nil {
+		o.ServiceSettings.EnableUserAccessTokens = new(bool)
+		*o.ServiceSettings.EnableUserAccessTokens = false
+	}
+
 	if o.ServiceSettings.EnableOnlyAdminIntegrations == nil {
 		o.ServiceSettings.EnableOnlyAdminIntegrations = new(bool)
 		*o.ServiceSettings.EnableOnlyAdminIntegrations = true
@@ -823,6 +1041,11 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.AllowCorsFrom = ""
 	}
 
+	if o.ServiceSettings.WebsocketAllowedOrigins == nil {
+		o.ServiceSettings.WebsocketAllowedOrigins = new(string)
+		*o.ServiceSettings.WebsocketAllowedOrigins = ""
+	}
+
 	if o.ServiceSettings.WebserverMode == nil {
 		o.ServiceSettings.WebserverMode = new(string)
 		*o.ServiceSettings.WebserverMode = "gzip"
@@ -865,6 +1088,11 @@ func (o *Config) SetDefaults() {
 		*o.ClusterSettings.Enable = false
 	}
 
+	if o.ClusterSettings.ClusterName == nil {
+		o.ClusterSettings.ClusterName = new(string)
+		*o.ClusterSettings.ClusterName = ""
+	}
+
 	if o.ClusterSettings.InterNodeUrls == nil {
 		o.ClusterSettings.InterNodeUrls = []string{}
 	}
@@ -899,6 +1127,16 @@ func (o *Config) SetDefaults() {
 		*o.ComplianceSettings.EnableDaily = false
 	}
 
+	if o.ComplianceSettings.EnableFileAccessLog == nil {
+		o.ComplianceSettings.EnableFileAccessLog = new(bool)
+		*o.ComplianceSettings.EnableFileAccessLog = false
+	}
+
+	if o.ComplianceSettings.FileAccessLogRetentionDays == nil {
+		o.ComplianceSettings.FileAccessLogRetentionDays = new(int)
+		*o.ComplianceSettings.FileAccessLogRetentionDays = DATA_RETENTION_SETTINGS_DEFAULT_RETENTION_DAYS
+	}
+
 	if o.LocalizationSettings.DefaultServerLocale == nil {
 		o.LocalizationSettings.DefaultServerLocale = new(string)
 		*o.LocalizationSettings.DefaultServerLocale = DEFAULT_LOCALE
@@ -1054,6 +1292,19 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.LetsEncryptCertificateCacheFile = "./config/letsencrypt.cache"
 	}
 
+	if o.ServiceSettings.LetsEncryptCertificateCacheDriver == nil {
+		o.ServiceSettings.LetsEncryptCertificateCacheDriver = new(string)
+		*o.ServiceSettings.LetsEncryptCertificateCacheDriver = LETS_ENCRYPT_CACHE_DRIVER_FILE
+	}
+
+	if o.ServiceSettings.LetsEncryptDirectoryURL == nil {
+		o.ServiceSettings.LetsEncryptDirectoryURL = new(string)
+		*o.ServiceSettings.LetsEncryptDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+	} else if *o.ServiceSettings.LetsEncryptDirectoryURL == "https://acme-v01.api.letsencrypt.org/directory" {
+		// Let's Encrypt no longer supports version 1 of the ACME protocol
+		*o.ServiceSettings.LetsEncryptDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+	}
+
 	if o.ServiceSettings.ReadTimeout == nil {
 		o.ServiceSettings.ReadTimeout = new(int)
 		*o.ServiceSettings.ReadTimeout = 300
@@ -1085,6 +1336,8 @@ func (o *Config) SetDefaults() {
 	}
 
 	o.defaultWebrtcSettings()
+	o.defaultSearchSettings()
+	o.defaultDataRetentionSettings()
 }
 
 func (o *Config) IsValid() *AppError {
@@ -1093,6 +1346,18 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_attempts.app_error", nil, "")
 	}
 
+	if *o.ServiceSettings.MaximumLoginAttemptsPerIp <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_attempts_per_ip.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.LoginLockoutDurationInSeconds <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_lockout_duration.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.MaximumLoginLockoutDurationInSeconds < *o.ServiceSettings.LoginLockoutDurationInSeconds {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_login_lockout_duration.app_error", nil, "")
+	}
+
 	if len(*o.ServiceSettings.SiteURL) != 0 {
 		if _, err := url.ParseRequestURI(*o.ServiceSettings.SiteURL); err != nil {
 			return NewLocAppError("Config.IsValid", "model.config.is_valid.site_url.app_error", nil, "")
@@ -1103,6 +1368,14 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.listen_address.app_error", nil, "")
 	}
 
+	if *o.ServiceSettings.SessionIdleTimeoutInMinutes < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.session_idle_timeout.app_error", nil, "")
+	}
+
+	if *o.ClusterSettings.Enable && len(*o.ClusterSettings.ClusterName) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_name.app_error", nil, "")
+	}
+
 	if *o.ClusterSettings.Enable && *o.EmailSettings.EnableEmailBatching {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_email_batching.app_error", nil, "")
 	}
@@ -1111,6 +1384,20 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.site_url_email_batching.app_error", nil, "")
 	}
 
+	if *o.ServiceSettings.ConnectionSecurity == CONN_SECURITY_TLS && *o.ServiceSettings.UseLetsEncrypt {
+		if len(*o.ServiceSettings.SiteURL) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.site_url_lets_encrypt.app_error", nil, "")
+		}
+
+		if !(*o.ServiceSettings.LetsEncryptCertificateCacheDriver == LETS_ENCRYPT_CACHE_DRIVER_FILE || *o.ServiceSettings.LetsEncryptCertificateCacheDriver == LETS_ENCRYPT_CACHE_DRIVER_DATABASE) {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.lets_encrypt_cache_driver.app_error", nil, "")
+		}
+
+		if _, err := url.ParseRequestURI(*o.ServiceSettings.LetsEncryptDirectoryURL); err != nil {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.lets_encrypt_directory_url.app_error", nil, "")
+		}
+	}
+
 	if o.TeamSettings.MaxUsersPerTeam <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_users.app_error", nil, "")
 	}
@@ -1123,6 +1410,10 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_notify_per_channel.app_error", nil, "")
 	}
 
+	if *o.TeamSettings.InvitationExpiryInHours <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.invitation_expiry.app_error", nil, "")
+	}
+
 	if !(*o.TeamSettings.RestrictDirectMessage == DIRECT_MESSAGE_ANY || *o.TeamSettings.RestrictDirectMessage == DIRECT_MESSAGE_TEAM) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.restrict_direct_message.app_error", nil, "")
 	}
@@ -1131,7 +1422,7 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.encrypt_sql.app_error", nil, "")
 	}
 
-	if !(o.SqlSettings.DriverName == DATABASE_DRIVER_MYSQL || o.SqlSettings.DriverName == DATABASE_DRIVER_POSTGRES) {
+	if !(o.SqlSettings.DriverName == DATABASE_DRIVER_MYSQL || o.SqlSettings.DriverName == DATABASE_DRIVER_POSTGRES || o.SqlSettings.DriverName == DATABASE_DRIVER_SQLITE) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_driver.app_error", nil, "")
 	}
 
@@ -1147,6 +1438,30 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_max_conn.app_error", nil, "")
 	}
 
+	if *o.SqlSettings.ConnMaxLifetimeMilliseconds < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_conn_max_lifetime_milliseconds.app_error", nil, "")
+	}
+
+	if *o.SqlSettings.ReplicaMaxIdleConns <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_replica_idle.app_error", nil, "")
+	}
+
+	if *o.SqlSettings.ReplicaMaxOpenConns <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_replica_max_conn.app_error", nil, "")
+	}
+
+	if *o.SqlSettings.ReplicaConnMaxLifetimeMilliseconds < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_replica_conn_max_lifetime_milliseconds.app_error", nil, "")
+	}
+
+	if *o.SqlSettings.HealthCheckMaxLatencyMilliseconds < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_health_check_max_latency.app_error", nil, "")
+	}
+
+	if *o.SqlSettings.HealthCheckMaxReplicationLagMilliseconds < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.sql_health_check_max_replication_lag.app_error", nil, "")
+	}
+
 	if *o.FileSettings.MaxFileSize <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_file_size.app_error", nil, "")
 	}
@@ -1155,6 +1470,14 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_driver.app_error", nil, "")
 	}
 
+	if !(*o.FileSettings.AmazonS3SSE == S3_SSE_NONE || *o.FileSettings.AmazonS3SSE == S3_SSE_AES256 || *o.FileSettings.AmazonS3SSE == S3_SSE_KMS) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.amazon_s3_sse.app_error", nil, "")
+	}
+
+	if len(*o.FileSettings.AmazonS3KMSKeyId) > 0 && *o.FileSettings.AmazonS3SSE != S3_SSE_KMS {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.amazon_s3_kms_key_id.app_error", nil, "")
+	}
+
 	if o.FileSettings.PreviewHeight < 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_preview_height.app_error", nil, "")
 	}
@@ -1163,6 +1486,10 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_preview_width.app_error", nil, "")
 	}
 
+	if *o.FileSettings.MaxAnimatedThumbnailFrames <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_max_animated_thumbnail_frames.app_error", nil, "")
+	}
+
 	if o.FileSettings.ProfileHeight <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_profile_height.app_error", nil, "")
 	}
@@ -1171,6 +1498,10 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_profile_width.app_error", nil, "")
 	}
 
+	if *o.FileSettings.MaxProfileImageVersions <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_max_profile_image_versions.app_error", nil, "")
+	}
+
 	if o.FileSettings.ThumbnailHeight <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_thumb_height.app_error", nil, "")
 	}
@@ -1203,6 +1534,24 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.email_batching_interval.app_error", nil, "")
 	}
 
+	if *o.EmailSettings.EnableInboundEmail && len(*o.EmailSettings.InboundEmailDomain) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.inbound_email_domain.app_error", nil, "")
+	}
+
+	if *o.EmailSettings.InboundEmailMaxMessageSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.inbound_email_max_message_size.app_error", nil, "")
+	}
+
+	for _, relay := range strings.Fields(*o.EmailSettings.InboundEmailTrustedRelays) {
+		if _, _, err := net.ParseCIDR(relay); err != nil && net.ParseIP(relay) == nil {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.inbound_email_trusted_relays.app_error", map[string]interface{}{"Relay": relay}, "")
+		}
+	}
+
+	if !(*o.EmailSettings.PushNotificationContents == ID_LOADED_NOTIFICATION || *o.EmailSettings.PushNotificationContents == GENERIC_NOTIFICATION || *o.EmailSettings.PushNotificationContents == FULL_NOTIFICATION) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.push_notification_contents.app_error", nil, "")
+	}
+
 	if o.RateLimitSettings.MemoryStoreSize <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.rate_mem.app_error", nil, "")
 	}
@@ -1299,10 +1648,22 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_burst.app_error", nil, "")
 	}
 
+	if *o.ComplianceSettings.FileAccessLogRetentionDays < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_access_log_retention_days.app_error", nil, "")
+	}
+
 	if err := o.isValidWebrtcSettings(); err != nil {
 		return err
 	}
 
+	if err := o.isValidSearchSettings(); err != nil {
+		return err
+	}
+
+	if err := o.isValidDataRetentionSettings(); err != nil {
+		return err
+	}
+
 	if !(*o.ServiceSettings.ConnectionSecurity == CONN_SECURITY_NONE || *o.ServiceSettings.ConnectionSecurity == CONN_SECURITY_TLS) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.webserver_security.app_error", nil, "")
 	}
@@ -1350,12 +1711,32 @@ func (o *Config) Sanitize() {
 		o.GitLabSettings.Secret = FAKE_SETTING
 	}
 
+	if len(o.GoogleSettings.Secret) > 0 {
+		o.GoogleSettings.Secret = FAKE_SETTING
+	}
+
+	if len(o.Office365Settings.Secret) > 0 {
+		o.Office365Settings.Secret = FAKE_SETTING
+	}
+
+	if o.WebrtcSettings.GatewayAdminSecret != nil && len(*o.WebrtcSettings.GatewayAdminSecret) > 0 {
+		*o.WebrtcSettings.GatewayAdminSecret = FAKE_SETTING
+	}
+
+	if o.WebrtcSettings.TurnSharedKey != nil && len(*o.WebrtcSettings.TurnSharedKey) > 0 {
+		*o.WebrtcSettings.TurnSharedKey = FAKE_SETTING
+	}
+
 	o.SqlSettings.DataSource = FAKE_SETTING
 	o.SqlSettings.AtRestEncryptKey = FAKE_SETTING
 
 	for i := range o.SqlSettings.DataSourceReplicas {
 		o.SqlSettings.DataSourceReplicas[i] = FAKE_SETTING
 	}
+
+	if o.SearchSettings.Password != nil && len(*o.SearchSettings.Password) > 0 {
+		*o.SearchSettings.Password = FAKE_SETTING
+	}
 }
 
 func (o *Config) defaultWebrtcSettings() {
@@ -1425,3 +1806,113 @@ func (o *Config) isValidWebrtcSettings() *AppError {
 
 	return nil
 }
+
+func (o *Config) defaultSearchSettings() {
+	if o.SearchSettings.Backend == nil {
+		o.SearchSettings.Backend = new(string)
+		*o.SearchSettings.Backend = SEARCH_BACKEND_DATABASE
+	}
+
+	if o.SearchSettings.EnableIndexing == nil {
+		o.SearchSettings.EnableIndexing = new(bool)
+		*o.SearchSettings.EnableIndexing = false
+	}
+
+	if o.SearchSettings.EnableSearching == nil {
+		o.SearchSettings.EnableSearching = new(bool)
+		*o.SearchSettings.EnableSearching = false
+	}
+
+	if o.SearchSettings.ConnectionUrl == nil {
+		o.SearchSettings.ConnectionUrl = new(string)
+		*o.SearchSettings.ConnectionUrl = ""
+	}
+
+	if o.SearchSettings.Username == nil {
+		o.SearchSettings.Username = new(string)
+		*o.SearchSettings.Username = ""
+	}
+
+	if o.SearchSettings.Password == nil {
+		o.SearchSettings.Password = new(string)
+		*o.SearchSettings.Password = ""
+	}
+
+	if o.SearchSettings.IndexPrefix == nil {
+		o.SearchSettings.IndexPrefix = new(string)
+		*o.SearchSettings.IndexPrefix = ""
+	}
+
+	if o.SearchSettings.BulkIndexingBatchSize == nil {
+		o.SearchSettings.BulkIndexingBatchSize = new(int)
+		*o.SearchSettings.BulkIndexingBatchSize = SEARCH_SETTINGS_DEFAULT_BULK_INDEXING_BATCH_SIZE
+	}
+
+	if o.SearchSettings.CJKSegmentation == nil {
+		o.SearchSettings.CJKSegmentation = new(string)
+		*o.SearchSettings.CJKSegmentation = SEARCH_CJK_SEGMENTATION_NONE
+	}
+}
+
+func (o *Config) isValidSearchSettings() *AppError {
+	if !(*o.SearchSettings.Backend == SEARCH_BACKEND_DATABASE || *o.SearchSettings.Backend == SEARCH_BACKEND_ELASTICSEARCH || *o.SearchSettings.Backend == SEARCH_BACKEND_BLEVE) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.search_backend.app_error", nil, "")
+	}
+
+	if *o.SearchSettings.Backend == SEARCH_BACKEND_ELASTICSEARCH && *o.SearchSettings.EnableIndexing && len(*o.SearchSettings.ConnectionUrl) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.search_connection_url.app_error", nil, "")
+	}
+
+	if *o.SearchSettings.BulkIndexingBatchSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.search_bulk_indexing_batch_size.app_error", nil, "")
+	}
+
+	if !(*o.SearchSettings.CJKSegmentation == SEARCH_CJK_SEGMENTATION_NONE || *o.SearchSettings.CJKSegmentation == SEARCH_CJK_SEGMENTATION_BIGRAM) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.search_cjk_segmentation.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *Config) defaultDataRetentionSettings() {
+	if o.DataRetentionSettings.EnableMessageDeletion == nil {
+		o.DataRetentionSettings.EnableMessageDeletion = new(bool)
+		*o.DataRetentionSettings.EnableMessageDeletion = false
+	}
+
+	if o.DataRetentionSettings.EnableFileDeletion == nil {
+		o.DataRetentionSettings.EnableFileDeletion = new(bool)
+		*o.DataRetentionSettings.EnableFileDeletion = false
+	}
+
+	if o.DataRetentionSettings.MessageRetentionDays == nil {
+		o.DataRetentionSettings.MessageRetentionDays = new(int)
+		*o.DataRetentionSettings.MessageRetentionDays = DATA_RETENTION_SETTINGS_DEFAULT_RETENTION_DAYS
+	}
+
+	if o.DataRetentionSettings.FileRetentionDays == nil {
+		o.DataRetentionSettings.FileRetentionDays = new(int)
+		*o.DataRetentionSettings.FileRetentionDays = DATA_RETENTION_SETTINGS_DEFAULT_RETENTION_DAYS
+	}
+
+	if o.DataRetentionSettings.DeletedFileRetentionDays == nil {
+		o.DataRetentionSettings.DeletedFileRetentionDays = new(int)
+		*o.DataRetentionSettings.DeletedFileRetentionDays = 0
+	}
+}
+
+func (o *Config) isValidDataRetentionSettings() *AppError {
+	if *o.DataRetentionSettings.MessageRetentionDays <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.message_retention_days_too_low.app_error", nil, "")
+	}
+
+	if *o.DataRetentionSettings.FileRetentionDays <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.file_retention_days_too_low.app_error", nil, "")
+	}
+
+	if *o.DataRetentionSettings.DeletedFileRetentionDays < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.deleted_file_retention_days.app_error", nil, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	for _, spec := range []string{
+		"* * * * *",
+		"0 2 * * *",
+		"*/15 0-6,22-23 1,15 * mon-fri",
+		"5/10 * * jan-jun sun",
+		"0 0 * * 7",
+		"@daily",
+		" @Hourly ",
+	} {
+		if _, err := ParseCronSchedule(spec); err != nil {
+			t.Fatal("should have parsed "+spec, err)
+		}
+	}
+
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@sometimes",
+	} {
+		if _, err := ParseCronSchedule(spec); err == nil {
+			t.Fatal("shouldn't have parsed " + spec)
+		}
+	}
+}
+
+func checkCronNext(t *testing.T, spec string, after time.Time, expected time.Time) {
+	schedule, err := ParseCronSchedule(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := schedule.Next(after); !next.Equal(expected) {
+		t.Fatalf("%q after %v should have been %v but was %v", spec, after, expected, next)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2017, time.June, 14, 10, 30, 15, 0, utc) // a Wednesday
+
+	checkCronNext(t, "* * * * *", start, time.Date(2017, time.June, 14, 10, 31, 0, 0, utc))
+	checkCronNext(t, "30 10 * * *", start, time.Date(2017, time.June, 15, 10, 30, 0, 0, utc))
+	checkCronNext(t, "*/15 * * * *", start, time.Date(2017, time.June, 14, 10, 45, 0, 0, utc))
+	checkCronNext(t, "0 2 * * *", start, time.Date(2017, time.June, 15, 2, 0, 0, 0, utc))
+	checkCronNext(t, "0 0 * * sat", start, time.Date(2017, time.June, 17, 0, 0, 0, 0, utc))
+	checkCronNext(t, "0 0 * * 7", start, time.Date(2017, time.June, 18, 0, 0, 0, 0, utc))
+	checkCronNext(t, "@monthly", start, time.Date(2017, time.July, 1, 0, 0, 0, 0, utc))
+	checkCronNext(t, "0 0 29 feb *", start, time.Date(2020, time.February, 29, 0, 0, 0, 0, utc))
+	checkCronNext(t, "59 23 31 dec *", start, time.Date(2017, time.December, 31, 23, 59, 0, 0, utc))
+
+	// a time matches either day when both are restricted
+	checkCronNext(t, "0 0 1 * fri", start, time.Date(2017, time.June, 16, 0, 0, 0, 0, utc))
+	checkCronNext(t, "0 0 15 * mon", start, time.Date(2017, time.June, 15, 0, 0, 0, 0, utc))
+
+	// but both have to match when only one is
+	checkCronNext(t, "0 0 1-7 * *", start, time.Date(2017, time.July, 1, 0, 0, 0, 0, utc))
+
+	if next := (&CronSchedule{}).Next(start); !next.IsZero() {
+		t.Fatal("shouldn't have found a time for an empty schedule")
+	}
+
+	if schedule, err := ParseCronSchedule("0 0 30 feb *"); err != nil {
+		t.Fatal(err)
+	} else if next := schedule.Next(start); !next.IsZero() {
+		t.Fatal("shouldn't have found a time for the 30th of February", next)
+	}
+}
+
+func TestCronScheduleNextTimezone(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip("time zone data isn't available", err)
+	}
+
+	// 2am doesn't exist on the day the clocks go forward
+	checkCronNext(t, "30 2 * * *", time.Date(2017, time.March, 12, 0, 0, 0, 0, toronto), time.Date(2017, time.March, 13, 2, 30, 0, 0, toronto))
+
+	// and 1am happens twice on the day they go back, so hourly runs shouldn't get stuck
+	schedule, _ := ParseCronSchedule("0 * * * *")
+	first := schedule.Next(time.Date(2017, time.November, 5, 0, 30, 0, 0, toronto))
+	second := schedule.Next(first)
+	if !second.After(first) || second.Sub(first) > 2*time.Hour {
+		t.Fatal("should have moved forward through the change", first, second)
+	}
+
+	checkCronNext(t, "0 9 * * *", time.Date(2017, time.June, 14, 12, 0, 0, 0, time.UTC).In(toronto), time.Date(2017, time.June, 14, 9, 0, 0, 0, toronto))
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CUSTOM_STATUS_EMOJI_MAX_LENGTH = 64
+	CUSTOM_STATUS_TEXT_MAX_RUNES   = 100
+)
+
+// CustomStatus is a short message, such as "Out of office", that a user has chosen to show next to their name. It's
+// cleared automatically after ExpiresAt if that's set.
+type CustomStatus struct {
+	Emoji     string `json:"emoji"`
+	Text      string `json:"text"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+}
+
+func (o *CustomStatus) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func CustomStatusFromJson(data io.Reader) *CustomStatus {
+	var o CustomStatus
+
+	if err := json.NewDecoder(data).Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func (o *CustomStatus) PreSave() {
+	o.Emoji = strings.TrimSpace(o.Emoji)
+	o.Text = strings.TrimSpace(o.Text)
+}
+
+func (o *CustomStatus) IsValid() *AppError {
+	if len(o.Emoji) == 0 && len(o.Text) == 0 {
+		return NewAppError("CustomStatus.IsValid", "model.custom_status.is_valid.empty.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.Emoji) > CUSTOM_STATUS_EMOJI_MAX_LENGTH || strings.ContainsAny(o.Emoji, ": ") {
+		return NewAppError("CustomStatus.IsValid", "model.custom_status.is_valid.emoji.app_error", nil, "emoji="+o.Emoji, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Text) > CUSTOM_STATUS_TEXT_MAX_RUNES {
+		return NewAppError("CustomStatus.IsValid", "model.custom_status.is_valid.text.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.ExpiresAt < 0 {
+		return NewAppError("CustomStatus.IsValid", "model.custom_status.is_valid.expires_at.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// IsExpired returns true if the status should have already been cleared.
+func (o *CustomStatus) IsExpired() bool {
+	return o.ExpiresAt > 0 && o.ExpiresAt <= GetMillis()
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCustomStatusJson(t *testing.T) {
+	o := &CustomStatus{Emoji: "palm_tree", Text: "On vacation", ExpiresAt: 1000}
+	ro := CustomStatusFromJson(strings.NewReader(o.ToJson()))
+
+	if *ro != *o {
+		t.Fatal("custom statuses do not match")
+	}
+}
+
+func TestCustomStatusIsValid(t *testing.T) {
+	o := &CustomStatus{Emoji: " palm_tree ", Text: " On vacation "}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	} else if o.Emoji != "palm_tree" || o.Text != "On vacation" {
+		t.Fatal("should have trimmed the status")
+	}
+
+	o.Emoji = ":palm_tree:"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Emoji = ""
+	o.Text = strings.Repeat("a", CUSTOM_STATUS_TEXT_MAX_RUNES+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Text = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestUserCustomStatus(t *testing.T) {
+	u := &User{}
+	if u.GetCustomStatus() != nil {
+		t.Fatal("shouldn't have a custom status")
+	}
+
+	u.SetCustomStatus(&CustomStatus{Text: "In a meeting", ExpiresAt: GetMillis() + 60000})
+	if status := u.GetCustomStatus(); status == nil || status.Text != "In a meeting" {
+		t.Fatal("should have the custom status")
+	}
+
+	u.CustomStatusExpiry = GetMillis() - 1
+	if u.GetCustomStatus() != nil {
+		t.Fatal("shouldn't return an expired custom status")
+	}
+
+	u.SetCustomStatus(nil)
+	if u.CustomStatusText != "" || u.CustomStatusExpiry != 0 {
+		t.Fatal("should have cleared the custom status")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	DATABASE_HEALTH_OK        = "OK"
+	DATABASE_HEALTH_UNHEALTHY = "UNHEALTHY"
+)
+
+// DatabaseConnectionHealth is the result of checking one of the data sources that the server connects to. The
+// replication lag is -1 if it couldn't be determined.
+type DatabaseConnectionHealth struct {
+	Name                       string   `json:"name"`
+	Healthy                    bool     `json:"healthy"`
+	LatencyMilliseconds        float64  `json:"latency_milliseconds"`
+	ReplicationLagMilliseconds int64    `json:"replication_lag_milliseconds"`
+	Errors                     []string `json:"errors"`
+}
+
+// DatabaseHealth is the result of checking that the master database and each of its replicas can be reached, that
+// the replicas are caught up and that the schema has been migrated to the version that the server expects.
+type DatabaseHealth struct {
+	Status                string                      `json:"status"`
+	CheckedAt             int64                       `json:"checked_at"`
+	SchemaVersion         string                      `json:"schema_version"`
+	ExpectedSchemaVersion string                      `json:"expected_schema_version"`
+	Master                *DatabaseConnectionHealth   `json:"master"`
+	Replicas              []*DatabaseConnectionHealth `json:"replicas"`
+}
+
+func (c *DatabaseConnectionHealth) AddError(format string, args ...interface{}) {
+	c.Errors = append(c.Errors, fmt.Sprintf(format, args...))
+}
+
+// Evaluate marks each connection as healthy or not based on the errors found while checking it and the given
+// limits, where a limit of 0 means that there isn't one, and then sets the overall status.
+func (h *DatabaseHealth) Evaluate(maxLatencyMilliseconds int, maxReplicationLagMilliseconds int) {
+	h.Status = DATABASE_HEALTH_OK
+
+	if h.Master != nil && len(h.Master.Errors) == 0 && h.SchemaVersion != h.ExpectedSchemaVersion {
+		h.Master.AddError("the schema version is %v but %v was expected", h.SchemaVersion, h.ExpectedSchemaVersion)
+	}
+
+	connections := append([]*DatabaseConnectionHealth{h.Master}, h.Replicas...)
+	for _, c := range connections {
+		if c == nil {
+			h.Status = DATABASE_HEALTH_UNHEALTHY
+			continue
+		}
+
+		if maxLatencyMilliseconds > 0 && c.LatencyMilliseconds > float64(maxLatencyMilliseconds) {
+			c.AddError("the latency of %.1fms is over the limit of %vms", c.LatencyMilliseconds, maxLatencyMilliseconds)
+		}
+
+		if maxReplicationLagMilliseconds > 0 && c.ReplicationLagMilliseconds > int64(maxReplicationLagMilliseconds) {
+			c.AddError("the replication lag of %vms is over the limit of %vms", c.ReplicationLagMilliseconds, maxReplicationLagMilliseconds)
+		}
+
+		c.Healthy = len(c.Errors) == 0
+		if !c.Healthy {
+			h.Status = DATABASE_HEALTH_UNHEALTHY
+		}
+	}
+}
+
+// WithoutErrors returns a copy of the health check without the errors found while checking each connection since they
+// can include details of how the database is set up.
+func (h *DatabaseHealth) WithoutErrors() *DatabaseHealth {
+	copied := *h
+
+	withoutErrors := func(c *DatabaseConnectionHealth) *DatabaseConnectionHealth {
+		if c == nil {
+			return nil
+		}
+
+		copiedConnection := *c
+		copiedConnection.Errors = nil
+		return &copiedConnection
+	}
+
+	copied.Master = withoutErrors(h.Master)
+	copied.Replicas = make([]*DatabaseConnectionHealth, len(h.Replicas))
+	for i, replica := range h.Replicas {
+		copied.Replicas[i] = withoutErrors(replica)
+	}
+
+	return &copied
+}
+
+func (h *DatabaseHealth) IsHealthy() bool {
+	return h.Status == DATABASE_HEALTH_OK
+}
+
+func (h *DatabaseHealth) ToJson() string {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func DatabaseHealthFromJson(data io.Reader) *DatabaseHealth {
+	var health DatabaseHealth
+	if err := json.NewDecoder(data).Decode(&health); err == nil {
+		return &health
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatabaseHealthEvaluate(t *testing.T) {
+	health := &DatabaseHealth{
+		SchemaVersion:         CurrentVersion,
+		ExpectedSchemaVersion: CurrentVersion,
+		Master:                &DatabaseConnectionHealth{Name: "master", LatencyMilliseconds: 2},
+		Replicas: []*DatabaseConnectionHealth{
+			{Name: "replica-0", LatencyMilliseconds: 3, ReplicationLagMilliseconds: 500},
+		},
+	}
+
+	health.Evaluate(100, 1000)
+	if !health.IsHealthy() || !health.Master.Healthy || !health.Replicas[0].Healthy {
+		t.Fatal("should be healthy")
+	}
+
+	health.Evaluate(0, 100)
+	if health.IsHealthy() || !health.Master.Healthy || health.Replicas[0].Healthy {
+		t.Fatal("replica should be lagging")
+	}
+
+	health = &DatabaseHealth{
+		SchemaVersion:         "3.0.0",
+		ExpectedSchemaVersion: CurrentVersion,
+		Master:                &DatabaseConnectionHealth{Name: "master", LatencyMilliseconds: 200},
+	}
+
+	health.Evaluate(100, 0)
+	if health.IsHealthy() || health.Master.Healthy || len(health.Master.Errors) != 2 {
+		t.Fatal("should have found an old schema and high latency", health.Master.Errors)
+	}
+
+	health = &DatabaseHealth{
+		ExpectedSchemaVersion: CurrentVersion,
+		Master:                &DatabaseConnectionHealth{Name: "master"},
+	}
+	health.Master.AddError("connection refused")
+
+	health.Evaluate(0, 0)
+	if health.IsHealthy() || len(health.Master.Errors) != 1 {
+		t.Fatal("shouldn't check the schema of a master that can't be reached", health.Master.Errors)
+	}
+
+	if received := DatabaseHealthFromJson(strings.NewReader(health.ToJson())); received == nil || received.Status != DATABASE_HEALTH_UNHEALTHY || received.Master.Errors[0] != "connection refused" {
+		t.Fatal("json didn't round trip")
+	}
+}
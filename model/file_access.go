@@ -0,0 +1,162 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	FILE_ACCESS_LINK_TYPE_DOWNLOAD  = "download"
+	FILE_ACCESS_LINK_TYPE_THUMBNAIL = "thumbnail"
+	FILE_ACCESS_LINK_TYPE_PREVIEW   = "preview"
+	FILE_ACCESS_LINK_TYPE_PUBLIC    = "public"
+	FILE_ACCESS_LINK_TYPE_EXPORT    = "export"
+
+	FILE_ACCESS_QUERY_MAX_LIMIT = 1000
+)
+
+// FileAccess records a file, or its thumbnail or preview, being sent to a user. UserId is empty for files that were
+// accessed through a public link or exported from the command line.
+type FileAccess struct {
+	Id        string `json:"id"`
+	FileId    string `json:"file_id"`
+	UserId    string `json:"user_id"`
+	IpAddress string `json:"ip_address"`
+	LinkType  string `json:"link_type"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+// FileAccessQuery selects a page of the file access log, oldest first. Only accesses by UserId and to FileId are
+// included if they're set, and only ones between Since and Until, where either may be 0 to leave that end of the
+// range open.
+type FileAccessQuery struct {
+	UserId string `json:"user_id"`
+	FileId string `json:"file_id"`
+	Since  int64  `json:"since"`
+	Until  int64  `json:"until"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+func (o *FileAccess) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *FileAccess) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.FileId) != 26 {
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.file_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.UserId) != 0 && len(o.UserId) != 26 {
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.user_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.IpAddress) > 64 {
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.ip_address.app_error", nil, "id="+o.Id)
+	}
+
+	switch o.LinkType {
+	case FILE_ACCESS_LINK_TYPE_DOWNLOAD, FILE_ACCESS_LINK_TYPE_THUMBNAIL, FILE_ACCESS_LINK_TYPE_PREVIEW, FILE_ACCESS_LINK_TYPE_PUBLIC, FILE_ACCESS_LINK_TYPE_EXPORT:
+	default:
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.link_type.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("FileAccess.IsValid", "model.file_access.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (q *FileAccessQuery) IsValid() *AppError {
+	if len(q.UserId) != 0 && len(q.UserId) != 26 {
+		return NewLocAppError("FileAccessQuery.IsValid", "model.file_access_query.is_valid.user_id.app_error", nil, "")
+	}
+
+	if len(q.FileId) != 0 && len(q.FileId) != 26 {
+		return NewLocAppError("FileAccessQuery.IsValid", "model.file_access_query.is_valid.file_id.app_error", nil, "")
+	}
+
+	if q.Since < 0 || q.Until < 0 || (q.Until != 0 && q.Until < q.Since) {
+		return NewLocAppError("FileAccessQuery.IsValid", "model.file_access_query.is_valid.range.app_error", nil, "")
+	}
+
+	if q.Offset < 0 || q.Limit <= 0 || q.Limit > FILE_ACCESS_QUERY_MAX_LIMIT {
+		return NewLocAppError("FileAccessQuery.IsValid", "model.file_access_query.is_valid.limit.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *FileAccess) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func FileAccessFromJson(data io.Reader) *FileAccess {
+	decoder := json.NewDecoder(data)
+	var o FileAccess
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func FileAccessListToJson(list []*FileAccess) string {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func FileAccessListFromJson(data io.Reader) []*FileAccess {
+	decoder := json.NewDecoder(data)
+	var list []*FileAccess
+	err := decoder.Decode(&list)
+	if err == nil {
+		return list
+	} else {
+		return nil
+	}
+}
+
+func (q *FileAccessQuery) ToJson() string {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func FileAccessQueryFromJson(data io.Reader) *FileAccessQuery {
+	decoder := json.NewDecoder(data)
+	var q FileAccessQuery
+	err := decoder.Decode(&q)
+	if err == nil {
+		return &q
+	} else {
+		return nil
+	}
+}
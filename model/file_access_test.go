@@ -0,0 +1,82 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileAccessJson(t *testing.T) {
+	o := FileAccess{Id: NewId(), FileId: NewId(), UserId: NewId(), IpAddress: "127.0.0.1", LinkType: FILE_ACCESS_LINK_TYPE_DOWNLOAD}
+	json := o.ToJson()
+	ro := FileAccessFromJson(strings.NewReader(json))
+
+	if ro.Id != o.Id || ro.FileId != o.FileId || ro.LinkType != o.LinkType {
+		t.Fatal("Ids do not match")
+	}
+
+	list := FileAccessListFromJson(strings.NewReader(FileAccessListToJson([]*FileAccess{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("list did not round trip")
+	}
+
+	q := FileAccessQuery{UserId: NewId(), Since: 1000, Limit: 10}
+	rq := FileAccessQueryFromJson(strings.NewReader(q.ToJson()))
+	if rq.UserId != q.UserId || rq.Since != q.Since || rq.Limit != q.Limit {
+		t.Fatal("query did not round trip")
+	}
+}
+
+func TestFileAccessIsValid(t *testing.T) {
+	o := FileAccess{FileId: NewId(), IpAddress: "127.0.0.1", LinkType: FILE_ACCESS_LINK_TYPE_PUBLIC}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.UserId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.LinkType = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.LinkType = FILE_ACCESS_LINK_TYPE_PREVIEW
+	o.FileId = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestFileAccessQueryIsValid(t *testing.T) {
+	q := FileAccessQuery{Limit: 100}
+
+	if err := q.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Since = 2000
+	q.Until = 1000
+	if err := q.IsValid(); err == nil {
+		t.Fatal("shouldn't allow a range that ends before it starts")
+	}
+
+	q.Until = 0
+	q.Limit = FILE_ACCESS_QUERY_MAX_LIMIT + 1
+	if err := q.IsValid(); err == nil {
+		t.Fatal("shouldn't allow too large a limit")
+	}
+
+	q.Limit = 100
+	q.FileId = "junk"
+	if err := q.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
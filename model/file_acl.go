@@ -0,0 +1,105 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	FILE_ACL_MAX_ENTRIES = 100
+)
+
+// FileAcl restricts who can download a file, along with its thumbnail and preview, to the users in UserIds and the
+// current members of the channels in ChannelIds. It's checked every time the file is requested, so posting or
+// forwarding the file somewhere else doesn't give anyone else access to it. The user who uploaded the file can always
+// access it, and files without a FileAcl can be accessed by anyone who can see the post that they're attached to.
+type FileAcl struct {
+	FileId     string      `json:"file_id"`
+	UserIds    StringArray `json:"user_ids"`
+	ChannelIds StringArray `json:"channel_ids"`
+	CreateAt   int64       `json:"create_at"`
+	UpdateAt   int64       `json:"update_at"`
+}
+
+func (o *FileAcl) PreSave() {
+	if o.UserIds == nil {
+		o.UserIds = StringArray{}
+	}
+
+	if o.ChannelIds == nil {
+		o.ChannelIds = StringArray{}
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	o.UpdateAt = GetMillis()
+}
+
+func (o *FileAcl) IsValid() *AppError {
+	if len(o.FileId) != 26 {
+		return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.file_id.app_error", nil, "")
+	}
+
+	if len(o.UserIds)+len(o.ChannelIds) > FILE_ACL_MAX_ENTRIES {
+		return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.too_many.app_error", map[string]interface{}{"Max": FILE_ACL_MAX_ENTRIES}, "file_id="+o.FileId)
+	}
+
+	for _, userId := range o.UserIds {
+		if len(userId) != 26 {
+			return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.user_id.app_error", nil, "file_id="+o.FileId)
+		}
+	}
+
+	for _, channelId := range o.ChannelIds {
+		if len(channelId) != 26 {
+			return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.channel_id.app_error", nil, "file_id="+o.FileId)
+		}
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.create_at.app_error", nil, "file_id="+o.FileId)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("FileAcl.IsValid", "model.file_acl.is_valid.update_at.app_error", nil, "file_id="+o.FileId)
+	}
+
+	return nil
+}
+
+// AllowsUser returns true if the user is one that the file has been shared with directly. Members of the channels
+// that it's shared with have to be checked separately.
+func (o *FileAcl) AllowsUser(userId string) bool {
+	for _, id := range o.UserIds {
+		if id == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *FileAcl) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func FileAclFromJson(data io.Reader) *FileAcl {
+	decoder := json.NewDecoder(data)
+	var o FileAcl
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
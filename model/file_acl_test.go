@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileAclJson(t *testing.T) {
+	o := FileAcl{FileId: NewId(), UserIds: StringArray{NewId()}, ChannelIds: StringArray{NewId()}}
+	json := o.ToJson()
+	ro := FileAclFromJson(strings.NewReader(json))
+
+	if ro.FileId != o.FileId || len(ro.UserIds) != 1 || ro.UserIds[0] != o.UserIds[0] || len(ro.ChannelIds) != 1 {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestFileAclIsValid(t *testing.T) {
+	o := FileAcl{FileId: NewId()}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.UserIds = StringArray{"junk"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserIds = StringArray{NewId()}
+	o.ChannelIds = StringArray{"junk"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelIds = StringArray{}
+	for i := 0; i < FILE_ACL_MAX_ENTRIES; i++ {
+		o.ChannelIds = append(o.ChannelIds, NewId())
+	}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid with too many entries")
+	}
+}
+
+func TestFileAclAllowsUser(t *testing.T) {
+	userId := NewId()
+	o := FileAcl{FileId: NewId(), UserIds: StringArray{userId}}
+
+	if !o.AllowsUser(userId) {
+		t.Fatal("should allow a user that the file is shared with")
+	}
+
+	if o.AllowsUser(NewId()) {
+		t.Fatal("shouldn't allow other users")
+	}
+}
@@ -5,13 +5,20 @@ package model
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"image"
-	"image/gif"
+	_ "image/gif"
 	"io"
 	"mime"
 	"path/filepath"
 	"strings"
+
+	_ "golang.org/x/image/webp"
 )
 
 type FileInfo struct {
@@ -24,6 +31,7 @@ type FileInfo struct {
 	Path            string `json:"-"` // not sent back to the client
 	ThumbnailPath   string `json:"-"` // not sent back to the client
 	PreviewPath     string `json:"-"` // not sent back to the client
+	WebPPreviewPath string `json:"-"` // not sent back to the client
 	Name            string `json:"name"`
 	Extension       string `json:"extension"`
 	Size            int64  `json:"size"`
@@ -31,6 +39,26 @@ type FileInfo struct {
 	Width           int    `json:"width,omitempty"`
 	Height          int    `json:"height,omitempty"`
 	HasPreviewImage bool   `json:"has_preview_image,omitempty"`
+	IsAnimated      bool   `json:"is_animated,omitempty"`
+	Duration        int64  `json:"duration,omitempty"` // in milliseconds, only set for audio and video
+	Waveform        string `json:"waveform,omitempty"` // base64 encoded peak amplitudes, only set for audio
+	Checksum        string `json:"checksum,omitempty"` // hex encoded SHA-256 of the file when it was uploaded
+}
+
+// VideoInfo holds the metadata that a transcoder extracts from an uploaded video, along with
+// a frame that can be shown as its poster.
+type VideoInfo struct {
+	Duration    int64
+	Width       int
+	Height      int
+	PosterFrame image.Image
+}
+
+// AudioInfo holds the duration of an audio file along with its decoded samples, mixed down
+// to a single channel and scaled to between -1 and 1.
+type AudioInfo struct {
+	Duration int64
+	Samples  []float64
 }
 
 func (info *FileInfo) ToJson() string {
@@ -109,17 +137,51 @@ func (o *FileInfo) IsValid() *AppError {
 		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.path.app_error", nil, "id="+o.Id)
 	}
 
+	if len(o.Checksum) != 0 && len(o.Checksum) != sha256.Size*2 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.checksum.app_error", nil, "id="+o.Id)
+	}
+
 	return nil
 }
 
+// GetFileChecksum returns the checksum that's stored on a FileInfo for a file with the given contents.
+func GetFileChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentDigest returns the value of a Content-Digest header for the file or an empty string if the file was uploaded
+// before checksums were recorded.
+func (o *FileInfo) ContentDigest() string {
+	sum, err := hex.DecodeString(o.Checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return ""
+	}
+
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
+
 func (o *FileInfo) IsImage() bool {
 	return strings.HasPrefix(o.MimeType, "image")
 }
 
+func (o *FileInfo) IsVideo() bool {
+	return strings.HasPrefix(o.MimeType, "video")
+}
+
+func (o *FileInfo) IsAudio() bool {
+	return strings.HasPrefix(o.MimeType, "audio")
+}
+
+func (o *FileInfo) IsPdf() bool {
+	return o.MimeType == "application/pdf"
+}
+
 func GetInfoForBytes(name string, data []byte) (*FileInfo, *AppError) {
 	info := &FileInfo{
-		Name: name,
-		Size: int64(len(data)),
+		Name:     name,
+		Size:     int64(len(data)),
+		Checksum: GetFileChecksum(data),
 	}
 	var err *AppError
 
@@ -141,12 +203,13 @@ func GetInfoForBytes(name string, data []byte) (*FileInfo, *AppError) {
 
 			if info.MimeType == "image/gif" {
 				// Just show the gif itself instead of a preview image for animated gifs
-				if gifConfig, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
+				if frames, err := CountGifFrames(data); err != nil {
 					// Still return the rest of the info even though it doesn't appear to be an actual gif
 					info.HasPreviewImage = true
 					err = NewLocAppError("GetInfoForBytes", "model.file_info.get.gif.app_error", nil, "name="+name)
 				} else {
-					info.HasPreviewImage = len(gifConfig.Image) == 1
+					info.HasPreviewImage = frames == 1
+					info.IsAnimated = frames > 1
 				}
 			} else {
 				info.HasPreviewImage = true
@@ -157,6 +220,61 @@ func GetInfoForBytes(name string, data []byte) (*FileInfo, *AppError) {
 	return info, err
 }
 
+// CountGifFrames returns the number of frames in a gif by walking through its blocks without decoding any of them, so
+// that it can be used to check how much memory decoding the whole thing would take.
+func CountGifFrames(data []byte) (int, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return 0, errors.New("not a gif")
+	}
+
+	// Skip the header, the logical screen descriptor and the global color table
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << ((data[10] & 0x07) + 1)
+	}
+
+	frames := 0
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x21: // extension
+			offset += 2
+		case 0x2C: // image descriptor
+			if offset+10 > len(data) {
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			flags := data[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << ((flags & 0x07) + 1)
+			}
+
+			// Skip the LZW minimum code size
+			offset += 1
+			frames += 1
+		case 0x3B: // trailer
+			return frames, nil
+		default:
+			return 0, fmt.Errorf("unknown gif block 0x%02x", data[offset])
+		}
+
+		// Skip the sub-blocks holding the extension or image data
+		for {
+			if offset >= len(data) {
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			size := int(data[offset])
+			offset += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+
+	return 0, io.ErrUnexpectedEOF
+}
+
 func GetEtagForFileInfos(infos []*FileInfo) string {
 	if len(infos) == 0 {
 		return Etag()
@@ -172,3 +290,12 @@ func GetEtagForFileInfos(infos []*FileInfo) string {
 
 	return Etag(infos[0].PostId, maxUpdateAt)
 }
+
+// FileInfoForIndexing is a file info along with the extra information that a search
+// backend needs to index it without looking up its post or channel.
+type FileInfoForIndexing struct {
+	FileInfo
+	ChannelId    string `json:"channel_id"`
+	TeamId       string `json:"team_id"`
+	PostCreateAt int64  `json:"post_create_at"`
+}
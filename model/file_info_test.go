@@ -4,8 +4,9 @@
 package model
 
 import (
+	"bytes"
 	"encoding/base64"
-	_ "image/gif"
+	"image/gif"
 	_ "image/png"
 	"io/ioutil"
 	"strings"
@@ -58,6 +59,30 @@ func TestFileInfoIsValid(t *testing.T) {
 	if err := info.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	info.Checksum = "abc"
+	if err := info.IsValid(); err == nil {
+		t.Fatal("Checksum of the wrong length isn't valid")
+	}
+
+	info.Checksum = GetFileChecksum([]byte("data"))
+	if err := info.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileInfoContentDigest(t *testing.T) {
+	info := &FileInfo{}
+	if digest := info.ContentDigest(); digest != "" {
+		t.Fatal("file without a checksum shouldn't have a digest")
+	}
+
+	info.Checksum = GetFileChecksum([]byte("hello world"))
+	if info.Checksum != "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9" {
+		t.Fatalf("got incorrect checksum: %v", info.Checksum)
+	} else if digest := info.ContentDigest(); digest != "sha-256=:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=:" {
+		t.Fatalf("got incorrect digest: %v", digest)
+	}
 }
 
 func TestFileInfoIsImage(t *testing.T) {
@@ -75,6 +100,51 @@ func TestFileInfoIsImage(t *testing.T) {
 	}
 }
 
+func TestFileInfoIsVideo(t *testing.T) {
+	info := &FileInfo{
+		MimeType: "video/mp4",
+	}
+
+	if !info.IsVideo() {
+		t.Fatal("file is a video")
+	}
+
+	info.MimeType = "image/png"
+	if info.IsVideo() {
+		t.Fatal("file is not a video")
+	}
+}
+
+func TestFileInfoIsAudio(t *testing.T) {
+	info := &FileInfo{
+		MimeType: "audio/ogg",
+	}
+
+	if !info.IsAudio() {
+		t.Fatal("file is audio")
+	}
+
+	info.MimeType = "video/mp4"
+	if info.IsAudio() {
+		t.Fatal("file is not audio")
+	}
+}
+
+func TestFileInfoIsPdf(t *testing.T) {
+	info := &FileInfo{
+		MimeType: "application/pdf",
+	}
+
+	if !info.IsPdf() {
+		t.Fatal("file is a pdf")
+	}
+
+	info.MimeType = "image/png"
+	if info.IsPdf() {
+		t.Fatal("file is not a pdf")
+	}
+}
+
 func TestGetInfoForFile(t *testing.T) {
 	fakeFile := make([]byte, 1000)
 
@@ -86,6 +156,8 @@ func TestGetInfoForFile(t *testing.T) {
 		t.Fatalf("Got incorrect extension: %v", info.Extension)
 	} else if info.Size != 1000 {
 		t.Fatalf("Got incorrect size: %v", info.Size)
+	} else if info.Checksum != GetFileChecksum(fakeFile) {
+		t.Fatalf("Got incorrect checksum: %v", info.Checksum)
 	} else if !strings.HasPrefix(info.MimeType, "text/plain") {
 		t.Fatalf("Got incorrect mime type: %v", info.MimeType)
 	} else if info.Width != 0 {
@@ -158,6 +230,26 @@ func TestGetInfoForFile(t *testing.T) {
 		t.Fatalf("Got incorrect height: %v", info.Height)
 	} else if info.HasPreviewImage {
 		t.Fatalf("Got incorrect has preview image: %v", info.HasPreviewImage)
+	} else if !info.IsAnimated {
+		t.Fatalf("Got incorrect is animated: %v", info.IsAnimated)
+	}
+
+	webpFile, err := ioutil.ReadFile("../tests/test.webp")
+	if err != nil {
+		t.Fatalf("Failed to load test.webp: %v", err.Error())
+	}
+	if info, err := GetInfoForBytes("test.webp", webpFile); err != nil {
+		t.Fatal(err)
+	} else if info.Extension != "webp" {
+		t.Fatalf("Got incorrect extension: %v", info.Extension)
+	} else if info.MimeType != "image/webp" {
+		t.Fatalf("Got incorrect mime type: %v", info.MimeType)
+	} else if info.Width != 150 {
+		t.Fatalf("Got incorrect width: %v", info.Width)
+	} else if info.Height != 103 {
+		t.Fatalf("Got incorrect height: %v", info.Height)
+	} else if !info.HasPreviewImage {
+		t.Fatalf("Got incorrect has preview image: %v", info.HasPreviewImage)
 	}
 
 	if info, err := GetInfoForBytes("filewithoutextension", fakeFile); err != nil {
@@ -198,3 +290,36 @@ func TestGetInfoForFile(t *testing.T) {
 		t.Fatalf("Got incorrect mime type: %v", info.MimeType)
 	}
 }
+
+func TestCountGifFrames(t *testing.T) {
+	gifFile, _ := base64.StdEncoding.DecodeString("R0lGODlhAQABAIABAP///wAAACwAAAAAAQABAAACAkQBADs=")
+	if frames, err := CountGifFrames(gifFile); err != nil {
+		t.Fatal(err)
+	} else if frames != 1 {
+		t.Fatalf("Got incorrect frame count: %v", frames)
+	}
+
+	animatedGifFile, err := ioutil.ReadFile("../tests/testgif.gif")
+	if err != nil {
+		t.Fatalf("Failed to load testgif.gif: %v", err.Error())
+	}
+
+	decoded, err := gif.DecodeAll(bytes.NewReader(animatedGifFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if frames, err := CountGifFrames(animatedGifFile); err != nil {
+		t.Fatal(err)
+	} else if frames != len(decoded.Image) {
+		t.Fatalf("Got incorrect frame count: %v, expected %v", frames, len(decoded.Image))
+	}
+
+	if _, err := CountGifFrames(animatedGifFile[:len(animatedGifFile)/2]); err == nil {
+		t.Fatal("should have failed on a truncated gif")
+	}
+
+	if _, err := CountGifFrames([]byte("not a gif at all")); err == nil {
+		t.Fatal("should have failed on something that isn't a gif")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Invitation grants a single person access to a team. Its Id is the token that is sent in the
+// invite link, and it's deleted once it has been used. An invitation with an Email can only be
+// used by an account with that email address.
+type Invitation struct {
+	Id        string `json:"id"`
+	TeamId    string `json:"team_id"`
+	Email     string `json:"email"`
+	CreatorId string `json:"creator_id"`
+	CreateAt  int64  `json:"create_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+func (o *Invitation) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.Email = strings.ToLower(o.Email)
+
+	// an invitation that's restored after failing to be used keeps its original time
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *Invitation) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Email) > 128 || (len(o.Email) > 0 && !IsValidEmail(o.Email)) {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.email.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CreatorId) != 0 && len(o.CreatorId) != 26 {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.creator_id.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.ExpiresAt <= o.CreateAt {
+		return NewLocAppError("Invitation.IsValid", "model.invitation.is_valid.expires_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *Invitation) IsExpired() bool {
+	return o.ExpiresAt <= GetMillis()
+}
+
+// CanBeUsedBy returns true if the invitation isn't limited to an email address other than the given one.
+func (o *Invitation) CanBeUsedBy(email string) bool {
+	return len(o.Email) == 0 || o.Email == strings.ToLower(email)
+}
+
+func (o *Invitation) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func InvitationFromJson(data io.Reader) *Invitation {
+	decoder := json.NewDecoder(data)
+	var o Invitation
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func InvitationListToJson(l []*Invitation) string {
+	if b, err := json.Marshal(l); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func InvitationListFromJson(data io.Reader) []*Invitation {
+	decoder := json.NewDecoder(data)
+	var o []*Invitation
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return o
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInvitationJson(t *testing.T) {
+	o := Invitation{Id: NewId(), TeamId: NewId(), Email: "test@example.com"}
+	json := o.ToJson()
+	ro := InvitationFromJson(strings.NewReader(json))
+
+	if ro.Id != o.Id || ro.Email != o.Email {
+		t.Fatal("Ids do not match")
+	}
+
+	l := InvitationListFromJson(strings.NewReader(InvitationListToJson([]*Invitation{&o})))
+	if len(l) != 1 || l[0].Id != o.Id {
+		t.Fatal("list should have round-tripped")
+	}
+}
+
+func TestInvitationIsValid(t *testing.T) {
+	o := Invitation{TeamId: NewId(), Email: "Test@Example.com"}
+	o.PreSave()
+	o.ExpiresAt = o.CreateAt + 1000
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Email != "test@example.com" {
+		t.Fatal("email should have been lowercased")
+	}
+
+	o.Email = "notanemail"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Email = ""
+	o.TeamId = "bad"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.TeamId = NewId()
+	o.ExpiresAt = o.CreateAt
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestInvitationCanBeUsedBy(t *testing.T) {
+	o := Invitation{}
+	if !o.CanBeUsedBy("anyone@example.com") {
+		t.Fatal("invitation without an email should be usable by anyone")
+	}
+
+	o.Email = "test@example.com"
+	if !o.CanBeUsedBy("Test@Example.com") {
+		t.Fatal("should be usable by the invited email regardless of case")
+	}
+
+	if o.CanBeUsedBy("other@example.com") {
+		t.Fatal("shouldn't be usable by another email")
+	}
+}
+
+func TestInvitationIsExpired(t *testing.T) {
+	o := Invitation{ExpiresAt: GetMillis() + 100000}
+	if o.IsExpired() {
+		t.Fatal("shouldn't be expired")
+	}
+
+	o.ExpiresAt = GetMillis() - 1
+	if !o.IsExpired() {
+		t.Fatal("should be expired")
+	}
+}
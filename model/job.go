@@ -1,100 +1,132 @@
-// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
 // See License.txt for license information.
 
 package model
 
 import (
-	"fmt"
-	"time"
+	"encoding/json"
+	"io"
+	"strconv"
 )
 
-type TaskFunc func()
+const (
+	JOB_TYPE_SEARCH_INDEXING        = "search_indexing"
+	JOB_TYPE_ANALYTICS_AGGREGATION  = "analytics_aggregation"
+	JOB_TYPE_DATA_RETENTION         = "data_retention"
+	JOB_TYPE_AUDIO_WAVEFORM         = "audio_waveform"
+	JOB_TYPE_FILE_STORAGE_MIGRATION = "file_storage_migration"
+	JOB_TYPE_PDF_PREVIEW            = "pdf_preview"
+	JOB_TYPE_CHANNEL_COUNTS_REPAIR  = "channel_counts_repair"
+	JOB_TYPE_BACKGROUND_MIGRATION   = "background_migration"
+
+	JOB_STATUS_PENDING          = "pending"
+	JOB_STATUS_IN_PROGRESS      = "in_progress"
+	JOB_STATUS_SUCCESS          = "success"
+	JOB_STATUS_ERROR            = "error"
+	JOB_STATUS_CANCEL_REQUESTED = "cancel_requested"
+	JOB_STATUS_CANCELED         = "canceled"
+)
 
-type ScheduledTask struct {
-	Name      string        `json:"name"`
-	Interval  time.Duration `json:"interval"`
-	Recurring bool          `json:"recurring"`
-	function  TaskFunc      `json:",omitempty"`
-	timer     *time.Timer   `json:",omitempty"`
+type Job struct {
+	Id             string    `json:"id"`
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	CreateAt       int64     `json:"create_at"`
+	StartAt        int64     `json:"start_at"`
+	LastActivityAt int64     `json:"last_activity_at"`
+	Progress       int64     `json:"progress"`
+	Data           StringMap `json:"data"`
 }
 
-var tasks = make(map[string]*ScheduledTask)
-
-func addTask(task *ScheduledTask) {
-	tasks[task.Name] = task
-}
+func (j *Job) PreSave() {
+	if j.Id == "" {
+		j.Id = NewId()
+	}
 
-func removeTaskByName(name string) {
-	delete(tasks, name)
-}
+	if j.CreateAt == 0 {
+		j.CreateAt = GetMillis()
+	}
 
-func GetTaskByName(name string) *ScheduledTask {
-	if task, ok := tasks[name]; ok {
-		return task
+	if j.Status == "" {
+		j.Status = JOB_STATUS_PENDING
 	}
-	return nil
-}
 
-func GetAllTasks() *map[string]*ScheduledTask {
-	return &tasks
+	if j.Data == nil {
+		j.Data = make(StringMap)
+	}
 }
 
-func CreateTask(name string, function TaskFunc, timeToExecution time.Duration) *ScheduledTask {
-	task := &ScheduledTask{
-		Name:      name,
-		Interval:  timeToExecution,
-		Recurring: false,
-		function:  function,
+func (j *Job) IsValid() *AppError {
+	if len(j.Id) != 26 {
+		return NewLocAppError("Job.IsValid", "model.job.is_valid.id.app_error", nil, "")
 	}
 
-	taskRunner := func() {
-		go task.function()
-		removeTaskByName(task.Name)
+	if j.CreateAt == 0 {
+		return NewLocAppError("Job.IsValid", "model.job.is_valid.create_at.app_error", nil, "id="+j.Id)
 	}
 
-	task.timer = time.AfterFunc(timeToExecution, taskRunner)
+	if len(j.Type) == 0 || len(j.Type) > 32 {
+		return NewLocAppError("Job.IsValid", "model.job.is_valid.type.app_error", nil, "id="+j.Id)
+	}
 
-	addTask(task)
+	switch j.Status {
+	case JOB_STATUS_PENDING, JOB_STATUS_IN_PROGRESS, JOB_STATUS_SUCCESS, JOB_STATUS_ERROR, JOB_STATUS_CANCEL_REQUESTED, JOB_STATUS_CANCELED:
+	default:
+		return NewLocAppError("Job.IsValid", "model.job.is_valid.status.app_error", nil, "id="+j.Id)
+	}
 
-	return task
+	return nil
 }
 
-func CreateRecurringTask(name string, function TaskFunc, interval time.Duration) *ScheduledTask {
-	task := &ScheduledTask{
-		Name:      name,
-		Interval:  interval,
-		Recurring: true,
-		function:  function,
-	}
+// IsFinished returns true if the job has stopped running and won't be picked up again.
+func (j *Job) IsFinished() bool {
+	return j.Status == JOB_STATUS_SUCCESS || j.Status == JOB_STATUS_ERROR || j.Status == JOB_STATUS_CANCELED
+}
 
-	taskRecurer := func() {
-		go task.function()
-		task.timer.Reset(task.Interval)
+// GetDataInt64 returns a numeric value stored in the job's data, such as a checkpoint,
+// or 0 if it hasn't been set.
+func (j *Job) GetDataInt64(key string) int64 {
+	if value, err := strconv.ParseInt(j.Data[key], 10, 64); err == nil {
+		return value
 	}
 
-	task.timer = time.AfterFunc(interval, taskRecurer)
+	return 0
+}
 
-	addTask(task)
+func (j *Job) SetDataInt64(key string, value int64) {
+	j.Data[key] = strconv.FormatInt(value, 10)
+}
 
-	return task
+func (j *Job) ToJson() string {
+	if b, err := json.Marshal(j); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
 }
 
-func (task *ScheduledTask) Cancel() {
-	task.timer.Stop()
-	removeTaskByName(task.Name)
+func JobFromJson(data io.Reader) *Job {
+	var job Job
+	if err := json.NewDecoder(data).Decode(&job); err == nil {
+		return &job
+	} else {
+		return nil
+	}
 }
 
-// Executes the task immediatly. A recurring task will be run regularally after interval.
-func (task *ScheduledTask) Execute() {
-	task.function()
-	task.timer.Reset(task.Interval)
+func JobsToJson(jobs []*Job) string {
+	if b, err := json.Marshal(jobs); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
 }
 
-func (task *ScheduledTask) String() string {
-	return fmt.Sprintf(
-		"%s\nInterval: %s\nRecurring: %t\n",
-		task.Name,
-		task.Interval.String(),
-		task.Recurring,
-	)
+func JobsFromJson(data io.Reader) []*Job {
+	var jobs []*Job
+	if err := json.NewDecoder(data).Decode(&jobs); err == nil {
+		return jobs
+	} else {
+		return nil
+	}
 }
@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	JOB_SCHEDULE_MAX_LENGTH = 128
+	JOB_TIMEZONE_MAX_LENGTH = 64
+)
+
+// JobSchedule says when jobs of a type are scheduled. Schedule is a cron expression evaluated in Timezone, which is
+// an IANA time zone name such as "America/Toronto" or empty for the server's local time. UpdateAt is when the schedule
+// itself was last changed, not when it was last run.
+type JobSchedule struct {
+	JobType   string `json:"job_type"`
+	Schedule  string `json:"schedule"`
+	Timezone  string `json:"timezone"`
+	NextRunAt int64  `json:"next_run_at"`
+	LastRunAt int64  `json:"last_run_at"`
+	UpdateAt  int64  `json:"update_at"`
+}
+
+func (s *JobSchedule) PreSave() {
+	s.UpdateAt = GetMillis()
+}
+
+func (s *JobSchedule) IsValid() *AppError {
+	if len(s.JobType) == 0 || len(s.JobType) > 32 {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.job_type.app_error", nil, "")
+	}
+
+	if len(s.Schedule) > JOB_SCHEDULE_MAX_LENGTH {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.schedule.app_error", nil, "job_type="+s.JobType)
+	} else if _, err := ParseCronSchedule(s.Schedule); err != nil {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.schedule.app_error", nil, "job_type="+s.JobType+", "+err.Error())
+	}
+
+	if len(s.Timezone) > JOB_TIMEZONE_MAX_LENGTH {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.timezone.app_error", nil, "job_type="+s.JobType)
+	} else if _, err := s.GetLocation(); err != nil {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.timezone.app_error", nil, "job_type="+s.JobType+", "+err.Error())
+	}
+
+	if s.UpdateAt == 0 {
+		return NewLocAppError("JobSchedule.IsValid", "model.job_schedule.is_valid.update_at.app_error", nil, "job_type="+s.JobType)
+	}
+
+	return nil
+}
+
+func (s *JobSchedule) GetLocation() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(s.Timezone)
+}
+
+// GetNextRunAfter returns the first time in milliseconds after the given one at which the schedule should run, or 0
+// if it never runs again.
+func (s *JobSchedule) GetNextRunAfter(after int64) (int64, error) {
+	schedule, err := ParseCronSchedule(s.Schedule)
+	if err != nil {
+		return 0, err
+	}
+
+	loc, err := s.GetLocation()
+	if err != nil {
+		return 0, err
+	}
+
+	next := schedule.Next(time.Unix(0, after*int64(time.Millisecond)).In(loc))
+	if next.IsZero() {
+		return 0, nil
+	}
+
+	return next.UnixNano() / int64(time.Millisecond), nil
+}
+
+func (s *JobSchedule) ToJson() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func JobScheduleFromJson(data io.Reader) *JobSchedule {
+	var schedule JobSchedule
+	if err := json.NewDecoder(data).Decode(&schedule); err == nil {
+		return &schedule
+	}
+
+	return nil
+}
+
+func JobSchedulesToJson(schedules []*JobSchedule) string {
+	if b, err := json.Marshal(schedules); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func JobSchedulesFromJson(data io.Reader) []*JobSchedule {
+	var schedules []*JobSchedule
+	if err := json.NewDecoder(data).Decode(&schedules); err != nil {
+		return nil
+	}
+
+	return schedules
+}
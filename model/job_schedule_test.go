@@ -0,0 +1,74 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobScheduleJson(t *testing.T) {
+	schedule := &JobSchedule{JobType: JOB_TYPE_DATA_RETENTION, Schedule: "0 2 * * *", Timezone: "UTC", NextRunAt: GetMillis()}
+	result := JobScheduleFromJson(strings.NewReader(schedule.ToJson()))
+
+	if *result != *schedule {
+		t.Fatal("schedules should have matched")
+	}
+
+	list := JobSchedulesFromJson(strings.NewReader(JobSchedulesToJson([]*JobSchedule{schedule})))
+	if len(list) != 1 || *list[0] != *schedule {
+		t.Fatal("schedule lists should have matched")
+	}
+}
+
+func TestJobScheduleIsValid(t *testing.T) {
+	schedule := &JobSchedule{JobType: JOB_TYPE_DATA_RETENTION, Schedule: "0 2 * * *"}
+	schedule.PreSave()
+
+	if err := schedule.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule.Schedule = "0 2 * *"
+	if err := schedule.IsValid(); err == nil {
+		t.Fatal("should be invalid with a bad schedule")
+	}
+
+	schedule.Schedule = "0 2 * * *"
+	schedule.Timezone = "Nowhere/Special"
+	if err := schedule.IsValid(); err == nil {
+		t.Fatal("should be invalid with an unknown time zone")
+	}
+
+	schedule.Timezone = "UTC"
+	schedule.JobType = ""
+	if err := schedule.IsValid(); err == nil {
+		t.Fatal("should be invalid without a job type")
+	}
+}
+
+func TestJobScheduleGetNextRunAfter(t *testing.T) {
+	schedule := &JobSchedule{Schedule: "0 2 * * *", Timezone: "UTC"}
+	after := time.Date(2017, time.June, 14, 10, 30, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	expected := time.Date(2017, time.June, 15, 2, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+
+	if next, err := schedule.GetNextRunAfter(after); err != nil {
+		t.Fatal(err)
+	} else if next != expected {
+		t.Fatal("should have run at 2am the next day", next, expected)
+	}
+
+	schedule.Schedule = "0 0 30 feb *"
+	if next, err := schedule.GetNextRunAfter(after); err != nil {
+		t.Fatal(err)
+	} else if next != 0 {
+		t.Fatal("should never run", next)
+	}
+
+	schedule.Schedule = "junk"
+	if _, err := schedule.GetNextRunAfter(after); err == nil {
+		t.Fatal("should have failed with a bad schedule")
+	}
+}
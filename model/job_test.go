@@ -1,188 +1,69 @@
-// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
 // See License.txt for license information.
 
 package model
 
 import (
+	"strings"
 	"testing"
-	"time"
 )
 
-func TestCreateTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue = 1
-	}
-
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
+func TestJobJson(t *testing.T) {
+	job := &Job{
+		Type: JOB_TYPE_SEARCH_INDEXING,
 	}
+	job.PreSave()
+	job.SetDataInt64("checkpoint", 1234)
 
-	time.Sleep(TASK_TIME + time.Second)
+	json := job.ToJson()
+	rjob := JobFromJson(strings.NewReader(json))
 
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
+	if rjob.Id != job.Id {
+		t.Fatal("ids should have matched")
 	}
 
-	if task.Name != TASK_NAME {
-		t.Fatal("Bad name")
+	if rjob.GetDataInt64("checkpoint") != 1234 {
+		t.Fatal("data should have matched")
 	}
 
-	if task.Interval != TASK_TIME {
-		t.Fatal("Bad interval")
+	if rjob.GetDataInt64("missing") != 0 {
+		t.Fatal("missing data should be 0")
 	}
 
-	if task.Recurring != false {
-		t.Fatal("should not reccur")
+	jobs := JobsFromJson(strings.NewReader(JobsToJson([]*Job{job})))
+	if len(jobs) != 1 || jobs[0].Id != job.Id {
+		t.Fatal("list should have matched")
 	}
 }
 
-func TestCreateRecurringTask(t *testing.T) {
-	TASK_NAME := "Test Recurring Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
-	}
-
-	task := CreateRecurringTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
-	}
-
-	time.Sleep(TASK_TIME)
-
-	if testValue != 2 {
-		t.Fatal("Task did not re-execute")
-	}
-
-	if task.Name != TASK_NAME {
-		t.Fatal("Bad name")
-	}
+func TestJobIsValid(t *testing.T) {
+	job := &Job{}
 
-	if task.Interval != TASK_TIME {
-		t.Fatal("Bad interval")
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	if task.Recurring != true {
-		t.Fatal("should reccur")
+	job.PreSave()
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid without a type")
 	}
 
-	task.Cancel()
-}
-
-func TestCancelTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue = 1
+	job.Type = JOB_TYPE_SEARCH_INDEXING
+	if err := job.IsValid(); err != nil {
+		t.Fatal(err)
 	}
 
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
+	if job.Status != JOB_STATUS_PENDING {
+		t.Fatal("new jobs should be pending")
 	}
-	task.Cancel()
-
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-}
-
-func TestGetAllTasks(t *testing.T) {
-	doNothing := func() {}
-
-	CreateTask("Task1", doNothing, time.Hour)
-	CreateTask("Task2", doNothing, time.Second)
-	CreateRecurringTask("Task3", doNothing, time.Second)
-	task4 := CreateRecurringTask("Task4", doNothing, time.Second)
-
-	task4.Cancel()
-
-	time.Sleep(time.Second * 3)
 
-	tasks := *GetAllTasks()
-	if len(tasks) != 2 {
-		t.Fatal("Wrong number of tasks got: ", len(tasks))
+	job.Status = "junk"
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid with an unknown status")
 	}
-	for _, task := range tasks {
-		if task.Name != "Task1" && task.Name != "Task3" {
-			t.Fatal("Wrong tasks")
-		}
-	}
-}
-
-func TestExecuteTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 5
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
-	}
-
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-
-	task.Execute()
-
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
-	}
-
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 2 {
-		t.Fatal("Task re-executed")
-	}
-}
-
-func TestExecuteTaskRecurring(t *testing.T) {
-	TASK_NAME := "Test Recurring Task"
-	TASK_TIME := time.Second * 5
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
-	}
-
-	task := CreateRecurringTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-
-	time.Sleep(time.Second * 3)
-
-	task.Execute()
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
-	}
-
-	time.Sleep(time.Second * 3)
-	if testValue != 1 {
-		t.Fatal("Task should not have executed before 5 seconds")
-	}
-
-	time.Sleep(time.Second * 3)
 
-	if testValue != 2 {
-		t.Fatal("Task did not re-execute after forced execution")
+	job.Status = JOB_STATUS_SUCCESS
+	if !job.IsFinished() {
+		t.Fatal("should be finished")
 	}
 }
@@ -0,0 +1,36 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	LEASE_NAME_MAX_LENGTH = 64
+)
+
+// Lease is held by at most one server at a time so that periodic work shared by a cluster runs on a single server.
+// A lease that isn't renewed before ExpireAt can be taken over by another server.
+type Lease struct {
+	Name     string
+	OwnerId  string
+	ExpireAt int64
+}
+
+func (l *Lease) IsValid() *AppError {
+	if len(l.Name) == 0 || len(l.Name) > LEASE_NAME_MAX_LENGTH {
+		return NewLocAppError("Lease.IsValid", "model.lease.is_valid.name.app_error", nil, "")
+	}
+
+	if len(l.OwnerId) != 26 {
+		return NewLocAppError("Lease.IsValid", "model.lease.is_valid.owner_id.app_error", nil, "name="+l.Name)
+	}
+
+	if l.ExpireAt == 0 {
+		return NewLocAppError("Lease.IsValid", "model.lease.is_valid.expire_at.app_error", nil, "name="+l.Name)
+	}
+
+	return nil
+}
+
+func (l *Lease) IsExpired() bool {
+	return l.ExpireAt < GetMillis()
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLeaseIsValid(t *testing.T) {
+	l := &Lease{Name: "Data Retention", OwnerId: NewId(), ExpireAt: GetMillis() + 1000}
+	if err := l.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Name = strings.Repeat("a", LEASE_NAME_MAX_LENGTH+1)
+	if err := l.IsValid(); err == nil {
+		t.Fatal("should be invalid with a long name")
+	}
+
+	l.Name = ""
+	if err := l.IsValid(); err == nil {
+		t.Fatal("should be invalid without a name")
+	}
+
+	l.Name = "Data Retention"
+	l.OwnerId = "junk"
+	if err := l.IsValid(); err == nil {
+		t.Fatal("should be invalid without an owner")
+	}
+
+	l.OwnerId = NewId()
+	l.ExpireAt = 0
+	if err := l.IsValid(); err == nil {
+		t.Fatal("should be invalid without an expiry")
+	}
+}
+
+func TestLeaseIsExpired(t *testing.T) {
+	l := &Lease{ExpireAt: GetMillis() + 10000}
+	if l.IsExpired() {
+		t.Fatal("shouldn't have expired yet")
+	}
+
+	l.ExpireAt = GetMillis() - 1
+	if !l.IsExpired() {
+		t.Fatal("should have expired")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// LegalHold keeps the posts and files of a user or a channel from being permanently deleted, whether by the data
+// retention job or by deleting the user or channel, for as long as the hold exists. The held user or channel itself
+// can't be permanently deleted in that time. Only content created between
+// StartAt and EndAt is held, where either of them may be 0 to leave that end of the range open.
+type LegalHold struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	UserId      string `json:"user_id"`
+	ChannelId   string `json:"channel_id"`
+	StartAt     int64  `json:"start_at"`
+	EndAt       int64  `json:"end_at"`
+	CreateAt    int64  `json:"create_at"`
+	UpdateAt    int64  `json:"update_at"`
+}
+
+// LegalHoldExport is a page of the posts held by a legal hold along with the files attached to them. Deleted posts
+// and the old versions of edited posts are included.
+type LegalHoldExport struct {
+	Posts     []*Post     `json:"posts"`
+	FileInfos []*FileInfo `json:"file_infos"`
+}
+
+func (o *LegalHold) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *LegalHold) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *LegalHold) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.update_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.DisplayName) == 0 || len(o.DisplayName) > 64 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.display_name.app_error", nil, "id="+o.Id)
+	}
+
+	if (len(o.UserId) == 0) == (len(o.ChannelId) == 0) {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.target.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.UserId) != 0 && len(o.UserId) != 26 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.user_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 0 && len(o.ChannelId) != 26 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.channel_id.app_error", nil, "id="+o.Id)
+	}
+
+	if o.StartAt < 0 || o.EndAt < 0 || (o.EndAt != 0 && o.EndAt < o.StartAt) {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.range.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+// Holds returns true if content created at the given time by the given user in the given channel is held.
+func (o *LegalHold) Holds(userId string, channelId string, createAt int64) bool {
+	if (len(o.UserId) == 0 || o.UserId != userId) && (len(o.ChannelId) == 0 || o.ChannelId != channelId) {
+		return false
+	}
+
+	return (o.StartAt == 0 || createAt >= o.StartAt) && (o.EndAt == 0 || createAt <= o.EndAt)
+}
+
+func (o *LegalHold) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LegalHoldFromJson(data io.Reader) *LegalHold {
+	decoder := json.NewDecoder(data)
+	var o LegalHold
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func LegalHoldListToJson(list []*LegalHold) string {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func LegalHoldListFromJson(data io.Reader) []*LegalHold {
+	decoder := json.NewDecoder(data)
+	var list []*LegalHold
+	err := decoder.Decode(&list)
+	if err == nil {
+		return list
+	} else {
+		return nil
+	}
+}
+
+func (o *LegalHoldExport) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LegalHoldExportFromJson(data io.Reader) *LegalHoldExport {
+	decoder := json.NewDecoder(data)
+	var o LegalHoldExport
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
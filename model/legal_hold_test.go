@@ -0,0 +1,91 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLegalHoldJson(t *testing.T) {
+	o := LegalHold{Id: NewId(), DisplayName: "Litigation", UserId: NewId(), StartAt: 1000}
+	json := o.ToJson()
+	ro := LegalHoldFromJson(strings.NewReader(json))
+
+	if ro.Id != o.Id || ro.UserId != o.UserId || ro.StartAt != o.StartAt {
+		t.Fatal("Ids do not match")
+	}
+
+	list := LegalHoldListFromJson(strings.NewReader(LegalHoldListToJson([]*LegalHold{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("list did not round trip")
+	}
+
+	export := LegalHoldExport{Posts: []*Post{{Id: NewId()}}, FileInfos: []*FileInfo{}}
+	rexport := LegalHoldExportFromJson(strings.NewReader(export.ToJson()))
+	if len(rexport.Posts) != 1 || rexport.Posts[0].Id != export.Posts[0].Id {
+		t.Fatal("export did not round trip")
+	}
+}
+
+func TestLegalHoldIsValid(t *testing.T) {
+	o := LegalHold{DisplayName: "Litigation"}
+	o.PreSave()
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should require a user or channel")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.ChannelId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("shouldn't allow both a user and a channel")
+	}
+
+	o.UserId = ""
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.StartAt = 2000
+	o.EndAt = 1000
+	if err := o.IsValid(); err == nil {
+		t.Fatal("shouldn't allow a range that ends before it starts")
+	}
+
+	o.EndAt = 0
+	if err := o.IsValid(); err != nil {
+		t.Fatal("should allow a range without an end", err)
+	}
+
+	o.DisplayName = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should require a display name")
+	}
+}
+
+func TestLegalHoldHolds(t *testing.T) {
+	userId := NewId()
+	channelId := NewId()
+
+	o := LegalHold{UserId: userId}
+	if !o.Holds(userId, NewId(), 1000) {
+		t.Fatal("should hold the user's content in any channel")
+	} else if o.Holds(NewId(), channelId, 1000) {
+		t.Fatal("shouldn't hold another user's content")
+	}
+
+	o = LegalHold{ChannelId: channelId, StartAt: 1000, EndAt: 2000}
+	if !o.Holds(NewId(), channelId, 1000) || !o.Holds(NewId(), channelId, 2000) {
+		t.Fatal("should hold the channel's content in the range")
+	} else if o.Holds(NewId(), channelId, 999) || o.Holds(NewId(), channelId, 2001) {
+		t.Fatal("shouldn't hold content outside of the range")
+	} else if o.Holds("", "", 1500) {
+		t.Fatal("shouldn't hold content without a user or channel")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	LOG_LEVEL_DEBUG    = "DEBUG"
+	LOG_LEVEL_INFO     = "INFO"
+	LOG_LEVEL_WARN     = "WARN"
+	LOG_LEVEL_ERROR    = "ERROR"
+	LOG_LEVEL_CRITICAL = "CRITICAL"
+
+	LOG_QUERY_MAX_LIMIT = 1000
+)
+
+var logLevelSeverities = map[string]int{
+	LOG_LEVEL_DEBUG:    0,
+	LOG_LEVEL_INFO:     1,
+	LOG_LEVEL_WARN:     2,
+	LOG_LEVEL_ERROR:    3,
+	LOG_LEVEL_CRITICAL: 4,
+}
+
+// LogEntry is a message written to the server log. Messages that span several lines, such as ones with stack traces,
+// are a single entry. Timestamp is 0 and Level is empty if they couldn't be read from the log line.
+type LogEntry struct {
+	Timestamp int64  `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+// LogQuery selects a page of the log entries of a cluster node, or of the server that receives it if NodeId is
+// empty. Entries are returned newest first and only include ones at Level or above, if it's set, that were written
+// between Since and Until, where either may be 0 to leave that end of the range open.
+type LogQuery struct {
+	NodeId string `json:"node_id"`
+	Level  string `json:"level"`
+	Since  int64  `json:"since"`
+	Until  int64  `json:"until"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// LogPage is a page of log entries returned by a LogQuery.
+type LogPage struct {
+	NodeId  string      `json:"node_id"`
+	Entries []*LogEntry `json:"entries"`
+	HasMore bool        `json:"has_more"`
+}
+
+func (q *LogQuery) IsValid() *AppError {
+	if len(q.Level) > 0 {
+		if _, ok := logLevelSeverities[q.Level]; !ok {
+			return NewLocAppError("LogQuery.IsValid", "model.log_query.is_valid.level.app_error", nil, "level="+q.Level)
+		}
+	}
+
+	if q.Since < 0 || q.Until < 0 || (q.Until != 0 && q.Until < q.Since) {
+		return NewLocAppError("LogQuery.IsValid", "model.log_query.is_valid.range.app_error", nil, "")
+	}
+
+	if q.Offset < 0 || q.Limit <= 0 || q.Limit > LOG_QUERY_MAX_LIMIT {
+		return NewLocAppError("LogQuery.IsValid", "model.log_query.is_valid.limit.app_error", nil, "")
+	}
+
+	return nil
+}
+
+// Matches returns whether a log entry is one that the query selects.
+func (q *LogQuery) Matches(entry *LogEntry) bool {
+	if len(q.Level) > 0 && logLevelSeverities[entry.Level] < logLevelSeverities[q.Level] {
+		return false
+	}
+
+	if (q.Since > 0 || q.Until > 0) && entry.Timestamp == 0 {
+		return false
+	}
+
+	if q.Since > 0 && entry.Timestamp < q.Since {
+		return false
+	}
+
+	if q.Until > 0 && entry.Timestamp > q.Until {
+		return false
+	}
+
+	return true
+}
+
+func (q *LogQuery) ToJson() string {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func LogQueryFromJson(data io.Reader) *LogQuery {
+	var query LogQuery
+	if err := json.NewDecoder(data).Decode(&query); err == nil {
+		return &query
+	}
+
+	return nil
+}
+
+func (p *LogPage) ToJson() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func LogPageFromJson(data io.Reader) *LogPage {
+	var page LogPage
+	if err := json.NewDecoder(data).Decode(&page); err == nil {
+		return &page
+	}
+
+	return nil
+}
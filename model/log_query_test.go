@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogQueryIsValid(t *testing.T) {
+	query := &LogQuery{Limit: 100}
+	if err := query.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	query.Level = "VERBOSE"
+	if err := query.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	query.Level = LOG_LEVEL_WARN
+	query.Since = 2000
+	query.Until = 1000
+	if err := query.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	query.Until = 0
+	query.Limit = LOG_QUERY_MAX_LIMIT + 1
+	if err := query.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	query.Limit = LOG_QUERY_MAX_LIMIT
+	if err := query.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if received := LogQueryFromJson(strings.NewReader(query.ToJson())); received == nil || *received != *query {
+		t.Fatal("json didn't round trip")
+	}
+}
+
+func TestLogQueryMatches(t *testing.T) {
+	query := &LogQuery{Level: LOG_LEVEL_WARN, Limit: 100}
+
+	if query.Matches(&LogEntry{Level: LOG_LEVEL_INFO, Timestamp: 1000}) {
+		t.Fatal("shouldn't match a lower level")
+	}
+
+	if !query.Matches(&LogEntry{Level: LOG_LEVEL_WARN, Timestamp: 1000}) || !query.Matches(&LogEntry{Level: LOG_LEVEL_CRITICAL, Timestamp: 1000}) {
+		t.Fatal("should match the level and above")
+	}
+
+	query.Since = 1000
+	query.Until = 2000
+	if !query.Matches(&LogEntry{Level: LOG_LEVEL_ERROR, Timestamp: 2000}) {
+		t.Fatal("should match the end of the range")
+	}
+
+	if query.Matches(&LogEntry{Level: LOG_LEVEL_ERROR, Timestamp: 2001}) || query.Matches(&LogEntry{Level: LOG_LEVEL_ERROR, Timestamp: 999}) {
+		t.Fatal("shouldn't match outside of the range")
+	}
+
+	if query.Matches(&LogEntry{Level: LOG_LEVEL_ERROR}) {
+		t.Fatal("shouldn't match an entry without a time when there's a range")
+	}
+}
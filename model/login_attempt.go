@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	LOGIN_ATTEMPT_TYPE_USER = "user"
+	LOGIN_ATTEMPT_TYPE_IP   = "ip"
+)
+
+// LoginAttempt counts the failed logins for a single account or IP address. Once FailedAttempts
+// reaches the configured maximum, logins are refused until LockedUntil and the counter starts
+// over. Each lockout lasts twice as long as the previous one.
+type LoginAttempt struct {
+	Type           string `json:"type"`
+	Identifier     string `json:"identifier"`
+	FailedAttempts int    `json:"failed_attempts"`
+	Lockouts       int    `json:"lockouts"`
+	LastFailedAt   int64  `json:"last_failed_at"`
+	LockedUntil    int64  `json:"locked_until"`
+}
+
+func (o *LoginAttempt) IsLocked() bool {
+	return o.LockedUntil > GetMillis()
+}
+
+func (o *LoginAttempt) IsValid() *AppError {
+	if o.Type != LOGIN_ATTEMPT_TYPE_USER && o.Type != LOGIN_ATTEMPT_TYPE_IP {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.type.app_error", nil, "type="+o.Type)
+	}
+
+	if len(o.Identifier) == 0 || len(o.Identifier) > 64 {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.identifier.app_error", nil, "type="+o.Type)
+	}
+
+	if o.LastFailedAt == 0 {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.last_failed_at.app_error", nil, "type="+o.Type+", identifier="+o.Identifier)
+	}
+
+	return nil
+}
+
+func (o *LoginAttempt) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LoginAttemptFromJson(data io.Reader) *LoginAttempt {
+	decoder := json.NewDecoder(data)
+	var o LoginAttempt
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoginAttemptJson(t *testing.T) {
+	o := LoginAttempt{Type: LOGIN_ATTEMPT_TYPE_USER, Identifier: NewId(), FailedAttempts: 2}
+	json := o.ToJson()
+	ro := LoginAttemptFromJson(strings.NewReader(json))
+
+	if ro.Identifier != o.Identifier || ro.FailedAttempts != o.FailedAttempts {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestLoginAttemptIsValid(t *testing.T) {
+	o := LoginAttempt{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = LOGIN_ATTEMPT_TYPE_IP
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Identifier = strings.Repeat("1", 65)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Identifier = "127.0.0.1"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.LastFailedAt = GetMillis()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.IsLocked() {
+		t.Fatal("shouldn't be locked")
+	}
+
+	o.LockedUntil = GetMillis() + 60000
+	if !o.IsLocked() {
+		t.Fatal("should be locked")
+	}
+}
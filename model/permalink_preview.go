@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	PERMALINK_PREVIEW_MESSAGE_MAX_RUNES = 300
+	PERMALINK_PREVIEW_MAX_PER_POST      = 5
+)
+
+var permalinkPostIdPattern = regexp.MustCompile(`^/[A-Za-z0-9_\-]+/pl/([A-Za-z0-9]{26})\b`)
+
+// PermalinkPreview is a snippet of a post that's linked to from another post on the same server, saved when the
+// linking post is created or edited so that it can be shown alongside it. It's only returned to users who can read
+// the channel that the linked post is in.
+type PermalinkPreview struct {
+	PostId          string `json:"post_id"`
+	LinkedPostId    string `json:"linked_post_id"`
+	LinkedChannelId string `json:"linked_channel_id"`
+	LinkedUserId    string `json:"linked_user_id"`
+	LinkedCreateAt  int64  `json:"linked_create_at"`
+	Message         string `json:"message"`
+	UpdateAt        int64  `json:"update_at"`
+}
+
+// NewPermalinkPreview creates the preview of a linked post that's shown alongside the post with the given id.
+func NewPermalinkPreview(postId string, linked *Post) *PermalinkPreview {
+	return &PermalinkPreview{
+		PostId:          postId,
+		LinkedPostId:    linked.Id,
+		LinkedChannelId: linked.ChannelId,
+		LinkedUserId:    linked.UserId,
+		LinkedCreateAt:  linked.CreateAt,
+		Message:         PermalinkPreviewMessage(linked.Message),
+	}
+}
+
+// PermalinkPreviewMessage shortens a message to the length shown in a preview.
+func PermalinkPreviewMessage(message string) string {
+	if utf8.RuneCountInString(message) <= PERMALINK_PREVIEW_MESSAGE_MAX_RUNES {
+		return message
+	}
+
+	return string([]rune(message)[:PERMALINK_PREVIEW_MESSAGE_MAX_RUNES]) + "..."
+}
+
+// ParsePermalinkPostIds returns the ids of the posts that a message links to with permalinks to the given site, up to
+// the number that are previewed for each post.
+func ParsePermalinkPostIds(message string, siteURL string) []string {
+	siteURL = strings.TrimRight(siteURL, "/")
+	if len(siteURL) == 0 {
+		return []string{}
+	}
+
+	postIds := []string{}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(message, siteURL)[1:] {
+		if match := permalinkPostIdPattern.FindStringSubmatch(part); match != nil && !seen[match[1]] {
+			seen[match[1]] = true
+			postIds = append(postIds, match[1])
+
+			if len(postIds) == PERMALINK_PREVIEW_MAX_PER_POST {
+				break
+			}
+		}
+	}
+
+	return postIds
+}
+
+func (o *PermalinkPreview) PreSave() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *PermalinkPreview) IsValid() *AppError {
+	if len(o.PostId) != 26 {
+		return NewLocAppError("PermalinkPreview.IsValid", "model.permalink_preview.is_valid.post_id.app_error", nil, "")
+	}
+
+	if len(o.LinkedPostId) != 26 {
+		return NewLocAppError("PermalinkPreview.IsValid", "model.permalink_preview.is_valid.linked_post_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if len(o.LinkedChannelId) != 26 {
+		return NewLocAppError("PermalinkPreview.IsValid", "model.permalink_preview.is_valid.linked_channel_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if len(o.LinkedUserId) != 26 {
+		return NewLocAppError("PermalinkPreview.IsValid", "model.permalink_preview.is_valid.linked_user_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("PermalinkPreview.IsValid", "model.permalink_preview.is_valid.update_at.app_error", nil, "post_id="+o.PostId)
+	}
+
+	return nil
+}
+
+func PermalinkPreviewsToJson(o []*PermalinkPreview) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func PermalinkPreviewsFromJson(data io.Reader) []*PermalinkPreview {
+	decoder := json.NewDecoder(data)
+	var o []*PermalinkPreview
+	if err := decoder.Decode(&o); err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPermalinkPreviewsJson(t *testing.T) {
+	o := []*PermalinkPreview{{PostId: NewId(), LinkedPostId: NewId(), Message: "message"}}
+	json := PermalinkPreviewsToJson(o)
+	ro := PermalinkPreviewsFromJson(strings.NewReader(json))
+
+	if len(ro) != 1 || ro[0].PostId != o[0].PostId || ro[0].LinkedPostId != o[0].LinkedPostId || ro[0].Message != o[0].Message {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestPermalinkPreviewIsValid(t *testing.T) {
+	o := NewPermalinkPreview(NewId(), &Post{Id: NewId(), ChannelId: NewId(), UserId: NewId(), Message: "message"})
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.LinkedChannelId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestPermalinkPreviewMessage(t *testing.T) {
+	if message := PermalinkPreviewMessage("short"); message != "short" {
+		t.Fatal("shouldn't have changed a short message")
+	}
+
+	long := strings.Repeat("字", PERMALINK_PREVIEW_MESSAGE_MAX_RUNES+10)
+	if message := PermalinkPreviewMessage(long); utf8.RuneCountInString(message) != PERMALINK_PREVIEW_MESSAGE_MAX_RUNES+3 || !strings.HasSuffix(message, "...") {
+		t.Fatal("should have shortened a long message", message)
+	}
+}
+
+func TestParsePermalinkPostIds(t *testing.T) {
+	id1 := NewId()
+	id2 := NewId()
+
+	message := "see http://localhost:8065/team/pl/" + id1 + " and (http://localhost:8065/other-team/pl/" + id2 + ") again http://localhost:8065/team/pl/" + id1
+	if postIds := ParsePermalinkPostIds(message, "http://localhost:8065/"); len(postIds) != 2 || postIds[0] != id1 || postIds[1] != id2 {
+		t.Fatal("should have found both linked posts once", postIds)
+	}
+
+	if postIds := ParsePermalinkPostIds("http://example.com/team/pl/"+id1, "http://localhost:8065"); len(postIds) != 0 {
+		t.Fatal("shouldn't have found links to other sites")
+	}
+
+	if postIds := ParsePermalinkPostIds("http://localhost:8065/team/pl/"+id1+"x", "http://localhost:8065"); len(postIds) != 0 {
+		t.Fatal("shouldn't have found an invalid post id")
+	}
+
+	if postIds := ParsePermalinkPostIds("http://localhost:8065/team/pl/"+id1, ""); len(postIds) != 0 {
+		t.Fatal("shouldn't have found links without a site url")
+	}
+
+	message = ""
+	for i := 0; i < PERMALINK_PREVIEW_MAX_PER_POST+1; i++ {
+		message += " http://localhost:8065/team/pl/" + NewId()
+	}
+	if postIds := ParsePermalinkPostIds(message, "http://localhost:8065"); len(postIds) != PERMALINK_PREVIEW_MAX_PER_POST {
+		t.Fatal("should have limited the number of linked posts", len(postIds))
+	}
+}
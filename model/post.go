@@ -32,6 +32,14 @@ const (
 	POST_PROPS_MAX_RUNES       = 8000
 )
 
+// Props set on a post that was forwarded from another channel to record where it was originally posted
+const (
+	POST_PROPS_FORWARDED_POST_ID    = "forwarded_post_id"
+	POST_PROPS_FORWARDED_CHANNEL_ID = "forwarded_channel_id"
+	POST_PROPS_FORWARDED_USER_ID    = "forwarded_user_id"
+	POST_PROPS_FORWARDED_CREATE_AT  = "forwarded_create_at"
+)
+
 type Post struct {
 	Id            string          `json:"id"`
 	CreateAt      int64           `json:"create_at"`
@@ -51,6 +59,12 @@ type Post struct {
 	FileIds       StringArray     `json:"file_ids,omitempty"`
 	PendingPostId string          `json:"pending_post_id" db:"-"`
 	HasReactions  bool            `json:"has_reactions,omitempty"`
+	FileCount     int64           `json:"file_count,omitempty"`
+	HasImage      bool            `json:"has_image,omitempty"`
+
+	// PermalinkPreviews are the previews of the posts that this one links to. They're only filled in when posts are
+	// returned to a user, since they depend on which channels that user can read.
+	PermalinkPreviews []*PermalinkPreview `json:"permalink_previews,omitempty" db:"-"`
 }
 
 func (o *Post) ToJson() string {
@@ -171,6 +185,12 @@ func (o *Post) PreSave() {
 	if o.FileIds == nil {
 		o.FileIds = []string{}
 	}
+
+	// these are kept up to date by the FileInfo store as files are attached to the post
+	o.FileCount = 0
+	o.HasImage = false
+
+	o.PermalinkPreviews = nil
 }
 
 func (o *Post) MakeNonNil() {
@@ -189,3 +209,20 @@ func (o *Post) AddProp(key string, value interface{}) {
 func (o *Post) IsSystemMessage() bool {
 	return len(o.Type) >= len(POST_SYSTEM_MESSAGE_PREFIX) && o.Type[:len(POST_SYSTEM_MESSAGE_PREFIX)] == POST_SYSTEM_MESSAGE_PREFIX
 }
+
+// IsForwarded returns true if the post is a copy of one that was forwarded from another channel.
+func (o *Post) IsForwarded() bool {
+	forwardedPostId, _ := o.Props[POST_PROPS_FORWARDED_POST_ID].(string)
+	return len(forwardedPostId) > 0
+}
+
+// PostForIndexing is a post along with the extra information that a search backend
+// needs to index it without looking up its channel.
+type PostForIndexing struct {
+	Post
+	TeamId string `json:"team_id"`
+
+	// SegmentedMessage is the message with any CJK text split into bigrams, only set when that's enabled and the
+	// message contains some
+	SegmentedMessage string `json:"segmented_message,omitempty"`
+}
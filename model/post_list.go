@@ -6,11 +6,21 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"sort"
 )
 
 type PostList struct {
-	Order []string         `json:"order"`
-	Posts map[string]*Post `json:"posts"`
+	Order       []string                 `json:"order"`
+	Posts       map[string]*Post         `json:"posts"`
+	Matches     map[string][]SearchMatch `json:"matches,omitempty"`      // only set for search results
+	FileMatches map[string][]SearchMatch `json:"file_matches,omitempty"` // only set for searches by file name
+}
+
+// SearchMatch is the part of a post's message or a file's name that matched a search, given as byte offsets into it so
+// that clients can highlight it without repeating the search engine's matching.
+type SearchMatch struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 func (o *PostList) ToJson() string {
@@ -54,12 +64,65 @@ func (o *PostList) AddPost(post *Post) {
 	o.Posts[post.Id] = post
 }
 
+// AddSearchMatches records where a search matched a post's message. Matches are kept in order and any that overlap
+// are combined.
+func (o *PostList) AddSearchMatches(postId string, matches []SearchMatch) {
+	if len(matches) == 0 {
+		return
+	}
+
+	if o.Matches == nil {
+		o.Matches = make(map[string][]SearchMatch)
+	}
+
+	o.Matches[postId] = combineSearchMatches(o.Matches[postId], matches)
+}
+
+// AddFileSearchMatches records where a search matched the name of one of the files attached to the posts.
+func (o *PostList) AddFileSearchMatches(fileId string, matches []SearchMatch) {
+	if len(matches) == 0 {
+		return
+	}
+
+	if o.FileMatches == nil {
+		o.FileMatches = make(map[string][]SearchMatch)
+	}
+
+	o.FileMatches[fileId] = combineSearchMatches(o.FileMatches[fileId], matches)
+}
+
+func combineSearchMatches(existing []SearchMatch, matches []SearchMatch) []SearchMatch {
+	all := append(append([]SearchMatch{}, existing...), matches...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Start < all[j].Start
+	})
+
+	combined := all[:1]
+	for _, match := range all[1:] {
+		if last := &combined[len(combined)-1]; match.Start <= last.End {
+			if match.End > last.End {
+				last.End = match.End
+			}
+		} else {
+			combined = append(combined, match)
+		}
+	}
+
+	return combined
+}
+
 func (o *PostList) Extend(other *PostList) {
 	for _, postId := range other.Order {
 		if _, ok := o.Posts[postId]; !ok {
 			o.AddPost(other.Posts[postId])
 			o.AddOrder(postId)
 		}
+
+		o.AddSearchMatches(postId, other.Matches[postId])
+	}
+
+	for fileId, matches := range other.FileMatches {
+		o.AddFileSearchMatches(fileId, matches)
 	}
 }
 
@@ -68,3 +68,46 @@ func TestPostListExtend(t *testing.T) {
 		t.Fatal("extending l2 again changed l2")
 	}
 }
+
+func TestPostListAddSearchMatches(t *testing.T) {
+	l1 := &PostList{}
+	p1 := &Post{Id: NewId(), Message: "some message"}
+	l1.AddPost(p1)
+	l1.AddOrder(p1.Id)
+	l1.AddSearchMatches(p1.Id, []SearchMatch{{5, 12}, {0, 4}})
+
+	if matches := l1.Matches[p1.Id]; len(matches) != 2 || matches[0] != (SearchMatch{0, 4}) || matches[1] != (SearchMatch{5, 12}) {
+		t.Fatal("matches should be in order", matches)
+	}
+
+	l2 := &PostList{}
+	l2.AddPost(p1)
+	l2.AddOrder(p1.Id)
+	l2.AddSearchMatches(p1.Id, []SearchMatch{{2, 7}})
+
+	l1.Extend(l2)
+
+	if matches := l1.Matches[p1.Id]; len(matches) != 1 || matches[0] != (SearchMatch{0, 12}) {
+		t.Fatal("overlapping matches should've been combined", matches)
+	}
+
+	if received := PostListFromJson(strings.NewReader(l1.ToJson())); received.Matches[p1.Id][0] != (SearchMatch{0, 12}) {
+		t.Fatal("matches should be included in json")
+	}
+
+	fileId := NewId()
+	l2.AddFileSearchMatches(fileId, []SearchMatch{{4, 8}})
+	l1.Extend(l2)
+
+	if matches := l1.FileMatches[fileId]; len(matches) != 1 || matches[0] != (SearchMatch{4, 8}) {
+		t.Fatal("file matches should've been added", matches)
+	}
+
+	if received := PostListFromJson(strings.NewReader(l1.ToJson())); received.FileMatches[fileId][0] != (SearchMatch{4, 8}) {
+		t.Fatal("file matches should be included in json")
+	}
+
+	if json := (&PostList{}).ToJson(); strings.Contains(json, "matches") {
+		t.Fatal("matches shouldn't be included in json when there aren't any")
+	}
+}
@@ -113,3 +113,17 @@ func TestPostIsSystemMessage(t *testing.T) {
 		t.Fatalf("TestPostIsSystemMessage failed, expected post2.IsSystemMessage() to be true")
 	}
 }
+
+func TestPostIsForwarded(t *testing.T) {
+	post := Post{Message: "test"}
+	post.PreSave()
+
+	if post.IsForwarded() {
+		t.Fatal("shouldn't be forwarded without the forwarded props")
+	}
+
+	post.AddProp(POST_PROPS_FORWARDED_POST_ID, NewId())
+	if !post.IsForwarded() {
+		t.Fatal("should be forwarded")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+var translationLocalePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8}){0,2}$`)
+
+// PostTranslation is a post's message translated into another language. It's saved the first time that someone asks
+// for the post in that language and reused until the post is edited, which is when its EditAt no longer matches.
+type PostTranslation struct {
+	PostId       string `json:"post_id"`
+	Locale       string `json:"locale"`
+	SourceLocale string `json:"source_locale"`
+	Message      string `json:"message"`
+	PostEditAt   int64  `json:"post_edit_at"`
+	CreateAt     int64  `json:"create_at"`
+}
+
+func (o *PostTranslation) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostTranslationFromJson(data io.Reader) *PostTranslation {
+	decoder := json.NewDecoder(data)
+	var o PostTranslation
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func (o *PostTranslation) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *PostTranslation) IsValid() *AppError {
+	if len(o.PostId) != 26 {
+		return NewLocAppError("PostTranslation.IsValid", "model.post_translation.is_valid.post_id.app_error", nil, "")
+	}
+
+	if !IsValidTranslationLocale(o.Locale) {
+		return NewLocAppError("PostTranslation.IsValid", "model.post_translation.is_valid.locale.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if len(o.SourceLocale) > 0 && !IsValidTranslationLocale(o.SourceLocale) {
+		return NewLocAppError("PostTranslation.IsValid", "model.post_translation.is_valid.source_locale.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("PostTranslation.IsValid", "model.post_translation.is_valid.create_at.app_error", nil, "post_id="+o.PostId)
+	}
+
+	return nil
+}
+
+// IsValidTranslationLocale returns true if a locale looks like a language tag, such as "fr" or "zh-TW".
+func IsValidTranslationLocale(locale string) bool {
+	return translationLocalePattern.MatchString(locale)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostTranslationIsValid(t *testing.T) {
+	translation := &PostTranslation{PostId: NewId(), Locale: "fr", Message: "bonjour"}
+	translation.PreSave()
+
+	if err := translation.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	translation.SourceLocale = "not a locale"
+	if err := translation.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	translation.SourceLocale = "en"
+	if err := translation.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if rtranslation := PostTranslationFromJson(strings.NewReader(translation.ToJson())); rtranslation.Message != translation.Message {
+		t.Fatal("should have round tripped")
+	}
+}
+
+func TestIsValidTranslationLocale(t *testing.T) {
+	for _, locale := range []string{"en", "fr", "pt-BR", "zh_TW", "zh-Hant-TW", "haw"} {
+		if !IsValidTranslationLocale(locale) {
+			t.Fatal("should be valid " + locale)
+		}
+	}
+
+	for _, locale := range []string{"", "e", "english", "en-", "en US", "../en", "en-US-x-private-y"} {
+		if IsValidTranslationLocale(locale) {
+			t.Fatal("should be invalid " + locale)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	PROFILE_IMAGE_THUMBNAIL_SIZE = 64
+)
+
+// ProfileImage is one of the profile pictures that a user has uploaded. The picture itself is stored as a FileInfo,
+// with its Path pointing to the picture resized to the configured profile size and its ThumbnailPath pointing to a
+// smaller version of it. The user's current picture is the one that was most recently made active.
+type ProfileImage struct {
+	FileId   string `json:"file_id"`
+	UserId   string `json:"user_id"`
+	CreateAt int64  `json:"create_at"`
+	ActiveAt int64  `json:"active_at"`
+}
+
+func (o *ProfileImage) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.ActiveAt == 0 {
+		o.ActiveAt = o.CreateAt
+	}
+}
+
+func (o *ProfileImage) IsValid() *AppError {
+	if len(o.FileId) != 26 {
+		return NewLocAppError("ProfileImage.IsValid", "model.profile_image.is_valid.file_id.app_error", nil, "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("ProfileImage.IsValid", "model.profile_image.is_valid.user_id.app_error", nil, "file_id="+o.FileId)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ProfileImage.IsValid", "model.profile_image.is_valid.create_at.app_error", nil, "file_id="+o.FileId)
+	}
+
+	if o.ActiveAt == 0 {
+		return NewLocAppError("ProfileImage.IsValid", "model.profile_image.is_valid.active_at.app_error", nil, "file_id="+o.FileId)
+	}
+
+	return nil
+}
+
+func ProfileImagesToJson(o []*ProfileImage) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ProfileImagesFromJson(data io.Reader) []*ProfileImage {
+	decoder := json.NewDecoder(data)
+	var o []*ProfileImage
+	if err := decoder.Decode(&o); err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
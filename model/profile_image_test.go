@@ -0,0 +1,42 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfileImagesJson(t *testing.T) {
+	o := &ProfileImage{FileId: NewId(), UserId: NewId(), CreateAt: 1000, ActiveAt: 2000}
+	list := ProfileImagesFromJson(strings.NewReader(ProfileImagesToJson([]*ProfileImage{o})))
+
+	if len(list) != 1 || list[0].FileId != o.FileId || list[0].UserId != o.UserId || list[0].ActiveAt != o.ActiveAt {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestProfileImageIsValid(t *testing.T) {
+	o := ProfileImage{FileId: NewId(), UserId: NewId()}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.ActiveAt != o.CreateAt {
+		t.Fatal("a new picture should be active from when it was created")
+	}
+
+	o.UserId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.FileId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
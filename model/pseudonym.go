@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	PSEUDONYM_PREFIX       = "user_"
+	PSEUDONYM_EMAIL_DOMAIN = "pseudonymized.invalid"
+)
+
+// Pseudonym is the opaque identifier that replaces a user's username and email address in anonymized exports. A
+// user keeps the same pseudonym in every export so that datasets can be joined without identifying anyone.
+type Pseudonym struct {
+	UserId    string `json:"user_id"`
+	Pseudonym string `json:"pseudonym"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+func (o *Pseudonym) PreSave() {
+	if o.Pseudonym == "" {
+		o.Pseudonym = PSEUDONYM_PREFIX + NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *Pseudonym) IsValid() *AppError {
+	if len(o.UserId) != 26 {
+		return NewLocAppError("Pseudonym.IsValid", "model.pseudonym.is_valid.user_id.app_error", nil, "")
+	}
+
+	if !strings.HasPrefix(o.Pseudonym, PSEUDONYM_PREFIX) || !IsValidUsername(o.Pseudonym) {
+		return NewLocAppError("Pseudonym.IsValid", "model.pseudonym.is_valid.pseudonym.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("Pseudonym.IsValid", "model.pseudonym.is_valid.create_at.app_error", nil, "user_id="+o.UserId)
+	}
+
+	return nil
+}
+
+// Email returns the address that replaces the user's email address. It uses a reserved domain so that it can never
+// be delivered to.
+func (o *Pseudonym) Email() string {
+	return o.Pseudonym + "@" + PSEUDONYM_EMAIL_DOMAIN
+}
+
+func PseudonymListToJson(l []*Pseudonym) string {
+	if b, err := json.Marshal(l); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PseudonymListFromJson(data io.Reader) []*Pseudonym {
+	decoder := json.NewDecoder(data)
+	var o []*Pseudonym
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return o
+	}
+}
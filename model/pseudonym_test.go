@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPseudonymIsValid(t *testing.T) {
+	o := &Pseudonym{UserId: NewId()}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(o.Email(), "@"+PSEUDONYM_EMAIL_DOMAIN) || !IsValidEmail(o.Email()) {
+		t.Fatal("should have a valid email address", o.Email())
+	}
+
+	o.Pseudonym = "someone"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestPseudonymListJson(t *testing.T) {
+	o := &Pseudonym{UserId: NewId()}
+	o.PreSave()
+
+	l := PseudonymListFromJson(strings.NewReader(PseudonymListToJson([]*Pseudonym{o})))
+	if len(l) != 1 || *l[0] != *o {
+		t.Fatal("pseudonyms do not match")
+	}
+}
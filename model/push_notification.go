@@ -33,6 +33,7 @@ type PushNotification struct {
 	TeamId           string `json:"team_id"`
 	ChannelId        string `json:"channel_id"`
 	ChannelName      string `json:"channel_name"`
+	PostId           string `json:"post_id"`
 	Type             string `json:"type"`
 }
 
@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// RetentionPolicy overrides the global data retention settings for a single team or
+// channel. A channel's policy takes precedence over its team's policy. A retention period
+// of 0 days keeps the messages or files forever.
+type RetentionPolicy struct {
+	Id                   string `json:"id"`
+	DisplayName          string `json:"display_name"`
+	TeamId               string `json:"team_id"`
+	ChannelId            string `json:"channel_id"`
+	MessageRetentionDays int    `json:"message_retention_days"`
+	FileRetentionDays    int    `json:"file_retention_days"`
+	CreateAt             int64  `json:"create_at"`
+	UpdateAt             int64  `json:"update_at"`
+}
+
+// RetentionScope selects the posts that a single retention period applies to. A scope
+// without a team or channel covers every channel, including direct and group messages,
+// that isn't excluded.
+type RetentionScope struct {
+	ChannelId         string
+	TeamId            string
+	ExcludeChannelIds []string
+	ExcludeTeamIds    []string
+}
+
+// RetentionPreview holds the number of posts and files that would be deleted by a
+// retention policy if the retention job were to run now.
+type RetentionPreview struct {
+	PostCount int64 `json:"post_count"`
+	FileCount int64 `json:"file_count"`
+}
+
+func (o *RetentionPolicy) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *RetentionPolicy) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *RetentionPolicy) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.update_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.DisplayName) == 0 || len(o.DisplayName) > 64 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.display_name.app_error", nil, "id="+o.Id)
+	}
+
+	if (len(o.TeamId) == 0) == (len(o.ChannelId) == 0) {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.target.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 0 && len(o.TeamId) != 26 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 0 && len(o.ChannelId) != 26 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.channel_id.app_error", nil, "id="+o.Id)
+	}
+
+	if o.MessageRetentionDays < 0 || o.FileRetentionDays < 0 {
+		return NewLocAppError("RetentionPolicy.IsValid", "model.retention_policy.is_valid.retention_days.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *RetentionPolicy) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func RetentionPolicyFromJson(data io.Reader) *RetentionPolicy {
+	decoder := json.NewDecoder(data)
+	var o RetentionPolicy
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func RetentionPolicyListToJson(list []*RetentionPolicy) string {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func RetentionPolicyListFromJson(data io.Reader) []*RetentionPolicy {
+	decoder := json.NewDecoder(data)
+	var list []*RetentionPolicy
+	err := decoder.Decode(&list)
+	if err == nil {
+		return list
+	} else {
+		return nil
+	}
+}
+
+func (o *RetentionPreview) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func RetentionPreviewFromJson(data io.Reader) *RetentionPreview {
+	decoder := json.NewDecoder(data)
+	var o RetentionPreview
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// GetRetentionScope returns the scope of a policy given the rest of the policies on the
+// system. A team's policy doesn't apply to the channels that have their own policy.
+func (o *RetentionPolicy) GetRetentionScope(policies []*RetentionPolicy) *RetentionScope {
+	if len(o.ChannelId) > 0 {
+		return &RetentionScope{ChannelId: o.ChannelId}
+	}
+
+	scope := &RetentionScope{TeamId: o.TeamId, ExcludeChannelIds: []string{}}
+	for _, policy := range policies {
+		if len(policy.ChannelId) > 0 && policy.Id != o.Id {
+			scope.ExcludeChannelIds = append(scope.ExcludeChannelIds, policy.ChannelId)
+		}
+	}
+
+	return scope
+}
+
+// GetGlobalRetentionScope returns the scope of the global retention settings, which apply
+// to every team and channel that doesn't have its own policy.
+func GetGlobalRetentionScope(policies []*RetentionPolicy) *RetentionScope {
+	scope := &RetentionScope{ExcludeChannelIds: []string{}, ExcludeTeamIds: []string{}}
+	for _, policy := range policies {
+		if len(policy.ChannelId) > 0 {
+			scope.ExcludeChannelIds = append(scope.ExcludeChannelIds, policy.ChannelId)
+		} else {
+			scope.ExcludeTeamIds = append(scope.ExcludeTeamIds, policy.TeamId)
+		}
+	}
+
+	return scope
+}
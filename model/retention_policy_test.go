@@ -0,0 +1,92 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRetentionPolicyJson(t *testing.T) {
+	o := RetentionPolicy{Id: NewId(), DisplayName: "Legal", TeamId: NewId(), MessageRetentionDays: 30}
+	json := o.ToJson()
+	ro := RetentionPolicyFromJson(strings.NewReader(json))
+
+	if ro.Id != o.Id || ro.TeamId != o.TeamId || ro.MessageRetentionDays != o.MessageRetentionDays {
+		t.Fatal("Ids do not match")
+	}
+
+	list := RetentionPolicyListFromJson(strings.NewReader(RetentionPolicyListToJson([]*RetentionPolicy{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("list did not round trip")
+	}
+
+	preview := RetentionPreview{PostCount: 3, FileCount: 1}
+	rpreview := RetentionPreviewFromJson(strings.NewReader(preview.ToJson()))
+	if rpreview.PostCount != 3 || rpreview.FileCount != 1 {
+		t.Fatal("preview did not round trip")
+	}
+}
+
+func TestRetentionPolicyIsValid(t *testing.T) {
+	o := RetentionPolicy{DisplayName: "Legal", MessageRetentionDays: 30}
+	o.PreSave()
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should require a team or channel")
+	}
+
+	o.TeamId = NewId()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.ChannelId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("shouldn't allow both a team and a channel")
+	}
+
+	o.TeamId = ""
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.FileRetentionDays = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("shouldn't allow negative retention")
+	}
+
+	o.FileRetentionDays = 0
+	o.DisplayName = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should require a display name")
+	}
+}
+
+func TestGetRetentionScope(t *testing.T) {
+	teamPolicy := &RetentionPolicy{Id: NewId(), TeamId: NewId()}
+	channelPolicy := &RetentionPolicy{Id: NewId(), ChannelId: NewId()}
+	policies := []*RetentionPolicy{teamPolicy, channelPolicy}
+
+	if scope := channelPolicy.GetRetentionScope(policies); scope.ChannelId != channelPolicy.ChannelId || len(scope.ExcludeChannelIds) != 0 {
+		t.Fatal("incorrect channel scope")
+	}
+
+	if scope := teamPolicy.GetRetentionScope(policies); scope.TeamId != teamPolicy.TeamId || len(scope.ExcludeChannelIds) != 1 || scope.ExcludeChannelIds[0] != channelPolicy.ChannelId {
+		t.Fatal("team scope should exclude channels with their own policy")
+	}
+
+	scope := GetGlobalRetentionScope(policies)
+	if len(scope.TeamId) != 0 || len(scope.ChannelId) != 0 {
+		t.Fatal("global scope should cover every channel")
+	}
+
+	if len(scope.ExcludeTeamIds) != 1 || scope.ExcludeTeamIds[0] != teamPolicy.TeamId {
+		t.Fatal("global scope should exclude teams with a policy")
+	}
+
+	if len(scope.ExcludeChannelIds) != 1 || scope.ExcludeChannelIds[0] != channelPolicy.ChannelId {
+		t.Fatal("global scope should exclude channels with a policy")
+	}
+}
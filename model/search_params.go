@@ -17,9 +17,12 @@ type SearchParams struct {
 	InChannels []string
 	FromUsers  []string
 	OrTerms    bool
+	HasFile    bool
+	HasImage   bool
+	FileNames  []string
 }
 
-var searchFlags = [...]string{"from", "channel", "in"}
+var searchFlags = [...]string{"from", "channel", "in", "has", "file"}
 
 func splitWordsNoQuotes(text string) []string {
 	words := []string{}
@@ -129,6 +132,9 @@ func ParseSearchParams(text string) []*SearchParams {
 
 	inChannels := []string{}
 	fromUsers := []string{}
+	hasFile := false
+	hasImage := false
+	fileNames := []string{}
 
 	for _, flagPair := range flags {
 		flag := flagPair[0]
@@ -138,6 +144,15 @@ func ParseSearchParams(text string) []*SearchParams {
 			inChannels = append(inChannels, value)
 		} else if flag == "from" {
 			fromUsers = append(fromUsers, value)
+		} else if flag == "has" {
+			switch strings.ToLower(value) {
+			case "file", "files", "attachment", "attachments":
+				hasFile = true
+			case "image", "images":
+				hasImage = true
+			}
+		} else if flag == "file" {
+			fileNames = append(fileNames, value)
 		}
 	}
 
@@ -149,6 +164,9 @@ func ParseSearchParams(text string) []*SearchParams {
 			IsHashtag:  false,
 			InChannels: inChannels,
 			FromUsers:  fromUsers,
+			HasFile:    hasFile,
+			HasImage:   hasImage,
+			FileNames:  fileNames,
 		})
 	}
 
@@ -158,16 +176,22 @@ func ParseSearchParams(text string) []*SearchParams {
 			IsHashtag:  true,
 			InChannels: inChannels,
 			FromUsers:  fromUsers,
+			HasFile:    hasFile,
+			HasImage:   hasImage,
+			FileNames:  fileNames,
 		})
 	}
 
 	// special case for when no terms are specified but we still have a filter
-	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && (len(inChannels) != 0 || len(fromUsers) != 0) {
+	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && (len(inChannels) != 0 || len(fromUsers) != 0 || hasFile || hasImage || len(fileNames) != 0) {
 		paramsList = append(paramsList, &SearchParams{
 			Terms:      "",
 			IsHashtag:  true,
 			InChannels: inChannels,
 			FromUsers:  fromUsers,
+			HasFile:    hasFile,
+			HasImage:   hasImage,
+			FileNames:  fileNames,
 		})
 	}
 
@@ -220,4 +220,24 @@ func TestParseSearchParams(t *testing.T) {
 	if sp := ParseSearchParams("wildcar*"); len(sp) != 1 || sp[0].Terms != "wildcar*" || sp[0].IsHashtag != false || len(sp[0].InChannels) != 0 || len(sp[0].FromUsers) != 0 {
 		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
 	}
+
+	if sp := ParseSearchParams("testing has:file"); len(sp) != 1 || sp[0].Terms != "testing" || !sp[0].HasFile || sp[0].HasImage {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("has:images"); len(sp) != 1 || sp[0].Terms != "" || sp[0].HasFile || !sp[0].HasImage {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("report.pdf"); len(sp) != 1 || sp[0].Terms != "report.pdf" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("testing file:report.pdf File: notes.txt"); len(sp) != 1 || sp[0].Terms != "testing" || len(sp[0].FileNames) != 2 || sp[0].FileNames[0] != "report.pdf" || sp[0].FileNames[1] != "notes.txt" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("file:report.pdf"); len(sp) != 1 || sp[0].Terms != "" || len(sp[0].FileNames) != 1 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
 }
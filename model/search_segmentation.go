@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IsCJK returns true if a character is from one of the Chinese, Japanese or Korean scripts. Text in these scripts
+// doesn't separate words with spaces the way that search backends expect.
+func IsCJK(r rune) bool {
+	// the prolonged sound mark is common to both kana scripts, so it isn't in either of them
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー'
+}
+
+func ContainsCJK(text string) bool {
+	return strings.IndexFunc(text, IsCJK) != -1
+}
+
+// SegmentCJKBigrams splits each run of CJK characters in some text into overlapping pairs of characters so that a
+// search backend that splits words on spaces can match part of the run. Everything else is left as it is. For
+// example, "東京タワー tower" becomes "東京 京タ タワ ワー tower".
+func SegmentCJKBigrams(text string) string {
+	var segments []string
+	var run []rune
+
+	endRun := func() {
+		if len(run) == 1 {
+			segments = append(segments, string(run))
+		}
+
+		for i := 1; i < len(run); i++ {
+			segments = append(segments, string(run[i-1:i+1]))
+		}
+
+		run = run[:0]
+	}
+
+	start := -1
+	for i, r := range text {
+		if IsCJK(r) {
+			if start != -1 {
+				segments = append(segments, text[start:i])
+				start = -1
+			}
+
+			run = append(run, r)
+		} else {
+			endRun()
+
+			if unicode.IsSpace(r) {
+				if start != -1 {
+					segments = append(segments, text[start:i])
+					start = -1
+				}
+			} else if start == -1 {
+				start = i
+			}
+		}
+	}
+
+	endRun()
+
+	if start != -1 {
+		segments = append(segments, text[start:])
+	}
+
+	return strings.Join(segments, " ")
+}
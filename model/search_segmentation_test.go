@@ -0,0 +1,41 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestContainsCJK(t *testing.T) {
+	for _, text := range []string{"東京", "ひらがな", "カタカナ", "한국어", "word 中文"} {
+		if !ContainsCJK(text) {
+			t.Fatalf("%v should contain CJK characters", text)
+		}
+	}
+
+	for _, text := range []string{"", "word", "café", "Привет"} {
+		if ContainsCJK(text) {
+			t.Fatalf("%v shouldn't contain CJK characters", text)
+		}
+	}
+}
+
+func TestSegmentCJKBigrams(t *testing.T) {
+	for text, expected := range map[string]string{
+		"":                 "",
+		"hello world":      "hello world",
+		"中":                "中",
+		"中文":               "中文",
+		"東京タワー":            "東京 京タ タワ ワー",
+		"東京タワー tower":      "東京 京タ タワ ワー tower",
+		"go言語で書く":          "go 言語 語で で書 書く",
+		"서울에서 만나요":         "서울 울에 에서 만나 나요",
+		"one 二 three":      "one 二 three",
+		"  spaced   out  ": "spaced out",
+	} {
+		if actual := SegmentCJKBigrams(text); actual != expected {
+			t.Fatalf("%v should've been segmented as %v, got %v", text, expected, actual)
+		}
+	}
+}
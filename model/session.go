@@ -6,6 +6,7 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -137,3 +138,26 @@ func SessionsFromJson(data io.Reader) []*Session {
 		return nil
 	}
 }
+
+func (me *Session) IsUserAccessToken() bool {
+	return me.Props[SESSION_PROP_TYPE] == SESSION_TYPE_USER_ACCESS_TOKEN
+}
+
+// GetUserAccessTokenScopes returns the scopes of the personal access token that the session was created for. A session
+// that wasn't created for a token, or one created for a token without scopes, has none and isn't limited by them.
+func (me *Session) GetUserAccessTokenScopes() []string {
+	if !me.IsUserAccessToken() {
+		return []string{}
+	}
+
+	return strings.Fields(me.Props[SESSION_PROP_USER_ACCESS_TOKEN_SCOPES])
+}
+
+func (me *Session) GetUserAccessTokenRateLimit() int {
+	if !me.IsUserAccessToken() {
+		return 0
+	}
+
+	limit, _ := strconv.Atoi(me.Props[SESSION_PROP_USER_ACCESS_TOKEN_RATE_LIMIT])
+	return limit
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	TEAM_DEFAULT_CHANNELS_MAX = 20
+)
+
+// TeamDefaultChannel is a channel that new members of a team are added to when they join it, in addition to Town
+// Square and Off-Topic.
+type TeamDefaultChannel struct {
+	TeamId    string `json:"team_id"`
+	ChannelId string `json:"channel_id"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+func (o *TeamDefaultChannel) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *TeamDefaultChannel) IsValid() *AppError {
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("TeamDefaultChannel.IsValid", "model.team_default_channel.is_valid.team_id.app_error", nil, "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("TeamDefaultChannel.IsValid", "model.team_default_channel.is_valid.channel_id.app_error", nil, "team_id="+o.TeamId)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("TeamDefaultChannel.IsValid", "model.team_default_channel.is_valid.create_at.app_error", nil, "team_id="+o.TeamId)
+	}
+
+	return nil
+}
+
+func TeamDefaultChannelListToJson(l []*TeamDefaultChannel) string {
+	if b, err := json.Marshal(l); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func TeamDefaultChannelListFromJson(data io.Reader) []*TeamDefaultChannel {
+	decoder := json.NewDecoder(data)
+	var o []*TeamDefaultChannel
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return o
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// TeamInviteLink is a reusable invite link to a team. Its Id is the token that is used in the
+// link. A link stops working once it has been used MaxUses times, once it has expired or once
+// it has been replaced by a new link. A MaxUses or ExpiresAt of 0 means that there's no limit.
+// UseCount is kept after a link stops working so that it records how many users joined through it.
+type TeamInviteLink struct {
+	Id        string `json:"id"`
+	TeamId    string `json:"team_id"`
+	CreatorId string `json:"creator_id"`
+	CreateAt  int64  `json:"create_at"`
+	DeleteAt  int64  `json:"delete_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	MaxUses   int64  `json:"max_uses"`
+	UseCount  int64  `json:"use_count"`
+}
+
+func (o *TeamInviteLink) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.DeleteAt = 0
+	o.UseCount = 0
+}
+
+func (o *TeamInviteLink) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CreatorId) != 26 {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.creator_id.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.ExpiresAt != 0 && o.ExpiresAt <= o.CreateAt {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.expires_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.MaxUses < 0 {
+		return NewLocAppError("TeamInviteLink.IsValid", "model.team_invite_link.is_valid.max_uses.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *TeamInviteLink) IsExpired() bool {
+	return o.ExpiresAt != 0 && o.ExpiresAt <= GetMillis()
+}
+
+func (o *TeamInviteLink) IsUsedUp() bool {
+	return o.MaxUses != 0 && o.UseCount >= o.MaxUses
+}
+
+// IsActive returns true if the link can still be used to join its team.
+func (o *TeamInviteLink) IsActive() bool {
+	return o.DeleteAt == 0 && !o.IsExpired() && !o.IsUsedUp()
+}
+
+func (o *TeamInviteLink) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func TeamInviteLinkFromJson(data io.Reader) *TeamInviteLink {
+	decoder := json.NewDecoder(data)
+	var o TeamInviteLink
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func TeamInviteLinkListToJson(l []*TeamInviteLink) string {
+	if b, err := json.Marshal(l); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func TeamInviteLinkListFromJson(data io.Reader) []*TeamInviteLink {
+	decoder := json.NewDecoder(data)
+	var o []*TeamInviteLink
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	} else {
+		return o
+	}
+}
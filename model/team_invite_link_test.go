@@ -0,0 +1,79 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTeamInviteLinkJson(t *testing.T) {
+	o := TeamInviteLink{Id: NewId(), TeamId: NewId(), MaxUses: 5}
+	json := o.ToJson()
+	ro := TeamInviteLinkFromJson(strings.NewReader(json))
+
+	if ro.Id != o.Id || ro.MaxUses != o.MaxUses {
+		t.Fatal("Ids do not match")
+	}
+
+	l := TeamInviteLinkListFromJson(strings.NewReader(TeamInviteLinkListToJson([]*TeamInviteLink{&o})))
+	if len(l) != 1 || l[0].Id != o.Id {
+		t.Fatal("list should have round-tripped")
+	}
+}
+
+func TestTeamInviteLinkIsValid(t *testing.T) {
+	o := TeamInviteLink{TeamId: NewId(), CreatorId: NewId(), UseCount: 3}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.UseCount != 0 {
+		t.Fatal("use count should have been reset")
+	}
+
+	o.MaxUses = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.MaxUses = 0
+	o.ExpiresAt = o.CreateAt
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ExpiresAt = 0
+	o.TeamId = "bad"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestTeamInviteLinkIsActive(t *testing.T) {
+	o := TeamInviteLink{}
+	if !o.IsActive() {
+		t.Fatal("link without limits should be active")
+	}
+
+	o.MaxUses = 2
+	o.UseCount = 2
+	if o.IsActive() || !o.IsUsedUp() {
+		t.Fatal("link should be used up")
+	}
+
+	o.UseCount = 1
+	o.ExpiresAt = GetMillis() - 1
+	if o.IsActive() || !o.IsExpired() {
+		t.Fatal("link should be expired")
+	}
+
+	o.ExpiresAt = GetMillis() + 100000
+	o.DeleteAt = GetMillis()
+	if o.IsActive() {
+		t.Fatal("deleted link shouldn't be active")
+	}
+}
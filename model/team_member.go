@@ -9,10 +9,19 @@ import (
 	"strings"
 )
 
+const (
+	TEAM_MEMBER_SORT_BY_USERNAME  = "username"
+	TEAM_MEMBER_SORT_BY_JOINED_AT = "joined_at"
+
+	// The most team members that are returned at once. Requests for more are limited to this.
+	TEAM_MEMBERS_MAX_PAGE_SIZE = 200
+)
+
 type TeamMember struct {
 	TeamId   string `json:"team_id"`
 	UserId   string `json:"user_id"`
 	Roles    string `json:"roles"`
+	CreateAt int64  `json:"create_at"`
 	DeleteAt int64  `json:"delete_at"`
 }
 
@@ -61,6 +70,10 @@ func TeamMembersFromJson(data io.Reader) []*TeamMember {
 	}
 }
 
+func IsValidTeamMemberSort(sort string) bool {
+	return sort == "" || sort == TEAM_MEMBER_SORT_BY_USERNAME || sort == TEAM_MEMBER_SORT_BY_JOINED_AT
+}
+
 func TeamsUnreadToJson(o []*TeamUnread) string {
 	if b, err := json.Marshal(o); err != nil {
 		return "[]"
@@ -93,6 +106,12 @@ func (o *TeamMember) IsValid() *AppError {
 	return nil
 }
 
+func (o *TeamMember) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
 func (o *TeamMember) PreUpdate() {
 }
 
@@ -0,0 +1,84 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ThreadMembership tracks a user's place in a thread of replies. Users follow the threads that they start or reply to,
+// and each reply made by someone else increments the UnreadReplies of everyone following the thread until they mark
+// it as read.
+type ThreadMembership struct {
+	PostId        string `json:"post_id"`
+	ChannelId     string `json:"channel_id"`
+	UserId        string `json:"user_id"`
+	Following     bool   `json:"following"`
+	LastViewedAt  int64  `json:"last_viewed_at"`
+	UnreadReplies int64  `json:"unread_replies"`
+	LastUpdateAt  int64  `json:"last_update_at"`
+}
+
+func (o *ThreadMembership) PreSave() {
+	o.LastUpdateAt = GetMillis()
+}
+
+func (o *ThreadMembership) IsValid() *AppError {
+	if len(o.PostId) != 26 {
+		return NewLocAppError("ThreadMembership.IsValid", "model.thread_membership.is_valid.post_id.app_error", nil, "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("ThreadMembership.IsValid", "model.thread_membership.is_valid.channel_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("ThreadMembership.IsValid", "model.thread_membership.is_valid.user_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if o.UnreadReplies < 0 {
+		return NewLocAppError("ThreadMembership.IsValid", "model.thread_membership.is_valid.unread_replies.app_error", nil, "post_id="+o.PostId)
+	}
+
+	return nil
+}
+
+func (o *ThreadMembership) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadMembershipFromJson(data io.Reader) *ThreadMembership {
+	decoder := json.NewDecoder(data)
+	var o ThreadMembership
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func ThreadMembershipsToJson(o []*ThreadMembership) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadMembershipsFromJson(data io.Reader) []*ThreadMembership {
+	decoder := json.NewDecoder(data)
+	var o []*ThreadMembership
+	if err := decoder.Decode(&o); err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreadMembershipJson(t *testing.T) {
+	o := ThreadMembership{PostId: NewId(), ChannelId: NewId(), UserId: NewId(), Following: true, UnreadReplies: 3}
+	json := o.ToJson()
+	ro := ThreadMembershipFromJson(strings.NewReader(json))
+
+	if ro.PostId != o.PostId || ro.UserId != o.UserId || !ro.Following || ro.UnreadReplies != 3 {
+		t.Fatal("Ids do not match")
+	}
+
+	list := ThreadMembershipsFromJson(strings.NewReader(ThreadMembershipsToJson([]*ThreadMembership{&o})))
+	if len(list) != 1 || list[0].PostId != o.PostId {
+		t.Fatal("list should have round tripped")
+	}
+}
+
+func TestThreadMembershipIsValid(t *testing.T) {
+	o := ThreadMembership{PostId: NewId(), ChannelId: NewId(), UserId: NewId()}
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.UserId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.ChannelId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelId = NewId()
+	o.UnreadReplies = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
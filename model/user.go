@@ -57,6 +57,9 @@ type User struct {
 	MfaActive          bool      `json:"mfa_active,omitempty"`
 	MfaSecret          string    `json:"mfa_secret,omitempty"`
 	LastActivityAt     int64     `db:"-" json:"last_activity_at,omitempty"`
+	CustomStatusEmoji  string    `json:"custom_status_emoji,omitempty"`
+	CustomStatusText   string    `json:"custom_status_text,omitempty"`
+	CustomStatusExpiry int64     `json:"custom_status_expiry,omitempty"`
 }
 
 // IsValid validates the user and returns an error if it isn't configured
@@ -292,6 +295,33 @@ func (u *User) AddNotifyProp(key string, value string) {
 	u.NotifyProps[key] = value
 }
 
+// GetCustomStatus returns the user's custom status, or nil if they don't have one or it has expired.
+func (u *User) GetCustomStatus() *CustomStatus {
+	status := &CustomStatus{
+		Emoji:     u.CustomStatusEmoji,
+		Text:      u.CustomStatusText,
+		ExpiresAt: u.CustomStatusExpiry,
+	}
+
+	if (status.Emoji == "" && status.Text == "") || status.IsExpired() {
+		return nil
+	}
+
+	return status
+}
+
+func (u *User) SetCustomStatus(status *CustomStatus) {
+	if status == nil {
+		u.CustomStatusEmoji = ""
+		u.CustomStatusText = ""
+		u.CustomStatusExpiry = 0
+	} else {
+		u.CustomStatusEmoji = status.Emoji
+		u.CustomStatusText = status.Text
+		u.CustomStatusExpiry = status.ExpiresAt
+	}
+}
+
 func (u *User) GetFullName() string {
 	if u.FirstName != "" && u.LastName != "" {
 		return u.FirstName + " " + u.LastName
@@ -350,8 +380,8 @@ func IsValidUserRoles(userRoles string) bool {
 		}
 	}
 
-	// Exclude just the system_admin role explicitly to prevent mistakes
-	if len(roles) == 1 && roles[0] == "system_admin" {
+	// Exclude just the admin roles explicitly to prevent mistakes
+	if len(roles) == 1 && IsAdminRole(roles[0]) {
 		return false
 	}
 
@@ -0,0 +1,171 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	USER_ACCESS_TOKEN_SCOPE_READ_POSTS      = "read-posts"
+	USER_ACCESS_TOKEN_SCOPE_WRITE_POSTS     = "write-posts"
+	USER_ACCESS_TOKEN_SCOPE_MANAGE_CHANNELS = "manage-channels"
+	USER_ACCESS_TOKEN_SCOPE_FILES_ONLY      = "files-only"
+
+	USER_ACCESS_TOKEN_DESCRIPTION_MAX_LENGTH = 512
+
+	SESSION_PROP_TYPE                         = "type"
+	SESSION_TYPE_USER_ACCESS_TOKEN            = "UserAccessToken"
+	SESSION_PROP_USER_ACCESS_TOKEN_ID         = "user_access_token_id"
+	SESSION_PROP_USER_ACCESS_TOKEN_SCOPES     = "user_access_token_scopes"
+	SESSION_PROP_USER_ACCESS_TOKEN_RATE_LIMIT = "user_access_token_rate_limit"
+)
+
+var UserAccessTokenScopes = []string{
+	USER_ACCESS_TOKEN_SCOPE_READ_POSTS,
+	USER_ACCESS_TOKEN_SCOPE_WRITE_POSTS,
+	USER_ACCESS_TOKEN_SCOPE_MANAGE_CHANNELS,
+	USER_ACCESS_TOKEN_SCOPE_FILES_ONLY,
+}
+
+// UserAccessToken is a personal access token that an integration can use in place of a session token to make
+// requests as the user that created it. A token with no scopes can do anything its user can, otherwise it's limited
+// to the routes covered by its scopes. RateLimit is the number of requests per minute allowed with the token, or 0
+// for no limit beyond the server's own.
+type UserAccessToken struct {
+	Id          string      `json:"id"`
+	Token       string      `json:"token,omitempty"`
+	UserId      string      `json:"user_id"`
+	Description string      `json:"description"`
+	Scopes      StringArray `json:"scopes"`
+	RateLimit   int         `json:"rate_limit"`
+	CreateAt    int64       `json:"create_at"`
+	LastUsedAt  int64       `json:"last_used_at"`
+}
+
+func (t *UserAccessToken) IsValid() *AppError {
+	if len(t.Id) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.id.app_error", nil, "", 400)
+	}
+
+	if len(t.Token) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.token.app_error", nil, "", 400)
+	}
+
+	if len(t.UserId) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.user_id.app_error", nil, "", 400)
+	}
+
+	if len(t.Description) > USER_ACCESS_TOKEN_DESCRIPTION_MAX_LENGTH {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.description.app_error", nil, "", 400)
+	}
+
+	for _, scope := range t.Scopes {
+		if !IsValidUserAccessTokenScope(scope) {
+			return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.scope.app_error", nil, "scope="+scope, 400)
+		}
+	}
+
+	if t.RateLimit < 0 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.rate_limit.app_error", nil, "", 400)
+	}
+
+	if t.CreateAt == 0 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.create_at.app_error", nil, "", 400)
+	}
+
+	return nil
+}
+
+func (t *UserAccessToken) PreSave() {
+	if t.Id == "" {
+		t.Id = NewId()
+	}
+
+	t.Token = NewId()
+	t.CreateAt = GetMillis()
+	t.LastUsedAt = 0
+
+	if t.Scopes == nil {
+		t.Scopes = StringArray{}
+	}
+}
+
+// Sanitize removes the token itself, which is only returned when it's created.
+func (t *UserAccessToken) Sanitize() {
+	t.Token = ""
+}
+
+// NewSession returns the session that requests made with the token are handled in. It isn't saved, and doesn't
+// expire until the token is revoked.
+func (t *UserAccessToken) NewSession(user *User) *Session {
+	session := &Session{
+		Id:             t.Id,
+		Token:          t.Token,
+		CreateAt:       t.CreateAt,
+		LastActivityAt: GetMillis(),
+		UserId:         t.UserId,
+		Roles:          user.Roles,
+	}
+
+	session.AddProp(SESSION_PROP_TYPE, SESSION_TYPE_USER_ACCESS_TOKEN)
+	session.AddProp(SESSION_PROP_USER_ACCESS_TOKEN_ID, t.Id)
+	session.AddProp(SESSION_PROP_USER_ACCESS_TOKEN_SCOPES, strings.Join(t.Scopes, " "))
+	session.AddProp(SESSION_PROP_USER_ACCESS_TOKEN_RATE_LIMIT, strconv.Itoa(t.RateLimit))
+
+	return session
+}
+
+func IsValidUserAccessTokenScope(scope string) bool {
+	for _, s := range UserAccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *UserAccessToken) ToJson() string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenFromJson(data io.Reader) *UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var t UserAccessToken
+	err := decoder.Decode(&t)
+	if err == nil {
+		return &t
+	} else {
+		return nil
+	}
+}
+
+func UserAccessTokenListToJson(t []*UserAccessToken) string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenListFromJson(data io.Reader) []*UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var t []*UserAccessToken
+	err := decoder.Decode(&t)
+	if err == nil {
+		return t
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserAccessTokenJson(t *testing.T) {
+	o := &UserAccessToken{Id: NewId(), UserId: NewId(), Scopes: StringArray{USER_ACCESS_TOKEN_SCOPE_READ_POSTS}, RateLimit: 10}
+	ro := UserAccessTokenFromJson(strings.NewReader(o.ToJson()))
+
+	if ro.Id != o.Id || ro.UserId != o.UserId || len(ro.Scopes) != 1 || ro.RateLimit != 10 {
+		t.Fatal("tokens do not match")
+	}
+
+	list := UserAccessTokenListFromJson(strings.NewReader(UserAccessTokenListToJson([]*UserAccessToken{o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("token lists do not match")
+	}
+}
+
+func TestUserAccessTokenIsValid(t *testing.T) {
+	o := &UserAccessToken{UserId: NewId(), Scopes: StringArray{USER_ACCESS_TOKEN_SCOPE_FILES_ONLY}}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Scopes = StringArray{"junk"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Scopes = StringArray{}
+	o.RateLimit = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestUserAccessTokenNewSession(t *testing.T) {
+	o := &UserAccessToken{UserId: NewId(), Scopes: StringArray{USER_ACCESS_TOKEN_SCOPE_READ_POSTS, USER_ACCESS_TOKEN_SCOPE_WRITE_POSTS}, RateLimit: 5}
+	o.PreSave()
+
+	session := o.NewSession(&User{Id: o.UserId, Roles: ROLE_SYSTEM_USER.Id})
+	if !session.IsUserAccessToken() || session.Token != o.Token || session.Id != o.Id || session.IsExpired() {
+		t.Fatal("should be a session for the token")
+	}
+
+	if scopes := session.GetUserAccessTokenScopes(); len(scopes) != 2 || scopes[0] != USER_ACCESS_TOKEN_SCOPE_READ_POSTS {
+		t.Fatal("should have the token's scopes", scopes)
+	}
+
+	if session.GetUserAccessTokenRateLimit() != 5 {
+		t.Fatal("should have the token's rate limit")
+	}
+
+	other := &Session{}
+	if other.IsUserAccessToken() || len(other.GetUserAccessTokenScopes()) != 0 || other.GetUserAccessTokenRateLimit() != 0 {
+		t.Fatal("shouldn't be a session for a token")
+	}
+}
@@ -240,6 +240,14 @@ func TestRoles(t *testing.T) {
 		t.Fatal()
 	}
 
+	if !IsValidUserRoles("system_user system_read_only_admin") {
+		t.Fatal()
+	}
+
+	if IsValidUserRoles("system_compliance_officer") {
+		t.Fatal()
+	}
+
 	if IsInRole("system_admin junk", "admin") {
 		t.Fatal()
 	}
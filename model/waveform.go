@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	// The number of amplitudes kept in a file's waveform
+	WAVEFORM_SAMPLES = 64
+
+	WAV_FORMAT_PCM = 1
+)
+
+// IsWav returns true if the file is an uncompressed wave file that can be decoded without
+// a transcoder.
+func (o *FileInfo) IsWav() bool {
+	return o.MimeType == "audio/wav" || o.MimeType == "audio/x-wav" || o.MimeType == "audio/wave" || o.MimeType == "audio/vnd.wave"
+}
+
+// GetAudioInfoForWav decodes an 8 or 16 bit PCM wave file.
+func GetAudioInfoForWav(data []byte) (*AudioInfo, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errors.New("not a wave file")
+	}
+
+	var format, channels, bitsPerSample uint16
+	var sampleRate uint32
+	var samples []byte
+
+	for offset := 12; offset+8 <= len(data); {
+		chunkId := string(data[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		offset += 8
+
+		if chunkSize < 0 || offset+chunkSize > len(data) {
+			// a truncated data chunk still holds usable samples
+			chunkSize = len(data) - offset
+		}
+
+		switch chunkId {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, errors.New("invalid format chunk")
+			}
+
+			format = binary.LittleEndian.Uint16(data[offset : offset+2])
+			channels = binary.LittleEndian.Uint16(data[offset+2 : offset+4])
+			sampleRate = binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+			bitsPerSample = binary.LittleEndian.Uint16(data[offset+14 : offset+16])
+		case "data":
+			samples = data[offset : offset+chunkSize]
+		}
+
+		// chunks are padded to an even number of bytes
+		offset += chunkSize + chunkSize%2
+	}
+
+	if format != WAV_FORMAT_PCM || channels == 0 || sampleRate == 0 || (bitsPerSample != 8 && bitsPerSample != 16) {
+		return nil, errors.New("unsupported wave format")
+	}
+
+	if samples == nil {
+		return nil, errors.New("missing data chunk")
+	}
+
+	bytesPerFrame := int(channels) * int(bitsPerSample) / 8
+	frames := len(samples) / bytesPerFrame
+
+	info := &AudioInfo{
+		Duration: int64(frames) * 1000 / int64(sampleRate),
+		Samples:  make([]float64, frames),
+	}
+
+	reader := bytes.NewReader(samples)
+	for i := 0; i < frames; i++ {
+		var sum float64
+		for c := 0; c < int(channels); c++ {
+			if bitsPerSample == 8 {
+				var sample uint8
+				binary.Read(reader, binary.LittleEndian, &sample)
+				sum += (float64(sample) - 128) / 128
+			} else {
+				var sample int16
+				binary.Read(reader, binary.LittleEndian, &sample)
+				sum += float64(sample) / 32768
+			}
+		}
+
+		info.Samples[i] = sum / float64(channels)
+	}
+
+	return info, nil
+}
+
+// GetWaveform reduces the samples to the given number of peak amplitudes, each scaled to
+// between 0 and 255 so that the loudest one is 255.
+func GetWaveform(samples []float64, size int) []byte {
+	waveform := make([]byte, size)
+	if len(samples) == 0 || size == 0 {
+		return waveform
+	}
+
+	peaks := make([]float64, size)
+	var max float64
+	for i := range peaks {
+		start := i * len(samples) / size
+		end := (i + 1) * len(samples) / size
+		if end == start && end < len(samples) {
+			end = start + 1
+		}
+
+		for _, sample := range samples[start:end] {
+			peaks[i] = math.Max(peaks[i], math.Abs(sample))
+		}
+
+		max = math.Max(max, peaks[i])
+	}
+
+	if max == 0 {
+		return waveform
+	}
+
+	for i, peak := range peaks {
+		waveform[i] = byte(math.Floor(peak / max * 255))
+	}
+
+	return waveform
+}
+
+// EncodeWaveform returns the waveform in the form that's stored on a FileInfo.
+func EncodeWaveform(waveform []byte) string {
+	return base64.StdEncoding.EncodeToString(waveform)
+}
+
+func DecodeWaveform(encoded string) []byte {
+	if waveform, err := base64.StdEncoding.DecodeString(encoded); err != nil {
+		return nil
+	} else {
+		return waveform
+	}
+}
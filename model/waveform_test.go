@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func makeWav(sampleRate uint32, channels uint16, samples []int16) []byte {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+len(samples)*2))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(16))
+	binary.Write(buf, binary.LittleEndian, uint16(WAV_FORMAT_PCM))
+	binary.Write(buf, binary.LittleEndian, channels)
+	binary.Write(buf, binary.LittleEndian, sampleRate)
+	binary.Write(buf, binary.LittleEndian, sampleRate*uint32(channels)*2)
+	binary.Write(buf, binary.LittleEndian, channels*2)
+	binary.Write(buf, binary.LittleEndian, uint16(16))
+
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(len(samples)*2))
+	binary.Write(buf, binary.LittleEndian, samples)
+
+	return buf.Bytes()
+}
+
+func TestGetAudioInfoForWav(t *testing.T) {
+	samples := make([]int16, 2000)
+	for i := range samples {
+		if i%2 == 0 {
+			samples[i] = 16384
+		} else {
+			samples[i] = -16384
+		}
+	}
+
+	if info, err := GetAudioInfoForWav(makeWav(1000, 2, samples)); err != nil {
+		t.Fatal(err)
+	} else if info.Duration != 1000 {
+		t.Fatal("incorrect duration", info.Duration)
+	} else if len(info.Samples) != 1000 {
+		t.Fatal("should've mixed the channels together", len(info.Samples))
+	} else if info.Samples[0] != 0 {
+		t.Fatal("should've averaged the channels", info.Samples[0])
+	}
+
+	if info, err := GetAudioInfoForWav(makeWav(500, 1, samples)); err != nil {
+		t.Fatal(err)
+	} else if info.Duration != 4000 || info.Samples[0] != 0.5 || info.Samples[1] != -0.5 {
+		t.Fatal("incorrect audio info")
+	}
+
+	if _, err := GetAudioInfoForWav([]byte("not a wave file")); err == nil {
+		t.Fatal("shouldn't have decoded an invalid file")
+	}
+}
+
+func TestGetWaveform(t *testing.T) {
+	samples := []float64{0, 0.1, -0.25, 0.1, 0.5, -0.5, 0, 0}
+
+	if waveform := GetWaveform(samples, 4); !bytes.Equal(waveform, []byte{51, 127, 255, 0}) {
+		t.Fatal("incorrect waveform", waveform)
+	}
+
+	if waveform := GetWaveform(samples, 16); len(waveform) != 16 || waveform[9] != 255 {
+		t.Fatal("should've stretched a short file", waveform)
+	}
+
+	if waveform := GetWaveform(nil, 4); !bytes.Equal(waveform, []byte{0, 0, 0, 0}) {
+		t.Fatal("should've returned a flat waveform for an empty file", waveform)
+	}
+
+	waveform := []byte{0, 127, 255}
+	if decoded := DecodeWaveform(EncodeWaveform(waveform)); !bytes.Equal(decoded, waveform) {
+		t.Fatal("waveform should've round tripped", decoded)
+	}
+}
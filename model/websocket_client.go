@@ -5,6 +5,8 @@ package model
 
 import (
 	"encoding/json"
+	"strconv"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -21,6 +23,8 @@ type WebSocketClient struct {
 	EventChannel    chan *WebSocketEvent
 	ResponseChannel chan *WebSocketResponse
 	ListenError     *AppError
+	ConnectionId    string // The id of the connection on the server, used to resume it after reconnecting
+	EventSequence   int64  // The sequence number of the last event received on the connection
 }
 
 // NewWebSocketClient constructs a new WebSocket client with convienence
@@ -40,6 +44,8 @@ func NewWebSocketClient(url, authToken string) (*WebSocketClient, *AppError) {
 		make(chan *WebSocketEvent, 100),
 		make(chan *WebSocketResponse, 100),
 		nil,
+		"",
+		0,
 	}
 
 	client.SendMessage(WEBSOCKET_AUTHENTICATION_CHALLENGE, map[string]interface{}{"token": authToken})
@@ -47,9 +53,16 @@ func NewWebSocketClient(url, authToken string) (*WebSocketClient, *AppError) {
 	return client, nil
 }
 
+// Connect opens a new connection to the server. If the client was connected before, it asks the server to resume the
+// previous connection, and the hello event says whether events were missed.
 func (wsc *WebSocketClient) Connect() *AppError {
+	url := wsc.ApiUrl + "/users/websocket"
+	if len(wsc.ConnectionId) > 0 {
+		url += "?connection_id=" + wsc.ConnectionId + "&sequence_number=" + strconv.FormatInt(wsc.EventSequence, 10)
+	}
+
 	var err error
-	wsc.Conn, _, err = websocket.DefaultDialer.Dial(wsc.ApiUrl+"/users/websocket", nil)
+	wsc.Conn, _, err = websocket.DefaultDialer.Dial(url, nil)
 	if err != nil {
 		return NewLocAppError("NewWebSocketClient", "model.websocket_client.connect_fail.app_error", nil, err.Error())
 	}
@@ -87,6 +100,19 @@ func (wsc *WebSocketClient) Listen() {
 
 			var event WebSocketEvent
 			if err := json.Unmarshal(rawMsg, &event); err == nil && event.IsValid() {
+				if event.Event == WEBSOCKET_EVENT_HELLO {
+					if connectionId, ok := event.Data["connection_id"].(string); ok {
+						wsc.ConnectionId = connectionId
+					}
+
+					// Events on a connection that wasn't resumed are numbered from the start again
+					if resumed, _ := event.Data["resumed"].(bool); !resumed {
+						wsc.EventSequence = 0
+					}
+				} else if event.Sequence > 0 {
+					wsc.EventSequence = event.Sequence
+				}
+
 				wsc.EventChannel <- &event
 				continue
 			}
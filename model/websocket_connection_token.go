@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	WEBSOCKET_CONNECTION_TOKEN_EXPIRY_MILLIS = 1000 * 60 // 1 minute
+)
+
+// WebSocketConnectionToken lets a client open a WebSocket for its session without passing its session token in the
+// URL or relying on cookies. It can only be used once and expires shortly after it is created.
+type WebSocketConnectionToken struct {
+	Token     string `json:"token"`
+	SessionId string `json:"-"`
+	CreateAt  int64  `json:"create_at"`
+	ExpireAt  int64  `json:"expire_at"`
+}
+
+func (o *WebSocketConnectionToken) PreSave() {
+	if o.Token == "" {
+		o.Token = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.ExpireAt = o.CreateAt + WEBSOCKET_CONNECTION_TOKEN_EXPIRY_MILLIS
+}
+
+func (o *WebSocketConnectionToken) IsValid() *AppError {
+	if len(o.Token) != 26 {
+		return NewLocAppError("WebSocketConnectionToken.IsValid", "model.websocket_connection_token.is_valid.token.app_error", nil, "")
+	}
+
+	if len(o.SessionId) != 26 {
+		return NewLocAppError("WebSocketConnectionToken.IsValid", "model.websocket_connection_token.is_valid.session_id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 || o.ExpireAt < o.CreateAt {
+		return NewLocAppError("WebSocketConnectionToken.IsValid", "model.websocket_connection_token.is_valid.expire_at.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *WebSocketConnectionToken) IsExpired() bool {
+	return o.ExpireAt < GetMillis()
+}
+
+func (o *WebSocketConnectionToken) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func WebSocketConnectionTokenFromJson(data io.Reader) *WebSocketConnectionToken {
+	var o WebSocketConnectionToken
+	if err := json.NewDecoder(data).Decode(&o); err == nil {
+		return &o
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWebSocketConnectionTokenJson(t *testing.T) {
+	o := &WebSocketConnectionToken{SessionId: NewId()}
+	o.PreSave()
+
+	ro := WebSocketConnectionTokenFromJson(strings.NewReader(o.ToJson()))
+	if ro.Token != o.Token || ro.ExpireAt != o.ExpireAt {
+		t.Fatal("tokens should have matched")
+	}
+
+	if ro.SessionId != "" {
+		t.Fatal("shouldn't have sent the session id to the client")
+	}
+}
+
+func TestWebSocketConnectionTokenIsValid(t *testing.T) {
+	o := &WebSocketConnectionToken{SessionId: NewId()}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.IsExpired() {
+		t.Fatal("shouldn't have expired yet")
+	}
+
+	o.SessionId = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a session")
+	}
+
+	o.SessionId = NewId()
+	o.Token = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a token")
+	}
+
+	o.Token = NewId()
+	o.ExpireAt = o.CreateAt - 1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid when it expires before it was created")
+	}
+
+	if !o.IsExpired() {
+		t.Fatal("should have expired")
+	}
+}
@@ -4,12 +4,15 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"strconv"
 )
 
 const (
 	WEBSOCKET_EVENT_TYPING             = "typing"
+	WEBSOCKET_EVENT_TYPING_USERS       = "typing_users"
 	WEBSOCKET_EVENT_POSTED             = "posted"
 	WEBSOCKET_EVENT_POST_EDITED        = "post_edited"
 	WEBSOCKET_EVENT_POST_DELETED       = "post_deleted"
@@ -25,11 +28,16 @@ const (
 	WEBSOCKET_EVENT_PREFERENCE_CHANGED = "preference_changed"
 	WEBSOCKET_EVENT_EPHEMERAL_MESSAGE  = "ephemeral_message"
 	WEBSOCKET_EVENT_STATUS_CHANGE      = "status_change"
+	WEBSOCKET_EVENT_CUSTOM_STATUS      = "custom_status_change"
 	WEBSOCKET_EVENT_HELLO              = "hello"
 	WEBSOCKET_EVENT_WEBRTC             = "webrtc"
 	WEBSOCKET_AUTHENTICATION_CHALLENGE = "authentication_challenge"
 	WEBSOCKET_EVENT_REACTION_ADDED     = "reaction_added"
 	WEBSOCKET_EVENT_REACTION_REMOVED   = "reaction_removed"
+	WEBSOCKET_EVENT_FILE_ATTACHED      = "file_attached"
+	WEBSOCKET_EVENT_FILE_DELETED       = "file_deleted"
+	WEBSOCKET_EVENT_FILE_UPDATED       = "file_updated"
+	WEBSOCKET_EVENT_THREAD_UPDATED     = "thread_updated"
 )
 
 type WebSocketMessage interface {
@@ -51,6 +59,7 @@ type WebSocketEvent struct {
 	Event          string                 `json:"event"`
 	Data           map[string]interface{} `json:"data"`
 	Broadcast      *WebsocketBroadcast    `json:"broadcast"`
+	Sequence       int64                  `json:"seq,omitempty"`
 	PreComputeJson []byte                 `json:"-"`
 }
 
@@ -104,6 +113,56 @@ func WebSocketEventFromJson(data io.Reader) *WebSocketEvent {
 	}
 }
 
+// SequencedWebSocketEvent is an event numbered with the sequence number of a connection that it's sent on. The event
+// is shared with the other connections that it's sent on, and the sequence number is only added to its precomputed
+// JSON as it's written so that the JSON doesn't need to be copied for every connection.
+type SequencedWebSocketEvent struct {
+	Event    *WebSocketEvent
+	Sequence int64
+}
+
+func (o *SequencedWebSocketEvent) IsValid() bool {
+	return o.Event.IsValid()
+}
+
+func (o *SequencedWebSocketEvent) EventType() string {
+	return o.Event.EventType()
+}
+
+func (o *SequencedWebSocketEvent) DoPreComputeJson() {
+	o.Event.DoPreComputeJson()
+}
+
+// GetPreComputeJson returns a copy of the event's precomputed JSON with the sequence number added to it. WriteJson
+// should be used instead where possible since it doesn't need to make a copy.
+func (o *SequencedWebSocketEvent) GetPreComputeJson() []byte {
+	var buf bytes.Buffer
+	o.WriteJson(&buf)
+	return buf.Bytes()
+}
+
+// WriteJson writes the event's precomputed JSON with the sequence number added to it.
+func (o *SequencedWebSocketEvent) WriteJson(w io.Writer) error {
+	b := o.Event.GetPreComputeJson()
+	if len(b) < 2 {
+		_, err := w.Write(b)
+		return err
+	}
+
+	if _, err := io.WriteString(w, `{"seq":`+strconv.FormatInt(o.Sequence, 10)+`,`); err != nil {
+		return err
+	}
+
+	_, err := w.Write(b[1:])
+	return err
+}
+
+func (o *SequencedWebSocketEvent) ToJson() string {
+	event := *o.Event
+	event.Sequence = o.Sequence
+	return event.ToJson()
+}
+
 type WebSocketResponse struct {
 	Status         string                 `json:"status"`
 	SeqReply       int64                  `json:"seq_reply,omitempty"`
@@ -4,6 +4,7 @@
 package model
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
@@ -54,3 +55,27 @@ func TestWebSocketResponse(t *testing.T) {
 		t.Fatal("Ids do not match")
 	}
 }
+
+func TestSequencedWebSocketEvent(t *testing.T) {
+	m := NewWebSocketEvent(WEBSOCKET_EVENT_POSTED, "team_id", "channel_id", "", nil)
+	m.Add("post", "post")
+	m.DoPreComputeJson()
+
+	sequenced := &SequencedWebSocketEvent{Event: m, Sequence: 5}
+
+	var buf bytes.Buffer
+	if err := sequenced.WriteJson(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	result := WebSocketEventFromJson(&buf)
+	if result.Sequence != 5 || result.Event != WEBSOCKET_EVENT_POSTED || result.Data["post"] != "post" || result.Broadcast.ChannelId != "channel_id" {
+		t.Fatal("should have added the sequence number to the event's JSON")
+	} else if m.Sequence != 0 || strings.Contains(string(m.GetPreComputeJson()), "seq") {
+		t.Fatal("shouldn't have changed the shared event")
+	}
+
+	if result := WebSocketEventFromJson(strings.NewReader(sequenced.ToJson())); result.Sequence != 5 {
+		t.Fatal("should have included the sequence number in the JSON")
+	}
+}
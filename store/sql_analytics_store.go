@@ -0,0 +1,199 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlAnalyticsStore struct {
+	*SqlStore
+}
+
+func NewSqlAnalyticsStore(sqlStore *SqlStore) AnalyticsStore {
+	s := &SqlAnalyticsStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.AnalyticsDailyStat{}, "AnalyticsDailyStats").SetKeys(false, "Day", "TeamId")
+		table.ColMap("Day").SetMaxSize(10)
+		table.ColMap("TeamId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlAnalyticsStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_analyticsdailystats_team_id", "AnalyticsDailyStats", "TeamId")
+}
+
+// ComputeDailyStats aggregates the posts created between startTime and endTime into a
+// stat for the whole system and one for each team that had any activity.
+func (s SqlAnalyticsStore) ComputeDailyStats(day string, startTime int64, endTime int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		params := map[string]interface{}{"StartTime": startTime, "EndTime": endTime}
+
+		total := &model.AnalyticsDailyStat{Day: day}
+		if err := s.GetReplica().SelectOne(total,
+			`SELECT
+				COUNT(Posts.Id) AS PostCount,
+				COUNT(DISTINCT Posts.UserId) AS ActiveUserCount
+			FROM
+				Posts
+			WHERE
+				Posts.CreateAt >= :StartTime
+				AND Posts.CreateAt <= :EndTime`, params); err != nil {
+			result.Err = model.NewLocAppError("SqlAnalyticsStore.ComputeDailyStats", "store.sql_analytics.compute_daily_stats.app_error", nil, "day="+day+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+		total.Day = day
+		total.TeamId = ""
+
+		var stats []*model.AnalyticsDailyStat
+		if _, err := s.GetReplica().Select(&stats,
+			`SELECT
+				Channels.TeamId AS TeamId,
+				COUNT(Posts.Id) AS PostCount,
+				COUNT(DISTINCT Posts.UserId) AS ActiveUserCount
+			FROM
+				Posts
+			INNER JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				Channels.TeamId != ''
+				AND Posts.CreateAt >= :StartTime
+				AND Posts.CreateAt <= :EndTime
+			GROUP BY
+				Channels.TeamId`, params); err != nil {
+			result.Err = model.NewLocAppError("SqlAnalyticsStore.ComputeDailyStats", "store.sql_analytics.compute_daily_stats.app_error", nil, "day="+day+", "+err.Error())
+		} else {
+			for _, stat := range stats {
+				stat.Day = day
+			}
+
+			result.Data = append([]*model.AnalyticsDailyStat{total}, stats...)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SaveDailyStats replaces all of the stored stats for a day so that aggregating the same
+// day more than once doesn't count anything twice.
+func (s SqlAnalyticsStore) SaveDailyStats(day string, stats []*model.AnalyticsDailyStat) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		for _, stat := range stats {
+			stat.PreSave()
+			if result.Err = stat.IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlAnalyticsStore.SaveDailyStats", "store.sql_analytics.save_daily_stats.begin.app_error", nil, err.Error())
+		} else {
+			if _, err := transaction.Exec("DELETE FROM AnalyticsDailyStats WHERE Day = :Day", map[string]interface{}{"Day": day}); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewLocAppError("SqlAnalyticsStore.SaveDailyStats", "store.sql_analytics.save_daily_stats.app_error", nil, "day="+day+", "+err.Error())
+			} else {
+				for _, stat := range stats {
+					if err := transaction.Insert(stat); err != nil {
+						transaction.Rollback()
+						result.Err = model.NewLocAppError("SqlAnalyticsStore.SaveDailyStats", "store.sql_analytics.save_daily_stats.app_error", nil, "day="+day+", "+err.Error())
+						break
+					}
+				}
+
+				if result.Err == nil {
+					if err := transaction.Commit(); err != nil {
+						result.Err = model.NewLocAppError("SqlAnalyticsStore.SaveDailyStats", "store.sql_analytics.save_daily_stats.commit.app_error", nil, err.Error())
+					} else {
+						result.Data = stats
+					}
+				}
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetLastAggregatedDay returns the most recent day that has been aggregated, or a blank
+// string if the daily stats haven't been computed yet.
+func (s SqlAnalyticsStore) GetLastAggregatedDay() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if day, err := s.GetReplica().SelectStr("SELECT Day FROM AnalyticsDailyStats WHERE TeamId = '' ORDER BY Day DESC LIMIT 1"); err != nil {
+			result.Err = model.NewLocAppError("SqlAnalyticsStore.GetLastAggregatedDay", "store.sql_analytics.get_last_aggregated_day.app_error", nil, err.Error())
+		} else {
+			result.Data = day
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlAnalyticsStore) GetPostCountsByDay(teamId string, startDay string, endDay string) StoreChannel {
+	return s.getDailyRows("PostCount", teamId, startDay, endDay)
+}
+
+func (s SqlAnalyticsStore) GetUserCountsWithPostsByDay(teamId string, startDay string, endDay string) StoreChannel {
+	return s.getDailyRows("ActiveUserCount", teamId, startDay, endDay)
+}
+
+func (s SqlAnalyticsStore) getDailyRows(column string, teamId string, startDay string, endDay string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var rows model.AnalyticsRows
+		if _, err := s.GetReplica().Select(&rows,
+			`SELECT
+				Day AS Name,
+				`+column+` AS Value
+			FROM
+				AnalyticsDailyStats
+			WHERE
+				TeamId = :TeamId
+				AND Day >= :StartDay
+				AND Day <= :EndDay
+				AND `+column+` > 0
+			ORDER BY
+				Day DESC
+			LIMIT 30`, map[string]interface{}{"TeamId": teamId, "StartDay": startDay, "EndDay": endDay}); err != nil {
+			result.Err = model.NewLocAppError("SqlAnalyticsStore.getDailyRows", "store.sql_analytics.get_daily_rows.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestAnalyticsDailyStats(t *testing.T) {
+	Setup()
+
+	// pick a day that no other test will have created posts on
+	date := time.Date(2001, time.January, 1, 12, 0, 0, 0, time.Local)
+	day := model.AnalyticsDayFromTime(date)
+	startTime := utils.MillisFromTime(utils.StartOfDay(date))
+	endTime := utils.MillisFromTime(utils.EndOfDay(date))
+
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	for i, userId := range []string{userId1, userId1, userId2} {
+		o := &model.Post{}
+		o.ChannelId = c1.Id
+		o.UserId = userId
+		o.Message = "a" + model.NewId() + "b"
+		o.CreateAt = startTime + int64(i)
+		Must(store.Post().Save(o))
+	}
+
+	o := &model.Post{}
+	o.ChannelId = c1.Id
+	o.UserId = userId1
+	o.Message = "a" + model.NewId() + "b"
+	o.CreateAt = endTime + 1
+	Must(store.Post().Save(o))
+
+	var stats []*model.AnalyticsDailyStat
+	if result := <-store.Analytics().ComputeDailyStats(day, startTime, endTime); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		stats = result.Data.([]*model.AnalyticsDailyStat)
+	}
+
+	if len(stats) != 2 {
+		t.Fatal("should have a stat for the system and one for the team")
+	}
+
+	if stats[0].TeamId != "" || stats[0].PostCount != 3 || stats[0].ActiveUserCount != 2 {
+		t.Fatal("incorrect system stat")
+	}
+
+	if stats[1].TeamId != c1.TeamId || stats[1].PostCount != 3 || stats[1].ActiveUserCount != 2 {
+		t.Fatal("incorrect team stat")
+	}
+
+	// saving the same day twice should replace the existing stats
+	for i := 0; i < 2; i++ {
+		if result := <-store.Analytics().SaveDailyStats(day, stats); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	if result := <-store.Analytics().GetPostCountsByDay(c1.TeamId, day, day); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if rows := result.Data.(model.AnalyticsRows); len(rows) != 1 || rows[0].Name != day || rows[0].Value != 3 {
+		t.Fatal("incorrect post counts")
+	}
+
+	if result := <-store.Analytics().GetUserCountsWithPostsByDay(c1.TeamId, day, day); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if rows := result.Data.(model.AnalyticsRows); len(rows) != 1 || rows[0].Value != 2 {
+		t.Fatal("incorrect user counts")
+	}
+
+	if result := <-store.Analytics().GetLastAggregatedDay(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if lastDay := result.Data.(string); lastDay < day {
+		t.Fatal("incorrect last aggregated day")
+	}
+
+	if result := <-store.Analytics().SaveDailyStats(day, []*model.AnalyticsDailyStat{}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+}
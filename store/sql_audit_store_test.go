@@ -12,6 +12,8 @@ import (
 func TestSqlAuditStore(t *testing.T) {
 	Setup()
 
+	Must(store.Audit().Save(&model.Audit{UserId: model.NewId(), IpAddress: "ipaddress", Action: "Action"}))
+
 	audit := &model.Audit{UserId: model.NewId(), IpAddress: "ipaddress", Action: "Action"}
 	Must(store.Audit().Save(audit))
 	time.Sleep(100 * time.Millisecond)
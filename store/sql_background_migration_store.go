@@ -0,0 +1,193 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	BACKGROUND_MIGRATION_POST_FILE_COUNTS = "PostFileCounts"
+
+	BACKGROUND_MIGRATION_SYSTEM_PREFIX = "BackgroundMigration"
+	BACKGROUND_MIGRATION_PENDING       = "pending"
+	BACKGROUND_MIGRATION_COMPLETE      = "complete"
+)
+
+// backgroundMigration changes the data in a table that may be too large to change in a single statement while the
+// server is upgrading without locking it for a long time. The upgrade queues the migration, and it's then run in small
+// batches by a job once the server has started.
+type backgroundMigration struct {
+	name string
+
+	// count returns roughly how many rows the migration will change so that its progress can be reported.
+	count func(ss *SqlStore) (int64, error)
+
+	// migrateBatch migrates up to limit rows, ordered by id, starting after the one with the given id. It returns the
+	// ids of the rows that it migrated so that the next batch can start after the last of them.
+	migrateBatch func(ss *SqlStore, afterId string, limit int) ([]string, error)
+}
+
+// backgroundMigrations lists every background migration in the order that they should be run.
+var backgroundMigrations = []*backgroundMigration{
+	{
+		name:         BACKGROUND_MIGRATION_POST_FILE_COUNTS,
+		count:        countPostFileCounts,
+		migrateBatch: migratePostFileCounts,
+	},
+}
+
+func getBackgroundMigration(name string) *backgroundMigration {
+	for _, migration := range backgroundMigrations {
+		if migration.name == name {
+			return migration
+		}
+	}
+
+	return nil
+}
+
+// queueBackgroundMigration marks a background migration as needing to be run. It's called by the upgrade after it has
+// made the schema changes that the migration fills in the data for.
+func (ss *SqlStore) queueBackgroundMigration(name string) {
+	if result := <-ss.system.SaveOrUpdate(&model.System{Name: BACKGROUND_MIGRATION_SYSTEM_PREFIX + name, Value: BACKGROUND_MIGRATION_PENDING}); result.Err != nil {
+		l4g.Error(utils.T("store.sql_background_migration.queue.error"), name, result.Err.Error())
+	}
+}
+
+type SqlBackgroundMigrationStore struct {
+	*SqlStore
+}
+
+func NewSqlBackgroundMigrationStore(sqlStore *SqlStore) BackgroundMigrationStore {
+	return &SqlBackgroundMigrationStore{sqlStore}
+}
+
+func (s SqlBackgroundMigrationStore) CreateIndexesIfNotExists() {
+}
+
+// GetPending returns the names of the background migrations that have been queued but haven't finished yet, in the
+// order that they should be run.
+func (s SqlBackgroundMigrationStore) GetPending() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		pending := []string{}
+		for _, migration := range backgroundMigrations {
+			if status, err := s.GetMaster().SelectStr("SELECT Value FROM Systems WHERE Name = :Name", map[string]interface{}{"Name": BACKGROUND_MIGRATION_SYSTEM_PREFIX + migration.name}); err != nil && err != sql.ErrNoRows {
+				result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.GetPending", "store.sql_background_migration.get_pending.app_error", nil, "name="+migration.name+", "+err.Error())
+				break
+			} else if status == BACKGROUND_MIGRATION_PENDING {
+				pending = append(pending, migration.name)
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = pending
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlBackgroundMigrationStore) CountRows(name string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if migration := getBackgroundMigration(name); migration == nil {
+			result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.CountRows", "store.sql_background_migration.unknown.app_error", nil, "name="+name)
+		} else if count, err := migration.count(s.SqlStore); err != nil {
+			result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.CountRows", "store.sql_background_migration.count_rows.app_error", nil, "name="+name+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// MigrateBatch runs the next batch of a background migration, starting after the row with the given id, and returns the
+// ids of the rows that were migrated. An empty list means that the migration has finished.
+func (s SqlBackgroundMigrationStore) MigrateBatch(name string, afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if migration := getBackgroundMigration(name); migration == nil {
+			result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.MigrateBatch", "store.sql_background_migration.unknown.app_error", nil, "name="+name)
+		} else if ids, err := migration.migrateBatch(s.SqlStore, afterId, limit); err != nil {
+			result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.MigrateBatch", "store.sql_background_migration.migrate_batch.app_error", nil, "name="+name+", after_id="+afterId+", "+err.Error())
+		} else {
+			result.Data = ids
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlBackgroundMigrationStore) Complete(name string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE Systems SET Value = :Value WHERE Name = :Name", map[string]interface{}{"Value": BACKGROUND_MIGRATION_COMPLETE, "Name": BACKGROUND_MIGRATION_SYSTEM_PREFIX + name}); err != nil {
+			result.Err = model.NewLocAppError("SqlBackgroundMigrationStore.Complete", "store.sql_background_migration.complete.app_error", nil, "name="+name+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func countPostFileCounts(ss *SqlStore) (int64, error) {
+	return ss.GetReplica().SelectInt("SELECT COUNT(DISTINCT PostId) FROM FileInfo WHERE PostId != ''")
+}
+
+// migratePostFileCounts fills in the FileCount and HasImage columns of the posts that had files attached before the
+// columns were added.
+func migratePostFileCounts(ss *SqlStore, afterId string, limit int) ([]string, error) {
+	var postIds []string
+	if _, err := ss.GetMaster().Select(&postIds, "SELECT DISTINCT PostId FROM FileInfo WHERE PostId > :AfterId ORDER BY PostId LIMIT :Limit", map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+		return nil, err
+	}
+
+	if len(postIds) == 0 {
+		return postIds, nil
+	}
+
+	props := make(map[string]interface{})
+	if _, err := ss.GetMaster().Exec(
+		`UPDATE
+			Posts
+		SET
+			FileCount = (SELECT count(0) FROM FileInfo WHERE FileInfo.PostId = Posts.Id AND FileInfo.DeleteAt = 0),
+			HasImage = (SELECT count(0) > 0 FROM FileInfo WHERE FileInfo.PostId = Posts.Id AND FileInfo.DeleteAt = 0 AND FileInfo.MimeType LIKE 'image%')
+		WHERE
+			Id IN (`+inQueryParams("PostId", postIds, props)+`)`, props); err != nil {
+		return nil, err
+	}
+
+	return postIds, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestBackgroundMigrationStore(t *testing.T) {
+	Setup()
+
+	store.(*SqlStore).queueBackgroundMigration(BACKGROUND_MIGRATION_POST_FILE_COUNTS)
+
+	if pending := Must(store.BackgroundMigration().GetPending()).([]string); len(pending) != 1 || pending[0] != BACKGROUND_MIGRATION_POST_FILE_COUNTS {
+		t.Fatal("should have queued the migration", pending)
+	}
+
+	post := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a"})).(*model.Post)
+	for _, mimeType := range []string{"image/png", "text/plain"} {
+		info := &model.FileInfo{CreatorId: post.UserId, PostId: post.Id, Path: "file", MimeType: mimeType}
+		Must(store.FileInfo().Save(info))
+		defer func() {
+			<-store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+		}()
+	}
+
+	if count := Must(store.BackgroundMigration().CountRows(BACKGROUND_MIGRATION_POST_FILE_COUNTS)).(int64); count < 1 {
+		t.Fatal("should have counted the post with files")
+	}
+
+	// start just before the post so that it's the only one migrated
+	if ids := Must(store.BackgroundMigration().MigrateBatch(BACKGROUND_MIGRATION_POST_FILE_COUNTS, post.Id[:len(post.Id)-1], 1)).([]string); len(ids) != 1 || ids[0] != post.Id {
+		t.Fatal("should have migrated the post", ids)
+	}
+
+	if migrated := Must(store.Post().GetSingle(post.Id)).(*model.Post); migrated.FileCount != 2 || !migrated.HasImage {
+		t.Fatal("should have filled in the file count and image flag", migrated.FileCount, migrated.HasImage)
+	}
+
+	if result := <-store.BackgroundMigration().MigrateBatch("junk", "", 1); result.Err == nil {
+		t.Fatal("shouldn't have run an unknown migration")
+	}
+
+	Must(store.BackgroundMigration().Complete(BACKGROUND_MIGRATION_POST_FILE_COUNTS))
+
+	if pending := Must(store.BackgroundMigration().GetPending()).([]string); len(pending) != 0 {
+		t.Fatal("shouldn't have any pending migrations once it's complete")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlCertificateCacheStore struct {
+	*SqlStore
+}
+
+func NewSqlCertificateCacheStore(sqlStore *SqlStore) CertificateCacheStore {
+	s := &SqlCertificateCacheStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.CertificateCacheEntry{}, "CertificateCache").SetKeys(false, "Name")
+		table.ColMap("Name").SetMaxSize(model.CERTIFICATE_CACHE_NAME_MAX_LENGTH)
+		table.ColMap("Data").SetMaxSize(model.CERTIFICATE_CACHE_DATA_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (s SqlCertificateCacheStore) CreateIndexesIfNotExists() {
+}
+
+func (s SqlCertificateCacheStore) SaveOrUpdate(entry *model.CertificateCacheEntry) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		entry.PreSave()
+		if result.Err = entry.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(entry); err != nil {
+			result.Err = model.NewLocAppError("SqlCertificateCacheStore.SaveOrUpdate", "store.sql_certificate_cache.save.app_error", nil, "name="+entry.Name+", "+err.Error())
+		} else if count == 0 {
+			if err := s.GetMaster().Insert(entry); err != nil {
+				result.Err = model.NewLocAppError("SqlCertificateCacheStore.SaveOrUpdate", "store.sql_certificate_cache.save.app_error", nil, "name="+entry.Name+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = entry
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlCertificateCacheStore) Get(name string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		// Read from the master so that a certificate issued by another server is picked up straight away
+		if obj, err := s.GetMaster().Get(model.CertificateCacheEntry{}, name); err != nil {
+			result.Err = model.NewLocAppError("SqlCertificateCacheStore.Get", "store.sql_certificate_cache.get.app_error", nil, "name="+name+", "+err.Error())
+		} else if obj == nil {
+			result.Err = model.NewLocAppError("SqlCertificateCacheStore.Get", "store.sql_certificate_cache.get.missing.app_error", nil, "name="+name)
+		} else {
+			result.Data = obj.(*model.CertificateCacheEntry)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlCertificateCacheStore) Delete(name string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM CertificateCache WHERE Name = :Name", map[string]interface{}{"Name": name}); err != nil {
+			result.Err = model.NewLocAppError("SqlCertificateCacheStore.Delete", "store.sql_certificate_cache.delete.app_error", nil, "name="+name+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestCertificateCacheStore(t *testing.T) {
+	Setup()
+
+	name := "example.com-" + model.NewId()
+
+	if result := <-store.CertificateCache().Get(name); result.Err == nil {
+		t.Fatal("shouldn't have found a missing entry")
+	}
+
+	if err := (<-store.CertificateCache().SaveOrUpdate(&model.CertificateCacheEntry{Name: name, Data: "first"})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if entry := Must(store.CertificateCache().Get(name)).(*model.CertificateCacheEntry); entry.Data != "first" {
+		t.Fatal("should have gotten the saved entry")
+	}
+
+	if err := (<-store.CertificateCache().SaveOrUpdate(&model.CertificateCacheEntry{Name: name, Data: "second"})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if entry := Must(store.CertificateCache().Get(name)).(*model.CertificateCacheEntry); entry.Data != "second" {
+		t.Fatal("should have overwritten the saved entry")
+	}
+
+	if err := (<-store.CertificateCache().SaveOrUpdate(&model.CertificateCacheEntry{})).Err; err == nil {
+		t.Fatal("shouldn't have saved an entry without a name")
+	}
+
+	if err := (<-store.CertificateCache().Delete(name)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.CertificateCache().Get(name); result.Err == nil {
+		t.Fatal("shouldn't have found a deleted entry")
+	}
+}
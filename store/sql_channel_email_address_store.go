@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlChannelEmailAddressStore struct {
+	*SqlStore
+}
+
+func NewSqlChannelEmailAddressStore(sqlStore *SqlStore) ChannelEmailAddressStore {
+	s := &SqlChannelEmailAddressStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ChannelEmailAddress{}, "ChannelEmailAddresses").SetKeys(false, "ChannelId")
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("Token").SetMaxSize(26).SetUnique(true)
+		table.ColMap("CreatorId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlChannelEmailAddressStore) CreateIndexesIfNotExists() {
+}
+
+// Replace saves the email address of a channel in place of any address that it had before, so that the old address
+// stops working as soon as the new one is returned.
+func (s SqlChannelEmailAddressStore) Replace(address *model.ChannelEmailAddress) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		address.PreSave()
+		if result.Err = address.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelEmailAddressStore.Replace", "store.sql_channel_email_address.replace.open_transaction.app_error", nil, err.Error())
+		} else if _, err := transaction.Exec("DELETE FROM ChannelEmailAddresses WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": address.ChannelId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlChannelEmailAddressStore.Replace", "store.sql_channel_email_address.replace.delete.app_error", nil, "channel_id="+address.ChannelId+", "+err.Error())
+		} else if err := transaction.Insert(address); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlChannelEmailAddressStore.Replace", "store.sql_channel_email_address.replace.save.app_error", nil, "channel_id="+address.ChannelId+", "+err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelEmailAddressStore.Replace", "store.sql_channel_email_address.replace.commit_transaction.app_error", nil, err.Error())
+		} else {
+			result.Data = address
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlChannelEmailAddressStore) GetForChannel(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var address model.ChannelEmailAddress
+		if err := s.GetReplica().SelectOne(&address, "SELECT * FROM ChannelEmailAddresses WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId}); err == sql.ErrNoRows {
+			result.Err = model.NewAppError("SqlChannelEmailAddressStore.GetForChannel", "store.sql_channel_email_address.get_for_channel.missing.app_error", nil, "channel_id="+channelId, http.StatusNotFound)
+		} else if err != nil {
+			result.Err = model.NewAppError("SqlChannelEmailAddressStore.GetForChannel", "store.sql_channel_email_address.get_for_channel.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = &address
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlChannelEmailAddressStore) GetByToken(token string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var address model.ChannelEmailAddress
+		if err := s.GetMaster().SelectOne(&address, "SELECT * FROM ChannelEmailAddresses WHERE Token = :Token", map[string]interface{}{"Token": token}); err == sql.ErrNoRows {
+			result.Err = model.NewAppError("SqlChannelEmailAddressStore.GetByToken", "store.sql_channel_email_address.get_by_token.missing.app_error", nil, "", http.StatusNotFound)
+		} else if err != nil {
+			result.Err = model.NewAppError("SqlChannelEmailAddressStore.GetByToken", "store.sql_channel_email_address.get_by_token.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = &address
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForChannel removes the email address of a channel, such as when it's turned off or when the channel is deleted.
+func (s SqlChannelEmailAddressStore) DeleteForChannel(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ChannelEmailAddresses WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelEmailAddressStore.DeleteForChannel", "store.sql_channel_email_address.delete_for_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestChannelEmailAddressStore(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	creatorId := model.NewId()
+
+	if result := <-store.ChannelEmailAddress().GetForChannel(channelId); result.Err == nil {
+		t.Fatal("shouldn't have found an address before one was saved")
+	}
+
+	first := Must(store.ChannelEmailAddress().Replace(&model.ChannelEmailAddress{ChannelId: channelId, CreatorId: creatorId})).(*model.ChannelEmailAddress)
+	if len(first.Token) != 26 {
+		t.Fatal("should have generated a token")
+	}
+
+	if address := Must(store.ChannelEmailAddress().GetByToken(first.Token)).(*model.ChannelEmailAddress); address.ChannelId != channelId {
+		t.Fatal("should have found the channel by its token")
+	}
+
+	if result := <-store.ChannelEmailAddress().Replace(&model.ChannelEmailAddress{ChannelId: channelId}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid address")
+	}
+
+	second := Must(store.ChannelEmailAddress().Replace(&model.ChannelEmailAddress{ChannelId: channelId, CreatorId: creatorId})).(*model.ChannelEmailAddress)
+
+	if result := <-store.ChannelEmailAddress().GetByToken(first.Token); result.Err == nil {
+		t.Fatal("old token should've stopped working")
+	}
+
+	if address := Must(store.ChannelEmailAddress().GetForChannel(channelId)).(*model.ChannelEmailAddress); address.Token != second.Token {
+		t.Fatal("should have replaced the address")
+	}
+
+	Must(store.ChannelEmailAddress().DeleteForChannel(channelId))
+
+	if result := <-store.ChannelEmailAddress().GetByToken(second.Token); result.Err == nil {
+		t.Fatal("should have deleted the address")
+	}
+}
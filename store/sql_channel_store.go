@@ -438,6 +438,8 @@ func (s SqlChannelStore) PermanentDeleteMembersByChannel(channelId string) Store
 			result.Err = model.NewLocAppError("SqlChannelStore.RemoveAllMembersByChannel", "store.sql_channel.remove_member.app_error", nil, "channel_id="+channelId+", "+err.Error())
 		}
 
+		s.InvalidateMemberCount(channelId)
+
 		storeChannel <- result
 		close(storeChannel)
 	}()
@@ -694,6 +696,63 @@ func (s SqlChannelStore) SaveMember(member *model.ChannelMember) StoreChannel {
 		}
 
 		s.InvalidateAllChannelMembersForUser(member.UserId)
+		s.InvalidateMemberCount(member.ChannelId)
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SaveMembers adds members to several channels in a single transaction so that either all or none of them are added.
+func (s SqlChannelStore) SaveMembers(members []*model.ChannelMember) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		var result StoreResult
+
+		channels := make([]*model.Channel, len(members))
+		for i, member := range members {
+			if cr := <-s.GetFromMaster(member.ChannelId); cr.Err != nil {
+				result.Err = cr.Err
+				storeChannel <- result
+				close(storeChannel)
+				return
+			} else {
+				channels[i] = cr.Data.(*model.Channel)
+			}
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.SaveMembers", "store.sql_channel.save_member.open_transaction.app_error", nil, err.Error())
+		} else {
+			for i, member := range members {
+				if result = s.saveMemberT(transaction, member, channels[i]); result.Err != nil {
+					transaction.Rollback()
+					break
+				}
+			}
+
+			if result.Err == nil {
+				if err := transaction.Commit(); err != nil {
+					result.Err = model.NewLocAppError("SqlChannelStore.SaveMembers", "store.sql_channel.save_member.commit_transaction.app_error", nil, err.Error())
+				} else {
+					for _, channel := range channels {
+						if mu := <-s.extraUpdated(channel); mu.Err != nil {
+							result.Err = mu.Err
+						}
+					}
+
+					result.Data = members
+				}
+			}
+		}
+
+		for _, member := range members {
+			s.InvalidateAllChannelMembersForUser(member.UserId)
+			s.InvalidateMemberCount(member.ChannelId)
+		}
 
 		storeChannel <- result
 		close(storeChannel)
@@ -999,6 +1058,83 @@ func (s SqlChannelStore) GetMemberCount(channelId string, allowFromCache bool) S
 	return storeChannel
 }
 
+// GetMemberCounts returns a map of channel ids to the number of active users in each of the
+// given channels. Counts that aren't already cached are loaded with a single query.
+func (s SqlChannelStore) GetMemberCounts(channelIds []string, allowFromCache bool) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+	metrics := einterfaces.GetMetricsInterface()
+
+	go func() {
+		result := StoreResult{}
+
+		counts := make(map[string]int64, len(channelIds))
+		missingIds := []string{}
+
+		for _, channelId := range channelIds {
+			if allowFromCache {
+				if cacheItem, ok := channelMemberCountsCache.Get(channelId); ok {
+					if metrics != nil {
+						metrics.IncrementMemCacheHitCounter("Channel Member Counts")
+					}
+					counts[channelId] = cacheItem.(int64)
+					continue
+				}
+			}
+
+			if metrics != nil {
+				metrics.IncrementMemCacheMissCounter("Channel Member Counts")
+			}
+
+			// channels without any active members won't be returned by the query
+			counts[channelId] = 0
+			missingIds = append(missingIds, channelId)
+		}
+
+		if len(missingIds) > 0 {
+			props := make(map[string]interface{})
+
+			var rows []struct {
+				ChannelId string
+				Count     int64
+			}
+			if _, err := s.GetReplica().Select(&rows, `
+				SELECT
+					ChannelMembers.ChannelId, count(*) AS Count
+				FROM
+					ChannelMembers,
+					Users
+				WHERE
+					ChannelMembers.UserId = Users.Id
+					AND ChannelMembers.ChannelId IN (`+inQueryParams("ChannelId", missingIds, props)+`)
+					AND Users.DeleteAt = 0
+				GROUP BY
+					ChannelMembers.ChannelId`, props); err != nil {
+				result.Err = model.NewLocAppError("SqlChannelStore.GetMemberCounts", "store.sql_channel.get_member_counts.app_error", nil, err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+
+			for _, row := range rows {
+				counts[row.ChannelId] = row.Count
+			}
+
+			if allowFromCache {
+				for _, channelId := range missingIds {
+					channelMemberCountsCache.AddWithExpiresInSecs(channelId, counts[channelId], CHANNEL_MEMBERS_COUNTS_CACHE_SEC)
+				}
+			}
+		}
+
+		result.Data = counts
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) RemoveMember(channelId string, userId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1022,6 +1158,8 @@ func (s SqlChannelStore) RemoveMember(channelId string, userId string) StoreChan
 			}
 		}
 
+		s.InvalidateMemberCount(channelId)
+
 		storeChannel <- result
 		close(storeChannel)
 	}()
@@ -1035,8 +1173,18 @@ func (s SqlChannelStore) PermanentDeleteMembersByUser(userId string) StoreChanne
 	go func() {
 		result := StoreResult{}
 
-		if _, err := s.GetMaster().Exec("DELETE FROM ChannelMembers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+		var channelIds []string
+		if _, err := s.GetMaster().Select(&channelIds, "SELECT ChannelId FROM ChannelMembers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
 			result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.permanent_delete_members_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else if _, err := s.GetMaster().Exec("DELETE FROM ChannelMembers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.permanent_delete_members_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			for _, channelId := range channelIds {
+				s.InvalidateMemberCount(channelId)
+			}
+
+			// return the channels that the user was removed from so that other servers can be told about them
+			result.Data = channelIds
 		}
 
 		storeChannel <- result
@@ -1046,6 +1194,26 @@ func (s SqlChannelStore) PermanentDeleteMembersByUser(userId string) StoreChanne
 	return storeChannel
 }
 
+// countedPostsFilter matches the posts that are counted in the TotalMsgCount of a channel. Posts about users joining and
+// leaving aren't counted so that they don't mark the channel as unread.
+var countedPostsFilter = "Type NOT IN ('" + strings.Join([]string{
+	model.POST_JOIN_LEAVE,
+	model.POST_JOIN_CHANNEL,
+	model.POST_LEAVE_CHANNEL,
+	model.POST_ADD_REMOVE,
+	model.POST_ADD_TO_CHANNEL,
+	model.POST_REMOVE_FROM_CHANNEL,
+}, "', '") + "')"
+
+// lastViewedAtMsgCount is the MsgCount of a member who has seen a channel up to :NewLastViewedAt. Rather than counting
+// the posts after that, which is slow in busy channels, a member who hasn't seen every post is marked as having at least
+// one unread message. The repair job recounts it exactly.
+var lastViewedAtMsgCount = `CASE
+				WHEN :NewLastViewedAt >= Channels.LastPostAt THEN Channels.TotalMsgCount
+				WHEN ChannelMembers.MsgCount < Channels.TotalMsgCount THEN ChannelMembers.MsgCount
+				ELSE Channels.TotalMsgCount - 1
+			END`
+
 func (s SqlChannelStore) SetLastViewedAt(channelId string, userId string, newLastViewedAt int64) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1054,15 +1222,12 @@ func (s SqlChannelStore) SetLastViewedAt(channelId string, userId string, newLas
 
 		var query string
 
-		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES || utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
 			query = `UPDATE
 				ChannelMembers
 			SET
 			    MentionCount = 0,
-			    MsgCount = Channels.TotalMsgCount - (SELECT COUNT(*)
-			    					 FROM Posts
-			    					 WHERE ChannelId = :ChannelId
-			    					 AND CreateAt > :NewLastViewedAt),
+			    MsgCount = ` + lastViewedAtMsgCount + `,
 			    LastViewedAt = :NewLastViewedAt
 			FROM
 				Channels
@@ -1075,10 +1240,7 @@ func (s SqlChannelStore) SetLastViewedAt(channelId string, userId string, newLas
 				ChannelMembers, Channels
 			SET
 			    ChannelMembers.MentionCount = 0,
-			    ChannelMembers.MsgCount = Channels.TotalMsgCount - (SELECT COUNT(*)
-										FROM Posts
-										WHERE ChannelId = :ChannelId
-										AND CreateAt > :NewLastViewedAt),
+			    ChannelMembers.MsgCount = ` + lastViewedAtMsgCount + `,
 			    ChannelMembers.LastViewedAt = :NewLastViewedAt
 			WHERE
 			    Channels.Id = ChannelMembers.ChannelId
@@ -1117,7 +1279,7 @@ func (s SqlChannelStore) UpdateLastViewedAt(channelIds []string, userId string)
 			idQuery += "ChannelId = :channelId" + strconv.Itoa(index)
 		}
 
-		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES || utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
 			query = `UPDATE
 				ChannelMembers
 			SET
@@ -1186,6 +1348,137 @@ func (s SqlChannelStore) IncrementMentionCount(channelId string, userId string)
 	return storeChannel
 }
 
+// RepairMessageCounts recounts the TotalMsgCount of the next limit channels after the one with the given id, ordered by
+// id, along with the MsgCount of their members, in case they've drifted from the posts that are actually in the
+// channel. Mention counts depend on each member's notification settings, so they're recounted by the app with
+// RepairMentionCount. The ids of the channels that were repaired are returned.
+func (s SqlChannelStore) RepairMessageCounts(afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var channels []*model.Channel
+		if _, err := s.GetMaster().Select(&channels, "SELECT * FROM Channels WHERE Id > :AfterId ORDER BY Id LIMIT :Limit", map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.RepairMessageCounts", "store.sql_channel.repair_message_counts.get.app_error", nil, err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		channelIds := make([]string, 0, len(channels))
+		for _, channel := range channels {
+			if transaction, err := s.GetMaster().Begin(); err != nil {
+				result.Err = model.NewLocAppError("SqlChannelStore.RepairMessageCounts", "store.sql_channel.repair_message_counts.begin.app_error", nil, err.Error())
+			} else if err := repairMessageCounts(transaction, channel); err != nil {
+				transaction.Rollback()
+
+				result.Err = model.NewLocAppError("SqlChannelStore.RepairMessageCounts", "store.sql_channel.repair_message_counts.app_error", nil, "channel_id="+channel.Id+", "+err.Error())
+			} else if err := transaction.Commit(); err != nil {
+				// don't need to rollback here since the transaction is already closed
+				result.Err = model.NewLocAppError("SqlChannelStore.RepairMessageCounts", "store.sql_channel.repair_message_counts.commit.app_error", nil, err.Error())
+			}
+
+			if result.Err != nil {
+				break
+			}
+
+			s.InvalidateChannel(channel.Id)
+			channelIds = append(channelIds, channel.Id)
+		}
+
+		if result.Err == nil {
+			result.Data = channelIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func repairMessageCounts(transaction *gorp.Transaction, channel *model.Channel) error {
+	// Lock the channel and its members while they're recounted so that a post or a view that happens in the meantime
+	// waits for the new counts instead of being overwritten by them. SQLite only allows one writer at a time anyway.
+	forUpdate := " FOR UPDATE"
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		forUpdate = ""
+	}
+
+	lastPostAt, err := transaction.SelectInt("SELECT LastPostAt FROM Channels WHERE Id = :ChannelId"+forUpdate, map[string]interface{}{"ChannelId": channel.Id})
+	if err != nil {
+		return err
+	}
+
+	var userIds []string
+	if _, err := transaction.Select(&userIds, "SELECT UserId FROM ChannelMembers WHERE ChannelId = :ChannelId"+forUpdate, map[string]interface{}{"ChannelId": channel.Id}); err != nil {
+		return err
+	}
+
+	// A post is saved before the channel's count is incremented, so any post after LastPostAt is about to be counted
+	// once the channel is unlocked
+	props := map[string]interface{}{"ChannelId": channel.Id, "LastPostAt": lastPostAt}
+
+	totalMsgCount, err := transaction.SelectInt("SELECT COUNT(*) FROM Posts WHERE ChannelId = :ChannelId AND CreateAt <= :LastPostAt AND "+countedPostsFilter, props)
+	if err != nil {
+		return err
+	}
+	props["TotalMsgCount"] = totalMsgCount
+
+	if _, err := transaction.Exec("UPDATE Channels SET TotalMsgCount = :TotalMsgCount WHERE Id = :ChannelId", props); err != nil {
+		return err
+	}
+
+	_, err = transaction.Exec(
+		`UPDATE
+			ChannelMembers
+		SET
+			MsgCount = :TotalMsgCount - (SELECT COUNT(*)
+							FROM Posts
+							WHERE ChannelId = :ChannelId
+							AND CreateAt > ChannelMembers.LastViewedAt
+							AND CreateAt <= :LastPostAt
+							AND `+countedPostsFilter+`)
+		WHERE
+			ChannelId = :ChannelId`, props)
+
+	return err
+}
+
+// RepairMentionCount sets the MentionCount of a channel member that was recounted from the posts that they haven't seen.
+// Nothing is changed if the member has viewed the channel or been mentioned since it was recounted, which is checked
+// using the LastViewedAt and LastUpdateAt that it was recounted with. The result is true if the count was set.
+func (s SqlChannelStore) RepairMentionCount(member *model.ChannelMember) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				ChannelMembers
+			SET
+				MentionCount = :MentionCount
+			WHERE
+				ChannelId = :ChannelId
+					AND UserId = :UserId
+					AND LastViewedAt = :LastViewedAt
+					AND LastUpdateAt = :LastUpdateAt`,
+			map[string]interface{}{"MentionCount": member.MentionCount, "ChannelId": member.ChannelId, "UserId": member.UserId, "LastViewedAt": member.LastViewedAt, "LastUpdateAt": member.LastUpdateAt}); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.RepairMentionCount", "store.sql_channel.repair_mention_count.app_error", nil, "channel_id="+member.ChannelId+", user_id="+member.UserId+", "+err.Error())
+		} else {
+			count, _ := sqlResult.RowsAffected()
+			result.Data = count == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) GetAll(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1407,6 +1700,9 @@ func (s SqlChannelStore) performSearch(searchQuery string, term string, paramete
 
 		searchClause := fmt.Sprintf("AND MATCH(%s) AGAINST (:Term IN BOOLEAN MODE)", "Name, DisplayName")
 		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		searchClause := generateSqliteSearchClause([]string{"Name", "DisplayName"}, strings.Fields(term), false, parameters)
+		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
 	}
 
 	var channels model.ChannelList
@@ -1428,6 +1724,13 @@ func (s SqlChannelStore) GetMembersByIds(channelId string, userIds []string) Sto
 	go func() {
 		result := StoreResult{}
 
+		if len(userIds) == 0 {
+			result.Err = model.NewLocAppError("SqlChannelStore.GetMembersByIds", "store.sql_channel.get_members_by_ids.app_error", nil, "channelId="+channelId+" no user ids")
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
 		var members model.ChannelMembers
 		props := make(map[string]interface{})
 		idQuery := ""
@@ -416,6 +416,40 @@ func TestChannelStoreGetDeletedByName(t *testing.T) {
 	}
 }
 
+func TestChannelStoreSaveMembers(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Name", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	c2 := Must(store.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Name", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_PRIVATE})).(*model.Channel)
+
+	u1 := Must(store.User().Save(&model.User{Email: model.NewId()})).(*model.User)
+	u2 := Must(store.User().Save(&model.User{Email: model.NewId()})).(*model.User)
+
+	Must(store.Channel().SaveMembers([]*model.ChannelMember{
+		{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()},
+		{ChannelId: c2.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()},
+	}))
+
+	if members := Must(store.Channel().GetMembersForUser(teamId, u1.Id)).(*model.ChannelMembers); len(*members) != 2 {
+		t.Fatal("should have added the user to both channels")
+	}
+
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c2.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	if result := <-store.Channel().SaveMembers([]*model.ChannelMember{
+		{ChannelId: c1.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()},
+		{ChannelId: c2.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()},
+	}); result.Err == nil {
+		t.Fatal("shouldn't have added a user to a channel that they're already in")
+	}
+
+	if result := <-store.Channel().GetMember(c1.Id, u2.Id); result.Err == nil {
+		t.Fatal("shouldn't have added the user to any of the channels")
+	}
+}
+
 func TestChannelMemberStore(t *testing.T) {
 	Setup()
 
@@ -429,6 +463,8 @@ func TestChannelMemberStore(t *testing.T) {
 	c1t1 := (<-store.Channel().Get(c1.Id, false)).Data.(*model.Channel)
 	t1 := c1t1.ExtraUpdateAt
 
+	time.Sleep(2 * time.Millisecond)
+
 	u1 := model.User{}
 	u1.Email = model.NewId()
 	u1.Nickname = model.NewId()
@@ -483,6 +519,8 @@ func TestChannelMemberStore(t *testing.T) {
 		t.Fatal("should have saved 2 members")
 	}
 
+	time.Sleep(2 * time.Millisecond)
+
 	Must(store.Channel().RemoveMember(o2.ChannelId, o2.UserId))
 
 	count = (<-store.Channel().GetMemberCount(o1.ChannelId, false)).Data.(int64)
@@ -490,6 +528,10 @@ func TestChannelMemberStore(t *testing.T) {
 		t.Fatal("should have removed 1 member")
 	}
 
+	if store.Channel().GetMemberCountFromCache(o1.ChannelId) != 1 {
+		t.Fatal("removing a member should have updated the cached count")
+	}
+
 	c1t3 := (<-store.Channel().Get(c1.Id, false)).Data.(*model.Channel)
 	t3 := c1t3.ExtraUpdateAt
 
@@ -513,6 +555,36 @@ func TestChannelMemberStore(t *testing.T) {
 	}
 }
 
+func TestChannelStoreGetMemberCounts(t *testing.T) {
+	Setup()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Name", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	c2 := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Name", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+
+	u1 := Must(store.User().Save(&model.User{Email: model.NewId(), Nickname: model.NewId()})).(*model.User)
+	u2 := Must(store.User().Save(&model.User{Email: model.NewId(), Nickname: model.NewId()})).(*model.User)
+
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c2.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	missingId := model.NewId()
+
+	for _, allowFromCache := range []bool{false, true, true} {
+		if result := <-store.Channel().GetMemberCounts([]string{c1.Id, c2.Id, missingId}, allowFromCache); result.Err != nil {
+			t.Fatal(result.Err)
+		} else if counts := result.Data.(map[string]int64); len(counts) != 3 || counts[c1.Id] != 2 || counts[c2.Id] != 1 || counts[missingId] != 0 {
+			t.Fatal("got incorrect member counts", counts)
+		}
+	}
+
+	Must(store.Channel().PermanentDeleteMembersByUser(u1.Id))
+
+	if counts := Must(store.Channel().GetMemberCounts([]string{c1.Id, c2.Id}, true)).(map[string]int64); counts[c1.Id] != 1 || counts[c2.Id] != 0 {
+		t.Fatal("deleting a user's memberships should have updated the cached counts", counts)
+	}
+}
+
 func TestChannelDeleteMemberStore(t *testing.T) {
 	Setup()
 
@@ -526,6 +598,8 @@ func TestChannelDeleteMemberStore(t *testing.T) {
 	c1t1 := (<-store.Channel().Get(c1.Id, false)).Data.(*model.Channel)
 	t1 := c1t1.ExtraUpdateAt
 
+	time.Sleep(2 * time.Millisecond)
+
 	u1 := model.User{}
 	u1.Email = model.NewId()
 	u1.Nickname = model.NewId()
@@ -933,6 +1007,107 @@ func TestChannelStoreIncrementMentionCount(t *testing.T) {
 	}
 }
 
+func TestChannelStoreRepairMessageCounts(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Channel1"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	o1.TotalMsgCount = 25
+	Must(store.Channel().Save(&o1))
+
+	userId := model.NewId()
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "a", CreateAt: 1000}))
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "b", CreateAt: 2000}))
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "c", CreateAt: 3000, Type: model.POST_JOIN_CHANNEL}))
+
+	m1 := model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), NotifyProps: model.GetDefaultChannelNotifyProps()}
+	m1.LastViewedAt = 1500
+	m1.MsgCount = 20
+	m1.MentionCount = 3
+	Must(store.Channel().SaveMember(&m1))
+
+	m2 := model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), NotifyProps: model.GetDefaultChannelNotifyProps()}
+	m2.LastViewedAt = 3000
+	m2.MentionCount = 2
+	Must(store.Channel().SaveMember(&m2))
+
+	// start just before the channel so that it's the only one repaired
+	if result := <-store.Channel().RepairMessageCounts(o1.Id[:len(o1.Id)-1], 1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if channelIds := result.Data.([]string); len(channelIds) != 1 || channelIds[0] != o1.Id {
+		t.Fatal("should've repaired the channel", channelIds)
+	}
+
+	if channel := Must(store.Channel().Get(o1.Id, false)).(*model.Channel); channel.TotalMsgCount != 2 {
+		t.Fatal("should only have counted the regular posts", channel.TotalMsgCount)
+	}
+
+	if member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember); member.MsgCount != 1 {
+		t.Fatal("should've counted the posts that were seen", member.MsgCount)
+	} else if member.MentionCount != 3 {
+		t.Fatal("shouldn't have cleared the mentions of a member with unread posts")
+	}
+
+	if member := Must(store.Channel().GetMember(o1.Id, m2.UserId)).(*model.ChannelMember); member.MsgCount != 2 {
+		t.Fatal("should've counted every post as seen", member.MsgCount)
+	} else if member.MentionCount != 2 {
+		t.Fatal("should've left the mentions to be recounted separately")
+	}
+
+	member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember)
+	member.MentionCount = 1
+	if repaired := Must(store.Channel().RepairMentionCount(member)).(bool); !repaired {
+		t.Fatal("should've repaired the mention count")
+	} else if member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember); member.MentionCount != 1 {
+		t.Fatal("should've set the mention count", member.MentionCount)
+	}
+
+	Must(store.Channel().IncrementMentionCount(o1.Id, m1.UserId))
+
+	member.MentionCount = 0
+	if repaired := Must(store.Channel().RepairMentionCount(member)).(bool); repaired {
+		t.Fatal("shouldn't have repaired the mention count of a member who was mentioned since it was recounted")
+	}
+}
+
+func TestChannelStoreSetLastViewedAt(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Channel1"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o1))
+
+	userId := model.NewId()
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "a", CreateAt: 1000}))
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "b", CreateAt: 2000}))
+	Must(store.Post().Save(&model.Post{ChannelId: o1.Id, UserId: userId, Message: "c", CreateAt: 3000}))
+
+	m1 := model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), NotifyProps: model.GetDefaultChannelNotifyProps()}
+	m1.MentionCount = 1
+	Must(store.Channel().SaveMember(&m1))
+
+	Must(store.Channel().SetLastViewedAt(o1.Id, m1.UserId, 3000))
+	if member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember); member.MsgCount != 3 || member.MentionCount != 0 || member.LastViewedAt != 3000 {
+		t.Fatal("should've marked every post as seen", member.MsgCount, member.MentionCount, member.LastViewedAt)
+	}
+
+	Must(store.Channel().SetLastViewedAt(o1.Id, m1.UserId, 1500))
+	if member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember); member.MsgCount != 2 || member.LastViewedAt != 1500 {
+		t.Fatal("should've marked the channel as unread", member.MsgCount, member.LastViewedAt)
+	}
+
+	Must(store.Channel().SetLastViewedAt(o1.Id, m1.UserId, 2500))
+	if member := Must(store.Channel().GetMember(o1.Id, m1.UserId)).(*model.ChannelMember); member.MsgCount != 2 {
+		t.Fatal("should've kept the channel unread", member.MsgCount)
+	}
+}
+
 func TestGetMember(t *testing.T) {
 	Setup()
 
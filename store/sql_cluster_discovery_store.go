@@ -0,0 +1,208 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlClusterDiscoveryStore struct {
+	*SqlStore
+}
+
+func NewSqlClusterDiscoveryStore(sqlStore *SqlStore) ClusterDiscoveryStore {
+	s := &SqlClusterDiscoveryStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ClusterDiscovery{}, "ClusterDiscovery").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("Type").SetMaxSize(64)
+		table.ColMap("ClusterName").SetMaxSize(64)
+		table.ColMap("Hostname").SetMaxSize(512)
+		table.ColMap("Version").SetMaxSize(64)
+		table.ColMap("ConfigHash").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlClusterDiscoveryStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_cluster_discovery_name", "ClusterDiscovery", "ClusterName")
+	s.CreateIndexIfNotExists("idx_cluster_discovery_last_ping_at", "ClusterDiscovery", "LastPingAt")
+}
+
+func (s SqlClusterDiscoveryStore) Save(discovery *model.ClusterDiscovery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		discovery.PreSave()
+		if result.Err = discovery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(discovery); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Save", "store.sql_cluster_discovery.save.app_error", nil, err.Error())
+		} else {
+			result.Data = discovery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) Delete(discovery *model.ClusterDiscovery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+		result.Data = false
+
+		if res, err := s.GetMaster().Exec(
+			`DELETE
+			FROM
+				ClusterDiscovery
+			WHERE
+				Type = :Type
+				AND ClusterName = :ClusterName
+				AND Hostname = :Hostname
+				AND Port = :Port`, map[string]interface{}{"Type": discovery.Type, "ClusterName": discovery.ClusterName, "Hostname": discovery.Hostname, "Port": discovery.Port}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Delete", "store.sql_cluster_discovery.delete.app_error", nil, err.Error())
+		} else if count, _ := res.RowsAffected(); count > 0 {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) Exists(discovery *model.ClusterDiscovery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+		result.Data = false
+
+		if count, err := s.GetMaster().SelectInt(
+			`SELECT
+				COUNT(*)
+			FROM
+				ClusterDiscovery
+			WHERE
+				Type = :Type
+				AND ClusterName = :ClusterName
+				AND Hostname = :Hostname
+				AND Port = :Port`, map[string]interface{}{"Type": discovery.Type, "ClusterName": discovery.ClusterName, "Hostname": discovery.Hostname, "Port": discovery.Port}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Exists", "store.sql_cluster_discovery.exists.app_error", nil, err.Error())
+		} else if count > 0 {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) GetAll(discoveryType, clusterName string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		lastPingAt := model.GetMillis() - model.CDS_OFFLINE_AFTER_MILLIS
+
+		var list []*model.ClusterDiscovery
+		if _, err := s.GetMaster().Select(
+			&list,
+			`SELECT
+				*
+			FROM
+				ClusterDiscovery
+			WHERE
+				Type = :Type
+				AND ClusterName = :ClusterName
+				AND LastPingAt > :LastPingAt
+			ORDER BY
+				CreateAt, Id`, map[string]interface{}{"Type": discoveryType, "ClusterName": clusterName, "LastPingAt": lastPingAt}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.GetAll", "store.sql_cluster_discovery.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) SetLastPingAt(discovery *model.ClusterDiscovery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE ClusterDiscovery
+			SET
+				LastPingAt = :LastPingAt,
+				ConfigHash = :ConfigHash,
+				Version = :Version
+			WHERE
+				Type = :Type
+				AND ClusterName = :ClusterName
+				AND Hostname = :Hostname
+				AND Port = :Port`,
+			map[string]interface{}{
+				"LastPingAt":  model.GetMillis(),
+				"ConfigHash":  discovery.ConfigHash,
+				"Version":     discovery.Version,
+				"Type":        discovery.Type,
+				"ClusterName": discovery.ClusterName,
+				"Hostname":    discovery.Hostname,
+				"Port":        discovery.Port,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.SetLastPingAt", "store.sql_cluster_discovery.set_last_ping.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) Cleanup() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`DELETE FROM ClusterDiscovery
+			WHERE
+				LastPingAt < :LastPingAt`,
+			map[string]interface{}{
+				"LastPingAt": model.GetMillis() - model.CDS_OFFLINE_AFTER_MILLIS,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Cleanup", "store.sql_cluster_discovery.cleanup.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlClusterDiscoveryStore(t *testing.T) {
+	Setup()
+
+	discovery := &model.ClusterDiscovery{
+		ClusterName: "cluster_name_" + model.NewId(),
+		Hostname:    "hostname",
+		Type:        model.CDS_TYPE_APP,
+	}
+
+	if result := <-store.ClusterDiscovery().Save(discovery); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().Exists(discovery); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should exist")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	discovery2 := &model.ClusterDiscovery{
+		ClusterName: discovery.ClusterName,
+		Hostname:    "hostname2",
+		Type:        model.CDS_TYPE_APP,
+	}
+	Must(store.ClusterDiscovery().Save(discovery2))
+
+	if result := <-store.ClusterDiscovery().GetAll(model.CDS_TYPE_APP, discovery.ClusterName); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if list := result.Data.([]*model.ClusterDiscovery); len(list) != 2 {
+		t.Fatal("should have found both nodes")
+	} else if list[0].Id != discovery.Id {
+		t.Fatal("should be ordered oldest first")
+	}
+
+	discovery.ConfigHash = "hash"
+	if result := <-store.ClusterDiscovery().SetLastPingAt(discovery); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().Delete(discovery); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have deleted")
+	}
+
+	if result := <-store.ClusterDiscovery().Exists(discovery); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("should not exist")
+	}
+
+	stale := &model.ClusterDiscovery{
+		ClusterName: discovery.ClusterName,
+		Hostname:    "stale",
+		Type:        model.CDS_TYPE_APP,
+		CreateAt:    1,
+		LastPingAt:  1,
+	}
+	Must(store.ClusterDiscovery().Save(stale))
+
+	if result := <-store.ClusterDiscovery().Cleanup(); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().Exists(stale); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("stale node should have been cleaned up")
+	}
+
+	Must(store.ClusterDiscovery().Delete(discovery2))
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlFileAccessStore struct {
+	*SqlStore
+}
+
+func NewSqlFileAccessStore(sqlStore *SqlStore) FileAccessStore {
+	s := &SqlFileAccessStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.FileAccess{}, "FileAccesses").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("FileId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("IpAddress").SetMaxSize(64)
+		table.ColMap("LinkType").SetMaxSize(16)
+	}
+
+	return s
+}
+
+func (s SqlFileAccessStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_fileaccesses_file_id", "FileAccesses", "FileId")
+	s.CreateIndexIfNotExists("idx_fileaccesses_user_id", "FileAccesses", "UserId")
+	s.CreateIndexIfNotExists("idx_fileaccesses_create_at", "FileAccesses", "CreateAt")
+}
+
+func (s SqlFileAccessStore) Save(access *model.FileAccess) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		access.PreSave()
+		if result.Err = access.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(access); err != nil {
+			result.Err = model.NewLocAppError("SqlFileAccessStore.Save", "store.sql_file_access.save.app_error", nil, "file_id="+access.FileId+", "+err.Error())
+		} else {
+			result.Data = access
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Query returns the page of the file access log that the query selects, oldest first.
+func (s SqlFileAccessStore) Query(query *model.FileAccessQuery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Offset": query.Offset, "Limit": query.Limit}
+		sql := "SELECT * FROM FileAccesses WHERE 1 = 1"
+
+		if len(query.UserId) > 0 {
+			sql += " AND UserId = :UserId"
+			props["UserId"] = query.UserId
+		}
+
+		if len(query.FileId) > 0 {
+			sql += " AND FileId = :FileId"
+			props["FileId"] = query.FileId
+		}
+
+		if query.Since > 0 {
+			sql += " AND CreateAt >= :Since"
+			props["Since"] = query.Since
+		}
+
+		if query.Until > 0 {
+			sql += " AND CreateAt <= :Until"
+			props["Until"] = query.Until
+		}
+
+		sql += " ORDER BY CreateAt ASC, Id ASC LIMIT :Limit OFFSET :Offset"
+
+		var accesses []*model.FileAccess
+		if _, err := s.GetReplica().Select(&accesses, sql, props); err != nil {
+			result.Err = model.NewLocAppError("SqlFileAccessStore.Query", "store.sql_file_access.query.app_error", nil, err.Error())
+		} else {
+			result.Data = accesses
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteBefore deletes the accesses recorded before the given time and returns how many there were.
+func (s SqlFileAccessStore) PermanentDeleteBefore(before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM FileAccesses WHERE CreateAt < :Before", map[string]interface{}{"Before": before}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileAccessStore.PermanentDeleteBefore", "store.sql_file_access.permanent_delete_before.app_error", nil, err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
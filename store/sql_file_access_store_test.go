@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestFileAccessStoreSaveQuery(t *testing.T) {
+	Setup()
+
+	fileId := model.NewId()
+	userId := model.NewId()
+
+	a1 := Must(store.FileAccess().Save(&model.FileAccess{FileId: fileId, UserId: userId, IpAddress: "127.0.0.1", LinkType: model.FILE_ACCESS_LINK_TYPE_DOWNLOAD, CreateAt: 1000})).(*model.FileAccess)
+	a2 := Must(store.FileAccess().Save(&model.FileAccess{FileId: fileId, IpAddress: "127.0.0.2", LinkType: model.FILE_ACCESS_LINK_TYPE_PUBLIC, CreateAt: 2000})).(*model.FileAccess)
+	a3 := Must(store.FileAccess().Save(&model.FileAccess{FileId: model.NewId(), UserId: userId, IpAddress: "127.0.0.1", LinkType: model.FILE_ACCESS_LINK_TYPE_PREVIEW, CreateAt: 3000})).(*model.FileAccess)
+
+	if result := <-store.FileAccess().Save(&model.FileAccess{FileId: fileId, LinkType: "junk"}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid access")
+	}
+
+	if accesses := Must(store.FileAccess().Query(&model.FileAccessQuery{FileId: fileId, Limit: 10})).([]*model.FileAccess); len(accesses) != 2 || accesses[0].Id != a1.Id || accesses[1].Id != a2.Id {
+		t.Fatal("should've returned the accesses to the file, oldest first")
+	}
+
+	if accesses := Must(store.FileAccess().Query(&model.FileAccessQuery{UserId: userId, Limit: 10})).([]*model.FileAccess); len(accesses) != 2 || accesses[0].Id != a1.Id || accesses[1].Id != a3.Id {
+		t.Fatal("should've returned the accesses by the user")
+	}
+
+	if accesses := Must(store.FileAccess().Query(&model.FileAccessQuery{UserId: userId, Since: 1001, Until: 3000, Limit: 10})).([]*model.FileAccess); len(accesses) != 1 || accesses[0].Id != a3.Id {
+		t.Fatal("should've only returned accesses in the range")
+	}
+
+	if accesses := Must(store.FileAccess().Query(&model.FileAccessQuery{FileId: fileId, Offset: 1, Limit: 1})).([]*model.FileAccess); len(accesses) != 1 || accesses[0].Id != a2.Id {
+		t.Fatal("should've returned the second page")
+	}
+}
+
+func TestFileAccessStorePermanentDeleteBefore(t *testing.T) {
+	Setup()
+
+	fileId := model.NewId()
+
+	Must(store.FileAccess().Save(&model.FileAccess{FileId: fileId, LinkType: model.FILE_ACCESS_LINK_TYPE_DOWNLOAD, CreateAt: 1000}))
+	newer := Must(store.FileAccess().Save(&model.FileAccess{FileId: fileId, LinkType: model.FILE_ACCESS_LINK_TYPE_DOWNLOAD, CreateAt: 2000})).(*model.FileAccess)
+
+	if deleted := Must(store.FileAccess().PermanentDeleteBefore(2000)).(int64); deleted < 1 {
+		t.Fatal("should have deleted the older access")
+	}
+
+	if accesses := Must(store.FileAccess().Query(&model.FileAccessQuery{FileId: fileId, Limit: 10})).([]*model.FileAccess); len(accesses) != 1 || accesses[0].Id != newer.Id {
+		t.Fatal("should only have kept the newer access")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlFileAclStore struct {
+	*SqlStore
+}
+
+func NewSqlFileAclStore(sqlStore *SqlStore) FileAclStore {
+	s := &SqlFileAclStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.FileAcl{}, "FileAcls").SetKeys(false, "FileId")
+		table.ColMap("FileId").SetMaxSize(26)
+		table.ColMap("UserIds").SetMaxSize(4000)
+		table.ColMap("ChannelIds").SetMaxSize(4000)
+	}
+
+	return s
+}
+
+func (s SqlFileAclStore) CreateIndexesIfNotExists() {
+}
+
+// Save creates the access control list of a file or replaces the one that it already has.
+func (s SqlFileAclStore) Save(acl *model.FileAcl) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var existing model.FileAcl
+		if err := s.GetMaster().SelectOne(&existing, "SELECT * FROM FileAcls WHERE FileId = :FileId", map[string]interface{}{"FileId": acl.FileId}); err == nil {
+			acl.CreateAt = existing.CreateAt
+		} else if err != sql.ErrNoRows {
+			result.Err = model.NewLocAppError("SqlFileAclStore.Save", "store.sql_file_acl.save.app_error", nil, "file_id="+acl.FileId+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		acl.PreSave()
+		if result.Err = acl.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var err error
+		if existing.FileId == "" {
+			err = s.GetMaster().Insert(acl)
+		} else {
+			_, err = s.GetMaster().Update(acl)
+		}
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlFileAclStore.Save", "store.sql_file_acl.save.app_error", nil, "file_id="+acl.FileId+", "+err.Error())
+		} else {
+			result.Data = acl
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Get returns the access control list of a file. It's read from the master so that changes to it take effect as soon
+// as they're made.
+func (s SqlFileAclStore) Get(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var acl model.FileAcl
+		if err := s.GetMaster().SelectOne(&acl, "SELECT * FROM FileAcls WHERE FileId = :FileId", map[string]interface{}{"FileId": fileId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlFileAclStore.Get", "store.sql_file_acl.get.app_error", nil, "file_id="+fileId+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlFileAclStore.Get", "store.sql_file_acl.get.app_error", nil, "file_id="+fileId+", "+err.Error())
+			}
+		} else {
+			result.Data = &acl
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileAclStore) Delete(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM FileAcls WHERE FileId = :FileId", map[string]interface{}{"FileId": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileAclStore.Delete", "store.sql_file_acl.delete.app_error", nil, "file_id="+fileId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
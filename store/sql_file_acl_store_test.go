@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestFileAclStore(t *testing.T) {
+	Setup()
+
+	fileId := model.NewId()
+
+	if result := <-store.FileAcl().Get(fileId); result.Err == nil {
+		t.Fatal("shouldn't have found an acl for an unrestricted file")
+	}
+
+	userId := model.NewId()
+	saved := Must(store.FileAcl().Save(&model.FileAcl{FileId: fileId, UserIds: model.StringArray{userId}})).(*model.FileAcl)
+
+	if acl := Must(store.FileAcl().Get(fileId)).(*model.FileAcl); len(acl.UserIds) != 1 || acl.UserIds[0] != userId || len(acl.ChannelIds) != 0 {
+		t.Fatal("should have saved the acl")
+	}
+
+	channelId := model.NewId()
+	Must(store.FileAcl().Save(&model.FileAcl{FileId: fileId, ChannelIds: model.StringArray{channelId}}))
+
+	if acl := Must(store.FileAcl().Get(fileId)).(*model.FileAcl); len(acl.UserIds) != 0 || len(acl.ChannelIds) != 1 || acl.ChannelIds[0] != channelId {
+		t.Fatal("should have replaced the acl")
+	} else if acl.CreateAt != saved.CreateAt {
+		t.Fatal("should have kept the original create time")
+	}
+
+	if result := <-store.FileAcl().Save(&model.FileAcl{FileId: fileId, UserIds: model.StringArray{"junk"}}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid acl")
+	}
+
+	Must(store.FileAcl().Delete(fileId))
+
+	if result := <-store.FileAcl().Get(fileId); result.Err == nil {
+		t.Fatal("should have deleted the acl")
+	}
+}
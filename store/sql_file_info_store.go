@@ -3,13 +3,27 @@
 package store
 
 import (
+	"github.com/go-gorp/gorp"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 type SqlFileInfoStore struct {
 	*SqlStore
 }
 
+const (
+	FILE_INFO_CACHE_SIZE = 25000
+	FILE_INFO_CACHE_SEC  = 1800 // 30 minutes
+)
+
+var fileInfoCache *utils.Cache = utils.NewLru(FILE_INFO_CACHE_SIZE)
+
+func ClearFileCaches() {
+	fileInfoCache.Purge()
+}
+
 func NewSqlFileInfoStore(sqlStore *SqlStore) FileInfoStore {
 	s := &SqlFileInfoStore{sqlStore}
 
@@ -21,9 +35,12 @@ func NewSqlFileInfoStore(sqlStore *SqlStore) FileInfoStore {
 		table.ColMap("Path").SetMaxSize(512)
 		table.ColMap("ThumbnailPath").SetMaxSize(512)
 		table.ColMap("PreviewPath").SetMaxSize(512)
+		table.ColMap("WebPPreviewPath").SetMaxSize(512)
 		table.ColMap("Name").SetMaxSize(256)
 		table.ColMap("Extension").SetMaxSize(64)
 		table.ColMap("MimeType").SetMaxSize(256)
+		table.ColMap("Waveform").SetMaxSize(512)
+		table.ColMap("Checksum").SetMaxSize(64)
 	}
 
 	return s
@@ -62,6 +79,127 @@ func (fs SqlFileInfoStore) Save(info *model.FileInfo) StoreChannel {
 	return storeChannel
 }
 
+func (fs SqlFileInfoStore) Update(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		info.UpdateAt = model.GetMillis()
+		if result.Err = info.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := fs.GetMaster().Update(info); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Update", "store.sql_file_info.update.app_error", nil, "id="+info.Id+", "+err.Error())
+		} else if count != 1 {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Update", "store.sql_file_info.update.app_error", nil, "id="+info.Id)
+		} else {
+			if len(info.PostId) > 0 {
+				fs.InvalidateFileInfosForPostCache(info.PostId)
+			}
+
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdatePreview saves the preview generated for a file along with the dimensions and duration found while generating
+// it. Only those columns are changed so that it doesn't undo changes made to the file while the preview was being
+// generated, such as the file being attached to a post, and it returns the file as it is after the update.
+func (fs SqlFileInfoStore) UpdatePreview(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				ThumbnailPath = :ThumbnailPath,
+				PreviewPath = :PreviewPath,
+				HasPreviewImage = :HasPreviewImage,
+				Width = :Width,
+				Height = :Height,
+				Duration = :Duration,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id`,
+			map[string]interface{}{
+				"ThumbnailPath":   info.ThumbnailPath,
+				"PreviewPath":     info.PreviewPath,
+				"HasPreviewImage": info.HasPreviewImage,
+				"Width":           info.Width,
+				"Height":          info.Height,
+				"Duration":        info.Duration,
+				"UpdateAt":        model.GetMillis(),
+				"Id":              info.Id,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdatePreview", "store.sql_file_info.update_preview.app_error", nil, "id="+info.Id+", "+err.Error())
+		} else {
+			result.Data, result.Err = fs.getUpdated(info.Id)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateWaveform saves the duration and waveform found for an audio file. Like UpdatePreview, only those columns are
+// changed, and it returns the file as it is after the update.
+func (fs SqlFileInfoStore) UpdateWaveform(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				Duration = :Duration,
+				Waveform = :Waveform,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id`,
+			map[string]interface{}{"Duration": info.Duration, "Waveform": info.Waveform, "UpdateAt": model.GetMillis(), "Id": info.Id}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdateWaveform", "store.sql_file_info.update_waveform.app_error", nil, "id="+info.Id+", "+err.Error())
+		} else {
+			result.Data, result.Err = fs.getUpdated(info.Id)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// getUpdated reads a file back from the master after one of its columns was updated and clears the cached files of
+// the post that it's attached to.
+func (fs SqlFileInfoStore) getUpdated(id string) (*model.FileInfo, *model.AppError) {
+	info := &model.FileInfo{}
+	if err := fs.GetMaster().SelectOne(info, "SELECT * FROM FileInfo WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+		return nil, model.NewLocAppError("SqlFileInfoStore.getUpdated", "store.sql_file_info.get.app_error", nil, "id="+id+", "+err.Error())
+	}
+
+	if len(info.PostId) > 0 {
+		fs.InvalidateFileInfosForPostCache(info.PostId)
+	}
+
+	return info, nil
+}
+
 func (fs SqlFileInfoStore) Get(id string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -119,12 +257,64 @@ func (fs SqlFileInfoStore) GetByPath(path string) StoreChannel {
 	return storeChannel
 }
 
-func (fs SqlFileInfoStore) GetForPost(postId string) StoreChannel {
+// CountByPath returns how many FileInfos, including deleted ones, point to the file at the given path. Forwarded
+// posts get their own copies of the original post's FileInfos that share its files, and those copies keep the
+// CreateAt of the original so that they can be found here without scanning the whole table.
+func (fs SqlFileInfoStore) CountByPath(path string, createAt int64) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
 	go func() {
 		result := StoreResult{}
 
+		if count, err := fs.GetMaster().SelectInt(
+			`SELECT
+				COUNT(*)
+			FROM
+				FileInfo
+			WHERE
+				CreateAt = :CreateAt
+				AND Path = :Path`, map[string]interface{}{"CreateAt": createAt, "Path": path}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.CountByPath", "store.sql_file_info.count_by_path.app_error", nil, "path="+path+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (fs SqlFileInfoStore) InvalidateFileInfosForPostCache(postId string) {
+	fileInfoCache.Remove(postId)
+}
+
+func (fs SqlFileInfoStore) GetForPost(postId string, allowFromCache bool) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		metrics := einterfaces.GetMetricsInterface()
+
+		if allowFromCache {
+			if cacheItem, ok := fileInfoCache.Get(postId); ok {
+				if metrics != nil {
+					metrics.IncrementMemCacheHitCounter("File Info")
+				}
+
+				result.Data = cacheItem.([]*model.FileInfo)
+				storeChannel <- result
+				close(storeChannel)
+				return
+			} else {
+				if metrics != nil {
+					metrics.IncrementMemCacheMissCounter("File Info")
+				}
+			}
+		}
+
 		var infos []*model.FileInfo
 
 		if _, err := fs.GetReplica().Select(&infos,
@@ -140,6 +330,10 @@ func (fs SqlFileInfoStore) GetForPost(postId string) StoreChannel {
 			result.Err = model.NewLocAppError("SqlFileInfoStore.GetForPost",
 				"store.sql_file_info.get_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
 		} else {
+			if len(infos) > 0 {
+				fileInfoCache.AddWithExpiresInSecs(postId, infos, FILE_INFO_CACHE_SEC)
+			}
+
 			result.Data = infos
 		}
 
@@ -156,16 +350,21 @@ func (fs SqlFileInfoStore) AttachToPost(fileId, postId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		if _, err := fs.GetMaster().Exec(
-			`UPDATE
-					FileInfo
-				SET
-					PostId = :PostId
-				WHERE
-					Id = :Id
-					AND PostId = ''`, map[string]interface{}{"PostId": postId, "Id": fileId}); err != nil {
+		if transaction, err := fs.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AttachToPost",
+				"store.sql_file_info.attach_to_post.begin.app_error", nil, "post_id="+postId+", file_id="+fileId+", err="+err.Error())
+		} else if info, err := attachFileAndUpdatePost(transaction, fileId, postId); err != nil {
+			transaction.Rollback()
+
 			result.Err = model.NewLocAppError("SqlFileInfoStore.AttachToPost",
 				"store.sql_file_info.attach_to_post.app_error", nil, "post_id="+postId+", file_id="+fileId+", err="+err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			// don't need to rollback here since the transaction is already closed
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AttachToPost",
+				"store.sql_file_info.attach_to_post.commit.app_error", nil, "post_id="+postId+", file_id="+fileId+", err="+err.Error())
+		} else {
+			fs.InvalidateFileInfosForPostCache(postId)
+			result.Data = info
 		}
 
 		storeChannel <- result
@@ -181,16 +380,20 @@ func (fs SqlFileInfoStore) DeleteForPost(postId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		if _, err := fs.GetMaster().Exec(
-			`UPDATE
-				FileInfo
-			SET
-				DeleteAt = :DeleteAt
-			WHERE
-				PostId = :PostId`, map[string]interface{}{"DeleteAt": model.GetMillis(), "PostId": postId}); err != nil {
+		if transaction, err := fs.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.DeleteForPost",
+				"store.sql_file_info.delete_for_post.begin.app_error", nil, "post_id="+postId+", err="+err.Error())
+		} else if err := deleteFilesAndUpdatePost(transaction, postId); err != nil {
+			transaction.Rollback()
+
 			result.Err = model.NewLocAppError("SqlFileInfoStore.DeleteForPost",
 				"store.sql_file_info.delete_for_post.app_error", nil, "post_id="+postId+", err="+err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			// don't need to rollback here since the transaction is already closed
+			result.Err = model.NewLocAppError("SqlFileInfoStore.DeleteForPost",
+				"store.sql_file_info.delete_for_post.commit.app_error", nil, "post_id="+postId+", err="+err.Error())
 		} else {
+			fs.InvalidateFileInfosForPostCache(postId)
 			result.Data = postId
 		}
 
@@ -200,3 +403,441 @@ func (fs SqlFileInfoStore) DeleteForPost(postId string) StoreChannel {
 
 	return storeChannel
 }
+
+// PermanentDeleteBatch deletes the given files other than the ones held by a legal hold and returns the ids of the
+// files that it deleted.
+func (fs SqlFileInfoStore) PermanentDeleteBatch(fileIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(fileIds) > 0 {
+			if transaction, err := fs.GetMaster().Begin(); err != nil {
+				result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDeleteBatch", "store.sql_file_info.permanent_delete_batch.begin.app_error", nil, err.Error())
+			} else if deletedIds, postIds, err := permanentDeleteFilesAndUpdatePosts(transaction, fileIds); err != nil {
+				transaction.Rollback()
+
+				result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDeleteBatch", "store.sql_file_info.permanent_delete_batch.app_error", nil, err.Error())
+			} else if err := transaction.Commit(); err != nil {
+				// don't need to rollback here since the transaction is already closed
+				result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDeleteBatch", "store.sql_file_info.permanent_delete_batch.commit.app_error", nil, err.Error())
+			} else {
+				for _, postId := range postIds {
+					fs.InvalidateFileInfosForPostCache(postId)
+				}
+
+				result.Data = deletedIds
+			}
+		} else {
+			result.Data = []string{}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDeleted returns the files that were deleted after the given time, most recently deleted first.
+func (fs SqlFileInfoStore) GetDeleted(deletedAfter int64, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				DeleteAt > :DeletedAfter
+			ORDER BY
+				DeleteAt DESC, Id
+			LIMIT :Limit
+			OFFSET :Offset`, map[string]interface{}{"DeletedAfter": deletedAfter, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetDeleted", "store.sql_file_info.get_deleted.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDeletedBefore returns up to limit files that were deleted before the given time and aren't under a legal hold,
+// least recently deleted first.
+func (fs SqlFileInfoStore) GetDeletedBefore(before int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				DeleteAt != 0
+				AND DeleteAt < :Before`+LEGAL_HOLD_EXCLUDE_FILES+`
+			ORDER BY
+				DeleteAt, Id
+			LIMIT :Limit`, map[string]interface{}{"Before": before, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetDeletedBefore", "store.sql_file_info.get_deleted_before.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Restore undeletes the given files if they were deleted after the given time and reattaches them to their posts. Files
+// whose post has since been deleted are left alone since nothing would show them. The files that were restored are
+// returned and any others are ignored.
+func (fs SqlFileInfoStore) Restore(fileIds []string, deletedAfter int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(fileIds) == 0 {
+			result.Data = []*model.FileInfo{}
+		} else if transaction, err := fs.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Restore", "store.sql_file_info.restore.begin.app_error", nil, err.Error())
+		} else if infos, err := restoreFilesAndUpdatePosts(transaction, fileIds, deletedAfter); err != nil {
+			transaction.Rollback()
+
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Restore", "store.sql_file_info.restore.app_error", nil, err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			// don't need to rollback here since the transaction is already closed
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Restore", "store.sql_file_info.restore.commit.app_error", nil, err.Error())
+		} else {
+			for _, info := range infos {
+				if len(info.PostId) > 0 {
+					fs.InvalidateFileInfosForPostCache(info.PostId)
+				}
+			}
+
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func attachFileAndUpdatePost(transaction *gorp.Transaction, fileId string, postId string) (*model.FileInfo, error) {
+	if _, err := transaction.Exec(
+		`UPDATE
+			FileInfo
+		SET
+			PostId = :PostId
+		WHERE
+			Id = :Id
+			AND PostId = ''`, map[string]interface{}{"PostId": postId, "Id": fileId}); err != nil {
+		return nil, err
+	}
+
+	if err := updatePostForFiles(transaction, postId); err != nil {
+		return nil, err
+	}
+
+	info := &model.FileInfo{}
+	if err := transaction.SelectOne(info, "SELECT * FROM FileInfo WHERE Id = :Id", map[string]interface{}{"Id": fileId}); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func deleteFilesAndUpdatePost(transaction *gorp.Transaction, postId string) error {
+	if _, err := transaction.Exec(
+		`UPDATE
+			FileInfo
+		SET
+			DeleteAt = :DeleteAt
+		WHERE
+			PostId = :PostId`, map[string]interface{}{"DeleteAt": model.GetMillis(), "PostId": postId}); err != nil {
+		return err
+	}
+
+	return updatePostForFiles(transaction, postId)
+}
+
+// permanentDeleteFilesAndUpdatePosts deletes the given files, other than the ones held by a legal hold, and updates
+// the posts that they were attached to, returning the ids of the files that were deleted and of those posts.
+func permanentDeleteFilesAndUpdatePosts(transaction *gorp.Transaction, fileIds []string) ([]string, []string, error) {
+	props := make(map[string]interface{})
+
+	var deletedIds []string
+	if _, err := transaction.Select(&deletedIds, "SELECT Id FROM FileInfo WHERE Id IN ("+inQueryParams("FileId", fileIds, props)+")"+LEGAL_HOLD_EXCLUDE_FILES, props); err != nil {
+		return nil, nil, err
+	}
+
+	if len(deletedIds) == 0 {
+		return deletedIds, nil, nil
+	}
+
+	props = make(map[string]interface{})
+	inClause := inQueryParams("FileId", deletedIds, props)
+
+	var postIds []string
+	if _, err := transaction.Select(&postIds, "SELECT DISTINCT PostId FROM FileInfo WHERE Id IN ("+inClause+") AND PostId != ''", props); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := transaction.Exec("DELETE FROM FileInfo WHERE Id IN ("+inClause+")", props); err != nil {
+		return nil, nil, err
+	}
+
+	for _, postId := range postIds {
+		if err := updatePostForFiles(transaction, postId); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return deletedIds, postIds, nil
+}
+
+func restoreFilesAndUpdatePosts(transaction *gorp.Transaction, fileIds []string, deletedAfter int64) ([]*model.FileInfo, error) {
+	props := map[string]interface{}{"DeletedAfter": deletedAfter}
+	inClause := inQueryParams("FileId", fileIds, props)
+
+	var infos []*model.FileInfo
+	if _, err := transaction.Select(&infos,
+		`SELECT
+			*
+		FROM
+			FileInfo
+		WHERE
+			Id IN (`+inClause+`)
+			AND DeleteAt != 0
+			AND DeleteAt > :DeletedAfter
+			AND (PostId = '' OR PostId IN (SELECT Id FROM Posts WHERE DeleteAt = 0))`, props); err != nil {
+		return nil, err
+	}
+
+	if len(infos) == 0 {
+		return infos, nil
+	}
+
+	updateAt := model.GetMillis()
+	updateProps := map[string]interface{}{"UpdateAt": updateAt}
+	restoreIds := make([]string, len(infos))
+	for i, info := range infos {
+		restoreIds[i] = info.Id
+	}
+
+	if _, err := transaction.Exec("UPDATE FileInfo SET DeleteAt = 0, UpdateAt = :UpdateAt WHERE Id IN ("+inQueryParams("FileId", restoreIds, updateProps)+")", updateProps); err != nil {
+		return nil, err
+	}
+
+	var postIds []string
+	fileIdsForPosts := map[string][]string{}
+	for _, info := range infos {
+		info.DeleteAt = 0
+		info.UpdateAt = updateAt
+
+		if len(info.PostId) > 0 {
+			if _, ok := fileIdsForPosts[info.PostId]; !ok {
+				postIds = append(postIds, info.PostId)
+			}
+
+			fileIdsForPosts[info.PostId] = append(fileIdsForPosts[info.PostId], info.Id)
+		}
+	}
+
+	for _, postId := range postIds {
+		if err := restorePostFileIds(transaction, postId, fileIdsForPosts[postId]); err != nil {
+			return nil, err
+		}
+
+		if err := updatePostForFiles(transaction, postId); err != nil {
+			return nil, err
+		}
+	}
+
+	return infos, nil
+}
+
+// restorePostFileIds adds the given files back to the FileIds of a post, keeping any that are still there.
+func restorePostFileIds(transaction *gorp.Transaction, postId string, fileIds []string) error {
+	post := &model.Post{}
+	if err := transaction.SelectOne(post, "SELECT * FROM Posts WHERE Id = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+		return err
+	}
+
+	newFileIds := utils.RemoveDuplicatesFromStringArray(append(post.FileIds, fileIds...))
+	if len(newFileIds) == len(post.FileIds) {
+		return nil
+	}
+
+	_, err := transaction.Exec("UPDATE Posts SET FileIds = :FileIds WHERE Id = :PostId", map[string]interface{}{"FileIds": model.ArrayToJson(newFileIds), "PostId": postId})
+
+	return err
+}
+
+const (
+	// Set FileCount and HasImage to match the files attached to the post, update UpdateAt only if FileCount changes
+	UPDATE_POST_FILE_COUNT_QUERY = `UPDATE
+			Posts
+		SET
+			UpdateAt = (CASE
+				WHEN FileCount != (SELECT count(0) FROM FileInfo WHERE PostId = :PostId AND DeleteAt = 0) THEN :UpdateAt
+				ELSE UpdateAt
+			END),
+			FileCount = (SELECT count(0) FROM FileInfo WHERE PostId = :PostId AND DeleteAt = 0),
+			HasImage = (SELECT count(0) > 0 FROM FileInfo WHERE PostId = :PostId AND DeleteAt = 0 AND MimeType LIKE 'image%')
+		WHERE
+			Id = :PostId`
+)
+
+func updatePostForFiles(transaction *gorp.Transaction, postId string) error {
+	_, err := transaction.Exec(UPDATE_POST_FILE_COUNT_QUERY, map[string]interface{}{"PostId": postId, "UpdateAt": model.GetMillis()})
+
+	return err
+}
+
+// GetBatchForMigration returns the next batch of files, including deleted ones, created after the given file when
+// they're ordered by CreateAt and then Id.
+func (fs SqlFileInfoStore) GetBatchForMigration(lastCreateAt int64, lastId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				CreateAt > :LastCreateAt
+				OR (CreateAt = :LastCreateAt AND Id > :LastId)
+			ORDER BY
+				CreateAt, Id
+			LIMIT :Limit`, map[string]interface{}{"LastCreateAt": lastCreateAt, "LastId": lastId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetBatchForMigration", "store.sql_file_info.get_batch_for_migration.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetBatchForIndexing returns the next batch of files attached to posts made before endTime, along with the ids of
+// their posts' channels and teams. Files are ordered by the CreateAt of their post and then by Id, starting after the
+// file identified by startTime and startId, so that they're indexed over the same window as the posts even though
+// they're usually uploaded before the post is made. If teamId is set, only files in that team's channels are returned.
+func (fs SqlFileInfoStore) GetBatchForIndexing(startTime int64, startId string, endTime int64, teamId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		teamFilter := ""
+		if teamId != "" {
+			teamFilter = "AND Channels.TeamId = :TeamId"
+		}
+
+		var infos []*model.FileInfoForIndexing
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				FileInfo.*,
+				Posts.ChannelId AS ChannelId,
+				COALESCE(Channels.TeamId, '') AS TeamId,
+				Posts.CreateAt AS PostCreateAt
+			FROM
+				FileInfo
+				INNER JOIN Posts ON FileInfo.PostId = Posts.Id
+				LEFT JOIN Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				FileInfo.DeleteAt = 0
+				AND Posts.DeleteAt = 0
+				AND (Posts.CreateAt > :StartTime OR (Posts.CreateAt = :StartTime AND FileInfo.Id > :StartId))
+				AND Posts.CreateAt < :EndTime
+				`+teamFilter+`
+			ORDER BY
+				Posts.CreateAt, FileInfo.Id
+			LIMIT :Limit`, map[string]interface{}{"StartTime": startTime, "StartId": startId, "EndTime": endTime, "TeamId": teamId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetBatchForIndexing", "store.sql_file_info.get_batch_for_indexing.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdatePaths saves the paths of the given files, and their thumbnails and previews, in a single transaction so that
+// either all or none of them are changed.
+func (fs SqlFileInfoStore) UpdatePaths(infos []*model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if transaction, err := fs.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdatePaths", "store.sql_file_info.update_paths.begin.app_error", nil, err.Error())
+		} else if err := updateFilePaths(transaction, infos); err != nil {
+			transaction.Rollback()
+
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdatePaths", "store.sql_file_info.update_paths.app_error", nil, err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			// don't need to rollback here since the transaction is already closed
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdatePaths", "store.sql_file_info.update_paths.commit.app_error", nil, err.Error())
+		} else {
+			for _, info := range infos {
+				if len(info.PostId) > 0 {
+					fs.InvalidateFileInfosForPostCache(info.PostId)
+				}
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func updateFilePaths(transaction *gorp.Transaction, infos []*model.FileInfo) error {
+	for _, info := range infos {
+		if _, err := transaction.Exec(
+			`UPDATE
+				FileInfo
+			SET
+				Path = :Path,
+				ThumbnailPath = :ThumbnailPath,
+				PreviewPath = :PreviewPath,
+				WebPPreviewPath = :WebPPreviewPath
+			WHERE
+				Id = :Id`, map[string]interface{}{"Path": info.Path, "ThumbnailPath": info.ThumbnailPath, "PreviewPath": info.PreviewPath, "WebPPreviewPath": info.WebPPreviewPath, "Id": info.Id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -45,6 +45,35 @@ func TestFileInfoSaveGet(t *testing.T) {
 	}
 }
 
+func TestFileInfoUpdate(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      "video.mp4",
+		MimeType:  "video/mp4",
+	})).(*model.FileInfo)
+
+	info.Duration = 1500
+	info.Width = 640
+	info.Height = 480
+	info.HasPreviewImage = true
+
+	if result := <-store.FileInfo().Update(info); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.FileInfo); returned.Duration != 1500 || returned.Width != 640 || !returned.HasPreviewImage {
+		t.Fatal("should've updated the FileInfo")
+	}
+
+	if result := <-store.FileInfo().Update(&model.FileInfo{Id: model.NewId(), CreatorId: model.NewId(), Path: "missing", CreateAt: 1}); result.Err == nil {
+		t.Fatal("shouldn't have updated a FileInfo that doesn't exist")
+	}
+}
+
 func TestFileInfoSaveGetByPath(t *testing.T) {
 	Setup()
 
@@ -80,6 +109,40 @@ func TestFileInfoSaveGetByPath(t *testing.T) {
 	}
 }
 
+func TestFileInfoCountByPath(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      fmt.Sprintf("%v/file.txt", model.NewId()),
+	})).(*model.FileInfo)
+	defer func() {
+		<-store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	if count := Must(store.FileInfo().CountByPath(info.Path, info.CreateAt)).(int64); count != 1 {
+		t.Fatal("should've counted the file", count)
+	}
+
+	copied := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: info.CreatorId,
+		Path:      info.Path,
+		CreateAt:  info.CreateAt,
+		UpdateAt:  info.UpdateAt,
+		DeleteAt:  123,
+	})).(*model.FileInfo)
+
+	if count := Must(store.FileInfo().CountByPath(info.Path, info.CreateAt)).(int64); count != 2 {
+		t.Fatal("should've counted the deleted copy", count)
+	}
+
+	Must(store.FileInfo().PermanentDeleteBatch([]string{copied.Id}))
+
+	if count := Must(store.FileInfo().CountByPath(info.Path, info.CreateAt)).(int64); count != 1 {
+		t.Fatal("shouldn't have counted the removed copy", count)
+	}
+}
+
 func TestFileInfoGetForPost(t *testing.T) {
 	Setup()
 
@@ -114,7 +177,7 @@ func TestFileInfoGetForPost(t *testing.T) {
 		infos[i] = Must(store.FileInfo().Save(info)).(*model.FileInfo)
 	}
 
-	if result := <-store.FileInfo().GetForPost(postId); result.Err != nil {
+	if result := <-store.FileInfo().GetForPost(postId, false); result.Err != nil {
 		t.Fatal(result.Err)
 	} else if returned := result.Data.([]*model.FileInfo); len(returned) != 2 {
 		t.Fatal("should've returned exactly 2 file infos")
@@ -153,17 +216,126 @@ func TestFileInfoAttachToPost(t *testing.T) {
 
 	if result := <-store.FileInfo().AttachToPost(info2.Id, postId); result.Err != nil {
 		t.Fatal(result.Err)
-	} else {
-		info2 = Must(store.FileInfo().Get(info2.Id)).(*model.FileInfo)
+	} else if info2 = result.Data.(*model.FileInfo); info2.PostId != postId {
+		t.Fatal("should've returned the attached file info")
 	}
 
-	if result := <-store.FileInfo().GetForPost(postId); result.Err != nil {
+	if result := <-store.FileInfo().GetForPost(postId, false); result.Err != nil {
 		t.Fatal(result.Err)
 	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 2 {
 		t.Fatal("should've returned exactly 2 file infos")
 	}
 }
 
+func TestFileInfoGetForPostCacheInvalidation(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	postId := model.NewId()
+
+	getCached := func() []*model.FileInfo {
+		return Must(store.FileInfo().GetForPost(postId, true)).([]*model.FileInfo)
+	}
+
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, Path: "file.txt"})).(*model.FileInfo)
+	Must(store.FileInfo().AttachToPost(info1.Id, postId))
+
+	if infos := getCached(); len(infos) != 1 {
+		t.Fatal("should've returned the attached file")
+	}
+
+	info2 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, Path: "file.txt"})).(*model.FileInfo)
+	info2 = Must(store.FileInfo().AttachToPost(info2.Id, postId)).(*model.FileInfo)
+
+	if infos := getCached(); len(infos) != 2 {
+		t.Fatal("attaching a file should've invalidated the cache")
+	}
+
+	info2.Name = "renamed.txt"
+	Must(store.FileInfo().Update(info2))
+
+	for _, info := range getCached() {
+		if info.Id == info2.Id && info.Name != "renamed.txt" {
+			t.Fatal("updating a file should've invalidated the cache")
+		}
+	}
+
+	Must(store.FileInfo().PermanentDeleteBatch([]string{info2.Id}))
+
+	if infos := getCached(); len(infos) != 1 {
+		t.Fatal("permanently deleting a file should've invalidated the cache")
+	}
+
+	Must(store.FileInfo().DeleteForPost(postId))
+
+	if infos := getCached(); len(infos) != 0 {
+		t.Fatal("deleting the files for a post should've invalidated the cache")
+	}
+}
+
+func TestFileInfoUpdatePreview(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "video.mp4", MimeType: "video/mp4"})).(*model.FileInfo)
+	defer func() {
+		<-store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	// the preview is generated from the file as it was uploaded, so it doesn't know that it's been attached since
+	uploaded := *info
+	Must(store.FileInfo().AttachToPost(info.Id, postId))
+
+	if infos := Must(store.FileInfo().GetForPost(postId, true)).([]*model.FileInfo); len(infos) != 1 || infos[0].HasPreviewImage {
+		t.Fatal("shouldn't have a preview yet")
+	}
+
+	uploaded.ThumbnailPath = "video_thumb.jpg"
+	uploaded.PreviewPath = "video_preview.jpg"
+	uploaded.HasPreviewImage = true
+	uploaded.Width = 640
+	uploaded.Height = 480
+	uploaded.Duration = 1500
+
+	if updated := Must(store.FileInfo().UpdatePreview(&uploaded)).(*model.FileInfo); updated.PostId != postId {
+		t.Fatal("shouldn't have detached the file from its post")
+	} else if updated.PreviewPath != "video_preview.jpg" || !updated.HasPreviewImage || updated.Width != 640 || updated.Duration != 1500 {
+		t.Fatal("should have saved the preview")
+	}
+
+	if infos := Must(store.FileInfo().GetForPost(postId, true)).([]*model.FileInfo); len(infos) != 1 || !infos[0].HasPreviewImage {
+		t.Fatal("should have invalidated the cached files of the post")
+	}
+}
+
+func TestFileInfoUpdateWaveform(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "audio.wav", MimeType: "audio/wav"})).(*model.FileInfo)
+	defer func() {
+		<-store.FileInfo().PermanentDeleteBatch([]string{info.Id})
+	}()
+
+	uploaded := *info
+	Must(store.FileInfo().AttachToPost(info.Id, postId))
+
+	uploaded.Duration = 2000
+	uploaded.Waveform = "AAEC"
+
+	if updated := Must(store.FileInfo().UpdateWaveform(&uploaded)).(*model.FileInfo); updated.PostId != postId {
+		t.Fatal("shouldn't have detached the file from its post")
+	} else if updated.Duration != 2000 || updated.Waveform != "AAEC" {
+		t.Fatal("should have saved the waveform")
+	}
+
+	if infos := Must(store.FileInfo().GetForPost(postId, true)).([]*model.FileInfo); len(infos) != 1 || infos[0].Waveform != "AAEC" {
+		t.Fatal("should have returned the waveform with the post's files")
+	}
+}
+
 func TestFileInfoDeleteForPost(t *testing.T) {
 	Setup()
 
@@ -202,7 +374,196 @@ func TestFileInfoDeleteForPost(t *testing.T) {
 		t.Fatal(result.Err)
 	}
 
-	if infos := Must(store.FileInfo().GetForPost(postId)).([]*model.FileInfo); len(infos) != 0 {
+	if infos := Must(store.FileInfo().GetForPost(postId, false)).([]*model.FileInfo); len(infos) != 0 {
 		t.Fatal("shouldn't have returned any file infos")
 	}
 }
+
+func TestFileInfoUpdatesPostFileCount(t *testing.T) {
+	Setup()
+
+	post := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "files"})).(*model.Post)
+
+	image := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: post.UserId, Path: "image.png", MimeType: "image/png"})).(*model.FileInfo)
+	file := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: post.UserId, Path: "file.txt", MimeType: "text/plain"})).(*model.FileInfo)
+
+	Must(store.FileInfo().AttachToPost(file.Id, post.Id))
+
+	if received := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]; received.FileCount != 1 || received.HasImage {
+		t.Fatal("post should have one file and no images")
+	}
+
+	Must(store.FileInfo().AttachToPost(image.Id, post.Id))
+
+	if received := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]; received.FileCount != 2 || !received.HasImage {
+		t.Fatal("post should have two files including an image")
+	}
+
+	Must(store.FileInfo().PermanentDeleteBatch([]string{image.Id}))
+
+	if received := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]; received.FileCount != 1 || received.HasImage {
+		t.Fatal("post should no longer have an image")
+	}
+
+	Must(store.FileInfo().DeleteForPost(post.Id))
+
+	if received := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]; received.FileCount != 0 || received.HasImage {
+		t.Fatal("post should no longer have any files")
+	}
+}
+
+func TestFileInfoGetDeletedAndRestore(t *testing.T) {
+	Setup()
+
+	start := model.GetMillis()
+
+	post := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "files"})).(*model.Post)
+
+	image := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: post.UserId, PostId: post.Id, Path: "image.png", MimeType: "image/png"})).(*model.FileInfo)
+	file := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: post.UserId, PostId: post.Id, Path: "file.txt", MimeType: "text/plain"})).(*model.FileInfo)
+	old := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: post.UserId, PostId: model.NewId(), Path: "old.txt", DeleteAt: 123})).(*model.FileInfo)
+
+	Must(store.FileInfo().DeleteForPost(post.Id))
+
+	deleted := map[string]bool{}
+	for _, info := range Must(store.FileInfo().GetDeleted(start-1, 0, 1000)).([]*model.FileInfo) {
+		deleted[info.Id] = true
+	}
+	if !deleted[image.Id] || !deleted[file.Id] {
+		t.Fatal("should've returned the recently deleted files")
+	} else if deleted[old.Id] {
+		t.Fatal("shouldn't have returned a file deleted before the given time")
+	}
+
+	deleted = map[string]bool{}
+	for _, info := range Must(store.FileInfo().GetDeletedBefore(start, 1000)).([]*model.FileInfo) {
+		deleted[info.Id] = true
+	}
+	if !deleted[old.Id] {
+		t.Fatal("should've returned the file deleted before the given time")
+	} else if deleted[image.Id] || deleted[file.Id] {
+		t.Fatal("shouldn't have returned the recently deleted files")
+	}
+
+	if restored := Must(store.FileInfo().Restore([]string{file.Id}, model.GetMillis()+1000)).([]*model.FileInfo); len(restored) != 0 {
+		t.Fatal("shouldn't have restored a file deleted before the given time")
+	}
+
+	if restored := Must(store.FileInfo().Restore([]string{file.Id, old.Id}, start-1)).([]*model.FileInfo); len(restored) != 1 {
+		t.Fatal("should've only restored the recently deleted file")
+	} else if restored[0].Id != file.Id || restored[0].DeleteAt != 0 {
+		t.Fatal("should've returned the restored file")
+	}
+
+	if infos := Must(store.FileInfo().GetForPost(post.Id, false)).([]*model.FileInfo); len(infos) != 1 || infos[0].Id != file.Id {
+		t.Fatal("restored file should be attached to its post again")
+	}
+
+	if received := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]; received.FileCount != 1 || received.HasImage {
+		t.Fatal("post should have one file and no images")
+	} else if len(received.FileIds) != 1 || received.FileIds[0] != file.Id {
+		t.Fatal("restored file should be back in the post's FileIds")
+	}
+
+	deletedPost := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "deleted"})).(*model.Post)
+	onDeletedPost := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: deletedPost.UserId, PostId: deletedPost.Id, Path: "deleted.txt"})).(*model.FileInfo)
+	onPurgedPost := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: deletedPost.UserId, PostId: model.NewId(), Path: "purged.txt", DeleteAt: model.GetMillis()})).(*model.FileInfo)
+
+	Must(store.FileInfo().DeleteForPost(deletedPost.Id))
+	Must(store.Post().Delete(deletedPost.Id, model.GetMillis()))
+
+	if restored := Must(store.FileInfo().Restore([]string{onDeletedPost.Id, onPurgedPost.Id}, start-1)).([]*model.FileInfo); len(restored) != 0 {
+		t.Fatal("shouldn't have restored files whose posts are gone")
+	}
+
+	if restored := Must(store.FileInfo().Restore([]string{file.Id}, 0)).([]*model.FileInfo); len(restored) != 0 {
+		t.Fatal("shouldn't have restored a file that isn't deleted")
+	}
+}
+
+func TestFileInfoGetBatchForMigrationAndUpdatePaths(t *testing.T) {
+	Setup()
+
+	// use a time in the future so that files saved by other tests aren't included
+	createAt := model.GetMillis() + 1000*60*60*24*365
+
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "file1.txt", CreateAt: createAt, UpdateAt: createAt})).(*model.FileInfo)
+	info2 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "file2.txt", CreateAt: createAt, UpdateAt: createAt})).(*model.FileInfo)
+	info3 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "file3.png", ThumbnailPath: "file3_thumb.jpg", PreviewPath: "file3_preview.jpg", CreateAt: createAt + 1, UpdateAt: createAt + 1})).(*model.FileInfo)
+	defer func() {
+		<-store.FileInfo().PermanentDeleteBatch([]string{info1.Id, info2.Id, info3.Id})
+	}()
+
+	first, second := info1, info2
+	if second.Id < first.Id {
+		first, second = second, first
+	}
+
+	if infos := Must(store.FileInfo().GetBatchForMigration(createAt-1, "", 2)).([]*model.FileInfo); len(infos) != 2 || infos[0].Id != first.Id || infos[1].Id != second.Id {
+		t.Fatal("should've returned the first batch in order")
+	}
+
+	if infos := Must(store.FileInfo().GetBatchForMigration(createAt, second.Id, 2)).([]*model.FileInfo); len(infos) != 1 || infos[0].Id != info3.Id {
+		t.Fatal("should've continued after the last file")
+	}
+
+	info1.Path = "migrated/file1.txt"
+	info3.Path = "migrated/file3.png"
+	info3.ThumbnailPath = "migrated/file3_thumb.jpg"
+	info3.PreviewPath = "migrated/file3_preview.jpg"
+
+	Must(store.FileInfo().UpdatePaths([]*model.FileInfo{info1, info3}))
+
+	if received := Must(store.FileInfo().Get(info1.Id)).(*model.FileInfo); received.Path != "migrated/file1.txt" {
+		t.Fatal("should've updated the path")
+	}
+
+	if received := Must(store.FileInfo().Get(info3.Id)).(*model.FileInfo); received.Path != info3.Path || received.ThumbnailPath != info3.ThumbnailPath || received.PreviewPath != info3.PreviewPath {
+		t.Fatal("should've updated the thumbnail and preview paths")
+	}
+
+	if received := Must(store.FileInfo().Get(info2.Id)).(*model.FileInfo); received.Path != "file2.txt" {
+		t.Fatal("shouldn't have updated other files")
+	}
+}
+
+func TestFileInfoGetBatchForIndexing(t *testing.T) {
+	Setup()
+
+	// use a time in the future so that files saved by other tests aren't included
+	createAt := model.GetMillis() + 1000*60*60*24*365
+
+	channel := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Name", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	otherChannel := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Name", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	post := Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: model.NewId(), Message: "message", CreateAt: createAt})).(*model.Post)
+	laterPost := Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: model.NewId(), Message: "message", CreateAt: createAt + 10})).(*model.Post)
+	otherPost := Must(store.Post().Save(&model.Post{ChannelId: otherChannel.Id, UserId: model.NewId(), Message: "message", CreateAt: createAt})).(*model.Post)
+
+	// files are usually uploaded before their post is made
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: post.Id, Path: "file1.txt", CreateAt: createAt - 100, UpdateAt: createAt - 100})).(*model.FileInfo)
+	info2 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: laterPost.Id, Path: "file2.txt", CreateAt: createAt - 200, UpdateAt: createAt - 200})).(*model.FileInfo)
+	unattached := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "file3.txt", CreateAt: createAt, UpdateAt: createAt})).(*model.FileInfo)
+	deleted := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: post.Id, Path: "file4.txt", CreateAt: createAt, UpdateAt: createAt, DeleteAt: createAt})).(*model.FileInfo)
+	otherTeam := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: otherPost.Id, Path: "file5.txt", CreateAt: createAt, UpdateAt: createAt})).(*model.FileInfo)
+	defer func() {
+		<-store.FileInfo().PermanentDeleteBatch([]string{info1.Id, info2.Id, unattached.Id, deleted.Id, otherTeam.Id})
+	}()
+
+	if infos := Must(store.FileInfo().GetBatchForIndexing(createAt-1, "", createAt+100, channel.TeamId, 1)).([]*model.FileInfoForIndexing); len(infos) != 1 || infos[0].Id != info1.Id {
+		t.Fatal("should've returned the first attached file")
+	} else if infos[0].ChannelId != channel.Id || infos[0].TeamId != channel.TeamId || infos[0].PostCreateAt != post.CreateAt {
+		t.Fatal("should've returned the file's channel, team and post time")
+	}
+
+	if infos := Must(store.FileInfo().GetBatchForIndexing(createAt, info1.Id, createAt+100, channel.TeamId, 10)).([]*model.FileInfoForIndexing); len(infos) != 1 || infos[0].Id != info2.Id {
+		t.Fatal("should've continued after the last file")
+	}
+
+	if infos := Must(store.FileInfo().GetBatchForIndexing(createAt-1, "", createAt+10, channel.TeamId, 10)).([]*model.FileInfoForIndexing); len(infos) != 1 || infos[0].Id != info1.Id {
+		t.Fatal("shouldn't have returned files on posts made after the end time")
+	}
+
+	if infos := Must(store.FileInfo().GetBatchForIndexing(createAt-1, "", createAt+100, "", 10)).([]*model.FileInfoForIndexing); len(infos) != 3 {
+		t.Fatal("should've returned the files from every team", len(infos))
+	}
+}
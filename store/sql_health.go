@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// CheckHealth measures how long a round trip to the master and each replica takes, how far behind the master each
+// replica is and which version the schema has been migrated to. The result still needs to be evaluated against the
+// configured limits.
+func (ss *SqlStore) CheckHealth() *model.DatabaseHealth {
+	health := &model.DatabaseHealth{
+		CheckedAt:             model.GetMillis(),
+		ExpectedSchemaVersion: model.CurrentVersion,
+		Replicas:              []*model.DatabaseConnectionHealth{},
+	}
+
+	health.Master = checkConnectionHealth("master", ss.GetMaster())
+	if len(health.Master.Errors) == 0 {
+		if version, err := ss.GetMaster().SelectStr("SELECT Value FROM Systems WHERE Name='Version'"); err != nil {
+			health.Master.AddError("unable to get the schema version: %v", err.Error())
+		} else {
+			health.SchemaVersion = version
+		}
+	}
+
+	if len(utils.Cfg.SqlSettings.DataSourceReplicas) > 0 {
+		for i, replica := range ss.replicas {
+			check := checkConnectionHealth(fmt.Sprintf("replica-%v", i), replica)
+
+			if len(check.Errors) == 0 {
+				if lag, err := getReplicationLag(replica); err != nil {
+					check.ReplicationLagMilliseconds = -1
+					check.AddError("unable to get the replication lag: %v", err.Error())
+				} else {
+					check.ReplicationLagMilliseconds = lag
+				}
+			}
+
+			health.Replicas = append(health.Replicas, check)
+		}
+	}
+
+	return health
+}
+
+func checkConnectionHealth(name string, db *gorp.DbMap) *model.DatabaseConnectionHealth {
+	check := &model.DatabaseConnectionHealth{
+		Name:   name,
+		Errors: []string{},
+	}
+
+	start := time.Now()
+	if _, err := db.SelectInt("SELECT 1"); err != nil {
+		check.AddError("unable to query the database: %v", err.Error())
+	}
+	check.LatencyMilliseconds = float64(time.Since(start)) / float64(time.Millisecond)
+
+	return check
+}
+
+// getReplicationLag returns how many milliseconds a replica is behind its master, which is 0 if it's caught up or
+// if it isn't actually replicating from anything.
+func getReplicationLag(db *gorp.DbMap) (int64, error) {
+	switch utils.Cfg.SqlSettings.DriverName {
+	case model.DATABASE_DRIVER_POSTGRES:
+		// A replica that has replayed everything it has received is only behind by however long it takes to receive
+		// the next change, which isn't something that it can measure
+		lag, err := db.SelectFloat(`SELECT
+				CASE WHEN NOT pg_is_in_recovery() OR pg_last_xlog_receive_location() = pg_last_xlog_replay_location() THEN 0
+				ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) * 1000, 0) END`)
+		return int64(lag), err
+	case model.DATABASE_DRIVER_MYSQL:
+		return getMySqlReplicationLag(db.Db)
+	default:
+		return 0, nil
+	}
+}
+
+func getMySqlReplicationLag(db *dbsql.DB) (int64, error) {
+	rows, err := db.Query("SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]dbsql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" {
+			continue
+		}
+
+		if values[i] == nil {
+			return 0, errors.New("replication isn't running")
+		}
+
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return seconds * 1000, nil
+	}
+
+	return 0, errors.New("Seconds_Behind_Master is missing from the slave status")
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlInvitationStore struct {
+	*SqlStore
+}
+
+func NewSqlInvitationStore(sqlStore *SqlStore) InvitationStore {
+	s := &SqlInvitationStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Invitation{}, "Invitations").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("Email").SetMaxSize(128)
+		table.ColMap("CreatorId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlInvitationStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_invitations_team_id", "Invitations", "TeamId")
+	s.CreateIndexIfNotExists("idx_invitations_expires_at", "Invitations", "ExpiresAt")
+}
+
+func (s SqlInvitationStore) Save(invitation *model.Invitation) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		invitation.PreSave()
+		if result.Err = invitation.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(invitation); err != nil {
+			result.Err = model.NewLocAppError("SqlInvitationStore.Save", "store.sql_invitation.save.app_error", nil, "id="+invitation.Id+", "+err.Error())
+		} else {
+			result.Data = invitation
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlInvitationStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var invitation model.Invitation
+		if err := s.GetMaster().SelectOne(&invitation, "SELECT * FROM Invitations WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlInvitationStore.Get", "store.sql_invitation.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlInvitationStore.Get", "store.sql_invitation.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = &invitation
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForTeam returns the invitations to a team that haven't been used or expired yet.
+func (s SqlInvitationStore) GetForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var invitations []*model.Invitation
+		if _, err := s.GetReplica().Select(&invitations, "SELECT * FROM Invitations WHERE TeamId = :TeamId AND ExpiresAt > :Now ORDER BY CreateAt DESC", map[string]interface{}{"TeamId": teamId, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlInvitationStore.GetForTeam", "store.sql_invitation.get_for_team.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = invitations
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete removes an invitation. The number of deleted rows is returned so that only one of
+// several concurrent requests to use an invitation succeeds.
+func (s SqlInvitationStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM Invitations WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlInvitationStore.Delete", "store.sql_invitation.delete.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlInvitationStore) PermanentDeleteByTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Invitations WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlInvitationStore.PermanentDeleteByTeam", "store.sql_invitation.delete.app_error", nil, "team_id="+teamId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlInvitationStore) DeleteExpired(before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM Invitations WHERE ExpiresAt < :Before", map[string]interface{}{"Before": before}); err != nil {
+			result.Err = model.NewLocAppError("SqlInvitationStore.DeleteExpired", "store.sql_invitation.delete.app_error", nil, err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
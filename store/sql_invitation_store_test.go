@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestInvitationStoreSaveGet(t *testing.T) {
+	Setup()
+
+	invitation := &model.Invitation{TeamId: model.NewId(), Email: "success+" + model.NewId() + "@simulator.amazonses.com", ExpiresAt: model.GetMillis() + 100000}
+	if err := (<-store.Invitation().Save(invitation)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.Invitation().Get(invitation.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.(*model.Invitation); saved.TeamId != invitation.TeamId || saved.Email != invitation.Email {
+		t.Fatal("should have gotten the saved invitation")
+	}
+
+	if result := <-store.Invitation().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing invitation")
+	}
+
+	if err := (<-store.Invitation().Save(&model.Invitation{TeamId: model.NewId()})).Err; err == nil {
+		t.Fatal("shouldn't have saved an invitation without an expiry time")
+	}
+}
+
+func TestInvitationStoreGetForTeam(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	i1 := Must(store.Invitation().Save(&model.Invitation{TeamId: teamId, ExpiresAt: model.GetMillis() + 100000})).(*model.Invitation)
+	i2 := &model.Invitation{TeamId: teamId, ExpiresAt: model.GetMillis() + 100000}
+	Must(store.Invitation().Save(i2))
+	Must(store.Invitation().Save(&model.Invitation{TeamId: model.NewId(), ExpiresAt: model.GetMillis() + 100000}))
+
+	// save an invitation and then make it expired, since an expiry time in the past isn't valid
+	expired := Must(store.Invitation().Save(&model.Invitation{TeamId: teamId, ExpiresAt: model.GetMillis() + 100000})).(*model.Invitation)
+	if _, err := store.(*SqlStore).GetMaster().Exec("UPDATE Invitations SET ExpiresAt = 1 WHERE Id = :Id", map[string]interface{}{"Id": expired.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.Invitation().GetForTeam(teamId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if invitations := result.Data.([]*model.Invitation); len(invitations) != 2 {
+		t.Fatal("should have returned the team's outstanding invitations", len(invitations))
+	} else {
+		for _, invitation := range invitations {
+			if invitation.Id != i1.Id && invitation.Id != i2.Id {
+				t.Fatal("should only have returned the team's outstanding invitations")
+			}
+		}
+	}
+
+	if result := <-store.Invitation().DeleteExpired(model.GetMillis()); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) < 1 {
+		t.Fatal("should have deleted the expired invitation")
+	}
+
+	if err := (<-store.Invitation().PermanentDeleteByTeam(teamId)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.Invitation().GetForTeam(teamId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Invitation)) != 0 {
+		t.Fatal("should have deleted the team's invitations")
+	}
+}
+
+func TestInvitationStoreDelete(t *testing.T) {
+	Setup()
+
+	invitation := Must(store.Invitation().Save(&model.Invitation{TeamId: model.NewId(), ExpiresAt: model.GetMillis() + 100000})).(*model.Invitation)
+
+	if result := <-store.Invitation().Delete(invitation.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 1 {
+		t.Fatal("should have deleted the invitation")
+	}
+
+	if result := <-store.Invitation().Delete(invitation.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 0 {
+		t.Fatal("shouldn't have deleted the invitation twice")
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlJobStore struct {
+	*SqlStore
+}
+
+func NewSqlJobStore(sqlStore *SqlStore) JobStore {
+	s := &SqlJobStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Job{}, "Jobs").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("Type").SetMaxSize(32)
+		table.ColMap("Status").SetMaxSize(32)
+		table.ColMap("Data").SetMaxSize(1024)
+
+		scheduleTable := db.AddTableWithName(model.JobSchedule{}, "JobSchedules").SetKeys(false, "JobType")
+		scheduleTable.ColMap("JobType").SetMaxSize(32)
+		scheduleTable.ColMap("Schedule").SetMaxSize(model.JOB_SCHEDULE_MAX_LENGTH)
+		scheduleTable.ColMap("Timezone").SetMaxSize(model.JOB_TIMEZONE_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (jss SqlJobStore) CreateIndexesIfNotExists() {
+	jss.CreateIndexIfNotExists("idx_jobs_type", "Jobs", "Type")
+	jss.CreateIndexIfNotExists("idx_jobs_status", "Jobs", "Status")
+}
+
+func (jss SqlJobStore) Save(job *model.Job) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		job.PreSave()
+		if result.Err = job.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := jss.GetMaster().Insert(job); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.Save", "store.sql_job.save.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateOptimistically saves the job only if its status in the database still matches
+// currentStatus. The result data is true if the job was updated, which lets several app
+// servers race to claim the same pending job without more than one of them winning.
+func (jss SqlJobStore) UpdateOptimistically(job *model.Job, currentStatus string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		job.LastActivityAt = model.GetMillis()
+
+		if sqlResult, err := jss.GetMaster().Exec(
+			`UPDATE
+				Jobs
+			SET
+				Status = :Status,
+				StartAt = :StartAt,
+				LastActivityAt = :LastActivityAt,
+				Progress = :Progress,
+				Data = :Data
+			WHERE
+				Id = :Id
+				AND Status = :OldStatus`,
+			map[string]interface{}{
+				"Id":             job.Id,
+				"OldStatus":      currentStatus,
+				"Status":         job.Status,
+				"StartAt":        job.StartAt,
+				"LastActivityAt": job.LastActivityAt,
+				"Progress":       job.Progress,
+				"Data":           model.MapToJson(job.Data),
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.UpdateOptimistically", "store.sql_job.update.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.UpdateOptimistically", "store.sql_job.update.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) UpdateStatus(id string, status string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := jss.GetMaster().Exec(
+			`UPDATE
+				Jobs
+			SET
+				Status = :Status,
+				LastActivityAt = :LastActivityAt
+			WHERE
+				Id = :Id`,
+			map[string]interface{}{"Id": id, "Status": status, "LastActivityAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.UpdateStatus", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var job *model.Job
+
+		if err := jss.GetReplica().SelectOne(&job,
+			`SELECT
+				*
+			FROM
+				Jobs
+			WHERE
+				Id = :Id`, map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewLocAppError("SqlJobStore.Get", "store.sql_job.get.app_error", nil, "id="+id+", "+err.Error())
+				result.Err.StatusCode = 404
+			} else {
+				result.Err = model.NewLocAppError("SqlJobStore.Get", "store.sql_job.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetAllByType(jobType string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.Job
+
+		if _, err := jss.GetReplica().Select(&jobs,
+			`SELECT
+				*
+			FROM
+				Jobs
+			WHERE
+				Type = :Type
+			ORDER BY
+				CreateAt DESC
+			LIMIT
+				:Limit
+			OFFSET
+				:Offset`, map[string]interface{}{"Type": jobType, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.GetAllByType", "store.sql_job.get_all.app_error", nil, "type="+jobType+", "+err.Error())
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetAllByStatus(status string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.Job
+
+		if _, err := jss.GetReplica().Select(&jobs,
+			`SELECT
+				*
+			FROM
+				Jobs
+			WHERE
+				Status = :Status
+			ORDER BY
+				CreateAt ASC`, map[string]interface{}{"Status": status}); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.GetAllByStatus", "store.sql_job.get_all.app_error", nil, "status="+status+", "+err.Error())
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := jss.GetMaster().Exec(
+			`DELETE FROM
+				Jobs
+			WHERE
+				Id = :Id`, map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.Delete", "store.sql_job.delete.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) SaveSchedule(schedule *model.JobSchedule) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		schedule.PreSave()
+		if result.Err = schedule.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := jss.GetMaster().Update(schedule); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.SaveSchedule", "store.sql_job.save_schedule.app_error", nil, "job_type="+schedule.JobType+", "+err.Error())
+		} else if count == 0 {
+			if err := jss.GetMaster().Insert(schedule); err != nil {
+				result.Err = model.NewLocAppError("SqlJobStore.SaveSchedule", "store.sql_job.save_schedule.app_error", nil, "job_type="+schedule.JobType+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = schedule
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SaveScheduleRun saves when a job type was last run and when it's next due without changing the rest of its schedule.
+// The result is false and nothing is saved if the schedule was changed after it was read. A schedule that hasn't been
+// saved before is saved as it is unless it was saved by someone else in the meantime.
+func (jss SqlJobStore) SaveScheduleRun(schedule *model.JobSchedule) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if schedule.UpdateAt == 0 {
+			schedule.PreSave()
+			if result.Err = schedule.IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+
+			if err := jss.GetMaster().Insert(schedule); err == nil {
+				result.Data = true
+			} else if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "jobschedules_pkey", "JobType"}) {
+				result.Data = false
+			} else {
+				result.Err = model.NewLocAppError("SqlJobStore.SaveScheduleRun", "store.sql_job.save_schedule_run.app_error", nil, "job_type="+schedule.JobType+", "+err.Error())
+			}
+		} else {
+			if sqlResult, err := jss.GetMaster().Exec(
+				`UPDATE
+					JobSchedules
+				SET
+					NextRunAt = :NextRunAt,
+					LastRunAt = :LastRunAt
+				WHERE
+					JobType = :JobType
+					AND UpdateAt = :UpdateAt`,
+				map[string]interface{}{"NextRunAt": schedule.NextRunAt, "LastRunAt": schedule.LastRunAt, "JobType": schedule.JobType, "UpdateAt": schedule.UpdateAt}); err != nil {
+				result.Err = model.NewLocAppError("SqlJobStore.SaveScheduleRun", "store.sql_job.save_schedule_run.app_error", nil, "job_type="+schedule.JobType+", "+err.Error())
+			} else if count, err := sqlResult.RowsAffected(); err != nil {
+				result.Err = model.NewLocAppError("SqlJobStore.SaveScheduleRun", "store.sql_job.save_schedule_run.app_error", nil, "job_type="+schedule.JobType+", "+err.Error())
+			} else {
+				result.Data = count == 1
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetAllSchedules() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var schedules []*model.JobSchedule
+
+		// Read from the master so that a run scheduled by another server is seen straight away
+		if _, err := jss.GetMaster().Select(&schedules, "SELECT * FROM JobSchedules ORDER BY JobType"); err != nil {
+			result.Err = model.NewLocAppError("SqlJobStore.GetAllSchedules", "store.sql_job.get_all_schedules.app_error", nil, err.Error())
+		} else {
+			result.Data = schedules
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
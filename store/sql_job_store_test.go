@@ -0,0 +1,196 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestJobSaveGet(t *testing.T) {
+	Setup()
+
+	job := &model.Job{
+		Type: model.JOB_TYPE_SEARCH_INDEXING,
+		Data: map[string]string{
+			"checkpoint": "1234",
+		},
+	}
+
+	if result := <-store.Job().Save(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	defer func() {
+		<-store.Job().Delete(job.Id)
+	}()
+
+	if result := <-store.Job().Get(job.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Job); received.Id != job.Id {
+		t.Fatal("received incorrect job after save")
+	} else if received.Data["checkpoint"] != "1234" {
+		t.Fatal("received incorrect job data after save")
+	}
+}
+
+func TestJobGetAllByType(t *testing.T) {
+	Setup()
+
+	jobType := model.NewId()
+
+	jobs := []*model.Job{
+		{Type: jobType, CreateAt: 1000},
+		{Type: jobType, CreateAt: 999},
+		{Type: model.NewId()},
+	}
+
+	for _, job := range jobs {
+		Must(store.Job().Save(job))
+		defer store.Job().Delete(job.Id)
+	}
+
+	if result := <-store.Job().GetAllByType(jobType, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.Job); len(received) != 2 {
+		t.Fatal("received wrong number of jobs")
+	} else if received[0].Id != jobs[0].Id || received[1].Id != jobs[1].Id {
+		t.Fatal("should've received newest job first")
+	}
+
+	if result := <-store.Job().GetAllByType(jobType, 1, 1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.Job); len(received) != 1 || received[0].Id != jobs[1].Id {
+		t.Fatal("should've received the second page")
+	}
+}
+
+func TestJobUpdateOptimistically(t *testing.T) {
+	Setup()
+
+	job := &model.Job{
+		Type: model.JOB_TYPE_SEARCH_INDEXING,
+	}
+
+	Must(store.Job().Save(job))
+	defer store.Job().Delete(job.Id)
+
+	job.Status = model.JOB_STATUS_IN_PROGRESS
+	job.Progress = 50
+
+	if result := <-store.Job().UpdateOptimistically(job, model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("should not have updated a job with a different status")
+	}
+
+	if result := <-store.Job().UpdateOptimistically(job, model.JOB_STATUS_PENDING); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have updated the job")
+	}
+
+	if received := Must(store.Job().Get(job.Id)).(*model.Job); received.Status != model.JOB_STATUS_IN_PROGRESS || received.Progress != 50 {
+		t.Fatal("should have saved the updated job")
+	}
+
+	if result := <-store.Job().UpdateStatus(job.Id, model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if received := Must(store.Job().Get(job.Id)).(*model.Job); received.Status != model.JOB_STATUS_SUCCESS {
+		t.Fatal("should have updated the status")
+	}
+
+	if result := <-store.Job().GetAllByStatus(model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, received := range result.Data.([]*model.Job) {
+			if received.Id == job.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have found the job by status")
+		}
+	}
+}
+
+func TestJobSaveGetSchedules(t *testing.T) {
+	Setup()
+
+	jobType := "test_" + model.NewId()[:20]
+
+	schedule := &model.JobSchedule{JobType: jobType, Schedule: "0 2 * * *", NextRunAt: 1000}
+	if result := <-store.Job().SaveSchedule(schedule); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	schedule.Schedule = "@hourly"
+	schedule.Timezone = "UTC"
+	if result := <-store.Job().SaveSchedule(schedule); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	found := false
+	for _, received := range Must(store.Job().GetAllSchedules()).([]*model.JobSchedule) {
+		if received.JobType == jobType {
+			found = true
+
+			if *received != *schedule {
+				t.Fatal("should have gotten the updated schedule")
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("should have gotten the saved schedule")
+	}
+
+	if result := <-store.Job().SaveSchedule(&model.JobSchedule{JobType: jobType, Schedule: "junk"}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid schedule")
+	}
+}
+
+func TestJobSaveScheduleRun(t *testing.T) {
+	Setup()
+
+	jobType := "test_" + model.NewId()[:20]
+
+	schedule := &model.JobSchedule{JobType: jobType, Schedule: "@daily", NextRunAt: 1000}
+	if saved := Must(store.Job().SaveScheduleRun(schedule)).(bool); !saved {
+		t.Fatal("should have saved a new schedule")
+	}
+
+	if saved := Must(store.Job().SaveScheduleRun(&model.JobSchedule{JobType: jobType, Schedule: "@daily", NextRunAt: 2000})).(bool); saved {
+		t.Fatal("shouldn't have replaced a schedule saved in the meantime")
+	}
+
+	edited := *schedule
+	edited.Schedule = "@hourly"
+	time.Sleep(2 * time.Millisecond)
+	Must(store.Job().SaveSchedule(&edited))
+
+	schedule.LastRunAt = 1000
+	schedule.NextRunAt = 3000
+	if saved := Must(store.Job().SaveScheduleRun(schedule)).(bool); saved {
+		t.Fatal("shouldn't have saved a run of a schedule that was changed after it was read")
+	}
+
+	edited.LastRunAt = 1000
+	edited.NextRunAt = 4000
+	if saved := Must(store.Job().SaveScheduleRun(&edited)).(bool); !saved {
+		t.Fatal("should have saved the run")
+	}
+
+	for _, received := range Must(store.Job().GetAllSchedules()).([]*model.JobSchedule) {
+		if received.JobType == jobType && (received.Schedule != "@hourly" || received.LastRunAt != 1000 || received.NextRunAt != 4000 || received.UpdateAt != edited.UpdateAt) {
+			t.Fatal("should only have saved the run", received)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlLeaseStore struct {
+	*SqlStore
+}
+
+func NewSqlLeaseStore(sqlStore *SqlStore) LeaseStore {
+	s := &SqlLeaseStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Lease{}, "Leases").SetKeys(false, "Name")
+		table.ColMap("Name").SetMaxSize(model.LEASE_NAME_MAX_LENGTH)
+		table.ColMap("OwnerId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlLeaseStore) CreateIndexesIfNotExists() {
+}
+
+// Acquire takes the lease if nobody holds it or the previous holder let it expire, and extends it if it is already
+// held by lease.OwnerId. The result's data is true if lease.OwnerId holds the lease afterwards.
+func (s SqlLeaseStore) Acquire(lease *model.Lease) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = lease.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		params := map[string]interface{}{
+			"Name":     lease.Name,
+			"OwnerId":  lease.OwnerId,
+			"ExpireAt": lease.ExpireAt,
+			"Now":      model.GetMillis(),
+		}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				Leases
+			SET
+				OwnerId = :OwnerId,
+				ExpireAt = :ExpireAt
+			WHERE
+				Name = :Name
+				AND (OwnerId = :OwnerId OR ExpireAt < :Now)`, params); err != nil {
+			result.Err = model.NewLocAppError("SqlLeaseStore.Acquire", "store.sql_lease.acquire.app_error", nil, "name="+lease.Name+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows > 0 {
+			result.Data = true
+		} else if err := s.GetMaster().Insert(lease); err == nil {
+			result.Data = true
+		} else if count, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM Leases WHERE Name = :Name AND OwnerId = :OwnerId", params); err != nil {
+			result.Err = model.NewLocAppError("SqlLeaseStore.Acquire", "store.sql_lease.acquire.app_error", nil, "name="+lease.Name+", "+err.Error())
+		} else {
+			// Either another server holds the lease or, since MySQL doesn't count rows that an update leaves unchanged,
+			// this server renewed it with the same expiry
+			result.Data = count > 0
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Release gives up the lease if it is held by ownerId so that another server can take it over straight away.
+func (s SqlLeaseStore) Release(name, ownerId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Leases WHERE Name = :Name AND OwnerId = :OwnerId",
+			map[string]interface{}{"Name": name, "OwnerId": ownerId}); err != nil {
+			result.Err = model.NewLocAppError("SqlLeaseStore.Release", "store.sql_lease.release.app_error", nil, "name="+name+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestLeaseStoreAcquire(t *testing.T) {
+	Setup()
+
+	name := "test-" + model.NewId()
+	owner1 := model.NewId()
+	owner2 := model.NewId()
+
+	if !Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner1, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("should have acquired a free lease")
+	}
+
+	if Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner2, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("shouldn't have acquired a lease held by another owner")
+	}
+
+	expireAt := model.GetMillis() - 1000
+	if !Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner1, ExpireAt: expireAt})).(bool) {
+		t.Fatal("should have renewed a lease held by the same owner")
+	}
+
+	if !Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner1, ExpireAt: expireAt})).(bool) {
+		t.Fatal("should have renewed a lease without changing its expiry")
+	}
+
+	if !Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner2, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("should have taken over an expired lease")
+	}
+
+	if Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner1, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("shouldn't have got back a lease that was taken over")
+	}
+
+	if result := <-store.Lease().Acquire(&model.Lease{Name: name, OwnerId: "junk", ExpireAt: model.GetMillis()}); result.Err == nil {
+		t.Fatal("shouldn't have acquired an invalid lease")
+	}
+}
+
+func TestLeaseStoreRelease(t *testing.T) {
+	Setup()
+
+	name := "test-" + model.NewId()
+	owner1 := model.NewId()
+	owner2 := model.NewId()
+
+	Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner1, ExpireAt: model.GetMillis() + 60000}))
+
+	Must(store.Lease().Release(name, owner2))
+
+	if Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner2, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("shouldn't have released a lease held by another owner")
+	}
+
+	Must(store.Lease().Release(name, owner1))
+
+	if !Must(store.Lease().Acquire(&model.Lease{Name: name, OwnerId: owner2, ExpireAt: model.GetMillis() + 60000})).(bool) {
+		t.Fatal("should have acquired a released lease")
+	}
+}
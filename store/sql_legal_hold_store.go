@@ -0,0 +1,279 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	// Conditions to add to a query on the Posts table so that it leaves out the posts held by a legal hold. Every
+	// query that permanently deletes posts must use it.
+	LEGAL_HOLD_EXCLUDE_POSTS = ` AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				LegalHolds
+			WHERE
+				((LegalHolds.UserId != '' AND LegalHolds.UserId = Posts.UserId)
+					OR (LegalHolds.ChannelId != '' AND LegalHolds.ChannelId = Posts.ChannelId))
+				AND (LegalHolds.StartAt = 0 OR Posts.CreateAt >= LegalHolds.StartAt)
+				AND (LegalHolds.EndAt = 0 OR Posts.CreateAt <= LegalHolds.EndAt))`
+
+	// Conditions to add to a query on the FileInfo table so that it leaves out the files held by a legal hold. Every
+	// query that permanently deletes files must use it.
+	LEGAL_HOLD_EXCLUDE_FILES = ` AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				LegalHolds
+			WHERE
+				((LegalHolds.UserId != '' AND LegalHolds.UserId = FileInfo.CreatorId)
+					OR (LegalHolds.ChannelId != '' AND LegalHolds.ChannelId IN (SELECT HeldPosts.ChannelId FROM Posts HeldPosts WHERE HeldPosts.Id = FileInfo.PostId)))
+				AND (LegalHolds.StartAt = 0 OR FileInfo.CreateAt >= LegalHolds.StartAt)
+				AND (LegalHolds.EndAt = 0 OR FileInfo.CreateAt <= LegalHolds.EndAt))`
+)
+
+type SqlLegalHoldStore struct {
+	*SqlStore
+}
+
+func NewSqlLegalHoldStore(sqlStore *SqlStore) LegalHoldStore {
+	s := &SqlLegalHoldStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.LegalHold{}, "LegalHolds").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("DisplayName").SetMaxSize(64)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlLegalHoldStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_legalholds_user_id", "LegalHolds", "UserId")
+	s.CreateIndexIfNotExists("idx_legalholds_channel_id", "LegalHolds", "ChannelId")
+}
+
+func (s SqlLegalHoldStore) Save(hold *model.LegalHold) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(hold.Id) > 0 {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Save", "store.sql_legal_hold.save.existing.app_error", nil, "id="+hold.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		hold.PreSave()
+		if result.Err = hold.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(hold); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Save", "store.sql_legal_hold.save.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = hold
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) Update(hold *model.LegalHold) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		hold.PreUpdate()
+		if result.Err = hold.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(hold); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Update", "store.sql_legal_hold.update.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlLegalHoldStore.Update", "store.sql_legal_hold.get.app_error", nil, "id="+hold.Id, http.StatusNotFound)
+		} else {
+			result.Data = hold
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var hold model.LegalHold
+		if err := s.GetReplica().SelectOne(&hold, "SELECT * FROM LegalHolds WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlLegalHoldStore.Get", "store.sql_legal_hold.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlLegalHoldStore.Get", "store.sql_legal_hold.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = &hold
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) GetAll() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var holds []*model.LegalHold
+		if _, err := s.GetReplica().Select(&holds, "SELECT * FROM LegalHolds ORDER BY DisplayName"); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetAll", "store.sql_legal_hold.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = holds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM LegalHolds WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Delete", "store.sql_legal_hold.delete.app_error", nil, "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlLegalHoldStore.Delete", "store.sql_legal_hold.get.app_error", nil, "id="+id, http.StatusNotFound)
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetExport returns a page of the posts held by a legal hold, oldest first, along with the files attached to them.
+func (s SqlLegalHoldStore) GetExport(hold *model.LegalHold, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Offset": offset, "Limit": limit}
+		query := "SELECT * FROM Posts WHERE "
+
+		if len(hold.UserId) > 0 {
+			query += "UserId = :UserId"
+			props["UserId"] = hold.UserId
+		} else {
+			query += "ChannelId = :ChannelId"
+			props["ChannelId"] = hold.ChannelId
+		}
+
+		if hold.StartAt > 0 {
+			query += " AND CreateAt >= :StartAt"
+			props["StartAt"] = hold.StartAt
+		}
+
+		if hold.EndAt > 0 {
+			query += " AND CreateAt <= :EndAt"
+			props["EndAt"] = hold.EndAt
+		}
+
+		query += " ORDER BY CreateAt ASC, Id ASC LIMIT :Limit OFFSET :Offset"
+
+		export := &model.LegalHoldExport{Posts: []*model.Post{}, FileInfos: []*model.FileInfo{}}
+
+		if _, err := s.GetReplica().Select(&export.Posts, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetExport", "store.sql_legal_hold.get_export.app_error", nil, "id="+hold.Id+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if len(export.Posts) > 0 {
+			postIds := make([]string, len(export.Posts))
+			for i, post := range export.Posts {
+				postIds[i] = post.Id
+			}
+
+			fileProps := make(map[string]interface{})
+			if _, err := s.GetReplica().Select(&export.FileInfos, "SELECT * FROM FileInfo WHERE PostId IN ("+inQueryParams("PostId", postIds, fileProps)+") ORDER BY CreateAt ASC", fileProps); err != nil {
+				result.Err = model.NewLocAppError("SqlLegalHoldStore.GetExport", "store.sql_legal_hold.get_export.app_error", nil, "id="+hold.Id+", "+err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		result.Data = export
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// CountForTarget returns the number of legal holds on the given user or channel. Either of them may be empty to only
+// count the holds on the other.
+func (s SqlLegalHoldStore) CountForTarget(userId string, channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if count, err := s.GetMaster().SelectInt(`
+			SELECT
+				COUNT(*)
+			FROM
+				LegalHolds
+			WHERE
+				(UserId != '' AND UserId = :UserId)
+				OR (ChannelId != '' AND ChannelId = :ChannelId)`, map[string]interface{}{"UserId": userId, "ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.CountForTarget", "store.sql_legal_hold.count_for_target.app_error", nil, "user_id="+userId+", channel_id="+channelId+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestLegalHoldSaveGetUpdateDelete(t *testing.T) {
+	Setup()
+
+	hold := &model.LegalHold{
+		DisplayName: "Investigation",
+		UserId:      model.NewId(),
+	}
+
+	if result := <-store.LegalHold().Save(hold); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LegalHold().Save(hold); result.Err == nil {
+		t.Fatal("shouldn't be able to save an existing hold")
+	}
+
+	if result := <-store.LegalHold().Get(hold.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.LegalHold); received.UserId != hold.UserId {
+		t.Fatal("received incorrect hold")
+	}
+
+	hold.EndAt = 5000
+	if result := <-store.LegalHold().Update(hold); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LegalHold().GetAll(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, received := range result.Data.([]*model.LegalHold) {
+			if received.Id == hold.Id {
+				found = received.EndAt == 5000
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the updated hold")
+		}
+	}
+
+	if result := <-store.LegalHold().Delete(hold.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LegalHold().Get(hold.Id); result.Err == nil {
+		t.Fatal("hold should have been deleted")
+	}
+
+	if result := <-store.LegalHold().Delete(hold.Id); result.Err == nil {
+		t.Fatal("shouldn't be able to delete a hold twice")
+	}
+}
+
+func TestLegalHoldPreventsPermanentDeletion(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	heldUserId := model.NewId()
+
+	c1 := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	c2 := &model.Channel{TeamId: teamId, DisplayName: "Channel2", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c2 = Must(store.Channel().Save(c2)).(*model.Channel)
+
+	Must(store.LegalHold().Save(&model.LegalHold{DisplayName: "User", UserId: heldUserId, EndAt: 2000}))
+	channelHold := Must(store.LegalHold().Save(&model.LegalHold{DisplayName: "Channel", ChannelId: c2.Id})).(*model.LegalHold)
+
+	heldByUser := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: heldUserId, Message: "held", CreateAt: 1000})).(*model.Post)
+	notHeld := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: heldUserId, Message: "after the hold", CreateAt: 3000})).(*model.Post)
+	heldByChannel := Must(store.Post().Save(&model.Post{ChannelId: c2.Id, UserId: model.NewId(), Message: "held", CreateAt: 1000})).(*model.Post)
+
+	heldInfo := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: heldUserId, PostId: heldByUser.Id, Path: "held.txt", CreateAt: 1000, UpdateAt: 1000})).(*model.FileInfo)
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: heldUserId, PostId: notHeld.Id, Path: "file.txt", CreateAt: 3000, UpdateAt: 3000})).(*model.FileInfo)
+
+	if result := <-store.RetentionPolicy().GetPostIdsBefore(&model.RetentionScope{TeamId: teamId}, 4000, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if postIds := result.Data.([]string); len(postIds) != 1 || postIds[0] != notHeld.Id {
+		t.Fatal("should've only returned the post that isn't held", postIds)
+	}
+
+	if result := <-store.RetentionPolicy().GetFileInfosBefore(&model.RetentionScope{TeamId: teamId}, 4000, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 1 || infos[0].Id != info.Id {
+		t.Fatal("should've only returned the file that isn't held")
+	}
+
+	if result := <-store.LegalHold().GetExport(channelHold, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if export := result.Data.(*model.LegalHoldExport); len(export.Posts) != 1 || export.Posts[0].Id != heldByChannel.Id {
+		t.Fatal("should've exported the post in the held channel")
+	}
+
+	if result := <-store.FileInfo().PermanentDeleteBatch([]string{heldInfo.Id, info.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().Get(heldInfo.Id); result.Err != nil {
+		t.Fatal("shouldn't have deleted the held file")
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err == nil {
+		t.Fatal("should've deleted the file that isn't held")
+	}
+
+	if result := <-store.Post().PermanentDeleteBatch([]string{heldByUser.Id, heldByChannel.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count != 0 {
+		t.Fatal("shouldn't have deleted any held posts")
+	}
+
+	Must(store.Post().PermanentDeleteByUser(heldUserId))
+	Must(store.Post().PermanentDeleteByChannel(c2.Id))
+
+	if result := <-store.Post().Get(heldByUser.Id); result.Err != nil {
+		t.Fatal("shouldn't have deleted the post held by the user hold")
+	}
+
+	if result := <-store.Post().Get(heldByChannel.Id); result.Err != nil {
+		t.Fatal("shouldn't have deleted the post held by the channel hold")
+	}
+
+	if result := <-store.Post().Get(notHeld.Id); result.Err == nil {
+		t.Fatal("should've deleted the post that isn't held")
+	}
+
+	Must(store.LegalHold().Delete(channelHold.Id))
+	Must(store.Post().PermanentDeleteByChannel(c2.Id))
+
+	if result := <-store.Post().Get(heldByChannel.Id); result.Err == nil {
+		t.Fatal("should've deleted the post once the hold was removed")
+	}
+}
+
+func TestLegalHoldCountForTarget(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	channelId := model.NewId()
+
+	Must(store.LegalHold().Save(&model.LegalHold{DisplayName: "User", UserId: userId}))
+	Must(store.LegalHold().Save(&model.LegalHold{DisplayName: "User again", UserId: userId, StartAt: 1000}))
+	channelHold := Must(store.LegalHold().Save(&model.LegalHold{DisplayName: "Channel", ChannelId: channelId})).(*model.LegalHold)
+
+	if count := Must(store.LegalHold().CountForTarget(userId, "")).(int64); count != 2 {
+		t.Fatal("should have counted the holds on the user", count)
+	}
+
+	if count := Must(store.LegalHold().CountForTarget("", channelId)).(int64); count != 1 {
+		t.Fatal("should have counted the hold on the channel", count)
+	}
+
+	if count := Must(store.LegalHold().CountForTarget(model.NewId(), model.NewId())).(int64); count != 0 {
+		t.Fatal("shouldn't have counted holds on anything else", count)
+	}
+
+	Must(store.LegalHold().Delete(channelHold.Id))
+
+	if count := Must(store.LegalHold().CountForTarget("", channelId)).(int64); count != 0 {
+		t.Fatal("shouldn't have counted a deleted hold", count)
+	}
+}
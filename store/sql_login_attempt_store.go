@@ -0,0 +1,238 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlLoginAttemptStore struct {
+	*SqlStore
+}
+
+func NewSqlLoginAttemptStore(sqlStore *SqlStore) LoginAttemptStore {
+	s := &SqlLoginAttemptStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.LoginAttempt{}, "LoginAttempts").SetKeys(false, "Type", "Identifier")
+		table.ColMap("Type").SetMaxSize(16)
+		table.ColMap("Identifier").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlLoginAttemptStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_loginattempts_last_failed_at", "LoginAttempts", "LastFailedAt")
+}
+
+func (s SqlLoginAttemptStore) SaveOrUpdate(attempt *model.LoginAttempt) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = attempt.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(attempt); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveOrUpdate", "store.sql_login_attempt.update.app_error", nil, "type="+attempt.Type+", "+err.Error())
+		} else if count == 0 {
+			if err := s.GetMaster().Insert(attempt); err != nil {
+				result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveOrUpdate", "store.sql_login_attempt.save.app_error", nil, "type="+attempt.Type+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = attempt
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// IncrementFailedAttempts counts a failed login in a single statement so that concurrent failures aren't lost, and
+// returns the attempt as it is afterwards. The count and lockouts start over if the attempt isn't locked and hasn't
+// failed since resetBefore.
+func (s SqlLoginAttemptStore) IncrementFailedAttempts(attemptType, identifier string, now int64, resetBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		params := map[string]interface{}{"Type": attemptType, "Identifier": identifier, "Now": now, "ResetBefore": resetBefore}
+
+		// LastFailedAt is set last since MySQL uses the updated values of earlier columns in later assignments
+		increment := func() (int64, error) {
+			sqlResult, err := s.GetMaster().Exec(
+				`UPDATE
+					LoginAttempts
+				SET
+					FailedAttempts = CASE WHEN LockedUntil <= :Now AND LastFailedAt < :ResetBefore THEN 1 ELSE FailedAttempts + 1 END,
+					Lockouts = CASE WHEN LockedUntil <= :Now AND LastFailedAt < :ResetBefore THEN 0 ELSE Lockouts END,
+					LastFailedAt = :Now
+				WHERE
+					Type = :Type
+					AND Identifier = :Identifier`, params)
+			if err != nil {
+				return 0, err
+			}
+
+			return sqlResult.RowsAffected()
+		}
+
+		if count, err := increment(); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.IncrementFailedAttempts", "store.sql_login_attempt.update.app_error", nil, "type="+attemptType+", "+err.Error())
+		} else if count == 0 {
+			attempt := &model.LoginAttempt{Type: attemptType, Identifier: identifier, FailedAttempts: 1, LastFailedAt: now}
+			if result.Err = attempt.IsValid(); result.Err == nil {
+				if err := s.GetMaster().Insert(attempt); err != nil {
+					// another failure may have created the attempt first
+					if _, err := increment(); err != nil {
+						result.Err = model.NewLocAppError("SqlLoginAttemptStore.IncrementFailedAttempts", "store.sql_login_attempt.save.app_error", nil, "type="+attemptType+", "+err.Error())
+					}
+				}
+			}
+		}
+
+		if result.Err == nil {
+			var attempt model.LoginAttempt
+			if err := s.GetMaster().SelectOne(&attempt, "SELECT * FROM LoginAttempts WHERE Type = :Type AND Identifier = :Identifier", params); err != nil {
+				result.Err = model.NewLocAppError("SqlLoginAttemptStore.IncrementFailedAttempts", "store.sql_login_attempt.get.app_error", nil, "type="+attemptType+", "+err.Error())
+			} else {
+				result.Data = &attempt
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Lock locks out an attempt until lockedUntil and starts its count over, but only if it still has at least maxAttempts
+// failures and the given number of lockouts so that it's only locked once when failures happen at the same time. The
+// result is true if the attempt was locked.
+func (s SqlLoginAttemptStore) Lock(attemptType, identifier string, maxAttempts int, lockouts int, lockedUntil int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				LoginAttempts
+			SET
+				FailedAttempts = 0,
+				Lockouts = Lockouts + 1,
+				LockedUntil = :LockedUntil
+			WHERE
+				Type = :Type
+				AND Identifier = :Identifier
+				AND FailedAttempts >= :MaxAttempts
+				AND Lockouts = :Lockouts`,
+			map[string]interface{}{"Type": attemptType, "Identifier": identifier, "MaxAttempts": maxAttempts, "Lockouts": lockouts, "LockedUntil": lockedUntil}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.Lock", "store.sql_login_attempt.update.app_error", nil, "type="+attemptType+", "+err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected > 0
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Unlock lifts the lockout of an attempt and resets its failed login count. The number of lockouts is kept so that
+// the next lockout still lasts longer.
+func (s SqlLoginAttemptStore) Unlock(attemptType, identifier string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE LoginAttempts SET FailedAttempts = 0, LockedUntil = 0 WHERE Type = :Type AND Identifier = :Identifier", map[string]interface{}{"Type": attemptType, "Identifier": identifier}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.Unlock", "store.sql_login_attempt.update.app_error", nil, "type="+attemptType+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLoginAttemptStore) Get(attemptType, identifier string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var attempt model.LoginAttempt
+		if err := s.GetMaster().SelectOne(&attempt, "SELECT * FROM LoginAttempts WHERE Type = :Type AND Identifier = :Identifier", map[string]interface{}{"Type": attemptType, "Identifier": identifier}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlLoginAttemptStore.Get", "store.sql_login_attempt.get.app_error", nil, "type="+attemptType+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlLoginAttemptStore.Get", "store.sql_login_attempt.get.app_error", nil, "type="+attemptType+", "+err.Error())
+			}
+		} else {
+			result.Data = &attempt
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLoginAttemptStore) Delete(attemptType, identifier string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM LoginAttempts WHERE Type = :Type AND Identifier = :Identifier", map[string]interface{}{"Type": attemptType, "Identifier": identifier}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.Delete", "store.sql_login_attempt.delete.app_error", nil, "type="+attemptType+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteOlderThan removes the attempts that haven't failed since the given time and aren't locked.
+func (s SqlLoginAttemptStore) DeleteOlderThan(time int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM LoginAttempts WHERE LastFailedAt < :Time AND LockedUntil < :Time", map[string]interface{}{"Time": time}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.DeleteOlderThan", "store.sql_login_attempt.delete.app_error", nil, err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
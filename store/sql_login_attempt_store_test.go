@@ -0,0 +1,146 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestLoginAttemptStoreSaveOrUpdate(t *testing.T) {
+	Setup()
+
+	attempt := &model.LoginAttempt{
+		Type:           model.LOGIN_ATTEMPT_TYPE_USER,
+		Identifier:     model.NewId(),
+		FailedAttempts: 1,
+		LastFailedAt:   model.GetMillis(),
+	}
+
+	if err := (<-store.LoginAttempt().SaveOrUpdate(attempt)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	attempt.FailedAttempts = 2
+	attempt.Lockouts = 1
+	attempt.LockedUntil = model.GetMillis() + 60000
+	if err := (<-store.LoginAttempt().SaveOrUpdate(attempt)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.LoginAttempt().Get(attempt.Type, attempt.Identifier); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.(*model.LoginAttempt); saved.FailedAttempts != 2 || saved.Lockouts != 1 || saved.LockedUntil != attempt.LockedUntil {
+		t.Fatal("should have updated the attempt")
+	}
+
+	if result := <-store.LoginAttempt().Get(model.LOGIN_ATTEMPT_TYPE_IP, attempt.Identifier); result.Err == nil {
+		t.Fatal("shouldn't have found an attempt of another type")
+	} else if result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have returned not found")
+	}
+
+	if err := (<-store.LoginAttempt().SaveOrUpdate(&model.LoginAttempt{Type: "bad"})).Err; err == nil {
+		t.Fatal("should have failed to save an invalid attempt")
+	}
+}
+
+func TestLoginAttemptStoreDelete(t *testing.T) {
+	Setup()
+
+	attempt := &model.LoginAttempt{
+		Type:         model.LOGIN_ATTEMPT_TYPE_IP,
+		Identifier:   model.NewId(),
+		LastFailedAt: model.GetMillis(),
+	}
+	Must(store.LoginAttempt().SaveOrUpdate(attempt))
+
+	if err := (<-store.LoginAttempt().Delete(attempt.Type, attempt.Identifier)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.LoginAttempt().Get(attempt.Type, attempt.Identifier); result.Err == nil {
+		t.Fatal("should have deleted the attempt")
+	}
+}
+
+func TestLoginAttemptStoreDeleteOlderThan(t *testing.T) {
+	Setup()
+
+	now := model.GetMillis()
+
+	old := &model.LoginAttempt{Type: model.LOGIN_ATTEMPT_TYPE_IP, Identifier: model.NewId(), LastFailedAt: now - 100000}
+	Must(store.LoginAttempt().SaveOrUpdate(old))
+
+	locked := &model.LoginAttempt{Type: model.LOGIN_ATTEMPT_TYPE_IP, Identifier: model.NewId(), LastFailedAt: now - 100000, LockedUntil: now + 100000}
+	Must(store.LoginAttempt().SaveOrUpdate(locked))
+
+	recent := &model.LoginAttempt{Type: model.LOGIN_ATTEMPT_TYPE_IP, Identifier: model.NewId(), LastFailedAt: now}
+	Must(store.LoginAttempt().SaveOrUpdate(recent))
+
+	if err := (<-store.LoginAttempt().DeleteOlderThan(now - 50000)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.LoginAttempt().Get(old.Type, old.Identifier); result.Err == nil {
+		t.Fatal("should have deleted the old attempt")
+	}
+
+	if result := <-store.LoginAttempt().Get(locked.Type, locked.Identifier); result.Err != nil {
+		t.Fatal("shouldn't have deleted the locked attempt")
+	}
+
+	if result := <-store.LoginAttempt().Get(recent.Type, recent.Identifier); result.Err != nil {
+		t.Fatal("shouldn't have deleted the recent attempt")
+	}
+}
+
+func TestLoginAttemptStoreIncrementFailedAttempts(t *testing.T) {
+	Setup()
+
+	now := model.GetMillis()
+	identifier := model.NewId()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := (<-store.LoginAttempt().IncrementFailedAttempts(model.LOGIN_ATTEMPT_TYPE_IP, identifier, now, now-100000)).Err; err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if attempt := Must(store.LoginAttempt().Get(model.LOGIN_ATTEMPT_TYPE_IP, identifier)).(*model.LoginAttempt); attempt.FailedAttempts != 10 {
+		t.Fatalf("should have counted every failure, got %v", attempt.FailedAttempts)
+	}
+
+	if locked := Must(store.LoginAttempt().Lock(model.LOGIN_ATTEMPT_TYPE_IP, identifier, 10, 0, now+100000)).(bool); !locked {
+		t.Fatal("should have locked the attempt")
+	}
+
+	if locked := Must(store.LoginAttempt().Lock(model.LOGIN_ATTEMPT_TYPE_IP, identifier, 10, 0, now+100000)).(bool); locked {
+		t.Fatal("shouldn't have locked the attempt twice")
+	}
+
+	if attempt := Must(store.LoginAttempt().IncrementFailedAttempts(model.LOGIN_ATTEMPT_TYPE_IP, identifier, now+1, now-100000)).(*model.LoginAttempt); attempt.FailedAttempts != 1 || attempt.Lockouts != 1 || attempt.LockedUntil != now+100000 {
+		t.Fatal("should have counted the failure after the lockout")
+	}
+
+	Must(store.LoginAttempt().Unlock(model.LOGIN_ATTEMPT_TYPE_IP, identifier))
+
+	if attempt := Must(store.LoginAttempt().Get(model.LOGIN_ATTEMPT_TYPE_IP, identifier)).(*model.LoginAttempt); attempt.FailedAttempts != 0 || attempt.IsLocked() || attempt.Lockouts != 1 {
+		t.Fatal("should have unlocked the attempt and reset its count")
+	}
+
+	// the count starts over once the attempt hasn't failed for a while
+	if attempt := Must(store.LoginAttempt().IncrementFailedAttempts(model.LOGIN_ATTEMPT_TYPE_IP, identifier, now+200000, now+100000)).(*model.LoginAttempt); attempt.FailedAttempts != 1 || attempt.Lockouts != 0 {
+		t.Fatal("should have started the count over")
+	}
+}
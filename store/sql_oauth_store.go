@@ -4,6 +4,7 @@
 package store
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/go-gorp/gorp"
@@ -43,6 +44,11 @@ func NewSqlOAuthStore(sqlStore *SqlStore) OAuthStore {
 		tableAccess.ColMap("RefreshToken").SetMaxSize(26)
 		tableAccess.ColMap("RedirectUri").SetMaxSize(256)
 		tableAccess.SetUniqueTogether("ClientId", "UserId")
+
+		tableRevoked := db.AddTableWithName(model.OAuthRevokedToken{}, "OAuthRevokedTokens").SetKeys(false, "Token")
+		tableRevoked.ColMap("Token").SetMaxSize(26)
+		tableRevoked.ColMap("ClientId").SetMaxSize(26)
+		tableRevoked.ColMap("UserId").SetMaxSize(26)
 	}
 
 	return as
@@ -54,6 +60,8 @@ func (as SqlOAuthStore) CreateIndexesIfNotExists() {
 	as.CreateIndexIfNotExists("idx_oauthaccessdata_user_id", "OAuthAccessData", "UserId")
 	as.CreateIndexIfNotExists("idx_oauthaccessdata_refresh_token", "OAuthAccessData", "RefreshToken")
 	as.CreateIndexIfNotExists("idx_oauthauthdata_client_id", "OAuthAuthData", "Code")
+	as.CreateIndexIfNotExists("idx_oauthrevokedtokens_client_id", "OAuthRevokedTokens", "ClientId")
+	as.CreateIndexIfNotExists("idx_oauthrevokedtokens_user_id", "OAuthRevokedTokens", "UserId")
 }
 
 func (as SqlOAuthStore) SaveApp(app *model.OAuthApp) StoreChannel {
@@ -330,6 +338,50 @@ func (as SqlOAuthStore) GetAccessDataByUserForApp(userId, clientId string) Store
 	return storeChannel
 }
 
+func (as SqlOAuthStore) GetAccessDataForApp(clientId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var accessData []*model.AccessData
+
+		if _, err := as.GetReplica().Select(&accessData, "SELECT * FROM OAuthAccessData WHERE ClientId = :ClientId",
+			map[string]interface{}{"ClientId": clientId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataForApp", "store.sql_oauth.get_access_data_for_app.app_error", nil, "client_id="+clientId+", "+err.Error())
+		} else {
+			result.Data = accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (as SqlOAuthStore) GetAccessDataByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var accessData []*model.AccessData
+
+		if _, err := as.GetReplica().Select(&accessData, "SELECT * FROM OAuthAccessData WHERE UserId = :UserId",
+			map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataByUser", "store.sql_oauth.get_access_data_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) GetAccessDataByRefreshToken(token string) StoreChannel {
 
 	storeChannel := make(StoreChannel, 1)
@@ -387,8 +439,8 @@ func (as SqlOAuthStore) UpdateAccessData(accessData *model.AccessData) StoreChan
 	go func() {
 		result := StoreResult{}
 
-		if _, err := as.GetMaster().Exec("UPDATE OAuthAccessData SET Token = :Token, ExpiresAt = :ExpiresAt WHERE ClientId = :ClientId AND UserID = :UserId",
-			map[string]interface{}{"Token": accessData.Token, "ExpiresAt": accessData.ExpiresAt, "ClientId": accessData.ClientId, "UserId": accessData.UserId}); err != nil {
+		if _, err := as.GetMaster().Exec("UPDATE OAuthAccessData SET Token = :Token, RefreshToken = :RefreshToken, ExpiresAt = :ExpiresAt WHERE ClientId = :ClientId AND UserID = :UserId",
+			map[string]interface{}{"Token": accessData.Token, "RefreshToken": accessData.RefreshToken, "ExpiresAt": accessData.ExpiresAt, "ClientId": accessData.ClientId, "UserId": accessData.UserId}); err != nil {
 			result.Err = model.NewLocAppError("SqlOAuthStore.Update", "store.sql_oauth.update_access_data.app_error", nil,
 				"clientId="+accessData.ClientId+",userId="+accessData.UserId+", "+err.Error())
 		} else {
@@ -419,6 +471,81 @@ func (as SqlOAuthStore) RemoveAccessData(token string) StoreChannel {
 	return storeChannel
 }
 
+// SaveRevokedToken adds a refresh token to the revocation list. Since a token can only be added
+// once, this fails with store.sql_oauth.save_revoked_token.exists.app_error if it was already used.
+func (as SqlOAuthStore) SaveRevokedToken(revoked *model.OAuthRevokedToken) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		revoked.PreSave()
+		if result.Err = revoked.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := as.GetMaster().Insert(revoked); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"Token", "oauthrevokedtokens_pkey", "PRIMARY"}) {
+				result.Err = model.NewAppError("SqlOAuthStore.SaveRevokedToken", "store.sql_oauth.save_revoked_token.exists.app_error", nil, "client_id="+revoked.ClientId+", user_id="+revoked.UserId, http.StatusBadRequest)
+			} else {
+				result.Err = model.NewLocAppError("SqlOAuthStore.SaveRevokedToken", "store.sql_oauth.save_revoked_token.app_error", nil, err.Error())
+			}
+		} else {
+			result.Data = revoked
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (as SqlOAuthStore) GetRevokedToken(token string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		revoked := model.OAuthRevokedToken{}
+
+		if err := as.GetReplica().SelectOne(&revoked, "SELECT * FROM OAuthRevokedTokens WHERE Token = :Token", map[string]interface{}{"Token": token}); err != nil {
+			result.Err = model.NewAppError("SqlOAuthStore.GetRevokedToken", "store.sql_oauth.get_revoked_token.app_error", nil, err.Error(), http.StatusNotFound)
+		} else {
+			result.Data = &revoked
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteExpiredRevokedTokens removes the revoked tokens whose access expired before the given time. Those can't be
+// exchanged any more, so there's no need to remember that they were used.
+func (as SqlOAuthStore) DeleteExpiredRevokedTokens(before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := as.GetMaster().Exec("DELETE FROM OAuthRevokedTokens WHERE ExpiresAt < :Before", map[string]interface{}{"Before": before}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteExpiredRevokedTokens", "store.sql_oauth.delete_expired_revoked_tokens.app_error", nil, err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) SaveAuthData(authData *model.AuthData) StoreChannel {
 
 	storeChannel := make(StoreChannel, 1)
@@ -496,6 +623,8 @@ func (as SqlOAuthStore) PermanentDeleteAuthDataByUser(userId string) StoreChanne
 		_, err := as.GetMaster().Exec("DELETE FROM OAuthAccessData WHERE UserId = :UserId", map[string]interface{}{"UserId": userId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlOAuthStore.RemoveAuthDataByUserId", "store.sql_oauth.permanent_delete_auth_data_by_user.app_error", nil, "err="+err.Error())
+		} else if _, err := as.GetMaster().Exec("DELETE FROM OAuthRevokedTokens WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RemoveAuthDataByUserId", "store.sql_oauth.permanent_delete_auth_data_by_user.app_error", nil, "err="+err.Error())
 		}
 
 		storeChannel <- result
@@ -524,6 +653,11 @@ func (as SqlOAuthStore) deleteOAuthTokens(transaction *gorp.Transaction, clientI
 		return result
 	}
 
+	if _, err := transaction.Exec("DELETE FROM OAuthRevokedTokens WHERE ClientId = :Id", map[string]interface{}{"Id": clientId}); err != nil {
+		result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "id="+clientId+", err="+err.Error())
+		return result
+	}
+
 	return as.deleteAppExtras(transaction, clientId)
 }
 
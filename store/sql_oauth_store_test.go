@@ -140,6 +140,102 @@ func TestOAuthStoreRemoveAccessData(t *testing.T) {
 	}
 }
 
+func TestOAuthStoreUpdateAccessDataRotatesRefreshToken(t *testing.T) {
+	Setup()
+
+	a1 := Must(store.OAuth().SaveAccessData(&model.AccessData{ClientId: model.NewId(), UserId: model.NewId(), Token: model.NewId(), RefreshToken: model.NewId()})).(*model.AccessData)
+	oldRefreshToken := a1.RefreshToken
+
+	a1.Token = model.NewId()
+	a1.RefreshToken = model.NewId()
+	Must(store.OAuth().UpdateAccessData(a1))
+
+	if result := <-store.OAuth().GetAccessDataByRefreshToken(oldRefreshToken); result.Err == nil {
+		t.Fatal("shouldn't have found the access data by the old refresh token")
+	}
+
+	if result := <-store.OAuth().GetAccessDataByRefreshToken(a1.RefreshToken); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.AccessData).Token != a1.Token {
+		t.Fatal("should have updated the access token")
+	}
+}
+
+func TestOAuthStoreGetAccessDataForAppAndUser(t *testing.T) {
+	Setup()
+
+	clientId := model.NewId()
+	userId := model.NewId()
+
+	Must(store.OAuth().SaveAccessData(&model.AccessData{ClientId: clientId, UserId: userId, Token: model.NewId(), RefreshToken: model.NewId()}))
+	Must(store.OAuth().SaveAccessData(&model.AccessData{ClientId: clientId, UserId: model.NewId(), Token: model.NewId(), RefreshToken: model.NewId()}))
+	Must(store.OAuth().SaveAccessData(&model.AccessData{ClientId: model.NewId(), UserId: userId, Token: model.NewId(), RefreshToken: model.NewId()}))
+	Must(store.OAuth().SaveAccessData(&model.AccessData{ClientId: model.NewId(), UserId: model.NewId(), Token: model.NewId(), RefreshToken: model.NewId()}))
+
+	if accessData := Must(store.OAuth().GetAccessDataForApp(clientId)).([]*model.AccessData); len(accessData) != 2 {
+		t.Fatal("should have returned the tokens of both users of the app")
+	}
+
+	if accessData := Must(store.OAuth().GetAccessDataByUser(userId)).([]*model.AccessData); len(accessData) != 2 {
+		t.Fatal("should have returned the user's tokens for both apps")
+	}
+}
+
+func TestOAuthStoreRevokedTokens(t *testing.T) {
+	Setup()
+
+	revoked := &model.OAuthRevokedToken{Token: model.NewId(), ClientId: model.NewId(), UserId: model.NewId()}
+
+	if result := <-store.OAuth().SaveRevokedToken(revoked); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if revoked.RevokedAt == 0 {
+		t.Fatal("should have set the revocation time")
+	}
+
+	if result := <-store.OAuth().SaveRevokedToken(&model.OAuthRevokedToken{Token: revoked.Token, ClientId: revoked.ClientId, UserId: revoked.UserId}); result.Err == nil {
+		t.Fatal("shouldn't be able to revoke a token twice")
+	} else if result.Err.Id != "store.sql_oauth.save_revoked_token.exists.app_error" {
+		t.Fatal("should have returned the already revoked error", result.Err)
+	}
+
+	if result := <-store.OAuth().GetRevokedToken(revoked.Token); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.OAuthRevokedToken); returned.ClientId != revoked.ClientId || returned.UserId != revoked.UserId {
+		t.Fatal("should have returned the revoked token")
+	}
+
+	if result := <-store.OAuth().GetRevokedToken(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a token that wasn't revoked")
+	}
+
+	Must(store.OAuth().PermanentDeleteAuthDataByUser(revoked.UserId))
+
+	if result := <-store.OAuth().GetRevokedToken(revoked.Token); result.Err == nil {
+		t.Fatal("should have deleted the user's revoked tokens")
+	}
+}
+
+func TestOAuthStoreDeleteExpiredRevokedTokens(t *testing.T) {
+	Setup()
+
+	now := model.GetMillis()
+
+	expired := Must(store.OAuth().SaveRevokedToken(&model.OAuthRevokedToken{Token: model.NewId(), ClientId: model.NewId(), UserId: model.NewId(), ExpiresAt: now - 1000})).(*model.OAuthRevokedToken)
+	unexpired := Must(store.OAuth().SaveRevokedToken(&model.OAuthRevokedToken{Token: model.NewId(), ClientId: model.NewId(), UserId: model.NewId(), ExpiresAt: now + 1000})).(*model.OAuthRevokedToken)
+
+	if deleted := Must(store.OAuth().DeleteExpiredRevokedTokens(now)).(int64); deleted < 1 {
+		t.Fatal("should have deleted the expired token")
+	}
+
+	if result := <-store.OAuth().GetRevokedToken(expired.Token); result.Err == nil {
+		t.Fatal("should have deleted the expired token")
+	}
+
+	if result := <-store.OAuth().GetRevokedToken(unexpired.Token); result.Err != nil {
+		t.Fatal("shouldn't have deleted a token that hasn't expired", result.Err)
+	}
+}
+
 func TestOAuthStoreSaveAuthData(t *testing.T) {
 	Setup()
 
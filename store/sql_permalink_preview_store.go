@@ -0,0 +1,240 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlPermalinkPreviewStore struct {
+	*SqlStore
+}
+
+func NewSqlPermalinkPreviewStore(sqlStore *SqlStore) PermalinkPreviewStore {
+	s := &SqlPermalinkPreviewStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.PermalinkPreview{}, "PermalinkPreviews").SetKeys(false, "PostId", "LinkedPostId")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("LinkedPostId").SetMaxSize(26)
+		table.ColMap("LinkedChannelId").SetMaxSize(26)
+		table.ColMap("LinkedUserId").SetMaxSize(26)
+		table.ColMap("Message").SetMaxSize(1024)
+	}
+
+	return s
+}
+
+func (s SqlPermalinkPreviewStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_permalinkpreviews_linked_post_id", "PermalinkPreviews", "LinkedPostId")
+}
+
+// SaveForPost replaces the previews shown alongside a post with the given ones.
+func (s SqlPermalinkPreviewStore) SaveForPost(postId string, previews []*model.PermalinkPreview) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		for _, preview := range previews {
+			preview.PreSave()
+			if result.Err = preview.IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.SaveForPost", "store.sql_permalink_preview.save_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := transaction.Exec("DELETE FROM PermalinkPreviews WHERE PostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.SaveForPost", "store.sql_permalink_preview.save_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		for _, preview := range previews {
+			if err := transaction.Insert(preview); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.SaveForPost", "store.sql_permalink_preview.save_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.SaveForPost", "store.sql_permalink_preview.save_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = previews
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForPosts returns the previews shown alongside any of the given posts.
+func (s SqlPermalinkPreviewStore) GetForPosts(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var previews []*model.PermalinkPreview
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			if _, err := s.GetReplica().Select(&previews,
+				`SELECT
+					*
+				FROM
+					PermalinkPreviews
+				WHERE
+					PostId IN (`+inQueryParams("PostId", postIds, props)+`)
+				ORDER BY
+					LinkedCreateAt ASC`, props); err != nil {
+				result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.GetForPosts", "store.sql_permalink_preview.get_for_posts.app_error", nil, "post_ids="+strings.Join(postIds, ",")+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = previews
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateForLinkedPost refreshes the previews of a post that's been edited wherever it's linked to.
+func (s SqlPermalinkPreviewStore) UpdateForLinkedPost(linked *model.Post) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				PermalinkPreviews
+			SET
+				Message = :Message,
+				UpdateAt = :UpdateAt
+			WHERE
+				LinkedPostId = :LinkedPostId`,
+			map[string]interface{}{"Message": model.PermalinkPreviewMessage(linked.Message), "UpdateAt": model.GetMillis(), "LinkedPostId": linked.Id}); err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.UpdateForLinkedPost", "store.sql_permalink_preview.update_for_linked_post.app_error", nil, "linked_post_id="+linked.Id+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForPost removes the previews shown alongside a post along with the previews of it shown alongside other posts.
+func (s SqlPermalinkPreviewStore) DeleteForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM PermalinkPreviews WHERE PostId = :PostId OR LinkedPostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.DeleteForPost", "store.sql_permalink_preview.delete_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteBatch removes the previews shown alongside the given posts along with the previews of them shown
+// alongside other posts.
+func (s SqlPermalinkPreviewStore) PermanentDeleteBatch(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			inClause := inQueryParams("PostId", postIds, props)
+			if _, err := s.GetMaster().Exec("DELETE FROM PermalinkPreviews WHERE PostId IN ("+inClause+") OR LinkedPostId IN ("+inClause+")", props); err != nil {
+				result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.PermanentDeleteBatch", "store.sql_permalink_preview.permanent_delete_batch.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteByChannel removes the previews shown alongside the posts in a channel along with the previews of them
+// shown alongside other posts. It's called before the channel's posts are deleted, so it leaves out the posts held by a
+// legal hold in the same way.
+func (s SqlPermalinkPreviewStore) PermanentDeleteByChannel(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		postIds := "SELECT Id FROM Posts WHERE ChannelId = :ChannelId" + LEGAL_HOLD_EXCLUDE_POSTS
+		if _, err := s.GetMaster().Exec("DELETE FROM PermalinkPreviews WHERE PostId IN ("+postIds+") OR LinkedPostId IN ("+postIds+")", map[string]interface{}{"ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.PermanentDeleteByChannel", "store.sql_permalink_preview.permanent_delete_by_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteByUser removes the previews shown alongside a user's posts, and the replies to them, along with the
+// previews of those posts shown alongside other posts. It's called before the user's posts are deleted, so it leaves
+// out the posts held by a legal hold in the same way.
+func (s SqlPermalinkPreviewStore) PermanentDeleteByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		postIds := `SELECT
+				Id
+			FROM
+				Posts
+			WHERE
+				(UserId = :UserId
+					OR RootId IN (SELECT Id FROM Posts WHERE UserId = :UserId AND RootId = ''` + LEGAL_HOLD_EXCLUDE_POSTS + `))` + LEGAL_HOLD_EXCLUDE_POSTS
+		if _, err := s.GetMaster().Exec("DELETE FROM PermalinkPreviews WHERE PostId IN ("+postIds+") OR LinkedPostId IN ("+postIds+")", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPermalinkPreviewStore.PermanentDeleteByUser", "store.sql_permalink_preview.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestPermalinkPreviewStore(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+	linked1 := &model.Post{Id: model.NewId(), ChannelId: model.NewId(), UserId: model.NewId(), CreateAt: 1, Message: "first"}
+	linked2 := &model.Post{Id: model.NewId(), ChannelId: model.NewId(), UserId: model.NewId(), CreateAt: 2, Message: "second"}
+
+	Must(store.PermalinkPreview().SaveForPost(postId, []*model.PermalinkPreview{
+		model.NewPermalinkPreview(postId, linked2),
+		model.NewPermalinkPreview(postId, linked1),
+	}))
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{postId})).([]*model.PermalinkPreview); len(previews) != 2 {
+		t.Fatal("should have saved both previews")
+	} else if previews[0].LinkedPostId != linked1.Id || previews[1].LinkedPostId != linked2.Id {
+		t.Fatal("should be sorted by when the linked posts were made")
+	}
+
+	// saving the previews again replaces the ones already there
+	Must(store.PermalinkPreview().SaveForPost(postId, []*model.PermalinkPreview{model.NewPermalinkPreview(postId, linked1)}))
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{postId})).([]*model.PermalinkPreview); len(previews) != 1 || previews[0].LinkedPostId != linked1.Id {
+		t.Fatal("should have replaced the previews")
+	}
+
+	if result := <-store.PermalinkPreview().SaveForPost(postId, []*model.PermalinkPreview{{PostId: postId}}); result.Err == nil {
+		t.Fatal("shouldn't be able to save an invalid preview")
+	}
+
+	linked1.Message = "edited"
+	if rows := Must(store.PermalinkPreview().UpdateForLinkedPost(linked1)).(int64); rows != 1 {
+		t.Fatal("should have updated the preview")
+	}
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{postId})).([]*model.PermalinkPreview); len(previews) != 1 || previews[0].Message != "edited" {
+		t.Fatal("should have updated the message")
+	}
+
+	otherPostId := model.NewId()
+	Must(store.PermalinkPreview().SaveForPost(otherPostId, []*model.PermalinkPreview{model.NewPermalinkPreview(otherPostId, linked1)}))
+
+	// deleting the linked post removes its previews from every post that links to it
+	Must(store.PermalinkPreview().DeleteForPost(linked1.Id))
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{postId})).([]*model.PermalinkPreview); len(previews) != 0 {
+		t.Fatal("should have deleted the preview")
+	}
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{otherPostId})).([]*model.PermalinkPreview); len(previews) != 0 {
+		t.Fatal("should have deleted the preview from the other post")
+	}
+
+	if previews := Must(store.PermalinkPreview().GetForPosts([]string{})).([]*model.PermalinkPreview); len(previews) != 0 {
+		t.Fatal("shouldn't have returned any previews")
+	}
+}
+
+func TestPermalinkPreviewStorePermanentDelete(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	savePost := func(channelId string, userId string, rootId string) *model.Post {
+		return Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: userId, RootId: rootId, ParentId: rootId, Message: "message"})).(*model.Post)
+	}
+
+	inChannel := savePost(channelId, model.NewId(), "")
+	byUser := savePost(model.NewId(), userId, "")
+	reply := savePost(byUser.ChannelId, model.NewId(), byUser.Id)
+	other := savePost(model.NewId(), model.NewId(), "")
+
+	// the other post links to each of the posts, and each of them links back to it
+	linkedPosts := []*model.Post{inChannel, byUser, reply}
+	previews := []*model.PermalinkPreview{}
+	for _, post := range linkedPosts {
+		previews = append(previews, model.NewPermalinkPreview(other.Id, post))
+		Must(store.PermalinkPreview().SaveForPost(post.Id, []*model.PermalinkPreview{model.NewPermalinkPreview(post.Id, other)}))
+	}
+	Must(store.PermalinkPreview().SaveForPost(other.Id, previews))
+
+	countPreviews := func(postId string) int {
+		return len(Must(store.PermalinkPreview().GetForPosts([]string{postId})).([]*model.PermalinkPreview))
+	}
+
+	Must(store.PermalinkPreview().PermanentDeleteByChannel(channelId))
+
+	if countPreviews(inChannel.Id) != 0 || countPreviews(other.Id) != 2 {
+		t.Fatal("should have deleted the previews of the posts in the channel")
+	}
+
+	Must(store.PermalinkPreview().PermanentDeleteByUser(userId))
+
+	if countPreviews(byUser.Id) != 0 || countPreviews(reply.Id) != 0 || countPreviews(other.Id) != 0 {
+		t.Fatal("should have deleted the previews of the user's posts and the replies to them")
+	}
+
+	Must(store.PermalinkPreview().SaveForPost(other.Id, []*model.PermalinkPreview{model.NewPermalinkPreview(other.Id, inChannel)}))
+	Must(store.PermalinkPreview().PermanentDeleteBatch([]string{inChannel.Id}))
+
+	if countPreviews(other.Id) != 0 {
+		t.Fatal("should have deleted the previews of the deleted posts")
+	}
+}
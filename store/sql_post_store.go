@@ -5,10 +5,12 @@ package store
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/go-gorp/gorp"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -24,6 +26,13 @@ const (
 
 	LAST_POSTS_CACHE_SIZE = 1000
 	LAST_POSTS_CACHE_SEC  = 900 // 15 minutes
+
+	// The most posts that are returned by a single page of channel history
+	POST_HISTORY_MAX_PAGE_SIZE = 1000
+
+	// The most posts that SaveMultiple inserts with one statement, kept low enough that the parameters fit within
+	// SQLite's limit of 999 per statement
+	POST_SAVE_MULTIPLE_INSERT_SIZE = 50
 )
 
 var lastPostTimeCache = utils.NewLru(LAST_POST_TIME_CACHE_SIZE)
@@ -63,8 +72,17 @@ func (s SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_posts_root_id", "Posts", "RootId")
 	s.CreateIndexIfNotExists("idx_posts_user_id", "Posts", "UserId")
 
+	// used to page through a channel's history and to find the posts changed since a given time
+	s.CreateIndexIfNotExists("idx_posts_channel_id_delete_at_create_at", "Posts", "ChannelId, DeleteAt, CreateAt, Id")
+	s.CreateIndexIfNotExists("idx_posts_channel_id_update_at", "Posts", "ChannelId, UpdateAt, Id")
+
 	s.CreateFullTextIndexIfNotExists("idx_posts_message_txt", "Posts", "Message")
 	s.CreateFullTextIndexIfNotExists("idx_posts_hashtags_txt", "Posts", "Hashtags")
+
+	// used to find CJK search terms anywhere in messages
+	if *utils.Cfg.SearchSettings.CJKSegmentation == model.SEARCH_CJK_SEGMENTATION_BIGRAM {
+		s.CreateTrigramIndexIfNotExists("idx_posts_message_trgm", "Posts", "Message")
+	}
 }
 
 func (s SqlPostStore) Save(post *model.Post) StoreChannel {
@@ -115,6 +133,179 @@ func (s SqlPostStore) Save(post *model.Post) StoreChannel {
 	return storeChannel
 }
 
+// SaveMultiple inserts a batch of new posts in a single transaction for bulk imports. Like Save, it keeps the
+// LastPostAt and TotalMsgCount of their channels and the UpdateAt of their root posts up to date, but it does so with
+// one update per channel and thread instead of one per post. Either every post is saved or none of them are.
+func (s SqlPostStore) SaveMultiple(posts []*model.Post) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		for _, post := range posts {
+			if len(post.Id) > 0 {
+				result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save.existing.app_error", nil, "id="+post.Id)
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		for _, post := range posts {
+			post.PreSave()
+			if result.Err = post.IsValid(); result.Err != nil {
+				break
+			}
+		}
+
+		if result.Err == nil {
+			if transaction, err := s.GetMaster().Begin(); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save_multiple.open_transaction.app_error", nil, err.Error())
+			} else {
+				result = s.saveMultipleT(transaction, posts)
+				if result.Err != nil {
+					transaction.Rollback()
+				} else if err := transaction.Commit(); err != nil {
+					result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save_multiple.commit_transaction.app_error", nil, err.Error())
+				}
+			}
+		}
+
+		if result.Err != nil {
+			// Don't leave ids on posts that weren't saved so that they can be saved again
+			for _, post := range posts {
+				post.Id = ""
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// postInsertColumns are the columns of Posts that insertPosts sets, in order
+var postInsertColumns = []string{
+	"Id", "CreateAt", "UpdateAt", "EditAt", "DeleteAt", "UserId", "ChannelId", "RootId", "ParentId", "OriginalId",
+	"Message", "Type", "Props", "Hashtags", "Filenames", "FileIds", "HasReactions", "FileCount", "HasImage",
+}
+
+// insertPosts inserts the given posts with a single multi-row INSERT statement.
+func insertPosts(transaction *gorp.Transaction, posts []*model.Post) error {
+	props := make(map[string]interface{})
+	rows := make([]string, len(posts))
+
+	for i, post := range posts {
+		values := map[string]interface{}{
+			"Id":           post.Id,
+			"CreateAt":     post.CreateAt,
+			"UpdateAt":     post.UpdateAt,
+			"EditAt":       post.EditAt,
+			"DeleteAt":     post.DeleteAt,
+			"UserId":       post.UserId,
+			"ChannelId":    post.ChannelId,
+			"RootId":       post.RootId,
+			"ParentId":     post.ParentId,
+			"OriginalId":   post.OriginalId,
+			"Message":      post.Message,
+			"Type":         post.Type,
+			"Props":        model.StringInterfaceToJson(post.Props),
+			"Hashtags":     post.Hashtags,
+			"Filenames":    model.ArrayToJson(post.Filenames),
+			"FileIds":      model.ArrayToJson(post.FileIds),
+			"HasReactions": post.HasReactions,
+			"FileCount":    post.FileCount,
+			"HasImage":     post.HasImage,
+		}
+
+		params := make([]string, len(postInsertColumns))
+		for j, column := range postInsertColumns {
+			params[j] = ":" + column + strconv.Itoa(i)
+			props[column+strconv.Itoa(i)] = values[column]
+		}
+
+		rows[i] = "(" + strings.Join(params, ", ") + ")"
+	}
+
+	_, err := transaction.Exec("INSERT INTO Posts ("+strings.Join(postInsertColumns, ", ")+") VALUES "+strings.Join(rows, ", "), props)
+
+	return err
+}
+
+type channelPostCounts struct {
+	lastPostAt int64
+	msgCount   int64
+}
+
+func (s SqlPostStore) saveMultipleT(transaction *gorp.Transaction, posts []*model.Post) StoreResult {
+	result := StoreResult{}
+
+	channels := make(map[string]*channelPostCounts)
+	roots := make(map[string]int64)
+
+	for i := 0; i < len(posts); i += POST_SAVE_MULTIPLE_INSERT_SIZE {
+		end := i + POST_SAVE_MULTIPLE_INSERT_SIZE
+		if end > len(posts) {
+			end = len(posts)
+		}
+
+		if err := insertPosts(transaction, posts[i:end]); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save.app_error", nil, "id="+posts[i].Id+", "+err.Error())
+			return result
+		}
+	}
+
+	for _, post := range posts {
+		counts, ok := channels[post.ChannelId]
+		if !ok {
+			counts = &channelPostCounts{}
+			channels[post.ChannelId] = counts
+		}
+
+		if post.UpdateAt > counts.lastPostAt {
+			counts.lastPostAt = post.UpdateAt
+		}
+
+		// don't update TotalMsgCount for unimportant messages so that the channel isn't marked as unread
+		if post.Type != model.POST_JOIN_LEAVE && post.Type != model.POST_JOIN_CHANNEL && post.Type != model.POST_LEAVE_CHANNEL &&
+			post.Type != model.POST_ADD_REMOVE && post.Type != model.POST_ADD_TO_CHANNEL && post.Type != model.POST_REMOVE_FROM_CHANNEL {
+			counts.msgCount++
+		}
+
+		if len(post.RootId) > 0 && post.UpdateAt > roots[post.RootId] {
+			roots[post.RootId] = post.UpdateAt
+		}
+	}
+
+	for channelId, counts := range channels {
+		if _, err := transaction.Exec(
+			`UPDATE
+				Channels
+			SET
+				LastPostAt = CASE WHEN LastPostAt < :LastPostAt THEN :LastPostAt ELSE LastPostAt END,
+				TotalMsgCount = TotalMsgCount + :MsgCount
+			WHERE
+				Id = :ChannelId`,
+			map[string]interface{}{"LastPostAt": counts.lastPostAt, "MsgCount": counts.msgCount, "ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save_multiple.update_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
+			return result
+		}
+	}
+
+	for rootId, updateAt := range roots {
+		if _, err := transaction.Exec("UPDATE Posts SET UpdateAt = :UpdateAt WHERE Id = :RootId AND UpdateAt < :UpdateAt",
+			map[string]interface{}{"UpdateAt": updateAt, "RootId": rootId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.SaveMultiple", "store.sql_post.save_multiple.update_root.app_error", nil, "root_id="+rootId+", "+err.Error())
+			return result
+		}
+	}
+
+	result.Data = posts
+
+	return result
+}
+
 func (s SqlPostStore) Update(newPost *model.Post, oldPost *model.Post) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -322,7 +513,7 @@ func (s SqlPostStore) permanentDelete(postId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"Id": postId, "RootId": postId})
+		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE (Id = :Id OR RootId = :RootId)"+LEGAL_HOLD_EXCLUDE_POSTS, map[string]interface{}{"Id": postId, "RootId": postId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.Delete", "store.sql_post.permanent_delete.app_error", nil, "id="+postId+", err="+err.Error())
 		}
@@ -340,7 +531,7 @@ func (s SqlPostStore) permanentDeleteAllCommentByUser(userId string) StoreChanne
 	go func() {
 		result := StoreResult{}
 
-		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE UserId = :UserId AND RootId != ''", map[string]interface{}{"UserId": userId})
+		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE UserId = :UserId AND RootId != ''"+LEGAL_HOLD_EXCLUDE_POSTS, map[string]interface{}{"UserId": userId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.permanentDeleteAllCommentByUser", "store.sql_post.permanent_delete_all_comments_by_user.app_error", nil, "userId="+userId+", err="+err.Error())
 		}
@@ -373,7 +564,7 @@ func (s SqlPostStore) PermanentDeleteByUser(userId string) StoreChannel {
 
 		for found {
 			var ids []string
-			_, err := s.GetMaster().Select(&ids, "SELECT Id FROM Posts WHERE UserId = :UserId LIMIT 1000", map[string]interface{}{"UserId": userId})
+			_, err := s.GetMaster().Select(&ids, "SELECT Id FROM Posts WHERE UserId = :UserId"+LEGAL_HOLD_EXCLUDE_POSTS+" LIMIT 1000", map[string]interface{}{"UserId": userId})
 			if err != nil {
 				result.Err = model.NewLocAppError("SqlPostStore.PermanentDeleteByUser.select", "store.sql_post.permanent_delete_by_user.app_error", nil, "userId="+userId+", err="+err.Error())
 				storeChannel <- result
@@ -415,7 +606,7 @@ func (s SqlPostStore) PermanentDeleteByChannel(channelId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		if _, err := s.GetMaster().Exec("DELETE FROM Posts WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId}); err != nil {
+		if _, err := s.GetMaster().Exec("DELETE FROM Posts WHERE ChannelId = :ChannelId"+LEGAL_HOLD_EXCLUDE_POSTS, map[string]interface{}{"ChannelId": channelId}); err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.PermanentDeleteByChannel", "store.sql_post.permanent_delete_by_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
 		}
 
@@ -426,21 +617,48 @@ func (s SqlPostStore) PermanentDeleteByChannel(channelId string) StoreChannel {
 	return storeChannel
 }
 
-func (s SqlPostStore) GetPosts(channelId string, offset int, limit int, allowFromCache bool) StoreChannel {
+func (s SqlPostStore) PermanentDeleteBatch(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			if sqlResult, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id IN ("+inQueryParams("PostId", postIds, props)+")"+LEGAL_HOLD_EXCLUDE_POSTS, props); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.PermanentDeleteBatch", "store.sql_post.permanent_delete_batch.app_error", nil, err.Error())
+			} else {
+				rows, _ := sqlResult.RowsAffected()
+				result.Data = rows
+			}
+		} else {
+			result.Data = int64(0)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPosts returns the most recent page of a channel's history. Older pages are read with GetPostsBefore, starting from
+// the earliest post on this one.
+func (s SqlPostStore) GetPosts(channelId string, limit int, allowFromCache bool) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
 	go func() {
 		result := StoreResult{}
 		metrics := einterfaces.GetMetricsInterface()
 
-		if limit > 1000 {
+		if limit > POST_HISTORY_MAX_PAGE_SIZE {
 			result.Err = model.NewLocAppError("SqlPostStore.GetLinearPosts", "store.sql_post.get_posts.app_error", nil, "channelId="+channelId)
 			storeChannel <- result
 			close(storeChannel)
 			return
 		}
 
-		if allowFromCache && offset == 0 && limit == 60 {
+		if allowFromCache && limit == 60 {
 			if cacheItem, ok := lastPostsCache.Get(channelId); ok {
 				if metrics != nil {
 					metrics.IncrementMemCacheHitCounter("Last Posts Cache")
@@ -461,8 +679,8 @@ func (s SqlPostStore) GetPosts(channelId string, offset int, limit int, allowFro
 			}
 		}
 
-		rpc := s.getRootPosts(channelId, offset, limit)
-		cpc := s.getParentsPosts(channelId, offset, limit)
+		rpc := s.getRootPosts(channelId, latestPostCursor, limit)
+		cpc := s.getParentsPosts(channelId, latestPostCursor, limit)
 
 		if rpr := <-rpc; rpr.Err != nil {
 			result.Err = rpr.Err
@@ -485,7 +703,7 @@ func (s SqlPostStore) GetPosts(channelId string, offset int, limit int, allowFro
 
 			list.MakeNonNil()
 
-			if offset == 0 && limit == 60 {
+			if limit == 60 {
 				lastPostsCache.AddWithExpiresInSecs(channelId, list, LAST_POSTS_CACHE_SEC)
 			}
 
@@ -529,18 +747,31 @@ func (s SqlPostStore) GetPostsSince(channelId string, time int64, allowFromCache
 			}
 		}
 
+		// The oldest changes are returned first so that a client that receives a full page can get the rest by asking
+		// again for the posts changed since the last one that it received. Since that only uses UpdateAt as a cursor, a
+		// page always includes every post changed at the same time as its last one, even if that makes it bigger.
+		page := `ChannelId = :ChannelId
+			    AND UpdateAt > :Time
+			    AND UpdateAt <= (SELECT MAX(UpdateAt) FROM (SELECT
+			        UpdateAt
+			    FROM
+			        Posts
+			    WHERE
+			        ChannelId = :ChannelId
+			            AND UpdateAt > :Time
+			    ORDER BY UpdateAt ASC
+			    LIMIT :Limit) boundary_tab)`
+
 		var posts []*model.Post
 		_, err := s.GetReplica().Select(&posts,
-			`(SELECT
+			`SELECT
 			    *
 			FROM
 			    Posts
 			WHERE
-			    (UpdateAt > :Time
-			        AND ChannelId = :ChannelId)
-			LIMIT 1000)
+			    `+page+`
 			UNION
-			(SELECT
+			SELECT
 			    *
 			FROM
 			    Posts
@@ -552,11 +783,9 @@ func (s SqlPostStore) GetPostsSince(channelId string, time int64, allowFromCache
 			    FROM
 			        Posts
 			    WHERE
-			        UpdateAt > :Time
-			            AND ChannelId = :ChannelId
-			    LIMIT 1000) temp_tab))
-			ORDER BY CreateAt DESC`,
-			map[string]interface{}{"ChannelId": channelId, "Time": time})
+			        `+page+`) temp_tab)
+			ORDER BY CreateAt DESC, Id DESC`,
+			map[string]interface{}{"ChannelId": channelId, "Time": time, "Limit": POST_HISTORY_MAX_PAGE_SIZE})
 
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetPostsSince", "store.sql_post.get_posts_since.app_error", nil, "channelId="+channelId+err.Error())
@@ -576,7 +805,10 @@ func (s SqlPostStore) GetPostsSince(channelId string, time int64, allowFromCache
 				}
 			}
 
-			lastPostTimeCache.AddWithExpiresInSecs(channelId, latestUpdate, LAST_POST_TIME_CACHE_SEC)
+			// a full page means that there may be newer changes, so the latest update time isn't known
+			if len(list.Order) < POST_HISTORY_MAX_PAGE_SIZE {
+				lastPostTimeCache.AddWithExpiresInSecs(channelId, latestUpdate, LAST_POST_TIME_CACHE_SEC)
+			}
 
 			result.Data = list
 		}
@@ -588,47 +820,71 @@ func (s SqlPostStore) GetPostsSince(channelId string, time int64, allowFromCache
 	return storeChannel
 }
 
-func (s SqlPostStore) GetPostsBefore(channelId string, postId string, numPosts int, offset int) StoreChannel {
-	return s.getPostsAround(channelId, postId, numPosts, offset, true)
+// GetPostsBefore returns the posts created before the given one, which is used as the cursor to page back through a
+// channel's history.
+func (s SqlPostStore) GetPostsBefore(channelId string, postId string, numPosts int) StoreChannel {
+	return s.getPostsAround(channelId, postId, numPosts, true)
 }
 
-func (s SqlPostStore) GetPostsAfter(channelId string, postId string, numPosts int, offset int) StoreChannel {
-	return s.getPostsAround(channelId, postId, numPosts, offset, false)
+// GetPostsAfter returns the posts created after the given one, which is used as the cursor to page forward through a
+// channel's history.
+func (s SqlPostStore) GetPostsAfter(channelId string, postId string, numPosts int) StoreChannel {
+	return s.getPostsAround(channelId, postId, numPosts, false)
 }
 
-func (s SqlPostStore) getPostsAround(channelId string, postId string, numPosts int, offset int, before bool) StoreChannel {
+func (s SqlPostStore) getPostsAround(channelId string, postId string, numPosts int, before bool) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
 	go func() {
 		result := StoreResult{}
 
-		var direction string
-		var sort string
+		sort := "ASC"
 		if before {
-			direction = "<"
 			sort = "DESC"
-		} else {
-			direction = ">"
-			sort = "ASC"
 		}
 
+		if numPosts > POST_HISTORY_MAX_PAGE_SIZE {
+			numPosts = POST_HISTORY_MAX_PAGE_SIZE
+		}
+
+		// Look up the post that the page starts from so that its (CreateAt, Id) can be used as a cursor.
+		// Seeking past it on the index is much cheaper than skipping rows with an offset, and the Id breaks
+		// ties between posts created in the same millisecond so that none are skipped or repeated.
+		var start []postCursor
+		if _, err := s.GetReplica().Select(&start, "SELECT CreateAt, Id FROM Posts WHERE Id = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostContext", "store.sql_post.get_posts_around.get.app_error", nil, "channelId="+channelId+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if len(start) == 0 {
+			// there's nothing around a post that doesn't exist
+			list := &model.PostList{}
+			list.MakeNonNil()
+			result.Data = list
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		props := map[string]interface{}{"ChannelId": channelId, "CursorCreateAt": start[0].CreateAt, "CursorId": start[0].Id, "NumPosts": numPosts}
+
 		var posts []*model.Post
 		var parents []*model.Post
 		_, err1 := s.GetReplica().Select(&posts,
-			`(SELECT
+			`SELECT
 			    *
 			FROM
 			    Posts
 			WHERE
-				(CreateAt `+direction+` (SELECT CreateAt FROM Posts WHERE Id = :PostId)
-			        AND ChannelId = :ChannelId
-					AND DeleteAt = 0)
-			ORDER BY CreateAt `+sort+`
-			LIMIT :NumPosts
-			OFFSET :Offset)`,
-			map[string]interface{}{"ChannelId": channelId, "PostId": postId, "NumPosts": numPosts, "Offset": offset})
+				(ChannelId = :ChannelId
+					AND DeleteAt = 0
+					AND `+postCursorClause(before)+`)
+			ORDER BY CreateAt `+sort+`, Id `+sort+`
+			LIMIT :NumPosts`, props)
 		_, err2 := s.GetReplica().Select(&parents,
-			`(SELECT
+			`SELECT
 			    *
 			FROM
 			    Posts
@@ -640,15 +896,13 @@ func (s SqlPostStore) getPostsAround(channelId string, postId string, numPosts i
 			    FROM
 			        Posts
 			    WHERE
-					(CreateAt `+direction+` (SELECT CreateAt FROM Posts WHERE Id = :PostId)
-						AND ChannelId = :ChannelId
-						AND DeleteAt = 0)
-					ORDER BY CreateAt `+sort+`
-					LIMIT :NumPosts
-					OFFSET :Offset)
-			    temp_tab))
-			ORDER BY CreateAt DESC`,
-			map[string]interface{}{"ChannelId": channelId, "PostId": postId, "NumPosts": numPosts, "Offset": offset})
+					(ChannelId = :ChannelId
+						AND DeleteAt = 0
+						AND `+postCursorClause(before)+`)
+					ORDER BY CreateAt `+sort+`, Id `+sort+`
+					LIMIT :NumPosts)
+			    temp_tab)
+			ORDER BY CreateAt DESC, Id DESC`, props)
 
 		if err1 != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetPostContext", "store.sql_post.get_posts_around.get.app_error", nil, "channelId="+channelId+err1.Error())
@@ -686,14 +940,14 @@ func (s SqlPostStore) getPostsAround(channelId string, postId string, numPosts i
 	return storeChannel
 }
 
-func (s SqlPostStore) getRootPosts(channelId string, offset int, limit int) StoreChannel {
+func (s SqlPostStore) getRootPosts(channelId string, cursor postCursor, limit int) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
 	go func() {
 		result := StoreResult{}
 
 		var posts []*model.Post
-		_, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE ChannelId = :ChannelId AND DeleteAt = 0 ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset", map[string]interface{}{"ChannelId": channelId, "Offset": offset, "Limit": limit})
+		_, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE ChannelId = :ChannelId AND DeleteAt = 0 AND "+postCursorClause(true)+" ORDER BY CreateAt DESC, Id DESC LIMIT :Limit", map[string]interface{}{"ChannelId": channelId, "CursorCreateAt": cursor.CreateAt, "CursorId": cursor.Id, "Limit": limit})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetLinearPosts", "store.sql_post.get_root_posts.app_error", nil, "channelId="+channelId+err.Error())
 		} else {
@@ -707,7 +961,7 @@ func (s SqlPostStore) getRootPosts(channelId string, offset int, limit int) Stor
 	return storeChannel
 }
 
-func (s SqlPostStore) getParentsPosts(channelId string, offset int, limit int) StoreChannel {
+func (s SqlPostStore) getParentsPosts(channelId string, cursor postCursor, limit int) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
 	go func() {
@@ -730,15 +984,16 @@ func (s SqlPostStore) getParentsPosts(channelId string, offset int, limit int) S
 			    WHERE
 			        ChannelId = :ChannelId1
 			            AND DeleteAt = 0
-			    ORDER BY CreateAt DESC
-			    LIMIT :Limit OFFSET :Offset) q3
+			            AND `+postCursorClause(true)+`
+			    ORDER BY CreateAt DESC, Id DESC
+			    LIMIT :Limit) q3
 			    WHERE q3.RootId != '') q1
 			    ON q1.RootId = q2.Id OR q1.RootId = q2.RootId
 			WHERE
 			    ChannelId = :ChannelId2
 			        AND DeleteAt = 0
 			ORDER BY CreateAt`,
-			map[string]interface{}{"ChannelId1": channelId, "Offset": offset, "Limit": limit, "ChannelId2": channelId})
+			map[string]interface{}{"ChannelId1": channelId, "CursorCreateAt": cursor.CreateAt, "CursorId": cursor.Id, "Limit": limit, "ChannelId2": channelId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetLinearPosts", "store.sql_post.get_parents_posts.app_error", nil, "channelId="+channelId+err.Error())
 		} else {
@@ -752,6 +1007,25 @@ func (s SqlPostStore) getParentsPosts(channelId string, offset int, limit int) S
 	return storeChannel
 }
 
+// postCursor is a position in a channel's history, which is ordered by CreateAt and then by Id.
+type postCursor struct {
+	CreateAt int64
+	Id       string
+}
+
+// latestPostCursor comes after every post so that paging back from it starts with the most recent one.
+var latestPostCursor = postCursor{CreateAt: math.MaxInt64}
+
+// postCursorClause matches the posts before or after the cursor given by the CursorCreateAt and CursorId parameters.
+func postCursorClause(before bool) string {
+	direction := ">"
+	if before {
+		direction = "<"
+	}
+
+	return "(CreateAt " + direction + " :CursorCreateAt OR (CreateAt = :CursorCreateAt AND Id " + direction + " :CursorId))"
+}
+
 var specialSearchChar = []string{
 	"<",
 	">",
@@ -764,6 +1038,85 @@ var specialSearchChar = []string{
 	":",
 }
 
+// generateFileNameSearchClause matches the posts with an attached file whose name contains any of the given
+// terms. Since it can't use an index, it's only used when the search asks for file names with the file: flag.
+// Each term is added to params using paramPrefix.
+func generateFileNameSearchClause(paramPrefix string, terms []string, params map[string]interface{}) string {
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		paramName := paramPrefix + strconv.Itoa(i)
+		params[paramName] = "%" + escapeLikeTerm(strings.ToLower(term)) + "%"
+
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+			clauses[i] = "lower(Name) LIKE :" + paramName
+		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+			// SQLite doesn't have a default escape character
+			clauses[i] = "Name LIKE :" + paramName + " ESCAPE '\\'"
+		} else {
+			clauses[i] = "Name LIKE :" + paramName
+		}
+	}
+
+	return `Id IN (
+					SELECT
+						PostId
+					FROM
+						FileInfo
+					WHERE
+						DeleteAt = 0
+						AND PostId != ''
+						AND (` + strings.Join(clauses, " OR ") + `))`
+}
+
+var cjkSearchTermPattern = regexp.MustCompile(`-?"[^"]*"|[^\s"]+`)
+
+// splitCJKSearchTerms separates the words and quoted phrases in a search that contain CJK characters from the rest of
+// the terms. Those that start with a hyphen are returned separately as terms that posts mustn't contain. Quoted phrases
+// are kept whole, including their spaces, since they're matched as substrings rather than word by word.
+func splitCJKSearchTerms(terms string) (otherTerms string, cjkTerms []string, excludedCJKTerms []string) {
+	var other []string
+
+	for _, term := range cjkSearchTermPattern.FindAllString(terms, -1) {
+		if !model.ContainsCJK(term) {
+			other = append(other, term)
+			continue
+		}
+
+		excluded := strings.HasPrefix(term, "-")
+		term = strings.TrimPrefix(term, "-")
+
+		if strings.HasPrefix(term, "\"") {
+			term = strings.Trim(term, "\"")
+		} else {
+			term = strings.Trim(term, "*")
+		}
+
+		if term == "" {
+			continue
+		}
+
+		if excluded {
+			excludedCJKTerms = append(excludedCJKTerms, term)
+		} else {
+			cjkTerms = append(cjkTerms, term)
+		}
+	}
+
+	return strings.Join(other, " "), cjkTerms, excludedCJKTerms
+}
+
+// generateExcludedLikeSearchClause matches the rows where the column doesn't contain any of the terms.
+func generateExcludedLikeSearchClause(paramPrefix string, column string, terms []string, params map[string]interface{}) string {
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		paramName := paramPrefix + strconv.Itoa(i)
+		params[paramName] = "%" + escapeLikeTerm(term) + "%"
+		clauses[i] = column + " NOT LIKE :" + paramName + " ESCAPE '\\'"
+	}
+
+	return "(" + strings.Join(clauses, " AND ") + ")"
+}
+
 func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchParams) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -778,7 +1131,7 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 		termMap := map[string]bool{}
 		terms := params.Terms
 
-		if terms == "" && len(params.InChannels) == 0 && len(params.FromUsers) == 0 {
+		if terms == "" && len(params.InChannels) == 0 && len(params.FromUsers) == 0 && !params.HasFile && !params.HasImage && len(params.FileNames) == 0 {
 			result.Data = []*model.Post{}
 			storeChannel <- result
 			return
@@ -792,11 +1145,26 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			}
 		}
 
+		// The full text indexes split words on spaces, so they can't find a word that's part of a longer run of CJK
+		// characters. Those terms are looked for anywhere in the message instead, which matches the same posts as
+		// searching for each of their bigrams in order would.
+		var cjkTerms, excludedCJKTerms []string
+		if *utils.Cfg.SearchSettings.CJKSegmentation == model.SEARCH_CJK_SEGMENTATION_BIGRAM && !params.IsHashtag && model.ContainsCJK(terms) {
+			terms, cjkTerms, excludedCJKTerms = splitCJKSearchTerms(terms)
+		}
+
 		// these chars have special meaning and can be treated as spaces
 		for _, c := range specialSearchChar {
 			terms = strings.Replace(terms, c, " ", -1)
 		}
 
+		// excluded terms only narrow down what the rest of the search finds
+		if strings.TrimSpace(terms) == "" && len(cjkTerms) == 0 && len(params.InChannels) == 0 && len(params.FromUsers) == 0 && !params.HasFile && !params.HasImage && len(params.FileNames) == 0 {
+			result.Data = &model.PostList{}
+			storeChannel <- result
+			return
+		}
+
 		var posts []*model.Post
 
 		searchQuery := `
@@ -808,6 +1176,8 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 				DeleteAt = 0
 				AND Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
 				POST_FILTER
+				ATTACHMENT_FILTER
+				FILE_NAME_FILTER
 				AND ChannelId IN (
 					SELECT
 						Id
@@ -880,9 +1250,25 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			searchQuery = strings.Replace(searchQuery, "POST_FILTER", "", 1)
 		}
 
+		if params.HasImage {
+			searchQuery = strings.Replace(searchQuery, "ATTACHMENT_FILTER", "AND HasImage = true", 1)
+		} else if params.HasFile {
+			searchQuery = strings.Replace(searchQuery, "ATTACHMENT_FILTER", "AND FileCount > 0", 1)
+		} else {
+			searchQuery = strings.Replace(searchQuery, "ATTACHMENT_FILTER", "", 1)
+		}
+
+		if len(params.FileNames) > 0 {
+			fileNameFilter := "AND " + generateFileNameSearchClause("FileName", params.FileNames, queryParams)
+			searchQuery = strings.Replace(searchQuery, "FILE_NAME_FILTER", fileNameFilter, 1)
+		} else {
+			searchQuery = strings.Replace(searchQuery, "FILE_NAME_FILTER", "", 1)
+		}
+
+		var searchClause string
 		if terms == "" {
-			// we've already confirmed that we have a channel or user to search for
-			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
+			// we've already confirmed that we have a channel, user or attachment to search for
+			searchClause = ""
 		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 			// Parse text for wildcards
 			if wildcard, err := regexp.Compile("\\*($| )"); err == nil {
@@ -895,11 +1281,9 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 				terms = strings.Join(strings.Fields(terms), " & ")
 			}
 
-			searchClause := fmt.Sprintf("AND %s @@  to_tsquery(:Terms)", searchType)
-			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
+			searchClause = fmt.Sprintf("%s @@  to_tsquery(:Terms)", searchType)
 		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
-			searchClause := fmt.Sprintf("AND MATCH (%s) AGAINST (:Terms IN BOOLEAN MODE)", searchType)
-			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
+			searchClause = fmt.Sprintf("MATCH (%s) AGAINST (:Terms IN BOOLEAN MODE)", searchType)
 
 			if !params.OrTerms {
 				splitTerms := strings.Fields(terms)
@@ -909,6 +1293,39 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 
 				terms = strings.Join(splitTerms, " ")
 			}
+		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+			// every term is already matched as a prefix, so wildcards and quotes can be ignored
+			splitTerms := strings.Fields(strings.NewReplacer("*", " ", "\"", " ").Replace(terms))
+
+			searchClause = strings.TrimPrefix(generateSqliteSearchClause([]string{searchType}, splitTerms, params.OrTerms, queryParams), "AND ")
+		}
+
+		if len(cjkTerms) > 0 {
+			cjkClause := strings.TrimPrefix(generateLikeSearchClause("CJKTerm", []string{searchType}, cjkTerms, params.OrTerms, queryParams), "AND ")
+
+			if searchClause == "" {
+				searchClause = cjkClause
+			} else if params.OrTerms {
+				searchClause = "(" + searchClause + " OR " + cjkClause + ")"
+			} else {
+				searchClause = "(" + searchClause + " AND " + cjkClause + ")"
+			}
+		}
+
+		if len(excludedCJKTerms) > 0 {
+			excludedClause := generateExcludedLikeSearchClause("ExcludedCJKTerm", searchType, excludedCJKTerms, queryParams)
+
+			if searchClause == "" {
+				searchClause = excludedClause
+			} else {
+				searchClause = "(" + searchClause + " AND " + excludedClause + ")"
+			}
+		}
+
+		if searchClause == "" {
+			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
+		} else {
+			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "AND "+searchClause, 1)
 		}
 
 		queryParams["Terms"] = terms
@@ -936,6 +1353,10 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			list.AddOrder(p.Id)
 		}
 
+		if result.Err == nil {
+			s.addSearchMatches(list, params, terms, cjkTerms)
+		}
+
 		list.MakeNonNil()
 
 		result.Data = list
@@ -986,6 +1407,22 @@ func (s SqlPostStore) AnalyticsUserCountsWithPostsByDay(teamId string) StoreChan
 				GROUP BY DATE(TO_TIMESTAMP(Posts.CreateAt / 1000))
 				ORDER BY Name DESC
 				LIMIT 30`
+		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+			query =
+				`SELECT
+					DATE(Posts.CreateAt / 1000, 'unixepoch') AS Name, COUNT(DISTINCT Posts.UserId) AS Value
+				FROM Posts`
+
+			if len(teamId) > 0 {
+				query += " INNER JOIN Channels ON Posts.ChannelId = Channels.Id AND Channels.TeamId = :TeamId AND"
+			} else {
+				query += " WHERE"
+			}
+
+			query += ` Posts.CreateAt >= :StartTime AND Posts.CreateAt <= :EndTime
+				GROUP BY DATE(Posts.CreateAt / 1000, 'unixepoch')
+				ORDER BY Name DESC
+				LIMIT 30`
 		}
 
 		end := utils.MillisFromTime(utils.EndOfDay(utils.Yesterday()))
@@ -1050,6 +1487,23 @@ func (s SqlPostStore) AnalyticsPostCountsByDay(teamId string) StoreChannel {
 				GROUP BY DATE(TO_TIMESTAMP(Posts.CreateAt / 1000))
 				ORDER BY Name DESC
 				LIMIT 30`
+		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+			query =
+				`SELECT
+					DATE(Posts.CreateAt / 1000, 'unixepoch') AS Name, Count(Posts.Id) AS Value
+				FROM Posts`
+
+			if len(teamId) > 0 {
+				query += " INNER JOIN Channels ON Posts.ChannelId = Channels.Id  AND Channels.TeamId = :TeamId AND"
+			} else {
+				query += " WHERE"
+			}
+
+			query += ` Posts.CreateAt <= :EndTime
+				            AND Posts.CreateAt >= :StartTime
+				GROUP BY DATE(Posts.CreateAt / 1000, 'unixepoch')
+				ORDER BY Name DESC
+				LIMIT 30`
 		}
 
 		end := utils.MillisFromTime(utils.EndOfDay(utils.Yesterday()))
@@ -1112,3 +1566,88 @@ func (s SqlPostStore) AnalyticsPostCount(teamId string, mustHaveFile bool, mustH
 
 	return storeChannel
 }
+
+// GetPostsBatchForIndexing returns up to limit posts created before endTime, ordered by
+// (CreateAt, Id) and starting after the post identified by startTime and startPostId. Paging
+// on both columns lets a caller resume exactly where a previous batch left off even when
+// several posts share the same CreateAt. If teamId is set, only posts in that team's channels
+// are returned.
+func (s SqlPostStore) GetPostsBatchForIndexing(startTime int64, startPostId string, endTime int64, teamId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		teamFilter := ""
+		if teamId != "" {
+			teamFilter = "AND Channels.TeamId = :TeamId"
+		}
+
+		var posts []*model.PostForIndexing
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				Posts.*,
+				Channels.TeamId
+			FROM
+				Posts
+			LEFT JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				(Posts.CreateAt > :StartTime
+					OR (Posts.CreateAt = :StartTime AND Posts.Id > :StartPostId))
+				AND Posts.CreateAt < :EndTime
+				AND Posts.DeleteAt = 0
+				`+teamFilter+`
+			ORDER BY
+				Posts.CreateAt, Posts.Id
+			LIMIT
+				:Limit`,
+			map[string]interface{}{"StartTime": startTime, "StartPostId": startPostId, "EndTime": endTime, "TeamId": teamId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsBatchForIndexing", "store.sql_post.get_posts_batch_for_indexing.app_error", nil, err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsBatchForExport returns up to limit of a channel's posts, ordered by (CreateAt, Id) and
+// starting after the post identified by startTime and startPostId in the same way as
+// GetPostsBatchForIndexing.
+func (s SqlPostStore) GetPostsBatchForExport(channelId string, startTime int64, startPostId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND (CreateAt > :StartTime
+					OR (CreateAt = :StartTime AND Id > :StartPostId))
+				AND DeleteAt = 0
+			ORDER BY
+				CreateAt, Id
+			LIMIT
+				:Limit`,
+			map[string]interface{}{"ChannelId": channelId, "StartTime": startTime, "StartPostId": startPostId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsBatchForExport", "store.sql_post.get_posts_batch_for_export.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -30,6 +30,82 @@ func TestPostStoreSave(t *testing.T) {
 	}
 }
 
+func TestPostStoreSaveMultiple(t *testing.T) {
+	Setup()
+
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	root := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "root", CreateAt: 1000}
+	reply := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "reply", CreateAt: 3000}
+	joined := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "joined", CreateAt: 2000, Type: model.POST_JOIN_CHANNEL}
+
+	if result := <-store.Post().SaveMultiple([]*model.Post{root}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	reply.RootId = root.Id
+	reply.ParentId = root.Id
+
+	if result := <-store.Post().SaveMultiple([]*model.Post{reply, joined}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.([]*model.Post); len(saved) != 2 || saved[0].Id == "" || saved[1].Id == "" {
+		t.Fatal("should have saved both posts")
+	}
+
+	channel := Must(store.Channel().Get(c1.Id, false)).(*model.Channel)
+	if channel.LastPostAt != 3000 {
+		t.Fatal("should have updated the channel's last post time", channel.LastPostAt)
+	} else if channel.TotalMsgCount != 2 {
+		t.Fatal("should only have counted the posts that aren't system messages", channel.TotalMsgCount)
+	}
+
+	if rootPost := Must(store.Post().Get(root.Id)).(*model.PostList).Posts[root.Id]; rootPost.UpdateAt != 3000 {
+		t.Fatal("should have updated the root post", rootPost.UpdateAt)
+	}
+
+	// save more posts than fit in one insert statement
+	many := make([]*model.Post, POST_SAVE_MULTIPLE_INSERT_SIZE*2+1)
+	for i := range many {
+		many[i] = &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "many", CreateAt: int64(4000 + i), Props: model.StringInterface{"index": i}, FileIds: []string{model.NewId()}}
+	}
+
+	if result := <-store.Post().SaveMultiple(many); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	last := many[len(many)-1]
+	if saved := Must(store.Post().Get(last.Id)).(*model.PostList).Posts[last.Id]; saved.Message != "many" || saved.CreateAt != last.CreateAt {
+		t.Fatal("should have saved every post")
+	} else if saved.Props["index"] != float64(len(many)-1) || len(saved.FileIds) != 1 || saved.FileIds[0] != last.FileIds[0] {
+		t.Fatal("should have saved the post's props and files", saved.Props, saved.FileIds)
+	}
+
+	if channel := Must(store.Channel().Get(c1.Id, false)).(*model.Channel); channel.TotalMsgCount != int64(2+len(many)) {
+		t.Fatal("should have counted every post", channel.TotalMsgCount)
+	}
+
+	valid := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "valid"}
+	invalid := &model.Post{ChannelId: "junk", UserId: model.NewId(), Message: "invalid"}
+	if result := <-store.Post().SaveMultiple([]*model.Post{valid, invalid}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid post")
+	} else if valid.Id != "" {
+		t.Fatal("shouldn't have left an id on a post that wasn't saved")
+	}
+
+	if result := <-store.Post().SaveMultiple([]*model.Post{root}); result.Err == nil {
+		t.Fatal("shouldn't be able to update from save")
+	}
+
+	if channel := Must(store.Channel().Get(c1.Id, false)).(*model.Channel); channel.TotalMsgCount != int64(2+len(many)) {
+		t.Fatal("shouldn't have changed the channel when nothing was saved", channel.TotalMsgCount)
+	}
+}
+
 func TestPostStoreGet(t *testing.T) {
 	Setup()
 
@@ -528,7 +604,7 @@ func TestPostStoreGetPostsWtihDetails(t *testing.T) {
 	o5.RootId = o4.Id
 	o5 = (<-store.Post().Save(o5)).Data.(*model.Post)
 
-	r1 := (<-store.Post().GetPosts(o1.ChannelId, 0, 4, false)).Data.(*model.PostList)
+	r1 := (<-store.Post().GetPosts(o1.ChannelId, 4, false)).Data.(*model.PostList)
 
 	if r1.Order[0] != o5.Id {
 		t.Fatal("invalid order")
@@ -554,7 +630,7 @@ func TestPostStoreGetPostsWtihDetails(t *testing.T) {
 		t.Fatal("Missing parent")
 	}
 
-	r2 := (<-store.Post().GetPosts(o1.ChannelId, 0, 4, true)).Data.(*model.PostList)
+	r2 := (<-store.Post().GetPosts(o1.ChannelId, 4, true)).Data.(*model.PostList)
 
 	if r2.Order[0] != o5.Id {
 		t.Fatal("invalid order")
@@ -639,13 +715,13 @@ func TestPostStoreGetPostsBeforeAfter(t *testing.T) {
 	o5.RootId = o4.Id
 	o5 = (<-store.Post().Save(o5)).Data.(*model.Post)
 
-	r1 := (<-store.Post().GetPostsBefore(o1.ChannelId, o1.Id, 4, 0)).Data.(*model.PostList)
+	r1 := (<-store.Post().GetPostsBefore(o1.ChannelId, o1.Id, 4)).Data.(*model.PostList)
 
 	if len(r1.Posts) != 0 {
 		t.Fatal("Wrong size")
 	}
 
-	r2 := (<-store.Post().GetPostsAfter(o1.ChannelId, o1.Id, 4, 0)).Data.(*model.PostList)
+	r2 := (<-store.Post().GetPostsAfter(o1.ChannelId, o1.Id, 4)).Data.(*model.PostList)
 
 	if r2.Order[0] != o4.Id {
 		t.Fatal("invalid order")
@@ -667,7 +743,7 @@ func TestPostStoreGetPostsBeforeAfter(t *testing.T) {
 		t.Fatal("wrong size")
 	}
 
-	r3 := (<-store.Post().GetPostsBefore(o3.ChannelId, o3.Id, 2, 0)).Data.(*model.PostList)
+	r3 := (<-store.Post().GetPostsBefore(o3.ChannelId, o3.Id, 2)).Data.(*model.PostList)
 
 	if r3.Order[0] != o2a.Id {
 		t.Fatal("invalid order")
@@ -686,6 +762,76 @@ func TestPostStoreGetPostsBeforeAfter(t *testing.T) {
 	}
 }
 
+func TestPostStoreGetPostsBeforeAfterWithSameCreateAt(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	createAt := model.GetMillis()
+
+	posts := make([]*model.Post, 5)
+	for i := range posts {
+		posts[i] = Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: createAt})).(*model.Post)
+	}
+
+	// posts created at the same time are ordered by id, so start paging from the lowest one
+	cursor := posts[0].Id
+	for _, post := range posts {
+		if post.Id < cursor {
+			cursor = post.Id
+		}
+	}
+
+	seen := map[string]bool{cursor: true}
+	for {
+		list := Must(store.Post().GetPostsAfter(channelId, cursor, 2)).(*model.PostList)
+		if len(list.Order) == 0 {
+			break
+		}
+
+		for _, postId := range list.Order {
+			if seen[postId] {
+				t.Fatal("should not have returned a post twice")
+			}
+			seen[postId] = true
+		}
+
+		// the newest post is first in the order
+		cursor = list.Order[0]
+	}
+
+	if len(seen) != len(posts) {
+		t.Fatal("should have returned every post after the first one", len(seen))
+	}
+
+	// paging back from the latest post should also return each post once
+	cursor = Must(store.Post().GetPosts(channelId, 1, false)).(*model.PostList).Order[0]
+	seen = map[string]bool{cursor: true}
+	for {
+		list := Must(store.Post().GetPostsBefore(channelId, cursor, 2)).(*model.PostList)
+		if len(list.Order) == 0 {
+			break
+		}
+
+		for _, postId := range list.Order {
+			if seen[postId] {
+				t.Fatal("should not have returned a post twice")
+			}
+			seen[postId] = true
+		}
+
+		// the oldest post is last in the order
+		cursor = list.Order[len(list.Order)-1]
+	}
+
+	if len(seen) != len(posts) {
+		t.Fatal("should have returned every post before the latest one", len(seen))
+	}
+
+	if list := Must(store.Post().GetPostsBefore(channelId, model.NewId(), 2)).(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("shouldn't return any posts around a post that doesn't exist")
+	}
+}
+
 func TestPostStoreGetPostsSince(t *testing.T) {
 	Setup()
 	o0 := &model.Post{}
@@ -907,6 +1053,157 @@ func TestPostStoreSearch(t *testing.T) {
 	}
 }
 
+func TestPostStoreSearchCJK(t *testing.T) {
+	Setup()
+
+	cjkSegmentation := *utils.Cfg.SearchSettings.CJKSegmentation
+	defer func() {
+		*utils.Cfg.SearchSettings.CJKSegmentation = cjkSegmentation
+	}()
+	*utils.Cfg.SearchSettings.CJKSegmentation = model.SEARCH_CJK_SEGMENTATION_BIGRAM
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "東京タワーに行きました"})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "東京タワー tower"})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "서울에서 만나요"}))
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "タワー"})).(*model.PostList); len(r.Order) != 2 {
+		t.Fatal("should have found both posts containing the word", len(r.Order))
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "タワー tower"})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("should only have found the post that contains all of the terms")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "行き 서울", OrTerms: true})).(*model.PostList); len(r.Order) != 2 {
+		t.Fatal("should have found posts that contain either term", len(r.Order))
+	} else if _, ok := r.Posts[o1.Id]; !ok {
+		t.Fatal("should have found the post containing the first term")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "大阪"})).(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't have found any posts")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "タワー -行き"})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("shouldn't have found the post containing the excluded term")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "-行き"})).(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't have found any posts with only an excluded term")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "\"タワー tower\""})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("should have found the post containing the whole phrase")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "\"タワー に\""})).(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't have found the phrase's words when they aren't together")
+	}
+}
+
+func TestSplitCJKSearchTerms(t *testing.T) {
+	other, cjk, excluded := splitCJKSearchTerms(`東京* -大阪 "東京 タワー" -"京都 駅" tower -bridge`)
+
+	if other != "tower -bridge" {
+		t.Fatal("should have kept the other terms as they were", other)
+	} else if len(cjk) != 2 || cjk[0] != "東京" || cjk[1] != "東京 タワー" {
+		t.Fatal("should have returned the CJK terms and phrases", cjk)
+	} else if len(excluded) != 2 || excluded[0] != "大阪" || excluded[1] != "京都 駅" {
+		t.Fatal("should have returned the excluded CJK terms and phrases", excluded)
+	}
+}
+
+func TestPostStoreSearchMatches(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "the quick brown fox jumps over the lazy dog", Hashtags: "#quickly"})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "see #quickly for more", Hashtags: "#quickly"})).(*model.Post)
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "brown"})).(*model.PostList); len(r.Order) != 1 {
+		t.Fatal("should have found the post", len(r.Order))
+	} else if matches := r.Matches[o1.Id]; len(matches) != 1 || matches[0] != (model.SearchMatch{Start: 10, End: 15}) {
+		t.Fatal("should have returned where the post matched", matches)
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "fox dog"})).(*model.PostList); len(r.Order) != 1 {
+		t.Fatal("should have found the post", len(r.Order))
+	} else if matches := r.Matches[o1.Id]; len(matches) != 2 || matches[0] != (model.SearchMatch{Start: 16, End: 19}) || matches[1] != (model.SearchMatch{Start: 40, End: 43}) {
+		t.Fatal("should have returned where each term matched in order", matches)
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#quickly", IsHashtag: true})).(*model.PostList); len(r.Order) != 2 {
+		t.Fatal("should have found both posts", len(r.Order))
+	} else if matches := r.Matches[o2.Id]; len(matches) != 1 || matches[0] != (model.SearchMatch{Start: 4, End: 12}) {
+		t.Fatal("should have returned where the hashtag matched", matches)
+	} else if len(r.Matches[o1.Id]) != 0 {
+		t.Fatal("shouldn't have returned matches for a post whose message doesn't contain the hashtag")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{InChannels: []string{c1.Name}})).(*model.PostList); len(r.Order) != 2 {
+		t.Fatal("should have found both posts", len(r.Order))
+	} else if len(r.Matches) != 0 {
+		t.Fatal("shouldn't have returned any matches without any terms")
+	}
+}
+
+func TestPostStoreSearchFileNames(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "here you go", FileCount: 1})).(*model.Post)
+	f1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, PostId: o1.Id, Path: "file.pdf", Name: "Quarterly_Report.pdf"})).(*model.FileInfo)
+
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "the quarterly numbers"})).(*model.Post)
+
+	o3 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId, Message: "deleted attachment", FileCount: 1})).(*model.Post)
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, PostId: o3.Id, Path: "file.pdf", Name: "report.pdf", DeleteAt: model.GetMillis()}))
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "quarterly"})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("plain terms should only have matched the message")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "here -you"})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o1.Id {
+		t.Fatal("an excluded term shouldn't have been matched against file names")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "", IsHashtag: true, FileNames: []string{"report.pdf"}})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o1.Id {
+		t.Fatal("should have found the post with the attached file")
+	} else if matches := r.FileMatches[f1.Id]; len(matches) != 1 || matches[0] != (model.SearchMatch{Start: 10, End: 20}) {
+		t.Fatal("should have returned where the file name matched", matches)
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "", IsHashtag: true, FileNames: []string{"quarterly_r"}})).(*model.PostList); len(r.Order) != 1 || r.Order[0] != o1.Id {
+		t.Fatal("should have found the post by its file name")
+	} else if matches := r.FileMatches[f1.Id]; len(matches) != 1 || matches[0] != (model.SearchMatch{Start: 0, End: 11}) {
+		t.Fatal("should have returned where the file name matched ignoring case", matches)
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "here"})).(*model.PostList); len(r.FileMatches) != 0 {
+		t.Fatal("shouldn't have returned file matches without searching by file name")
+	}
+
+	if r := Must(store.Post().Search(teamId, userId, &model.SearchParams{Terms: "here", FileNames: []string{"notes.txt"}})).(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't have found a post without a matching file")
+	}
+}
+
 func TestUserCountsWithPostsByDay(t *testing.T) {
 	Setup()
 
@@ -1123,3 +1420,65 @@ func TestPostStoreGetFlaggedPosts(t *testing.T) {
 		t.Fatal("should have 2 posts")
 	}
 }
+
+func TestPostStoreGetPostsBatchForIndexing(t *testing.T) {
+	Setup()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+	c2 := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Channel2", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+
+	startTime := model.GetMillis()
+
+	p1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime})).(*model.Post)
+	p2 := Must(store.Post().Save(&model.Post{ChannelId: c2.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime + 1})).(*model.Post)
+
+	if posts := Must(store.Post().GetPostsBatchForIndexing(startTime-1, "", startTime+2, "", 100)).([]*model.PostForIndexing); len(posts) != 2 || posts[0].Id != p1.Id || posts[1].Id != p2.Id {
+		t.Fatal("should have returned both posts in order")
+	} else if posts[0].TeamId != c1.TeamId {
+		t.Fatal("should have returned the team of the post's channel")
+	}
+
+	if posts := Must(store.Post().GetPostsBatchForIndexing(startTime-1, "", startTime+2, c2.TeamId, 100)).([]*model.PostForIndexing); len(posts) != 1 || posts[0].Id != p2.Id {
+		t.Fatal("should only have returned the post in the given team")
+	}
+}
+
+func TestPostStoreGetPostsBatchForExport(t *testing.T) {
+	Setup()
+
+	c1 := Must(store.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN})).(*model.Channel)
+
+	startTime := model.GetMillis()
+
+	p1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime})).(*model.Post)
+	p2 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime + 1})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime + 1}))
+	deleted := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: startTime + 2})).(*model.Post)
+	Must(store.Post().Delete(deleted.Id, model.GetMillis()))
+
+	if posts := Must(store.Post().GetPostsBatchForExport(c1.Id, 0, "", 100)).([]*model.Post); len(posts) != 2 || posts[0].Id != p1.Id || posts[1].Id != p2.Id {
+		t.Fatal("should have returned the channel's posts in order")
+	}
+
+	if posts := Must(store.Post().GetPostsBatchForExport(c1.Id, p1.CreateAt, p1.Id, 100)).([]*model.Post); len(posts) != 1 || posts[0].Id != p2.Id {
+		t.Fatal("should have returned the posts after the given one")
+	}
+}
+
+func TestPostStoreGetPostsSinceWithSameUpdateAt(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	createAt := model.GetMillis()
+
+	posts := make([]*model.Post, POST_HISTORY_MAX_PAGE_SIZE+1)
+	for i := range posts {
+		posts[i] = &model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "message", CreateAt: createAt}
+	}
+	Must(store.Post().SaveMultiple(posts))
+
+	// splitting the posts changed at the same time across pages would skip the rest of them
+	if list := Must(store.Post().GetPostsSince(channelId, createAt-1, false)).(*model.PostList); len(list.Order) != len(posts) {
+		t.Fatal("should have returned every post changed at the same time", len(list.Order))
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlPostTranslationStore struct {
+	*SqlStore
+}
+
+func NewSqlPostTranslationStore(sqlStore *SqlStore) PostTranslationStore {
+	s := &SqlPostTranslationStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.PostTranslation{}, "PostTranslations").SetKeys(false, "PostId", "Locale")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("Locale").SetMaxSize(32)
+		table.ColMap("SourceLocale").SetMaxSize(32)
+		table.ColMap("Message").SetMaxSize(4000)
+	}
+
+	return s
+}
+
+func (s SqlPostTranslationStore) CreateIndexesIfNotExists() {
+}
+
+// Save saves a translation of a post in place of any earlier translation of it into the same locale.
+func (s SqlPostTranslationStore) Save(translation *model.PostTranslation) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		translation.PreSave()
+		if result.Err = translation.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.Save", "store.sql_post_translation.save.open_transaction.app_error", nil, err.Error())
+		} else if _, err := transaction.Exec("DELETE FROM PostTranslations WHERE PostId = :PostId AND Locale = :Locale", map[string]interface{}{"PostId": translation.PostId, "Locale": translation.Locale}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.Save", "store.sql_post_translation.save.delete.app_error", nil, "post_id="+translation.PostId+", "+err.Error())
+		} else if err := transaction.Insert(translation); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.Save", "store.sql_post_translation.save.app_error", nil, "post_id="+translation.PostId+", "+err.Error())
+		} else if err := transaction.Commit(); err != nil {
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.Save", "store.sql_post_translation.save.commit_transaction.app_error", nil, err.Error())
+		} else {
+			result.Data = translation
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostTranslationStore) Get(postId string, locale string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var translation model.PostTranslation
+		if err := s.GetReplica().SelectOne(&translation, "SELECT * FROM PostTranslations WHERE PostId = :PostId AND Locale = :Locale", map[string]interface{}{"PostId": postId, "Locale": locale}); err == sql.ErrNoRows {
+			result.Err = model.NewAppError("SqlPostTranslationStore.Get", "store.sql_post_translation.get.missing.app_error", nil, "post_id="+postId+", locale="+locale, http.StatusNotFound)
+		} else if err != nil {
+			result.Err = model.NewAppError("SqlPostTranslationStore.Get", "store.sql_post_translation.get.app_error", nil, "post_id="+postId+", locale="+locale+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = &translation
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForPost removes all of the translations of a post, such as when it's deleted.
+func (s SqlPostTranslationStore) DeleteForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM PostTranslations WHERE PostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.DeleteForPost", "store.sql_post_translation.delete_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteBatch removes all of the translations of the given posts.
+func (s SqlPostTranslationStore) PermanentDeleteBatch(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			if _, err := s.GetMaster().Exec("DELETE FROM PostTranslations WHERE PostId IN ("+inQueryParams("PostId", postIds, props)+")", props); err != nil {
+				result.Err = model.NewLocAppError("SqlPostTranslationStore.PermanentDeleteBatch", "store.sql_post_translation.permanent_delete_batch.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteByChannel removes the translations of the posts in a channel. It's called before the channel's posts
+// are deleted, so it leaves out the posts held by a legal hold in the same way.
+func (s SqlPostTranslationStore) PermanentDeleteByChannel(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM PostTranslations WHERE PostId IN (SELECT Id FROM Posts WHERE ChannelId = :ChannelId"+LEGAL_HOLD_EXCLUDE_POSTS+")", map[string]interface{}{"ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.PermanentDeleteByChannel", "store.sql_post_translation.permanent_delete_by_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteByUser removes the translations of a user's posts and the replies to them. It's called before the
+// user's posts are deleted, so it leaves out the posts held by a legal hold in the same way.
+func (s SqlPostTranslationStore) PermanentDeleteByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`DELETE FROM
+				PostTranslations
+			WHERE
+				PostId IN (
+					SELECT
+						Id
+					FROM
+						Posts
+					WHERE
+						(UserId = :UserId
+							OR RootId IN (SELECT Id FROM Posts WHERE UserId = :UserId AND RootId = ''`+LEGAL_HOLD_EXCLUDE_POSTS+`))`+LEGAL_HOLD_EXCLUDE_POSTS+`)`,
+			map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostTranslationStore.PermanentDeleteByUser", "store.sql_post_translation.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestPostTranslationStore(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+
+	if result := <-store.PostTranslation().Get(postId, "fr"); result.Err == nil {
+		t.Fatal("shouldn't have found a translation before one was saved")
+	}
+
+	Must(store.PostTranslation().Save(&model.PostTranslation{PostId: postId, Locale: "fr", SourceLocale: "en", Message: "bonjour"}))
+	Must(store.PostTranslation().Save(&model.PostTranslation{PostId: postId, Locale: "de", SourceLocale: "en", Message: "hallo"}))
+
+	if result := <-store.PostTranslation().Save(&model.PostTranslation{PostId: postId, Locale: "not a locale"}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid translation")
+	}
+
+	Must(store.PostTranslation().Save(&model.PostTranslation{PostId: postId, Locale: "fr", SourceLocale: "en", Message: "salut", PostEditAt: 1}))
+
+	if translation := Must(store.PostTranslation().Get(postId, "fr")).(*model.PostTranslation); translation.Message != "salut" || translation.PostEditAt != 1 {
+		t.Fatal("should have replaced the translation")
+	}
+
+	if translation := Must(store.PostTranslation().Get(postId, "de")).(*model.PostTranslation); translation.Message != "hallo" {
+		t.Fatal("shouldn't have changed the translation into another locale")
+	}
+
+	Must(store.PostTranslation().DeleteForPost(postId))
+
+	if result := <-store.PostTranslation().Get(postId, "de"); result.Err == nil {
+		t.Fatal("should have deleted the translations")
+	}
+}
+
+func TestPostTranslationStorePermanentDelete(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	savePost := func(channelId string, userId string, rootId string) *model.Post {
+		post := Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: userId, RootId: rootId, ParentId: rootId, Message: "message"})).(*model.Post)
+		Must(store.PostTranslation().Save(&model.PostTranslation{PostId: post.Id, Locale: "fr", SourceLocale: "en", Message: "message"}))
+		return post
+	}
+
+	hasTranslation := func(post *model.Post) bool {
+		return (<-store.PostTranslation().Get(post.Id, "fr")).Err == nil
+	}
+
+	inChannel := savePost(channelId, model.NewId(), "")
+	byUser := savePost(model.NewId(), userId, "")
+	reply := savePost(byUser.ChannelId, model.NewId(), byUser.Id)
+	other := savePost(model.NewId(), model.NewId(), "")
+
+	Must(store.PostTranslation().PermanentDeleteByChannel(channelId))
+
+	if hasTranslation(inChannel) || !hasTranslation(byUser) {
+		t.Fatal("should only have deleted the translations of the posts in the channel")
+	}
+
+	Must(store.PostTranslation().PermanentDeleteByUser(userId))
+
+	if hasTranslation(byUser) || hasTranslation(reply) || !hasTranslation(other) {
+		t.Fatal("should only have deleted the translations of the user's posts and the replies to them")
+	}
+
+	Must(store.PostTranslation().PermanentDeleteBatch([]string{other.Id}))
+
+	if hasTranslation(other) {
+		t.Fatal("should have deleted the translations of the given posts")
+	}
+}
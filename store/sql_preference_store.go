@@ -121,6 +121,17 @@ func (s SqlPreferenceStore) save(transaction *gorp.Transaction, preference *mode
 				Value = :Value`, params); err != nil {
 			result.Err = model.NewLocAppError("SqlPreferenceStore.save", "store.sql_preference.save.updating.app_error", nil, err.Error())
 		}
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		if _, err := transaction.Exec(
+			`INSERT INTO
+				Preferences
+				(UserId, Category, Name, Value)
+			VALUES
+				(:UserId, :Category, :Name, :Value)
+			ON CONFLICT (UserId, Category, Name) DO UPDATE SET
+				Value = :Value`, params); err != nil {
+			result.Err = model.NewLocAppError("SqlPreferenceStore.save", "store.sql_preference.save.updating.app_error", nil, err.Error())
+		}
 	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 		// postgres has no way to upsert values until version 9.5 and trying inserting and then updating causes transactions to abort
 		count, err := transaction.SelectInt(
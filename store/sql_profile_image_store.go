@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlProfileImageStore struct {
+	*SqlStore
+}
+
+func NewSqlProfileImageStore(sqlStore *SqlStore) ProfileImageStore {
+	s := &SqlProfileImageStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ProfileImage{}, "ProfileImages").SetKeys(false, "FileId")
+		table.ColMap("FileId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlProfileImageStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_profileimages_user_id", "ProfileImages", "UserId")
+}
+
+func (s SqlProfileImageStore) Save(image *model.ProfileImage) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		image.PreSave()
+		if result.Err = image.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(image); err != nil {
+			result.Err = model.NewLocAppError("SqlProfileImageStore.Save", "store.sql_profile_image.save.app_error", nil, "file_id="+image.FileId+", "+err.Error())
+		} else {
+			result.Data = image
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlProfileImageStore) Get(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var image model.ProfileImage
+		if err := s.GetMaster().SelectOne(&image, "SELECT * FROM ProfileImages WHERE FileId = :FileId", map[string]interface{}{"FileId": fileId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlProfileImageStore.Get", "store.sql_profile_image.get.app_error", nil, "file_id="+fileId+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlProfileImageStore.Get", "store.sql_profile_image.get.app_error", nil, "file_id="+fileId+", "+err.Error())
+			}
+		} else {
+			result.Data = &image
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForUser returns the profile pictures that a user has uploaded, starting with their current one and followed by
+// the others in the order that they were last used.
+func (s SqlProfileImageStore) GetForUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var images []*model.ProfileImage
+		if _, err := s.GetMaster().Select(&images, "SELECT * FROM ProfileImages WHERE UserId = :UserId ORDER BY ActiveAt DESC, CreateAt DESC", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlProfileImageStore.GetForUser", "store.sql_profile_image.get_for_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = images
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Activate makes a profile picture the current one for its user.
+func (s SqlProfileImageStore) Activate(fileId string, activeAt int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE ProfileImages SET ActiveAt = :ActiveAt WHERE FileId = :FileId", map[string]interface{}{"ActiveAt": activeAt, "FileId": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlProfileImageStore.Activate", "store.sql_profile_image.activate.app_error", nil, "file_id="+fileId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlProfileImageStore) PermanentDeleteBatch(fileIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(fileIds) > 0 {
+			props := make(map[string]interface{})
+			if _, err := s.GetMaster().Exec("DELETE FROM ProfileImages WHERE FileId IN ("+inQueryParams("FileId", fileIds, props)+")", props); err != nil {
+				result.Err = model.NewLocAppError("SqlProfileImageStore.PermanentDeleteBatch", "store.sql_profile_image.permanent_delete_batch.app_error", nil, "file_ids="+strings.Join(fileIds, ",")+", "+err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
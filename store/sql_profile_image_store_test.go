@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestProfileImageStore(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	i1 := Must(store.ProfileImage().Save(&model.ProfileImage{FileId: model.NewId(), UserId: userId, CreateAt: 1000})).(*model.ProfileImage)
+	i2 := Must(store.ProfileImage().Save(&model.ProfileImage{FileId: model.NewId(), UserId: userId, CreateAt: 2000})).(*model.ProfileImage)
+	Must(store.ProfileImage().Save(&model.ProfileImage{FileId: model.NewId(), UserId: model.NewId()}))
+
+	if result := <-store.ProfileImage().Save(&model.ProfileImage{FileId: "junk", UserId: userId}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid picture")
+	}
+
+	if images := Must(store.ProfileImage().GetForUser(userId)).([]*model.ProfileImage); len(images) != 2 || images[0].FileId != i2.FileId || images[1].FileId != i1.FileId {
+		t.Fatal("should have returned the newest picture first")
+	}
+
+	Must(store.ProfileImage().Activate(i1.FileId, 3000))
+
+	if images := Must(store.ProfileImage().GetForUser(userId)).([]*model.ProfileImage); len(images) != 2 || images[0].FileId != i1.FileId {
+		t.Fatal("should have returned the activated picture first")
+	}
+
+	if image := Must(store.ProfileImage().Get(i1.FileId)).(*model.ProfileImage); image.ActiveAt != 3000 {
+		t.Fatal("should have updated when the picture was activated")
+	}
+
+	Must(store.ProfileImage().PermanentDeleteBatch([]string{i1.FileId, i2.FileId}))
+
+	if result := <-store.ProfileImage().Get(i1.FileId); result.Err == nil || result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have deleted the picture")
+	}
+
+	if images := Must(store.ProfileImage().GetForUser(userId)).([]*model.ProfileImage); len(images) != 0 {
+		t.Fatal("should have deleted every picture")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+// SqlPseudonymStore keeps the mapping between users and their pseudonyms in its own table so that it can be
+// restricted separately from the exports that use it.
+type SqlPseudonymStore struct {
+	*SqlStore
+}
+
+func NewSqlPseudonymStore(sqlStore *SqlStore) PseudonymStore {
+	s := &SqlPseudonymStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Pseudonym{}, "Pseudonyms").SetKeys(false, "UserId")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("Pseudonym").SetMaxSize(64).SetUnique(true)
+	}
+
+	return s
+}
+
+func (s SqlPseudonymStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_pseudonyms_create_at", "Pseudonyms", "CreateAt")
+}
+
+func (s SqlPseudonymStore) Save(pseudonym *model.Pseudonym) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		pseudonym.PreSave()
+		if result.Err = pseudonym.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(pseudonym); err != nil {
+			result.Err = model.NewLocAppError("SqlPseudonymStore.Save", "store.sql_pseudonym.save.app_error", nil, "user_id="+pseudonym.UserId+", "+err.Error())
+		} else {
+			result.Data = pseudonym
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPseudonymStore) GetForUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var pseudonym model.Pseudonym
+		if err := s.GetMaster().SelectOne(&pseudonym, "SELECT * FROM Pseudonyms WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlPseudonymStore.GetForUser", "store.sql_pseudonym.get_for_user.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlPseudonymStore.GetForUser", "store.sql_pseudonym.get_for_user.app_error", nil, "user_id="+userId+", "+err.Error())
+			}
+		} else {
+			result.Data = &pseudonym
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAll returns a page of the mapping between users and their pseudonyms, oldest first.
+func (s SqlPseudonymStore) GetAll(offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var pseudonyms []*model.Pseudonym
+		if _, err := s.GetReplica().Select(&pseudonyms, "SELECT * FROM Pseudonyms ORDER BY CreateAt, UserId LIMIT :Limit OFFSET :Offset", map[string]interface{}{"Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlPseudonymStore.GetAll", "store.sql_pseudonym.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = pseudonyms
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPseudonymStore) PermanentDeleteByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Pseudonyms WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPseudonymStore.PermanentDeleteByUser", "store.sql_pseudonym.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
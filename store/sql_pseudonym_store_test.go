@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestPseudonymStore(t *testing.T) {
+	Setup()
+
+	p1 := Must(store.Pseudonym().Save(&model.Pseudonym{UserId: model.NewId()})).(*model.Pseudonym)
+
+	if result := <-store.Pseudonym().Save(&model.Pseudonym{UserId: p1.UserId}); result.Err == nil {
+		t.Fatal("shouldn't be able to give a user a second pseudonym")
+	}
+
+	if result := <-store.Pseudonym().Save(&model.Pseudonym{UserId: model.NewId(), Pseudonym: p1.Pseudonym}); result.Err == nil {
+		t.Fatal("shouldn't be able to give two users the same pseudonym")
+	}
+
+	if pseudonym := Must(store.Pseudonym().GetForUser(p1.UserId)).(*model.Pseudonym); pseudonym.Pseudonym != p1.Pseudonym {
+		t.Fatal("should have returned the user's pseudonym")
+	}
+
+	if result := <-store.Pseudonym().GetForUser(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a pseudonym for a user without one")
+	}
+
+	p2 := Must(store.Pseudonym().Save(&model.Pseudonym{UserId: model.NewId(), CreateAt: p1.CreateAt + 1})).(*model.Pseudonym)
+
+	found := 0
+	for _, pseudonym := range Must(store.Pseudonym().GetAll(0, 10000)).([]*model.Pseudonym) {
+		if pseudonym.UserId == p1.UserId || pseudonym.UserId == p2.UserId {
+			found++
+		}
+	}
+
+	if found != 2 {
+		t.Fatal("should have returned both pseudonyms")
+	}
+
+	Must(store.Pseudonym().PermanentDeleteByUser(p1.UserId))
+
+	if result := <-store.Pseudonym().GetForUser(p1.UserId); result.Err == nil {
+		t.Fatal("should have deleted the pseudonym")
+	}
+}
@@ -54,7 +54,7 @@ func (s SqlReactionStore) Save(reaction *model.Reaction) StoreChannel {
 				transaction.Rollback()
 
 				// We don't consider duplicated save calls as an error
-				if !IsUniqueConstraintError(err.Error(), []string{"reactions_pkey", "PRIMARY"}) {
+				if !IsUniqueConstraintError(err.Error(), []string{"reactions_pkey", "PRIMARY", "PostId"}) {
 					result.Err = model.NewLocAppError("SqlPreferenceStore.Save", "store.sql_reaction.save.save.app_error", nil, err.Error())
 				}
 			} else {
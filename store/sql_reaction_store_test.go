@@ -6,6 +6,7 @@ package store
 import (
 	"github.com/mattermost/platform/model"
 	"testing"
+	"time"
 )
 
 func TestReactionSave(t *testing.T) {
@@ -17,6 +18,8 @@ func TestReactionSave(t *testing.T) {
 	})).(*model.Post)
 	firstUpdateAt := post.UpdateAt
 
+	time.Sleep(2 * time.Millisecond)
+
 	reaction1 := &model.Reaction{
 		UserId:    model.NewId(),
 		PostId:    post.Id,
@@ -104,6 +107,8 @@ func TestReactionDelete(t *testing.T) {
 	Must(store.Reaction().Save(reaction))
 	firstUpdateAt := Must(store.Post().Get(reaction.PostId)).(*model.PostList).Posts[post.Id].UpdateAt
 
+	time.Sleep(2 * time.Millisecond)
+
 	if result := <-store.Reaction().Delete(reaction); result.Err != nil {
 		t.Fatal(result.Err)
 	}
@@ -0,0 +1,344 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlRetentionPolicyStore struct {
+	*SqlStore
+}
+
+func NewSqlRetentionPolicyStore(sqlStore *SqlStore) RetentionPolicyStore {
+	s := &SqlRetentionPolicyStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.RetentionPolicy{}, "RetentionPolicies").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("DisplayName").SetMaxSize(64)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlRetentionPolicyStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_retentionpolicies_team_id", "RetentionPolicies", "TeamId")
+	s.CreateIndexIfNotExists("idx_retentionpolicies_channel_id", "RetentionPolicies", "ChannelId")
+}
+
+func (s SqlRetentionPolicyStore) Save(policy *model.RetentionPolicy) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(policy.Id) > 0 {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.Save", "store.sql_retention_policy.save.existing.app_error", nil, "id="+policy.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		policy.PreSave()
+		if result.Err = policy.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(policy); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.Save", "store.sql_retention_policy.save.app_error", nil, "id="+policy.Id+", "+err.Error())
+		} else {
+			result.Data = policy
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRetentionPolicyStore) Update(policy *model.RetentionPolicy) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		policy.PreUpdate()
+		if result.Err = policy.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(policy); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.Update", "store.sql_retention_policy.update.app_error", nil, "id="+policy.Id+", "+err.Error())
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlRetentionPolicyStore.Update", "store.sql_retention_policy.get.app_error", nil, "id="+policy.Id, http.StatusNotFound)
+		} else {
+			result.Data = policy
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRetentionPolicyStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var policy model.RetentionPolicy
+		if err := s.GetReplica().SelectOne(&policy, "SELECT * FROM RetentionPolicies WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlRetentionPolicyStore.Get", "store.sql_retention_policy.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlRetentionPolicyStore.Get", "store.sql_retention_policy.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = &policy
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRetentionPolicyStore) GetAll() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var policies []*model.RetentionPolicy
+		if _, err := s.GetReplica().Select(&policies, "SELECT * FROM RetentionPolicies ORDER BY DisplayName"); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.GetAll", "store.sql_retention_policy.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = policies
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRetentionPolicyStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM RetentionPolicies WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.Delete", "store.sql_retention_policy.delete.app_error", nil, "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlRetentionPolicyStore.Delete", "store.sql_retention_policy.get.app_error", nil, "id="+id, http.StatusNotFound)
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// CountPostsBefore returns the number of posts in the scope that were created before the
+// given time.
+func (s SqlRetentionPolicyStore) CountPostsBefore(scope *model.RetentionScope, before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Before": before}
+		query :=
+			`SELECT
+				COUNT(Posts.Id)
+			FROM
+				Posts
+			INNER JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				Posts.CreateAt < :Before` + LEGAL_HOLD_EXCLUDE_POSTS + retentionScopeQuery(scope, props)
+
+		if count, err := s.GetReplica().SelectInt(query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.CountPostsBefore", "store.sql_retention_policy.count_posts.app_error", nil, err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostIdsBefore returns the ids of up to limit posts in the scope that were created
+// before the given time, oldest first.
+func (s SqlRetentionPolicyStore) GetPostIdsBefore(scope *model.RetentionScope, before int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Before": before, "Limit": limit}
+		query :=
+			`SELECT
+				Posts.Id
+			FROM
+				Posts
+			INNER JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				Posts.CreateAt < :Before` + LEGAL_HOLD_EXCLUDE_POSTS + retentionScopeQuery(scope, props) + `
+			ORDER BY
+				Posts.CreateAt ASC
+			LIMIT
+				:Limit`
+
+		// read from the master since the posts are deleted right after and a lagging
+		// replica would keep returning the same ones
+		var postIds []string
+		if _, err := s.GetMaster().Select(&postIds, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.GetPostIdsBefore", "store.sql_retention_policy.get_posts.app_error", nil, err.Error())
+		} else {
+			result.Data = postIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// CountFilesBefore returns the number of files attached to posts in the scope that were
+// uploaded before the given time.
+func (s SqlRetentionPolicyStore) CountFilesBefore(scope *model.RetentionScope, before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Before": before}
+		query :=
+			`SELECT
+				COUNT(FileInfo.Id)
+			FROM
+				FileInfo
+			INNER JOIN
+				Posts ON FileInfo.PostId = Posts.Id
+			INNER JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				FileInfo.CreateAt < :Before` + LEGAL_HOLD_EXCLUDE_FILES + retentionScopeQuery(scope, props)
+
+		if count, err := s.GetReplica().SelectInt(query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.CountFilesBefore", "store.sql_retention_policy.count_files.app_error", nil, err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFileInfosBefore returns up to limit files attached to posts in the scope that were
+// uploaded before the given time, oldest first.
+func (s SqlRetentionPolicyStore) GetFileInfosBefore(scope *model.RetentionScope, before int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Before": before, "Limit": limit}
+		query :=
+			`SELECT
+				FileInfo.*
+			FROM
+				FileInfo
+			INNER JOIN
+				Posts ON FileInfo.PostId = Posts.Id
+			INNER JOIN
+				Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				FileInfo.CreateAt < :Before` + LEGAL_HOLD_EXCLUDE_FILES + retentionScopeQuery(scope, props) + `
+			ORDER BY
+				FileInfo.CreateAt ASC
+			LIMIT
+				:Limit`
+
+		// read from the master for the same reason as GetPostIdsBefore
+		var infos []*model.FileInfo
+		if _, err := s.GetMaster().Select(&infos, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlRetentionPolicyStore.GetFileInfosBefore", "store.sql_retention_policy.get_files.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// retentionScopeQuery returns the conditions that limit a query joined against the
+// Channels table to the given scope, adding any parameters that it needs to props.
+func retentionScopeQuery(scope *model.RetentionScope, props map[string]interface{}) string {
+	query := ""
+
+	if len(scope.ChannelId) > 0 {
+		query += " AND Channels.Id = :ChannelId"
+		props["ChannelId"] = scope.ChannelId
+	}
+
+	if len(scope.TeamId) > 0 {
+		query += " AND Channels.TeamId = :TeamId"
+		props["TeamId"] = scope.TeamId
+	}
+
+	if len(scope.ExcludeChannelIds) > 0 {
+		query += " AND Channels.Id NOT IN (" + inQueryParams("ExcludeChannelId", scope.ExcludeChannelIds, props) + ")"
+	}
+
+	if len(scope.ExcludeTeamIds) > 0 {
+		query += " AND Channels.TeamId NOT IN (" + inQueryParams("ExcludeTeamId", scope.ExcludeTeamIds, props) + ")"
+	}
+
+	return query
+}
+
+func inQueryParams(prefix string, values []string, props map[string]interface{}) string {
+	keys := make([]string, len(values))
+	for index, value := range values {
+		key := prefix + strconv.Itoa(index)
+		props[key] = value
+		keys[index] = ":" + key
+	}
+
+	return strings.Join(keys, ", ")
+}
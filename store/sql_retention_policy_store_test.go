@@ -0,0 +1,126 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestRetentionPolicySaveGetUpdateDelete(t *testing.T) {
+	Setup()
+
+	policy := &model.RetentionPolicy{
+		DisplayName:          "Legal",
+		TeamId:               model.NewId(),
+		MessageRetentionDays: 30,
+	}
+
+	if result := <-store.RetentionPolicy().Save(policy); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.RetentionPolicy().Save(policy); result.Err == nil {
+		t.Fatal("shouldn't be able to save an existing policy")
+	}
+
+	if result := <-store.RetentionPolicy().Get(policy.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.RetentionPolicy); received.TeamId != policy.TeamId || received.MessageRetentionDays != 30 {
+		t.Fatal("received incorrect policy")
+	}
+
+	policy.FileRetentionDays = 10
+	if result := <-store.RetentionPolicy().Update(policy); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.RetentionPolicy().GetAll(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, received := range result.Data.([]*model.RetentionPolicy) {
+			if received.Id == policy.Id {
+				found = received.FileRetentionDays == 10
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the updated policy")
+		}
+	}
+
+	if result := <-store.RetentionPolicy().Delete(policy.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.RetentionPolicy().Get(policy.Id); result.Err == nil {
+		t.Fatal("policy should have been deleted")
+	}
+
+	if result := <-store.RetentionPolicy().Delete(policy.Id); result.Err == nil {
+		t.Fatal("shouldn't be able to delete a policy twice")
+	}
+}
+
+func TestRetentionPolicyPostsAndFilesBefore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	c1 := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	c2 := &model.Channel{TeamId: teamId, DisplayName: "Channel2", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c2 = Must(store.Channel().Save(c2)).(*model.Channel)
+
+	old1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "old", CreateAt: 1000})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "new", CreateAt: 3000}))
+	Must(store.Post().Save(&model.Post{ChannelId: c2.Id, UserId: model.NewId(), Message: "old", CreateAt: 1000}))
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: old1.Id, Path: "file.txt", CreateAt: 1000, UpdateAt: 1000})).(*model.FileInfo)
+
+	scope := &model.RetentionScope{TeamId: teamId, ExcludeChannelIds: []string{c2.Id}}
+
+	if result := <-store.RetentionPolicy().CountPostsBefore(scope, 2000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count != 1 {
+		t.Fatal("should've counted one post")
+	}
+
+	if result := <-store.RetentionPolicy().GetPostIdsBefore(scope, 2000, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if postIds := result.Data.([]string); len(postIds) != 1 || postIds[0] != old1.Id {
+		t.Fatal("should've returned the old post")
+	}
+
+	if result := <-store.RetentionPolicy().CountFilesBefore(scope, 2000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count != 1 {
+		t.Fatal("should've counted one file")
+	}
+
+	if result := <-store.RetentionPolicy().GetFileInfosBefore(scope, 2000, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 1 || infos[0].Id != info.Id {
+		t.Fatal("should've returned the old file")
+	}
+
+	if result := <-store.FileInfo().PermanentDeleteBatch([]string{info.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Post().PermanentDeleteBatch([]string{old1.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count != 1 {
+		t.Fatal("should've deleted one post")
+	}
+
+	if result := <-store.RetentionPolicy().CountPostsBefore(&model.RetentionScope{ChannelId: c1.Id}, 2000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count != 0 {
+		t.Fatal("old post should have been deleted")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlRoleStore struct {
+	*SqlStore
+}
+
+func NewSqlRoleStore(sqlStore *SqlStore) RoleStore {
+	s := &SqlRoleStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Role{}, "Roles").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(model.ROLE_ID_MAX_LENGTH)
+		table.ColMap("Name").SetMaxSize(model.ROLE_NAME_MAX_LENGTH)
+		table.ColMap("Description").SetMaxSize(model.ROLE_DESCRIPTION_MAX_LENGTH)
+		table.ColMap("Permissions").SetMaxSize(model.ROLE_PERMISSIONS_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (s SqlRoleStore) CreateIndexesIfNotExists() {
+}
+
+// Save inserts the role or, if one with the same id has already been saved, replaces it.
+func (s SqlRoleStore) Save(role *model.Role) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = role.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(role); err != nil {
+			result.Err = model.NewLocAppError("SqlRoleStore.Save", "store.sql_role.save.app_error", nil, "id="+role.Id+", "+err.Error())
+		} else if count == 0 {
+			if err := s.GetMaster().Insert(role); err != nil {
+				result.Err = model.NewLocAppError("SqlRoleStore.Save", "store.sql_role.save.app_error", nil, "id="+role.Id+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = role
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRoleStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if obj, err := s.GetReplica().Get(model.Role{}, id); err != nil {
+			result.Err = model.NewLocAppError("SqlRoleStore.Get", "store.sql_role.get.app_error", nil, "id="+id+", "+err.Error())
+		} else if obj == nil {
+			result.Err = model.NewAppError("SqlRoleStore.Get", "store.sql_role.get.missing.app_error", nil, "id="+id, http.StatusNotFound)
+		} else {
+			result.Data = obj.(*model.Role)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRoleStore) GetAll() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var roles []*model.Role
+		if _, err := s.GetReplica().Select(&roles, "SELECT * FROM Roles"); err != nil {
+			result.Err = model.NewLocAppError("SqlRoleStore.GetAll", "store.sql_role.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = roles
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
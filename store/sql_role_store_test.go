@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestRoleStore(t *testing.T) {
+	Setup()
+
+	role := &model.Role{
+		Id:          "test_role_" + model.NewId(),
+		Name:        "authentication.roles.test.name",
+		Description: "authentication.roles.test.description",
+		Permissions: []string{model.PERMISSION_READ_SYSTEM.Id},
+	}
+
+	if result := <-store.Role().Get(role.Id); result.Err == nil {
+		t.Fatal("shouldn't have found a missing role")
+	}
+
+	if err := (<-store.Role().Save(role)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if saved := Must(store.Role().Get(role.Id)).(*model.Role); len(saved.Permissions) != 1 || saved.Permissions[0] != model.PERMISSION_READ_SYSTEM.Id {
+		t.Fatal("should have gotten the saved permissions", saved.Permissions)
+	}
+
+	role.Permissions = []string{model.PERMISSION_READ_SYSTEM.Id, model.PERMISSION_MANAGE_COMPLIANCE.Id}
+	if err := (<-store.Role().Save(role)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if saved := Must(store.Role().Get(role.Id)).(*model.Role); len(saved.Permissions) != 2 || saved.Permissions[1] != model.PERMISSION_MANAGE_COMPLIANCE.Id {
+		t.Fatal("should have replaced the saved permissions", saved.Permissions)
+	}
+
+	found := false
+	for _, saved := range Must(store.Role().GetAll()).([]*model.Role) {
+		if saved.Id == role.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have gotten the saved role")
+	}
+
+	if err := (<-store.Role().Save(&model.Role{})).Err; err == nil {
+		t.Fatal("shouldn't have saved a role without an id")
+	}
+}
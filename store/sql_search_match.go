@@ -0,0 +1,292 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// Search results include where each post's message matched so that clients can highlight it. Postgres stems the words
+// that it searches for, so it's asked where it found them using ts_headline. The other databases match the terms as
+// they were typed, so that's repeated here instead. Searches by file name also include where each file's name matched.
+
+const (
+	// ts_headline surrounds each match with these, and they're removed again to find where the matches are
+	SEARCH_HEADLINE_START_SEL = "\x1e"
+	SEARCH_HEADLINE_STOP_SEL  = "\x1f"
+)
+
+var searchPhrasePattern = regexp.MustCompile(`"[^"]*"`)
+
+// searchMatchTerm is a word or quoted phrase that's looked for in messages. Its words are lower case, and the last
+// one only needs to match the start of a word when the term ended with a wildcard.
+type searchMatchTerm struct {
+	words  []string
+	prefix bool
+}
+
+// searchWord is a word in a message along with its byte offsets.
+type searchWord struct {
+	text  string
+	start int
+	end   int
+}
+
+// addSearchMatches records where the search matched the message of each post in the list. tsQuery is the query that
+// Postgres searched with and cjkTerms are the terms that were looked for anywhere in messages.
+func (s SqlPostStore) addSearchMatches(list *model.PostList, params *model.SearchParams, tsQuery string, cjkTerms []string) {
+	var headlines map[string]string
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES && !params.IsHashtag && len(tsQuery) > 0 {
+		headlines = s.getSearchHeadlines(list.Order, tsQuery)
+	}
+
+	// SQLite looks for each term anywhere in the message like it does for CJK terms
+	substrings := cjkTerms
+	var terms []searchMatchTerm
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE && !params.IsHashtag {
+		for _, term := range parseSearchMatchTerms(params.Terms, false) {
+			substrings = append(substrings, term.words...)
+		}
+	} else {
+		terms = parseSearchMatchTerms(params.Terms, params.IsHashtag)
+	}
+	patterns := compileSearchSubstrings(substrings)
+
+	for _, postId := range list.Order {
+		message := list.Posts[postId].Message
+
+		if headline, ok := headlines[postId]; ok {
+			if matches, ok := parseSearchHeadline(message, headline); ok {
+				list.AddSearchMatches(postId, matches)
+				list.AddSearchMatches(postId, findSearchMatches(message, nil, patterns, false))
+				continue
+			}
+		}
+
+		list.AddSearchMatches(postId, findSearchMatches(message, terms, patterns, params.IsHashtag))
+	}
+
+	if len(params.FileNames) > 0 {
+		s.addFileSearchMatches(list, params.FileNames)
+	}
+}
+
+// addFileSearchMatches records where the names of the files attached to the posts in the list contain any of the
+// file names that were searched for. Like the search itself, those are matched anywhere in the name, ignoring case.
+func (s SqlPostStore) addFileSearchMatches(list *model.PostList, fileNames []string) {
+	if len(list.Order) == 0 {
+		return
+	}
+
+	props := map[string]interface{}{}
+	inClause := inQueryParams("PostId", list.Order, props)
+
+	var files []struct {
+		Id   string
+		Name string
+	}
+	if _, err := s.GetReplica().Select(&files, "SELECT Id, Name FROM FileInfo WHERE DeleteAt = 0 AND PostId IN ("+inClause+")", props); err != nil {
+		return
+	}
+
+	patterns := compileSearchSubstrings(fileNames)
+	for _, file := range files {
+		list.AddFileSearchMatches(file.Id, findSearchMatches(file.Name, nil, patterns, false))
+	}
+}
+
+// getSearchHeadlines returns the messages of the given posts with the words that match the query marked by ts_headline.
+// Any posts that it fails to get headlines for fall back to being matched like on the other databases.
+func (s SqlPostStore) getSearchHeadlines(postIds []string, tsQuery string) map[string]string {
+	headlines := map[string]string{}
+	if len(postIds) == 0 {
+		return headlines
+	}
+
+	props := map[string]interface{}{
+		"Terms":   tsQuery,
+		"Options": `StartSel="` + SEARCH_HEADLINE_START_SEL + `", StopSel="` + SEARCH_HEADLINE_STOP_SEL + `", HighlightAll=true`,
+	}
+	inClause := inQueryParams("PostId", postIds, props)
+
+	var rows []struct {
+		Id       string
+		Headline string
+	}
+	if _, err := s.GetReplica().Select(&rows, "SELECT Id, ts_headline(Message, to_tsquery(:Terms), :Options) AS Headline FROM Posts WHERE Id IN ("+inClause+")", props); err != nil {
+		return headlines
+	}
+
+	for _, row := range rows {
+		headlines[row.Id] = row.Headline
+	}
+
+	return headlines
+}
+
+// parseSearchHeadline returns where ts_headline marked matches in a message. It returns false if the headline isn't
+// the whole message with only the markers added, such as when the message itself contains one of the markers.
+func parseSearchHeadline(message string, headline string) ([]model.SearchMatch, bool) {
+	var matches []model.SearchMatch
+	var stripped bytes.Buffer
+
+	start := -1
+	for i := 0; i < len(headline); i++ {
+		switch headline[i] {
+		case SEARCH_HEADLINE_START_SEL[0]:
+			start = stripped.Len()
+		case SEARCH_HEADLINE_STOP_SEL[0]:
+			if start != -1 && stripped.Len() > start {
+				matches = append(matches, model.SearchMatch{Start: start, End: stripped.Len()})
+			}
+			start = -1
+		default:
+			stripped.WriteByte(headline[i])
+		}
+	}
+
+	if stripped.String() != message {
+		return nil, false
+	}
+
+	return matches, true
+}
+
+// parseSearchMatchTerms splits the terms of a search into the words and quoted phrases that are looked for.
+func parseSearchMatchTerms(terms string, isHashtag bool) []searchMatchTerm {
+	var parsed []searchMatchTerm
+
+	if isHashtag {
+		for _, hashtag := range strings.Fields(strings.ToLower(terms)) {
+			parsed = append(parsed, searchMatchTerm{words: []string{hashtag}})
+		}
+
+		return parsed
+	}
+
+	for _, phrase := range searchPhrasePattern.FindAllString(terms, -1) {
+		if words := splitSearchTerm(strings.Trim(phrase, "\"")); len(words) > 0 {
+			parsed = append(parsed, searchMatchTerm{words: words})
+		}
+	}
+
+	for _, term := range strings.Fields(strings.Replace(searchPhrasePattern.ReplaceAllString(terms, " "), "\"", " ", -1)) {
+		prefix := strings.HasSuffix(term, "*")
+
+		for _, word := range splitSearchTerm(term) {
+			parsed = append(parsed, searchMatchTerm{words: []string{word}, prefix: prefix})
+		}
+	}
+
+	return parsed
+}
+
+// splitSearchTerm splits a term into lower case words the same way that Search does before searching for it.
+func splitSearchTerm(term string) []string {
+	for _, c := range specialSearchChar {
+		term = strings.Replace(term, c, " ", -1)
+	}
+
+	return strings.Fields(strings.ToLower(strings.Replace(term, "*", " ", -1)))
+}
+
+// compileSearchSubstrings returns patterns that find the given substrings ignoring case. They're compiled once per
+// search rather than for every post that's checked.
+func compileSearchSubstrings(substrings []string) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+
+	for _, substring := range substrings {
+		if len(substring) == 0 {
+			continue
+		}
+
+		patterns = append(patterns, regexp.MustCompile("(?i)"+regexp.QuoteMeta(substring)))
+	}
+
+	return patterns
+}
+
+// findSearchMatches returns where the given terms match whole words in a message, or the start of a word for terms
+// that ended with a wildcard, and where any of the substring patterns match it.
+func findSearchMatches(message string, terms []searchMatchTerm, patterns []*regexp.Regexp, isHashtag bool) []model.SearchMatch {
+	var matches []model.SearchMatch
+
+	if len(terms) > 0 {
+		words := splitSearchWords(message, isHashtag)
+
+		for _, term := range terms {
+			for i := 0; i+len(term.words) <= len(words); i++ {
+				if searchTermMatchesWords(term, words[i:i+len(term.words)]) {
+					matches = append(matches, model.SearchMatch{Start: words[i].start, End: words[i+len(term.words)-1].end})
+				}
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		for _, index := range pattern.FindAllStringIndex(message, -1) {
+			matches = append(matches, model.SearchMatch{Start: index[0], End: index[1]})
+		}
+	}
+
+	return matches
+}
+
+func searchTermMatchesWords(term searchMatchTerm, words []searchWord) bool {
+	for i, word := range term.words {
+		text := strings.ToLower(words[i].text)
+
+		if term.prefix && i == len(term.words)-1 {
+			if !strings.HasPrefix(text, word) {
+				return false
+			}
+		} else if text != word {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitSearchWords splits a message into its words. Hashtags are kept whole when searching for them.
+func splitSearchWords(message string, isHashtag bool) []searchWord {
+	isWordChar := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || (isHashtag && (r == '#' || r == '-' || r == '.'))
+	}
+
+	var words []searchWord
+
+	start := -1
+	for i, r := range message {
+		if isWordChar(r) {
+			if start == -1 {
+				start = i
+			}
+		} else if start != -1 {
+			words = append(words, newSearchWord(message, start, i))
+			start = -1
+		}
+	}
+
+	if start != -1 {
+		words = append(words, newSearchWord(message, start, len(message)))
+	}
+
+	return words
+}
+
+func newSearchWord(message string, start int, end int) searchWord {
+	// hashtags can contain periods, but not at the end where they're more likely to end a sentence
+	for end > start && message[end-1] == '.' {
+		end--
+	}
+
+	return searchWord{text: message[start:end], start: start, end: end}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestFindSearchMatches(t *testing.T) {
+	message := "Testing the tests, and a test plan. #test-plan"
+
+	for _, tc := range []struct {
+		terms     string
+		isHashtag bool
+		expected  []model.SearchMatch
+	}{
+		{"test", false, []model.SearchMatch{{Start: 25, End: 29}, {Start: 37, End: 41}}},
+		{"test*", false, []model.SearchMatch{{Start: 0, End: 7}, {Start: 12, End: 17}, {Start: 25, End: 29}, {Start: 37, End: 41}}},
+		{"TESTS", false, []model.SearchMatch{{Start: 12, End: 17}}},
+		{`"test plan"`, false, []model.SearchMatch{{Start: 25, End: 34}, {Start: 37, End: 46}}},
+		{"test-plan", false, []model.SearchMatch{{Start: 25, End: 29}, {Start: 30, End: 34}, {Start: 37, End: 41}, {Start: 42, End: 46}}},
+		{"#test-plan", true, []model.SearchMatch{{Start: 36, End: 46}}},
+		{"#test", true, nil},
+		{"missing", false, nil},
+	} {
+		if matches := findSearchMatches(message, parseSearchMatchTerms(tc.terms, tc.isHashtag), nil, tc.isHashtag); !reflect.DeepEqual(normalizeSearchMatches(matches), tc.expected) {
+			t.Fatalf("got incorrect matches for %v: %v", tc.terms, matches)
+		}
+	}
+
+	if matches := findSearchMatches("東京タワーに行きました", nil, compileSearchSubstrings([]string{"タワー"}), false); !reflect.DeepEqual(matches, []model.SearchMatch{{Start: 6, End: 15}}) {
+		t.Fatalf("should have found the substring: %v", matches)
+	}
+
+	if matches := findSearchMatches("TestingTests", nil, compileSearchSubstrings([]string{"test", ""}), false); !reflect.DeepEqual(matches, []model.SearchMatch{{Start: 0, End: 4}, {Start: 7, End: 11}}) {
+		t.Fatalf("should have found the substring ignoring case: %v", matches)
+	}
+}
+
+func normalizeSearchMatches(matches []model.SearchMatch) []model.SearchMatch {
+	list := &model.PostList{}
+	list.AddSearchMatches("post", matches)
+	return list.Matches["post"]
+}
+
+func TestParseSearchHeadline(t *testing.T) {
+	message := "the runners were running"
+	headline := "the " + SEARCH_HEADLINE_START_SEL + "runners" + SEARCH_HEADLINE_STOP_SEL + " were " + SEARCH_HEADLINE_START_SEL + "running" + SEARCH_HEADLINE_STOP_SEL
+
+	if matches, ok := parseSearchHeadline(message, headline); !ok {
+		t.Fatal("should have parsed the headline")
+	} else if !reflect.DeepEqual(matches, []model.SearchMatch{{Start: 4, End: 11}, {Start: 17, End: 24}}) {
+		t.Fatalf("got incorrect matches: %v", matches)
+	}
+
+	if matches, ok := parseSearchHeadline(message, message); !ok || len(matches) != 0 {
+		t.Fatal("should have parsed a headline without any matches")
+	}
+
+	if _, ok := parseSearchHeadline(message, "the "+SEARCH_HEADLINE_START_SEL+"runners"+SEARCH_HEADLINE_STOP_SEL); ok {
+		t.Fatal("shouldn't have accepted a headline that doesn't contain the whole message")
+	}
+}
@@ -4,11 +4,17 @@
 package store
 
 import (
+	"strconv"
+
 	l4g "github.com/alecthomas/log4go"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
 
+const (
+	SESSION_ACTIVITY_BATCH_SIZE = 500
+)
+
 type SqlSessionStore struct {
 	*SqlStore
 }
@@ -282,6 +288,52 @@ func (me SqlSessionStore) UpdateLastActivityAt(sessionId string, time int64) Sto
 	return storeChannel
 }
 
+// UpdateLastActivityAtBatch sets the LastActivityAt of several sessions at once, given as a map of
+// session ids to times, so that activity can be saved periodically instead of on every request.
+func (me SqlSessionStore) UpdateLastActivityAtBatch(activity map[string]int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		sessionIds := make([]string, 0, len(activity))
+		for sessionId := range activity {
+			sessionIds = append(sessionIds, sessionId)
+		}
+
+		for start := 0; start < len(sessionIds); start += SESSION_ACTIVITY_BATCH_SIZE {
+			end := start + SESSION_ACTIVITY_BATCH_SIZE
+			if end > len(sessionIds) {
+				end = len(sessionIds)
+			}
+
+			params := make(map[string]interface{})
+			cases := ""
+			ids := ""
+			for i, sessionId := range sessionIds[start:end] {
+				params["Id"+strconv.Itoa(i)] = sessionId
+				params["LastActivityAt"+strconv.Itoa(i)] = activity[sessionId]
+
+				cases += " WHEN :Id" + strconv.Itoa(i) + " THEN :LastActivityAt" + strconv.Itoa(i)
+				if len(ids) > 0 {
+					ids += ", "
+				}
+				ids += ":Id" + strconv.Itoa(i)
+			}
+
+			if _, err := me.GetMaster().Exec("UPDATE Sessions SET LastActivityAt = CASE Id"+cases+" ELSE LastActivityAt END WHERE Id IN ("+ids+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlSessionStore.UpdateLastActivityAtBatch", "store.sql_session.update_last_activity.app_error", nil, err.Error())
+				break
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (me SqlSessionStore) UpdateRoles(userId, roles string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -218,6 +218,53 @@ func TestSessionStoreUpdateLastActivityAt(t *testing.T) {
 
 }
 
+func TestSessionStoreUpdateLastActivityAtBatch(t *testing.T) {
+	Setup()
+
+	s1 := model.Session{}
+	s1.UserId = model.NewId()
+	Must(store.Session().Save(&s1))
+
+	s2 := model.Session{}
+	s2.UserId = model.NewId()
+	Must(store.Session().Save(&s2))
+
+	s3 := model.Session{}
+	s3.UserId = model.NewId()
+	Must(store.Session().Save(&s3))
+
+	activity := map[string]int64{
+		s1.Id: 1234567890,
+		s2.Id: 1234567891,
+	}
+
+	if err := (<-store.Session().UpdateLastActivityAtBatch(activity)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if r1 := <-store.Session().Get(s1.Id); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if r1.Data.(*model.Session).LastActivityAt != 1234567890 {
+		t.Fatal("LastActivityAt not updated correctly")
+	}
+
+	if r2 := <-store.Session().Get(s2.Id); r2.Err != nil {
+		t.Fatal(r2.Err)
+	} else if r2.Data.(*model.Session).LastActivityAt != 1234567891 {
+		t.Fatal("LastActivityAt not updated correctly")
+	}
+
+	if r3 := <-store.Session().Get(s3.Id); r3.Err != nil {
+		t.Fatal(r3.Err)
+	} else if r3.Data.(*model.Session).LastActivityAt != s3.LastActivityAt {
+		t.Fatal("LastActivityAt of another session shouldn't have changed")
+	}
+
+	if err := (<-store.Session().UpdateLastActivityAtBatch(map[string]int64{})).Err; err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSessionCount(t *testing.T) {
 	Setup()
 
@@ -18,6 +18,7 @@ import (
 	"io"
 	sqltrace "log"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -29,12 +30,19 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
 	INDEX_TYPE_FULL_TEXT = "full_text"
 	INDEX_TYPE_DEFAULT   = "default"
-	MAX_DB_CONN_LIFETIME = 15
+	INDEX_TYPE_PREFIX    = "prefix"
+	INDEX_TYPE_TRIGRAM   = "trigram"
+)
+
+const (
+	COLUMN_BACKFILL_SYSTEM_PREFIX = "ColumnBackfill"
+	COLUMN_BACKFILL_BATCH_SIZE    = 1000
 )
 
 const (
@@ -62,31 +70,60 @@ const (
 	EXIT_REMOVE_INDEX_POSTGRES       = 121
 	EXIT_REMOVE_INDEX_MYSQL          = 122
 	EXIT_REMOVE_INDEX_MISSING        = 123
+	EXIT_TABLE_EXISTS_SQLITE         = 124
+	EXIT_DOES_COLUMN_EXISTS_SQLITE   = 125
+	EXIT_CREATE_COLUMN_SQLITE        = 126
+	EXIT_CREATE_INDEX_SQLITE         = 127
+	EXIT_REMOVE_INDEX_SQLITE         = 128
+	EXIT_BACKFILL_COLUMN             = 129
 )
 
 type SqlStore struct {
-	master        *gorp.DbMap
-	replicas      []*gorp.DbMap
-	team          TeamStore
-	channel       ChannelStore
-	post          PostStore
-	user          UserStore
-	audit         AuditStore
-	compliance    ComplianceStore
-	session       SessionStore
-	oauth         OAuthStore
-	system        SystemStore
-	webhook       WebhookStore
-	command       CommandStore
-	preference    PreferenceStore
-	license       LicenseStore
-	recovery      PasswordRecoveryStore
-	emoji         EmojiStore
-	status        StatusStore
-	fileInfo      FileInfoStore
-	reaction      ReactionStore
-	SchemaVersion string
-	rrCounter     int64
+	master           *gorp.DbMap
+	replicas         []*gorp.DbMap
+	team             TeamStore
+	channel          ChannelStore
+	post             PostStore
+	user             UserStore
+	audit            AuditStore
+	compliance       ComplianceStore
+	session          SessionStore
+	oauth            OAuthStore
+	system           SystemStore
+	webhook          WebhookStore
+	command          CommandStore
+	preference       PreferenceStore
+	license          LicenseStore
+	recovery         PasswordRecoveryStore
+	emoji            EmojiStore
+	status           StatusStore
+	fileInfo         FileInfoStore
+	reaction         ReactionStore
+	clusterDiscovery ClusterDiscoveryStore
+	job              JobStore
+	analytics        AnalyticsStore
+	retentionPolicy  RetentionPolicyStore
+	loginAttempt     LoginAttemptStore
+	invitation       InvitationStore
+	teamInviteLink   TeamInviteLinkStore
+	certificateCache CertificateCacheStore
+	lease            LeaseStore
+	webSocketToken   WebSocketConnectionTokenStore
+	legalHold        LegalHoldStore
+	fileAccess       FileAccessStore
+	fileAcl          FileAclStore
+	threadMembership ThreadMembershipStore
+	bgMigration      BackgroundMigrationStore
+	profileImage     ProfileImageStore
+	permalinkPreview PermalinkPreviewStore
+	userAccessToken  UserAccessTokenStore
+	defaultChannel   TeamDefaultChannelStore
+	pseudonym        PseudonymStore
+	channelEmail     ChannelEmailAddressStore
+	postTranslation  PostTranslationStore
+	role             RoleStore
+	SchemaVersion    string
+	rrCounter        int64
 }
 
 func initConnection() *SqlStore {
@@ -96,7 +133,8 @@ func initConnection() *SqlStore {
 
 	sqlStore.master = setupConnection("master", utils.Cfg.SqlSettings.DriverName,
 		utils.Cfg.SqlSettings.DataSource, utils.Cfg.SqlSettings.MaxIdleConns,
-		utils.Cfg.SqlSettings.MaxOpenConns, utils.Cfg.SqlSettings.Trace)
+		utils.Cfg.SqlSettings.MaxOpenConns, *utils.Cfg.SqlSettings.ConnMaxLifetimeMilliseconds,
+		utils.Cfg.SqlSettings.Trace)
 
 	if len(utils.Cfg.SqlSettings.DataSourceReplicas) == 0 {
 		sqlStore.replicas = make([]*gorp.DbMap, 1)
@@ -105,8 +143,8 @@ func initConnection() *SqlStore {
 		sqlStore.replicas = make([]*gorp.DbMap, len(utils.Cfg.SqlSettings.DataSourceReplicas))
 		for i, replica := range utils.Cfg.SqlSettings.DataSourceReplicas {
 			sqlStore.replicas[i] = setupConnection(fmt.Sprintf("replica-%v", i), utils.Cfg.SqlSettings.DriverName, replica,
-				utils.Cfg.SqlSettings.MaxIdleConns, utils.Cfg.SqlSettings.MaxOpenConns,
-				utils.Cfg.SqlSettings.Trace)
+				*utils.Cfg.SqlSettings.ReplicaMaxIdleConns, *utils.Cfg.SqlSettings.ReplicaMaxOpenConns,
+				*utils.Cfg.SqlSettings.ReplicaConnMaxLifetimeMilliseconds, utils.Cfg.SqlSettings.Trace)
 		}
 	}
 
@@ -136,6 +174,29 @@ func NewSqlStore() Store {
 	sqlStore.status = NewSqlStatusStore(sqlStore)
 	sqlStore.fileInfo = NewSqlFileInfoStore(sqlStore)
 	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.clusterDiscovery = NewSqlClusterDiscoveryStore(sqlStore)
+	sqlStore.job = NewSqlJobStore(sqlStore)
+	sqlStore.analytics = NewSqlAnalyticsStore(sqlStore)
+	sqlStore.retentionPolicy = NewSqlRetentionPolicyStore(sqlStore)
+	sqlStore.loginAttempt = NewSqlLoginAttemptStore(sqlStore)
+	sqlStore.invitation = NewSqlInvitationStore(sqlStore)
+	sqlStore.teamInviteLink = NewSqlTeamInviteLinkStore(sqlStore)
+	sqlStore.defaultChannel = NewSqlTeamDefaultChannelStore(sqlStore)
+	sqlStore.certificateCache = NewSqlCertificateCacheStore(sqlStore)
+	sqlStore.lease = NewSqlLeaseStore(sqlStore)
+	sqlStore.webSocketToken = NewSqlWebSocketConnectionTokenStore(sqlStore)
+	sqlStore.legalHold = NewSqlLegalHoldStore(sqlStore)
+	sqlStore.fileAccess = NewSqlFileAccessStore(sqlStore)
+	sqlStore.fileAcl = NewSqlFileAclStore(sqlStore)
+	sqlStore.threadMembership = NewSqlThreadMembershipStore(sqlStore)
+	sqlStore.bgMigration = NewSqlBackgroundMigrationStore(sqlStore)
+	sqlStore.profileImage = NewSqlProfileImageStore(sqlStore)
+	sqlStore.permalinkPreview = NewSqlPermalinkPreviewStore(sqlStore)
+	sqlStore.userAccessToken = NewSqlUserAccessTokenStore(sqlStore)
+	sqlStore.pseudonym = NewSqlPseudonymStore(sqlStore)
+	sqlStore.channelEmail = NewSqlChannelEmailAddressStore(sqlStore)
+	sqlStore.postTranslation = NewSqlPostTranslationStore(sqlStore)
+	sqlStore.role = NewSqlRoleStore(sqlStore)
 
 	err := sqlStore.master.CreateTablesIfNotExists()
 	if err != nil {
@@ -164,13 +225,40 @@ func NewSqlStore() Store {
 	sqlStore.status.(*SqlStatusStore).CreateIndexesIfNotExists()
 	sqlStore.fileInfo.(*SqlFileInfoStore).CreateIndexesIfNotExists()
 	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.clusterDiscovery.(*SqlClusterDiscoveryStore).CreateIndexesIfNotExists()
+	sqlStore.job.(*SqlJobStore).CreateIndexesIfNotExists()
+	sqlStore.analytics.(*SqlAnalyticsStore).CreateIndexesIfNotExists()
+	sqlStore.retentionPolicy.(*SqlRetentionPolicyStore).CreateIndexesIfNotExists()
+	sqlStore.loginAttempt.(*SqlLoginAttemptStore).CreateIndexesIfNotExists()
+	sqlStore.invitation.(*SqlInvitationStore).CreateIndexesIfNotExists()
+	sqlStore.teamInviteLink.(*SqlTeamInviteLinkStore).CreateIndexesIfNotExists()
+	sqlStore.defaultChannel.(*SqlTeamDefaultChannelStore).CreateIndexesIfNotExists()
+	sqlStore.certificateCache.(*SqlCertificateCacheStore).CreateIndexesIfNotExists()
+	sqlStore.lease.(*SqlLeaseStore).CreateIndexesIfNotExists()
+	sqlStore.webSocketToken.(*SqlWebSocketConnectionTokenStore).CreateIndexesIfNotExists()
+	sqlStore.legalHold.(*SqlLegalHoldStore).CreateIndexesIfNotExists()
+	sqlStore.fileAccess.(*SqlFileAccessStore).CreateIndexesIfNotExists()
+	sqlStore.fileAcl.(*SqlFileAclStore).CreateIndexesIfNotExists()
+	sqlStore.threadMembership.(*SqlThreadMembershipStore).CreateIndexesIfNotExists()
+	sqlStore.bgMigration.(*SqlBackgroundMigrationStore).CreateIndexesIfNotExists()
+	sqlStore.profileImage.(*SqlProfileImageStore).CreateIndexesIfNotExists()
+	sqlStore.permalinkPreview.(*SqlPermalinkPreviewStore).CreateIndexesIfNotExists()
+	sqlStore.userAccessToken.(*SqlUserAccessTokenStore).CreateIndexesIfNotExists()
+	sqlStore.pseudonym.(*SqlPseudonymStore).CreateIndexesIfNotExists()
+	sqlStore.channelEmail.(*SqlChannelEmailAddressStore).CreateIndexesIfNotExists()
+	sqlStore.postTranslation.(*SqlPostTranslationStore).CreateIndexesIfNotExists()
+	sqlStore.role.(*SqlRoleStore).CreateIndexesIfNotExists()
 
 	sqlStore.preference.(*SqlPreferenceStore).DeleteUnusedFeatures()
 
 	return sqlStore
 }
 
-func setupConnection(con_type string, driver string, dataSource string, maxIdle int, maxOpen int, trace bool) *gorp.DbMap {
+func setupConnection(con_type string, driver string, dataSource string, maxIdle int, maxOpen int, connMaxLifetimeMilliseconds int, trace bool) *gorp.DbMap {
+
+	if driver == model.DATABASE_DRIVER_SQLITE {
+		dataSource = addSqliteConnectionOptions(dataSource)
+	}
 
 	db, err := dbsql.Open(driver, dataSource)
 	if err != nil {
@@ -189,11 +277,11 @@ func setupConnection(con_type string, driver string, dataSource string, maxIdle
 
 	db.SetMaxIdleConns(maxIdle)
 	db.SetMaxOpenConns(maxOpen)
-	db.SetConnMaxLifetime(time.Duration(MAX_DB_CONN_LIFETIME) * time.Minute)
+	db.SetConnMaxLifetime(time.Duration(connMaxLifetimeMilliseconds) * time.Millisecond)
 
 	var dbmap *gorp.DbMap
 
-	if driver == "sqlite3" {
+	if driver == model.DATABASE_DRIVER_SQLITE {
 		dbmap = &gorp.DbMap{Db: db, TypeConverter: mattermConverter{}, Dialect: gorp.SqliteDialect{}}
 	} else if driver == model.DATABASE_DRIVER_MYSQL {
 		dbmap = &gorp.DbMap{Db: db, TypeConverter: mattermConverter{}, Dialect: gorp.MySQLDialect{Engine: "InnoDB", Encoding: "UTF8MB4"}}
@@ -212,6 +300,33 @@ func setupConnection(con_type string, driver string, dataSource string, maxIdle
 	return dbmap
 }
 
+// addSqliteConnectionOptions sets any options that aren't already part of the data source so that concurrent
+// writes wait for each other. SQLite only allows a single writer, so each transaction takes the write lock when it
+// starts instead of failing when it later tries to upgrade a read lock.
+func addSqliteConnectionOptions(dataSource string) string {
+	options := map[string]string{
+		"_busy_timeout": "10000",
+		"_txlock":       "immediate",
+		"_journal_mode": "WAL",
+	}
+
+	for _, name := range []string{"_busy_timeout", "_txlock", "_journal_mode"} {
+		if strings.Contains(dataSource, name+"=") {
+			continue
+		}
+
+		if strings.Contains(dataSource, "?") {
+			dataSource += "&"
+		} else {
+			dataSource += "?"
+		}
+
+		dataSource += name + "=" + options[name]
+	}
+
+	return dataSource
+}
+
 func (ss *SqlStore) TotalMasterDbConnections() int {
 	return ss.GetMaster().Db.Stats().OpenConnections
 }
@@ -232,6 +347,22 @@ func (ss *SqlStore) TotalReadDbConnections() int {
 	return 0
 }
 
+// ConnectionPoolStats returns the statistics of the connection pool for each data source, keyed by
+// "master" or "replica-<index>".
+func (ss *SqlStore) ConnectionPoolStats() map[string]dbsql.DBStats {
+	stats := map[string]dbsql.DBStats{
+		"master": ss.GetMaster().Db.Stats(),
+	}
+
+	if len(utils.Cfg.SqlSettings.DataSourceReplicas) > 0 {
+		for i, replica := range ss.replicas {
+			stats[fmt.Sprintf("replica-%v", i)] = replica.Db.Stats()
+		}
+	}
+
+	return stats
+}
+
 func (ss *SqlStore) GetCurrentSchemaVersion() string {
 	version, _ := ss.GetMaster().SelectStr("SELECT Value FROM Systems WHERE Name='Version'")
 	return version
@@ -285,6 +416,20 @@ func (ss *SqlStore) DoesTableExist(tableName string) bool {
 
 		return count > 0
 
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		count, err := ss.GetMaster().SelectInt(
+			`SELECT count(0) FROM sqlite_master WHERE type = 'table' AND name = ? COLLATE NOCASE`,
+			tableName,
+		)
+
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.table_exists.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_TABLE_EXISTS_SQLITE)
+		}
+
+		return count > 0
+
 	} else {
 		l4g.Critical(utils.T("store.sql.column_exists_missing_driver.critical"))
 		time.Sleep(time.Second)
@@ -340,6 +485,21 @@ func (ss *SqlStore) DoesColumnExist(tableName string, columnName string) bool {
 
 		return count > 0
 
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		count, err := ss.GetMaster().SelectInt(
+			`SELECT count(0) FROM pragma_table_info(?) WHERE name = ? COLLATE NOCASE`,
+			tableName,
+			columnName,
+		)
+
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.column_exists.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_DOES_COLUMN_EXISTS_SQLITE)
+		}
+
+		return count > 0
+
 	} else {
 		l4g.Critical(utils.T("store.sql.column_exists_missing_driver.critical"))
 		time.Sleep(time.Second)
@@ -355,17 +515,38 @@ func (ss *SqlStore) CreateColumnIfNotExists(tableName string, columnName string,
 	}
 
 	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
-		_, err := ss.GetMaster().Exec("ALTER TABLE " + tableName + " ADD " + columnName + " " + postgresColType + " DEFAULT '" + defaultValue + "'")
+		// Adding a column with a default rewrites the whole table while holding a lock on it on versions of Postgres
+		// before 11. Setting the default in a separate clause only applies it to new rows, so the column is null for the
+		// existing ones until they're filled in a batch at a time. The backfill is recorded so that it can be finished
+		// if the server is stopped partway through.
+		if result := <-ss.system.SaveOrUpdate(&model.System{Name: COLUMN_BACKFILL_SYSTEM_PREFIX + tableName + "." + columnName, Value: BACKGROUND_MIGRATION_PENDING}); result.Err != nil {
+			l4g.Critical(utils.T("store.sql.create_column.critical"), result.Err.Error())
+			time.Sleep(time.Second)
+			os.Exit(EXIT_CREATE_COLUMN_POSTGRES)
+		}
+
+		_, err := ss.GetMaster().Exec("ALTER TABLE " + tableName + " ADD " + columnName + " " + postgresColType + ", ALTER COLUMN " + columnName + " SET DEFAULT '" + defaultValue + "'")
 		if err != nil {
 			l4g.Critical(utils.T("store.sql.create_column.critical"), err)
 			time.Sleep(time.Second)
 			os.Exit(EXIT_CREATE_COLUMN_POSTGRES)
 		}
 
+		ss.backfillColumn(tableName, columnName)
+
 		return true
 
 	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
-		_, err := ss.GetMaster().Exec("ALTER TABLE " + tableName + " ADD " + columnName + " " + mySqlColType + " DEFAULT '" + defaultValue + "'")
+		query := "ALTER TABLE " + tableName + " ADD " + columnName + " " + mySqlColType + " DEFAULT '" + defaultValue + "'"
+
+		// Add the column without locking the table so that large tables like Posts can still be written to while it's
+		// being rebuilt. Versions of MySQL that don't support online changes fall back to locking it.
+		_, err := ss.GetMaster().Exec(query + ", ALGORITHM=INPLACE, LOCK=NONE")
+		if err != nil {
+			l4g.Warn(utils.T("store.sql.create_column_online.warn"), tableName, columnName, err)
+			_, err = ss.GetMaster().Exec(query)
+		}
+
 		if err != nil {
 			l4g.Critical(utils.T("store.sql.create_column.critical"), err)
 			time.Sleep(time.Second)
@@ -374,6 +555,17 @@ func (ss *SqlStore) CreateColumnIfNotExists(tableName string, columnName string,
 
 		return true
 
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		// SQLite accepts the MySQL column types since it only uses them to pick a type affinity
+		_, err := ss.GetMaster().Exec("ALTER TABLE " + tableName + " ADD " + columnName + " " + mySqlColType + " DEFAULT '" + defaultValue + "'")
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.create_column.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_CREATE_COLUMN_SQLITE)
+		}
+
+		return true
+
 	} else {
 		l4g.Critical(utils.T("store.sql.create_column_missing_driver.critical"))
 		time.Sleep(time.Second)
@@ -382,6 +574,69 @@ func (ss *SqlStore) CreateColumnIfNotExists(tableName string, columnName string,
 	}
 }
 
+// backfillColumn sets a column that was added without a default for existing rows to its default in each of those rows.
+// Tables with an Id column are filled in a batch at a time so that they aren't locked for long. The rest are small
+// enough to fill in at once.
+func (ss *SqlStore) backfillColumn(tableName string, columnName string) {
+	if !ss.DoesColumnExist(tableName, "Id") {
+		if _, err := ss.GetMaster().Exec("UPDATE " + tableName + " SET " + columnName + " = DEFAULT WHERE " + columnName + " IS NULL"); err != nil {
+			l4g.Critical(utils.T("store.sql.backfill_column.critical"), tableName, columnName, err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_BACKFILL_COLUMN)
+		}
+	} else {
+		afterId := ""
+		for {
+			var ids []string
+			if _, err := ss.GetMaster().Select(&ids, "SELECT Id FROM "+tableName+" WHERE Id > :AfterId ORDER BY Id LIMIT :Limit", map[string]interface{}{"AfterId": afterId, "Limit": COLUMN_BACKFILL_BATCH_SIZE}); err != nil {
+				l4g.Critical(utils.T("store.sql.backfill_column.critical"), tableName, columnName, err)
+				time.Sleep(time.Second)
+				os.Exit(EXIT_BACKFILL_COLUMN)
+			}
+
+			if len(ids) == 0 {
+				break
+			}
+
+			if _, err := ss.GetMaster().Exec("UPDATE "+tableName+" SET "+columnName+" = DEFAULT WHERE Id >= :FirstId AND Id <= :LastId AND "+columnName+" IS NULL", map[string]interface{}{"FirstId": ids[0], "LastId": ids[len(ids)-1]}); err != nil {
+				l4g.Critical(utils.T("store.sql.backfill_column.critical"), tableName, columnName, err)
+				time.Sleep(time.Second)
+				os.Exit(EXIT_BACKFILL_COLUMN)
+			}
+
+			afterId = ids[len(ids)-1]
+		}
+	}
+
+	if _, err := ss.GetMaster().Exec("DELETE FROM Systems WHERE Name = :Name", map[string]interface{}{"Name": COLUMN_BACKFILL_SYSTEM_PREFIX + tableName + "." + columnName}); err != nil {
+		l4g.Critical(utils.T("store.sql.backfill_column.critical"), tableName, columnName, err)
+		time.Sleep(time.Second)
+		os.Exit(EXIT_BACKFILL_COLUMN)
+	}
+}
+
+// resumeColumnBackfills finishes filling in any columns that were still being filled in when the server was stopped.
+func (ss *SqlStore) resumeColumnBackfills() {
+	if utils.Cfg.SqlSettings.DriverName != model.DATABASE_DRIVER_POSTGRES {
+		return
+	}
+
+	var names []string
+	if _, err := ss.GetMaster().Select(&names, "SELECT Name FROM Systems WHERE Name LIKE :Prefix", map[string]interface{}{"Prefix": COLUMN_BACKFILL_SYSTEM_PREFIX + "%"}); err != nil {
+		l4g.Critical(utils.T("store.sql.resume_column_backfills.critical"), err)
+		time.Sleep(time.Second)
+		os.Exit(EXIT_BACKFILL_COLUMN)
+	}
+
+	for _, name := range names {
+		if parts := strings.SplitN(strings.TrimPrefix(name, COLUMN_BACKFILL_SYSTEM_PREFIX), ".", 2); len(parts) == 2 && ss.DoesColumnExist(parts[0], parts[1]) {
+			ss.backfillColumn(parts[0], parts[1])
+		} else {
+			ss.GetMaster().Exec("DELETE FROM Systems WHERE Name = :Name", map[string]interface{}{"Name": name})
+		}
+	}
+}
+
 func (ss *SqlStore) RemoveColumnIfExists(tableName string, columnName string) bool {
 
 	if !ss.DoesColumnExist(tableName, columnName) {
@@ -406,7 +661,7 @@ func (ss *SqlStore) RenameColumnIfExists(tableName string, oldColumnName string,
 	var err error
 	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
 		_, err = ss.GetMaster().Exec("ALTER TABLE " + tableName + " CHANGE " + oldColumnName + " " + newColumnName + " " + colType)
-	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES || utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
 		_, err = ss.GetMaster().Exec("ALTER TABLE " + tableName + " RENAME COLUMN " + oldColumnName + " TO " + newColumnName)
 	}
 
@@ -430,6 +685,13 @@ func (ss *SqlStore) GetMaxLengthOfColumnIfExists(tableName string, columnName st
 		result, err = ss.GetMaster().SelectStr("SELECT CHARACTER_MAXIMUM_LENGTH FROM information_schema.columns WHERE table_name = '" + tableName + "' AND COLUMN_NAME = '" + columnName + "'")
 	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 		result, err = ss.GetMaster().SelectStr("SELECT character_maximum_length FROM information_schema.columns WHERE table_name = '" + strings.ToLower(tableName) + "' AND column_name = '" + strings.ToLower(columnName) + "'")
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		// SQLite doesn't enforce the length, but it's still part of the declared type like VARCHAR(50)
+		var colType string
+		colType, err = ss.GetMaster().SelectStr("SELECT type FROM pragma_table_info(?) WHERE name = ? COLLATE NOCASE", tableName, columnName)
+		if start, end := strings.Index(colType, "("), strings.Index(colType, ")"); start != -1 && end > start {
+			result = colType[start+1 : end]
+		}
 	}
 
 	if err != nil {
@@ -451,6 +713,9 @@ func (ss *SqlStore) AlterColumnTypeIfExists(tableName string, columnName string,
 		_, err = ss.GetMaster().Exec("ALTER TABLE " + tableName + " MODIFY " + columnName + " " + mySqlColType)
 	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 		_, err = ss.GetMaster().Exec("ALTER TABLE " + strings.ToLower(tableName) + " ALTER COLUMN " + strings.ToLower(columnName) + " TYPE " + postgresColType)
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		// SQLite can't change the type of a column, but it doesn't enforce types or lengths either
+		return false
 	}
 
 	if err != nil {
@@ -474,6 +739,23 @@ func (ss *SqlStore) CreateFullTextIndexIfNotExists(indexName string, tableName s
 	return ss.createIndexIfNotExists(indexName, tableName, columnName, INDEX_TYPE_FULL_TEXT, false)
 }
 
+// CreatePrefixIndexIfNotExists creates an index that can be used for case insensitive prefix
+// matching with LIKE. On Postgres, a trigram index is used when the pg_trgm extension is available.
+func (ss *SqlStore) CreatePrefixIndexIfNotExists(indexName string, tableName string, columnName string) bool {
+	return ss.createIndexIfNotExists(indexName, tableName, columnName, INDEX_TYPE_PREFIX, false)
+}
+
+// CreateTrigramIndexIfNotExists creates an index that can be used to find a substring anywhere in a column with LIKE.
+// It's only created on Postgres when the pg_trgm extension is available since the other databases don't have an index
+// that supports that.
+func (ss *SqlStore) CreateTrigramIndexIfNotExists(indexName string, tableName string, columnName string) bool {
+	if utils.Cfg.SqlSettings.DriverName != model.DATABASE_DRIVER_POSTGRES {
+		return false
+	}
+
+	return ss.createIndexIfNotExists(indexName, tableName, columnName, INDEX_TYPE_TRIGRAM, false)
+}
+
 func (ss *SqlStore) createIndexIfNotExists(indexName string, tableName string, columnName string, indexType string, unique bool) bool {
 
 	uniqueStr := ""
@@ -492,12 +774,32 @@ func (ss *SqlStore) createIndexIfNotExists(indexName string, tableName string, c
 		if indexType == INDEX_TYPE_FULL_TEXT {
 			postgresColumnNames := convertMySQLFullTextColumnsToPostgres(columnName)
 			query = "CREATE INDEX " + indexName + " ON " + tableName + " USING gin(to_tsvector('english', " + postgresColumnNames + "))"
+		} else if indexType == INDEX_TYPE_PREFIX {
+			postgresColumnNames := "lower(" + convertMySQLFullTextColumnsToPostgres(columnName) + ")"
+			if ss.createTrigramExtensionIfNotExists() {
+				query = "CREATE INDEX " + indexName + " ON " + tableName + " USING gin(" + postgresColumnNames + " gin_trgm_ops)"
+			} else {
+				query = "CREATE INDEX " + indexName + " ON " + tableName + " (" + postgresColumnNames + " text_pattern_ops)"
+			}
+		} else if indexType == INDEX_TYPE_TRIGRAM {
+			if !ss.createTrigramExtensionIfNotExists() {
+				return false
+			}
+
+			query = "CREATE INDEX " + indexName + " ON " + tableName + " USING gin(" + columnName + " gin_trgm_ops)"
 		} else {
 			query = "CREATE " + uniqueStr + "INDEX " + indexName + " ON " + tableName + " (" + columnName + ")"
 		}
 
+		// Build the index concurrently so that writes to the table aren't blocked while it's being built
+		query = strings.Replace(query, "INDEX "+indexName, "INDEX CONCURRENTLY "+indexName, 1)
+
 		_, err = ss.GetMaster().Exec(query)
 		if err != nil {
+			// A concurrent build that fails leaves an invalid index behind that would otherwise be mistaken for a
+			// finished one the next time the server starts
+			ss.GetMaster().Exec("DROP INDEX IF EXISTS " + indexName)
+
 			l4g.Critical(utils.T("store.sql.create_index.critical"), err)
 			time.Sleep(time.Second)
 			os.Exit(EXIT_CREATE_INDEX_POSTGRES)
@@ -520,12 +822,46 @@ func (ss *SqlStore) createIndexIfNotExists(indexName string, tableName string, c
 			fullTextIndex = " FULLTEXT "
 		}
 
-		_, err = ss.GetMaster().Exec("CREATE  " + uniqueStr + fullTextIndex + " INDEX " + indexName + " ON " + tableName + " (" + columnName + ")")
+		query := "CREATE  " + uniqueStr + fullTextIndex + " INDEX " + indexName + " ON " + tableName + " (" + columnName + ")"
+
+		// Build the index without locking the table when it's supported, which isn't the case for full text indexes
+		// or older versions of MySQL
+		if indexType == INDEX_TYPE_FULL_TEXT {
+			_, err = ss.GetMaster().Exec(query)
+		} else if _, err = ss.GetMaster().Exec(query + " ALGORITHM=INPLACE LOCK=NONE"); err != nil {
+			l4g.Warn(utils.T("store.sql.create_index_online.warn"), indexName, err)
+			_, err = ss.GetMaster().Exec(query)
+		}
+
 		if err != nil {
 			l4g.Critical(utils.T("store.sql.create_index.critical"), err)
 			time.Sleep(time.Second)
 			os.Exit(EXIT_CREATE_INDEX_FULL_MYSQL)
 		}
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		count, err := ss.GetMaster().SelectInt("SELECT count(0) FROM sqlite_master WHERE type = 'index' AND name = ?", indexName)
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.check_index.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_CREATE_INDEX_SQLITE)
+		}
+
+		if count > 0 {
+			return false
+		}
+
+		if indexType == INDEX_TYPE_FULL_TEXT {
+			// SQLite only supports full text search through virtual tables, so searches fall back to
+			// LIKE queries and don't use an index
+			return false
+		}
+
+		_, err = ss.GetMaster().Exec("CREATE " + uniqueStr + "INDEX " + indexName + " ON " + tableName + " (" + columnName + ")")
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.create_index.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_CREATE_INDEX_SQLITE)
+		}
 	} else {
 		l4g.Critical(utils.T("store.sql.create_index_missing_driver.critical"))
 		time.Sleep(time.Second)
@@ -535,6 +871,17 @@ func (ss *SqlStore) createIndexIfNotExists(indexName string, tableName string, c
 	return true
 }
 
+func (ss *SqlStore) createTrigramExtensionIfNotExists() bool {
+	// This requires the extension to be installed and enough privileges to enable it, so fall back to a
+	// regular index if it fails
+	if _, err := ss.GetMaster().Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm"); err != nil {
+		l4g.Warn(utils.T("store.sql.create_trigram_extension.warn"), err)
+		return false
+	}
+
+	return true
+}
+
 func (ss *SqlStore) RemoveIndexIfExists(indexName string, tableName string) bool {
 
 	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
@@ -571,6 +918,24 @@ func (ss *SqlStore) RemoveIndexIfExists(indexName string, tableName string) bool
 			time.Sleep(time.Second)
 			os.Exit(EXIT_REMOVE_INDEX_MYSQL)
 		}
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		count, err := ss.GetMaster().SelectInt("SELECT count(0) FROM sqlite_master WHERE type = 'index' AND name = ?", indexName)
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.check_index.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_REMOVE_INDEX_SQLITE)
+		}
+
+		if count <= 0 {
+			return false
+		}
+
+		_, err = ss.GetMaster().Exec("DROP INDEX " + indexName)
+		if err != nil {
+			l4g.Critical(utils.T("store.sql.remove_index.critical"), err)
+			time.Sleep(time.Second)
+			os.Exit(EXIT_REMOVE_INDEX_SQLITE)
+		}
 	} else {
 		l4g.Critical(utils.T("store.sql.create_index_missing_driver.critical"))
 		time.Sleep(time.Second)
@@ -581,7 +946,7 @@ func (ss *SqlStore) RemoveIndexIfExists(indexName string, tableName string) bool
 }
 
 func IsUniqueConstraintError(err string, indexName []string) bool {
-	unique := strings.Contains(err, "unique constraint") || strings.Contains(err, "Duplicate entry")
+	unique := strings.Contains(err, "unique constraint") || strings.Contains(err, "Duplicate entry") || strings.Contains(err, "UNIQUE constraint")
 	field := false
 	for _, contain := range indexName {
 		if strings.Contains(err, contain) {
@@ -689,6 +1054,98 @@ func (ss *SqlStore) Reaction() ReactionStore {
 	return ss.reaction
 }
 
+func (ss *SqlStore) ClusterDiscovery() ClusterDiscoveryStore {
+	return ss.clusterDiscovery
+}
+
+func (ss *SqlStore) Job() JobStore {
+	return ss.job
+}
+
+func (ss *SqlStore) Analytics() AnalyticsStore {
+	return ss.analytics
+}
+
+func (ss *SqlStore) RetentionPolicy() RetentionPolicyStore {
+	return ss.retentionPolicy
+}
+
+func (ss *SqlStore) LoginAttempt() LoginAttemptStore {
+	return ss.loginAttempt
+}
+
+func (ss *SqlStore) Invitation() InvitationStore {
+	return ss.invitation
+}
+
+func (ss *SqlStore) TeamInviteLink() TeamInviteLinkStore {
+	return ss.teamInviteLink
+}
+
+func (ss *SqlStore) TeamDefaultChannel() TeamDefaultChannelStore {
+	return ss.defaultChannel
+}
+
+func (ss *SqlStore) CertificateCache() CertificateCacheStore {
+	return ss.certificateCache
+}
+
+func (ss *SqlStore) Lease() LeaseStore {
+	return ss.lease
+}
+
+func (ss *SqlStore) WebSocketConnectionToken() WebSocketConnectionTokenStore {
+	return ss.webSocketToken
+}
+
+func (ss *SqlStore) LegalHold() LegalHoldStore {
+	return ss.legalHold
+}
+
+func (ss *SqlStore) FileAccess() FileAccessStore {
+	return ss.fileAccess
+}
+
+func (ss *SqlStore) FileAcl() FileAclStore {
+	return ss.fileAcl
+}
+
+func (ss *SqlStore) ThreadMembership() ThreadMembershipStore {
+	return ss.threadMembership
+}
+
+func (ss *SqlStore) BackgroundMigration() BackgroundMigrationStore {
+	return ss.bgMigration
+}
+
+func (ss *SqlStore) ProfileImage() ProfileImageStore {
+	return ss.profileImage
+}
+
+func (ss *SqlStore) PermalinkPreview() PermalinkPreviewStore {
+	return ss.permalinkPreview
+}
+
+func (ss *SqlStore) UserAccessToken() UserAccessTokenStore {
+	return ss.userAccessToken
+}
+
+func (ss *SqlStore) Pseudonym() PseudonymStore {
+	return ss.pseudonym
+}
+
+func (ss *SqlStore) ChannelEmailAddress() ChannelEmailAddressStore {
+	return ss.channelEmail
+}
+
+func (ss *SqlStore) PostTranslation() PostTranslationStore {
+	return ss.postTranslation
+}
+
+func (ss *SqlStore) Role() RoleStore {
+	return ss.role
+}
+
 func (ss *SqlStore) DropAllTables() {
 	ss.master.TruncateTables()
 }
@@ -777,6 +1234,44 @@ func convertMySQLFullTextColumnsToPostgres(columnNames string) string {
 	return concatenatedColumnNames
 }
 
+// escapeLikeTerm escapes any wildcards in a term that's going to be used in a LIKE pattern.
+func escapeLikeTerm(term string) string {
+	term = strings.Replace(term, "\\", "\\\\", -1)
+	term = strings.Replace(term, "%", "\\%", -1)
+	term = strings.Replace(term, "_", "\\_", -1)
+
+	return term
+}
+
+// generateSqliteSearchClause approximates a full text search, which SQLite only supports through virtual
+// tables, by looking for each of the terms anywhere in any of the columns.
+func generateSqliteSearchClause(columns []string, terms []string, orTerms bool, params map[string]interface{}) string {
+	return generateLikeSearchClause("SearchTerm", columns, terms, orTerms, params)
+}
+
+// generateLikeSearchClause looks for each of the terms anywhere in any of the columns. Its parameters are named
+// starting with paramPrefix so that it can be used alongside other clauses that do the same.
+func generateLikeSearchClause(paramPrefix string, columns []string, terms []string, orTerms bool, params map[string]interface{}) string {
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		paramName := paramPrefix + strconv.Itoa(i)
+		params[paramName] = "%" + escapeLikeTerm(term) + "%"
+
+		columnClauses := make([]string, len(columns))
+		for j, column := range columns {
+			columnClauses[j] = column + " LIKE :" + paramName + " ESCAPE '\\'"
+		}
+
+		clauses[i] = "(" + strings.Join(columnClauses, " OR ") + ")"
+	}
+
+	if orTerms {
+		return "AND (" + strings.Join(clauses, " OR ") + ")"
+	} else {
+		return "AND (" + strings.Join(clauses, " AND ") + ")"
+	}
+}
+
 func encrypt(key []byte, text string) (string, error) {
 
 	if text == "" || text == "{}" {
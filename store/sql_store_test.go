@@ -4,6 +4,7 @@
 package store
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -16,7 +17,7 @@ var store Store
 func Setup() {
 	if store == nil {
 		utils.TranslationsPreInit()
-		utils.LoadConfig("config.json")
+		loadConfig()
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		store = NewSqlStore()
 
@@ -24,14 +25,25 @@ func Setup() {
 	}
 }
 
-func TestSqlStore1(t *testing.T) {
+// loadConfig loads the config while allowing the database to be overridden so that the tests can be run
+// without a database server, such as with MM_SQL_DRIVER=sqlite3 and MM_SQL_DATA_SOURCE=/tmp/mattermost_test.db
+func loadConfig() {
 	utils.LoadConfig("config.json")
+
+	if driverName := os.Getenv("MM_SQL_DRIVER"); driverName != "" {
+		utils.Cfg.SqlSettings.DriverName = driverName
+		utils.Cfg.SqlSettings.DataSource = os.Getenv("MM_SQL_DATA_SOURCE")
+	}
+}
+
+func TestSqlStore1(t *testing.T) {
+	loadConfig()
 	utils.Cfg.SqlSettings.Trace = true
 
 	store := NewSqlStore()
 	store.Close()
 
-	utils.LoadConfig("config.json")
+	loadConfig()
 }
 
 func TestEncrypt(t *testing.T) {
@@ -82,14 +94,17 @@ func TestAlertDbCmds(t *testing.T) {
 		t.Fatal("Failed to get max length found " + maxLen)
 	}
 
-	if !sqlStore.AlterColumnTypeIfExists("Systems", "Test", "VARCHAR(25)", "VARCHAR(25)") {
-		t.Fatal("failed to alter column size")
-	}
+	// SQLite can't change the type of a column
+	if utils.Cfg.SqlSettings.DriverName != model.DATABASE_DRIVER_SQLITE {
+		if !sqlStore.AlterColumnTypeIfExists("Systems", "Test", "VARCHAR(25)", "VARCHAR(25)") {
+			t.Fatal("failed to alter column size")
+		}
 
-	maxLen2 := sqlStore.GetMaxLengthOfColumnIfExists("Systems", "Test")
+		maxLen2 := sqlStore.GetMaxLengthOfColumnIfExists("Systems", "Test")
 
-	if maxLen2 != "25" {
-		t.Fatal("Failed to get max length")
+		if maxLen2 != "25" {
+			t.Fatal("Failed to get max length")
+		}
 	}
 
 	if !sqlStore.RenameColumnIfExists("Systems", "Test", "Test1", "VARCHAR(25)") {
@@ -166,3 +181,26 @@ func TestRemoveIndexIfExists(t *testing.T) {
 		t.Fatal("Should've failed to remove index that was already removed")
 	}
 }
+
+func TestSqlStoreCheckHealth(t *testing.T) {
+	Setup()
+
+	health := store.CheckHealth()
+
+	if health.Master == nil || len(health.Master.Errors) != 0 {
+		t.Fatal("should have been able to reach the master", health.Master)
+	}
+
+	if health.SchemaVersion != model.CurrentVersion || health.ExpectedSchemaVersion != model.CurrentVersion {
+		t.Fatal("should have found the current schema version", health.SchemaVersion)
+	}
+
+	if health.CheckedAt == 0 || health.Master.LatencyMilliseconds <= 0 {
+		t.Fatal("should have measured the latency")
+	}
+
+	health.Evaluate(0, 0)
+	if !health.IsHealthy() {
+		t.Fatal("should be healthy")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlTeamDefaultChannelStore struct {
+	*SqlStore
+}
+
+func NewSqlTeamDefaultChannelStore(sqlStore *SqlStore) TeamDefaultChannelStore {
+	s := &SqlTeamDefaultChannelStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.TeamDefaultChannel{}, "TeamDefaultChannels").SetKeys(false, "TeamId", "ChannelId")
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlTeamDefaultChannelStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_team_default_channels_channel_id", "TeamDefaultChannels", "ChannelId")
+}
+
+// GetForTeam returns the channels that new members of a team are added to in the order that they were added to the
+// set.
+func (s SqlTeamDefaultChannelStore) GetForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var defaultChannels []*model.TeamDefaultChannel
+		if _, err := s.GetReplica().Select(&defaultChannels, "SELECT * FROM TeamDefaultChannels WHERE TeamId = :TeamId ORDER BY CreateAt, ChannelId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.GetForTeam", "store.sql_team_default_channel.get_for_team.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = defaultChannels
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ReplaceForTeam replaces all of a team's default channels with the given ones in a single transaction so that users
+// joining the team never see part of the old set and part of the new one. The channels are returned by GetForTeam in
+// the order that they're given.
+func (s SqlTeamDefaultChannelStore) ReplaceForTeam(teamId string, channelIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		createAt := model.GetMillis()
+
+		defaultChannels := make([]*model.TeamDefaultChannel, len(channelIds))
+		for i, channelId := range channelIds {
+			// GetForTeam orders the channels by when they were added
+			defaultChannels[i] = &model.TeamDefaultChannel{TeamId: teamId, ChannelId: channelId, CreateAt: createAt + int64(i)}
+			defaultChannels[i].PreSave()
+
+			if result.Err = defaultChannels[i].IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.ReplaceForTeam", "store.sql_team_default_channel.replace_for_team.open_transaction.app_error", nil, err.Error())
+		} else if _, err := transaction.Exec("DELETE FROM TeamDefaultChannels WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.ReplaceForTeam", "store.sql_team_default_channel.replace_for_team.delete.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			for _, defaultChannel := range defaultChannels {
+				if err := transaction.Insert(defaultChannel); err != nil {
+					transaction.Rollback()
+					result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.ReplaceForTeam", "store.sql_team_default_channel.replace_for_team.save.app_error", nil, "team_id="+teamId+", channel_id="+defaultChannel.ChannelId+", "+err.Error())
+					break
+				}
+			}
+
+			if result.Err == nil {
+				if err := transaction.Commit(); err != nil {
+					result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.ReplaceForTeam", "store.sql_team_default_channel.replace_for_team.commit_transaction.app_error", nil, err.Error())
+				} else {
+					result.Data = defaultChannels
+				}
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForChannel removes a channel from the default channels of its team, such as when it's deleted.
+func (s SqlTeamDefaultChannelStore) DeleteForChannel(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamDefaultChannels WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.DeleteForChannel", "store.sql_team_default_channel.delete_for_channel.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlTeamDefaultChannelStore) PermanentDeleteByTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamDefaultChannels WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamDefaultChannelStore.PermanentDeleteByTeam", "store.sql_team_default_channel.permanent_delete_by_team.app_error", nil, "team_id="+teamId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
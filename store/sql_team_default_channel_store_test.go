@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestTeamDefaultChannelStore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	channelId1 := model.NewId()
+	channelId2 := model.NewId()
+	channelId3 := model.NewId()
+
+	Must(store.TeamDefaultChannel().ReplaceForTeam(teamId, []string{channelId1, channelId2}))
+	Must(store.TeamDefaultChannel().ReplaceForTeam(model.NewId(), []string{channelId1}))
+
+	if defaultChannels := Must(store.TeamDefaultChannel().GetForTeam(teamId)).([]*model.TeamDefaultChannel); len(defaultChannels) != 2 {
+		t.Fatal("should have saved the default channels")
+	}
+
+	if result := <-store.TeamDefaultChannel().ReplaceForTeam(teamId, []string{channelId3, "junk"}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid channel")
+	}
+
+	if defaultChannels := Must(store.TeamDefaultChannel().GetForTeam(teamId)).([]*model.TeamDefaultChannel); len(defaultChannels) != 2 {
+		t.Fatal("shouldn't have changed the default channels when saving failed")
+	}
+
+	Must(store.TeamDefaultChannel().ReplaceForTeam(teamId, []string{channelId3, channelId2}))
+
+	if defaultChannels := Must(store.TeamDefaultChannel().GetForTeam(teamId)).([]*model.TeamDefaultChannel); len(defaultChannels) != 2 || defaultChannels[0].ChannelId != channelId3 || defaultChannels[1].ChannelId != channelId2 {
+		t.Fatal("should have replaced the default channels in the given order")
+	}
+
+	Must(store.TeamDefaultChannel().DeleteForChannel(channelId2))
+
+	if defaultChannels := Must(store.TeamDefaultChannel().GetForTeam(teamId)).([]*model.TeamDefaultChannel); len(defaultChannels) != 1 || defaultChannels[0].ChannelId != channelId3 {
+		t.Fatal("should have removed the deleted channel")
+	}
+
+	Must(store.TeamDefaultChannel().PermanentDeleteByTeam(teamId))
+
+	if defaultChannels := Must(store.TeamDefaultChannel().GetForTeam(teamId)).([]*model.TeamDefaultChannel); len(defaultChannels) != 0 {
+		t.Fatal("should have deleted the team's default channels")
+	}
+}
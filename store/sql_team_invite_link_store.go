@@ -0,0 +1,191 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlTeamInviteLinkStore struct {
+	*SqlStore
+}
+
+func NewSqlTeamInviteLinkStore(sqlStore *SqlStore) TeamInviteLinkStore {
+	s := &SqlTeamInviteLinkStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.TeamInviteLink{}, "TeamInviteLinks").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlTeamInviteLinkStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_team_invite_links_team_id", "TeamInviteLinks", "TeamId")
+}
+
+func (s SqlTeamInviteLinkStore) Save(link *model.TeamInviteLink) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		link.PreSave()
+		if result.Err = link.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(link); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.Save", "store.sql_team_invite_link.save.app_error", nil, "id="+link.Id+", "+err.Error())
+		} else {
+			result.Data = link
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Get reads from the master so that a link that was just replaced on another server can't be
+// used because of replication lag.
+func (s SqlTeamInviteLinkStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var link model.TeamInviteLink
+		if err := s.GetMaster().SelectOne(&link, "SELECT * FROM TeamInviteLinks WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlTeamInviteLinkStore.Get", "store.sql_team_invite_link.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.Get", "store.sql_team_invite_link.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = &link
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForTeam returns all of the links that have been created for a team, including the ones
+// that no longer work, so that the number of users that joined through each can be reported.
+func (s SqlTeamInviteLinkStore) GetForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var links []*model.TeamInviteLink
+		if _, err := s.GetReplica().Select(&links, "SELECT * FROM TeamInviteLinks WHERE TeamId = :TeamId ORDER BY CreateAt DESC", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.GetForTeam", "store.sql_team_invite_link.get_for_team.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = links
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// IncrementUseCount records a use of a link if it's still active. The number of updated rows
+// is returned so that a link can't be used more than MaxUses times by concurrent requests.
+func (s SqlTeamInviteLinkStore) IncrementUseCount(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				TeamInviteLinks
+			SET
+				UseCount = UseCount + 1
+			WHERE
+				Id = :Id
+				AND DeleteAt = 0
+				AND (MaxUses = 0 OR UseCount < MaxUses)
+				AND (ExpiresAt = 0 OR ExpiresAt > :Now)`,
+			map[string]interface{}{"Id": id, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.IncrementUseCount", "store.sql_team_invite_link.increment_use_count.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			rowsAffected, _ := sqlResult.RowsAffected()
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DecrementUseCount gives back a use of a link that was recorded by IncrementUseCount.
+func (s SqlTeamInviteLinkStore) DecrementUseCount(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE TeamInviteLinks SET UseCount = UseCount - 1 WHERE Id = :Id AND UseCount > 0", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.DecrementUseCount", "store.sql_team_invite_link.decrement_use_count.app_error", nil, "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForTeam stops all of a team's links from working while keeping their use counts.
+func (s SqlTeamInviteLinkStore) DeleteForTeam(teamId string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE TeamInviteLinks SET DeleteAt = :DeleteAt WHERE TeamId = :TeamId AND DeleteAt = 0", map[string]interface{}{"DeleteAt": time, "TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.DeleteForTeam", "store.sql_team_invite_link.delete.app_error", nil, "team_id="+teamId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlTeamInviteLinkStore) PermanentDeleteByTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamInviteLinks WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamInviteLinkStore.PermanentDeleteByTeam", "store.sql_team_invite_link.delete.app_error", nil, "team_id="+teamId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestTeamInviteLinkStoreSaveGet(t *testing.T) {
+	Setup()
+
+	link := &model.TeamInviteLink{TeamId: model.NewId(), CreatorId: model.NewId(), MaxUses: 5}
+	if err := (<-store.TeamInviteLink().Save(link)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.TeamInviteLink().Get(link.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.(*model.TeamInviteLink); saved.TeamId != link.TeamId || saved.MaxUses != link.MaxUses {
+		t.Fatal("should have gotten the saved link")
+	}
+
+	if result := <-store.TeamInviteLink().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing link")
+	}
+
+	if err := (<-store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: model.NewId()})).Err; err == nil {
+		t.Fatal("shouldn't have saved a link without a creator")
+	}
+}
+
+func TestTeamInviteLinkStoreIncrementUseCount(t *testing.T) {
+	Setup()
+
+	link := Must(store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: model.NewId(), CreatorId: model.NewId(), MaxUses: 2})).(*model.TeamInviteLink)
+
+	for i := 0; i < 2; i++ {
+		if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(link.Id)).(int64); rowsAffected != 1 {
+			t.Fatal("should have used the link")
+		}
+	}
+
+	if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(link.Id)).(int64); rowsAffected != 0 {
+		t.Fatal("shouldn't be able to use the link more than its maximum number of uses")
+	}
+
+	if saved := Must(store.TeamInviteLink().Get(link.Id)).(*model.TeamInviteLink); saved.UseCount != 2 {
+		t.Fatal("should have counted the uses", saved.UseCount)
+	}
+
+	Must(store.TeamInviteLink().DecrementUseCount(link.Id))
+
+	if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(link.Id)).(int64); rowsAffected != 1 {
+		t.Fatal("should be able to use the link again once a use was given back")
+	}
+
+	expired := Must(store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: model.NewId(), CreatorId: model.NewId(), ExpiresAt: model.GetMillis() + 100000})).(*model.TeamInviteLink)
+	if _, err := store.(*SqlStore).GetMaster().Exec("UPDATE TeamInviteLinks SET ExpiresAt = 1 WHERE Id = :Id", map[string]interface{}{"Id": expired.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(expired.Id)).(int64); rowsAffected != 0 {
+		t.Fatal("shouldn't be able to use an expired link")
+	}
+}
+
+func TestTeamInviteLinkStoreDeleteForTeam(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	l1 := Must(store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: teamId, CreatorId: model.NewId()})).(*model.TeamInviteLink)
+	Must(store.TeamInviteLink().IncrementUseCount(l1.Id))
+	other := Must(store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: model.NewId(), CreatorId: model.NewId()})).(*model.TeamInviteLink)
+
+	if err := (<-store.TeamInviteLink().DeleteForTeam(teamId, model.GetMillis())).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(l1.Id)).(int64); rowsAffected != 0 {
+		t.Fatal("shouldn't be able to use a deleted link")
+	}
+
+	if rowsAffected := Must(store.TeamInviteLink().IncrementUseCount(other.Id)).(int64); rowsAffected != 1 {
+		t.Fatal("should still be able to use another team's link")
+	}
+
+	l2 := Must(store.TeamInviteLink().Save(&model.TeamInviteLink{TeamId: teamId, CreatorId: model.NewId()})).(*model.TeamInviteLink)
+
+	if links := Must(store.TeamInviteLink().GetForTeam(teamId)).([]*model.TeamInviteLink); len(links) != 2 {
+		t.Fatal("should have returned the team's links", len(links))
+	} else {
+		for _, link := range links {
+			if link.Id == l1.Id && (link.UseCount != 1 || link.DeleteAt == 0) {
+				t.Fatal("should have returned deleted links with their use counts")
+			} else if link.Id == l2.Id && link.DeleteAt != 0 {
+				t.Fatal("new link shouldn't have been deleted")
+			}
+		}
+	}
+
+	if err := (<-store.TeamInviteLink().PermanentDeleteByTeam(teamId)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if links := Must(store.TeamInviteLink().GetForTeam(teamId)).([]*model.TeamInviteLink); len(links) != 0 {
+		t.Fatal("should have deleted the team's links")
+	}
+}
@@ -54,6 +54,7 @@ func (s SqlTeamStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_teammembers_team_id", "TeamMembers", "TeamId")
 	s.CreateIndexIfNotExists("idx_teammembers_user_id", "TeamMembers", "UserId")
 	s.CreateIndexIfNotExists("idx_teammembers_delete_at", "TeamMembers", "DeleteAt")
+	s.CreateIndexIfNotExists("idx_teammembers_team_id_create_at", "TeamMembers", "TeamId, DeleteAt, CreateAt")
 }
 
 func (s SqlTeamStore) Save(team *model.Team) StoreChannel {
@@ -317,7 +318,7 @@ func (s SqlTeamStore) GetAllTeamListing() StoreChannel {
 
 		query := "SELECT * FROM Teams WHERE AllowOpenInvite = 1"
 
-		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES || utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
 			query = "SELECT * FROM Teams WHERE AllowOpenInvite = true"
 		}
 
@@ -383,6 +384,7 @@ func (s SqlTeamStore) SaveMember(member *model.TeamMember) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
+		member.PreSave()
 		if result.Err = member.IsValid(); result.Err != nil {
 			storeChannel <- result
 			close(storeChannel)
@@ -491,6 +493,79 @@ func (s SqlTeamStore) GetMembers(teamId string, offset int, limit int) StoreChan
 	return storeChannel
 }
 
+// GetMembersPage returns a page of the team's members sorted by username, by when they joined
+// the team, or by user id if sort is empty. If role is provided, only members with that role
+// are returned.
+func (s SqlTeamStore) GetMembersPage(teamId string, offset int, limit int, sort string, role string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"TeamId": teamId, "Offset": offset, "Limit": limit}
+
+		query := `
+			SELECT
+				TeamMembers.*
+			FROM
+				TeamMembers`
+
+		if sort == model.TEAM_MEMBER_SORT_BY_USERNAME {
+			query += `
+			INNER JOIN
+				Users ON Users.Id = TeamMembers.UserId`
+		}
+
+		query += `
+			WHERE
+				TeamMembers.TeamId = :TeamId
+				AND TeamMembers.DeleteAt = 0`
+
+		if len(role) > 0 {
+			// roles are stored as a space separated list
+			if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+				query += `
+				AND (' ' || TeamMembers.Roles || ' ') LIKE :Role`
+			} else {
+				query += `
+				AND CONCAT(' ', TeamMembers.Roles, ' ') LIKE :Role`
+			}
+			props["Role"] = "% " + role + " %"
+		}
+
+		switch sort {
+		case model.TEAM_MEMBER_SORT_BY_USERNAME:
+			query += `
+			ORDER BY
+				Users.Username ASC`
+		case model.TEAM_MEMBER_SORT_BY_JOINED_AT:
+			query += `
+			ORDER BY
+				TeamMembers.CreateAt ASC, TeamMembers.UserId ASC`
+		default:
+			query += `
+			ORDER BY
+				TeamMembers.UserId ASC`
+		}
+
+		query += `
+			LIMIT :Limit
+			OFFSET :Offset`
+
+		var members []*model.TeamMember
+		if _, err := s.GetReplica().Select(&members, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlTeamStore.GetMembersPage", "store.sql_team.get_members.app_error", nil, "teamId="+teamId+" "+err.Error())
+		} else {
+			result.Data = members
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlTeamStore) GetTotalMemberCount(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -556,6 +631,13 @@ func (s SqlTeamStore) GetMembersByIds(teamId string, userIds []string) StoreChan
 	go func() {
 		result := StoreResult{}
 
+		if len(userIds) == 0 {
+			result.Err = model.NewLocAppError("SqlTeamStore.GetMembersByIds", "store.sql_team.get_members_by_ids.app_error", nil, "teamId="+teamId+" no user ids")
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
 		var members []*model.TeamMember
 		props := make(map[string]interface{})
 		idQuery := ""
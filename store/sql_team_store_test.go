@@ -457,6 +457,42 @@ func TestGetTeamMember(t *testing.T) {
 	}
 }
 
+func TestGetTeamMembersPage(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	u1 := Must(store.User().Save(&model.User{Email: model.NewId(), Username: "b" + model.NewId()})).(*model.User)
+	u2 := Must(store.User().Save(&model.User{Email: model.NewId(), Username: "a" + model.NewId()})).(*model.User)
+	u3 := Must(store.User().Save(&model.User{Email: model.NewId(), Username: "c" + model.NewId()})).(*model.User)
+
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: u1.Id, Roles: "team_user team_admin", CreateAt: 1000}))
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: u2.Id, Roles: "team_user", CreateAt: 3000}))
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: u3.Id, Roles: "team_user", CreateAt: 2000}))
+
+	if members := Must(store.Team().GetMembersPage(teamId, 0, 100, model.TEAM_MEMBER_SORT_BY_USERNAME, "")).([]*model.TeamMember); len(members) != 3 ||
+		members[0].UserId != u2.Id || members[1].UserId != u1.Id || members[2].UserId != u3.Id {
+		t.Fatal("should have sorted the members by username")
+	}
+
+	if members := Must(store.Team().GetMembersPage(teamId, 0, 100, model.TEAM_MEMBER_SORT_BY_JOINED_AT, "")).([]*model.TeamMember); len(members) != 3 ||
+		members[0].UserId != u1.Id || members[1].UserId != u3.Id || members[2].UserId != u2.Id {
+		t.Fatal("should have sorted the members by when they joined")
+	}
+
+	if members := Must(store.Team().GetMembersPage(teamId, 1, 1, model.TEAM_MEMBER_SORT_BY_JOINED_AT, "")).([]*model.TeamMember); len(members) != 1 || members[0].UserId != u3.Id {
+		t.Fatal("should have returned the second page")
+	}
+
+	if members := Must(store.Team().GetMembersPage(teamId, 0, 100, "", "team_admin")).([]*model.TeamMember); len(members) != 1 || members[0].UserId != u1.Id {
+		t.Fatal("should have only returned the team admin")
+	}
+
+	if members := Must(store.Team().GetMembersPage(teamId, 0, 100, "", "team_user")).([]*model.TeamMember); len(members) != 3 {
+		t.Fatal("should have returned every team user")
+	}
+}
+
 func TestGetTeamMembersByIds(t *testing.T) {
 	Setup()
 
@@ -0,0 +1,224 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlThreadMembershipStore struct {
+	*SqlStore
+}
+
+func NewSqlThreadMembershipStore(sqlStore *SqlStore) ThreadMembershipStore {
+	s := &SqlThreadMembershipStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ThreadMembership{}, "ThreadMemberships").SetKeys(false, "PostId", "UserId")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlThreadMembershipStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_threadmemberships_user_id", "ThreadMemberships", "UserId")
+	s.CreateIndexIfNotExists("idx_threadmemberships_channel_id", "ThreadMemberships", "ChannelId")
+}
+
+// Save creates a user's membership in a thread or replaces the one that they already have.
+func (s SqlThreadMembershipStore) Save(membership *model.ThreadMembership) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		membership.PreSave()
+		if result.Err = membership.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		// MySQL doesn't count rows that an update didn't change, so check for an existing membership instead
+		var err error
+		var count int64
+		if count, err = s.GetMaster().SelectInt("SELECT COUNT(*) FROM ThreadMemberships WHERE PostId = :PostId AND UserId = :UserId", map[string]interface{}{"PostId": membership.PostId, "UserId": membership.UserId}); err == nil {
+			if count == 0 {
+				err = s.GetMaster().Insert(membership)
+			} else {
+				_, err = s.GetMaster().Update(membership)
+			}
+		}
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.Save", "store.sql_thread_membership.save.app_error", nil, "post_id="+membership.PostId+", user_id="+membership.UserId+", "+err.Error())
+		} else {
+			result.Data = membership
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadMembershipStore) Get(postId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var membership model.ThreadMembership
+		if err := s.GetMaster().SelectOne(&membership, "SELECT * FROM ThreadMemberships WHERE PostId = :PostId AND UserId = :UserId", map[string]interface{}{"PostId": postId, "UserId": userId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlThreadMembershipStore.Get", "store.sql_thread_membership.get.app_error", nil, "post_id="+postId+", user_id="+userId+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewLocAppError("SqlThreadMembershipStore.Get", "store.sql_thread_membership.get.app_error", nil, "post_id="+postId+", user_id="+userId+", "+err.Error())
+			}
+		} else {
+			result.Data = &membership
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFollowers returns the memberships of everyone following a thread.
+func (s SqlThreadMembershipStore) GetFollowers(postId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var memberships []*model.ThreadMembership
+		if _, err := s.GetMaster().Select(&memberships, "SELECT * FROM ThreadMemberships WHERE PostId = :PostId AND Following = :Following", map[string]interface{}{"PostId": postId, "Following": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.GetFollowers", "store.sql_thread_membership.get_followers.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = memberships
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForUser returns the memberships of the threads in a channel that a user is following, most recently updated first.
+func (s SqlThreadMembershipStore) GetForUser(userId string, channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var memberships []*model.ThreadMembership
+		if _, err := s.GetReplica().Select(&memberships,
+			`SELECT
+				*
+			FROM
+				ThreadMemberships
+			WHERE
+				UserId = :UserId
+				AND ChannelId = :ChannelId
+				AND Following = :Following
+			ORDER BY LastUpdateAt DESC`,
+			map[string]interface{}{"UserId": userId, "ChannelId": channelId, "Following": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.GetForUser", "store.sql_thread_membership.get_for_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = memberships
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// IncrementUnreadReplies counts a new reply to a thread as unread for everyone following it other than the user who made it.
+func (s SqlThreadMembershipStore) IncrementUnreadReplies(postId string, exceptUserId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				ThreadMemberships
+			SET
+				UnreadReplies = UnreadReplies + 1,
+				LastUpdateAt = :LastUpdateAt
+			WHERE
+				PostId = :PostId
+				AND UserId != :UserId
+				AND Following = :Following`,
+			map[string]interface{}{"LastUpdateAt": model.GetMillis(), "PostId": postId, "UserId": exceptUserId, "Following": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.IncrementUnreadReplies", "store.sql_thread_membership.increment_unread_replies.app_error", nil, "post_id="+postId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// MarkRead clears the unread replies of a user's membership in a thread and returns the updated membership.
+func (s SqlThreadMembershipStore) MarkRead(postId string, userId string, viewedAt int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				ThreadMemberships
+			SET
+				LastViewedAt = :LastViewedAt,
+				UnreadReplies = 0,
+				LastUpdateAt = :LastUpdateAt
+			WHERE
+				PostId = :PostId
+				AND UserId = :UserId`,
+			map[string]interface{}{"LastViewedAt": viewedAt, "LastUpdateAt": model.GetMillis(), "PostId": postId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.MarkRead", "store.sql_thread_membership.mark_read.app_error", nil, "post_id="+postId+", user_id="+userId+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlThreadMembershipStore.MarkRead", "store.sql_thread_membership.get.app_error", nil, "post_id="+postId+", user_id="+userId, http.StatusNotFound)
+		} else {
+			result = <-s.Get(postId, userId)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadMembershipStore) DeleteForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ThreadMemberships WHERE PostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadMembershipStore.DeleteForPost", "store.sql_thread_membership.delete_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestThreadMembershipStore(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+	channelId := model.NewId()
+
+	m1 := Must(store.ThreadMembership().Save(&model.ThreadMembership{PostId: postId, ChannelId: channelId, UserId: model.NewId(), Following: true, LastViewedAt: 1000})).(*model.ThreadMembership)
+	m2 := Must(store.ThreadMembership().Save(&model.ThreadMembership{PostId: postId, ChannelId: channelId, UserId: model.NewId(), Following: true, LastViewedAt: 1000})).(*model.ThreadMembership)
+	m3 := Must(store.ThreadMembership().Save(&model.ThreadMembership{PostId: postId, ChannelId: channelId, UserId: model.NewId(), Following: false})).(*model.ThreadMembership)
+
+	if result := <-store.ThreadMembership().Save(&model.ThreadMembership{PostId: postId, UserId: model.NewId()}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid membership")
+	}
+
+	Must(store.ThreadMembership().IncrementUnreadReplies(postId, m1.UserId))
+
+	if membership := Must(store.ThreadMembership().Get(postId, m1.UserId)).(*model.ThreadMembership); membership.UnreadReplies != 0 {
+		t.Fatal("shouldn't have counted a reply by the user themselves")
+	}
+
+	if membership := Must(store.ThreadMembership().Get(postId, m2.UserId)).(*model.ThreadMembership); membership.UnreadReplies != 1 {
+		t.Fatal("should have counted the reply", membership.UnreadReplies)
+	}
+
+	if membership := Must(store.ThreadMembership().Get(postId, m3.UserId)).(*model.ThreadMembership); membership.UnreadReplies != 0 {
+		t.Fatal("shouldn't have counted the reply for someone not following the thread")
+	}
+
+	if followers := Must(store.ThreadMembership().GetFollowers(postId)).([]*model.ThreadMembership); len(followers) != 2 {
+		t.Fatal("should have returned the followers", len(followers))
+	}
+
+	if memberships := Must(store.ThreadMembership().GetForUser(m2.UserId, channelId)).([]*model.ThreadMembership); len(memberships) != 1 || memberships[0].PostId != postId {
+		t.Fatal("should have returned the followed thread")
+	}
+
+	if memberships := Must(store.ThreadMembership().GetForUser(m3.UserId, channelId)).([]*model.ThreadMembership); len(memberships) != 0 {
+		t.Fatal("shouldn't have returned a thread that isn't followed")
+	}
+
+	if membership := Must(store.ThreadMembership().MarkRead(postId, m2.UserId, 2000)).(*model.ThreadMembership); membership.UnreadReplies != 0 || membership.LastViewedAt != 2000 {
+		t.Fatal("should have marked the thread as read")
+	}
+
+	if result := <-store.ThreadMembership().MarkRead(postId, model.NewId(), 2000); result.Err == nil || result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("shouldn't have marked a thread read for a user without a membership")
+	}
+
+	m1.Following = false
+	Must(store.ThreadMembership().Save(m1))
+	if followers := Must(store.ThreadMembership().GetFollowers(postId)).([]*model.ThreadMembership); len(followers) != 1 {
+		t.Fatal("should have stopped following the thread")
+	}
+
+	Must(store.ThreadMembership().DeleteForPost(postId))
+	if result := <-store.ThreadMembership().Get(postId, m2.UserId); result.Err == nil {
+		t.Fatal("should have deleted the memberships")
+	}
+}
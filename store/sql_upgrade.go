@@ -34,6 +34,9 @@ const (
 
 func UpgradeDatabase(sqlStore *SqlStore) {
 
+	// Finish adding any columns that were being added when the server was last stopped
+	sqlStore.resumeColumnBackfills()
+
 	UpgradeDatabaseToVersion31(sqlStore)
 	UpgradeDatabaseToVersion32(sqlStore)
 	UpgradeDatabaseToVersion33(sqlStore)
@@ -238,4 +241,48 @@ func UpgradeDatabaseToVersion37(sqlStore *SqlStore) {
 	// Add EditAt column to Posts
 	sqlStore.CreateColumnIfNotExists("Posts", "EditAt", " bigint", " bigint", "0")
 	// }
+
+	// Add Duration column to FileInfo for audio and video previews
+	sqlStore.CreateColumnIfNotExists("FileInfo", "Duration", " bigint", " bigint", "0")
+
+	// Add Waveform column to FileInfo for audio previews
+	sqlStore.CreateColumnIfNotExists("FileInfo", "Waveform", "varchar(512)", "varchar(512)", "")
+
+	// Add IsAnimated column to FileInfo so that clients know which gifs are animated
+	sqlStore.CreateColumnIfNotExists("FileInfo", "IsAnimated", "tinyint(1)", "boolean", "0")
+
+	// Add WebPPreviewPath column to FileInfo for clients that support WebP images
+	sqlStore.CreateColumnIfNotExists("FileInfo", "WebPPreviewPath", "varchar(512)", "varchar(512)", "")
+
+	// Add CreateAt column to TeamMembers so that members can be sorted by when they joined
+	sqlStore.CreateColumnIfNotExists("TeamMembers", "CreateAt", "bigint", "bigint", "0")
+
+	// Add FileCount and HasImage columns to Posts so that they don't need to be joined against FileInfo. They're
+	// filled in for existing posts in the background since there may be too many to update while upgrading.
+	sqlStore.CreateColumnIfNotExists("Posts", "HasImage", "tinyint", "boolean", "0")
+	if sqlStore.CreateColumnIfNotExists("Posts", "FileCount", "bigint", "bigint", "0") {
+		sqlStore.queueBackgroundMigration(BACKGROUND_MIGRATION_POST_FILE_COUNTS)
+	}
+
+	// Add custom status columns to Users so that users can show a message like "Out of office" until it expires
+	sqlStore.CreateColumnIfNotExists("Users", "CustomStatusEmoji", "varchar(64)", "varchar(64)", "")
+	sqlStore.CreateColumnIfNotExists("Users", "CustomStatusText", "varchar(100)", "varchar(100)", "")
+	sqlStore.CreateColumnIfNotExists("Users", "CustomStatusExpiry", "bigint", "bigint", "0")
+
+	// Add Checksum column to FileInfo so that downloaded files can be checked for corruption. Files uploaded before
+	// this don't have one since computing them would require reading every file.
+	sqlStore.CreateColumnIfNotExists("FileInfo", "Checksum", "varchar(64)", "varchar(64)", "")
+
+	// Add Anonymize column to Compliances for reports that replace users with their pseudonyms
+	sqlStore.CreateColumnIfNotExists("Compliances", "Anonymize", "tinyint(1)", "boolean", "0")
+
+	// Add LastRunAt column to JobSchedules so that runs can be saved without overwriting changes to the schedule
+	sqlStore.CreateColumnIfNotExists("JobSchedules", "LastRunAt", "bigint", "bigint", "0")
+
+	// Add ExpiresAt column to OAuthRevokedTokens so that tokens can be removed once they'd have expired. Tokens revoked
+	// before this are kept for as long as an OAuth session lasts.
+	if sqlStore.CreateColumnIfNotExists("OAuthRevokedTokens", "ExpiresAt", "bigint", "bigint", "0") {
+		sqlStore.GetMaster().Exec("UPDATE OAuthRevokedTokens SET ExpiresAt = RevokedAt + :SessionLength WHERE ExpiresAt = 0",
+			map[string]interface{}{"SessionLength": int64(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays) * 24 * 60 * 60 * 1000})
+	}
 }
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestUserAccessTokenStore(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	t1 := Must(store.UserAccessToken().Save(&model.UserAccessToken{UserId: userId, Description: "first", Scopes: model.StringArray{model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS}})).(*model.UserAccessToken)
+	t2 := Must(store.UserAccessToken().Save(&model.UserAccessToken{UserId: userId, Description: "second", RateLimit: 10})).(*model.UserAccessToken)
+	Must(store.UserAccessToken().Save(&model.UserAccessToken{UserId: model.NewId()}))
+
+	if result := <-store.UserAccessToken().Save(&model.UserAccessToken{UserId: userId, Scopes: model.StringArray{"junk"}}); result.Err == nil {
+		t.Fatal("shouldn't have saved a token with an invalid scope")
+	}
+
+	if token := Must(store.UserAccessToken().GetByToken(t1.Token)).(*model.UserAccessToken); token.Id != t1.Id || len(token.Scopes) != 1 || token.Scopes[0] != model.USER_ACCESS_TOKEN_SCOPE_READ_POSTS {
+		t.Fatal("should have returned the token with its scopes")
+	}
+
+	if token := Must(store.UserAccessToken().Get(t2.Id)).(*model.UserAccessToken); token.Token != t2.Token || token.RateLimit != 10 {
+		t.Fatal("should have returned the token with its rate limit")
+	}
+
+	if tokens := Must(store.UserAccessToken().GetByUser(userId)).([]*model.UserAccessToken); len(tokens) != 2 {
+		t.Fatal("should have returned the user's tokens")
+	}
+
+	Must(store.UserAccessToken().UpdateLastUsedAt(t1.Id, 2000))
+	Must(store.UserAccessToken().UpdateLastUsedAt(t1.Id, 1000))
+
+	if token := Must(store.UserAccessToken().Get(t1.Id)).(*model.UserAccessToken); token.LastUsedAt != 2000 {
+		t.Fatal("shouldn't have moved the last used time backwards", token.LastUsedAt)
+	}
+
+	Must(store.UserAccessToken().Delete(t1.Id))
+
+	if result := <-store.UserAccessToken().GetByToken(t1.Token); result.Err == nil || result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have deleted the token")
+	}
+
+	Must(store.UserAccessToken().PermanentDeleteByUser(userId))
+
+	if tokens := Must(store.UserAccessToken().GetByUser(userId)).([]*model.UserAccessToken); len(tokens) != 0 {
+		t.Fatal("should have deleted the user's tokens")
+	}
+}
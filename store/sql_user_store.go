@@ -27,6 +27,7 @@ const (
 	USER_SEARCH_OPTION_NAMES_ONLY_NO_FULL_NAME = "names_only_no_full_name"
 	USER_SEARCH_OPTION_ALL_NO_FULL_NAME        = "all_no_full_name"
 	USER_SEARCH_OPTION_ALLOW_INACTIVE          = "allow_inactive"
+	USER_SEARCH_OPTION_AUTOCOMPLETE            = "autocomplete"
 	USER_SEARCH_TYPE_NAMES_NO_FULL_NAME        = "Username, Nickname"
 	USER_SEARCH_TYPE_NAMES                     = "Username, FirstName, LastName, Nickname"
 	USER_SEARCH_TYPE_ALL_NO_FULL_NAME          = "Username, Nickname, Email"
@@ -69,6 +70,8 @@ func NewSqlUserStore(sqlStore *SqlStore) UserStore {
 		table.ColMap("Locale").SetMaxSize(5)
 		table.ColMap("MfaSecret").SetMaxSize(128)
 		table.ColMap("Position").SetMaxSize(64)
+		table.ColMap("CustomStatusEmoji").SetMaxSize(model.CUSTOM_STATUS_EMOJI_MAX_LENGTH)
+		table.ColMap("CustomStatusText").SetMaxSize(model.CUSTOM_STATUS_TEXT_MAX_RUNES)
 	}
 
 	return us
@@ -79,11 +82,21 @@ func (us SqlUserStore) CreateIndexesIfNotExists() {
 	us.CreateIndexIfNotExists("idx_users_update_at", "Users", "UpdateAt")
 	us.CreateIndexIfNotExists("idx_users_create_at", "Users", "CreateAt")
 	us.CreateIndexIfNotExists("idx_users_delete_at", "Users", "DeleteAt")
+	us.CreateIndexIfNotExists("idx_users_custom_status_expiry", "Users", "CustomStatusExpiry")
 
 	us.CreateFullTextIndexIfNotExists("idx_users_all_txt", "Users", USER_SEARCH_TYPE_ALL)
 	us.CreateFullTextIndexIfNotExists("idx_users_all_no_full_name_txt", "Users", USER_SEARCH_TYPE_ALL_NO_FULL_NAME)
 	us.CreateFullTextIndexIfNotExists("idx_users_names_txt", "Users", USER_SEARCH_TYPE_NAMES)
 	us.CreateFullTextIndexIfNotExists("idx_users_names_no_full_name_txt", "Users", USER_SEARCH_TYPE_NAMES_NO_FULL_NAME)
+
+	// Used for autocomplete. MySQL already has a usable index on Username since it's unique.
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		us.CreatePrefixIndexIfNotExists("idx_users_username_prefix", "Users", "Username")
+	}
+	us.CreatePrefixIndexIfNotExists("idx_users_nickname_prefix", "Users", "Nickname")
+	us.CreatePrefixIndexIfNotExists("idx_users_first_name_prefix", "Users", "FirstName")
+	us.CreatePrefixIndexIfNotExists("idx_users_last_name_prefix", "Users", "LastName")
+	us.CreatePrefixIndexIfNotExists("idx_users_full_name_prefix", "Users", "FirstName, LastName")
 }
 
 func (us SqlUserStore) Save(user *model.User) StoreChannel {
@@ -157,6 +170,9 @@ func (us SqlUserStore) Update(user *model.User, trustedUpdateData bool) StoreCha
 			user.FailedAttempts = oldUser.FailedAttempts
 			user.MfaSecret = oldUser.MfaSecret
 			user.MfaActive = oldUser.MfaActive
+			user.CustomStatusEmoji = oldUser.CustomStatusEmoji
+			user.CustomStatusText = oldUser.CustomStatusText
+			user.CustomStatusExpiry = oldUser.CustomStatusExpiry
 
 			if !trustedUpdateData {
 				user.Roles = oldUser.Roles
@@ -224,6 +240,107 @@ func (us SqlUserStore) UpdateLastPictureUpdate(userId string) StoreChannel {
 	return storeChannel
 }
 
+// UpdateCustomStatus sets a user's custom status, or clears it if status is nil.
+func (us SqlUserStore) UpdateCustomStatus(userId string, status *model.CustomStatus) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		user := &model.User{}
+		user.SetCustomStatus(status)
+
+		if _, err := us.GetMaster().Exec(
+			`UPDATE
+				Users
+			SET
+				CustomStatusEmoji = :Emoji,
+				CustomStatusText = :Text,
+				CustomStatusExpiry = :Expiry,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :UserId`,
+			map[string]interface{}{"Emoji": user.CustomStatusEmoji, "Text": user.CustomStatusText, "Expiry": user.CustomStatusExpiry, "UpdateAt": model.GetMillis(), "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.UpdateCustomStatus", "store.sql_user.update_custom_status.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = userId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ClearExpiredCustomStatus clears a user's custom status if it has expired by the given time. The result is true if it
+// was cleared and false if the user doesn't have a custom status that has expired, such as because they just set a new
+// one.
+func (us SqlUserStore) ClearExpiredCustomStatus(userId string, now int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := us.GetMaster().Exec(
+			`UPDATE
+				Users
+			SET
+				CustomStatusEmoji = '',
+				CustomStatusText = '',
+				CustomStatusExpiry = 0,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :UserId
+				AND CustomStatusExpiry > 0
+				AND CustomStatusExpiry <= :Now`,
+			map[string]interface{}{"UpdateAt": model.GetMillis(), "UserId": userId, "Now": now}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.ClearExpiredCustomStatus", "store.sql_user.clear_expired_custom_status.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else if count, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.ClearExpiredCustomStatus", "store.sql_user.clear_expired_custom_status.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = count == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetExpiredCustomStatuses returns the ids of up to limit users whose custom statuses expired before the given time.
+// It reads from the master so that statuses which were just cleared aren't returned again.
+func (us SqlUserStore) GetExpiredCustomStatuses(before int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var userIds []string
+		if _, err := us.GetMaster().Select(&userIds,
+			`SELECT
+				Id
+			FROM
+				Users
+			WHERE
+				CustomStatusExpiry > 0
+				AND CustomStatusExpiry <= :Before
+			ORDER BY
+				CustomStatusExpiry
+			LIMIT :Limit`, map[string]interface{}{"Before": before, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.GetExpiredCustomStatuses", "store.sql_user.get_expired_custom_statuses.app_error", nil, err.Error())
+		} else {
+			result.Data = userIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (us SqlUserStore) UpdateUpdateAt(userId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1385,6 +1502,9 @@ func (us SqlUserStore) performSearch(searchQuery string, term string, options ma
 
 	if term == "" {
 		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
+	} else if ok := options[USER_SEARCH_OPTION_AUTOCOMPLETE]; ok {
+		term = generateUserPrefixSearchTerm(originalTerm)
+		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", generateUserPrefixSearchClause(searchType), 1)
 	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 		if postgresUseOriginalTerm {
 			term = originalTerm
@@ -1418,6 +1538,14 @@ func (us SqlUserStore) performSearch(searchQuery string, term string, options ma
 
 		searchClause := fmt.Sprintf("AND MATCH(%s) AGAINST (:Term IN BOOLEAN MODE)", searchType)
 		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+		if strings.Contains(originalTerm, "@") && strings.Contains(originalTerm, ".") {
+			// match the whole email address
+			term = originalTerm
+		}
+
+		searchClause := generateSqliteSearchClause(strings.Split(searchType, ", "), strings.Fields(term), false, parameters)
+		searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
 	}
 
 	var users []*model.User
@@ -1438,3 +1566,44 @@ func (us SqlUserStore) performSearch(searchQuery string, term string, options ma
 
 	return result
 }
+
+// generateUserPrefixSearchTerm turns an autocomplete term into a LIKE pattern that matches anything
+// starting with it.
+func generateUserPrefixSearchTerm(term string) string {
+	term = strings.TrimSpace(strings.TrimPrefix(term, "@"))
+
+	// escape any wildcards typed by the user
+	term = escapeLikeTerm(term)
+
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		// Postgres compares case sensitively, so this matches the lowercase prefix indexes
+		term = strings.ToLower(term)
+	}
+
+	return term + "%"
+}
+
+// generateUserPrefixSearchClause matches the start of each of the searched columns along with
+// the full name if it's included. Each of these is backed by a prefix index.
+func generateUserPrefixSearchClause(searchType string) string {
+	columns := strings.Split(searchType, ", ")
+	if strings.Contains(searchType, "FirstName") && strings.Contains(searchType, "LastName") {
+		columns = append(columns, "FirstName, LastName")
+	}
+
+	clauses := make([]string, len(columns))
+	for i, column := range columns {
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+			clauses[i] = "lower(" + convertMySQLFullTextColumnsToPostgres(column) + ") LIKE :Term"
+		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_SQLITE {
+			// SQLite doesn't have a default escape character
+			clauses[i] = convertMySQLFullTextColumnsToPostgres(column) + " LIKE :Term ESCAPE '\\'"
+		} else if strings.Contains(column, ", ") {
+			clauses[i] = "CONCAT(" + strings.Replace(column, ", ", ", ' ', ", -1) + ") LIKE :Term"
+		} else {
+			clauses[i] = column + " LIKE :Term"
+		}
+	}
+
+	return "AND (" + strings.Join(clauses, " OR ") + ")"
+}
@@ -129,6 +129,69 @@ func TestUserStoreUpdateUpdateAt(t *testing.T) {
 
 }
 
+func TestUserStoreUpdateCustomStatus(t *testing.T) {
+	Setup()
+
+	u1 := &model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(u1))
+
+	u2 := &model.User{}
+	u2.Email = model.NewId()
+	Must(store.User().Save(u2))
+
+	expiry := model.GetMillis() - 1000
+	Must(store.User().UpdateCustomStatus(u1.Id, &model.CustomStatus{Emoji: "palm_tree", Text: "On vacation", ExpiresAt: expiry}))
+	Must(store.User().UpdateCustomStatus(u2.Id, &model.CustomStatus{Text: "In a meeting"}))
+
+	user := Must(store.User().Get(u1.Id)).(*model.User)
+	if user.CustomStatusEmoji != "palm_tree" || user.CustomStatusText != "On vacation" || user.CustomStatusExpiry != expiry {
+		t.Fatal("should have saved the custom status")
+	}
+
+	user.CustomStatusText = "changed"
+	Must(store.User().Update(user, false))
+	if user = Must(store.User().Get(u1.Id)).(*model.User); user.CustomStatusText != "On vacation" {
+		t.Fatal("shouldn't have changed the custom status when updating the user")
+	}
+
+	found := false
+	for _, userId := range Must(store.User().GetExpiredCustomStatuses(model.GetMillis(), 1000)).([]string) {
+		if userId == u2.Id {
+			t.Fatal("shouldn't have returned a custom status without an expiry")
+		} else if userId == u1.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the expired custom status")
+	}
+
+	if cleared := Must(store.User().ClearExpiredCustomStatus(u2.Id, model.GetMillis())).(bool); cleared {
+		t.Fatal("shouldn't have cleared a custom status without an expiry")
+	}
+
+	if cleared := Must(store.User().ClearExpiredCustomStatus(u1.Id, expiry-1)).(bool); cleared {
+		t.Fatal("shouldn't have cleared a custom status that hasn't expired yet")
+	}
+
+	if cleared := Must(store.User().ClearExpiredCustomStatus(u1.Id, model.GetMillis())).(bool); !cleared {
+		t.Fatal("should have cleared the expired custom status")
+	} else if user = Must(store.User().Get(u1.Id)).(*model.User); user.CustomStatusText != "" || user.CustomStatusExpiry != 0 {
+		t.Fatal("should have cleared the custom status")
+	}
+
+	if cleared := Must(store.User().ClearExpiredCustomStatus(u1.Id, model.GetMillis())).(bool); cleared {
+		t.Fatal("shouldn't have cleared the custom status again")
+	}
+
+	Must(store.User().UpdateCustomStatus(u2.Id, nil))
+	if user = Must(store.User().Get(u2.Id)).(*model.User); user.CustomStatusText != "" || user.CustomStatusExpiry != 0 {
+		t.Fatal("should have cleared the custom status")
+	}
+}
+
 func TestUserStoreUpdateFailedPasswordAttempts(t *testing.T) {
 	Setup()
 
@@ -1477,3 +1540,85 @@ func TestUserStoreSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestUserStoreAutocomplete(t *testing.T) {
+	Setup()
+
+	u1 := &model.User{}
+	u1.Username = "autocomplete" + model.NewId()
+	u1.FirstName = "Percival"
+	u1.LastName = "Quimby" + model.NewId()
+	u1.Nickname = "Percy"
+	u1.Email = model.NewId()
+	Must(store.User().Save(u1))
+
+	u2 := &model.User{}
+	u2.Username = "autocomplete_" + model.NewId()
+	u2.Email = model.NewId()
+	Must(store.User().Save(u2))
+
+	tid := model.NewId()
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: tid, UserId: u1.Id}))
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: tid, UserId: u2.Id}))
+
+	c1 := Must(store.Channel().Save(&model.Channel{
+		TeamId:      tid,
+		DisplayName: "NameName",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	searchOptions := map[string]bool{
+		USER_SEARCH_OPTION_AUTOCOMPLETE: true,
+		USER_SEARCH_OPTION_NAMES_ONLY:   true,
+	}
+
+	containsUser := func(profiles []*model.User, userId string) bool {
+		for _, profile := range profiles {
+			if profile.Id == userId {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if profiles := Must(store.User().Search(tid, "@AUTOcomplete", searchOptions)).([]*model.User); !containsUser(profiles, u1.Id) || !containsUser(profiles, u2.Id) {
+		t.Fatal("should've matched the start of the username ignoring case and the leading @")
+	}
+
+	if profiles := Must(store.User().Search(tid, "autocomplete_", searchOptions)).([]*model.User); containsUser(profiles, u1.Id) || !containsUser(profiles, u2.Id) {
+		t.Fatal("should've treated the underscore as a literal character")
+	}
+
+	if profiles := Must(store.User().Search(tid, "complete", searchOptions)).([]*model.User); len(profiles) != 0 {
+		t.Fatal("should only match prefixes")
+	}
+
+	if profiles := Must(store.User().Search(tid, "perc", searchOptions)).([]*model.User); !containsUser(profiles, u1.Id) {
+		t.Fatal("should've matched the nickname and first name")
+	}
+
+	if profiles := Must(store.User().Search(tid, "percival "+u1.LastName[:8], searchOptions)).([]*model.User); !containsUser(profiles, u1.Id) {
+		t.Fatal("should've matched the full name")
+	}
+
+	searchOptions[USER_SEARCH_OPTION_NAMES_ONLY] = false
+	searchOptions[USER_SEARCH_OPTION_NAMES_ONLY_NO_FULL_NAME] = true
+
+	if profiles := Must(store.User().Search(tid, "percival", searchOptions)).([]*model.User); containsUser(profiles, u1.Id) {
+		t.Fatal("shouldn't have matched the first name when full names are hidden")
+	}
+
+	searchOptions[USER_SEARCH_OPTION_NAMES_ONLY_NO_FULL_NAME] = false
+	searchOptions[USER_SEARCH_OPTION_NAMES_ONLY] = true
+
+	if profiles := Must(store.User().SearchInChannel(c1.Id, "autocomplete", searchOptions)).([]*model.User); len(profiles) != 1 || profiles[0].Id != u1.Id {
+		t.Fatal("should've only matched the channel member")
+	}
+
+	if profiles := Must(store.User().SearchNotInChannel(tid, c1.Id, "autocomplete", searchOptions)).([]*model.User); len(profiles) != 1 || profiles[0].Id != u2.Id {
+		t.Fatal("should've only matched the team member outside of the channel")
+	}
+}
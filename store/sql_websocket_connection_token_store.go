@@ -0,0 +1,101 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlWebSocketConnectionTokenStore struct {
+	*SqlStore
+}
+
+func NewSqlWebSocketConnectionTokenStore(sqlStore *SqlStore) WebSocketConnectionTokenStore {
+	s := &SqlWebSocketConnectionTokenStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.WebSocketConnectionToken{}, "WebSocketConnectionTokens").SetKeys(false, "Token")
+		table.ColMap("Token").SetMaxSize(26)
+		table.ColMap("SessionId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlWebSocketConnectionTokenStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_websocket_connection_tokens_expire_at", "WebSocketConnectionTokens", "ExpireAt")
+}
+
+func (s SqlWebSocketConnectionTokenStore) Save(token *model.WebSocketConnectionToken) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		token.PreSave()
+		if result.Err = token.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(token); err != nil {
+			result.Err = model.NewLocAppError("SqlWebSocketConnectionTokenStore.Save", "store.sql_websocket_connection_token.save.app_error", nil, err.Error())
+		} else {
+			result.Data = token
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Consume looks up a token and deletes it so that it can't be used again. If two requests try to use the same token
+// at once, only one of them gets it.
+func (s SqlWebSocketConnectionTokenStore) Consume(token string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var wsToken model.WebSocketConnectionToken
+		if err := s.GetMaster().SelectOne(&wsToken, "SELECT * FROM WebSocketConnectionTokens WHERE Token = :Token", map[string]interface{}{"Token": token}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebSocketConnectionTokenStore.Consume", "store.sql_websocket_connection_token.consume.missing.app_error", nil, err.Error())
+			result.Err.StatusCode = http.StatusUnauthorized
+		} else if sqlResult, err := s.GetMaster().Exec("DELETE FROM WebSocketConnectionTokens WHERE Token = :Token", map[string]interface{}{"Token": token}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebSocketConnectionTokenStore.Consume", "store.sql_websocket_connection_token.consume.app_error", nil, err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewLocAppError("SqlWebSocketConnectionTokenStore.Consume", "store.sql_websocket_connection_token.consume.missing.app_error", nil, "")
+			result.Err.StatusCode = http.StatusUnauthorized
+		} else {
+			result.Data = &wsToken
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebSocketConnectionTokenStore) DeleteExpired(before int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM WebSocketConnectionTokens WHERE ExpireAt < :Before", map[string]interface{}{"Before": before}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebSocketConnectionTokenStore.DeleteExpired", "store.sql_websocket_connection_token.delete_expired.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestWebSocketConnectionTokenStoreConsume(t *testing.T) {
+	Setup()
+
+	token := Must(store.WebSocketConnectionToken().Save(&model.WebSocketConnectionToken{SessionId: model.NewId()})).(*model.WebSocketConnectionToken)
+
+	if result := <-store.WebSocketConnectionToken().Consume(token.Token); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if consumed := result.Data.(*model.WebSocketConnectionToken); consumed.SessionId != token.SessionId {
+		t.Fatal("should have returned the token's session")
+	}
+
+	if result := <-store.WebSocketConnectionToken().Consume(token.Token); result.Err == nil {
+		t.Fatal("shouldn't have been able to use a token twice")
+	}
+
+	if result := <-store.WebSocketConnectionToken().Consume(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have been able to use a token that doesn't exist")
+	}
+}
+
+func TestWebSocketConnectionTokenStoreDeleteExpired(t *testing.T) {
+	Setup()
+
+	token := Must(store.WebSocketConnectionToken().Save(&model.WebSocketConnectionToken{SessionId: model.NewId()})).(*model.WebSocketConnectionToken)
+
+	Must(store.WebSocketConnectionToken().DeleteExpired(token.ExpireAt))
+	if result := <-store.WebSocketConnectionToken().Consume(token.Token); result.Err != nil {
+		t.Fatal("shouldn't have deleted a token that hasn't expired", result.Err)
+	}
+
+	token = Must(store.WebSocketConnectionToken().Save(&model.WebSocketConnectionToken{SessionId: model.NewId()})).(*model.WebSocketConnectionToken)
+
+	Must(store.WebSocketConnectionToken().DeleteExpired(token.ExpireAt + 1))
+	if result := <-store.WebSocketConnectionToken().Consume(token.Token); result.Err == nil {
+		t.Fatal("should have deleted an expired token")
+	}
+}
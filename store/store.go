@@ -4,6 +4,7 @@
 package store
 
 import (
+	"database/sql"
 	"time"
 
 	l4g "github.com/alecthomas/log4go"
@@ -47,11 +48,36 @@ type Store interface {
 	Status() StatusStore
 	FileInfo() FileInfoStore
 	Reaction() ReactionStore
+	ClusterDiscovery() ClusterDiscoveryStore
+	Job() JobStore
+	Analytics() AnalyticsStore
+	RetentionPolicy() RetentionPolicyStore
+	LoginAttempt() LoginAttemptStore
+	Invitation() InvitationStore
+	TeamInviteLink() TeamInviteLinkStore
+	TeamDefaultChannel() TeamDefaultChannelStore
+	CertificateCache() CertificateCacheStore
+	Lease() LeaseStore
+	WebSocketConnectionToken() WebSocketConnectionTokenStore
+	LegalHold() LegalHoldStore
+	FileAccess() FileAccessStore
+	FileAcl() FileAclStore
+	ThreadMembership() ThreadMembershipStore
+	BackgroundMigration() BackgroundMigrationStore
+	ProfileImage() ProfileImageStore
+	PermalinkPreview() PermalinkPreviewStore
+	UserAccessToken() UserAccessTokenStore
+	Pseudonym() PseudonymStore
+	ChannelEmailAddress() ChannelEmailAddressStore
+	PostTranslation() PostTranslationStore
+	Role() RoleStore
 	MarkSystemRanUnitTests()
 	Close()
 	DropAllTables()
 	TotalMasterDbConnections() int
 	TotalReadDbConnections() int
+	ConnectionPoolStats() map[string]sql.DBStats
+	CheckHealth() *model.DatabaseHealth
 }
 
 type TeamStore interface {
@@ -71,6 +97,7 @@ type TeamStore interface {
 	UpdateMember(member *model.TeamMember) StoreChannel
 	GetMember(teamId string, userId string) StoreChannel
 	GetMembers(teamId string, offset int, limit int) StoreChannel
+	GetMembersPage(teamId string, offset int, limit int, sort string, role string) StoreChannel
 	GetMembersByIds(teamId string, userIds []string) StoreChannel
 	GetTotalMemberCount(teamId string) StoreChannel
 	GetActiveMemberCount(teamId string) StoreChannel
@@ -104,6 +131,7 @@ type ChannelStore interface {
 	GetAll(teamId string) StoreChannel
 	GetForPost(postId string) StoreChannel
 	SaveMember(member *model.ChannelMember) StoreChannel
+	SaveMembers(members []*model.ChannelMember) StoreChannel
 	UpdateMember(member *model.ChannelMember) StoreChannel
 	GetMembers(channelId string) StoreChannel
 	GetMember(channelId string, userId string) StoreChannel
@@ -114,12 +142,15 @@ type ChannelStore interface {
 	InvalidateMemberCount(channelId string)
 	GetMemberCountFromCache(channelId string) int64
 	GetMemberCount(channelId string, allowFromCache bool) StoreChannel
+	GetMemberCounts(channelIds []string, allowFromCache bool) StoreChannel
 	RemoveMember(channelId string, userId string) StoreChannel
 	PermanentDeleteMembersByUser(userId string) StoreChannel
 	PermanentDeleteMembersByChannel(channelId string) StoreChannel
 	UpdateLastViewedAt(channelIds []string, userId string) StoreChannel
 	SetLastViewedAt(channelId string, userId string, newLastViewedAt int64) StoreChannel
 	IncrementMentionCount(channelId string, userId string) StoreChannel
+	RepairMessageCounts(afterId string, limit int) StoreChannel
+	RepairMentionCount(member *model.ChannelMember) StoreChannel
 	AnalyticsTypeCount(teamId string, channelType string) StoreChannel
 	ExtraUpdateByUser(userId string, time int64) StoreChannel
 	GetMembersForUser(teamId string, userId string) StoreChannel
@@ -130,16 +161,18 @@ type ChannelStore interface {
 
 type PostStore interface {
 	Save(post *model.Post) StoreChannel
+	SaveMultiple(posts []*model.Post) StoreChannel
 	Update(newPost *model.Post, oldPost *model.Post) StoreChannel
 	Get(id string) StoreChannel
 	GetSingle(id string) StoreChannel
 	Delete(postId string, time int64) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
 	PermanentDeleteByChannel(channelId string) StoreChannel
-	GetPosts(channelId string, offset int, limit int, allowFromCache bool) StoreChannel
+	PermanentDeleteBatch(postIds []string) StoreChannel
+	GetPosts(channelId string, limit int, allowFromCache bool) StoreChannel
 	GetFlaggedPosts(userId string, offset int, limit int) StoreChannel
-	GetPostsBefore(channelId string, postId string, numPosts int, offset int) StoreChannel
-	GetPostsAfter(channelId string, postId string, numPosts int, offset int) StoreChannel
+	GetPostsBefore(channelId string, postId string, numPosts int) StoreChannel
+	GetPostsAfter(channelId string, postId string, numPosts int) StoreChannel
 	GetPostsSince(channelId string, time int64, allowFromCache bool) StoreChannel
 	GetEtag(channelId string, allowFromCache bool) StoreChannel
 	Search(teamId string, userId string, params *model.SearchParams) StoreChannel
@@ -147,12 +180,17 @@ type PostStore interface {
 	AnalyticsPostCountsByDay(teamId string) StoreChannel
 	AnalyticsPostCount(teamId string, mustHaveFile bool, mustHaveHashtag bool) StoreChannel
 	InvalidateLastPostTimeCache(channelId string)
+	GetPostsBatchForIndexing(startTime int64, startPostId string, endTime int64, teamId string, limit int) StoreChannel
+	GetPostsBatchForExport(channelId string, startTime int64, startPostId string, limit int) StoreChannel
 }
 
 type UserStore interface {
 	Save(user *model.User) StoreChannel
 	Update(user *model.User, allowRoleUpdate bool) StoreChannel
 	UpdateLastPictureUpdate(userId string) StoreChannel
+	UpdateCustomStatus(userId string, status *model.CustomStatus) StoreChannel
+	ClearExpiredCustomStatus(userId string, now int64) StoreChannel
+	GetExpiredCustomStatuses(before int64, limit int) StoreChannel
 	UpdateUpdateAt(userId string) StoreChannel
 	UpdatePassword(userId, newPassword string) StoreChannel
 	UpdateAuthData(userId string, service string, authData *string, email string, resetMfa bool) StoreChannel
@@ -201,6 +239,7 @@ type SessionStore interface {
 	RemoveAllSessions() StoreChannel
 	PermanentDeleteSessionsByUser(teamId string) StoreChannel
 	UpdateLastActivityAt(sessionId string, time int64) StoreChannel
+	UpdateLastActivityAtBatch(activity map[string]int64) StoreChannel
 	UpdateRoles(userId string, roles string) StoreChannel
 	UpdateDeviceId(id string, deviceId string, expiresAt int64) StoreChannel
 	AnalyticsSessionCount() StoreChannel
@@ -236,9 +275,14 @@ type OAuthStore interface {
 	UpdateAccessData(accessData *model.AccessData) StoreChannel
 	GetAccessData(token string) StoreChannel
 	GetAccessDataByUserForApp(userId, clientId string) StoreChannel
+	GetAccessDataForApp(clientId string) StoreChannel
+	GetAccessDataByUser(userId string) StoreChannel
 	GetAccessDataByRefreshToken(token string) StoreChannel
 	GetPreviousAccessData(userId, clientId string) StoreChannel
 	RemoveAccessData(token string) StoreChannel
+	SaveRevokedToken(revoked *model.OAuthRevokedToken) StoreChannel
+	GetRevokedToken(token string) StoreChannel
+	DeleteExpiredRevokedTokens(before int64) StoreChannel
 }
 
 type SystemStore interface {
@@ -324,11 +368,23 @@ type StatusStore interface {
 
 type FileInfoStore interface {
 	Save(info *model.FileInfo) StoreChannel
+	Update(info *model.FileInfo) StoreChannel
 	Get(id string) StoreChannel
 	GetByPath(path string) StoreChannel
-	GetForPost(postId string) StoreChannel
+	CountByPath(path string, createAt int64) StoreChannel
+	GetForPost(postId string, allowFromCache bool) StoreChannel
+	InvalidateFileInfosForPostCache(postId string)
 	AttachToPost(fileId string, postId string) StoreChannel
 	DeleteForPost(postId string) StoreChannel
+	PermanentDeleteBatch(fileIds []string) StoreChannel
+	GetDeleted(deletedAfter int64, offset int, limit int) StoreChannel
+	GetDeletedBefore(before int64, limit int) StoreChannel
+	Restore(fileIds []string, deletedAfter int64) StoreChannel
+	GetBatchForMigration(lastCreateAt int64, lastId string, limit int) StoreChannel
+	GetBatchForIndexing(startTime int64, startId string, endTime int64, teamId string, limit int) StoreChannel
+	UpdatePaths(infos []*model.FileInfo) StoreChannel
+	UpdatePreview(info *model.FileInfo) StoreChannel
+	UpdateWaveform(info *model.FileInfo) StoreChannel
 }
 
 type ReactionStore interface {
@@ -337,3 +393,194 @@ type ReactionStore interface {
 	GetForPost(postId string) StoreChannel
 	DeleteAllWithEmojiName(emojiName string) StoreChannel
 }
+
+type ClusterDiscoveryStore interface {
+	Save(discovery *model.ClusterDiscovery) StoreChannel
+	Delete(discovery *model.ClusterDiscovery) StoreChannel
+	Exists(discovery *model.ClusterDiscovery) StoreChannel
+	GetAll(discoveryType, clusterName string) StoreChannel
+	SetLastPingAt(discovery *model.ClusterDiscovery) StoreChannel
+	Cleanup() StoreChannel
+}
+
+type JobStore interface {
+	Save(job *model.Job) StoreChannel
+	UpdateOptimistically(job *model.Job, currentStatus string) StoreChannel
+	UpdateStatus(id string, status string) StoreChannel
+	Get(id string) StoreChannel
+	GetAllByType(jobType string, offset int, limit int) StoreChannel
+	GetAllByStatus(status string) StoreChannel
+	Delete(id string) StoreChannel
+	SaveSchedule(schedule *model.JobSchedule) StoreChannel
+	SaveScheduleRun(schedule *model.JobSchedule) StoreChannel
+	GetAllSchedules() StoreChannel
+}
+
+type AnalyticsStore interface {
+	ComputeDailyStats(day string, startTime int64, endTime int64) StoreChannel
+	SaveDailyStats(day string, stats []*model.AnalyticsDailyStat) StoreChannel
+	GetLastAggregatedDay() StoreChannel
+	GetPostCountsByDay(teamId string, startDay string, endDay string) StoreChannel
+	GetUserCountsWithPostsByDay(teamId string, startDay string, endDay string) StoreChannel
+}
+
+type RetentionPolicyStore interface {
+	Save(policy *model.RetentionPolicy) StoreChannel
+	Update(policy *model.RetentionPolicy) StoreChannel
+	Get(id string) StoreChannel
+	GetAll() StoreChannel
+	Delete(id string) StoreChannel
+	CountPostsBefore(scope *model.RetentionScope, before int64) StoreChannel
+	GetPostIdsBefore(scope *model.RetentionScope, before int64, limit int) StoreChannel
+	CountFilesBefore(scope *model.RetentionScope, before int64) StoreChannel
+	GetFileInfosBefore(scope *model.RetentionScope, before int64, limit int) StoreChannel
+}
+
+type LoginAttemptStore interface {
+	SaveOrUpdate(attempt *model.LoginAttempt) StoreChannel
+	IncrementFailedAttempts(attemptType, identifier string, now int64, resetBefore int64) StoreChannel
+	Lock(attemptType, identifier string, maxAttempts int, lockouts int, lockedUntil int64) StoreChannel
+	Unlock(attemptType, identifier string) StoreChannel
+	Get(attemptType, identifier string) StoreChannel
+	Delete(attemptType, identifier string) StoreChannel
+	DeleteOlderThan(time int64) StoreChannel
+}
+
+type InvitationStore interface {
+	Save(invitation *model.Invitation) StoreChannel
+	Get(id string) StoreChannel
+	GetForTeam(teamId string) StoreChannel
+	Delete(id string) StoreChannel
+	PermanentDeleteByTeam(teamId string) StoreChannel
+	DeleteExpired(before int64) StoreChannel
+}
+
+type TeamInviteLinkStore interface {
+	Save(link *model.TeamInviteLink) StoreChannel
+	Get(id string) StoreChannel
+	GetForTeam(teamId string) StoreChannel
+	IncrementUseCount(id string) StoreChannel
+	DecrementUseCount(id string) StoreChannel
+	DeleteForTeam(teamId string, time int64) StoreChannel
+	PermanentDeleteByTeam(teamId string) StoreChannel
+}
+
+type TeamDefaultChannelStore interface {
+	GetForTeam(teamId string) StoreChannel
+	ReplaceForTeam(teamId string, channelIds []string) StoreChannel
+	DeleteForChannel(channelId string) StoreChannel
+	PermanentDeleteByTeam(teamId string) StoreChannel
+}
+
+type CertificateCacheStore interface {
+	SaveOrUpdate(entry *model.CertificateCacheEntry) StoreChannel
+	Get(name string) StoreChannel
+	Delete(name string) StoreChannel
+}
+
+type LeaseStore interface {
+	Acquire(lease *model.Lease) StoreChannel
+	Release(name, ownerId string) StoreChannel
+}
+
+type WebSocketConnectionTokenStore interface {
+	Save(token *model.WebSocketConnectionToken) StoreChannel
+	Consume(token string) StoreChannel
+	DeleteExpired(before int64) StoreChannel
+}
+
+type LegalHoldStore interface {
+	Save(hold *model.LegalHold) StoreChannel
+	Update(hold *model.LegalHold) StoreChannel
+	Get(id string) StoreChannel
+	GetAll() StoreChannel
+	Delete(id string) StoreChannel
+	GetExport(hold *model.LegalHold, offset int, limit int) StoreChannel
+	CountForTarget(userId string, channelId string) StoreChannel
+}
+
+type FileAccessStore interface {
+	Save(access *model.FileAccess) StoreChannel
+	Query(query *model.FileAccessQuery) StoreChannel
+	PermanentDeleteBefore(before int64) StoreChannel
+}
+
+type FileAclStore interface {
+	Save(acl *model.FileAcl) StoreChannel
+	Get(fileId string) StoreChannel
+	Delete(fileId string) StoreChannel
+}
+
+type ProfileImageStore interface {
+	Save(image *model.ProfileImage) StoreChannel
+	Get(fileId string) StoreChannel
+	GetForUser(userId string) StoreChannel
+	Activate(fileId string, activeAt int64) StoreChannel
+	PermanentDeleteBatch(fileIds []string) StoreChannel
+}
+
+type PermalinkPreviewStore interface {
+	SaveForPost(postId string, previews []*model.PermalinkPreview) StoreChannel
+	GetForPosts(postIds []string) StoreChannel
+	UpdateForLinkedPost(linked *model.Post) StoreChannel
+	DeleteForPost(postId string) StoreChannel
+	PermanentDeleteBatch(postIds []string) StoreChannel
+	PermanentDeleteByChannel(channelId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type UserAccessTokenStore interface {
+	Save(token *model.UserAccessToken) StoreChannel
+	Get(tokenId string) StoreChannel
+	GetByToken(tokenString string) StoreChannel
+	GetByUser(userId string) StoreChannel
+	UpdateLastUsedAt(tokenId string, lastUsedAt int64) StoreChannel
+	Delete(tokenId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type PseudonymStore interface {
+	Save(pseudonym *model.Pseudonym) StoreChannel
+	GetForUser(userId string) StoreChannel
+	GetAll(offset int, limit int) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type ChannelEmailAddressStore interface {
+	Replace(address *model.ChannelEmailAddress) StoreChannel
+	GetForChannel(channelId string) StoreChannel
+	GetByToken(token string) StoreChannel
+	DeleteForChannel(channelId string) StoreChannel
+}
+
+type PostTranslationStore interface {
+	Save(translation *model.PostTranslation) StoreChannel
+	Get(postId string, locale string) StoreChannel
+	DeleteForPost(postId string) StoreChannel
+	PermanentDeleteBatch(postIds []string) StoreChannel
+	PermanentDeleteByChannel(channelId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type BackgroundMigrationStore interface {
+	GetPending() StoreChannel
+	CountRows(name string) StoreChannel
+	MigrateBatch(name string, afterId string, limit int) StoreChannel
+	Complete(name string) StoreChannel
+}
+
+type ThreadMembershipStore interface {
+	Save(membership *model.ThreadMembership) StoreChannel
+	Get(postId string, userId string) StoreChannel
+	GetFollowers(postId string) StoreChannel
+	GetForUser(userId string, channelId string) StoreChannel
+	IncrementUnreadReplies(postId string, exceptUserId string) StoreChannel
+	MarkRead(postId string, userId string, viewedAt int64) StoreChannel
+	DeleteForPost(postId string) StoreChannel
+}
+
+type RoleStore interface {
+	Save(role *model.Role) StoreChannel
+	Get(id string) StoreChannel
+	GetAll() StoreChannel
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	l4g "github.com/alecthomas/log4go"
 
@@ -34,6 +35,9 @@ var ClientCfg map[string]string = map[string]string{}
 var originalDisableDebugLvl l4g.Level = l4g.DEBUG
 var siteURL = ""
 
+var cfgListeners = map[string]func(oldConfig *model.Config, newConfig *model.Config){}
+var cfgListenersMutex sync.RWMutex
+
 func GetSiteURL() string {
 	return siteURL
 }
@@ -157,28 +161,67 @@ func SaveConfig(fileName string, config *model.Config) *model.AppError {
 // It will search /tmp/fileName then attempt ./config/fileName,
 // then ../config/fileName and last it will look at fileName
 func LoadConfig(fileName string) {
+	config, fileName, err := readConfig(fileName)
+	if err != nil {
+		panic(err.SystemMessage(T))
+	}
+
+	applyConfig(fileName, config)
+}
+
+// ReloadConfig re-reads the config file that was last loaded and applies it to the running server. Unlike LoadConfig,
+// an invalid config file returns an error and leaves the current config in place.
+func ReloadConfig() *model.AppError {
+	config, fileName, err := readConfig(CfgFileName)
+	if err != nil {
+		return err
+	}
+
+	applyConfig(fileName, config)
+
+	return nil
+}
+
+// AddConfigListener registers a function to be called with the previous and new config every time the config is
+// loaded so that running subsystems can apply changed settings. It returns an id to pass to RemoveConfigListener.
+func AddConfigListener(listener func(oldConfig *model.Config, newConfig *model.Config)) string {
+	cfgListenersMutex.Lock()
+	defer cfgListenersMutex.Unlock()
+
+	id := model.NewId()
+	cfgListeners[id] = listener
+
+	return id
+}
+
+func RemoveConfigListener(id string) {
+	cfgListenersMutex.Lock()
+	defer cfgListenersMutex.Unlock()
+
+	delete(cfgListeners, id)
+}
 
+func readConfig(fileName string) (*model.Config, string, *model.AppError) {
 	fileName = FindConfigFile(fileName)
 
 	file, err := os.Open(fileName)
 	if err != nil {
-		panic(T("utils.config.load_config.opening.panic",
-			map[string]interface{}{"Filename": fileName, "Error": err.Error()}))
+		return nil, "", model.NewLocAppError("readConfig", "utils.config.load_config.opening.panic",
+			map[string]interface{}{"Filename": fileName, "Error": err.Error()}, "")
 	}
+	defer file.Close()
 
 	decoder := json.NewDecoder(file)
 	config := model.Config{}
 	err = decoder.Decode(&config)
 	if err != nil {
-		panic(T("utils.config.load_config.decoding.panic",
-			map[string]interface{}{"Filename": fileName, "Error": err.Error()}))
+		return nil, "", model.NewLocAppError("readConfig", "utils.config.load_config.decoding.panic",
+			map[string]interface{}{"Filename": fileName, "Error": err.Error()}, "")
 	}
 
 	if _, err := file.Stat(); err != nil {
-		panic(T("utils.config.load_config.getting.panic",
-			map[string]interface{}{"Filename": fileName, "Error": err.Error()}))
-	} else {
-		CfgFileName = fileName
+		return nil, "", model.NewLocAppError("readConfig", "utils.config.load_config.getting.panic",
+			map[string]interface{}{"Filename": fileName, "Error": err.Error()}, "")
 	}
 
 	needSave := len(config.SqlSettings.AtRestEncryptKey) == 0 || len(*config.FileSettings.PublicLinkSalt) == 0 ||
@@ -187,7 +230,7 @@ func LoadConfig(fileName string) {
 	config.SetDefaults()
 
 	if err := config.IsValid(); err != nil {
-		panic(T(err.Id))
+		return nil, "", err
 	}
 
 	if needSave {
@@ -197,9 +240,15 @@ func LoadConfig(fileName string) {
 	}
 
 	if err := ValidateLdapFilter(&config); err != nil {
-		panic(T(err.Id))
+		return nil, "", err
 	}
 
+	return &config, fileName, nil
+}
+
+func applyConfig(fileName string, config *model.Config) {
+	CfgFileName = fileName
+
 	configureLog(&config.LogSettings)
 
 	if config.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
@@ -209,7 +258,9 @@ func LoadConfig(fileName string) {
 		}
 	}
 
-	Cfg = &config
+	oldConfig := Cfg
+
+	Cfg = config
 	CfgHash = fmt.Sprintf("%x", md5.Sum([]byte(Cfg.ToJson())))
 	ClientCfg = getClientConfig(Cfg)
 
@@ -225,6 +276,17 @@ func LoadConfig(fileName string) {
 
 	SetDefaultRolesBasedOnConfig()
 	SetSiteURL(*Cfg.ServiceSettings.SiteURL)
+
+	cfgListenersMutex.RLock()
+	listeners := make([]func(oldConfig *model.Config, newConfig *model.Config), 0, len(cfgListeners))
+	for _, listener := range cfgListeners {
+		listeners = append(listeners, listener)
+	}
+	cfgListenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(oldConfig, Cfg)
+	}
 }
 
 func RegenerateClientConfig() {
@@ -411,6 +473,22 @@ func Desanitize(cfg *model.Config) {
 		cfg.GitLabSettings.Secret = Cfg.GitLabSettings.Secret
 	}
 
+	if cfg.GoogleSettings.Secret == model.FAKE_SETTING {
+		cfg.GoogleSettings.Secret = Cfg.GoogleSettings.Secret
+	}
+
+	if cfg.Office365Settings.Secret == model.FAKE_SETTING {
+		cfg.Office365Settings.Secret = Cfg.Office365Settings.Secret
+	}
+
+	if cfg.WebrtcSettings.GatewayAdminSecret != nil && *cfg.WebrtcSettings.GatewayAdminSecret == model.FAKE_SETTING {
+		*cfg.WebrtcSettings.GatewayAdminSecret = *Cfg.WebrtcSettings.GatewayAdminSecret
+	}
+
+	if cfg.WebrtcSettings.TurnSharedKey != nil && *cfg.WebrtcSettings.TurnSharedKey == model.FAKE_SETTING {
+		*cfg.WebrtcSettings.TurnSharedKey = *Cfg.WebrtcSettings.TurnSharedKey
+	}
+
 	if cfg.SqlSettings.DataSource == model.FAKE_SETTING {
 		cfg.SqlSettings.DataSource = Cfg.SqlSettings.DataSource
 	}
@@ -421,4 +499,8 @@ func Desanitize(cfg *model.Config) {
 	for i := range cfg.SqlSettings.DataSourceReplicas {
 		cfg.SqlSettings.DataSourceReplicas[i] = Cfg.SqlSettings.DataSourceReplicas[i]
 	}
+
+	if cfg.SearchSettings.Password != nil && *cfg.SearchSettings.Password == model.FAKE_SETTING {
+		*cfg.SearchSettings.Password = *Cfg.SearchSettings.Password
+	}
 }
@@ -4,7 +4,13 @@
 package utils
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/mattermost/platform/model"
 )
 
 func TestConfig(t *testing.T) {
@@ -12,3 +18,69 @@ func TestConfig(t *testing.T) {
 	LoadConfig("config.json")
 	InitTranslations(Cfg.LocalizationSettings)
 }
+
+func TestReloadConfig(t *testing.T) {
+	TranslationsPreInit()
+	LoadConfig("config.json")
+	defer LoadConfig("config.json")
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "config.json")
+	if err := SaveConfig(fileName, Cfg); err != nil {
+		t.Fatal(err)
+	}
+	LoadConfig(fileName)
+
+	var oldSiteName, newSiteName string
+	listenerId := AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		oldSiteName = oldConfig.TeamSettings.SiteName
+		newSiteName = newConfig.TeamSettings.SiteName
+	})
+	defer RemoveConfigListener(listenerId)
+
+	config := model.ConfigFromJson(strings.NewReader(Cfg.ToJson()))
+	previousSiteName := config.TeamSettings.SiteName
+	config.TeamSettings.SiteName = "Reloaded"
+	if err := SaveConfig(fileName, config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if Cfg.TeamSettings.SiteName != "Reloaded" {
+		t.Fatal("should have applied the new config")
+	} else if oldSiteName != previousSiteName || newSiteName != "Reloaded" {
+		t.Fatal("should have notified the listener of the change", oldSiteName, newSiteName)
+	}
+
+	if err := ioutil.WriteFile(fileName, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReloadConfig(); err == nil {
+		t.Fatal("should have failed to reload an invalid config")
+	} else if Cfg.TeamSettings.SiteName != "Reloaded" {
+		t.Fatal("should have kept the current config")
+	}
+
+	RemoveConfigListener(listenerId)
+	newSiteName = ""
+
+	config.TeamSettings.SiteName = "Removed"
+	if err := SaveConfig(fileName, config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	} else if newSiteName != "" {
+		t.Fatal("shouldn't have notified a removed listener")
+	}
+}
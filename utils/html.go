@@ -6,7 +6,12 @@ package utils
 import (
 	"bytes"
 	"html/template"
-	"net/http"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/nicksnyder/go-i18n/i18n"
@@ -15,6 +20,10 @@ import (
 
 // Global storage for templates
 var htmlTemplates *template.Template
+var localeHTMLTemplates map[string]*template.Template
+var htmlTemplatesLock sync.RWMutex
+var htmlTemplatesDir string
+var htmlTemplatesWatcher *fsnotify.Watcher
 
 type HTMLTemplate struct {
 	TemplateName string
@@ -33,10 +42,9 @@ func InitHTMLWithDir(dir string) {
 		return
 	}
 
-	templatesDir := FindDir(dir)
-	l4g.Debug(T("api.api.init.parsing_templates.debug"), templatesDir)
-	var err error
-	if htmlTemplates, err = template.ParseGlob(templatesDir + "*.html"); err != nil {
+	htmlTemplatesDir = FindDir(dir)
+	l4g.Debug(T("api.api.init.parsing_templates.debug"), htmlTemplatesDir)
+	if err := ReloadHTMLTemplates(); err != nil {
 		l4g.Error(T("api.api.init.parsing_templates.error"), err)
 	}
 
@@ -44,15 +52,19 @@ func InitHTMLWithDir(dir string) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		l4g.Error(T("web.create_dir.error"), err)
+		return
 	}
+	htmlTemplatesWatcher = watcher
 
 	go func() {
 		for {
 			select {
 			case event := <-watcher.Events:
-				if event.Op&fsnotify.Write == fsnotify.Write {
+				// editors often save by writing a new file and renaming it over the old one, and new overrides or
+				// locale directories are created rather than written to, so any change to a file is reparsed
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
 					l4g.Info(T("web.reparse_templates.info"), event.Name)
-					if htmlTemplates, err = template.ParseGlob(templatesDir + "*.html"); err != nil {
+					if err := ReloadHTMLTemplates(); err != nil {
 						l4g.Error(T("web.parsing_templates.error"), err)
 					}
 				}
@@ -62,12 +74,123 @@ func InitHTMLWithDir(dir string) {
 		}
 	}()
 
-	err = watcher.Add(templatesDir)
-	if err != nil {
+	watchHTMLTemplatesDir(htmlTemplatesDir)
+	watchHTMLTemplatesDir(htmlTemplateOverridesDir())
+}
+
+func watchHTMLTemplatesDir(dir string) {
+	if htmlTemplatesWatcher == nil || dir == "" {
+		return
+	}
+
+	if err := htmlTemplatesWatcher.Add(dir); err != nil {
 		l4g.Error(T("web.watcher_fail.error"), err)
 	}
 }
 
+// ReloadHTMLTemplates parses the default templates along with any overrides in the directory set by
+// EmailSettings.TemplatesDirectory. Templates directly in that directory replace the default ones with the same name
+// for everyone, while templates in a subdirectory named after a locale, such as "es", only replace them for users
+// with that locale. An error is only returned if the default templates can't be parsed, since overrides that can't
+// be parsed are logged and skipped so that a mistake in one doesn't stop every email from being sent.
+func ReloadHTMLTemplates() error {
+	templates, err := template.ParseGlob(htmlTemplatesDir + "*.html")
+	if err != nil {
+		return err
+	}
+
+	localeTemplates := make(map[string]*template.Template)
+
+	if overridesDir := htmlTemplateOverridesDir(); overridesDir != "" {
+		templates = parseHTMLTemplateOverrides(templates, overridesDir)
+
+		if entries, err := ioutil.ReadDir(overridesDir); err != nil {
+			l4g.Warn(T("utils.html.read_overrides.warn"), overridesDir, err)
+		} else {
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+
+				localeDir := filepath.Join(overridesDir, entry.Name())
+				localeTemplates[entry.Name()] = parseHTMLTemplateOverrides(templates, localeDir)
+
+				watchHTMLTemplatesDir(localeDir)
+			}
+		}
+	}
+
+	htmlTemplatesLock.Lock()
+	htmlTemplates = templates
+	localeHTMLTemplates = localeTemplates
+	htmlTemplatesLock.Unlock()
+
+	return nil
+}
+
+func htmlTemplateOverridesDir() string {
+	// the templates may be loaded before the config
+	if Cfg.EmailSettings.TemplatesDirectory == nil {
+		return ""
+	}
+
+	return *Cfg.EmailSettings.TemplatesDirectory
+}
+
+// parseHTMLTemplateOverrides returns a copy of the given templates with any templates defined by the .html files in
+// dir added to it, replacing existing templates with the same names. Each file is parsed into its own copy so that
+// one that can't be parsed is left out without affecting the rest.
+func parseHTMLTemplateOverrides(templates *template.Template, dir string) *template.Template {
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		l4g.Warn(T("utils.html.read_overrides.warn"), dir, err)
+		return templates
+	}
+
+	for _, file := range files {
+		if overridden, err := templates.Clone(); err != nil {
+			l4g.Warn(T("utils.html.parse_override.warn"), file, err)
+		} else if overridden, err = overridden.ParseFiles(file); err != nil {
+			l4g.Warn(T("utils.html.parse_override.warn"), file, err)
+		} else {
+			templates = overridden
+		}
+	}
+
+	return templates
+}
+
+// getHTMLTemplates returns the templates used for emails in the given locale.
+func getHTMLTemplates(locale string) *template.Template {
+	htmlTemplatesLock.RLock()
+	defer htmlTemplatesLock.RUnlock()
+
+	if templates, ok := localeHTMLTemplates[locale]; ok {
+		return templates
+	}
+
+	return htmlTemplates
+}
+
+// GetHTMLTemplateNames returns the names of the templates that can be rendered, sorted alphabetically.
+func GetHTMLTemplateNames() []string {
+	names := []string{}
+	for _, t := range getHTMLTemplates("").Templates() {
+		if t.Name() != "" && !strings.HasSuffix(t.Name(), ".html") {
+			names = append(names, t.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// HTMLTemplateExists returns true if a template with the given name can be rendered.
+func HTMLTemplateExists(templateName string) bool {
+	return getHTMLTemplates("").Lookup(templateName) != nil
+}
+
 func NewHTMLTemplate(templateName string, locale string) *HTMLTemplate {
 	return &HTMLTemplate{
 		TemplateName: templateName,
@@ -102,17 +225,17 @@ func (t *HTMLTemplate) Render() string {
 
 	var text bytes.Buffer
 
-	if err := htmlTemplates.ExecuteTemplate(&text, t.TemplateName, t); err != nil {
+	if err := getHTMLTemplates(t.Locale).ExecuteTemplate(&text, t.TemplateName, t); err != nil {
 		l4g.Error(T("api.api.render.error"), t.TemplateName, err)
 	}
 
 	return text.String()
 }
 
-func (t *HTMLTemplate) RenderToWriter(w http.ResponseWriter) error {
+func (t *HTMLTemplate) RenderToWriter(w io.Writer) error {
 	t.addDefaultProps()
 
-	if err := htmlTemplates.ExecuteTemplate(w, t.TemplateName, t); err != nil {
+	if err := getHTMLTemplates(t.Locale).ExecuteTemplate(w, t.TemplateName, t); err != nil {
 		l4g.Error(T("api.api.render.error"), t.TemplateName, err)
 		return err
 	}
@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLTemplateOverrides(t *testing.T) {
+	TranslationsPreInit()
+	LoadConfig("config.json")
+	InitTranslations(Cfg.LocalizationSettings)
+	InitHTML()
+
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "es"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "reset_body.html"), []byte(`{{define "reset_body"}}custom {{.Props.Title}}{{end}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "es", "reset_body.html"), []byte(`{{define "reset_body"}}personalizado {{.Props.Title}}{{end}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "verify_body.html"), []byte(`{{define "verify_body"}}broken {{.Props.Title}{{end}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	templatesDirectory := *Cfg.EmailSettings.TemplatesDirectory
+	defer func() {
+		*Cfg.EmailSettings.TemplatesDirectory = templatesDirectory
+		ReloadHTMLTemplates()
+	}()
+
+	*Cfg.EmailSettings.TemplatesDirectory = dir
+	if err := ReloadHTMLTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	page := NewHTMLTemplate("reset_body", "en")
+	page.Props["Title"] = "title"
+	if body := page.Render(); body != "custom title" {
+		t.Fatal("should have used the override", body)
+	}
+
+	page = NewHTMLTemplate("reset_body", "es")
+	page.Props["Title"] = "title"
+	if body := page.Render(); body != "personalizado title" {
+		t.Fatal("should have used the override for the locale", body)
+	}
+
+	if body := NewHTMLTemplate("verify_body", "es").Render(); !strings.Contains(body, "<table") {
+		t.Fatal("should have used the default template when the override can't be parsed")
+	}
+
+	t.Run("NewOverride", func(t *testing.T) {
+		watchHTMLTemplatesDir(dir)
+
+		// moving a file into the directory only creates it without writing to it
+		file, err := ioutil.TempFile("", "welcome_body")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+
+		if _, err := file.WriteString(`{{define "welcome_body"}}new {{.Props.Title}}{{end}}`); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		if err := os.Rename(file.Name(), filepath.Join(dir, "welcome_body.html")); err != nil {
+			t.Fatal(err)
+		}
+
+		page := NewHTMLTemplate("welcome_body", "en")
+		page.Props["Title"] = "title"
+		for i := 0; i < 50 && page.Render() != "new title"; i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if body := page.Render(); body != "new title" {
+			t.Fatal("should have reloaded the templates when an override was created", body)
+		}
+	})
+
+	if !HTMLTemplateExists("reset_body") || HTMLTemplateExists("junk") {
+		t.Fatal("should have found only the existing templates")
+	}
+
+	*Cfg.EmailSettings.TemplatesDirectory = ""
+	if err := ReloadHTMLTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := NewHTMLTemplate("reset_body", "es").Render(); !strings.Contains(body, "<table") {
+		t.Fatal("should have gone back to the default template")
+	}
+}
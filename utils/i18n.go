@@ -39,6 +39,11 @@ func InitTranslationsWithDir(dir string) {
 	}
 }
 
+// GetSupportedLocales returns the locales that the server has translations for, mapped to their translation files.
+func GetSupportedLocales() map[string]string {
+	return locales
+}
+
 func GetTranslationsBySystemLocale() i18n.TranslateFunc {
 	locale := *settings.DefaultServerLocale
 	if _, ok := locales[locale]; !ok {
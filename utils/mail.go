@@ -115,6 +115,7 @@ func SendMailUsingConfig(to, subject, body string, config *model.Config) *model.
 	headers["Content-Type"] = "text/html; charset=\"utf-8\""
 	headers["Content-Transfer-Encoding"] = "8bit"
 	headers["Date"] = time.Now().Format(time.RFC1123Z)
+	headers["Auto-Submitted"] = "auto-generated"
 
 	message := ""
 	for k, v := range headers {
@@ -199,6 +199,68 @@ func TestGetAccessToken(t *testing.T) {
 	ApiClient.ClearOAuthToken()
 }
 
+func TestRefreshAccessToken(t *testing.T) {
+	Setup()
+
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Password: "passwd1"}
+	ruser := ApiClient.Must(ApiClient.CreateUser(&user, "")).Data.(*model.User)
+	store.Must(app.Srv.Store.User().VerifyEmail(ruser.Id))
+
+	ApiClient.Must(ApiClient.LoginById(ruser.Id, "passwd1"))
+
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+	utils.SetDefaultRolesBasedOnConfig()
+	oauthApp := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	oauthApp = ApiClient.Must(ApiClient.RegisterApp(oauthApp)).Data.(*model.OAuthApp)
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+	utils.SetDefaultRolesBasedOnConfig()
+
+	redirect := ApiClient.Must(ApiClient.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, oauthApp.Id, oauthApp.CallbackUrls[0], "all", "123")).Data.(map[string]string)["redirect"]
+	rurl, _ := url.Parse(redirect)
+
+	ApiClient.Logout()
+
+	data := url.Values{"grant_type": []string{model.ACCESS_TOKEN_GRANT_TYPE}, "client_id": []string{oauthApp.Id}, "client_secret": []string{oauthApp.ClientSecret}, "code": []string{rurl.Query().Get("code")}, "redirect_uri": []string{oauthApp.CallbackUrls[0]}}
+	rsp := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+
+	firstRefreshToken := rsp.RefreshToken
+	if len(firstRefreshToken) == 0 {
+		t.Fatal("refresh token not returned")
+	}
+
+	data = url.Values{"grant_type": []string{model.REFRESH_TOKEN_GRANT_TYPE}, "client_id": []string{oauthApp.Id}, "client_secret": []string{oauthApp.ClientSecret}, "refresh_token": []string{firstRefreshToken}}
+	rsp = ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+
+	if len(rsp.AccessToken) == 0 {
+		t.Fatal("access token not returned")
+	} else if len(rsp.RefreshToken) == 0 || rsp.RefreshToken == firstRefreshToken {
+		t.Fatal("should have rotated the refresh token")
+	}
+	secondRefreshToken := rsp.RefreshToken
+
+	ApiClient.SetOAuthToken(rsp.AccessToken)
+	if _, err := ApiClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	}
+	ApiClient.ClearOAuthToken()
+
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - refresh token was already used")
+	}
+
+	ApiClient.SetOAuthToken(rsp.AccessToken)
+	if _, err := ApiClient.GetMe(""); err == nil {
+		t.Fatal("should have failed - reusing a refresh token revokes the access it granted")
+	}
+	ApiClient.ClearOAuthToken()
+
+	data.Set("refresh_token", secondRefreshToken)
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - the latest refresh token should have been revoked too")
+	}
+}
+
 func TestIncomingWebhook(t *testing.T) {
 	Setup()
 
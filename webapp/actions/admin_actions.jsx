@@ -71,6 +71,22 @@ export function adminResetMfa(userId, success, error) {
     );
 }
 
+export function queryLogs(query, success, error) {
+    Client.queryLogs(
+        query,
+        (data) => {
+            if (success) {
+                success(data);
+            }
+        },
+        (err) => {
+            if (error) {
+                error(err);
+            }
+        }
+    );
+}
+
 export function getClusterStatus(success, error) {
     Client.getClusterStatus(
         (data) => {
@@ -87,6 +103,38 @@ export function getClusterStatus(success, error) {
     );
 }
 
+export function getJobSchedules(success, error) {
+    Client.getJobSchedules(
+        (data) => {
+            if (success) {
+                success(data);
+            }
+        },
+        (err) => {
+            AsyncClient.dispatchError(err, 'getJobSchedules');
+            if (error) {
+                error(err);
+            }
+        }
+    );
+}
+
+export function updateJobSchedule(schedule, success, error) {
+    Client.updateJobSchedule(
+        schedule,
+        (data) => {
+            if (success) {
+                success(data);
+            }
+        },
+        (err) => {
+            if (error) {
+                error(err);
+            }
+        }
+    );
+}
+
 export function saveComplianceReports(job, success, error) {
     Client.saveComplianceReports(
         job,
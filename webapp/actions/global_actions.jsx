@@ -471,6 +471,15 @@ export function emitRemoteUserTypingEvent(channelId, userId, postParentId) {
     });
 }
 
+export function emitRemoteUsersTypingEvent(channelId, userIds, postParentId) {
+    AppDispatcher.handleViewAction({
+        type: Constants.ActionTypes.USERS_TYPING,
+        channelId,
+        userIds,
+        postParentId
+    });
+}
+
 export function emitUserLoggedOutEvent(redirectTo = '/', shouldSignalLogout = true) {
     Client.logout(
         () => {
@@ -60,6 +60,7 @@ export function initialize() {
     WebSocketClient.setEventCallback(handleEvent);
     WebSocketClient.setFirstConnectCallback(handleFirstConnect);
     WebSocketClient.setCloseCallback(handleClose);
+    WebSocketClient.setMissedEventsCallback(handleMissedEvents);
     WebSocketClient.initialize(connUrl);
 }
 
@@ -87,6 +88,17 @@ export function reconnect(includeWebSocket = true) {
     ErrorStore.emitChange();
 }
 
+// connectionRestored is called when requests succeed again after failing. The websocket resumes its connection by
+// itself and reloads everything if it missed events, so only the error needs to be cleared.
+export function connectionRestored() {
+    ErrorStore.clearLastError();
+    ErrorStore.emitChange();
+}
+
+function handleMissedEvents() {
+    reconnect(false);
+}
+
 function handleFirstConnect() {
     ErrorStore.clearLastError();
     ErrorStore.emitChange();
@@ -153,13 +165,21 @@ function handleEvent(msg) {
         break;
 
     case SocketEvents.TYPING:
-        handleUserTypingEvent(msg);
+        // The users typing are updated from typing_users, and this is only still sent for older clients
+        break;
+
+    case SocketEvents.TYPING_USERS:
+        handleTypingUsersEvent(msg);
         break;
 
     case SocketEvents.STATUS_CHANGED:
         handleStatusChangedEvent(msg);
         break;
 
+    case SocketEvents.CUSTOM_STATUS_CHANGED:
+        handleCustomStatusChangedEvent(msg);
+        break;
+
     case SocketEvents.HELLO:
         handleHelloEvent(msg);
         break;
@@ -303,11 +323,14 @@ function handlePreferenceChangedEvent(msg) {
     GlobalActions.emitPreferenceChangedEvent(preference);
 }
 
-function handleUserTypingEvent(msg) {
-    GlobalActions.emitRemoteUserTypingEvent(msg.broadcast.channel_id, msg.data.user_id, msg.data.parent_id);
+function handleTypingUsersEvent(msg) {
+    const userIds = msg.data.user_ids || [];
+
+    GlobalActions.emitRemoteUsersTypingEvent(msg.broadcast.channel_id, userIds, msg.data.parent_id);
 
-    if (UserStore.getStatus(msg.data.user_id) !== UserStatuses.ONLINE) {
-        StatusActions.loadStatusesByIds([msg.data.user_id]);
+    const notOnline = userIds.filter((userId) => UserStore.getStatus(userId) !== UserStatuses.ONLINE);
+    if (notOnline.length > 0) {
+        StatusActions.loadStatusesByIds(notOnline);
     }
 }
 
@@ -315,6 +338,21 @@ function handleStatusChangedEvent(msg) {
     UserStore.setStatus(msg.data.user_id, msg.data.status);
 }
 
+function handleCustomStatusChangedEvent(msg) {
+    const profile = UserStore.getProfile(msg.data.user_id);
+    if (!profile) {
+        return;
+    }
+
+    const customStatus = msg.data.custom_status ? JSON.parse(msg.data.custom_status) : {};
+    profile.custom_status_emoji = customStatus.emoji;
+    profile.custom_status_text = customStatus.text;
+    profile.custom_status_expiry = customStatus.expires_at;
+
+    UserStore.saveProfile(profile);
+    UserStore.emitChange(profile.id);
+}
+
 function handleHelloEvent(msg) {
     Client.serverVersion = msg.data.server_version;
     AsyncClient.checkVersion();
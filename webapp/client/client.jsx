@@ -327,6 +327,16 @@ export default class Client {
             end(this.handleResponse.bind(this, 'getLogs', success, error));
     }
 
+    queryLogs(query, success, error) {
+        return request.
+            post(`${this.getAdminRoute()}/logs/query`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            send(query).
+            end(this.handleResponse.bind(this, 'queryLogs', success, error));
+    }
+
     getClusterStatus(success, error) {
         return request.
             get(`${this.getAdminRoute()}/cluster_status`).
@@ -336,6 +346,25 @@ export default class Client {
             end(this.handleResponse.bind(this, 'getClusterStatus', success, error));
     }
 
+    getJobSchedules(success, error) {
+        return request.
+            get(`${this.getAdminRoute()}/jobs/schedules`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            end(this.handleResponse.bind(this, 'getJobSchedules', success, error));
+    }
+
+    updateJobSchedule(schedule, success, error) {
+        return request.
+            post(`${this.getAdminRoute()}/jobs/schedules/update`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            send(schedule).
+            end(this.handleResponse.bind(this, 'updateJobSchedule', success, error));
+    }
+
     getServerAudits(success, error) {
         return request.
             get(`${this.getAdminRoute()}/audits`).
@@ -7,7 +7,7 @@ import TeamStore from 'stores/team_store.jsx';
 import BrowserStore from 'stores/browser_store.jsx';
 
 import * as GlobalActions from 'actions/global_actions.jsx';
-import {reconnect} from 'actions/websocket_actions.jsx';
+import {connectionRestored} from 'actions/websocket_actions.jsx';
 
 import request from 'superagent';
 
@@ -61,7 +61,7 @@ class WebClientClass extends Client {
 
     handleSuccess = (res) => { // eslint-disable-line no-unused-vars
         if (res && !this.hasInternetConnection) {
-            reconnect();
+            connectionRestored();
             this.hasInternetConnection = true;
         }
     }
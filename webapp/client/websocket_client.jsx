@@ -17,6 +17,9 @@ export default class WebSocketClient {
         this.reconnectCallback = null;
         this.errorCallback = null;
         this.closeCallback = null;
+        this.missedEventsCallback = null;
+        this.connectionId = null;
+        this.eventSequence = 0;
     }
 
     initialize(connectionUrl = this.connectionUrl, token) {
@@ -33,7 +36,13 @@ export default class WebSocketClient {
             console.log('websocket connecting to ' + connectionUrl); //eslint-disable-line no-console
         }
 
-        this.conn = new WebSocket(connectionUrl);
+        // Ask the server to resume the previous connection so that it sends the events missed while disconnected
+        const resuming = Boolean(this.connectionId);
+        if (resuming) {
+            this.conn = new WebSocket(connectionUrl + '?connection_id=' + this.connectionId + '&sequence_number=' + this.eventSequence);
+        } else {
+            this.conn = new WebSocket(connectionUrl);
+        }
         this.connectionUrl = connectionUrl;
 
         this.conn.onopen = () => {
@@ -107,8 +116,24 @@ export default class WebSocketClient {
                     this.responseCallbacks[msg.seq_reply](msg);
                     Reflect.deleteProperty(this.responseCallbacks, msg.seq_reply);
                 }
-            } else if (this.eventCallback) {
-                this.eventCallback(msg);
+            } else {
+                if (msg.event === 'hello') {
+                    this.connectionId = msg.data.connection_id;
+
+                    if (!msg.data.resumed) {
+                        this.eventSequence = 0;
+
+                        if (resuming && this.missedEventsCallback) {
+                            this.missedEventsCallback();
+                        }
+                    }
+                } else if (msg.seq) {
+                    this.eventSequence = msg.seq;
+                }
+
+                if (this.eventCallback) {
+                    this.eventCallback(msg);
+                }
             }
         };
     }
@@ -133,9 +158,17 @@ export default class WebSocketClient {
         this.closeCallback = callback;
     }
 
+    // The missed events callback is called when the server couldn't resume a connection after reconnecting, so
+    // events may have been missed while disconnected
+    setMissedEventsCallback(callback) {
+        this.missedEventsCallback = callback;
+    }
+
     close() {
         this.connectFailCount = 0;
         this.sequence = 1;
+        this.connectionId = null;
+        this.eventSequence = 0;
         if (this.conn && this.conn.readyState === WebSocket.OPEN) {
             this.conn.onclose = () => {}; //eslint-disable-line no-empty-function
             this.conn.close();
@@ -748,6 +748,15 @@ export default class AdminSidebar extends React.Component {
                                 />
                                 {clusterSettings}
                                 {metricsSettings}
+                                <AdminSidebarSection
+                                    name='jobs'
+                                    title={
+                                        <FormattedMessage
+                                            id='admin.sidebar.jobs'
+                                            defaultMessage='Job Scheduling'
+                                        />
+                                    }
+                                />
                             </AdminSidebarSection>
                         </AdminSidebarCategory>
                         {this.renderTeams()}
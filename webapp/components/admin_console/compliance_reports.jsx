@@ -72,6 +72,7 @@ export default class ComplianceReports extends React.Component {
         job.keywords = ReactDOM.findDOMNode(this.refs.keywords).value;
         job.start_at = Date.parse(ReactDOM.findDOMNode(this.refs.from).value);
         job.end_at = Date.parse(ReactDOM.findDOMNode(this.refs.to).value);
+        job.anonymize = ReactDOM.findDOMNode(this.refs.anonymize).checked;
 
         saveComplianceReports(
             job,
@@ -81,6 +82,7 @@ export default class ComplianceReports extends React.Component {
                 ReactDOM.findDOMNode(this.refs.desc).value = '';
                 ReactDOM.findDOMNode(this.refs.from).value = '';
                 ReactDOM.findDOMNode(this.refs.to).value = '';
+                ReactDOM.findDOMNode(this.refs.anonymize).checked = false;
                 this.reload();
                 $('#run-button').button('reset');
             },
@@ -354,6 +356,19 @@ export default class ComplianceReports extends React.Component {
                         />
                     </div>
                 </div>
+                <div className='form-group'>
+                    <label className='checkbox-inline'>
+                        <input
+                            type='checkbox'
+                            id='anonymize'
+                            ref='anonymize'
+                        />
+                        <FormattedMessage
+                            id='admin.compliance_reports.anonymize'
+                            defaultMessage='Replace users with their pseudonyms'
+                        />
+                    </label>
+                </div>
                 <div className='clearfix'>
                     <button
                         id='run-button'
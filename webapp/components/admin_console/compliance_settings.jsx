@@ -24,6 +24,8 @@ export default class ComplianceSettings extends AdminSettings {
         config.ComplianceSettings.Enable = this.state.enable;
         config.ComplianceSettings.Directory = this.state.directory;
         config.ComplianceSettings.EnableDaily = this.state.enableDaily;
+        config.ComplianceSettings.EnableFileAccessLog = this.state.enableFileAccessLog;
+        config.ComplianceSettings.FileAccessLogRetentionDays = this.parseInt(this.state.fileAccessLogRetentionDays);
 
         return config;
     }
@@ -32,7 +34,9 @@ export default class ComplianceSettings extends AdminSettings {
         return {
             enable: config.ComplianceSettings.Enable,
             directory: config.ComplianceSettings.Directory,
-            enableDaily: config.ComplianceSettings.EnableDaily
+            enableDaily: config.ComplianceSettings.EnableDaily,
+            enableFileAccessLog: config.ComplianceSettings.EnableFileAccessLog,
+            fileAccessLogRetentionDays: config.ComplianceSettings.FileAccessLogRetentionDays
         };
     }
 
@@ -122,6 +126,42 @@ export default class ComplianceSettings extends AdminSettings {
                     onChange={this.handleChange}
                     disabled={!licenseEnabled || !this.state.enable}
                 />
+                <BooleanSetting
+                    id='enableFileAccessLog'
+                    label={
+                        <FormattedMessage
+                            id='admin.compliance.enableFileAccessLogTitle'
+                            defaultMessage='Enable File Access Log:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.compliance.enableFileAccessLogDesc'
+                            defaultMessage='When true, Mattermost records the user, IP address and time of every file download, preview and public link access so that it can be exported by a System Admin.'
+                        />
+                    }
+                    value={this.state.enableFileAccessLog}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='fileAccessLogRetentionDays'
+                    label={
+                        <FormattedMessage
+                            id='admin.compliance.fileAccessLogRetentionDaysTitle'
+                            defaultMessage='File Access Log Retention (days):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.compliance.fileAccessLogRetentionDaysExample', 'E.g.: "365"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.compliance.fileAccessLogRetentionDaysDesc'
+                            defaultMessage='Number of days to keep file access log entries for before the data retention job deletes them. Set to 0 to keep them forever.'
+                        />
+                    }
+                    value={this.state.fileAccessLogRetentionDays}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableFileAccessLog}
+                />
             </SettingsGroup>
         );
     }
@@ -14,6 +14,7 @@ import PurgeCachesButton from './purge_caches.jsx';
 import WebserverModeDropdownSetting from './webserver_mode_dropdown_setting.jsx';
 import {ConnectionSecurityDropdownSettingWebserver} from './connection_security_dropdown_setting.jsx';
 import BooleanSetting from './boolean_setting.jsx';
+import DropdownSetting from './dropdown_setting.jsx';
 
 export default class ConfigurationSettings extends AdminSettings {
     constructor(props) {
@@ -39,6 +40,8 @@ export default class ConfigurationSettings extends AdminSettings {
         config.ServiceSettings.TLSKeyFile = this.state.TLSKeyFile;
         config.ServiceSettings.UseLetsEncrypt = this.state.useLetsEncrypt;
         config.ServiceSettings.LetsEncryptCertificateCacheFile = this.state.letsEncryptCertificateCacheFile;
+        config.ServiceSettings.LetsEncryptCertificateCacheDriver = this.state.letsEncryptCertificateCacheDriver;
+        config.ServiceSettings.LetsEncryptDirectoryURL = this.state.letsEncryptDirectoryURL;
         config.ServiceSettings.Forward80To443 = this.state.forward80To443;
         config.ServiceSettings.ReadTimeout = this.parseIntNonZero(this.state.readTimeout);
         config.ServiceSettings.WriteTimeout = this.parseIntNonZero(this.state.writeTimeout);
@@ -56,6 +59,8 @@ export default class ConfigurationSettings extends AdminSettings {
             TLSKeyFile: config.ServiceSettings.TLSKeyFile,
             useLetsEncrypt: config.ServiceSettings.UseLetsEncrypt,
             letsEncryptCertificateCacheFile: config.ServiceSettings.LetsEncryptCertificateCacheFile,
+            letsEncryptCertificateCacheDriver: config.ServiceSettings.LetsEncryptCertificateCacheDriver,
+            letsEncryptDirectoryURL: config.ServiceSettings.LetsEncryptDirectoryURL,
             forward80To443: config.ServiceSettings.Forward80To443,
             readTimeout: config.ServiceSettings.ReadTimeout,
             writeTimeout: config.ServiceSettings.WriteTimeout
@@ -172,7 +177,7 @@ export default class ConfigurationSettings extends AdminSettings {
                     helpText={
                         <FormattedMessage
                             id='admin.service.useLetsEncryptDescription'
-                            defaultMessage="Enable the automatic retreval of certificates from the Let's Encrypt. The certificate will be retrieved when a client attempts to connect from a new domain. This will work with multiple domains."
+                            defaultMessage="Enable the automatic retrieval and renewal of a certificate for the domain of the Site URL from Let's Encrypt. Port 80 must be reachable from the internet so that Let's Encrypt can verify the domain."
                         />
                     }
                     value={this.state.useLetsEncrypt}
@@ -192,10 +197,51 @@ export default class ConfigurationSettings extends AdminSettings {
                             defaultMessage="Certificates retrieved and other data about the Let's Encrypt service will be stored in this file."
                         />
                     }
-                    disabled={!this.state.useLetsEncrypt}
+                    disabled={!this.state.useLetsEncrypt || this.state.letsEncryptCertificateCacheDriver !== 'file'}
                     value={this.state.letsEncryptCertificateCacheFile}
                     onChange={this.handleChange}
                 />
+                <DropdownSetting
+                    id='letsEncryptCertificateCacheDriver'
+                    values={[
+                        {value: 'file', text: Utils.localizeMessage('admin.service.letsEncryptCertificateCacheDriverFile', 'File')},
+                        {value: 'database', text: Utils.localizeMessage('admin.service.letsEncryptCertificateCacheDriverDatabase', 'Database')}
+                    ]}
+                    label={
+                        <FormattedMessage
+                            id='admin.service.letsEncryptCertificateCacheDriver'
+                            defaultMessage="Let's Encrypt Certificate Cache:"
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.letsEncryptCertificateCacheDriverDescription'
+                            defaultMessage="Where to store retrieved certificates. Use the database when running several servers so that they all share the same certificate."
+                        />
+                    }
+                    disabled={!this.state.useLetsEncrypt}
+                    value={this.state.letsEncryptCertificateCacheDriver}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='letsEncryptDirectoryURL'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.letsEncryptDirectoryURL'
+                            defaultMessage="Let's Encrypt Directory URL:"
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.letsEncryptDirectoryURLExample', 'Ex "https://acme-v02.api.letsencrypt.org/directory"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.letsEncryptDirectoryURLDescription'
+                            defaultMessage="Directory URL of the ACME certificate authority to retrieve certificates from. Use the Let's Encrypt staging directory to test the setup without hitting rate limits."
+                        />
+                    }
+                    disabled={!this.state.useLetsEncrypt}
+                    value={this.state.letsEncryptDirectoryURL}
+                    onChange={this.handleChange}
+                />
                 <BooleanSetting
                     id='forward80To443'
                     label={
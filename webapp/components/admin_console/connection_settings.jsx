@@ -22,6 +22,7 @@ export default class ConnectionSettings extends AdminSettings {
 
     getConfigFromState(config) {
         config.ServiceSettings.AllowCorsFrom = this.state.allowCorsFrom;
+        config.ServiceSettings.WebsocketAllowedOrigins = this.state.websocketAllowedOrigins;
         config.ServiceSettings.EnableInsecureOutgoingConnections = this.state.enableInsecureOutgoingConnections;
 
         return config;
@@ -30,6 +31,7 @@ export default class ConnectionSettings extends AdminSettings {
     getStateFromConfig(config) {
         return {
             allowCorsFrom: config.ServiceSettings.AllowCorsFrom,
+            websocketAllowedOrigins: config.ServiceSettings.WebsocketAllowedOrigins,
             enableInsecureOutgoingConnections: config.ServiceSettings.EnableInsecureOutgoingConnections
         };
     }
@@ -66,6 +68,24 @@ export default class ConnectionSettings extends AdminSettings {
                     value={this.state.allowCorsFrom}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='websocketAllowedOrigins'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.websocketOriginsTitle'
+                            defaultMessage='Allow WebSocket connections from:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.websocketOriginsEx', 'https://example.com https://chat.example.com')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.websocketOriginsDescription'
+                            defaultMessage='Space separated list of origins, such as "https://example.com", that browsers may open WebSocket connections from in addition to this site. Use "*" to allow any origin or leave it blank to only allow this site. Apps that don\'t send an origin are always allowed.'
+                        />
+                    }
+                    value={this.state.websocketAllowedOrigins}
+                    onChange={this.handleChange}
+                />
                 <BooleanSetting
                     id='enableInsecureOutgoingConnections'
                     label={
@@ -25,6 +25,7 @@ export default class WebhookSettings extends AdminSettings {
         config.ServiceSettings.EnablePostUsernameOverride = this.state.enablePostUsernameOverride;
         config.ServiceSettings.EnablePostIconOverride = this.state.enablePostIconOverride;
         config.ServiceSettings.EnableOAuthServiceProvider = this.state.enableOAuthServiceProvider;
+        config.ServiceSettings.EnableUserAccessTokens = this.state.enableUserAccessTokens;
 
         return config;
     }
@@ -37,7 +38,8 @@ export default class WebhookSettings extends AdminSettings {
             enableOnlyAdminIntegrations: config.ServiceSettings.EnableOnlyAdminIntegrations,
             enablePostUsernameOverride: config.ServiceSettings.EnablePostUsernameOverride,
             enablePostIconOverride: config.ServiceSettings.EnablePostIconOverride,
-            enableOAuthServiceProvider: config.ServiceSettings.EnableOAuthServiceProvider
+            enableOAuthServiceProvider: config.ServiceSettings.EnableOAuthServiceProvider,
+            enableUserAccessTokens: config.ServiceSettings.EnableUserAccessTokens
         };
     }
 
@@ -123,6 +125,23 @@ export default class WebhookSettings extends AdminSettings {
                     value={this.state.enableOAuthServiceProvider}
                     onChange={this.handleChange}
                 />
+                <BooleanSetting
+                    id='enableUserAccessTokens'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.userAccessTokensTitle'
+                            defaultMessage='Enable Personal Access Tokens: '
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.userAccessTokensDescription'
+                            defaultMessage='When true, users can create personal access tokens for integrations. A token can be limited to reading posts, writing posts, managing channels or files, and to a number of requests per minute.'
+                        />
+                    }
+                    value={this.state.enableUserAccessTokens}
+                    onChange={this.handleChange}
+                />
                 <BooleanSetting
                     id='enableOnlyAdminIntegrations'
                     label={
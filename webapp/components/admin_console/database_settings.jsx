@@ -27,6 +27,12 @@ export default class DatabaseSettings extends AdminSettings {
 
         config.SqlSettings.MaxIdleConns = this.parseIntNonZero(this.state.maxIdleConns);
         config.SqlSettings.MaxOpenConns = this.parseIntNonZero(this.state.maxOpenConns);
+        config.SqlSettings.ConnMaxLifetimeMilliseconds = this.parseInt(this.state.connMaxLifetimeMilliseconds);
+        config.SqlSettings.ReplicaMaxIdleConns = this.parseIntNonZero(this.state.replicaMaxIdleConns);
+        config.SqlSettings.ReplicaMaxOpenConns = this.parseIntNonZero(this.state.replicaMaxOpenConns);
+        config.SqlSettings.ReplicaConnMaxLifetimeMilliseconds = this.parseInt(this.state.replicaConnMaxLifetimeMilliseconds);
+        config.SqlSettings.HealthCheckMaxLatencyMilliseconds = this.parseInt(this.state.healthCheckMaxLatencyMilliseconds);
+        config.SqlSettings.HealthCheckMaxReplicationLagMilliseconds = this.parseInt(this.state.healthCheckMaxReplicationLagMilliseconds);
         config.SqlSettings.AtRestEncryptKey = this.state.atRestEncryptKey;
         config.SqlSettings.Trace = this.state.trace;
 
@@ -39,6 +45,12 @@ export default class DatabaseSettings extends AdminSettings {
             dataSource: config.SqlSettings.DataSource,
             maxIdleConns: config.SqlSettings.MaxIdleConns,
             maxOpenConns: config.SqlSettings.MaxOpenConns,
+            connMaxLifetimeMilliseconds: config.SqlSettings.ConnMaxLifetimeMilliseconds,
+            replicaMaxIdleConns: config.SqlSettings.ReplicaMaxIdleConns,
+            replicaMaxOpenConns: config.SqlSettings.ReplicaMaxOpenConns,
+            replicaConnMaxLifetimeMilliseconds: config.SqlSettings.ReplicaConnMaxLifetimeMilliseconds,
+            healthCheckMaxLatencyMilliseconds: config.SqlSettings.HealthCheckMaxLatencyMilliseconds,
+            healthCheckMaxReplicationLagMilliseconds: config.SqlSettings.HealthCheckMaxReplicationLagMilliseconds,
             atRestEncryptKey: config.SqlSettings.AtRestEncryptKey,
             trace: config.SqlSettings.Trace
         };
@@ -130,6 +142,114 @@ export default class DatabaseSettings extends AdminSettings {
                     value={this.state.maxOpenConns}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='connMaxLifetimeMilliseconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.connMaxLifetimeTitle'
+                            defaultMessage='Maximum Connection Lifetime (milliseconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.connMaxLifetimeExample', 'Ex "900000"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.connMaxLifetimeDescription'
+                            defaultMessage='Maximum amount of time that a connection to the database may be reused before it is closed. Set to 0 to reuse connections indefinitely.'
+                        />
+                    }
+                    value={this.state.connMaxLifetimeMilliseconds}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='replicaMaxIdleConns'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.replicaMaxConnectionsTitle'
+                            defaultMessage='Maximum Idle Replica Connections:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.maxConnectionsExample', 'Ex "10"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.replicaMaxConnectionsDescription'
+                            defaultMessage='Maximum number of idle connections held open to each read replica.'
+                        />
+                    }
+                    value={this.state.replicaMaxIdleConns}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='replicaMaxOpenConns'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.replicaMaxOpenTitle'
+                            defaultMessage='Maximum Open Replica Connections:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.maxOpenExample', 'Ex "10"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.replicaMaxOpenDescription'
+                            defaultMessage='Maximum number of open connections held open to each read replica.'
+                        />
+                    }
+                    value={this.state.replicaMaxOpenConns}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='replicaConnMaxLifetimeMilliseconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.replicaConnMaxLifetimeTitle'
+                            defaultMessage='Maximum Replica Connection Lifetime (milliseconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.connMaxLifetimeExample', 'Ex "900000"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.replicaConnMaxLifetimeDescription'
+                            defaultMessage='Maximum amount of time that a connection to a read replica may be reused before it is closed. Set to 0 to reuse connections indefinitely.'
+                        />
+                    }
+                    value={this.state.replicaConnMaxLifetimeMilliseconds}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='healthCheckMaxLatencyMilliseconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.healthCheckMaxLatencyTitle'
+                            defaultMessage='Maximum Health Check Latency (milliseconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.healthCheckMaxLatencyExample', 'Ex "1000"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.healthCheckMaxLatencyDescription'
+                            defaultMessage='The database is reported as unhealthy by /api/v3/admin/database_health when a query to the data source or a replica takes longer than this. Set to 0 to never report a slow database as unhealthy.'
+                        />
+                    }
+                    value={this.state.healthCheckMaxLatencyMilliseconds}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='healthCheckMaxReplicationLagMilliseconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.sql.healthCheckMaxReplicationLagTitle'
+                            defaultMessage='Maximum Health Check Replication Lag (milliseconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.sql.healthCheckMaxReplicationLagExample', 'Ex "30000"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.sql.healthCheckMaxReplicationLagDescription'
+                            defaultMessage='The database is reported as unhealthy by /api/v3/admin/database_health when a replica is further behind the data source than this. Set to 0 to never report a lagging replica as unhealthy.'
+                        />
+                    }
+                    value={this.state.healthCheckMaxReplicationLagMilliseconds}
+                    onChange={this.handleChange}
+                />
                 <GeneratedSetting
                     id='atRestEncryptKey'
                     label={
@@ -27,12 +27,18 @@ export default class EmailSettings extends AdminSettings {
         config.EmailSettings.FeedbackName = this.state.feedbackName;
         config.EmailSettings.FeedbackEmail = this.state.feedbackEmail;
         config.EmailSettings.FeedbackOrganization = this.state.feedbackOrganization;
+        config.EmailSettings.TemplatesDirectory = this.state.templatesDirectory;
         config.EmailSettings.SMTPUsername = this.state.smtpUsername;
         config.EmailSettings.SMTPPassword = this.state.smtpPassword;
         config.EmailSettings.SMTPServer = this.state.smtpServer;
         config.EmailSettings.SMTPPort = this.state.smtpPort;
         config.EmailSettings.ConnectionSecurity = this.state.connectionSecurity;
         config.EmailSettings.EnableEmailBatching = this.state.enableEmailBatching;
+        config.EmailSettings.EnableInboundEmail = this.state.enableInboundEmail;
+        config.EmailSettings.InboundEmailListenAddress = this.state.inboundEmailListenAddress;
+        config.EmailSettings.InboundEmailTrustedRelays = this.state.inboundEmailTrustedRelays;
+        config.EmailSettings.InboundEmailDomain = this.state.inboundEmailDomain;
+        config.EmailSettings.InboundEmailMaxMessageSize = this.parseInt(this.state.inboundEmailMaxMessageSize) * 1024 * 1024;
         config.ServiceSettings.EnableSecurityFixAlert = this.state.enableSecurityFixAlert;
 
         return config;
@@ -44,12 +50,18 @@ export default class EmailSettings extends AdminSettings {
             feedbackName: config.EmailSettings.FeedbackName,
             feedbackEmail: config.EmailSettings.FeedbackEmail,
             feedbackOrganization: config.EmailSettings.FeedbackOrganization,
+            templatesDirectory: config.EmailSettings.TemplatesDirectory,
             smtpUsername: config.EmailSettings.SMTPUsername,
             smtpPassword: config.EmailSettings.SMTPPassword,
             smtpServer: config.EmailSettings.SMTPServer,
             smtpPort: config.EmailSettings.SMTPPort,
             connectionSecurity: config.EmailSettings.ConnectionSecurity,
             enableEmailBatching: config.EmailSettings.EnableEmailBatching,
+            enableInboundEmail: config.EmailSettings.EnableInboundEmail,
+            inboundEmailListenAddress: config.EmailSettings.InboundEmailListenAddress,
+            inboundEmailTrustedRelays: config.EmailSettings.InboundEmailTrustedRelays,
+            inboundEmailDomain: config.EmailSettings.InboundEmailDomain,
+            inboundEmailMaxMessageSize: config.EmailSettings.InboundEmailMaxMessageSize / 1024 / 1024,
             enableSecurityFixAlert: config.ServiceSettings.EnableSecurityFixAlert
         };
     }
@@ -190,6 +202,25 @@ export default class EmailSettings extends AdminSettings {
                     onChange={this.handleChange}
                     disabled={!this.state.sendEmailNotifications}
                 />
+                <TextSetting
+                    id='templatesDirectory'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.templatesDirectoryTitle'
+                            defaultMessage='Email Templates Directory:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.email.templatesDirectoryExample', 'Ex: "/opt/mattermost/email_templates"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.templatesDirectoryDescription'
+                            defaultMessage='Directory containing templates that override the default email templates. Templates in subdirectories named after a language, such as "es", are only used for users with that language. If the field is left empty, the default templates are used.'
+                        />
+                    }
+                    value={this.state.templatesDirectory}
+                    onChange={this.handleChange}
+                    disabled={!this.state.sendEmailNotifications}
+                />
                 <TextSetting
                     id='smtpUsername'
                     label={
@@ -293,6 +324,99 @@ export default class EmailSettings extends AdminSettings {
                     value={this.state.enableSecurityFixAlert}
                     onChange={this.handleChange}
                 />
+                <BooleanSetting
+                    id='enableInboundEmail'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.enableInboundEmailTitle'
+                            defaultMessage='Enable Inbound Email: '
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.enableInboundEmailDescription'
+                            defaultMessage='When true, channels can be given email addresses, and email sent to them is posted in the channel as the user with the email address that it was sent from. Configure your mail server to relay email for the inbound email domain to the inbound email listen address.'
+                        />
+                    }
+                    value={this.state.enableInboundEmail}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='inboundEmailListenAddress'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailListenAddressTitle'
+                            defaultMessage='Inbound Email Listen Address:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.email.inboundEmailListenAddressExample', 'Ex: "127.0.0.1:2525"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailListenAddressDescription'
+                            defaultMessage='The address and port that the server receives email on over SMTP.'
+                        />
+                    }
+                    value={this.state.inboundEmailListenAddress}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableInboundEmail}
+                />
+                <TextSetting
+                    id='inboundEmailTrustedRelays'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailTrustedRelaysTitle'
+                            defaultMessage='Inbound Email Trusted Relays:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.email.inboundEmailTrustedRelaysExample', 'Ex: "127.0.0.1 10.0.0.0/8"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailTrustedRelaysDescription'
+                            defaultMessage='Space-separated IP addresses and CIDR ranges of the mail servers that are allowed to relay email to the server. Each relay must check SPF and DKIM and add an Authentication-Results header, since email is only posted when it passed one of them for the domain of its sender.'
+                        />
+                    }
+                    value={this.state.inboundEmailTrustedRelays}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableInboundEmail}
+                />
+                <TextSetting
+                    id='inboundEmailDomain'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailDomainTitle'
+                            defaultMessage='Inbound Email Domain:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.email.inboundEmailDomainExample', 'Ex: "chat.yourcompany.com"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailDomainDescription'
+                            defaultMessage='The domain of the email addresses that channels are given.'
+                        />
+                    }
+                    value={this.state.inboundEmailDomain}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableInboundEmail}
+                />
+                <TextSetting
+                    id='inboundEmailMaxMessageSize'
+                    label={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailMaxMessageSizeTitle'
+                            defaultMessage='Maximum Inbound Email Size:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.email.inboundEmailMaxMessageSizeExample', '10')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.email.inboundEmailMaxMessageSizeDescription'
+                            defaultMessage='Maximum size of an inbound email, including its attachments, in megabytes. Each attachment is also limited to the maximum file size.'
+                        />
+                    }
+                    value={this.state.inboundEmailMaxMessageSize}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableInboundEmail}
+                />
             </SettingsGroup>
         );
     }
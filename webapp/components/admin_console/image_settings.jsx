@@ -7,6 +7,7 @@ import * as Utils from 'utils/utils.jsx';
 
 import AdminSettings from './admin_settings.jsx';
 import {FormattedMessage} from 'react-intl';
+import BooleanSetting from './boolean_setting.jsx';
 import SettingsGroup from './settings_group.jsx';
 import TextSetting from './text_setting.jsx';
 
@@ -24,8 +25,12 @@ export default class ImageSettings extends AdminSettings {
         config.FileSettings.ThumbnailHeight = this.parseInt(this.state.thumbnailHeight);
         config.FileSettings.ProfileWidth = this.parseInt(this.state.profileWidth);
         config.FileSettings.ProfileHeight = this.parseInt(this.state.profileHeight);
+        config.FileSettings.MaxProfileImageVersions = this.parseIntNonZero(this.state.maxProfileImageVersions);
         config.FileSettings.PreviewWidth = this.parseInt(this.state.previewWidth);
         config.FileSettings.PreviewHeight = this.parseInt(this.state.previewHeight);
+        config.FileSettings.EnableAnimatedThumbnails = this.state.enableAnimatedThumbnails;
+        config.FileSettings.MaxAnimatedThumbnailFrames = this.parseIntNonZero(this.state.maxAnimatedThumbnailFrames);
+        config.FileSettings.EnableWebPPreviews = this.state.enableWebPPreviews;
 
         return config;
     }
@@ -36,8 +41,12 @@ export default class ImageSettings extends AdminSettings {
             thumbnailHeight: config.FileSettings.ThumbnailHeight,
             profileWidth: config.FileSettings.ProfileWidth,
             profileHeight: config.FileSettings.ProfileHeight,
+            maxProfileImageVersions: config.FileSettings.MaxProfileImageVersions,
             previewWidth: config.FileSettings.PreviewWidth,
-            previewHeight: config.FileSettings.PreviewHeight
+            previewHeight: config.FileSettings.PreviewHeight,
+            enableAnimatedThumbnails: config.FileSettings.EnableAnimatedThumbnails,
+            maxAnimatedThumbnailFrames: config.FileSettings.MaxAnimatedThumbnailFrames,
+            enableWebPPreviews: config.FileSettings.EnableWebPPreviews
         };
     }
 
@@ -127,6 +136,24 @@ export default class ImageSettings extends AdminSettings {
                     value={this.state.profileHeight}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='maxProfileImageVersions'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.maxProfileImageVersionsTitle'
+                            defaultMessage='Profile Picture History:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.image.maxProfileImageVersionsExample', 'Ex "5"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.maxProfileImageVersionsDescription'
+                            defaultMessage='Number of uploaded profile pictures to keep for each user, including the current one. Users can switch back to any of the pictures that are kept.'
+                        />
+                    }
+                    value={this.state.maxProfileImageVersions}
+                    onChange={this.handleChange}
+                />
                 <TextSetting
                     id='previewWidth'
                     label={
@@ -163,6 +190,59 @@ export default class ImageSettings extends AdminSettings {
                     value={this.state.previewHeight}
                     onChange={this.handleChange}
                 />
+                <BooleanSetting
+                    id='enableAnimatedThumbnails'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.enableAnimatedThumbnailsTitle'
+                            defaultMessage='Enable Animated Thumbnails:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.enableAnimatedThumbnailsDescription'
+                            defaultMessage='When true, animated GIFs get a scaled down animated thumbnail. When false, their thumbnail is a still image of the first frame. Updating this value changes how thumbnails are created in future, but does not change thumbnails created in the past.'
+                        />
+                    }
+                    value={this.state.enableAnimatedThumbnails}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='maxAnimatedThumbnailFrames'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.maxAnimatedThumbnailFramesTitle'
+                            defaultMessage='Maximum Animated Thumbnail Frames:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.image.maxAnimatedThumbnailFramesExample', 'Ex "100"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.maxAnimatedThumbnailFramesDescription'
+                            defaultMessage='Maximum number of frames in an animated GIF for it to get an animated thumbnail. GIFs with more frames, or that are too large to decode at once, get a still thumbnail of their first frame instead.'
+                        />
+                    }
+                    value={this.state.maxAnimatedThumbnailFrames}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enableAnimatedThumbnails}
+                />
+                <BooleanSetting
+                    id='enableWebPPreviews'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.enableWebPPreviewsTitle'
+                            defaultMessage='Enable WebP Previews:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.enableWebPPreviewsDescription'
+                            defaultMessage='When true, JPEG and PNG images also get a WebP preview image, which is smaller and is sent to clients that support it. Only takes effect if the server supports encoding WebP images. Updating this value changes how preview images are created in future, but does not change images created in the past.'
+                        />
+                    }
+                    value={this.state.enableWebPPreviews}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+import React from 'react';
+import {FormattedMessage, FormattedDate, FormattedTime} from 'react-intl';
+
+import LoadingScreen from '../loading_screen.jsx';
+
+import {getJobSchedules, updateJobSchedule} from 'actions/admin_actions.jsx';
+
+export default class JobScheduleSettings extends React.Component {
+    constructor(props) {
+        super(props);
+
+        this.load = this.load.bind(this);
+        this.handleChange = this.handleChange.bind(this);
+        this.handleSave = this.handleSave.bind(this);
+
+        this.state = {
+            schedules: null,
+            saving: null,
+            serverError: null
+        };
+    }
+
+    componentWillMount() {
+        this.load();
+    }
+
+    load() {
+        getJobSchedules(
+            (data) => {
+                this.setState({
+                    schedules: data
+                });
+            },
+            null
+        );
+    }
+
+    handleChange(index, field, value) {
+        const schedules = this.state.schedules.slice();
+        schedules[index] = Object.assign({}, schedules[index], {[field]: value});
+
+        this.setState({
+            schedules
+        });
+    }
+
+    handleSave(index, e) {
+        e.preventDefault();
+
+        const schedule = this.state.schedules[index];
+
+        this.setState({
+            saving: schedule.job_type,
+            serverError: null
+        });
+
+        updateJobSchedule(
+            schedule,
+            (data) => {
+                const schedules = this.state.schedules.slice();
+                schedules[index] = data;
+
+                this.setState({
+                    schedules,
+                    saving: null
+                });
+            },
+            (err) => {
+                this.setState({
+                    saving: null,
+                    serverError: err.message
+                });
+            }
+        );
+    }
+
+    renderJobType(jobType) {
+        switch (jobType) {
+        case 'data_retention':
+            return (
+                <FormattedMessage
+                    id='admin.jobs.dataRetention'
+                    defaultMessage='Data Retention'
+                />
+            );
+        case 'analytics_aggregation':
+            return (
+                <FormattedMessage
+                    id='admin.jobs.analyticsAggregation'
+                    defaultMessage='Analytics Aggregation'
+                />
+            );
+        default:
+            return jobType;
+        }
+    }
+
+    renderNextRun(nextRunAt) {
+        if (!nextRunAt) {
+            return (
+                <FormattedMessage
+                    id='admin.jobs.nextRunNever'
+                    defaultMessage='Not scheduled'
+                />
+            );
+        }
+
+        const date = new Date(nextRunAt);
+
+        return (
+            <span>
+                <FormattedDate
+                    value={date}
+                    day='numeric'
+                    month='short'
+                    year='numeric'
+                />
+                {' '}
+                <FormattedTime value={date}/>
+            </span>
+        );
+    }
+
+    renderRow(schedule, index) {
+        return (
+            <tr key={schedule.job_type}>
+                <td>{this.renderJobType(schedule.job_type)}</td>
+                <td>
+                    <input
+                        type='text'
+                        className='form-control'
+                        value={schedule.schedule}
+                        onChange={(e) => this.handleChange(index, 'schedule', e.target.value)}
+                    />
+                </td>
+                <td>
+                    <input
+                        type='text'
+                        className='form-control'
+                        placeholder='America/Toronto'
+                        value={schedule.timezone}
+                        onChange={(e) => this.handleChange(index, 'timezone', e.target.value)}
+                    />
+                </td>
+                <td style={{whiteSpace: 'nowrap'}}>{this.renderNextRun(schedule.next_run_at)}</td>
+                <td>
+                    <button
+                        className='btn btn-default'
+                        disabled={this.state.saving === schedule.job_type}
+                        onClick={(e) => this.handleSave(index, e)}
+                    >
+                        <FormattedMessage
+                            id='admin.jobs.save'
+                            defaultMessage='Save'
+                        />
+                    </button>
+                </td>
+            </tr>
+        );
+    }
+
+    render() {
+        if (this.state.schedules == null) {
+            return <LoadingScreen/>;
+        }
+
+        let serverError = null;
+        if (this.state.serverError) {
+            serverError = (
+                <div className='alert alert-warning'>
+                    <i className='fa fa-warning'/>
+                    {' ' + this.state.serverError}
+                </div>
+            );
+        }
+
+        return (
+            <div className='wrapper--fixed'>
+                <h3>
+                    <FormattedMessage
+                        id='admin.jobs.title'
+                        defaultMessage='Job Scheduling'
+                    />
+                </h3>
+                <div className='help-text'>
+                    <FormattedMessage
+                        id='admin.jobs.description'
+                        defaultMessage='Background jobs run on cron schedules such as "0 2 * * *" for 2am every day, in the given IANA time zone such as "America/Toronto" or in the server time zone if it is left blank. Save an empty schedule to go back to the default one.'
+                    />
+                </div>
+                {serverError}
+                <table className='table'>
+                    <thead>
+                        <tr>
+                            <th>
+                                <FormattedMessage
+                                    id='admin.jobs.jobType'
+                                    defaultMessage='Job'
+                                />
+                            </th>
+                            <th>
+                                <FormattedMessage
+                                    id='admin.jobs.schedule'
+                                    defaultMessage='Schedule'
+                                />
+                            </th>
+                            <th>
+                                <FormattedMessage
+                                    id='admin.jobs.timezone'
+                                    defaultMessage='Time Zone'
+                                />
+                            </th>
+                            <th>
+                                <FormattedMessage
+                                    id='admin.jobs.nextRun'
+                                    defaultMessage='Next Run'
+                                />
+                            </th>
+                            <th/>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {this.state.schedules.map((schedule, index) => this.renderRow(schedule, index))}
+                    </tbody>
+                </table>
+            </div>
+        );
+    }
+}
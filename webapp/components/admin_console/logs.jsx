@@ -1,86 +1,237 @@
 // Copyright (c) 2015 Mattermost, Inc. All Rights Reserved.
 // See License.txt for license information.
 
-import AdminStore from 'stores/admin_store.jsx';
 import LoadingScreen from '../loading_screen.jsx';
-import * as AsyncClient from 'utils/async_client.jsx';
 
-import {FormattedMessage} from 'react-intl';
+import {queryLogs, getClusterStatus} from 'actions/admin_actions.jsx';
+import {localizeMessage} from 'utils/utils.jsx';
+
+import {FormattedMessage, FormattedDate, FormattedTime} from 'react-intl';
 
 import React from 'react';
 
+const LOGS_PER_PAGE = 200;
+
 export default class Logs extends React.Component {
     constructor(props) {
         super(props);
 
-        this.onLogListenerChange = this.onLogListenerChange.bind(this);
+        this.load = this.load.bind(this);
         this.reload = this.reload.bind(this);
+        this.handleNodeChange = this.handleNodeChange.bind(this);
+        this.handleLevelChange = this.handleLevelChange.bind(this);
+        this.nextPage = this.nextPage.bind(this);
+        this.previousPage = this.previousPage.bind(this);
 
         this.state = {
-            logs: AdminStore.getLogs()
+            nodes: [],
+            nodeId: '',
+            level: '',
+            page: 0,
+            entries: null,
+            hasMore: false,
+            serverError: null
         };
     }
 
     componentDidMount() {
-        AdminStore.addLogChangeListener(this.onLogListenerChange);
-        AsyncClient.getLogs();
+        getClusterStatus(
+            (data) => {
+                this.setState({
+                    nodes: data
+                });
+            },
+            null
+        );
+
+        this.load(this.state);
         this.refs.logPanel.focus();
     }
 
-    componentDidUpdate() {
-        // Scroll Down to get the latest logs
-        var node = this.refs.logPanel;
-        node.scrollTop = node.scrollHeight;
-        node.focus();
-    }
+    load(options) {
+        this.setState({
+            entries: null,
+            serverError: null
+        });
 
-    componentWillUnmount() {
-        AdminStore.removeLogChangeListener(this.onLogListenerChange);
+        queryLogs(
+            {
+                node_id: options.nodeId,
+                level: options.level,
+                offset: options.page * LOGS_PER_PAGE,
+                limit: LOGS_PER_PAGE
+            },
+            (data) => {
+                this.setState({
+                    entries: data.entries,
+                    hasMore: data.has_more
+                });
+            },
+            (err) => {
+                this.setState({
+                    entries: [],
+                    hasMore: false,
+                    serverError: err.message
+                });
+            }
+        );
     }
 
-    onLogListenerChange() {
-        this.setState({
-            logs: AdminStore.getLogs()
-        });
+    changeQuery(changes) {
+        const options = Object.assign({}, this.state, changes);
+
+        this.setState(changes);
+        this.load(options);
     }
 
     reload() {
-        AdminStore.saveLogs(null);
-        this.setState({
-            logs: null
-        });
+        this.changeQuery({page: 0});
+    }
 
-        AsyncClient.getLogs();
+    handleNodeChange(e) {
+        this.changeQuery({nodeId: e.target.value, page: 0});
     }
 
-    render() {
-        var content = null;
+    handleLevelChange(e) {
+        this.changeQuery({level: e.target.value, page: 0});
+    }
+
+    nextPage(e) {
+        e.preventDefault();
+        this.changeQuery({page: this.state.page + 1});
+    }
+
+    previousPage(e) {
+        e.preventDefault();
+        this.changeQuery({page: this.state.page - 1});
+    }
+
+    renderNodeSelect() {
+        if (this.state.nodes.length === 0) {
+            return null;
+        }
+
+        return (
+            <select
+                className='form-control'
+                value={this.state.nodeId}
+                onChange={this.handleNodeChange}
+            >
+                <option value=''>
+                    {localizeMessage('admin.logs.thisServer', 'This server')}
+                </option>
+                {this.state.nodes.map((node) => {
+                    return (
+                        <option
+                            key={node.id}
+                            value={node.id}
+                        >
+                            {node.hostname || node.id}
+                        </option>
+                    );
+                })}
+            </select>
+        );
+    }
+
+    renderEntry(entry, index) {
+        const style = {
+            whiteSpace: 'pre',
+            fontFamily: 'monospace',
+            display: 'block'
+        };
+
+        if (entry.level === 'ERROR' || entry.level === 'CRITICAL') {
+            style.color = 'red';
+        }
+
+        let time = null;
+        if (entry.timestamp) {
+            const date = new Date(entry.timestamp);
+            time = (
+                <span>
+                    {'['}
+                    <FormattedDate
+                        value={date}
+                        day='2-digit'
+                        month='2-digit'
+                        year='numeric'
+                    />
+                    {' '}
+                    <FormattedTime
+                        value={date}
+                        hour='2-digit'
+                        minute='2-digit'
+                        second='2-digit'
+                    />
+                    {'] '}
+                </span>
+            );
+        }
 
-        if (this.state.logs === null) {
+        let level = null;
+        if (entry.level) {
+            level = '[' + entry.level + '] ';
+        }
+
+        return (
+            <span
+                key={'log_' + index}
+                style={style}
+            >
+                {time}
+                {level}
+                {entry.message}
+            </span>
+        );
+    }
+
+    render() {
+        let content = null;
+        if (this.state.entries === null) {
             content = <LoadingScreen/>;
         } else {
-            content = [];
-
-            for (var i = 0; i < this.state.logs.length; i++) {
-                var style = {
-                    whiteSpace: 'nowrap',
-                    fontFamily: 'monospace'
-                };
-
-                if (this.state.logs[i].indexOf('[EROR]') > 0) {
-                    style.color = 'red';
-                }
-
-                content.push(<br key={'br_' + i}/>);
-                content.push(
-                    <span
-                        key={'log_' + i}
-                        style={style}
-                    >
-                        {this.state.logs[i]}
-                    </span>
-                );
-            }
+            content = this.state.entries.map((entry, index) => this.renderEntry(entry, index));
+        }
+
+        let serverError = null;
+        if (this.state.serverError) {
+            serverError = (
+                <div className='alert alert-warning'>
+                    <i className='fa fa-warning'/>
+                    {' ' + this.state.serverError}
+                </div>
+            );
+        }
+
+        let newer = null;
+        if (this.state.page > 0) {
+            newer = (
+                <button
+                    className='btn btn-default'
+                    onClick={this.previousPage}
+                >
+                    <FormattedMessage
+                        id='admin.logs.newer'
+                        defaultMessage='Newer'
+                    />
+                </button>
+            );
+        }
+
+        let older = null;
+        if (this.state.hasMore) {
+            older = (
+                <button
+                    className='btn btn-default'
+                    onClick={this.nextPage}
+                >
+                    <FormattedMessage
+                        id='admin.logs.older'
+                        defaultMessage='Older'
+                    />
+                </button>
+            );
         }
 
         return (
@@ -91,16 +242,37 @@ export default class Logs extends React.Component {
                         defaultMessage='Server Logs'
                     />
                 </h3>
-                <button
-                    type='submit'
-                    className='btn btn-primary'
-                    onClick={this.reload}
-                >
-                    <FormattedMessage
-                        id='admin.logs.reload'
-                        defaultMessage='Reload'
-                    />
-                </button>
+                <div className='form-inline'>
+                    <button
+                        type='submit'
+                        className='btn btn-primary'
+                        onClick={this.reload}
+                    >
+                        <FormattedMessage
+                            id='admin.logs.reload'
+                            defaultMessage='Reload'
+                        />
+                    </button>
+                    {' '}
+                    {this.renderNodeSelect()}
+                    {' '}
+                    <select
+                        className='form-control'
+                        value={this.state.level}
+                        onChange={this.handleLevelChange}
+                    >
+                        <option value=''>{'DEBUG'}</option>
+                        <option value='INFO'>{'INFO'}</option>
+                        <option value='WARN'>{'WARN'}</option>
+                        <option value='ERROR'>{'ERROR'}</option>
+                        <option value='CRITICAL'>{'CRITICAL'}</option>
+                    </select>
+                    {' '}
+                    {newer}
+                    {' '}
+                    {older}
+                </div>
+                {serverError}
                 <div
                     tabIndex='-1'
                     ref='logPanel'
@@ -31,6 +31,9 @@ export default class PasswordSettings extends AdminSettings {
             passwordUppercase: props.config.PasswordSettings.Uppercase,
             passwordSymbol: props.config.PasswordSettings.Symbol,
             maximumLoginAttempts: props.config.ServiceSettings.MaximumLoginAttempts,
+            maximumLoginAttemptsPerIp: props.config.ServiceSettings.MaximumLoginAttemptsPerIp,
+            loginLockoutDurationInSeconds: props.config.ServiceSettings.LoginLockoutDurationInSeconds,
+            maximumLoginLockoutDurationInSeconds: props.config.ServiceSettings.MaximumLoginLockoutDurationInSeconds,
             passwordResetSalt: props.config.EmailSettings.PasswordResetSalt
         });
 
@@ -72,6 +75,9 @@ export default class PasswordSettings extends AdminSettings {
         }
 
         config.ServiceSettings.MaximumLoginAttempts = this.parseIntNonZero(this.state.maximumLoginAttempts);
+        config.ServiceSettings.MaximumLoginAttemptsPerIp = this.parseIntNonZero(this.state.maximumLoginAttemptsPerIp);
+        config.ServiceSettings.LoginLockoutDurationInSeconds = this.parseIntNonZero(this.state.loginLockoutDurationInSeconds);
+        config.ServiceSettings.MaximumLoginLockoutDurationInSeconds = this.parseIntNonZero(this.state.maximumLoginLockoutDurationInSeconds);
         config.EmailSettings.PasswordResetSalt = this.state.passwordResetSalt;
 
         return config;
@@ -85,6 +91,9 @@ export default class PasswordSettings extends AdminSettings {
             passwordUppercase: config.PasswordSettings.Uppercase,
             passwordSymbol: config.PasswordSettings.Symbol,
             maximumLoginAttempts: config.ServiceSettings.MaximumLoginAttempts,
+            maximumLoginAttemptsPerIp: config.ServiceSettings.MaximumLoginAttemptsPerIp,
+            loginLockoutDurationInSeconds: config.ServiceSettings.LoginLockoutDurationInSeconds,
+            maximumLoginLockoutDurationInSeconds: config.ServiceSettings.MaximumLoginLockoutDurationInSeconds,
             passwordResetSalt: config.EmailSettings.PasswordResetSalt
         };
     }
@@ -297,12 +306,66 @@ export default class PasswordSettings extends AdminSettings {
                     helpText={
                         <FormattedMessage
                             id='admin.service.attemptDescription'
-                            defaultMessage='Login attempts allowed before user is locked out and required to reset password via email.'
+                            defaultMessage='Number of failed login attempts allowed before a user is temporarily locked out.'
                         />
                     }
                     value={this.state.maximumLoginAttempts}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='maximumLoginAttemptsPerIp'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.attemptPerIpTitle'
+                            defaultMessage='Maximum Login Attempts Per IP Address:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.attemptPerIpExample', 'Ex "200"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.attemptPerIpDescription'
+                            defaultMessage='Number of failed login attempts allowed from a single IP address, for any account, before logins from that address are temporarily blocked.'
+                        />
+                    }
+                    value={this.state.maximumLoginAttemptsPerIp}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='loginLockoutDurationInSeconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.lockoutDurationTitle'
+                            defaultMessage='Lockout Duration (seconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.lockoutDurationExample', 'Ex "60"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.lockoutDurationDescription'
+                            defaultMessage='Length of the first lockout. Each further lockout of the same account or IP address lasts twice as long as the previous one.'
+                        />
+                    }
+                    value={this.state.loginLockoutDurationInSeconds}
+                    onChange={this.handleChange}
+                />
+                <TextSetting
+                    id='maximumLoginLockoutDurationInSeconds'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.maxLockoutDurationTitle'
+                            defaultMessage='Maximum Lockout Duration (seconds):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.maxLockoutDurationExample', 'Ex "3600"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.maxLockoutDurationDescription'
+                            defaultMessage='Longest that an account or IP address can be locked out for. Lockouts stop growing once they reach this length, and start over once there have been no failed logins for this long.'
+                        />
+                    }
+                    value={this.state.maximumLoginLockoutDurationInSeconds}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
@@ -211,6 +211,7 @@ export default class PushSettings extends AdminSettings {
                 <DropdownSetting
                     id='pushNotificationContents'
                     values={[
+                        {value: 'id_loaded', text: Utils.localizeMessage('admin.email.idLoadedPushNotification', 'Send generic description without user or channel names')},
                         {value: 'generic', text: Utils.localizeMessage('admin.email.genericPushNotification', 'Send generic description with user and channel names')},
                         {value: 'full', text: Utils.localizeMessage('admin.email.fullPushNotification', 'Send full message snippet')}
                     ]}
@@ -226,7 +227,8 @@ export default class PushSettings extends AdminSettings {
                     helpText={
                         <FormattedHTMLMessage
                             id='admin.email.pushContentDesc'
-                            defaultMessage='Selecting "Send generic description with user and channel names" provides push notifications with generic messages, including names of users and channels but no specific details from the message text.<br /><br />
+                            defaultMessage='Selecting "Send generic description without user or channel names" only sends the ids of the message and its channel, so that the mobile app has to load the message from the server before showing it. Use this option if no message contents may leave your servers.<br /><br />
+                            Selecting "Send generic description with user and channel names" provides push notifications with generic messages, including names of users and channels but no specific details from the message text.<br /><br />
                             Selecting "Send full message snippet" sends excerpts from messages triggering notifications with specifics and may include confidential information sent in messages. If your Push Notification Service is outside your firewall, it is HIGHLY RECOMMENDED this option only be used with an "https" protocol to encrypt the connection.'
                         />
                     }
@@ -24,6 +24,7 @@ export default class SessionSettings extends AdminSettings {
         config.ServiceSettings.SessionLengthMobileInDays = this.parseIntNonZero(this.state.sessionLengthMobileInDays);
         config.ServiceSettings.SessionLengthSSOInDays = this.parseIntNonZero(this.state.sessionLengthSSOInDays);
         config.ServiceSettings.SessionCacheInMinutes = this.parseIntNonZero(this.state.sessionCacheInMinutes);
+        config.ServiceSettings.SessionIdleTimeoutInMinutes = this.parseInt(this.state.sessionIdleTimeoutInMinutes);
 
         return config;
     }
@@ -33,7 +34,8 @@ export default class SessionSettings extends AdminSettings {
             sessionLengthWebInDays: config.ServiceSettings.SessionLengthWebInDays,
             sessionLengthMobileInDays: config.ServiceSettings.SessionLengthMobileInDays,
             sessionLengthSSOInDays: config.ServiceSettings.SessionLengthSSOInDays,
-            sessionCacheInMinutes: config.ServiceSettings.SessionCacheInMinutes
+            sessionCacheInMinutes: config.ServiceSettings.SessionCacheInMinutes,
+            sessionIdleTimeoutInMinutes: config.ServiceSettings.SessionIdleTimeoutInMinutes
         };
     }
 
@@ -123,6 +125,24 @@ export default class SessionSettings extends AdminSettings {
                     value={this.state.sessionCacheInMinutes}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='sessionIdleTimeoutInMinutes'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.sessionIdleTimeout'
+                            defaultMessage='Session Idle Timeout (minutes):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.service.sessionIdleTimeoutEx', 'Ex "60"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.sessionIdleTimeoutDesc'
+                            defaultMessage='The number of minutes after which AD/LDAP, email and SSO users who have not used Mattermost are logged out. Using Mattermost extends their session until the session length above is reached. Set to 0 to disable. Does not apply to the mobile apps or to OAuth 2.0 apps.'
+                        />
+                    }
+                    value={this.state.sessionIdleTimeoutInMinutes}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
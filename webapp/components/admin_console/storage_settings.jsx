@@ -15,6 +15,10 @@ import BooleanSetting from './boolean_setting.jsx';
 const DRIVER_LOCAL = 'local';
 const DRIVER_S3 = 'amazons3';
 
+const SSE_NONE = '';
+const SSE_AES256 = 'AES256';
+const SSE_KMS = 'aws:kms';
+
 export default class StorageSettings extends AdminSettings {
     constructor(props) {
         super(props);
@@ -33,6 +37,10 @@ export default class StorageSettings extends AdminSettings {
         config.FileSettings.AmazonS3Bucket = this.state.amazonS3Bucket;
         config.FileSettings.AmazonS3Endpoint = this.state.amazonS3Endpoint;
         config.FileSettings.AmazonS3SSL = this.state.amazonS3SSL;
+        config.FileSettings.AmazonS3SSE = this.state.amazonS3SSE;
+        config.FileSettings.AmazonS3KMSKeyId = this.state.amazonS3KMSKeyId;
+        config.FileSettings.SendContentDigest = this.state.sendContentDigest;
+        config.FileSettings.VerifyChecksums = this.state.verifyChecksums;
 
         return config;
     }
@@ -46,7 +54,11 @@ export default class StorageSettings extends AdminSettings {
             amazonS3SecretAccessKey: config.FileSettings.AmazonS3SecretAccessKey,
             amazonS3Bucket: config.FileSettings.AmazonS3Bucket,
             amazonS3Endpoint: config.FileSettings.AmazonS3Endpoint,
-            amazonS3SSL: config.FileSettings.AmazonS3SSL
+            amazonS3SSL: config.FileSettings.AmazonS3SSL,
+            amazonS3SSE: config.FileSettings.AmazonS3SSE,
+            amazonS3KMSKeyId: config.FileSettings.AmazonS3KMSKeyId,
+            sendContentDigest: config.FileSettings.SendContentDigest,
+            verifyChecksums: config.FileSettings.VerifyChecksums
         };
     }
 
@@ -201,6 +213,48 @@ export default class StorageSettings extends AdminSettings {
                     onChange={this.handleChange}
                     disabled={this.state.driverName !== DRIVER_S3}
                 />
+                <DropdownSetting
+                    id='amazonS3SSE'
+                    values={[
+                        {value: SSE_NONE, text: Utils.localizeMessage('admin.image.amazonS3SSENone', 'None')},
+                        {value: SSE_AES256, text: Utils.localizeMessage('admin.image.amazonS3SSEAES256', 'Amazon S3-Managed Keys (SSE-S3)')},
+                        {value: SSE_KMS, text: Utils.localizeMessage('admin.image.amazonS3SSEKMS', 'AWS KMS-Managed Keys (SSE-KMS)')}
+                    ]}
+                    label={
+                        <FormattedMessage
+                            id='admin.image.amazonS3SSETitle'
+                            defaultMessage='Amazon S3 Server-Side Encryption:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.amazonS3SSEDescription'
+                            defaultMessage='Ask Amazon S3 to encrypt files when they are stored, using either keys managed by Amazon S3 or a key from AWS Key Management Service.'
+                        />
+                    }
+                    value={this.state.amazonS3SSE}
+                    onChange={this.handleChange}
+                    disabled={this.state.driverName !== DRIVER_S3}
+                />
+                <TextSetting
+                    id='amazonS3KMSKeyId'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.amazonS3KMSKeyIdTitle'
+                            defaultMessage='AWS KMS Key ID:'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.image.amazonS3KMSKeyIdExample', 'E.g.: "arn:aws:kms:us-east-1:123456789012:key/..."')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.amazonS3KMSKeyIdDescription'
+                            defaultMessage='ID or ARN of the AWS KMS key used to encrypt files. Leave blank to use the default key for Amazon S3 in your account.'
+                        />
+                    }
+                    value={this.state.amazonS3KMSKeyId}
+                    onChange={this.handleChange}
+                    disabled={this.state.driverName !== DRIVER_S3 || this.state.amazonS3SSE !== SSE_KMS}
+                />
                 <TextSetting
                     id='maxFileSize'
                     label={
@@ -219,6 +273,40 @@ export default class StorageSettings extends AdminSettings {
                     value={this.state.maxFileSize}
                     onChange={this.handleChange}
                 />
+                <BooleanSetting
+                    id='verifyChecksums'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.verifyChecksumsTitle'
+                            defaultMessage='Verify Files on Download:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.verifyChecksumsDescription'
+                            defaultMessage='When true, files are checked against the checksum recorded when they were uploaded before they are downloaded. Files that do not match are not sent and are reported in the audit log since the file storage may be corrupted.'
+                        />
+                    }
+                    value={this.state.verifyChecksums}
+                    onChange={this.handleChange}
+                />
+                <BooleanSetting
+                    id='sendContentDigest'
+                    label={
+                        <FormattedMessage
+                            id='admin.image.sendContentDigestTitle'
+                            defaultMessage='Send Content-Digest Header:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.image.sendContentDigestDescription'
+                            defaultMessage='When true, downloaded files include a Content-Digest header with their checksum so that clients can check that they were received intact.'
+                        />
+                    }
+                    value={this.state.sendContentDigest}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
@@ -15,6 +15,7 @@ import TextSetting from './text_setting.jsx';
 
 const RESTRICT_DIRECT_MESSAGE_ANY = 'any';
 const RESTRICT_DIRECT_MESSAGE_TEAM = 'team';
+const DEFAULT_INVITATION_EXPIRY_IN_HOURS = 48;
 
 export default class UsersAndTeamsSettings extends AdminSettings {
     constructor(props) {
@@ -33,6 +34,8 @@ export default class UsersAndTeamsSettings extends AdminSettings {
         config.TeamSettings.RestrictDirectMessage = this.state.restrictDirectMessage;
         config.TeamSettings.MaxChannelsPerTeam = this.parseIntNonZero(this.state.maxChannelsPerTeam, Constants.DEFAULT_MAX_CHANNELS_PER_TEAM);
         config.TeamSettings.MaxNotificationsPerChannel = this.parseIntNonZero(this.state.maxNotificationsPerChannel, Constants.DEFAULT_MAX_NOTIFICATIONS_PER_CHANNEL);
+        config.TeamSettings.InvitationExpiryInHours = this.parseIntNonZero(this.state.invitationExpiryInHours, DEFAULT_INVITATION_EXPIRY_IN_HOURS);
+        config.TeamSettings.EnablePermanentInviteLinks = this.state.enablePermanentInviteLinks;
 
         return config;
     }
@@ -45,7 +48,9 @@ export default class UsersAndTeamsSettings extends AdminSettings {
             restrictCreationToDomains: config.TeamSettings.RestrictCreationToDomains,
             restrictDirectMessage: config.TeamSettings.RestrictDirectMessage,
             maxChannelsPerTeam: config.TeamSettings.MaxChannelsPerTeam,
-            maxNotificationsPerChannel: config.TeamSettings.MaxNotificationsPerChannel
+            maxNotificationsPerChannel: config.TeamSettings.MaxNotificationsPerChannel,
+            invitationExpiryInHours: config.TeamSettings.InvitationExpiryInHours,
+            enablePermanentInviteLinks: config.TeamSettings.EnablePermanentInviteLinks
         };
     }
 
@@ -190,6 +195,41 @@ export default class UsersAndTeamsSettings extends AdminSettings {
                     value={this.state.restrictDirectMessage}
                     onChange={this.handleChange}
                 />
+                <TextSetting
+                    id='invitationExpiryInHours'
+                    label={
+                        <FormattedMessage
+                            id='admin.team.invitationExpiryTitle'
+                            defaultMessage='Invitation Expiry (hours):'
+                        />
+                    }
+                    placeholder={Utils.localizeMessage('admin.team.invitationExpiryExample', 'Ex "48"')}
+                    helpText={
+                        <FormattedMessage
+                            id='admin.team.invitationExpiryDescription'
+                            defaultMessage='Number of hours that an invitation can be used for. Each invitation can only be used once.'
+                        />
+                    }
+                    value={this.state.invitationExpiryInHours}
+                    onChange={this.handleChange}
+                />
+                <BooleanSetting
+                    id='enablePermanentInviteLinks'
+                    label={
+                        <FormattedMessage
+                            id='admin.team.permanentInviteLinksTitle'
+                            defaultMessage='Enable Team Invite Links: '
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.team.permanentInviteLinksDescription'
+                            defaultMessage='When true, the invite link of each team can be used by anyone to join the team, and never expires. When false, users can only join teams using invitations.'
+                        />
+                    }
+                    value={this.state.enablePermanentInviteLinks}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
@@ -5,6 +5,7 @@ import Suggestion from './suggestion.jsx';
 import Provider from './provider.jsx';
 
 import ChannelStore from 'stores/channel_store.jsx';
+import EmojiStore from 'stores/emoji_store.jsx';
 import UserStore from 'stores/user_store.jsx';
 import SuggestionStore from 'stores/suggestion_store.jsx';
 
@@ -20,6 +21,34 @@ import {FormattedMessage} from 'react-intl';
 import XRegExp from 'xregexp';
 
 class AtMentionSuggestion extends Suggestion {
+    renderCustomStatus(user) {
+        if (!user.custom_status_emoji && !user.custom_status_text) {
+            return null;
+        }
+
+        if (user.custom_status_expiry && user.custom_status_expiry <= Date.now()) {
+            return null;
+        }
+
+        let emoji;
+        const emojiData = user.custom_status_emoji && EmojiStore.get(user.custom_status_emoji);
+        if (emojiData) {
+            emoji = (
+                <img
+                    className='mention__custom-status-emoji'
+                    src={EmojiStore.getEmojiImageUrl(emojiData)}
+                />
+            );
+        }
+
+        return (
+            <span className='mention__custom-status'>
+                {emoji}
+                {user.custom_status_text}
+            </span>
+        );
+    }
+
     render() {
         const isSelection = this.props.isSelection;
         const user = this.props.item;
@@ -27,6 +56,7 @@ class AtMentionSuggestion extends Suggestion {
         let username;
         let description;
         let icon;
+        let customStatus;
         if (user.username === 'all') {
             username = 'all';
             description = (
@@ -74,6 +104,8 @@ class AtMentionSuggestion extends Suggestion {
                     src={Client.getUsersRoute() + '/' + user.id + '/image?time=' + user.last_picture_update}
                 />
             );
+
+            customStatus = this.renderCustomStatus(user);
         }
 
         let className = 'mentions__name';
@@ -97,6 +129,7 @@ class AtMentionSuggestion extends Suggestion {
                         {' '}
                         {description}
                     </span>
+                    {customStatus}
                 </div>
             </div>
         );
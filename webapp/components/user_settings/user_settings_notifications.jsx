@@ -25,6 +25,7 @@ function getNotificationsStateFromStores() {
     let enableEmail = 'true';
     let pushActivity = 'mention';
     let pushStatus = Constants.UserStatuses.ONLINE;
+    let pushContents = 'default';
 
     if (user.notify_props) {
         if (user.notify_props.desktop) {
@@ -48,6 +49,9 @@ function getNotificationsStateFromStores() {
         if (user.notify_props.push_status) {
             pushStatus = user.notify_props.push_status;
         }
+        if (user.notify_props.push_contents) {
+            pushContents = user.notify_props.push_contents;
+        }
     }
 
     let usernameKey = false;
@@ -84,6 +88,7 @@ function getNotificationsStateFromStores() {
         enableEmail,
         pushActivity,
         pushStatus,
+        pushContents,
         desktopSound: sound,
         usernameKey,
         customKeys,
@@ -126,6 +131,7 @@ export default class NotificationsTab extends React.Component {
         data.desktop_duration = this.state.desktopDuration;
         data.push = this.state.pushActivity;
         data.push_status = this.state.pushStatus;
+        data.push_contents = this.state.pushContents;
         data.comments = this.state.notifyCommentsLevel;
 
         const mentionKeys = [];
@@ -206,6 +212,11 @@ export default class NotificationsTab extends React.Component {
         this.refs.wrapper.focus();
     }
 
+    handlePushContentsRadio(pushContents) {
+        this.setState({pushContents});
+        this.refs.wrapper.focus();
+    }
+
     handleEmailRadio(enableEmail) {
         this.setState({enableEmail});
         this.refs.wrapper.focus();
@@ -322,6 +333,58 @@ export default class NotificationsTab extends React.Component {
                                     />
                                 </label>
                             </div>
+                            <hr/>
+                            <label>
+                                <FormattedMessage
+                                    id='user.settings.push_notification.contents'
+                                    defaultMessage='Include in push notifications'
+                                />
+                            </label>
+                            <br/>
+                            <div className='radio'>
+                                <label>
+                                    <input
+                                        type='radio'
+                                        name='pushNotificationContents'
+                                        checked={this.state.pushContents !== 'generic' && this.state.pushContents !== 'id_loaded'}
+                                        onChange={this.handlePushContentsRadio.bind(this, 'default')}
+                                    />
+                                    <FormattedMessage
+                                        id='user.settings.push_notification.contentsDefault'
+                                        defaultMessage='As much as allowed by the System Administrator'
+                                    />
+                                </label>
+                                <br/>
+                            </div>
+                            <div className='radio'>
+                                <label>
+                                    <input
+                                        type='radio'
+                                        name='pushNotificationContents'
+                                        checked={this.state.pushContents === 'generic'}
+                                        onChange={this.handlePushContentsRadio.bind(this, 'generic')}
+                                    />
+                                    <FormattedMessage
+                                        id='user.settings.push_notification.contentsGeneric'
+                                        defaultMessage='Sender and channel names only'
+                                    />
+                                </label>
+                                <br/>
+                            </div>
+                            <div className='radio'>
+                                <label>
+                                    <input
+                                        type='radio'
+                                        name='pushNotificationContents'
+                                        checked={this.state.pushContents === 'id_loaded'}
+                                        onChange={this.handlePushContentsRadio.bind(this, 'id_loaded')}
+                                    />
+                                    <FormattedMessage
+                                        id='user.settings.push_notification.contentsIdLoaded'
+                                        defaultMessage='No message details'
+                                    />
+                                </label>
+                            </div>
                         </div>
                     );
 
@@ -19,6 +19,7 @@ import LdapSettings from 'components/admin_console/ldap_settings.jsx';
 import SamlSettings from 'components/admin_console/saml_settings.jsx';
 import ClusterSettings from 'components/admin_console/cluster_settings.jsx';
 import MetricsSettings from 'components/admin_console/metrics_settings.jsx';
+import JobScheduleSettings from 'components/admin_console/job_schedule_settings.jsx';
 import SignupSettings from 'components/admin_console/signup_settings.jsx';
 import PasswordSettings from 'components/admin_console/password_settings.jsx';
 import MfaSettings from 'components/admin_console/mfa_settings.jsx';
@@ -211,6 +212,10 @@ export default (
                 path='metrics'
                 component={MetricsSettings}
             />
+            <Route
+                path='jobs'
+                component={JobScheduleSettings}
+            />
         </Route>
         <Route path='team'>
             <Redirect
@@ -68,6 +68,34 @@ class UserTypingStoreClass extends EventEmitter {
         this.emitChange();
     }
 
+    // The server sends everyone who is typing in a location whenever that changes and takes care of removing users
+    // who stop typing, so this replaces the users typing there instead of setting timeouts
+    usersTyping(channelId, userIds, postParentId) {
+        const loc = channelId + postParentId;
+
+        if (this.typingUsers[loc]) {
+            for (const name of Object.keys(this.typingUsers[loc])) {
+                clearTimeout(this.typingUsers[loc][name]);
+            }
+        }
+
+        const currentUserId = UserStore.getCurrentId();
+        const typing = {};
+        for (const userId of userIds) {
+            if (userId !== currentUserId) {
+                typing[this.nameFromId(userId)] = true;
+            }
+        }
+
+        if (Object.keys(typing).length > 0) {
+            this.typingUsers[loc] = typing;
+        } else {
+            Reflect.deleteProperty(this.typingUsers, loc);
+        }
+
+        this.emitChange();
+    }
+
     getUsersTyping(channelId, postParentId) {
         // Key representing a location where users can type
         const loc = channelId + postParentId;
@@ -102,6 +130,9 @@ UserTypingStore.dispatchToken = AppDispatcher.register((payload) => {
     case ActionTypes.USER_TYPING:
         UserTypingStore.userTyping(action.channelId, action.userId, action.postParentId);
         break;
+    case ActionTypes.USERS_TYPING:
+        UserTypingStore.usersTyping(action.channelId, action.userIds, action.postParentId);
+        break;
     }
 });
 
@@ -91,6 +91,22 @@ describe('Client.Admin', function() {
         });
     });
 
+    it('Admin.queryLogs', function(done) {
+        TestHelper.initBasic(() => {
+            TestHelper.basicClient().enableLogErrorsToConsole(false); // Disabling since this unit test causes an error
+            TestHelper.basicClient().queryLogs(
+                {limit: 10},
+                function() {
+                    done(new Error('should need system admin permissions'));
+                },
+                function(err) {
+                    assert.equal(err.id, 'api.context.permissions.app_error');
+                    done();
+                }
+            );
+        });
+    });
+
     it('Admin.getServerAudits', function(done) {
         TestHelper.initBasic(() => {
             TestHelper.basicClient().enableLogErrorsToConsole(false); // Disabling since this unit test causes an error
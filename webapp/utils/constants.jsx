@@ -152,6 +152,7 @@ export const ActionTypes = keyMirror({
     SHOW_SEARCH: null,
 
     USER_TYPING: null,
+    USERS_TYPING: null,
 
     TOGGLE_IMPORT_THEME_MODAL: null,
     TOGGLE_INVITE_MEMBER_MODAL: null,
@@ -212,9 +213,11 @@ export const SocketEvents = {
     USER_REMOVED: 'user_removed',
     USER_UPDATED: 'user_updated',
     TYPING: 'typing',
+    TYPING_USERS: 'typing_users',
     PREFERENCE_CHANGED: 'preference_changed',
     EPHEMERAL_MESSAGE: 'ephemeral_message',
     STATUS_CHANGED: 'status_change',
+    CUSTOM_STATUS_CHANGED: 'custom_status_change',
     HELLO: 'hello',
     WEBRTC: 'webrtc',
     REACTION_ADDED: 'reaction_added',
@@ -300,7 +303,7 @@ export const Constants = {
 
     SPECIAL_MENTIONS: ['all', 'channel', 'here'],
     CHARACTER_LIMIT: 4000,
-    IMAGE_TYPES: ['jpg', 'gif', 'bmp', 'png', 'jpeg'],
+    IMAGE_TYPES: ['jpg', 'gif', 'bmp', 'png', 'jpeg', 'webp'],
     AUDIO_TYPES: ['mp3', 'wav', 'wma', 'm4a', 'flac', 'aac', 'ogg'],
     VIDEO_TYPES: ['mp4', 'avi', 'webm', 'mkv', 'wmv', 'mpg', 'mov', 'flv'],
     PRESENTATION_TYPES: ['ppt', 'pptx'],